@@ -26,6 +26,24 @@ func gracefulShutdown(server *server.Server, done chan bool) {
 	// Stop Dropbox refresh service
 	server.StopDropboxRefreshService()
 
+	// Stop search indexing
+	server.StopSearchIndexService()
+
+	// Stop Dropbox listing sync
+	server.StopDropboxListingService()
+
+	// Stop document review reminders
+	server.StopDocumentReviewService()
+
+	// Stop download analytics purge
+	server.StopDownloadAnalyticsService()
+
+	// Stop expired upload cleanup
+	server.StopResumableUploadService()
+
+	// Stop category reconciliation
+	server.StopCategoryReconciliationService()
+
 	// The context is used to inform the server it has 5 seconds to finish
 	// the request it is currently handling
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
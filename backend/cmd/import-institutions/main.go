@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"backend/data"
+	"backend/internal/database"
+	"backend/internal/geo"
+	"backend/internal/models"
+	"backend/internal/repository"
+	"backend/internal/service"
+)
+
+// Imports institutions from a CSV or JSON file, or from the bundled default
+// list when no file is given:
+//
+//	go run cmd/import-institutions/main.go [-file hospitals.csv] [-format csv] [-dry-run]
+func main() {
+	file := flag.String("file", "", "CSV or JSON file to import (defaults to the bundled institution list)")
+	formatFlag := flag.String("format", "", "csv or json (defaults to the file extension)")
+	dryRun := flag.Bool("dry-run", false, "report what would change without saving")
+	flag.Parse()
+
+	source := "bundled institutions.json"
+	content := data.Institutions
+	format := models.ImportFormatJSON
+	if *file != "" {
+		var err error
+		if content, err = os.ReadFile(*file); err != nil {
+			log.Fatalf("Failed to read %s: %v", *file, err)
+		}
+		if format, err = models.DetectImportFormat(*formatFlag, *file); err != nil {
+			log.Fatalf("%v", err)
+		}
+		source = *file
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	client, db, err := database.Connect(ctx)
+	if err != nil {
+		log.Fatalf("Failed to connect to MongoDB: %v", err)
+	}
+	defer func() {
+		if err := client.Disconnect(ctx); err != nil {
+			log.Printf("disconnect: %v", err)
+		}
+	}()
+
+	fmt.Printf("Connected to %s (database: %s)\n", database.ConnectionLabel(), database.DatabaseName())
+
+	gazetteer, err := geo.LoadGazetteerFromEnv()
+	if err != nil {
+		log.Printf("Warning: failed to load gazetteer, geocoding disabled: %v", err)
+	}
+
+	encryptionService, err := service.NewEncryptionService()
+	if err != nil {
+		log.Fatalf("Failed to initialize encryption service: %v", err)
+	}
+
+	institutionService := service.NewInstitutionService(
+		repository.NewInstitutionRepository(db),
+		repository.NewUserRepository(db, encryptionService),
+		repository.NewRegistrySubmissionRepository(db, encryptionService),
+		repository.NewAuditRepository(db),
+		repository.NewInstitutionImportRepository(db),
+		nil,
+		nil,
+		nil,
+		gazetteer,
+	)
+
+	if *dryRun {
+		fmt.Printf("🔍 Dry run: importing %s (nothing will be saved)...\n\n", source)
+	} else {
+		fmt.Printf("📥 Importing %s...\n\n", source)
+	}
+
+	result, err := institutionService.ImportInstitutions(ctx, content, service.InstitutionImportOptions{
+		Source: source,
+		Format: format,
+		DryRun: *dryRun,
+	}, nil, "")
+	if err != nil {
+		log.Fatalf("Import failed: %v", err)
+	}
+
+	for _, row := range result.Rows {
+		switch row.Status {
+		case models.ImportRowCreated:
+			fmt.Printf("  + row %d: %s\n", row.Row, row.Name)
+		case models.ImportRowUpdated:
+			fmt.Printf("  ~ row %d: %s (%v)\n", row.Row, row.Name, row.Changes)
+		default:
+			fmt.Printf("  - row %d: %s skipped: %s\n", row.Row, row.Name, row.Reason)
+		}
+	}
+
+	fmt.Printf("\n✅ %d created, %d updated, %d skipped\n", result.Created, result.Updated, result.Skipped)
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"time"
+
+	"backend/internal/database"
+	"backend/internal/repository"
+	"backend/internal/service"
+)
+
+// Re-encrypts every stored secret, such as Dropbox tokens and the SMTP
+// password, and all encrypted personal data with the primary encryption key,
+// and rebuilds the blind indexes of personal data. Run it after adding a new
+// primary key to ENCRYPTION_KEYS, keeping the old keys until it reports no
+// failures:
+//
+//	go run cmd/reencrypt/main.go [-dry-run]
+func main() {
+	dryRun := flag.Bool("dry-run", false, "report what would be re-encrypted without saving")
+	flag.Parse()
+
+	encryptionService, err := service.NewEncryptionService()
+	if err != nil {
+		log.Fatalf("Failed to initialize encryption service: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	client, db, err := database.Connect(ctx)
+	if err != nil {
+		log.Fatalf("Failed to connect to MongoDB: %v", err)
+	}
+	defer func() {
+		if err := client.Disconnect(ctx); err != nil {
+			log.Printf("disconnect: %v", err)
+		}
+	}()
+
+	fmt.Printf("Connected to %s (database: %s)\n", database.ConnectionLabel(), database.DatabaseName())
+	fmt.Printf("Primary key: %s\n", encryptionService.PrimaryKeyID())
+	if *dryRun {
+		fmt.Println("Dry run: nothing will be saved")
+	}
+	fmt.Println()
+
+	reencryptionService := service.NewReencryptionService(repository.NewEncryptedFieldRepository(db), encryptionService)
+	results, err := reencryptionService.Run(ctx, *dryRun)
+
+	failed := 0
+	for _, result := range results {
+		fmt.Printf("%-40s checked %d, re-encrypted %d, changed meanwhile %d, failed %d\n",
+			result.Field, result.Checked, result.Reencrypted, result.Changed, result.Failed)
+		for _, message := range result.Errors {
+			fmt.Printf("  %s\n", message)
+		}
+		failed += result.Failed
+	}
+	if err != nil {
+		log.Fatalf("Re-encryption stopped: %v", err)
+	}
+
+	fmt.Println()
+	if failed > 0 {
+		log.Fatalf("%d values could not be decrypted; keep every old key until they are fixed", failed)
+	}
+	fmt.Println("Done")
+}
@@ -0,0 +1,11 @@
+// Package data bundles reference data files shipped with the backend.
+package data
+
+import _ "embed"
+
+// Institutions is the default institution list in the institution import
+// JSON format. It is loaded by the import-institutions command and can be
+// edited without touching Go code.
+//
+//go:embed institutions.json
+var Institutions []byte
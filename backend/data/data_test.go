@@ -0,0 +1,25 @@
+package data
+
+import (
+	"testing"
+
+	"backend/internal/models"
+)
+
+func TestBundledInstitutionsAreValid(t *testing.T) {
+	rows, err := models.ParseInstitutionImport(Institutions, models.ImportFormatJSON)
+	if err != nil {
+		t.Fatalf("bundled institutions do not parse: %v", err)
+	}
+
+	seen := map[string]bool{}
+	for _, row := range rows {
+		if err := row.CreateRequest().Validate(); err != nil {
+			t.Errorf("%s: %v", row.Name, err)
+		}
+		if seen[row.Name] {
+			t.Errorf("%s is listed twice", row.Name)
+		}
+		seen[row.Name] = true
+	}
+}
@@ -0,0 +1,395 @@
+// Package dropboxfake is an in-memory stand-in for the Dropbox HTTP API,
+// served over httptest so code using the Dropbox SDK can be tested without
+// network access.
+//
+// It implements the routes the backend uses for listings and downloads:
+// files/list_folder, files/list_folder/continue and files/download.
+// Cursors follow the real semantics: continuing a cursor returns only the
+// entries changed since it was issued, deletions included, and
+// ExpireCursors makes existing cursors fail with a reset error.
+package dropboxfake
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dropbox/dropbox-sdk-go-unofficial/v6/dropbox"
+	"github.com/dropbox/dropbox-sdk-go-unofficial/v6/dropbox/files"
+)
+
+type entry struct {
+	id       string
+	path     string // Display path
+	folder   bool
+	content  []byte
+	modified time.Time
+	rev      int
+}
+
+// Server is a fake Dropbox API. The zero value is not usable; call New.
+type Server struct {
+	server *httptest.Server
+
+	mu         sync.Mutex
+	entries    map[string]*entry // Keyed by lower-cased path
+	changes    []string          // Lower-cased paths, one per change
+	generation int               // Cursors from earlier generations are reset
+	nextID     int
+	calls      map[string]int
+	now        time.Time
+
+	// PageSize limits the entries returned per listing call; zero means no limit
+	PageSize int
+}
+
+// New starts a fake Dropbox API server. Close it when done.
+func New() *Server {
+	s := &Server{
+		entries: map[string]*entry{},
+		calls:   map[string]int{},
+		now:     time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	s.server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// Close shuts the server down
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+// Config returns an SDK configuration that sends every request to the fake
+func (s *Server) Config() dropbox.Config {
+	return dropbox.Config{
+		Token:    "fake-token",
+		LogLevel: dropbox.LogOff,
+		URLGenerator: func(hostType, namespace, route string) string {
+			return fmt.Sprintf("%s/2/%s/%s", s.server.URL, namespace, route)
+		},
+	}
+}
+
+// Client returns a files client connected to the fake
+func (s *Server) Client() files.Client {
+	return files.New(s.Config())
+}
+
+// Calls returns how many times a route, such as "files/list_folder", was called
+func (s *Server) Calls(route string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls[route]
+}
+
+// AddFolder creates a folder and any missing parents
+func (s *Server) AddFolder(p string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.addFolderLocked(p)
+}
+
+// AddFile creates or overwrites a file, creating any missing parent folders
+func (s *Server) AddFile(p string, content []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p = cleanPath(p)
+	s.addFolderLocked(path.Dir(p))
+	s.now = s.now.Add(time.Minute)
+
+	key := strings.ToLower(p)
+	if existing, ok := s.entries[key]; ok && !existing.folder {
+		existing.content = content
+		existing.modified = s.now
+		existing.rev++
+	} else {
+		s.entries[key] = &entry{id: s.newID(), path: p, content: content, modified: s.now, rev: 1}
+	}
+	s.changes = append(s.changes, key)
+}
+
+// Remove deletes a file or a folder with everything in it
+func (s *Server) Remove(p string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := strings.ToLower(cleanPath(p))
+	for k := range s.entries {
+		if k == key || strings.HasPrefix(k, key+"/") {
+			delete(s.entries, k)
+			s.changes = append(s.changes, k)
+		}
+	}
+}
+
+// ExpireCursors makes every cursor issued so far fail with a reset error,
+// as Dropbox does when it can no longer serve changes for a cursor
+func (s *Server) ExpireCursors() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.generation++
+}
+
+func (s *Server) addFolderLocked(p string) {
+	p = cleanPath(p)
+	if p == "" || p == "/" {
+		return
+	}
+	key := strings.ToLower(p)
+	if _, ok := s.entries[key]; ok {
+		return
+	}
+	s.addFolderLocked(path.Dir(p))
+	s.entries[key] = &entry{id: s.newID(), path: p, folder: true}
+	s.changes = append(s.changes, key)
+}
+
+func (s *Server) newID() string {
+	s.nextID++
+	return fmt.Sprintf("id:fake%d", s.nextID)
+}
+
+func cleanPath(p string) string {
+	if p == "" {
+		return ""
+	}
+	return path.Clean("/" + strings.TrimPrefix(p, "/"))
+}
+
+// cursor is the state encoded in the opaque cursors handed to clients
+type cursor struct {
+	Path      string `json:"p"`
+	Recursive bool   `json:"r"`
+	From      int    `json:"f"` // Changes before this index have been seen
+	Until     int    `json:"u"` // Changes up to this index are being paged
+	Offset    int    `json:"o"`
+	Snapshot  bool   `json:"s"`
+	Gen       int    `json:"g"`
+}
+
+func (c cursor) encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(value string) (cursor, bool) {
+	var c cursor
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || json.Unmarshal(data, &c) != nil {
+		return c, false
+	}
+	return c, true
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	route := strings.TrimPrefix(r.URL.Path, "/2/")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls[route]++
+
+	switch route {
+	case "files/list_folder":
+		var arg struct {
+			Path      string `json:"path"`
+			Recursive bool   `json:"recursive"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&arg); err != nil {
+			writeError(w, http.StatusBadRequest, "bad_request", "")
+			return
+		}
+		key := strings.ToLower(cleanPath(arg.Path))
+		if key != "" {
+			if e, ok := s.entries[key]; !ok || !e.folder {
+				writeError(w, http.StatusConflict, "path/not_found/", `{".tag":"path","path":{".tag":"not_found"}}`)
+				return
+			}
+		}
+		s.writePage(w, cursor{Path: key, Recursive: arg.Recursive, Until: len(s.changes), Snapshot: true, Gen: s.generation})
+
+	case "files/list_folder/continue":
+		var arg struct {
+			Cursor string `json:"cursor"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&arg); err != nil {
+			writeError(w, http.StatusBadRequest, "bad_request", "")
+			return
+		}
+		c, ok := decodeCursor(arg.Cursor)
+		if !ok {
+			writeError(w, http.StatusBadRequest, "bad_request", "")
+			return
+		}
+		if c.Gen != s.generation {
+			writeError(w, http.StatusConflict, "reset/", `{".tag":"reset"}`)
+			return
+		}
+		if !c.Snapshot && c.Offset == 0 {
+			c.Until = len(s.changes)
+		}
+		s.writePage(w, c)
+
+	case "files/download":
+		var arg struct {
+			Path string `json:"path"`
+		}
+		if err := json.Unmarshal([]byte(r.Header.Get("Dropbox-API-Arg")), &arg); err != nil {
+			writeError(w, http.StatusBadRequest, "bad_request", "")
+			return
+		}
+		e, ok := s.entries[strings.ToLower(cleanPath(arg.Path))]
+		if !ok || e.folder {
+			writeError(w, http.StatusConflict, "path/not_found/", `{".tag":"path","path":{".tag":"not_found"}}`)
+			return
+		}
+		result, _ := json.Marshal(e.metadata())
+		w.Header().Set("Dropbox-API-Result", string(result))
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Write(e.content)
+
+	default:
+		writeError(w, http.StatusNotFound, "unsupported route "+route, "")
+	}
+}
+
+// writePage writes one page of a listing or of the changes since a cursor
+func (s *Server) writePage(w http.ResponseWriter, c cursor) {
+	var items []map[string]interface{}
+	if c.Snapshot {
+		items = s.snapshot(c)
+	} else {
+		items = s.changesSince(c)
+	}
+
+	end := len(items)
+	if s.PageSize > 0 && c.Offset+s.PageSize < end {
+		end = c.Offset + s.PageSize
+	}
+	page := items[min(c.Offset, len(items)):end]
+
+	next := c
+	hasMore := end < len(items)
+	if hasMore {
+		next.Offset = end
+	} else {
+		next = cursor{Path: c.Path, Recursive: c.Recursive, From: c.Until, Gen: c.Gen}
+	}
+
+	if page == nil {
+		page = []map[string]interface{}{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"entries":  page,
+		"cursor":   next.encode(),
+		"has_more": hasMore,
+	})
+}
+
+// inScope reports whether a path is listed by a cursor's folder
+func inScope(c cursor, key string) bool {
+	if c.Recursive {
+		return key == c.Path || strings.HasPrefix(key, c.Path+"/")
+	}
+	parent := path.Dir(key)
+	if c.Path == "" {
+		return parent == "/"
+	}
+	return parent == c.Path
+}
+
+func (s *Server) snapshot(c cursor) []map[string]interface{} {
+	keys := []string{}
+	for key := range s.entries {
+		if inScope(c, key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	items := make([]map[string]interface{}, 0, len(keys))
+	for _, key := range keys {
+		items = append(items, s.entries[key].metadata())
+	}
+	return items
+}
+
+func (s *Server) changesSince(c cursor) []map[string]interface{} {
+	seen := map[string]bool{}
+	keys := []string{}
+	for i := c.From; i < c.Until && i < len(s.changes); i++ {
+		key := s.changes[i]
+		if !seen[key] && inScope(c, key) {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+
+	items := make([]map[string]interface{}, 0, len(keys))
+	for _, key := range keys {
+		if e, ok := s.entries[key]; ok {
+			items = append(items, e.metadata())
+		} else {
+			items = append(items, map[string]interface{}{
+				".tag":       "deleted",
+				"name":       path.Base(key),
+				"path_lower": key,
+			})
+		}
+	}
+	return items
+}
+
+func (e *entry) metadata() map[string]interface{} {
+	m := map[string]interface{}{
+		"name":         path.Base(e.path),
+		"id":           e.id,
+		"path_lower":   strings.ToLower(e.path),
+		"path_display": e.path,
+	}
+	if e.folder {
+		m[".tag"] = "folder"
+		return m
+	}
+	m[".tag"] = "file"
+	m["size"] = len(e.content)
+	m["client_modified"] = e.modified.Format(time.RFC3339)
+	m["server_modified"] = e.modified.Format(time.RFC3339)
+	m["rev"] = fmt.Sprintf("%09x", e.rev)
+	m["content_hash"] = contentHash(e.content)
+	return m
+}
+
+// contentHash computes Dropbox's content hash: the SHA-256 of the
+// concatenated SHA-256 digests of each 4 MiB block
+func contentHash(content []byte) string {
+	const blockSize = 4 << 20
+	overall := sha256.New()
+	for start := 0; start < len(content); start += blockSize {
+		end := min(start+blockSize, len(content))
+		block := sha256.Sum256(content[start:end])
+		overall.Write(block[:])
+	}
+	return hex.EncodeToString(overall.Sum(nil))
+}
+
+func writeError(w http.ResponseWriter, status int, summary, detail string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if detail == "" {
+		io.WriteString(w, summary)
+		return
+	}
+	fmt.Fprintf(w, `{"error_summary":%q,"error":%s}`, summary, detail)
+}
@@ -0,0 +1,249 @@
+// Package dropboxoauth talks to Dropbox's OAuth 2 endpoints: it builds
+// authorization URLs, exchanges authorization codes for tokens and
+// refreshes access tokens.
+//
+// Two flows are supported. The code flow authenticates the app with its
+// key and secret. The PKCE flow (RFC 7636) needs only the app key: the
+// app sends the SHA-256 of a random code verifier when it asks for
+// authorization and proves it started the flow by sending the verifier
+// with the code, so no secret has to be stored. Tokens issued through
+// PKCE are refreshed with the app key alone.
+package dropboxoauth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Dropbox's OAuth endpoints
+const (
+	AuthorizeURL = "https://www.dropbox.com/oauth2/authorize"
+	TokenURL     = "https://api.dropbox.com/oauth2/token"
+)
+
+// requestTimeout bounds a call to the token endpoint
+const requestTimeout = 20 * time.Second
+
+var (
+	ErrMissingAppKey  = errors.New("dropbox app key is required")
+	ErrMissingCode    = errors.New("authorization code is required")
+	ErrMissingToken   = errors.New("refresh token is required")
+	ErrInvalidToken   = errors.New("invalid token response: missing access token")
+	ErrInvalidPayload = errors.New("invalid token response")
+)
+
+// Error is a failure reported by the token endpoint
+type Error struct {
+	StatusCode  int
+	Code        string // OAuth error code, such as "invalid_grant"
+	Description string
+	Body        string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("status %d: %s", e.StatusCode, e.Body)
+}
+
+// InvalidGrant reports whether the code or refresh token was rejected,
+// meaning Dropbox must be authorized again
+func (e *Error) InvalidGrant() bool {
+	return e.Code == "invalid_grant"
+}
+
+// Token is the token endpoint's response
+type Token struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`    // Seconds until expiry
+	RefreshToken string `json:"refresh_token"` // Only when a code is exchanged
+	Scope        string `json:"scope"`
+	UID          string `json:"uid"`
+	AccountID    string `json:"account_id"`
+}
+
+// Client calls the token endpoint for a Dropbox app. AppSecret is left
+// empty for the PKCE flow.
+type Client struct {
+	AppKey    string
+	AppSecret string
+
+	// TokenURL overrides the token endpoint, for tests
+	TokenURL string
+
+	// HTTPClient overrides the HTTP client
+	HTTPClient *http.Client
+}
+
+// AuthorizationURL returns the URL an admin visits to authorize the app.
+// An offline token is requested so a refresh token is issued. redirectURI
+// may be empty, in which case Dropbox shows the code for the admin to copy.
+// challenge is the PKCE code challenge, or empty for the code flow.
+func (c *Client) AuthorizationURL(redirectURI, state, challenge string) (string, error) {
+	if c.AppKey == "" {
+		return "", ErrMissingAppKey
+	}
+
+	params := url.Values{}
+	params.Set("client_id", c.AppKey)
+	params.Set("response_type", "code")
+	params.Set("token_access_type", "offline")
+	if redirectURI != "" {
+		params.Set("redirect_uri", redirectURI)
+	}
+	if state != "" {
+		params.Set("state", state)
+	}
+	if challenge != "" {
+		params.Set("code_challenge", challenge)
+		params.Set("code_challenge_method", "S256")
+	}
+	return AuthorizeURL + "?" + params.Encode(), nil
+}
+
+// Exchange exchanges an authorization code for tokens. redirectURI must
+// match the one in the authorization URL. verifier is the PKCE code
+// verifier, or empty for the code flow.
+func (c *Client) Exchange(ctx context.Context, code, redirectURI, verifier string) (*Token, error) {
+	if code == "" {
+		return nil, ErrMissingCode
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	if redirectURI != "" {
+		form.Set("redirect_uri", redirectURI)
+	}
+	if verifier != "" {
+		form.Set("code_verifier", verifier)
+	}
+
+	token, err := c.post(ctx, form)
+	if err != nil {
+		return nil, err
+	}
+	if token.RefreshToken == "" {
+		return nil, fmt.Errorf("%w: missing refresh token", ErrInvalidPayload)
+	}
+	return token, nil
+}
+
+// Refresh gets a new access token with a refresh token
+func (c *Client) Refresh(ctx context.Context, refreshToken string) (*Token, error) {
+	if refreshToken == "" {
+		return nil, ErrMissingToken
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "refresh_token")
+	form.Set("refresh_token", refreshToken)
+	return c.post(ctx, form)
+}
+
+// post sends a request to the token endpoint, authenticating with the app
+// secret when there is one
+func (c *Client) post(ctx context.Context, form url.Values) (*Token, error) {
+	if c.AppKey == "" {
+		return nil, ErrMissingAppKey
+	}
+	form.Set("client_id", c.AppKey)
+	if c.AppSecret != "" {
+		form.Set("client_secret", c.AppSecret)
+	}
+
+	endpoint := c.TokenURL
+	if endpoint == "" {
+		endpoint = TokenURL
+	}
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: requestTimeout}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("User-Agent", "DoctorsWorkspace/1.0")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call token endpoint: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		oauthErr := &Error{StatusCode: resp.StatusCode, Body: string(body)}
+		var payload struct {
+			Error            string `json:"error"`
+			ErrorDescription string `json:"error_description"`
+		}
+		if json.Unmarshal(body, &payload) == nil {
+			oauthErr.Code = payload.Error
+			oauthErr.Description = payload.ErrorDescription
+		}
+		return nil, oauthErr
+	}
+
+	var token Token
+	if err := json.Unmarshal(body, &token); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPayload, err)
+	}
+	if token.AccessToken == "" {
+		return nil, ErrInvalidToken
+	}
+	return &token, nil
+}
+
+// NewVerifier returns a random PKCE code verifier of 64 characters
+func NewVerifier() (string, error) {
+	return randomString(48)
+}
+
+// Challenge returns the S256 code challenge of a verifier
+func Challenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// NewState returns a random, unguessable state parameter
+func NewState() (string, error) {
+	return randomString(32)
+}
+
+// randomString returns n random bytes encoded as unpadded base64url, which
+// only uses characters allowed in a PKCE verifier
+func randomString(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate random value: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// Hash returns the hex SHA-256 of a value, so a state or session token can
+// be looked up without being stored
+func Hash(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
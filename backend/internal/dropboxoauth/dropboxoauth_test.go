@@ -0,0 +1,247 @@
+package dropboxoauth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+// fakeTokenEndpoint is a local stand-in for Dropbox's token endpoint. It
+// issues tokens for one code, checks the PKCE verifier or the app secret,
+// and accepts one refresh token.
+type fakeTokenEndpoint struct {
+	appKey       string
+	appSecret    string // Empty when the app uses PKCE
+	code         string
+	challenge    string
+	redirectURI  string
+	refreshToken string
+
+	last url.Values
+}
+
+func (f *fakeTokenEndpoint) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/x-www-form-urlencoded" {
+		http.Error(w, `{"error": "invalid_request"}`, http.StatusBadRequest)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, `{"error": "invalid_request"}`, http.StatusBadRequest)
+		return
+	}
+	form := r.PostForm
+	f.last = form
+
+	fail := func(code string) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error": "` + code + `", "error_description": "rejected"}`))
+	}
+
+	if form.Get("client_id") != f.appKey {
+		fail("invalid_client")
+		return
+	}
+	if f.appSecret != "" && form.Get("client_secret") != f.appSecret {
+		fail("invalid_client")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	switch form.Get("grant_type") {
+	case "authorization_code":
+		if form.Get("code") != f.code || form.Get("redirect_uri") != f.redirectURI {
+			fail("invalid_grant")
+			return
+		}
+		if f.appSecret == "" && Challenge(form.Get("code_verifier")) != f.challenge {
+			fail("invalid_grant")
+			return
+		}
+		_, _ = w.Write([]byte(`{"access_token": "sl.access", "token_type": "bearer", "expires_in": 14400, "refresh_token": "` + f.refreshToken + `", "account_id": "dbid:abc"}`))
+	case "refresh_token":
+		if form.Get("refresh_token") != f.refreshToken {
+			fail("invalid_grant")
+			return
+		}
+		_, _ = w.Write([]byte(`{"access_token": "sl.refreshed", "token_type": "bearer", "expires_in": 14400}`))
+	default:
+		fail("unsupported_grant_type")
+	}
+}
+
+func TestChallenge(t *testing.T) {
+	// Example from RFC 7636, appendix B
+	got := Challenge("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk")
+	if want := "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"; got != want {
+		t.Errorf("Challenge() = %q, want %q", got, want)
+	}
+}
+
+func TestNewVerifier(t *testing.T) {
+	verifier, err := NewVerifier()
+	if err != nil {
+		t.Fatalf("NewVerifier() error = %v", err)
+	}
+	// RFC 7636 allows 43 to 128 unreserved characters
+	if len(verifier) < 43 || len(verifier) > 128 {
+		t.Errorf("verifier length = %d", len(verifier))
+	}
+	for _, r := range verifier {
+		if !(r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.' || r == '~') {
+			t.Fatalf("verifier %q has character %q", verifier, r)
+		}
+	}
+
+	other, _ := NewVerifier()
+	if other == verifier {
+		t.Error("NewVerifier() returned the same value twice")
+	}
+}
+
+func TestAuthorizationURL(t *testing.T) {
+	client := &Client{AppKey: "app-key"}
+	raw, err := client.AuthorizationURL("https://example.org/callback", "state-1", "challenge-1")
+	if err != nil {
+		t.Fatalf("AuthorizationURL() error = %v", err)
+	}
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		t.Fatalf("url.Parse() error = %v", err)
+	}
+	want := map[string]string{
+		"client_id":             "app-key",
+		"response_type":         "code",
+		"token_access_type":     "offline",
+		"redirect_uri":          "https://example.org/callback",
+		"state":                 "state-1",
+		"code_challenge":        "challenge-1",
+		"code_challenge_method": "S256",
+	}
+	for key, value := range want {
+		if got := parsed.Query().Get(key); got != value {
+			t.Errorf("%s = %q, want %q", key, got, value)
+		}
+	}
+
+	// The code flow sends no challenge
+	raw, _ = client.AuthorizationURL("", "state-1", "")
+	parsed, _ = url.Parse(raw)
+	if parsed.Query().Has("code_challenge") || parsed.Query().Has("redirect_uri") {
+		t.Errorf("code flow URL = %s", raw)
+	}
+
+	if _, err := (&Client{}).AuthorizationURL("", "", ""); err != ErrMissingAppKey {
+		t.Errorf("AuthorizationURL() without app key error = %v, want %v", err, ErrMissingAppKey)
+	}
+}
+
+func TestExchange(t *testing.T) {
+	verifier := "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+
+	tests := []struct {
+		name         string
+		serverSecret string
+		clientSecret string
+		code         string
+		redirectURI  string
+		verifier     string
+		wantCode     string // OAuth error code, empty on success
+	}{
+		{name: "pkce", code: "code-1", redirectURI: "https://example.org/callback", verifier: verifier},
+		{name: "pkce without redirect", code: "code-1", verifier: verifier},
+		{name: "pkce wrong verifier", code: "code-1", redirectURI: "https://example.org/callback", verifier: "not-the-verifier", wantCode: "invalid_grant"},
+		{name: "pkce missing verifier", code: "code-1", redirectURI: "https://example.org/callback", wantCode: "invalid_grant"},
+		{name: "wrong code", code: "code-2", redirectURI: "https://example.org/callback", verifier: verifier, wantCode: "invalid_grant"},
+		{name: "redirect mismatch", code: "code-1", redirectURI: "https://evil.example/callback", verifier: verifier, wantCode: "invalid_grant"},
+		{name: "app secret", serverSecret: "secret", clientSecret: "secret", code: "code-1", redirectURI: "https://example.org/callback"},
+		{name: "wrong app secret", serverSecret: "secret", clientSecret: "other", code: "code-1", redirectURI: "https://example.org/callback", wantCode: "invalid_client"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeTokenEndpoint{
+				appKey:       "app-key",
+				appSecret:    tt.serverSecret,
+				code:         "code-1",
+				challenge:    Challenge(verifier),
+				redirectURI:  "https://example.org/callback",
+				refreshToken: "refresh-1",
+			}
+			if tt.redirectURI == "" {
+				fake.redirectURI = ""
+			}
+			server := httptest.NewServer(fake)
+			defer server.Close()
+
+			client := &Client{AppKey: "app-key", AppSecret: tt.clientSecret, TokenURL: server.URL}
+			token, err := client.Exchange(context.Background(), tt.code, tt.redirectURI, tt.verifier)
+
+			if tt.wantCode != "" {
+				var oauthErr *Error
+				if !errors.As(err, &oauthErr) || oauthErr.Code != tt.wantCode {
+					t.Fatalf("Exchange() error = %v, want %s", err, tt.wantCode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Exchange() error = %v", err)
+			}
+			if token.AccessToken != "sl.access" || token.RefreshToken != "refresh-1" || token.ExpiresIn != 14400 || token.AccountID != "dbid:abc" {
+				t.Errorf("token = %+v", token)
+			}
+			if tt.clientSecret == "" && fake.last.Has("client_secret") {
+				t.Error("PKCE exchange sent a client secret")
+			}
+		})
+	}
+}
+
+func TestExchangeMissingCode(t *testing.T) {
+	client := &Client{AppKey: "app-key", TokenURL: "http://127.0.0.1:0"}
+	if _, err := client.Exchange(context.Background(), "", "", "verifier"); err != ErrMissingCode {
+		t.Errorf("Exchange() error = %v, want %v", err, ErrMissingCode)
+	}
+}
+
+func TestRefresh(t *testing.T) {
+	fake := &fakeTokenEndpoint{appKey: "app-key", refreshToken: "refresh-1"}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	client := &Client{AppKey: "app-key", TokenURL: server.URL}
+	token, err := client.Refresh(context.Background(), "refresh-1")
+	if err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+	if token.AccessToken != "sl.refreshed" {
+		t.Errorf("access token = %q", token.AccessToken)
+	}
+	if fake.last.Has("client_secret") {
+		t.Error("refresh without an app secret sent one")
+	}
+
+	_, err = client.Refresh(context.Background(), "revoked")
+	var oauthErr *Error
+	if !errors.As(err, &oauthErr) || !oauthErr.InvalidGrant() {
+		t.Fatalf("Refresh() with revoked token error = %v, want invalid_grant", err)
+	}
+	if oauthErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("status = %d", oauthErr.StatusCode)
+	}
+}
+
+func TestRefreshInvalidResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"token_type": "bearer"}`))
+	}))
+	defer server.Close()
+
+	client := &Client{AppKey: "app-key", TokenURL: server.URL}
+	if _, err := client.Refresh(context.Background(), "refresh-1"); err != ErrInvalidToken {
+		t.Errorf("Refresh() error = %v, want %v", err, ErrInvalidToken)
+	}
+}
@@ -0,0 +1,226 @@
+// Package dropboxsync keeps a stored recursive listing of a Dropbox folder
+// up to date.
+//
+// The first sync lists the folder recursively with files/list_folder and
+// keeps the returned cursor. Later syncs pass the cursor to
+// files/list_folder/continue, which returns only the entries added, changed
+// or deleted since, so an unchanged folder costs one small API call. When
+// Dropbox resets a cursor the folder is listed in full again.
+package dropboxsync
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	"backend/internal/models"
+
+	"github.com/dropbox/dropbox-sdk-go-unofficial/v6/dropbox/files"
+)
+
+var (
+	ErrFolderNotFound = errors.New("folder not found in dropbox")
+)
+
+// ChangeType says how an entry differs from the previous sync
+type ChangeType string
+
+const (
+	ChangeAdded   ChangeType = "added"
+	ChangeUpdated ChangeType = "updated"
+	ChangeDeleted ChangeType = "deleted"
+)
+
+// Change is one entry that was added, updated or deleted by a sync. Deleted
+// changes carry the entry as it was last seen.
+type Change struct {
+	Type  ChangeType
+	Entry models.DropboxListingEntry
+}
+
+// Result describes what a sync did
+type Result struct {
+	Full    bool     // The folder was listed in full rather than from the cursor
+	Changes []Change // Entries that differ from the listing before the sync, sorted by path
+}
+
+// Sync brings listing up to date with the Dropbox folder at folderPath. A
+// folder that does not exist leaves the listing empty with Missing set.
+//
+// Changes are worked out by comparing the entries before and after the sync,
+// so replaying a sync that Dropbox has already reported reports nothing.
+func Sync(client files.Client, folderPath string, listing *models.DropboxListing) (Result, error) {
+	before := toMap(listing.Entries)
+	full, err := sync(client, folderPath, listing)
+	if err != nil {
+		return Result{}, err
+	}
+	return Result{Full: full, Changes: diff(before, toMap(listing.Entries))}, nil
+}
+
+func sync(client files.Client, folderPath string, listing *models.DropboxListing) (bool, error) {
+	root := strings.ToLower(folderPath)
+
+	if listing.HasCursor() {
+		entries := toMap(listing.Entries)
+		cursor, err := follow(client, listing.Cursor, root, entries)
+		switch {
+		case err == nil:
+			store(listing, entries, cursor)
+			return false, nil
+		case err == ErrFolderNotFound:
+			markMissing(listing)
+			return false, nil
+		case !isReset(err):
+			return false, err
+		}
+		// The cursor expired; fall back to a full listing
+	}
+
+	arg := files.NewListFolderArg(folderPath)
+	arg.Recursive = true
+	result, err := client.ListFolder(arg)
+	if err != nil {
+		if isNotFound(err) {
+			markMissing(listing)
+			return true, nil
+		}
+		return false, err
+	}
+
+	entries := map[string]models.DropboxListingEntry{}
+	apply(entries, root, result.Entries)
+	cursor := result.Cursor
+	if result.HasMore {
+		cursor, err = follow(client, result.Cursor, root, entries)
+		if err == ErrFolderNotFound {
+			markMissing(listing)
+			return true, nil
+		}
+		if err != nil {
+			return false, err
+		}
+	}
+
+	store(listing, entries, cursor)
+	return true, nil
+}
+
+// follow pages through list_folder/continue from cursor, applying every
+// change, and returns the cursor to resume from next time
+func follow(client files.Client, cursor, root string, entries map[string]models.DropboxListingEntry) (string, error) {
+	for {
+		result, err := client.ListFolderContinue(files.NewListFolderContinueArg(cursor))
+		if err != nil {
+			if isNotFound(err) {
+				return "", ErrFolderNotFound
+			}
+			return "", err
+		}
+
+		if !apply(entries, root, result.Entries) {
+			return "", ErrFolderNotFound
+		}
+		cursor = result.Cursor
+		if !result.HasMore {
+			return cursor, nil
+		}
+	}
+}
+
+// apply records listing entries in entries, keyed by lower-cased path. It
+// returns false when the root folder itself was deleted.
+func apply(entries map[string]models.DropboxListingEntry, root string, metadata []files.IsMetadata) bool {
+	for _, m := range metadata {
+		switch meta := m.(type) {
+		case *files.FileMetadata:
+			entries[meta.PathLower] = models.DropboxListingEntry{
+				PathLower:    meta.PathLower,
+				PathDisplay:  meta.PathDisplay,
+				Name:         meta.Name,
+				Size:         int64(meta.Size),
+				ModifiedTime: meta.ServerModified,
+				Rev:          meta.Rev,
+				ContentHash:  meta.ContentHash,
+			}
+		case *files.FolderMetadata:
+			if meta.PathLower == root {
+				continue
+			}
+			entries[meta.PathLower] = models.DropboxListingEntry{
+				PathLower:   meta.PathLower,
+				PathDisplay: meta.PathDisplay,
+				Name:        meta.Name,
+				IsFolder:    true,
+			}
+		case *files.DeletedMetadata:
+			if meta.PathLower == root {
+				return false
+			}
+			for key := range entries {
+				if key == meta.PathLower || strings.HasPrefix(key, meta.PathLower+"/") {
+					delete(entries, key)
+				}
+			}
+		}
+	}
+	return true
+}
+
+// diff compares the entries before and after a sync
+func diff(before, after map[string]models.DropboxListingEntry) []Change {
+	changes := []Change{}
+	for key, entry := range after {
+		old, ok := before[key]
+		switch {
+		case !ok:
+			changes = append(changes, Change{Type: ChangeAdded, Entry: entry})
+		case old.Rev != entry.Rev || old.ContentHash != entry.ContentHash || old.Size != entry.Size || old.IsFolder != entry.IsFolder || !old.ModifiedTime.Equal(entry.ModifiedTime):
+			changes = append(changes, Change{Type: ChangeUpdated, Entry: entry})
+		}
+	}
+	for key, entry := range before {
+		if _, ok := after[key]; !ok {
+			changes = append(changes, Change{Type: ChangeDeleted, Entry: entry})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Entry.PathLower < changes[j].Entry.PathLower })
+	return changes
+}
+
+func toMap(list []models.DropboxListingEntry) map[string]models.DropboxListingEntry {
+	entries := make(map[string]models.DropboxListingEntry, len(list))
+	for _, entry := range list {
+		entries[entry.PathLower] = entry
+	}
+	return entries
+}
+
+// store writes entries back to the listing, sorted by path
+func store(listing *models.DropboxListing, entries map[string]models.DropboxListingEntry, cursor string) {
+	list := make([]models.DropboxListingEntry, 0, len(entries))
+	for _, entry := range entries {
+		list = append(list, entry)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].PathLower < list[j].PathLower })
+
+	listing.Entries = list
+	listing.EntryCount = len(list)
+	listing.Cursor = cursor
+	listing.Missing = false
+}
+
+func markMissing(listing *models.DropboxListing) {
+	listing.Entries = []models.DropboxListingEntry{}
+	listing.EntryCount = 0
+	listing.Cursor = ""
+	listing.Missing = true
+}
+
+func isNotFound(err error) bool {
+	return strings.Contains(err.Error(), "path/not_found")
+}
+
+func isReset(err error) bool {
+	return strings.HasPrefix(err.Error(), "reset")
+}
@@ -0,0 +1,166 @@
+package dropboxsync
+
+import (
+	"reflect"
+	"testing"
+
+	"backend/internal/dropboxfake"
+	"backend/internal/models"
+)
+
+func paths(listing *models.DropboxListing) []string {
+	out := []string{}
+	for _, entry := range listing.Entries {
+		out = append(out, entry.PathDisplay)
+	}
+	return out
+}
+
+func changes(result Result) []string {
+	out := []string{}
+	for _, change := range result.Changes {
+		out = append(out, string(change.Type)+" "+change.Entry.PathDisplay)
+	}
+	return out
+}
+
+func TestSync(t *testing.T) {
+	tests := []struct {
+		name     string
+		pageSize int
+		change   func(fake *dropboxfake.Server)
+		want     []string
+		changes  []string
+		wantFull bool
+		missing  bool
+	}{
+		{
+			name:    "unchanged folder",
+			change:  func(fake *dropboxfake.Server) {},
+			want:    []string{"/SOPS/Anemia/Iron.pdf", "/SOPS/Anemia/Old", "/SOPS/Anemia/Old/2019.docx"},
+			changes: []string{},
+		},
+		{
+			name:     "unchanged folder with small pages",
+			pageSize: 1,
+			change:   func(fake *dropboxfake.Server) {},
+			want:     []string{"/SOPS/Anemia/Iron.pdf", "/SOPS/Anemia/Old", "/SOPS/Anemia/Old/2019.docx"},
+			changes:  []string{},
+		},
+		{
+			name: "added and deleted entries",
+			change: func(fake *dropboxfake.Server) {
+				fake.AddFile("/SOPS/Anemia/New/B12.txt", []byte("b12"))
+				fake.Remove("/SOPS/Anemia/Old")
+				fake.AddFile("/SOPS/Lymphoma/Other.pdf", []byte("outside the folder"))
+			},
+			want: []string{"/SOPS/Anemia/Iron.pdf", "/SOPS/Anemia/New", "/SOPS/Anemia/New/B12.txt"},
+			changes: []string{
+				"added /SOPS/Anemia/New",
+				"added /SOPS/Anemia/New/B12.txt",
+				"deleted /SOPS/Anemia/Old",
+				"deleted /SOPS/Anemia/Old/2019.docx",
+			},
+		},
+		{
+			name: "updated file",
+			change: func(fake *dropboxfake.Server) {
+				fake.AddFile("/SOPS/Anemia/Iron.pdf", []byte("iron, second edition"))
+			},
+			want:    []string{"/SOPS/Anemia/Iron.pdf", "/SOPS/Anemia/Old", "/SOPS/Anemia/Old/2019.docx"},
+			changes: []string{"updated /SOPS/Anemia/Iron.pdf"},
+		},
+		{
+			name: "reset cursor lists the folder again",
+			change: func(fake *dropboxfake.Server) {
+				fake.AddFile("/SOPS/Anemia/Folate.pdf", []byte("folate"))
+				fake.ExpireCursors()
+			},
+			want:     []string{"/SOPS/Anemia/Folate.pdf", "/SOPS/Anemia/Iron.pdf", "/SOPS/Anemia/Old", "/SOPS/Anemia/Old/2019.docx"},
+			changes:  []string{"added /SOPS/Anemia/Folate.pdf"},
+			wantFull: true,
+		},
+		{
+			name: "deleted folder",
+			change: func(fake *dropboxfake.Server) {
+				fake.Remove("/SOPS/Anemia")
+			},
+			want:    []string{},
+			changes: []string{"deleted /SOPS/Anemia/Iron.pdf", "deleted /SOPS/Anemia/Old", "deleted /SOPS/Anemia/Old/2019.docx"},
+			missing: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := dropboxfake.New()
+			defer fake.Close()
+			fake.PageSize = tt.pageSize
+			fake.AddFile("/SOPS/Anemia/Iron.pdf", []byte("iron"))
+			fake.AddFile("/SOPS/Anemia/Old/2019.docx", []byte("old"))
+			client := fake.Client()
+
+			listing := &models.DropboxListing{}
+			result, err := Sync(client, "/SOPS/Anemia", listing)
+			if err != nil {
+				t.Fatalf("initial Sync() error = %v", err)
+			}
+			if !result.Full || !listing.HasCursor() || listing.EntryCount != 3 || len(result.Changes) != 3 {
+				t.Fatalf("initial Sync() = %+v, %d entries, cursor %t", result, listing.EntryCount, listing.HasCursor())
+			}
+
+			tt.change(fake)
+			listCalls := fake.Calls("files/list_folder")
+
+			result, err = Sync(client, "/SOPS/Anemia", listing)
+			if err != nil {
+				t.Fatalf("Sync() error = %v", err)
+			}
+			if got := paths(listing); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("entries = %v, want %v", got, tt.want)
+			}
+			if got := changes(result); !reflect.DeepEqual(got, tt.changes) {
+				t.Errorf("changes = %v, want %v", got, tt.changes)
+			}
+			if result.Full != tt.wantFull {
+				t.Errorf("Full = %t, want %t", result.Full, tt.wantFull)
+			}
+			if listing.Missing != tt.missing {
+				t.Errorf("Missing = %t, want %t", listing.Missing, tt.missing)
+			}
+			if !tt.wantFull && fake.Calls("files/list_folder") != listCalls {
+				t.Errorf("incremental sync listed the folder in full")
+			}
+
+			// Syncing again reports nothing new
+			result, err = Sync(client, "/SOPS/Anemia", listing)
+			if err != nil {
+				t.Fatalf("repeated Sync() error = %v", err)
+			}
+			if len(result.Changes) != 0 {
+				t.Errorf("repeated Sync() changes = %v, want none", changes(result))
+			}
+		})
+	}
+}
+
+func TestSyncMissingFolder(t *testing.T) {
+	fake := dropboxfake.New()
+	defer fake.Close()
+
+	listing := &models.DropboxListing{}
+	if _, err := Sync(fake.Client(), "/SOPS/Nothing", listing); err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if !listing.Missing || listing.HasCursor() {
+		t.Fatalf("listing = %+v, want missing without cursor", listing)
+	}
+
+	fake.AddFile("/SOPS/Nothing/First.pdf", []byte("x"))
+	if _, err := Sync(fake.Client(), "/SOPS/Nothing", listing); err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if listing.Missing || listing.EntryCount != 1 {
+		t.Fatalf("listing = %+v, want one entry", listing)
+	}
+}
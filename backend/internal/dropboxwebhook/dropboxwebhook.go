@@ -0,0 +1,185 @@
+// Package dropboxwebhook verifies and schedules work for Dropbox webhook
+// notifications.
+//
+// Dropbox signs every notification with HMAC-SHA256 of the request body,
+// keyed by the app secret, and sends the hex digest in the
+// X-Dropbox-Signature header. A notification only says that something
+// changed for an account, not what, and carries no nonce or timestamp, so a
+// receiver cannot tell a replay from a real repeat. Handling is made safe
+// instead: notifications are coalesced so at most one sync runs and one more
+// is queued however many arrive, and the sync itself is idempotent.
+package dropboxwebhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"sync"
+)
+
+// SignatureHeader carries the hex HMAC-SHA256 of the request body
+const SignatureHeader = "X-Dropbox-Signature"
+
+var (
+	ErrMalformedNotification = errors.New("malformed webhook notification")
+)
+
+// Notification is the body of a Dropbox webhook request
+type Notification struct {
+	ListFolder struct {
+		Accounts []string `json:"accounts"`
+	} `json:"list_folder"`
+}
+
+// Sign returns the signature Dropbox sends for body
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySignature reports whether signature is the signature of body,
+// comparing in constant time
+func VerifySignature(secret string, body []byte, signature string) bool {
+	if secret == "" || signature == "" {
+		return false
+	}
+	got, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(got, mac.Sum(nil))
+}
+
+// ParseNotification decodes a notification body
+func ParseNotification(body []byte) (*Notification, error) {
+	var notification Notification
+	if err := json.Unmarshal(body, &notification); err != nil {
+		return nil, ErrMalformedNotification
+	}
+	return &notification, nil
+}
+
+// Pending collects the accounts of notifications until a sync takes them,
+// so a coalesced sync covers every account notified since the last one. It
+// is safe for concurrent use.
+type Pending struct {
+	mu       sync.Mutex
+	accounts map[string]bool
+	all      bool
+}
+
+// Add records the accounts of a notification. A notification that names no
+// accounts is taken to be about all of them.
+func (p *Pending) Add(accounts []string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(accounts) == 0 {
+		p.all = true
+		return
+	}
+	if p.accounts == nil {
+		p.accounts = map[string]bool{}
+	}
+	for _, account := range accounts {
+		p.accounts[account] = true
+	}
+}
+
+// Take returns the accounts recorded so far and starts a new set
+func (p *Pending) Take() Accounts {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	taken := Accounts{ids: p.accounts, all: p.all}
+	p.accounts, p.all = nil, false
+	return taken
+}
+
+// Accounts are the accounts a sync covers
+type Accounts struct {
+	ids map[string]bool
+	all bool
+}
+
+// Includes reports whether a connection authorized for an account needs
+// syncing. A connection whose account is not known, as for connections
+// authorized before it was recorded, always does.
+func (a Accounts) Includes(accountID string) bool {
+	return a.all || accountID == "" || a.ids[accountID]
+}
+
+// Coalescer runs a function in the background, never more than once at a
+// time. Triggers that arrive during a run are folded into a single
+// follow-up run.
+type Coalescer struct {
+	fn func()
+
+	mu      sync.Mutex
+	running bool
+	pending bool
+	idle    *sync.Cond
+}
+
+// NewCoalescer creates a Coalescer for fn
+func NewCoalescer(fn func()) *Coalescer {
+	c := &Coalescer{fn: fn}
+	c.idle = sync.NewCond(&c.mu)
+	return c
+}
+
+// Trigger asks for a run. It returns false when the request was folded into
+// a run that is already queued.
+func (c *Coalescer) Trigger() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.running {
+		if c.pending {
+			return false
+		}
+		c.pending = true
+		return true
+	}
+
+	c.running = true
+	go c.loop()
+	return true
+}
+
+// Running reports whether a run is in progress
+func (c *Coalescer) Running() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.running
+}
+
+// Wait blocks until no run is in progress or queued
+func (c *Coalescer) Wait() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for c.running {
+		c.idle.Wait()
+	}
+}
+
+func (c *Coalescer) loop() {
+	for {
+		c.fn()
+
+		c.mu.Lock()
+		if !c.pending {
+			c.running = false
+			c.idle.Broadcast()
+			c.mu.Unlock()
+			return
+		}
+		c.pending = false
+		c.mu.Unlock()
+	}
+}
@@ -0,0 +1,118 @@
+package dropboxwebhook
+
+import (
+	"sync/atomic"
+	"testing"
+)
+
+func TestVerifySignature(t *testing.T) {
+	body := []byte(`{"list_folder": {"accounts": ["dbid:AAH4f99T0taONIb-OurWxbNQ6ywGRopQngc"]}}`)
+	valid := Sign("app-secret", body)
+
+	tests := []struct {
+		name      string
+		secret    string
+		body      []byte
+		signature string
+		want      bool
+	}{
+		{name: "valid", secret: "app-secret", body: body, signature: valid, want: true},
+		{name: "wrong secret", secret: "other-secret", body: body, signature: valid},
+		{name: "tampered body", secret: "app-secret", body: []byte(`{"list_folder": {"accounts": []}}`), signature: valid},
+		{name: "not hex", secret: "app-secret", body: body, signature: "zz"},
+		{name: "missing signature", secret: "app-secret", body: body},
+		{name: "missing secret", body: body, signature: Sign("", body)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := VerifySignature(tt.secret, tt.body, tt.signature); got != tt.want {
+				t.Errorf("VerifySignature() = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseNotification(t *testing.T) {
+	notification, err := ParseNotification([]byte(`{"list_folder": {"accounts": ["dbid:a", "dbid:b"]}, "delta": {"users": [1, 2]}}`))
+	if err != nil {
+		t.Fatalf("ParseNotification() error = %v", err)
+	}
+	if len(notification.ListFolder.Accounts) != 2 {
+		t.Errorf("accounts = %v, want 2", notification.ListFolder.Accounts)
+	}
+
+	if _, err := ParseNotification([]byte("not json")); err != ErrMalformedNotification {
+		t.Errorf("ParseNotification() error = %v, want %v", err, ErrMalformedNotification)
+	}
+}
+
+func TestPending(t *testing.T) {
+	var p Pending
+	p.Add([]string{"dbid:a"})
+	p.Add([]string{"dbid:b", "dbid:a"})
+
+	taken := p.Take()
+	tests := []struct {
+		account string
+		want    bool
+	}{
+		{account: "dbid:a", want: true},
+		{account: "dbid:b", want: true},
+		{account: "dbid:c"},
+		{account: "", want: true}, // Not recorded for the connection
+	}
+	for _, tt := range tests {
+		if got := taken.Includes(tt.account); got != tt.want {
+			t.Errorf("Includes(%q) = %t, want %t", tt.account, got, tt.want)
+		}
+	}
+
+	if p.Take().Includes("dbid:a") {
+		t.Error("Take() kept accounts already taken")
+	}
+
+	p.Add(nil)
+	if !p.Take().Includes("dbid:c") {
+		t.Error("a notification without accounts should include every account")
+	}
+}
+
+func TestCoalescer(t *testing.T) {
+	var runs atomic.Int32
+	release := make(chan struct{})
+	started := make(chan struct{}, 10)
+
+	c := NewCoalescer(func() {
+		runs.Add(1)
+		started <- struct{}{}
+		<-release
+	})
+
+	if !c.Trigger() {
+		t.Fatal("first Trigger() = false, want a run")
+	}
+	<-started
+
+	// Triggers during a run queue exactly one more run
+	if !c.Trigger() {
+		t.Error("second Trigger() = false, want a queued run")
+	}
+	for i := 0; i < 5; i++ {
+		if c.Trigger() {
+			t.Error("replayed Trigger() = true, want it folded into the queued run")
+		}
+	}
+
+	release <- struct{}{}
+	<-started
+	release <- struct{}{}
+	c.Wait()
+
+	if got := runs.Load(); got != 2 {
+		t.Errorf("runs = %d, want 2", got)
+	}
+	if c.Running() {
+		t.Error("Running() = true after Wait()")
+	}
+}
@@ -0,0 +1,395 @@
+// Package fieldcrypt encrypts selected fields of models before they are
+// stored, so database dumps and backups do not expose personal data.
+//
+// String fields are marked with an encrypt tag:
+//
+//	PhoneNumber      string `bson:"phone_number" encrypt:"index=PhoneNumberIndex"`
+//	PhoneNumberIndex string `bson:"phone_number_index" json:"-"`
+//
+// `encrypt:"true"` encrypts the field. `encrypt:"index=Field"` also stores a
+// blind index of the value in the named field: a keyed hash that is the
+// same for equal values, so the field can still be matched exactly without
+// being decrypted.
+//
+// Stored values carry Prefix, so values written before a field was
+// encrypted are told apart and read as they are. A value is only taken to
+// be encrypted if it also decrypts, so input that happens to start with
+// Prefix is still encrypted.
+package fieldcrypt
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Prefix marks an encrypted value
+const Prefix = "enc:"
+
+// Cipher encrypts values and computes their blind indexes
+type Cipher interface {
+	Encrypt(plaintext string) (string, error)
+	Decrypt(ciphertext string) (string, error)
+	BlindIndex(value string) string
+}
+
+// Field is an encrypted field of a model by its dotted BSON path, with the
+// path of its blind index if it has one
+type Field struct {
+	Path  string
+	Index string
+}
+
+// IsEncrypted reports whether a stored value is encrypted
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, Prefix)
+}
+
+// Normalize returns the form of a value that blind indexes are computed
+// from, so that "MP 012 3456" and "mp0123456" match
+func Normalize(value string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) || strings.ContainsRune("-().", r) {
+			return -1
+		}
+		return unicode.ToLower(r)
+	}, value)
+}
+
+// decrypted returns the plaintext of a value encrypted with c. It reports
+// false for any other value, including plaintext that starts with Prefix.
+func decrypted(c Cipher, value string) (string, bool) {
+	if !IsEncrypted(value) {
+		return "", false
+	}
+	plaintext, err := c.Decrypt(strings.TrimPrefix(value, Prefix))
+	return plaintext, err == nil
+}
+
+// plaintextOf returns a value as it was before encryption
+func plaintextOf(c Cipher, value string) string {
+	if plaintext, ok := decrypted(c, value); ok {
+		return plaintext
+	}
+	return value
+}
+
+// EncryptString encrypts a value. Empty values and values already
+// encrypted with c are returned unchanged.
+func EncryptString(c Cipher, value string) (string, error) {
+	if value == "" {
+		return value, nil
+	}
+	if _, ok := decrypted(c, value); ok {
+		return value, nil
+	}
+	ciphertext, err := c.Encrypt(value)
+	if err != nil {
+		return "", err
+	}
+	return Prefix + ciphertext, nil
+}
+
+// DecryptString decrypts a value. Values that are not encrypted are
+// returned unchanged, and so are values that cannot be decrypted, along
+// with the error.
+func DecryptString(c Cipher, value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+	plaintext, err := c.Decrypt(strings.TrimPrefix(value, Prefix))
+	if err != nil {
+		return value, err
+	}
+	return plaintext, nil
+}
+
+// Index returns the blind index of a value, or "" for an empty value
+func Index(c Cipher, value string) string {
+	if value = Normalize(value); value == "" {
+		return ""
+	}
+	return c.BlindIndex(value)
+}
+
+// EncryptValue encrypts any JSON value, such as a submitted form field.
+// Documents and arrays read back from BSON are encoded as JSON objects and
+// arrays. Values already encrypted with EncryptValue are returned unchanged.
+func EncryptValue(c Cipher, value interface{}) (interface{}, error) {
+	if s, ok := value.(string); ok && IsEncrypted(s) {
+		if _, err := DecryptValue(c, s); err == nil {
+			return value, nil
+		}
+	}
+	encoded, err := json.Marshal(jsonValue(value))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode value: %w", err)
+	}
+	ciphertext, err := c.Encrypt(string(encoded))
+	if err != nil {
+		return nil, err
+	}
+	return Prefix + ciphertext, nil
+}
+
+// DecryptValue decrypts a value encrypted with EncryptValue. Values that are
+// not encrypted are returned unchanged, and so are values that cannot be
+// decrypted, along with the error.
+func DecryptValue(c Cipher, value interface{}) (interface{}, error) {
+	s, ok := value.(string)
+	if !ok || !IsEncrypted(s) {
+		return value, nil
+	}
+	plaintext, err := c.Decrypt(strings.TrimPrefix(s, Prefix))
+	if err != nil {
+		return value, err
+	}
+	var decoded interface{}
+	if err := json.Unmarshal([]byte(plaintext), &decoded); err != nil {
+		return value, fmt.Errorf("failed to decode value: %w", err)
+	}
+	return decoded, nil
+}
+
+// jsonValue converts the documents and arrays the BSON driver decodes
+// nested form data into to maps and slices, which encode as JSON objects
+// and arrays rather than lists of keys and values
+func jsonValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case primitive.D:
+		m := make(map[string]interface{}, len(v))
+		for _, e := range v {
+			m[e.Key] = jsonValue(e.Value)
+		}
+		return m
+	case primitive.M:
+		return jsonValue(map[string]interface{}(v))
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			m[key] = jsonValue(item)
+		}
+		return m
+	case primitive.A:
+		return jsonValue([]interface{}(v))
+	case []interface{}:
+		items := make([]interface{}, len(v))
+		for i, item := range v {
+			items[i] = jsonValue(item)
+		}
+		return items
+	}
+	return value
+}
+
+// DecryptValues decrypts the values of the given keys in place, such as the
+// sensitive fields of submitted form data. Other values are left as they
+// are, even if they look encrypted. A value that cannot be decrypted is
+// kept as stored; the first such error is returned once every other value
+// is decrypted.
+func DecryptValues(c Cipher, values map[string]interface{}, keys []string) error {
+	var first error
+	for _, key := range keys {
+		value, ok := values[key]
+		if !ok {
+			continue
+		}
+		decrypted, err := DecryptValue(c, value)
+		if err != nil && first == nil {
+			first = fmt.Errorf("failed to decrypt %s: %w", key, err)
+		}
+		values[key] = decrypted
+	}
+	return first
+}
+
+// Fields returns the encrypted fields of a model, a struct or a pointer to
+// one
+func Fields(model interface{}) []Field {
+	fields := []Field{}
+	collectFields(reflect.Indirect(reflect.ValueOf(model)).Type(), "", &fields)
+	return fields
+}
+
+func collectFields(t reflect.Type, prefix string, fields *[]Field) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, ok := bsonName(field)
+		if !ok {
+			continue
+		}
+
+		if tag, ok := field.Tag.Lookup("encrypt"); ok && field.Type.Kind() == reflect.String {
+			f := Field{Path: prefix + name}
+			if indexName := indexField(tag); indexName != "" {
+				if index, ok := t.FieldByName(indexName); ok {
+					indexBSON, _ := bsonName(index)
+					f.Index = prefix + indexBSON
+				}
+			}
+			*fields = append(*fields, f)
+			continue
+		}
+
+		if nested := structType(field.Type); nested != nil {
+			collectFields(nested, prefix+name+".", fields)
+		}
+	}
+}
+
+// EncryptStruct encrypts the tagged fields of a model in place, and sets
+// their blind indexes. v must be a pointer to a struct.
+func EncryptStruct(c Cipher, v interface{}) error {
+	return walk(reflect.ValueOf(v), func(s reflect.Value, field reflect.StructField, tag string) error {
+		value := s.FieldByIndex(field.Index)
+		plaintext := plaintextOf(c, value.String())
+		if indexName := indexField(tag); indexName != "" {
+			if index := s.FieldByName(indexName); index.IsValid() && index.Kind() == reflect.String {
+				index.SetString(Index(c, plaintext))
+			}
+		}
+
+		ciphertext, err := EncryptString(c, value.String())
+		if err != nil {
+			return fmt.Errorf("failed to encrypt %s: %w", field.Name, err)
+		}
+		value.SetString(ciphertext)
+		return nil
+	})
+}
+
+// DecryptStruct decrypts the tagged fields of a model in place. A field
+// that cannot be decrypted is kept as stored; the first such error is
+// returned once every other field is decrypted. v must be a pointer to a
+// struct.
+func DecryptStruct(c Cipher, v interface{}) error {
+	var first error
+	err := walk(reflect.ValueOf(v), func(s reflect.Value, field reflect.StructField, _ string) error {
+		value := s.FieldByIndex(field.Index)
+		plaintext, err := DecryptString(c, value.String())
+		if err != nil && first == nil {
+			first = fmt.Errorf("failed to decrypt %s: %w", field.Name, err)
+		}
+		value.SetString(plaintext)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return first
+}
+
+// EncryptUpdate encrypts the values an update sets on encrypted fields, and
+// sets their blind indexes. Keys are dotted BSON paths, as passed to $set.
+func EncryptUpdate(c Cipher, fields []Field, update map[string]interface{}) error {
+	for _, field := range fields {
+		value, ok := update[field.Path].(string)
+		if !ok {
+			continue
+		}
+
+		plaintext := plaintextOf(c, value)
+		ciphertext, err := EncryptString(c, value)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt %s: %w", field.Path, err)
+		}
+		update[field.Path] = ciphertext
+		if field.Index != "" {
+			update[field.Index] = Index(c, plaintext)
+		}
+	}
+	return nil
+}
+
+// walk calls fn for every tagged string field of the struct v points to,
+// including those of nested structs
+func walk(v reflect.Value, fn func(s reflect.Value, field reflect.StructField, tag string) error) error {
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("fieldcrypt: %s is not a pointer to a struct", v.Type())
+	}
+
+	s := v.Elem()
+	t := s.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		if tag, ok := field.Tag.Lookup("encrypt"); ok && field.Type.Kind() == reflect.String {
+			if err := fn(s, field, tag); err != nil {
+				return err
+			}
+			continue
+		}
+
+		value := s.Field(i)
+		switch {
+		case value.Kind() == reflect.Struct && structType(field.Type) != nil:
+			if err := walk(value.Addr(), fn); err != nil {
+				return err
+			}
+		case value.Kind() == reflect.Pointer && !value.IsNil() && structType(field.Type) != nil:
+			if err := walk(value, fn); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// structType returns the struct type a field holds, directly or through a
+// pointer, when it has encrypted fields
+func structType(t reflect.Type) reflect.Type {
+	return encryptedStruct(t, map[reflect.Type]bool{})
+}
+
+func encryptedStruct(t reflect.Type, seen map[reflect.Type]bool) reflect.Type {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || seen[t] {
+		return nil
+	}
+	seen[t] = true
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if _, ok := field.Tag.Lookup("encrypt"); ok {
+			return t
+		}
+		if field.IsExported() && encryptedStruct(field.Type, seen) != nil {
+			return t
+		}
+	}
+	return nil
+}
+
+// bsonName returns the name a field is stored under, following the BSON
+// driver's default of the lowercased field name
+func bsonName(field reflect.StructField) (string, bool) {
+	if !field.IsExported() {
+		return "", false
+	}
+	name, _, _ := strings.Cut(field.Tag.Get("bson"), ",")
+	switch name {
+	case "-":
+		return "", false
+	case "":
+		return strings.ToLower(field.Name), true
+	}
+	return name, true
+}
+
+// indexField returns the name of the field holding the blind index, from
+// an encrypt tag such as "index=PhoneNumberIndex"
+func indexField(tag string) string {
+	if name, ok := strings.CutPrefix(tag, "index="); ok {
+		return name
+	}
+	return ""
+}
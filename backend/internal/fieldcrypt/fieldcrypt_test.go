@@ -0,0 +1,295 @@
+package fieldcrypt
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// reverseCipher "encrypts" by reversing, which is enough to tell encrypted
+// values apart
+type reverseCipher struct{}
+
+func reverse(s string) string {
+	r := []rune(s)
+	for i, j := 0, len(r)-1; i < j; i, j = i+1, j-1 {
+		r[i], r[j] = r[j], r[i]
+	}
+	return string(r)
+}
+
+func (reverseCipher) Encrypt(plaintext string) (string, error) { return reverse(plaintext), nil }
+
+func (reverseCipher) Decrypt(ciphertext string) (string, error) {
+	if strings.HasPrefix(ciphertext, "bad") {
+		return "", errors.New("unknown key")
+	}
+	return reverse(ciphertext), nil
+}
+
+func (reverseCipher) BlindIndex(value string) string { return "idx(" + value + ")" }
+
+type profile struct {
+	Name        string `bson:"name"`
+	Phone       string `bson:"phone,omitempty" encrypt:"index=PhoneIndex"`
+	PhoneIndex  string `bson:"phone_index,omitempty"`
+	Note        string `bson:"note" encrypt:"true"`
+	Unencrypted string
+}
+
+type account struct {
+	Email   string   `bson:"email"`
+	Profile profile  `bson:"profile"`
+	Backup  *profile `bson:"backup,omitempty"`
+	Skipped profile  `bson:"-"`
+}
+
+func TestFields(t *testing.T) {
+	got := Fields(account{})
+	want := []Field{
+		{Path: "profile.phone", Index: "profile.phone_index"},
+		{Path: "profile.note"},
+		{Path: "backup.phone", Index: "backup.phone_index"},
+		{Path: "backup.note"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Fields() = %+v, want %+v", got, want)
+	}
+}
+
+func TestEncryptDecryptStruct(t *testing.T) {
+	c := reverseCipher{}
+	a := &account{
+		Email:   "a@example.org",
+		Profile: profile{Name: "Ann", Phone: "082 123 4567", Note: "note"},
+		Backup:  &profile{Phone: "enc:tsixe"}, // Already encrypted
+	}
+
+	if err := EncryptStruct(c, a); err != nil {
+		t.Fatalf("EncryptStruct() error = %v", err)
+	}
+	if a.Email != "a@example.org" || a.Profile.Name != "Ann" {
+		t.Errorf("untagged fields changed: %+v", a)
+	}
+	if a.Profile.Phone != "enc:7654 321 280" || a.Profile.Note != "enc:eton" {
+		t.Errorf("encrypted = %q, %q", a.Profile.Phone, a.Profile.Note)
+	}
+	if a.Profile.PhoneIndex != "idx(0821234567)" {
+		t.Errorf("PhoneIndex = %q, want the index of the normalized number", a.Profile.PhoneIndex)
+	}
+	if a.Backup.Phone != "enc:tsixe" || a.Backup.PhoneIndex != "idx(exist)" {
+		t.Errorf("already encrypted = %q, index %q", a.Backup.Phone, a.Backup.PhoneIndex)
+	}
+
+	if err := DecryptStruct(c, a); err != nil {
+		t.Fatalf("DecryptStruct() error = %v", err)
+	}
+	if a.Profile.Phone != "082 123 4567" || a.Profile.Note != "note" || a.Backup.Phone != "exist" {
+		t.Errorf("decrypted = %+v, %+v", a.Profile, a.Backup)
+	}
+
+	// Plaintext written before encryption is read as it is; a value that
+	// cannot be decrypted is kept as stored
+	legacy := &account{Profile: profile{Phone: "082", Note: "enc:bad"}}
+	if err := DecryptStruct(c, legacy); err == nil {
+		t.Error("DecryptStruct() error = nil, want an error")
+	}
+	if legacy.Profile.Phone != "082" || legacy.Profile.Note != "enc:bad" {
+		t.Errorf("DecryptStruct() = %+v", legacy.Profile)
+	}
+
+	// Input that only looks encrypted is encrypted like any other value
+	lookalike := &account{Profile: profile{Phone: "enc:bad 082"}}
+	if err := EncryptStruct(c, lookalike); err != nil {
+		t.Fatalf("EncryptStruct() error = %v", err)
+	}
+	if lookalike.Profile.Phone != "enc:280 dab:cne" || lookalike.Profile.PhoneIndex != "idx(enc:bad082)" {
+		t.Errorf("EncryptStruct() of a lookalike = %q, index %q", lookalike.Profile.Phone, lookalike.Profile.PhoneIndex)
+	}
+
+	if err := EncryptStruct(c, account{}); err == nil {
+		t.Error("EncryptStruct(struct) error = nil, want an error for a non-pointer")
+	}
+}
+
+func TestEncryptUpdate(t *testing.T) {
+	update := map[string]interface{}{
+		"profile.phone": "082",
+		"profile.note":  "",
+		"email":         "a@example.org",
+	}
+	if err := EncryptUpdate(reverseCipher{}, Fields(account{}), update); err != nil {
+		t.Fatalf("EncryptUpdate() error = %v", err)
+	}
+
+	want := map[string]interface{}{
+		"profile.phone":       "enc:280",
+		"profile.phone_index": "idx(082)",
+		"profile.note":        "",
+		"email":               "a@example.org",
+	}
+	if !reflect.DeepEqual(update, want) {
+		t.Errorf("EncryptUpdate() = %v, want %v", update, want)
+	}
+}
+
+func TestEncryptValue(t *testing.T) {
+	c := reverseCipher{}
+	tests := []struct {
+		name  string
+		value interface{}
+	}{
+		{name: "string", value: "Patient notes"},
+		{name: "number", value: 42.5},
+		{name: "list", value: []interface{}{"a", "b"}},
+		{name: "null", value: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encrypted, err := EncryptValue(c, tt.value)
+			if err != nil {
+				t.Fatalf("EncryptValue() error = %v", err)
+			}
+			if s, ok := encrypted.(string); !ok || !IsEncrypted(s) {
+				t.Fatalf("EncryptValue() = %v, want an encrypted string", encrypted)
+			}
+			again, _ := EncryptValue(c, encrypted)
+			if again != encrypted {
+				t.Errorf("EncryptValue() encrypted twice: %v", again)
+			}
+
+			decrypted, err := DecryptValue(c, encrypted)
+			if err != nil {
+				t.Fatalf("DecryptValue() error = %v", err)
+			}
+			if !reflect.DeepEqual(decrypted, tt.value) {
+				t.Errorf("DecryptValue() = %#v, want %#v", decrypted, tt.value)
+			}
+		})
+	}
+
+	if got, _ := DecryptValue(c, "plain"); got != "plain" {
+		t.Errorf("DecryptValue(plain) = %v, want it unchanged", got)
+	}
+}
+
+func TestDecryptString(t *testing.T) {
+	c := reverseCipher{}
+	tests := []struct {
+		name    string
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{name: "encrypted", value: "enc:eton", want: "note"},
+		{name: "plaintext", value: "note", want: "note"},
+		{name: "cannot be decrypted", value: "enc:bad", want: "enc:bad", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecryptString(c, tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DecryptString() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("DecryptString() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncryptValueFromBSON(t *testing.T) {
+	c := reverseCipher{}
+	// Nested form data as the BSON driver reads it back
+	stored := primitive.D{
+		{Key: "drug", Value: "Insulin"},
+		{Key: "doses", Value: primitive.A{
+			primitive.D{{Key: "units", Value: 10.0}, {Key: "time", Value: "08:00"}},
+			primitive.M{"units": 6.0, "time": "20:00"},
+		}},
+	}
+
+	encrypted, err := EncryptValue(c, stored)
+	if err != nil {
+		t.Fatalf("EncryptValue() error = %v", err)
+	}
+	decrypted, err := DecryptValue(c, encrypted)
+	if err != nil {
+		t.Fatalf("DecryptValue() error = %v", err)
+	}
+
+	want := map[string]interface{}{
+		"drug": "Insulin",
+		"doses": []interface{}{
+			map[string]interface{}{"units": 10.0, "time": "08:00"},
+			map[string]interface{}{"units": 6.0, "time": "20:00"},
+		},
+	}
+	if !reflect.DeepEqual(decrypted, want) {
+		t.Errorf("DecryptValue() = %#v, want %#v", decrypted, want)
+	}
+}
+
+func TestEncryptLookalikes(t *testing.T) {
+	c := reverseCipher{}
+	tests := []struct {
+		name        string
+		encrypt     func(value string) (interface{}, error)
+		value       string
+		wantEncrypt bool
+	}{
+		{name: "string encrypted", encrypt: func(v string) (interface{}, error) { return EncryptString(c, v) }, value: "enc:eton"},
+		{name: "string lookalike", encrypt: func(v string) (interface{}, error) { return EncryptString(c, v) }, value: "enc:bad note", wantEncrypt: true},
+		{name: "value encrypted", encrypt: func(v string) (interface{}, error) { return EncryptValue(c, v) }, value: `enc:"eton"`},
+		{name: "value lookalike", encrypt: func(v string) (interface{}, error) { return EncryptValue(c, v) }, value: "enc:bad note", wantEncrypt: true},
+		{name: "value not JSON", encrypt: func(v string) (interface{}, error) { return EncryptValue(c, v) }, value: "enc:eton", wantEncrypt: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.encrypt(tt.value)
+			if err != nil {
+				t.Fatalf("encrypt(%q) error = %v", tt.value, err)
+			}
+			if encrypted := got != tt.value; encrypted != tt.wantEncrypt {
+				t.Errorf("encrypt(%q) = %v, want encrypted %t", tt.value, got, tt.wantEncrypt)
+			}
+		})
+	}
+
+	// A lookalike comes back as it was entered
+	encrypted, _ := EncryptValue(c, "enc:bad note")
+	if got, err := DecryptValue(c, encrypted); err != nil || got != "enc:bad note" {
+		t.Errorf("DecryptValue() = %v, %v, want %q", got, err, "enc:bad note")
+	}
+}
+
+func TestDecryptValues(t *testing.T) {
+	c := reverseCipher{}
+	encrypted, _ := EncryptValue(c, "anemia")
+	values := map[string]interface{}{
+		"diagnosis": encrypted,
+		"notes":     "enc:typed by the user", // Not sensitive, so never encrypted
+		"hospital":  "City",
+		"history":   "enc:bad",
+	}
+
+	err := DecryptValues(c, values, []string{"diagnosis", "history", "missing"})
+	if err == nil {
+		t.Error("DecryptValues() error = nil, want an error for history")
+	}
+	want := map[string]interface{}{
+		"diagnosis": "anemia",
+		"notes":     "enc:typed by the user",
+		"hospital":  "City",
+		"history":   "enc:bad",
+	}
+	if !reflect.DeepEqual(values, want) {
+		t.Errorf("DecryptValues() = %v, want %v", values, want)
+	}
+}
@@ -0,0 +1,214 @@
+// Package geo provides offline geocoding of institutions from a locally
+// loaded gazetteer file and distance helpers for nearby search.
+//
+// The gazetteer is a CSV file with a header row naming at least the city,
+// latitude and longitude columns; province and country are optional but
+// improve matching when the same city name exists in several places:
+//
+//	city,province,country,latitude,longitude
+//	Cape Town,Western Cape,South Africa,-33.9249,18.4241
+//
+// No external geocoding API is ever called.
+package geo
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// GazetteerPathEnv names the environment variable holding the gazetteer file path
+const GazetteerPathEnv = "GAZETTEER_PATH"
+
+// earthRadiusKm is the mean radius of the earth used for distance calculations
+const earthRadiusKm = 6371.0088
+
+var (
+	ErrMissingColumns = errors.New("gazetteer must have city, latitude and longitude columns")
+	ErrNoEntries      = errors.New("gazetteer contains no valid entries")
+)
+
+// Place is a single gazetteer entry
+type Place struct {
+	City      string
+	Province  string
+	Country   string
+	Latitude  float64
+	Longitude float64
+}
+
+// Gazetteer looks up coordinates for place names. A nil Gazetteer is valid
+// and never finds anything, so callers need not check whether one is loaded.
+type Gazetteer struct {
+	places []Place
+	// byCity indexes places by normalised city name
+	byCity map[string][]int
+}
+
+// LoadGazetteerFromEnv loads the gazetteer named by GAZETTEER_PATH. It returns
+// nil without an error when the variable is not set.
+func LoadGazetteerFromEnv() (*Gazetteer, error) {
+	path := os.Getenv(GazetteerPathEnv)
+	if path == "" {
+		return nil, nil
+	}
+	return LoadGazetteer(path)
+}
+
+// LoadGazetteer reads a gazetteer CSV file
+func LoadGazetteer(path string) (*Gazetteer, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ParseGazetteer(f)
+}
+
+// ParseGazetteer reads gazetteer CSV data. Rows with missing names or
+// out-of-range coordinates are skipped.
+func ParseGazetteer(r io.Reader) (*Gazetteer, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read gazetteer header: %w", err)
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		switch normalize(name) {
+		case "city", "name", "town":
+			columns["city"] = i
+		case "province", "state", "region":
+			columns["province"] = i
+		case "country":
+			columns["country"] = i
+		case "latitude", "lat":
+			columns["lat"] = i
+		case "longitude", "lng", "lon":
+			columns["lng"] = i
+		}
+	}
+	for _, required := range []string{"city", "lat", "lng"} {
+		if _, ok := columns[required]; !ok {
+			return nil, ErrMissingColumns
+		}
+	}
+
+	field := func(record []string, column string) string {
+		i, ok := columns[column]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	g := &Gazetteer{byCity: map[string][]int{}}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read gazetteer: %w", err)
+		}
+
+		lat, latErr := strconv.ParseFloat(field(record, "lat"), 64)
+		lng, lngErr := strconv.ParseFloat(field(record, "lng"), 64)
+		city := field(record, "city")
+		if city == "" || latErr != nil || lngErr != nil || !ValidCoordinates(lat, lng) {
+			continue
+		}
+
+		key := normalize(city)
+		g.byCity[key] = append(g.byCity[key], len(g.places))
+		g.places = append(g.places, Place{
+			City:      city,
+			Province:  field(record, "province"),
+			Country:   field(record, "country"),
+			Latitude:  lat,
+			Longitude: lng,
+		})
+	}
+
+	if len(g.places) == 0 {
+		return nil, ErrNoEntries
+	}
+	return g, nil
+}
+
+// Len returns the number of places in the gazetteer
+func (g *Gazetteer) Len() int {
+	if g == nil {
+		return 0
+	}
+	return len(g.places)
+}
+
+// Lookup finds the place matching a city. Province and country narrow the
+// match when given; a city name that remains ambiguous is not matched.
+func (g *Gazetteer) Lookup(city, province, country string) (Place, bool) {
+	if g == nil {
+		return Place{}, false
+	}
+
+	candidates := g.byCity[normalize(city)]
+	if len(candidates) == 0 {
+		return Place{}, false
+	}
+
+	matches := func(want string, got func(Place) string, in []int) []int {
+		if want == "" {
+			return in
+		}
+		out := []int{}
+		for _, i := range in {
+			if value := got(g.places[i]); value == "" || normalize(value) == normalize(want) {
+				out = append(out, i)
+			}
+		}
+		return out
+	}
+
+	candidates = matches(country, func(p Place) string { return p.Country }, candidates)
+	candidates = matches(province, func(p Place) string { return p.Province }, candidates)
+	if len(candidates) != 1 {
+		return Place{}, false
+	}
+	return g.places[candidates[0]], true
+}
+
+// ValidCoordinates reports whether a latitude and longitude are in range
+func ValidCoordinates(lat, lng float64) bool {
+	return lat >= -90 && lat <= 90 && lng >= -180 && lng <= 180 &&
+		!math.IsNaN(lat) && !math.IsNaN(lng)
+}
+
+// DistanceKm returns the great-circle distance between two points in kilometres
+func DistanceKm(lat1, lng1, lat2, lng2 float64) float64 {
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+
+	dLat := toRad(lat2 - lat1)
+	dLng := toRad(lng2 - lng1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(lat1))*math.Cos(toRad(lat2))*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// normalize lowercases a place name and collapses punctuation and whitespace
+func normalize(s string) string {
+	fields := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(fields, " ")
+}
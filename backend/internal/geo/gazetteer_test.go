@@ -0,0 +1,76 @@
+package geo
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+const testGazetteer = `city,province,country,latitude,longitude
+Cape Town,Western Cape,South Africa,-33.9249,18.4241
+Johannesburg,Gauteng,South Africa,-26.2041,28.0473
+Springfield,Free State,South Africa,-29.0,26.0
+Springfield,KwaZulu-Natal,South Africa,-29.8,30.9
+Nowhere,,,,
+Bad,,,-120,18
+`
+
+func TestGazetteerLookup(t *testing.T) {
+	g, err := ParseGazetteer(strings.NewReader(testGazetteer))
+	if err != nil {
+		t.Fatalf("ParseGazetteer() error = %v", err)
+	}
+	if g.Len() != 4 {
+		t.Errorf("Len() = %d, want 4 (rows without valid coordinates skipped)", g.Len())
+	}
+
+	tests := []struct {
+		name     string
+		city     string
+		province string
+		country  string
+		wantLat  float64
+		wantOK   bool
+	}{
+		{"exact", "Cape Town", "Western Cape", "South Africa", -33.9249, true},
+		{"case and punctuation insensitive", "cape-town", "", "", -33.9249, true},
+		{"ambiguous without province", "Springfield", "", "South Africa", 0, false},
+		{"disambiguated by province", "Springfield", "KwaZulu-Natal", "", -29.8, true},
+		{"wrong country", "Johannesburg", "", "Namibia", 0, false},
+		{"unknown city", "Atlantis", "", "", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			place, ok := g.Lookup(tt.city, tt.province, tt.country)
+			if ok != tt.wantOK {
+				t.Fatalf("Lookup() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if ok && place.Latitude != tt.wantLat {
+				t.Errorf("Lookup() latitude = %v, want %v", place.Latitude, tt.wantLat)
+			}
+		})
+	}
+
+	var missing *Gazetteer
+	if _, ok := missing.Lookup("Cape Town", "", ""); ok {
+		t.Errorf("nil gazetteer should never match")
+	}
+}
+
+func TestParseGazetteerRequiresColumns(t *testing.T) {
+	if _, err := ParseGazetteer(strings.NewReader("city,province\nCape Town,Western Cape\n")); err != ErrMissingColumns {
+		t.Errorf("err = %v, want ErrMissingColumns", err)
+	}
+}
+
+func TestDistanceKm(t *testing.T) {
+	// Cape Town to Johannesburg is roughly 1260 km as the crow flies
+	d := DistanceKm(-33.9249, 18.4241, -26.2041, 28.0473)
+	if math.Abs(d-1263) > 10 {
+		t.Errorf("DistanceKm() = %.0f, want about 1263", d)
+	}
+	if d := DistanceKm(-33.9, 18.4, -33.9, 18.4); d != 0 {
+		t.Errorf("DistanceKm() for same point = %v, want 0", d)
+	}
+}
@@ -0,0 +1,422 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"time"
+
+	"backend/internal/middleware"
+	"backend/internal/models"
+	"backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AcknowledgementHandler handles read-and-acknowledge requirements on
+// library documents
+type AcknowledgementHandler struct {
+	ackService *service.AcknowledgementService
+}
+
+// NewAcknowledgementHandler creates a new AcknowledgementHandler
+func NewAcknowledgementHandler(ackService *service.AcknowledgementService) *AcknowledgementHandler {
+	return &AcknowledgementHandler{
+		ackService: ackService,
+	}
+}
+
+// acknowledgementErrorStatus maps acknowledgement errors to HTTP statuses,
+// falling back to the library mapping
+func acknowledgementErrorStatus(err error, fallback int) int {
+	switch err {
+	case service.ErrRequirementNotFound:
+		return http.StatusNotFound
+	case service.ErrNotInAudience:
+		return http.StatusForbidden
+	case service.ErrDuplicateRequirement, service.ErrRevisionOutdated:
+		return http.StatusConflict
+	case service.ErrDropboxNotConfigured:
+		return http.StatusServiceUnavailable
+	case service.ErrIncompleteSMTPConfig, models.ErrInvalidAcknowledgementPath, models.ErrInvalidAcknowledgementRole:
+		return http.StatusBadRequest
+	}
+	return libraryErrorStatus(err, fallback)
+}
+
+// optionalObjectID parses an optional ObjectID query parameter
+func optionalObjectID(c *gin.Context, name string) (*primitive.ObjectID, error) {
+	value := c.Query(name)
+	if value == "" {
+		return nil, nil
+	}
+	id, err := primitive.ObjectIDFromHex(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s ID", name)
+	}
+	return &id, nil
+}
+
+// CreateRequirement godoc
+// @Summary Require acknowledgement of a document
+// @Description Mark a file in a category as one its audience must read and acknowledge, starting at its current revision (requires the library's manage permission)
+// @Tags acknowledgements
+// @Accept json
+// @Produce json
+// @Param library path string true "Library slug"
+// @Param id path string true "Category ID"
+// @Param request body models.CreateAcknowledgementRequirementRequest true "Document path and audience"
+// @Success 201 {object} models.AcknowledgementRequirement
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /libraries/{library}/categories/{id}/acknowledgements [post]
+// @Security BearerAuth
+func (h *AcknowledgementHandler) CreateRequirement(c *gin.Context) {
+	categoryID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid category ID"})
+		return
+	}
+
+	var req models.CreateAcknowledgementRequirementRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	requirement, err := h.ackService.CreateRequirement(c.Request.Context(), librarySlug(c), categoryID, &req, user, middleware.GetIPAddress(c))
+	if err != nil {
+		c.JSON(acknowledgementErrorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, requirement)
+}
+
+// ListRequirements godoc
+// @Summary List acknowledgement requirements
+// @Description List a library's acknowledgement requirements (requires the library's manage permission)
+// @Tags acknowledgements
+// @Produce json
+// @Param library path string true "Library slug"
+// @Param category query string false "Only requirements in this category"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /libraries/{library}/acknowledgements [get]
+// @Security BearerAuth
+func (h *AcknowledgementHandler) ListRequirements(c *gin.Context) {
+	categoryID, err := optionalObjectID(c, "category")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	requirements, err := h.ackService.ListRequirements(c.Request.Context(), librarySlug(c), categoryID, user)
+	if err != nil {
+		c.JSON(acknowledgementErrorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"requirements": requirements,
+		"count":        len(requirements),
+	})
+}
+
+// UpdateRequirement godoc
+// @Summary Update an acknowledgement requirement
+// @Description Change a requirement's audience or deactivate it (requires the library's manage permission)
+// @Tags acknowledgements
+// @Accept json
+// @Produce json
+// @Param library path string true "Library slug"
+// @Param requirementId path string true "Requirement ID"
+// @Param request body models.UpdateAcknowledgementRequirementRequest true "Fields to update"
+// @Success 200 {object} models.AcknowledgementRequirement
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /libraries/{library}/acknowledgements/{requirementId} [put]
+// @Security BearerAuth
+func (h *AcknowledgementHandler) UpdateRequirement(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("requirementId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid requirement ID"})
+		return
+	}
+
+	var req models.UpdateAcknowledgementRequirementRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	requirement, err := h.ackService.UpdateRequirement(c.Request.Context(), librarySlug(c), id, &req, user, middleware.GetIPAddress(c))
+	if err != nil {
+		c.JSON(acknowledgementErrorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, requirement)
+}
+
+// DeleteRequirement godoc
+// @Summary Delete an acknowledgement requirement
+// @Description Remove a requirement and the acknowledgements recorded against it (requires the library's manage permission)
+// @Tags acknowledgements
+// @Produce json
+// @Param library path string true "Library slug"
+// @Param requirementId path string true "Requirement ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /libraries/{library}/acknowledgements/{requirementId} [delete]
+// @Security BearerAuth
+func (h *AcknowledgementHandler) DeleteRequirement(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("requirementId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid requirement ID"})
+		return
+	}
+
+	user, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	if err := h.ackService.DeleteRequirement(c.Request.Context(), librarySlug(c), id, user, middleware.GetIPAddress(c)); err != nil {
+		c.JSON(acknowledgementErrorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "requirement deleted successfully"})
+}
+
+// Acknowledge godoc
+// @Summary Acknowledge a document
+// @Description Record that the current user has read and understood the given revision of a document. Only the current revision can be acknowledged.
+// @Tags acknowledgements
+// @Accept json
+// @Produce json
+// @Param library path string true "Library slug"
+// @Param requirementId path string true "Requirement ID"
+// @Param request body models.AcknowledgeRequest true "Revision read"
+// @Success 200 {object} models.DocumentAcknowledgement
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /libraries/{library}/acknowledgements/{requirementId}/acknowledge [post]
+// @Security BearerAuth
+func (h *AcknowledgementHandler) Acknowledge(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("requirementId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid requirement ID"})
+		return
+	}
+
+	var req models.AcknowledgeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	ack, err := h.ackService.Acknowledge(c.Request.Context(), librarySlug(c), id, req.Rev, user, middleware.GetIPAddress(c))
+	if err != nil {
+		c.JSON(acknowledgementErrorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, ack)
+}
+
+// GetReport godoc
+// @Summary Get the acknowledgement compliance report
+// @Description Per-user acknowledgement status of a library's documents with totals per institution, as JSON or CSV (requires the library's manage permission)
+// @Tags acknowledgements
+// @Produce json,text/csv
+// @Param library path string true "Library slug"
+// @Param requirement query string false "Only this requirement"
+// @Param institution query string false "Only users of this institution"
+// @Param format query string false "json (default) or csv"
+// @Success 200 {object} models.AcknowledgementReport
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /libraries/{library}/acknowledgements/report [get]
+// @Security BearerAuth
+func (h *AcknowledgementHandler) GetReport(c *gin.Context) {
+	filter, ok := reportFilter(c)
+	if !ok {
+		return
+	}
+
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "csv" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be json or csv"})
+		return
+	}
+
+	user, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	slug := librarySlug(c)
+	report, err := h.ackService.Report(c.Request.Context(), slug, filter, user)
+	if err != nil {
+		c.JSON(acknowledgementErrorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	if format == "csv" {
+		var buf bytes.Buffer
+		if err := models.WriteAcknowledgementCSV(&buf, report.Rows); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		filename := fmt.Sprintf("%s-acknowledgements-%s.csv", slug, report.GeneratedAt.Format("2006-01-02"))
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+		c.Data(http.StatusOK, "text/csv; charset=utf-8", buf.Bytes())
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// SendReminders godoc
+// @Summary Send acknowledgement reminders
+// @Description Email each user with outstanding acknowledgements in the library one reminder listing their outstanding documents (requires the library's manage permission and complete SMTP settings)
+// @Tags acknowledgements
+// @Produce json
+// @Param library path string true "Library slug"
+// @Param requirement query string false "Only this requirement"
+// @Param institution query string false "Only users of this institution"
+// @Success 200 {object} service.AcknowledgementReminderResult
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /libraries/{library}/acknowledgements/reminders [post]
+// @Security BearerAuth
+func (h *AcknowledgementHandler) SendReminders(c *gin.Context) {
+	filter, ok := reportFilter(c)
+	if !ok {
+		return
+	}
+
+	user, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	result, err := h.ackService.SendReminders(c.Request.Context(), librarySlug(c), filter, user, middleware.GetIPAddress(c))
+	if err != nil {
+		c.JSON(acknowledgementErrorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// GetMine godoc
+// @Summary List my acknowledgements
+// @Description List the documents the current user must acknowledge across the libraries they can see, outstanding first
+// @Tags acknowledgements
+// @Produce json
+// @Param library query string false "Restrict to one library slug"
+// @Param status query string false "acknowledged or outstanding"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Router /acknowledgements [get]
+// @Security BearerAuth
+func (h *AcknowledgementHandler) GetMine(c *gin.Context) {
+	status := c.Query("status")
+	if status != "" && status != models.AcknowledgementStatusAcknowledged && status != models.AcknowledgementStatusOutstanding {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be acknowledged or outstanding"})
+		return
+	}
+
+	user, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	items, err := h.ackService.MyAcknowledgements(c.Request.Context(), user, c.Query("library"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	outstanding := 0
+	filtered := make([]models.AcknowledgementItem, 0, len(items))
+	for _, item := range items {
+		if item.Status == models.AcknowledgementStatusOutstanding {
+			outstanding++
+		}
+		if status == "" || item.Status == status {
+			filtered = append(filtered, item)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"items":       filtered,
+		"count":       len(filtered),
+		"outstanding": outstanding,
+		"checkedAt":   time.Now(),
+	})
+}
+
+// reportFilter reads the report filter query parameters, replying with 400
+// when one is invalid
+func reportFilter(c *gin.Context) (service.AcknowledgementReportFilter, bool) {
+	var filter service.AcknowledgementReportFilter
+	var err error
+	if filter.RequirementID, err = optionalObjectID(c, "requirement"); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return filter, false
+	}
+	if filter.InstitutionID, err = optionalObjectID(c, "institution"); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return filter, false
+	}
+	return filter, true
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"net/http"
+
+	"backend/internal/middleware"
+	"backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AlertHandler handles the notices shown in the in-app banner
+type AlertHandler struct {
+	dropboxHealthService *service.DropboxHealthService
+}
+
+// NewAlertHandler creates a new AlertHandler
+func NewAlertHandler(dropboxHealthService *service.DropboxHealthService) *AlertHandler {
+	return &AlertHandler{
+		dropboxHealthService: dropboxHealthService,
+	}
+}
+
+// GetAlerts godoc
+// @Summary Get system alerts
+// @Description Get the notices to show the current user in the in-app banner, such as a degraded Dropbox connection. System admins also see the error and a link to the status page.
+// @Tags alerts
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /alerts [get]
+// @Security BearerAuth
+func (h *AlertHandler) GetAlerts(c *gin.Context) {
+	user, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	alerts, err := h.dropboxHealthService.Alerts(c.Request.Context(), user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"alerts": alerts})
+}
@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"net/http"
+
+	"backend/internal/middleware"
+	"backend/internal/models"
+	"backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CategoryReconciliationHandler handles the report of categories that no
+// longer match their Dropbox folders
+type CategoryReconciliationHandler struct {
+	reconciliationService *service.CategoryReconciliationService
+}
+
+// NewCategoryReconciliationHandler creates a new CategoryReconciliationHandler
+func NewCategoryReconciliationHandler(reconciliationService *service.CategoryReconciliationService) *CategoryReconciliationHandler {
+	return &CategoryReconciliationHandler{
+		reconciliationService: reconciliationService,
+	}
+}
+
+// reconciliationErrorStatus maps reconciliation errors to HTTP statuses
+func reconciliationErrorStatus(err error) int {
+	switch err {
+	case service.ErrLibraryNotFound, service.ErrCategoryNotFound, service.ErrReconciliationFolderNotFound:
+		return http.StatusNotFound
+	case service.ErrFolderAlreadyLinked, service.ErrDuplicateSlug:
+		return http.StatusConflict
+	case service.ErrReconciliationNotDropbox:
+		return http.StatusBadRequest
+	case service.ErrDropboxNotConfigured:
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
+
+// GetReports godoc
+// @Summary Get category reconciliation reports
+// @Description Get the latest report of each Dropbox library: categories whose folders are missing, folders renamed in Dropbox, and folders with no category
+// @Tags admin-reconciliation
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/reconciliation [get]
+// @Security BearerAuth
+func (h *CategoryReconciliationHandler) GetReports(c *gin.Context) {
+	reports, err := h.reconciliationService.Reports(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"reports": reports})
+}
+
+// Run godoc
+// @Summary Reconcile categories now
+// @Description Compare every Dropbox library's categories with its folders now instead of waiting for the next scheduled run
+// @Tags admin-reconciliation
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 503 {object} map[string]string
+// @Router /admin/reconciliation/run [post]
+// @Security BearerAuth
+func (h *CategoryReconciliationHandler) Run(c *gin.Context) {
+	reports, err := h.reconciliationService.Run(c.Request.Context())
+	if err != nil {
+		c.JSON(reconciliationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"reports": reports})
+}
+
+// Fix godoc
+// @Summary Fix a reconciliation issue
+// @Description Re-link a category to a folder (relink: categoryId and folderId), create a category for a folder (create: folderId), or archive a category (archive: categoryId). Returns the library's updated report.
+// @Tags admin-reconciliation
+// @Accept json
+// @Produce json
+// @Param request body models.ReconciliationFixRequest true "Fix to apply"
+// @Success 200 {object} models.CategoryReconciliation
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string "Folder already linked, or a category with its name exists"
+// @Router /admin/reconciliation/fix [post]
+// @Security BearerAuth
+func (h *CategoryReconciliationHandler) Fix(c *gin.Context) {
+	user, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var req models.ReconciliationFixRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	report, err := h.reconciliationService.Fix(c.Request.Context(), &req, user, middleware.GetIPAddress(c))
+	if err != nil {
+		c.JSON(reconciliationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"backend/internal/middleware"
+	"backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DocumentFeedHandler handles the recently updated documents feed
+type DocumentFeedHandler struct {
+	revisionService *service.DocumentRevisionService
+}
+
+// NewDocumentFeedHandler creates a new DocumentFeedHandler
+func NewDocumentFeedHandler(revisionService *service.DocumentRevisionService) *DocumentFeedHandler {
+	return &DocumentFeedHandler{
+		revisionService: revisionService,
+	}
+}
+
+// GetRecent godoc
+// @Summary Get recently updated documents
+// @Description List files recently added or updated across the libraries the user can see, marking those changed since the user's last visit
+// @Tags documents
+// @Produce json
+// @Param library query string false "Restrict the feed to one library slug"
+// @Param limit query int false "Maximum items (max 100)" default(20)
+// @Success 200 {object} models.DocumentFeed
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Router /documents/recent [get]
+// @Security BearerAuth
+func (h *DocumentFeedHandler) GetRecent(c *gin.Context) {
+	user, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	limit := service.DefaultFeedLimit
+	if value := c.Query("limit"); value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive integer"})
+			return
+		}
+	}
+
+	feed, err := h.revisionService.Feed(c.Request.Context(), user, c.Query("library"), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, feed)
+}
+
+// MarkVisited godoc
+// @Summary Mark the recently updated feed as visited
+// @Description Record that the user has seen the feed, so later changes are marked as new
+// @Tags documents
+// @Produce json
+// @Success 200 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Router /documents/recent/visit [post]
+// @Security BearerAuth
+func (h *DocumentFeedHandler) MarkVisited(c *gin.Context) {
+	user, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	if err := h.revisionService.MarkFeedVisited(c.Request.Context(), user); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "feed marked as visited"})
+}
@@ -0,0 +1,307 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"backend/internal/middleware"
+	"backend/internal/models"
+	"backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// maxDocumentRequestSize bounds an upload request: the file plus room for
+// the multipart envelope and form fields
+const maxDocumentRequestSize = models.MaxDocumentUploadSize + 1024*1024
+
+// documentUploadTimeout replaces the server's read and write timeouts for
+// uploads, which take longer to receive and to pass on to storage
+const documentUploadTimeout = 10 * time.Minute
+
+// documentArchiveTimeout replaces the server's write timeout for ZIP
+// downloads, which stream for as long as the files take to fetch and send
+const documentArchiveTimeout = 30 * time.Minute
+
+// DocumentFileHandler handles uploading and organising the files of library
+// categories
+type DocumentFileHandler struct {
+	fileService *service.DocumentFileService
+}
+
+// NewDocumentFileHandler creates a new DocumentFileHandler
+func NewDocumentFileHandler(fileService *service.DocumentFileService) *DocumentFileHandler {
+	return &DocumentFileHandler{
+		fileService: fileService,
+	}
+}
+
+// documentFileErrorStatus maps file operation errors to HTTP statuses,
+// falling back to the library mapping
+func documentFileErrorStatus(err error) int {
+	switch err {
+	case models.ErrInvalidDocumentPath, models.ErrInvalidDocumentName, models.ErrUnsupportedDocumentType,
+		models.ErrEmptyDocument, models.ErrInvalidConflictPolicy:
+		return http.StatusBadRequest
+	case models.ErrDocumentTooLarge, models.ErrArchiveTooLarge:
+		return http.StatusRequestEntityTooLarge
+	case models.ErrArchiveEmpty:
+		return http.StatusNotFound
+	case service.ErrFileExists:
+		return http.StatusConflict
+	}
+	return libraryErrorStatus(err, http.StatusInternalServerError)
+}
+
+// UploadFile godoc
+// @Summary Upload a file to a library category
+// @Description Upload a document to a category folder or subfolder (requires the library's manage permission). Allowed types are PDF, Office and OpenDocument files, text, CSV and JPEG/PNG images, up to 50MB.
+// @Tags libraries
+// @Accept multipart/form-data
+// @Produce json
+// @Param library path string true "Library slug"
+// @Param id path string true "Category ID"
+// @Param file formData file true "File to upload"
+// @Param folder formData string false "Subfolder within the category folder"
+// @Param conflict formData string false "If a file with the name exists: reject (default), rename to keep both, or replace to store a new revision"
+// @Success 201 {object} service.DropboxFileInfo
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 413 {object} map[string]string
+// @Router /libraries/{library}/categories/{id}/files [post]
+// @Security BearerAuth
+func (h *DocumentFileHandler) UploadFile(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid category ID"})
+		return
+	}
+
+	user, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	deadline := time.Now().Add(documentUploadTimeout)
+	controller := http.NewResponseController(c.Writer)
+	_ = controller.SetReadDeadline(deadline)
+	_ = controller.SetWriteDeadline(deadline)
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxDocumentRequestSize)
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file is required and must be smaller than 50MB"})
+		return
+	}
+
+	conflict, err := models.ParseUploadConflictPolicy(c.PostForm("conflict"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	content, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read file"})
+		return
+	}
+	defer content.Close()
+
+	info, err := h.fileService.Upload(c.Request.Context(), librarySlug(c), id, service.DocumentUpload{
+		Folder:   c.PostForm("folder"),
+		Name:     file.Filename,
+		Size:     file.Size,
+		Content:  content,
+		Conflict: conflict,
+	}, user, middleware.GetIPAddress(c))
+	if err != nil {
+		c.JSON(documentFileErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, info)
+}
+
+// CreateFolder godoc
+// @Summary Create a subfolder in a library category
+// @Description Create a subfolder, and any missing parents, in a category folder (requires the library's manage permission)
+// @Tags libraries
+// @Accept json
+// @Produce json
+// @Param library path string true "Library slug"
+// @Param id path string true "Category ID"
+// @Param request body models.CreateDocumentFolderRequest true "Folder path"
+// @Success 201 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /libraries/{library}/categories/{id}/folders [post]
+// @Security BearerAuth
+func (h *DocumentFileHandler) CreateFolder(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid category ID"})
+		return
+	}
+
+	var req models.CreateDocumentFolderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	if err := h.fileService.CreateFolder(c.Request.Context(), librarySlug(c), id, req.Path, user, middleware.GetIPAddress(c)); err != nil {
+		c.JSON(documentFileErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "folder created successfully"})
+}
+
+// DeleteFile godoc
+// @Summary Delete a file or subfolder from a library category
+// @Description Delete a file, or a subfolder with everything in it (requires the library's manage permission). Dropbox keeps deleted files restorable for its retention period; the local and S3 drivers delete them outright.
+// @Tags libraries
+// @Produce json
+// @Param library path string true "Library slug"
+// @Param id path string true "Category ID"
+// @Param path query string true "File or subfolder path within the category folder"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /libraries/{library}/categories/{id}/files [delete]
+// @Security BearerAuth
+func (h *DocumentFileHandler) DeleteFile(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid category ID"})
+		return
+	}
+
+	filePath := c.Query("path")
+	if filePath == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file path is required"})
+		return
+	}
+
+	user, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	if err := h.fileService.Delete(c.Request.Context(), librarySlug(c), id, filePath, user, middleware.GetIPAddress(c)); err != nil {
+		c.JSON(documentFileErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "deleted successfully"})
+}
+
+// MoveFile godoc
+// @Summary Move or rename a file or subfolder in a library category
+// @Description Move or rename a file or subfolder within a category folder (requires the library's manage permission). Fails if the destination exists.
+// @Tags libraries
+// @Accept json
+// @Produce json
+// @Param library path string true "Library slug"
+// @Param id path string true "Category ID"
+// @Param request body models.MoveDocumentRequest true "Source and destination paths"
+// @Success 200 {object} service.DropboxFileInfo
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /libraries/{library}/categories/{id}/files/move [post]
+// @Security BearerAuth
+func (h *DocumentFileHandler) MoveFile(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid category ID"})
+		return
+	}
+
+	var req models.MoveDocumentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	info, err := h.fileService.Move(c.Request.Context(), librarySlug(c), id, &req, user, middleware.GetIPAddress(c))
+	if err != nil {
+		c.JSON(documentFileErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, info)
+}
+
+// DownloadArchive godoc
+// @Summary Download a library category or subfolder as a ZIP
+// @Description Stream the files of a category folder, or one of its subfolders, as a ZIP archive keeping the folder structure (requires the library's download permission). Folders of more than 2000 files or 1GB are refused.
+// @Tags libraries
+// @Produce application/zip
+// @Param library path string true "Library slug"
+// @Param id path string true "Category ID"
+// @Param path query string false "Subfolder within the category folder; the whole category if omitted"
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 413 {object} map[string]string
+// @Router /libraries/{library}/categories/{id}/files/archive [get]
+// @Security BearerAuth
+func (h *DocumentFileHandler) DownloadArchive(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid category ID"})
+		return
+	}
+
+	user, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	archive, err := h.fileService.PrepareArchive(c.Request.Context(), librarySlug(c), id, c.Query("path"), user)
+	if err != nil {
+		c.JSON(documentFileErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	_ = http.NewResponseController(c.Writer).SetWriteDeadline(time.Now().Add(documentArchiveTimeout))
+
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", archive.Name))
+	c.Status(http.StatusOK)
+
+	// Once streaming has started the status cannot change; a failed download
+	// ends without the ZIP's central directory, so clients see it as corrupt
+	if err := h.fileService.WriteArchive(c.Request.Context(), archive, c.Writer, user, middleware.GetIPAddress(c)); err != nil {
+		fmt.Printf("Warning: ZIP download of %s failed: %v\n", archive.Name, err)
+	}
+}
@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"net/http"
+
+	"backend/internal/middleware"
+	"backend/internal/models"
+	"backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DocumentReviewHandler handles document ownership, review cadence and the
+// overdue review report
+type DocumentReviewHandler struct {
+	reviewService *service.DocumentReviewService
+}
+
+// NewDocumentReviewHandler creates a new DocumentReviewHandler
+func NewDocumentReviewHandler(reviewService *service.DocumentReviewService) *DocumentReviewHandler {
+	return &DocumentReviewHandler{
+		reviewService: reviewService,
+	}
+}
+
+// documentReviewErrorStatus maps document review errors to HTTP statuses,
+// falling back to the library mapping
+func documentReviewErrorStatus(err error, fallback int) int {
+	switch err {
+	case service.ErrInvalidDocumentOwner, models.ErrInvalidDocumentStatus, models.ErrInvalidReviewInterval:
+		return http.StatusBadRequest
+	}
+	return libraryErrorStatus(err, fallback)
+}
+
+// GetMetadata godoc
+// @Summary Get document review metadata
+// @Description Get the status, owner and review dates of a file in a category
+// @Tags libraries
+// @Produce json
+// @Param library path string true "Library slug"
+// @Param id path string true "Category ID"
+// @Param path query string true "File path within the category folder"
+// @Success 200 {object} models.DocumentMetadata
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /libraries/{library}/categories/{id}/files/metadata [get]
+// @Security BearerAuth
+func (h *DocumentReviewHandler) GetMetadata(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid category ID"})
+		return
+	}
+
+	filePath := c.Query("path")
+	if filePath == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file path is required"})
+		return
+	}
+
+	user, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	metadata, err := h.reviewService.GetMetadata(c.Request.Context(), librarySlug(c), id, filePath, user)
+	if err != nil {
+		c.JSON(documentReviewErrorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, metadata)
+}
+
+// UpdateMetadata godoc
+// @Summary Update document review metadata
+// @Description Change the status, owner or review interval of a file, or record a review (requires the library's manage permission)
+// @Tags libraries
+// @Accept json
+// @Produce json
+// @Param library path string true "Library slug"
+// @Param id path string true "Category ID"
+// @Param path query string true "File path within the category folder"
+// @Param request body models.UpdateDocumentMetadataRequest true "Fields to update"
+// @Success 200 {object} models.DocumentMetadata
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /libraries/{library}/categories/{id}/files/metadata [put]
+// @Security BearerAuth
+func (h *DocumentReviewHandler) UpdateMetadata(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid category ID"})
+		return
+	}
+
+	filePath := c.Query("path")
+	if filePath == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file path is required"})
+		return
+	}
+
+	var req models.UpdateDocumentMetadataRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	metadata, err := h.reviewService.UpdateMetadata(c.Request.Context(), librarySlug(c), id, filePath, &req, user, middleware.GetIPAddress(c))
+	if err != nil {
+		c.JSON(documentReviewErrorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, metadata)
+}
+
+// GetOverdueReport godoc
+// @Summary Get documents overdue for review
+// @Description List documents across all libraries whose review date has passed, most overdue first (super admin only)
+// @Tags admin
+// @Produce json
+// @Param dueSoon query bool false "Also list documents due within the notice period"
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /admin/documents/reviews [get]
+// @Security BearerAuth
+func (h *DocumentReviewHandler) GetOverdueReport(c *gin.Context) {
+	items, err := h.reviewService.OverdueReport(c.Request.Context(), c.Query("dueSoon") == "true")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	overdue := 0
+	for _, item := range items {
+		if item.ReviewState == models.ReviewStateOverdue {
+			overdue++
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"documents": items,
+		"count":     len(items),
+		"overdue":   overdue,
+	})
+}
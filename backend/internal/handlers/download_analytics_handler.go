@@ -0,0 +1,351 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"backend/internal/middleware"
+	"backend/internal/models"
+	"backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DownloadAnalyticsHandler serves download analytics of library documents
+type DownloadAnalyticsHandler struct {
+	analyticsService *service.DownloadAnalyticsService
+}
+
+// NewDownloadAnalyticsHandler creates a new DownloadAnalyticsHandler
+func NewDownloadAnalyticsHandler(analyticsService *service.DownloadAnalyticsService) *DownloadAnalyticsHandler {
+	return &DownloadAnalyticsHandler{
+		analyticsService: analyticsService,
+	}
+}
+
+// analyticsErrorStatus maps analytics errors to HTTP statuses
+func analyticsErrorStatus(err error) int {
+	switch err {
+	case models.ErrInvalidDownloadRetention:
+		return http.StatusBadRequest
+	}
+	return libraryErrorStatus(err, http.StatusInternalServerError)
+}
+
+// analyticsQuery parses the query parameters shared by the analytics
+// reports, replying with an error if they are invalid
+func analyticsQuery(c *gin.Context) (models.DownloadAnalyticsQuery, string, bool) {
+	query, err := models.ParseDownloadAnalyticsQuery(
+		c.Query("library"), c.Query("from"), c.Query("to"), c.Query("interval"), c.Query("limit"), time.Now(),
+	)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return query, "", false
+	}
+
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "csv" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be json or csv"})
+		return query, "", false
+	}
+	return query, format, true
+}
+
+// writeAnalyticsCSV replies with a report as a CSV attachment
+func writeAnalyticsCSV(c *gin.Context, report string, query models.DownloadAnalyticsQuery, write func(io.Writer) error) {
+	var buf bytes.Buffer
+	if err := write(&buf); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	scope := "all"
+	if query.LibrarySlug != "" {
+		scope = query.LibrarySlug
+	}
+	filename := fmt.Sprintf("%s-%s-%s.csv", scope, report, query.To.Format("2006-01-02"))
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Data(http.StatusOK, "text/csv; charset=utf-8", buf.Bytes())
+}
+
+// GetTopDocuments godoc
+// @Summary Get the most downloaded documents
+// @Description Documents with the most downloads in the range, with the number of distinct users, as JSON or CSV (super admin only)
+// @Tags admin
+// @Produce json,text/csv
+// @Param library query string false "Only this library"
+// @Param from query string false "Start date (YYYY-MM-DD) or time; defaults to 90 days before to"
+// @Param to query string false "End date, inclusive, or time; defaults to now"
+// @Param limit query int false "Number of documents (1-100, default 20)"
+// @Param format query string false "json (default) or csv"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /admin/analytics/downloads/top [get]
+// @Security BearerAuth
+func (h *DownloadAnalyticsHandler) GetTopDocuments(c *gin.Context) {
+	query, format, ok := analyticsQuery(c)
+	if !ok {
+		return
+	}
+
+	documents, err := h.analyticsService.TopDocuments(c.Request.Context(), query)
+	if err != nil {
+		c.JSON(analyticsErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	if format == "csv" {
+		writeAnalyticsCSV(c, "top-documents", query, func(w io.Writer) error {
+			return models.WriteTopDocumentsCSV(w, documents)
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"documents": documents,
+		"from":      query.From,
+		"to":        query.To,
+	})
+}
+
+// GetDownloadsOverTime godoc
+// @Summary Get downloads over time
+// @Description Downloads and distinct users per day, week or month (UTC, weeks from Monday) in the range, as JSON or CSV (super admin only)
+// @Tags admin
+// @Produce json,text/csv
+// @Param library query string false "Only this library"
+// @Param from query string false "Start date (YYYY-MM-DD) or time; defaults to 90 days before to"
+// @Param to query string false "End date, inclusive, or time; defaults to now"
+// @Param interval query string false "day (default), week or month"
+// @Param format query string false "json (default) or csv"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /admin/analytics/downloads/timeline [get]
+// @Security BearerAuth
+func (h *DownloadAnalyticsHandler) GetDownloadsOverTime(c *gin.Context) {
+	query, format, ok := analyticsQuery(c)
+	if !ok {
+		return
+	}
+
+	periods, err := h.analyticsService.DownloadsOverTime(c.Request.Context(), query)
+	if err != nil {
+		c.JSON(analyticsErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	if format == "csv" {
+		writeAnalyticsCSV(c, "downloads-by-"+string(query.Interval), query, func(w io.Writer) error {
+			return models.WriteDownloadPeriodsCSV(w, periods)
+		})
+		return
+	}
+
+	var total int64
+	for _, period := range periods {
+		total += period.Downloads
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"periods":  periods,
+		"interval": query.Interval,
+		"total":    total,
+		"from":     query.From,
+		"to":       query.To,
+	})
+}
+
+// GetDownloadsByInstitution godoc
+// @Summary Get downloads by institution
+// @Description Downloads and distinct users per institution of the downloading users in the range, as JSON or CSV (super admin only)
+// @Tags admin
+// @Produce json,text/csv
+// @Param library query string false "Only this library"
+// @Param from query string false "Start date (YYYY-MM-DD) or time; defaults to 90 days before to"
+// @Param to query string false "End date, inclusive, or time; defaults to now"
+// @Param format query string false "json (default) or csv"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /admin/analytics/downloads/institutions [get]
+// @Security BearerAuth
+func (h *DownloadAnalyticsHandler) GetDownloadsByInstitution(c *gin.Context) {
+	query, format, ok := analyticsQuery(c)
+	if !ok {
+		return
+	}
+
+	institutions, err := h.analyticsService.DownloadsByInstitution(c.Request.Context(), query)
+	if err != nil {
+		c.JSON(analyticsErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	if format == "csv" {
+		writeAnalyticsCSV(c, "downloads-by-institution", query, func(w io.Writer) error {
+			return models.WriteDownloadGroupsCSV(w, "Institution", institutions)
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"institutions": institutions,
+		"from":         query.From,
+		"to":           query.To,
+	})
+}
+
+// GetDownloadsBySpecialty godoc
+// @Summary Get downloads by specialty
+// @Description Downloads and distinct users per specialty of the downloading users in the range, as JSON or CSV (super admin only)
+// @Tags admin
+// @Produce json,text/csv
+// @Param library query string false "Only this library"
+// @Param from query string false "Start date (YYYY-MM-DD) or time; defaults to 90 days before to"
+// @Param to query string false "End date, inclusive, or time; defaults to now"
+// @Param format query string false "json (default) or csv"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /admin/analytics/downloads/specialties [get]
+// @Security BearerAuth
+func (h *DownloadAnalyticsHandler) GetDownloadsBySpecialty(c *gin.Context) {
+	query, format, ok := analyticsQuery(c)
+	if !ok {
+		return
+	}
+
+	specialties, err := h.analyticsService.DownloadsBySpecialty(c.Request.Context(), query)
+	if err != nil {
+		c.JSON(analyticsErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	if format == "csv" {
+		writeAnalyticsCSV(c, "downloads-by-specialty", query, func(w io.Writer) error {
+			return models.WriteDownloadGroupsCSV(w, "Specialty", specialties)
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"specialties": specialties,
+		"from":        query.From,
+		"to":          query.To,
+	})
+}
+
+// GetNeverDownloaded godoc
+// @Summary Get documents nobody downloaded
+// @Description Documents in active categories without a download, least recently modified first, as JSON or CSV. Without from, every retained download counts (super admin only).
+// @Tags admin
+// @Produce json,text/csv
+// @Param library query string false "Only this library"
+// @Param from query string false "Only count downloads since this date (YYYY-MM-DD) or time"
+// @Param to query string false "Only count downloads up to this date, inclusive, or time"
+// @Param format query string false "json (default) or csv"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /admin/analytics/downloads/never [get]
+// @Security BearerAuth
+func (h *DownloadAnalyticsHandler) GetNeverDownloaded(c *gin.Context) {
+	query, format, ok := analyticsQuery(c)
+	if !ok {
+		return
+	}
+	if c.Query("from") == "" {
+		query.From = time.Time{}
+	}
+
+	documents, err := h.analyticsService.NeverDownloaded(c.Request.Context(), query)
+	if err != nil {
+		c.JSON(analyticsErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	if format == "csv" {
+		writeAnalyticsCSV(c, "never-downloaded", query, func(w io.Writer) error {
+			return models.WriteUndownloadedDocumentsCSV(w, documents)
+		})
+		return
+	}
+
+	response := gin.H{
+		"documents": documents,
+		"count":     len(documents),
+		"to":        query.To,
+	}
+	if !query.From.IsZero() {
+		response["from"] = query.From
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// GetSettings godoc
+// @Summary Get analytics settings
+// @Description Get how long download events are kept (super admin only)
+// @Tags admin
+// @Produce json
+// @Success 200 {object} models.AnalyticsSettings
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /admin/analytics/settings [get]
+// @Security BearerAuth
+func (h *DownloadAnalyticsHandler) GetSettings(c *gin.Context) {
+	settings, err := h.analyticsService.GetSettings(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+// UpdateSettings godoc
+// @Summary Update analytics settings
+// @Description Set how long download events are kept, from 30 to 3650 days. Shortening it removes older events straight away (super admin only).
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body models.UpdateAnalyticsSettingsRequest true "Analytics settings"
+// @Success 200 {object} models.AnalyticsSettings
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /admin/analytics/settings [put]
+// @Security BearerAuth
+func (h *DownloadAnalyticsHandler) UpdateSettings(c *gin.Context) {
+	var req models.UpdateAnalyticsSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	settings, err := h.analyticsService.UpdateSettings(c.Request.Context(), &req, userID, middleware.GetIPAddress(c))
+	if err != nil {
+		c.JSON(analyticsErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
@@ -8,6 +8,7 @@ import (
 	"backend/internal/service"
 
 	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 // DropboxAdminHandler handles admin operations for Dropbox configuration
@@ -22,9 +23,38 @@ func NewDropboxAdminHandler(oauthService *service.DropboxOAuthService) *DropboxA
 	}
 }
 
+// connectionParam reads the optional connection query parameter, a Dropbox
+// connection ID; without it the default connection is meant. It writes a
+// 400 response and returns false when the ID is invalid.
+func connectionParam(c *gin.Context) (*primitive.ObjectID, bool) {
+	value := c.Query("connection")
+	if value == "" {
+		return nil, true
+	}
+	id, err := primitive.ObjectIDFromHex(value)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid connection ID"})
+		return nil, false
+	}
+	return &id, true
+}
+
+// dropboxConnectionErrorStatus maps Dropbox connection errors onto a status code
+func dropboxConnectionErrorStatus(err error, fallback int) int {
+	switch err {
+	case service.ErrDropboxConnectionNotFound:
+		return http.StatusNotFound
+	case service.ErrDropboxConnectionInUse, service.ErrDuplicateDropboxConnection:
+		return http.StatusConflict
+	case models.ErrInvalidDropboxConnectionName:
+		return http.StatusBadRequest
+	}
+	return fallback
+}
+
 // GetStatus godoc
 // @Summary Get Dropbox connection status
-// @Description Get the current status of the Dropbox connection
+// @Description Get the status of the default Dropbox connection, and of every connection under connections
 // @Tags admin-dropbox
 // @Produce json
 // @Success 200 {object} map[string]interface{}
@@ -55,25 +85,32 @@ func (h *DropboxAdminHandler) GetStatus(c *gin.Context) {
 	c.JSON(http.StatusOK, status)
 }
 
-// InitiateAuthRequest represents the request to initiate OAuth
+// InitiateAuthRequest represents the request to initiate OAuth. Leave
+// AppSecret empty to use PKCE, so that no app secret is stored. Send
+// ConnectionID to re-authorize a connection and Name alone to create one;
+// with neither the default connection is authorized.
 type InitiateAuthRequest struct {
 	AppKey       string `json:"appKey" binding:"required"`
-	AppSecret    string `json:"appSecret" binding:"required"`
+	AppSecret    string `json:"appSecret"`    // Optional - empty means PKCE
 	ParentFolder string `json:"parentFolder"` // Optional - empty means Dropbox root
 	RedirectURI  string `json:"redirectUri"`  // Optional
+
+	ConnectionID *primitive.ObjectID `json:"connectionId"` // Optional - the connection to re-authorize
+	Name         string              `json:"name"`         // Optional - the connection's name
 }
 
 // InitiateAuth godoc
 // @Summary Initiate Dropbox OAuth flow
-// @Description Generate the authorization URL for admin to visit
+// @Description Generate the authorization URL for admin to visit. Without an app secret the PKCE flow is used and no secret is stored. Send the returned state back with the code to complete the flow, from the same session. Send connectionId to re-authorize a connection, or only a name to create a new connection.
 // @Tags admin-dropbox
 // @Accept json
 // @Produce json
 // @Param request body InitiateAuthRequest true "OAuth configuration"
-// @Success 200 {object} map[string]string
+// @Success 200 {object} map[string]interface{}
 // @Failure 400 {object} map[string]string
 // @Failure 401 {object} map[string]string
 // @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
 // @Router /admin/dropbox/authorize [post]
 // @Security BearerAuth
 func (h *DropboxAdminHandler) InitiateAuth(c *gin.Context) {
@@ -95,43 +132,57 @@ func (h *DropboxAdminHandler) InitiateAuth(c *gin.Context) {
 		return
 	}
 
-	// Store credentials temporarily in session (or you could return them to be sent in callback)
-	// For simplicity, we'll include them in the state parameter
-	// In production, you might want to store them in Redis or session storage
-
-	authURL, err := h.oauthService.GenerateAuthorizationURL(req.AppKey, req.RedirectURI)
+	// The state is bound to this session, so the callback must come from it too
+	authorization, err := h.oauthService.StartAuthorization(
+		c.Request.Context(),
+		req.AppKey,
+		req.AppSecret,
+		req.RedirectURI,
+		req.ParentFolder,
+		req.ConnectionID,
+		req.Name,
+		user,
+		c.GetString("token"),
+	)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(dropboxConnectionErrorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"authUrl":      authURL,
+		"authUrl":      authorization.URL,
+		"state":        authorization.State,
+		"method":       authorization.Method,
+		"expiresAt":    authorization.ExpiresAt,
 		"message":      "Visit this URL to authorize Dropbox access",
-		"instructions": "After authorizing, you will receive a code. Use the callback endpoint to complete setup.",
+		"instructions": "After authorizing, you will receive a code. Send it with the state to the callback endpoint to complete setup.",
 	})
 }
 
-// CompleteAuthRequest represents the request to complete OAuth
+// CompleteAuthRequest represents the request to complete OAuth. Send the
+// state from InitiateAuth; without one, the app key and secret are
+// required and the code is exchanged as before PKCE was supported.
 type CompleteAuthRequest struct {
 	Code         string `json:"code" binding:"required"`
-	AppKey       string `json:"appKey" binding:"required"`
-	AppSecret    string `json:"appSecret" binding:"required"`
-	ParentFolder string `json:"parentFolder"` // Optional - empty means Dropbox root
-	RedirectURI  string `json:"redirectUri"`  // Must match the one used in authorization
+	State        string `json:"state"`
+	AppKey       string `json:"appKey"`       // Only without state
+	AppSecret    string `json:"appSecret"`    // Only without state
+	ParentFolder string `json:"parentFolder"` // Only without state - empty means Dropbox root
+	RedirectURI  string `json:"redirectUri"`  // Only without state - must match the one used in authorization
 }
 
 // CompleteAuth godoc
 // @Summary Complete Dropbox OAuth flow
-// @Description Exchange authorization code for tokens and save configuration
+// @Description Exchange authorization code for tokens and save configuration. The state must come from an authorization this admin started in the same session, within 15 minutes, and can be used once.
 // @Tags admin-dropbox
 // @Accept json
 // @Produce json
-// @Param request body CompleteAuthRequest true "Authorization code and configuration"
+// @Param request body CompleteAuthRequest true "Authorization code and state"
 // @Success 200 {object} map[string]interface{}
 // @Failure 400 {object} map[string]string
 // @Failure 401 {object} map[string]string
 // @Failure 403 {object} map[string]string
+// @Failure 409 {object} map[string]string "A connection with the name exists"
 // @Failure 500 {object} map[string]string
 // @Router /admin/dropbox/callback [post]
 // @Security BearerAuth
@@ -156,18 +207,35 @@ func (h *DropboxAdminHandler) CompleteAuth(c *gin.Context) {
 
 	ipAddress := middleware.GetIPAddress(c)
 
-	config, err := h.oauthService.ExchangeCodeForTokens(
-		c.Request.Context(),
-		req.Code,
-		req.AppKey,
-		req.AppSecret,
-		req.RedirectURI,
-		req.ParentFolder,
-		user,
-		ipAddress,
-	)
+	var config *models.DropboxConfig
+	if req.State != "" {
+		config, err = h.oauthService.CompleteAuthorization(
+			c.Request.Context(),
+			req.Code,
+			req.State,
+			user,
+			c.GetString("token"),
+			ipAddress,
+		)
+	} else {
+		config, err = h.oauthService.ExchangeCodeForTokens(
+			c.Request.Context(),
+			req.Code,
+			req.AppKey,
+			req.AppSecret,
+			req.RedirectURI,
+			req.ParentFolder,
+			user,
+			ipAddress,
+		)
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		statusCode := dropboxConnectionErrorStatus(err, http.StatusInternalServerError)
+		switch err {
+		case service.ErrInvalidOAuthState, service.ErrInvalidAuthCode, service.ErrOAuthConfigNotFound:
+			statusCode = http.StatusBadRequest
+		}
+		c.JSON(statusCode, gin.H{"error": err.Error()})
 		return
 	}
 
@@ -179,9 +247,10 @@ func (h *DropboxAdminHandler) CompleteAuth(c *gin.Context) {
 
 // ForceRefresh godoc
 // @Summary Force refresh Dropbox access token
-// @Description Manually trigger a token refresh
+// @Description Manually trigger a token refresh of a connection
 // @Tags admin-dropbox
 // @Produce json
+// @Param connection query string false "Connection ID; the default connection when omitted"
 // @Success 200 {object} map[string]string
 // @Failure 401 {object} map[string]string
 // @Failure 403 {object} map[string]string
@@ -201,10 +270,14 @@ func (h *DropboxAdminHandler) ForceRefresh(c *gin.Context) {
 		return
 	}
 
+	connectionID, ok := connectionParam(c)
+	if !ok {
+		return
+	}
 	ipAddress := middleware.GetIPAddress(c)
 
-	if err := h.oauthService.ForceRefresh(c.Request.Context(), user, ipAddress); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.oauthService.ForceRefresh(c.Request.Context(), connectionID, user, ipAddress); err != nil {
+		c.JSON(dropboxConnectionErrorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -215,9 +288,10 @@ func (h *DropboxAdminHandler) ForceRefresh(c *gin.Context) {
 
 // TestConnection godoc
 // @Summary Test Dropbox connection
-// @Description Test if the Dropbox connection is working
+// @Description Test if a Dropbox connection is working
 // @Tags admin-dropbox
 // @Produce json
+// @Param connection query string false "Connection ID; the default connection when omitted"
 // @Success 200 {object} map[string]interface{}
 // @Failure 401 {object} map[string]string
 // @Failure 403 {object} map[string]string
@@ -237,9 +311,17 @@ func (h *DropboxAdminHandler) TestConnection(c *gin.Context) {
 		return
 	}
 
+	connectionID, ok := connectionParam(c)
+	if !ok {
+		return
+	}
 	ipAddress := middleware.GetIPAddress(c)
 
-	if err := h.oauthService.TestConnection(c.Request.Context(), user, ipAddress); err != nil {
+	if err := h.oauthService.TestConnection(c.Request.Context(), connectionID, user, ipAddress); err != nil {
+		if err == service.ErrDropboxConnectionNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusOK, gin.H{
 			"success": false,
 			"message": err.Error(),
@@ -255,12 +337,15 @@ func (h *DropboxAdminHandler) TestConnection(c *gin.Context) {
 
 // DeleteConfiguration godoc
 // @Summary Delete Dropbox configuration
-// @Description Delete the Dropbox configuration (requires re-authorization)
+// @Description Delete a Dropbox connection. The default connection requires re-authorization; other connections can only be deleted once no library or the registry uses them.
 // @Tags admin-dropbox
 // @Produce json
+// @Param connection query string false "Connection ID; the default connection when omitted"
 // @Success 200 {object} map[string]string
 // @Failure 401 {object} map[string]string
 // @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string "The connection is in use"
 // @Failure 500 {object} map[string]string
 // @Router /admin/dropbox/configuration [delete]
 // @Security BearerAuth
@@ -277,10 +362,14 @@ func (h *DropboxAdminHandler) DeleteConfiguration(c *gin.Context) {
 		return
 	}
 
+	connectionID, ok := connectionParam(c)
+	if !ok {
+		return
+	}
 	ipAddress := middleware.GetIPAddress(c)
 
-	if err := h.oauthService.DeleteConfiguration(c.Request.Context(), user, ipAddress); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.oauthService.DeleteConfiguration(c.Request.Context(), connectionID, user, ipAddress); err != nil {
+		c.JSON(dropboxConnectionErrorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
 		return
 	}
 
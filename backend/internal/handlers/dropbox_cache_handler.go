@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"net/http"
+
+	"backend/internal/repository"
+	"backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DropboxCacheHandler handles inspection and invalidation of cached Dropbox folder listings
+type DropboxCacheHandler struct {
+	listingService *service.DropboxListingService
+}
+
+// NewDropboxCacheHandler creates a new DropboxCacheHandler
+func NewDropboxCacheHandler(listingService *service.DropboxListingService) *DropboxCacheHandler {
+	return &DropboxCacheHandler{
+		listingService: listingService,
+	}
+}
+
+// ListCache godoc
+// @Summary List cached Dropbox listings
+// @Description List every cached folder listing with its entry count, last sync and last error
+// @Tags admin-dropbox
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /admin/dropbox/cache [get]
+// @Security BearerAuth
+func (h *DropboxCacheHandler) ListCache(c *gin.Context) {
+	listings, err := h.listingService.ListCached(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"listings": listings,
+		"count":    len(listings),
+	})
+}
+
+// GetCacheEntry godoc
+// @Summary Get a cached Dropbox listing
+// @Description Get the cached listing of one folder, including its entries
+// @Tags admin-dropbox
+// @Produce json
+// @Param path query string true "Full Dropbox path of the folder"
+// @Param connection query string false "Connection ID; the default connection when omitted"
+// @Success 200 {object} models.DropboxListing
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /admin/dropbox/cache/entry [get]
+// @Security BearerAuth
+func (h *DropboxCacheHandler) GetCacheEntry(c *gin.Context) {
+	path := c.Query("path")
+	if path == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "path is required"})
+		return
+	}
+	connectionID, ok := connectionParam(c)
+	if !ok {
+		return
+	}
+
+	listing, err := h.listingService.GetCached(c.Request.Context(), connectionID, path)
+	if err != nil {
+		if err == repository.ErrListingNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, listing)
+}
+
+// InvalidateCache godoc
+// @Summary Invalidate cached Dropbox listings
+// @Description Drop the cached listing of one folder, or of every folder when no path is given. The next read lists the folder in full again.
+// @Tags admin-dropbox
+// @Produce json
+// @Param path query string false "Full Dropbox path of the folder"
+// @Param connection query string false "Connection ID of the folder; the default connection when omitted"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /admin/dropbox/cache [delete]
+// @Security BearerAuth
+func (h *DropboxCacheHandler) InvalidateCache(c *gin.Context) {
+	connectionID, ok := connectionParam(c)
+	if !ok {
+		return
+	}
+
+	removed, err := h.listingService.Invalidate(c.Request.Context(), connectionID, c.Query("path"))
+	if err != nil {
+		if err == repository.ErrListingNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "cache invalidated",
+		"removed": removed,
+	})
+}
+
+// SyncCache godoc
+// @Summary Sync cached Dropbox listings
+// @Description Start syncing every cached listing with Dropbox in the background
+// @Tags admin-dropbox
+// @Produce json
+// @Success 202 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /admin/dropbox/cache/sync [post]
+// @Security BearerAuth
+func (h *DropboxCacheHandler) SyncCache(c *gin.Context) {
+	if err := h.listingService.TriggerSync(); err != nil {
+		if err == service.ErrDropboxNotConfigured {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "cache sync started"})
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+
+	"backend/internal/dropboxwebhook"
+	"backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxWebhookBodySize bounds notification bodies, which list account IDs only
+const maxWebhookBodySize = 1 << 20
+
+// DropboxWebhookHandler handles Dropbox webhook requests
+type DropboxWebhookHandler struct {
+	webhookService *service.DropboxWebhookService
+}
+
+// NewDropboxWebhookHandler creates a new DropboxWebhookHandler
+func NewDropboxWebhookHandler(webhookService *service.DropboxWebhookService) *DropboxWebhookHandler {
+	return &DropboxWebhookHandler{
+		webhookService: webhookService,
+	}
+}
+
+// Verify godoc
+// @Summary Verify the Dropbox webhook
+// @Description Echo Dropbox's challenge when the webhook URI is registered
+// @Tags webhooks
+// @Produce plain
+// @Param challenge query string true "Challenge sent by Dropbox"
+// @Success 200 {string} string
+// @Failure 400 {object} map[string]string
+// @Router /webhooks/dropbox [get]
+func (h *DropboxWebhookHandler) Verify(c *gin.Context) {
+	challenge := c.Query("challenge")
+	if challenge == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "challenge is required"})
+		return
+	}
+
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.String(http.StatusOK, challenge)
+}
+
+// Notify godoc
+// @Summary Receive a Dropbox change notification
+// @Description Verify the X-Dropbox-Signature header and sync the library folders of the notified accounts in the background
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param X-Dropbox-Signature header string true "Hex HMAC-SHA256 of the body keyed by the app secret"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 413 {object} map[string]string
+// @Failure 503 {object} map[string]string
+// @Router /webhooks/dropbox [post]
+func (h *DropboxWebhookHandler) Notify(c *gin.Context) {
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxWebhookBodySize+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read request body"})
+		return
+	}
+	if len(body) > maxWebhookBodySize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "request body too large"})
+		return
+	}
+
+	if err := h.webhookService.HandleNotification(body, c.GetHeader(dropboxwebhook.SignatureHeader)); err != nil {
+		statusCode := http.StatusInternalServerError
+		switch err {
+		case service.ErrInvalidWebhookSignature:
+			statusCode = http.StatusForbidden
+		case dropboxwebhook.ErrMalformedNotification:
+			statusCode = http.StatusBadRequest
+		case service.ErrWebhookSecretNotConfigured:
+			statusCode = http.StatusServiceUnavailable
+		}
+		c.JSON(statusCode, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "notification received"})
+}
+
+// GetStatus godoc
+// @Summary Get Dropbox webhook status
+// @Description Get the number of notifications received and the result of the last webhook sync
+// @Tags admin-dropbox
+// @Produce json
+// @Success 200 {object} service.DropboxWebhookStatus
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /admin/dropbox/webhook [get]
+// @Security BearerAuth
+func (h *DropboxWebhookHandler) GetStatus(c *gin.Context) {
+	c.JSON(http.StatusOK, h.webhookService.Status())
+}
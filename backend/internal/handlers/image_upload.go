@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"backend/internal/imaging"
+	"backend/internal/models"
+	"backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxImageUploadSize caps image uploads before they are decoded
+const maxImageUploadSize = 5 * 1024 * 1024
+
+// readImageUpload reads the "image" form file. It writes the error response
+// and returns nil when the file is missing or too large.
+func readImageUpload(c *gin.Context) []byte {
+	file, err := c.FormFile("image")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "image file is required"})
+		return nil
+	}
+
+	if file.Size > maxImageUploadSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "image size must be less than 5MB"})
+		return nil
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read image"})
+		return nil
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(io.LimitReader(f, maxImageUploadSize+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read image"})
+		return nil
+	}
+	if len(data) > maxImageUploadSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "image size must be less than 5MB"})
+		return nil
+	}
+
+	return data
+}
+
+// respondImageUpload writes the result of processing an image upload
+func respondImageUpload(c *gin.Context, image *models.Image, err error) {
+	if err != nil {
+		switch {
+		case errors.Is(err, imaging.ErrUnsupportedFormat),
+			errors.Is(err, imaging.ErrCorruptImage),
+			errors.Is(err, imaging.ErrImageTooLarge):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case err == service.ErrUnauthorized:
+			c.JSON(http.StatusForbidden, gin.H{"error": "insufficient permissions"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save image"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"imagePath": image.Path,
+		"variants":  image.Variants,
+		"message":   "image uploaded successfully",
+	})
+}
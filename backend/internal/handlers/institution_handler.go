@@ -1,16 +1,15 @@
 package handlers
 
 import (
-	"fmt"
+	"io"
+	"math"
 	"net/http"
-	"os"
-	"path/filepath"
+	"net/url"
 	"strconv"
-	"strings"
-	"time"
 
 	"backend/internal/middleware"
 	"backend/internal/models"
+	"backend/internal/pagination"
 	"backend/internal/repository"
 	"backend/internal/service"
 
@@ -187,7 +186,13 @@ func (h *InstitutionHandler) GetInstitution(c *gin.Context) {
 		return
 	}
 
-	institution, err := h.institutionService.GetInstitution(c.Request.Context(), institutionID)
+	viewer, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	institution, err := h.institutionService.GetInstitution(c.Request.Context(), institutionID, viewer)
 	if err != nil {
 		if err == repository.ErrInstitutionNotFound {
 			c.JSON(http.StatusNotFound, gin.H{"error": "institution not found"})
@@ -290,7 +295,7 @@ func (h *InstitutionHandler) DeleteInstitution(c *gin.Context) {
 			statusCode = http.StatusForbidden
 		} else if err == repository.ErrInstitutionNotFound {
 			statusCode = http.StatusNotFound
-		} else if err == service.ErrInstitutionHasUsers {
+		} else if err == service.ErrInstitutionHasUsers || err == service.ErrInstitutionHasUnits {
 			statusCode = http.StatusConflict
 		}
 		c.JSON(statusCode, gin.H{"error": err.Error()})
@@ -388,6 +393,381 @@ func (h *InstitutionHandler) DeactivateInstitution(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "institution deactivated successfully"})
 }
 
+// ListInstitutionUnits godoc
+// @Summary List the units of an institution
+// @Description Get the departments and units below an institution. Direct children only unless recursive is set.
+// @Tags institutions
+// @Produce json
+// @Param id path string true "Institution ID"
+// @Param recursive query bool false "Include units at every depth" default(false)
+// @Param limit query int false "Limit number of results" default(100)
+// @Param sort query string false "Sort keys: name, createdAt, status (prefix - for descending)" default(name)
+// @Param cursor query string false "Opaque cursor from a previous response's nextCursor"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /institutions/{id}/units [get]
+// @Security BearerAuth
+func (h *InstitutionHandler) ListInstitutionUnits(c *gin.Context) {
+	institutionID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid institution ID"})
+		return
+	}
+
+	viewer, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	if _, err := h.institutionService.GetInstitution(c.Request.Context(), institutionID, viewer); err != nil {
+		if err == repository.ErrInstitutionNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "institution not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	req, ok := parsePagination(c, institutionListPagination)
+	if !ok {
+		return
+	}
+
+	var filter service.InstitutionListFilter
+	if c.Query("recursive") == "true" {
+		filter.AncestorID = &institutionID
+	} else {
+		filter.ParentID = &institutionID
+	}
+
+	page, count, err := h.institutionService.ListInstitutions(c.Request.Context(), viewer, filter, req)
+	if err != nil {
+		if isPaginationError(err) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"institutions": page.Items,
+		"total":        count,
+		"pagination":   pagination.NewInfo(req, page, count),
+	})
+}
+
+// GetInstitutionPath godoc
+// @Summary Get the ancestors of an institution
+// @Description Get the institution and its parents, ordered from the top-level institution down
+// @Tags institutions
+// @Produce json
+// @Param id path string true "Institution ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /institutions/{id}/path [get]
+// @Security BearerAuth
+func (h *InstitutionHandler) GetInstitutionPath(c *gin.Context) {
+	institutionID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid institution ID"})
+		return
+	}
+
+	viewer, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	path, err := h.institutionService.GetInstitutionPath(c.Request.Context(), institutionID, viewer)
+	if err != nil {
+		if err == repository.ErrInstitutionNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "institution not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"path":      path,
+		"ancestors": path[:len(path)-1],
+	})
+}
+
+// GetInstitutionStats godoc
+// @Summary Get user counts for an institution
+// @Description Count the users of an institution, including users attached to its units (requires manage users permission)
+// @Tags institutions
+// @Produce json
+// @Param id path string true "Institution ID"
+// @Success 200 {object} models.InstitutionStats
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /institutions/{id}/stats [get]
+// @Security BearerAuth
+func (h *InstitutionHandler) GetInstitutionStats(c *gin.Context) {
+	institutionID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid institution ID"})
+		return
+	}
+
+	viewer, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	stats, err := h.institutionService.GetInstitutionStats(c.Request.Context(), institutionID, viewer)
+	if err != nil {
+		if err == service.ErrUnauthorized {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		if err == repository.ErrInstitutionNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "institution not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// ListPendingInstitutions godoc
+// @Summary List institutions awaiting moderation
+// @Description Get user-created institutions that are pending approval (requires manage users permission)
+// @Tags institutions
+// @Produce json
+// @Param limit query int false "Limit number of results" default(100)
+// @Param sort query string false "Sort keys: name, createdAt (prefix - for descending)" default(name)
+// @Param cursor query string false "Opaque cursor from a previous response's nextCursor"
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /institutions/moderation [get]
+// @Security BearerAuth
+func (h *InstitutionHandler) ListPendingInstitutions(c *gin.Context) {
+	viewer, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	req, ok := parsePagination(c, institutionListPagination)
+	if !ok {
+		return
+	}
+
+	status := models.InstitutionStatusPending
+	page, count, err := h.institutionService.ListInstitutions(c.Request.Context(), viewer, service.InstitutionListFilter{Status: &status}, req)
+	if err != nil {
+		if isPaginationError(err) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"institutions": page.Items,
+		"total":        count,
+		"pagination":   pagination.NewInfo(req, page, count),
+	})
+}
+
+// ModerateInstitution godoc
+// @Summary Approve or reject a user-created institution
+// @Description Approve (optionally with edits) or reject a pending institution and notify its creator (requires manage users permission)
+// @Tags institutions
+// @Accept json
+// @Produce json
+// @Param id path string true "Institution ID"
+// @Param request body models.ModerateInstitutionRequest true "Moderation decision"
+// @Success 200 {object} models.Institution
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /institutions/{id}/moderate [post]
+// @Security BearerAuth
+func (h *InstitutionHandler) ModerateInstitution(c *gin.Context) {
+	idParam := c.Param("id")
+	institutionID, err := primitive.ObjectIDFromHex(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid institution ID"})
+		return
+	}
+
+	var req models.ModerateInstitutionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	moderator, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	ipAddress := middleware.GetIPAddress(c)
+
+	institution, err := h.institutionService.ModerateInstitution(c.Request.Context(), institutionID, &req, moderator, ipAddress)
+	if err != nil {
+		statusCode := http.StatusBadRequest
+		if err == service.ErrUnauthorized {
+			statusCode = http.StatusForbidden
+		} else if err == repository.ErrInstitutionNotFound {
+			statusCode = http.StatusNotFound
+		} else if err == models.ErrInstitutionNotPending || err == repository.ErrDuplicateInstitution {
+			statusCode = http.StatusConflict
+		}
+		c.JSON(statusCode, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, institution)
+}
+
+// PreviewMerge godoc
+// @Summary Preview an institution merge
+// @Description Report how many users and submissions a merge would re-point and which aliases it would add (requires delete users permission)
+// @Tags institutions
+// @Accept json
+// @Produce json
+// @Param request body models.MergeInstitutionsRequest true "Target and source institutions"
+// @Success 200 {object} models.InstitutionMergePreview
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /institutions/merge/preview [post]
+// @Security BearerAuth
+func (h *InstitutionHandler) PreviewMerge(c *gin.Context) {
+	var req models.MergeInstitutionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	preview, err := h.institutionService.PreviewMerge(c.Request.Context(), &req, user)
+	if err != nil {
+		c.JSON(mergeErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, preview)
+}
+
+// MergeInstitutions godoc
+// @Summary Merge institutions
+// @Description Merge duplicate institutions into a canonical one. Users and registry submissions are re-pointed and retired names are kept as aliases (requires delete users permission)
+// @Tags institutions
+// @Accept json
+// @Produce json
+// @Param request body models.MergeInstitutionsRequest true "Target and source institutions"
+// @Success 200 {object} models.InstitutionMergeResult
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /institutions/merge [post]
+// @Security BearerAuth
+func (h *InstitutionHandler) MergeInstitutions(c *gin.Context) {
+	var req models.MergeInstitutionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	mergedBy, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	ipAddress := middleware.GetIPAddress(c)
+
+	result, err := h.institutionService.MergeInstitutions(c.Request.Context(), &req, mergedBy, ipAddress)
+	if err != nil {
+		c.JSON(mergeErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// mergeErrorStatus maps merge errors to HTTP status codes
+func mergeErrorStatus(err error) int {
+	switch err {
+	case service.ErrUnauthorized:
+		return http.StatusForbidden
+	case repository.ErrInstitutionNotFound, service.ErrMergeInstitutionNotFound:
+		return http.StatusNotFound
+	case models.ErrMergeTargetRequired, models.ErrMergeSourcesRequired, models.ErrMergeIntoSelf, models.ErrInvalidMergeID, models.ErrInstitutionCycle:
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// ListDuplicateCandidates godoc
+// @Summary List possible duplicate institutions
+// @Description Report pairs of institutions in the same city with similar names (requires delete users permission)
+// @Tags institutions
+// @Produce json
+// @Param threshold query number false "Minimum similarity score between 0 and 1" default(0.8)
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /institutions/duplicates [get]
+// @Security BearerAuth
+func (h *InstitutionHandler) ListDuplicateCandidates(c *gin.Context) {
+	user, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	threshold := models.DefaultDuplicateThreshold
+	if thresholdParam := c.Query("threshold"); thresholdParam != "" {
+		if t, err := strconv.ParseFloat(thresholdParam, 64); err == nil && t > 0 && t <= 1 {
+			threshold = t
+		}
+	}
+
+	candidates, err := h.institutionService.FindDuplicateCandidates(c.Request.Context(), threshold, user)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if err == service.ErrUnauthorized {
+			statusCode = http.StatusForbidden
+		}
+		c.JSON(statusCode, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"candidates": candidates,
+		"total":      len(candidates),
+		"threshold":  threshold,
+	})
+}
+
 // ListInstitutions godoc
 // @Summary List institutions
 // @Description Get a list of institutions with optional filtering and pagination
@@ -395,58 +775,88 @@ func (h *InstitutionHandler) DeactivateInstitution(c *gin.Context) {
 // @Produce json
 // @Param type query string false "Filter by institution type"
 // @Param is_active query bool false "Filter by active status"
+// @Param status query string false "Filter by moderation status (approved, pending, rejected)"
+// @Param parentId query string false "Only direct units of this institution; \"root\" for top-level institutions"
+// @Param province query string false "Filter by province"
+// @Param city query string false "Filter by city"
 // @Param search query string false "Search by name, city, province, or country"
 // @Param limit query int false "Limit number of results" default(100)
-// @Param skip query int false "Skip number of results" default(0)
+// @Param sort query string false "Sort keys: name, createdAt, status (prefix - for descending)" default(name)
+// @Param cursor query string false "Opaque cursor from a previous response's nextCursor"
+// @Param skip query int false "Skip number of results (deprecated, use cursor)" default(0)
 // @Success 200 {object} map[string]interface{}
 // @Failure 400 {object} map[string]string
 // @Failure 401 {object} map[string]string
 // @Router /institutions [get]
 // @Security BearerAuth
 func (h *InstitutionHandler) ListInstitutions(c *gin.Context) {
+	viewer, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
 	// Parse query parameters
-	var institutionType *models.InstitutionType
+	var filter service.InstitutionListFilter
 	if typeParam := c.Query("type"); typeParam != "" {
 		t := models.InstitutionType(typeParam)
 		if t.IsValid() {
-			institutionType = &t
+			filter.Type = &t
 		}
 	}
 
-	var isActive *bool
 	if isActiveParam := c.Query("is_active"); isActiveParam != "" {
 		active := isActiveParam == "true"
-		isActive = &active
+		filter.IsActive = &active
 	}
 
-	// Get search query parameter
-	search := c.Query("search")
-
-	limit := int64(100)
-	if limitParam := c.Query("limit"); limitParam != "" {
-		if l, err := strconv.ParseInt(limitParam, 10, 64); err == nil && l > 0 {
-			limit = l
+	if statusParam := c.Query("status"); statusParam != "" {
+		status := models.InstitutionStatus(statusParam)
+		if status.IsValid() {
+			filter.Status = &status
 		}
 	}
 
-	skip := int64(0)
-	if skipParam := c.Query("skip"); skipParam != "" {
-		if s, err := strconv.ParseInt(skipParam, 10, 64); err == nil && s >= 0 {
-			skip = s
+	if parentParam := c.Query("parentId"); parentParam != "" {
+		parentID := primitive.NilObjectID
+		if parentParam != "root" {
+			id, err := primitive.ObjectIDFromHex(parentParam)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid parent institution ID"})
+				return
+			}
+			parentID = id
 		}
+		filter.ParentID = &parentID
+	}
+
+	filter.Province = c.Query("province")
+	filter.City = c.Query("city")
+
+	// Get search query parameter
+	filter.Search = c.Query("search")
+
+	req, ok := parsePagination(c, institutionListPagination)
+	if !ok {
+		return
 	}
 
-	institutions, count, err := h.institutionService.ListInstitutions(c.Request.Context(), institutionType, isActive, search, limit, skip)
+	page, count, err := h.institutionService.ListInstitutions(c.Request.Context(), viewer, filter, req)
 	if err != nil {
+		if isPaginationError(err) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"institutions": institutions,
+		"institutions": page.Items,
 		"total":        count,
-		"limit":        limit,
-		"skip":         skip,
+		"limit":        req.Limit,
+		"skip":         req.Skip,
+		"pagination":   pagination.NewInfo(req, page, count),
 	})
 }
 
@@ -456,6 +866,8 @@ func (h *InstitutionHandler) ListInstitutions(c *gin.Context) {
 // @Tags institutions
 // @Accept json
 // @Produce json
+// @Param province query string false "Filter by province"
+// @Param city query string false "Filter by city"
 // @Success 200 {object} map[string]interface{}
 // @Failure 500 {object} map[string]string
 // @Router /institutions/public [get]
@@ -464,166 +876,350 @@ func (h *InstitutionHandler) ListPublicInstitutions(c *gin.Context) {
 	isActive := true
 
 	// Get all active institutions (no pagination for public access)
-	institutions, count, err := h.institutionService.ListInstitutions(c.Request.Context(), nil, &isActive, "", 1000, 0)
+	req, err := pagination.ParseQuery(url.Values{}, institutionListPagination)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	req.Limit = institutionListPagination.MaxLimit
+
+	filter := service.InstitutionListFilter{
+		IsActive: &isActive,
+		Province: c.Query("province"),
+		City:     c.Query("city"),
+	}
+
+	page, count, err := h.institutionService.ListInstitutions(c.Request.Context(), nil, filter, req)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"institutions": institutions,
+		"institutions": page.Items,
 		"total":        count,
 	})
 }
 
-// UploadImage godoc
-// @Summary Upload institution logo
-// @Description Upload an image for institution logo (requires manage users permission)
+// GetInstitutionFacets godoc
+// @Summary Count institutions by province and city (public)
+// @Description Get province and city counts of active institutions for the registration institution picker
 // @Tags institutions
-// @Accept multipart/form-data
 // @Produce json
-// @Param image formData file true "Image file (jpg, jpeg, png, webp, max 5MB)"
-// @Success 200 {object} map[string]string
+// @Success 200 {object} models.InstitutionFacets
+// @Failure 500 {object} map[string]string
+// @Router /institutions/public/facets [get]
+func (h *InstitutionHandler) GetInstitutionFacets(c *gin.Context) {
+	facets, err := h.institutionService.GetInstitutionFacets(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, facets)
+}
+
+// NearbyInstitutions godoc
+// @Summary Find institutions near a point
+// @Description Get active institutions within a radius of a point, closest first. Also served without authentication at /institutions/public/nearby.
+// @Tags institutions
+// @Produce json
+// @Param lat query number true "Latitude"
+// @Param lng query number true "Longitude"
+// @Param radiusKm query number false "Search radius in kilometres (max 500)" default(25)
+// @Param limit query int false "Limit number of results (max 100)" default(20)
+// @Success 200 {object} map[string]interface{}
 // @Failure 400 {object} map[string]string
+// @Router /institutions/nearby [get]
+func (h *InstitutionHandler) NearbyInstitutions(c *gin.Context) {
+	lat, latErr := strconv.ParseFloat(c.Query("lat"), 64)
+	lng, lngErr := strconv.ParseFloat(c.Query("lng"), 64)
+	if latErr != nil || lngErr != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "lat and lng are required"})
+		return
+	}
+	// ParseFloat accepts "NaN" and "Inf", which no range check catches
+	if !finite(lat) || !finite(lng) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": models.ErrInvalidCoordinates.Error()})
+		return
+	}
+
+	radiusKm := 25.0
+	if radiusParam := c.Query("radiusKm"); radiusParam != "" {
+		r, err := strconv.ParseFloat(radiusParam, 64)
+		if err != nil || !finite(r) || r <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid radiusKm"})
+			return
+		}
+		radiusKm = min(r, 500)
+	}
+
+	limit := int64(20)
+	if l, err := strconv.ParseInt(c.Query("limit"), 10, 64); err == nil && l > 0 {
+		limit = min(l, 100)
+	}
+
+	// Public requests have no user and only see approved institutions
+	viewer, _ := middleware.GetUserFromContext(c)
+
+	institutions, err := h.institutionService.NearbyInstitutions(c.Request.Context(), viewer, lat, lng, radiusKm, limit)
+	if err != nil {
+		if err == models.ErrInvalidCoordinates {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"institutions": institutions,
+		"total":        len(institutions),
+		"radiusKm":     radiusKm,
+	})
+}
+
+// finite reports whether a parsed query number is neither NaN nor infinite
+func finite(v float64) bool {
+	return !math.IsNaN(v) && !math.IsInf(v, 0)
+}
+
+// GeocodeInstitutions godoc
+// @Summary Geocode institutions from the gazetteer
+// @Description Look up coordinates for institutions without a location in the locally loaded gazetteer (requires manage users permission)
+// @Tags institutions
+// @Produce json
+// @Param overwrite query bool false "Also refresh earlier gazetteer matches" default(false)
+// @Success 200 {object} models.GeocodeResult
 // @Failure 401 {object} map[string]string
 // @Failure 403 {object} map[string]string
-// @Router /institutions/images/upload [post]
+// @Failure 503 {object} map[string]string
+// @Router /institutions/geocode [post]
 // @Security BearerAuth
-func (h *InstitutionHandler) UploadImage(c *gin.Context) {
+func (h *InstitutionHandler) GeocodeInstitutions(c *gin.Context) {
 	user, err := middleware.GetUserFromContext(c)
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
 		return
 	}
 
-	// Check permission - requires manage users permission
-	if !user.HasPermission(models.PermManageUsers) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "insufficient permissions"})
+	ipAddress := middleware.GetIPAddress(c)
+
+	result, err := h.institutionService.GeocodeInstitutions(c.Request.Context(), c.Query("overwrite") == "true", user, ipAddress)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if err == service.ErrUnauthorized {
+			statusCode = http.StatusForbidden
+		} else if err == service.ErrGazetteerNotLoaded {
+			statusCode = http.StatusServiceUnavailable
+		}
+		c.JSON(statusCode, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// ImportInstitutions godoc
+// @Summary Import institutions from CSV or JSON
+// @Description Upsert institutions from a CSV or JSON file, matching existing ones by name, alias or short name. Use dryRun to preview the outcome without saving (requires manage users permission).
+// @Tags institutions
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "CSV or JSON file (max 5MB)"
+// @Param format formData string false "csv or json (defaults to the file extension)"
+// @Param dryRun formData bool false "Report the outcome without saving" default(false)
+// @Success 200 {object} models.InstitutionImport
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /institutions/import [post]
+// @Security BearerAuth
+func (h *InstitutionHandler) ImportInstitutions(c *gin.Context) {
+	user, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
 		return
 	}
 
-	// Get file from form
-	file, err := c.FormFile("image")
+	file, err := c.FormFile("file")
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "image file is required"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "import file is required"})
 		return
 	}
 
 	// Validate file size (max 5MB)
 	if file.Size > 5*1024*1024 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "image size must be less than 5MB"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "import file must be less than 5MB"})
 		return
 	}
 
-	// Validate file extension
-	ext := strings.ToLower(filepath.Ext(file.Filename))
-	validExtensions := map[string]bool{
-		".jpg":  true,
-		".jpeg": true,
-		".png":  true,
-		".webp": true,
+	format, err := models.DetectImportFormat(c.PostForm("format"), file.Filename)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read import file"})
+		return
 	}
+	defer f.Close()
 
-	if !validExtensions[ext] {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid image format. Allowed: jpg, jpeg, png, webp"})
+	data, err := io.ReadAll(f)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read import file"})
 		return
 	}
 
-	// Create uploads directory if it doesn't exist
-	uploadDir := "./uploads/institutions"
-	if err := os.MkdirAll(uploadDir, 0755); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create upload directory"})
+	opts := service.InstitutionImportOptions{
+		Source: file.Filename,
+		Format: format,
+		DryRun: c.PostForm("dryRun") == "true",
+	}
+
+	ipAddress := middleware.GetIPAddress(c)
+
+	result, err := h.institutionService.ImportInstitutions(c.Request.Context(), data, opts, user, ipAddress)
+	if err != nil {
+		statusCode := http.StatusBadRequest
+		if err == service.ErrUnauthorized {
+			statusCode = http.StatusForbidden
+		}
+		c.JSON(statusCode, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Generate unique filename
-	filename := fmt.Sprintf("%d_%s%s", time.Now().Unix(), generateRandomString(8), ext)
-	filePath := filepath.Join(uploadDir, filename)
+	c.JSON(http.StatusOK, result)
+}
 
-	// Save file
-	if err := c.SaveUploadedFile(file, filePath); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save image"})
+// ListInstitutionImports godoc
+// @Summary List institution import history
+// @Description Get previous institution imports, newest first (requires manage users permission)
+// @Tags institutions
+// @Produce json
+// @Param limit query int false "Limit number of results" default(20)
+// @Param cursor query string false "Opaque cursor from a previous response's nextCursor"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /institutions/imports [get]
+// @Security BearerAuth
+func (h *InstitutionHandler) ListInstitutionImports(c *gin.Context) {
+	req, ok := parsePagination(c, institutionImportListPagination)
+	if !ok {
 		return
 	}
 
-	// Return relative path for database storage
-	imagePath := "/uploads/institutions/" + filename
+	page, count, err := h.institutionService.ListImports(c.Request.Context(), req)
+	if err != nil {
+		if isPaginationError(err) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"imagePath": imagePath,
-		"message":   "image uploaded successfully",
+		"imports":    page.Items,
+		"total":      count,
+		"pagination": pagination.NewInfo(req, page, count),
 	})
 }
 
-// UploadUserImage godoc
-// @Summary Upload institution logo (user)
-// @Description Upload an image for institution logo by a regular user (any authenticated user can upload)
+// GetInstitutionImport godoc
+// @Summary Get an institution import
+// @Description Get a recorded institution import with the outcome of every row (requires manage users permission)
 // @Tags institutions
-// @Accept multipart/form-data
 // @Produce json
-// @Param image formData file true "Image file (jpg, jpeg, png, webp, max 5MB)"
-// @Success 200 {object} map[string]string
+// @Param id path string true "Import ID"
+// @Success 200 {object} models.InstitutionImport
 // @Failure 400 {object} map[string]string
-// @Failure 401 {object} map[string]string
-// @Router /institutions/user/images/upload [post]
+// @Failure 404 {object} map[string]string
+// @Router /institutions/imports/{id} [get]
 // @Security BearerAuth
-func (h *InstitutionHandler) UploadUserImage(c *gin.Context) {
-	_, err := middleware.GetUserFromContext(c)
+func (h *InstitutionHandler) GetInstitutionImport(c *gin.Context) {
+	importID, err := primitive.ObjectIDFromHex(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid import ID"})
 		return
 	}
 
-	// Get file from form
-	file, err := c.FormFile("image")
+	imp, err := h.institutionService.GetImport(c.Request.Context(), importID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "image file is required"})
+		if err == repository.ErrInstitutionImportNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Validate file size (max 5MB)
-	if file.Size > 5*1024*1024 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "image size must be less than 5MB"})
+	c.JSON(http.StatusOK, imp)
+}
+
+// UploadImage godoc
+// @Summary Upload institution logo
+// @Description Upload an image for institution logo (requires manage users permission)
+// @Tags institutions
+// @Accept multipart/form-data
+// @Produce json
+// @Param image formData file true "Image file (jpg, png, gif or webp, max 5MB); EXIF metadata is stripped and resized variants are generated"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /institutions/images/upload [post]
+// @Security BearerAuth
+func (h *InstitutionHandler) UploadImage(c *gin.Context) {
+	user, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
 		return
 	}
 
-	// Validate file extension
-	ext := strings.ToLower(filepath.Ext(file.Filename))
-	allowedExts := []string{".jpg", ".jpeg", ".png", ".webp"}
-	allowed := false
-	for _, allowedExt := range allowedExts {
-		if ext == allowedExt {
-			allowed = true
-			break
-		}
-	}
-	if !allowed {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid file type. Only jpg, jpeg, png, and webp are allowed"})
+	// Check permission - requires manage users permission
+	if !user.HasPermission(models.PermManageUsers) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "insufficient permissions"})
 		return
 	}
 
-	// Create upload directory if it doesn't exist
-	uploadDir := "./uploads/institutions"
-	if err := os.MkdirAll(uploadDir, 0755); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create upload directory"})
+	data := readImageUpload(c)
+	if data == nil {
 		return
 	}
 
-	// Generate unique filename
-	filename := fmt.Sprintf("%d_%s%s", time.Now().Unix(), generateRandomString(8), ext)
-	filePath := filepath.Join(uploadDir, filename)
+	image, err := h.institutionService.UploadImage(c.Request.Context(), data, user)
+	respondImageUpload(c, image, err)
+}
 
-	// Save file
-	if err := c.SaveUploadedFile(file, filePath); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save image"})
+// UploadUserImage godoc
+// @Summary Upload institution logo (user)
+// @Description Upload an image for institution logo by a regular user (any authenticated user can upload)
+// @Tags institutions
+// @Accept multipart/form-data
+// @Produce json
+// @Param image formData file true "Image file (jpg, png, gif or webp, max 5MB); EXIF metadata is stripped and resized variants are generated"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Router /institutions/user/images/upload [post]
+// @Security BearerAuth
+func (h *InstitutionHandler) UploadUserImage(c *gin.Context) {
+	user, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
 		return
 	}
 
-	// Return relative path for database storage
-	imagePath := "/uploads/institutions/" + filename
+	data := readImageUpload(c)
+	if data == nil {
+		return
+	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"imagePath": imagePath,
-		"message":   "image uploaded successfully",
-	})
+	image, err := h.institutionService.UploadImage(c.Request.Context(), data, user)
+	respondImageUpload(c, image, err)
 }
@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestNearbyInstitutionsRejectsNonFiniteNumbers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := NewInstitutionHandler(nil)
+
+	r := gin.New()
+	r.GET("/institutions/nearby", h.NearbyInstitutions)
+
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{name: "radius NaN", query: "lat=-26.2&lng=28.0&radiusKm=NaN"},
+		{name: "radius infinite", query: "lat=-26.2&lng=28.0&radiusKm=Inf"},
+		{name: "radius negative infinite", query: "lat=-26.2&lng=28.0&radiusKm=-Inf"},
+		{name: "radius zero", query: "lat=-26.2&lng=28.0&radiusKm=0"},
+		{name: "latitude NaN", query: "lat=NaN&lng=28.0"},
+		{name: "longitude infinite", query: "lat=-26.2&lng=-Inf"},
+		{name: "missing latitude", query: "lng=28.0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/institutions/nearby?"+tt.query, nil))
+			if rr.Code != http.StatusBadRequest {
+				t.Errorf("GET ?%s = %d %s, want %d", tt.query, rr.Code, rr.Body.String(), http.StatusBadRequest)
+			}
+		})
+	}
+}
@@ -0,0 +1,717 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"backend/internal/middleware"
+	"backend/internal/models"
+	"backend/internal/pagination"
+	"backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// librarySlugKey is the context key LibraryAlias stores a fixed library slug under
+const librarySlugKey = "library_slug"
+
+// LibraryHandler handles document library and library category requests
+type LibraryHandler struct {
+	libraryService  *service.LibraryService
+	categoryService *service.LibraryCategoryService
+	revisionService *service.DocumentRevisionService
+}
+
+// NewLibraryHandler creates a new LibraryHandler
+func NewLibraryHandler(
+	libraryService *service.LibraryService,
+	categoryService *service.LibraryCategoryService,
+	revisionService *service.DocumentRevisionService,
+) *LibraryHandler {
+	return &LibraryHandler{
+		libraryService:  libraryService,
+		categoryService: categoryService,
+		revisionService: revisionService,
+	}
+}
+
+// LibraryAlias serves a fixed library's routes under another path, such as
+// /sops for the SOP library
+func LibraryAlias(slug string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(librarySlugKey, slug)
+		c.Next()
+	}
+}
+
+// librarySlug returns the library a request addresses, from an alias or the
+// :library path parameter
+func librarySlug(c *gin.Context) string {
+	if slug := c.GetString(librarySlugKey); slug != "" {
+		return slug
+	}
+	return c.Param("library")
+}
+
+// libraryErrorStatus maps library lookup and permission errors onto a status code
+func libraryErrorStatus(err error, fallback int) int {
+	switch err {
+	case service.ErrUnauthorized:
+		return http.StatusForbidden
+	case service.ErrLibraryNotFound, service.ErrCategoryNotFound, service.ErrDocumentNotFound, service.ErrRevisionNotFound:
+		return http.StatusNotFound
+	case service.ErrDuplicateSlug, service.ErrDuplicateLibrarySlug, service.ErrLibraryNotEmpty, service.ErrBuiltinLibrary,
+		service.ErrLibraryHasCategories, service.ErrStorageUnsupported:
+		return http.StatusConflict
+	case service.ErrStorageNotConfigured:
+		return http.StatusServiceUnavailable
+	case models.ErrInvalidDocumentPath:
+		return http.StatusBadRequest
+	}
+	return fallback
+}
+
+// ListLibraries godoc
+// @Summary List libraries
+// @Description List the document libraries the current user can view
+// @Tags libraries
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} map[string]string
+// @Router /libraries [get]
+// @Security BearerAuth
+func (h *LibraryHandler) ListLibraries(c *gin.Context) {
+	user, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	libraries, err := h.libraryService.ListLibraries(c.Request.Context(), user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"libraries": libraries})
+}
+
+// GetLibrary godoc
+// @Summary Get a library
+// @Description Get a document library by slug
+// @Tags libraries
+// @Produce json
+// @Param library path string true "Library slug"
+// @Success 200 {object} models.Library
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /libraries/{library} [get]
+// @Security BearerAuth
+func (h *LibraryHandler) GetLibrary(c *gin.Context) {
+	user, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	library, err := h.libraryService.GetLibrary(c.Request.Context(), librarySlug(c), user)
+	if err != nil {
+		c.JSON(libraryErrorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, library)
+}
+
+// CreateLibrary godoc
+// @Summary Create a library
+// @Description Create a document library with its own Dropbox root and permissions (requires super admin permission)
+// @Tags libraries
+// @Accept json
+// @Produce json
+// @Param request body models.CreateLibraryRequest true "Library information"
+// @Success 201 {object} models.Library
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /libraries [post]
+// @Security BearerAuth
+func (h *LibraryHandler) CreateLibrary(c *gin.Context) {
+	var req models.CreateLibraryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	library, err := h.libraryService.CreateLibrary(c.Request.Context(), &req, user, middleware.GetIPAddress(c))
+	if err != nil {
+		c.JSON(libraryErrorStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, library)
+}
+
+// UpdateLibrary godoc
+// @Summary Update a library
+// @Description Update a library's name, description, permissions or status; the slug and Dropbox root cannot change (requires super admin permission)
+// @Tags libraries
+// @Accept json
+// @Produce json
+// @Param library path string true "Library slug"
+// @Param request body models.UpdateLibraryRequest true "Update information"
+// @Success 200 {object} models.Library
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /libraries/{library} [put]
+// @Security BearerAuth
+func (h *LibraryHandler) UpdateLibrary(c *gin.Context) {
+	var req models.UpdateLibraryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	library, err := h.libraryService.UpdateLibrary(c.Request.Context(), librarySlug(c), &req, user, middleware.GetIPAddress(c))
+	if err != nil {
+		c.JSON(libraryErrorStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, library)
+}
+
+// DeleteLibrary godoc
+// @Summary Delete a library
+// @Description Delete an empty library; built-in libraries cannot be deleted and the Dropbox folder remains (requires super admin permission)
+// @Tags libraries
+// @Produce json
+// @Param library path string true "Library slug"
+// @Success 200 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /libraries/{library} [delete]
+// @Security BearerAuth
+func (h *LibraryHandler) DeleteLibrary(c *gin.Context) {
+	user, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	err = h.libraryService.DeleteLibrary(c.Request.Context(), librarySlug(c), user, middleware.GetIPAddress(c))
+	if err != nil {
+		c.JSON(libraryErrorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "library deleted successfully"})
+}
+
+// CreateCategory godoc
+// @Summary Create a library category
+// @Description Create a category and its Dropbox folder (requires the library's manage permission). Also served at /sops/categories and /working-parties/categories.
+// @Tags libraries
+// @Accept json
+// @Produce json
+// @Param library path string true "Library slug"
+// @Param request body models.CreateLibraryCategoryRequest true "Category information"
+// @Success 201 {object} models.LibraryCategory
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /libraries/{library}/categories [post]
+// @Security BearerAuth
+func (h *LibraryHandler) CreateCategory(c *gin.Context) {
+	var req models.CreateLibraryCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	ipAddress := middleware.GetIPAddress(c)
+
+	category, err := h.categoryService.CreateCategory(c.Request.Context(), librarySlug(c), &req, user, ipAddress)
+	if err != nil {
+		c.JSON(libraryErrorStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, category)
+}
+
+// GetCategory godoc
+// @Summary Get a library category
+// @Description Get category information by ID
+// @Tags libraries
+// @Produce json
+// @Param library path string true "Library slug"
+// @Param id path string true "Category ID"
+// @Success 200 {object} models.LibraryCategory
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /libraries/{library}/categories/{id} [get]
+// @Security BearerAuth
+func (h *LibraryHandler) GetCategory(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := primitive.ObjectIDFromHex(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid category ID"})
+		return
+	}
+
+	user, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	category, err := h.categoryService.GetCategory(c.Request.Context(), librarySlug(c), id, user)
+	if err != nil {
+		c.JSON(libraryErrorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, category)
+}
+
+// ListCategories godoc
+// @Summary List library categories
+// @Description List a library's categories with pagination and filters
+// @Tags libraries
+// @Produce json
+// @Param library path string true "Library slug"
+// @Param search query string false "Search term"
+// @Param limit query int false "Items per page" default(20)
+// @Param sort query string false "Sort keys: displayOrder, name, createdAt, status (prefix - for descending)" default(displayOrder,name)
+// @Param cursor query string false "Opaque cursor from a previous response's nextCursor"
+// @Param page query int false "Page number (deprecated, use cursor)" default(1)
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /libraries/{library}/categories [get]
+// @Security BearerAuth
+func (h *LibraryHandler) ListCategories(c *gin.Context) {
+	user, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	// Parse query parameters
+	search := c.Query("search")
+
+	req, ok := parsePagination(c, categoryListPagination)
+	if !ok {
+		return
+	}
+
+	page, total, err := h.categoryService.ListCategories(
+		c.Request.Context(),
+		librarySlug(c),
+		user,
+		search,
+		req,
+	)
+	if err != nil {
+		if isPaginationError(err) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(libraryErrorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"categories": page.Items,
+		"total":      total,
+		"page":       legacyPage(req),
+		"limit":      req.Limit,
+		"pagination": pagination.NewInfo(req, page, total),
+	})
+}
+
+// UpdateCategory godoc
+// @Summary Update a library category
+// @Description Update a category, renaming its Dropbox folder with it (requires the library's manage permission)
+// @Tags libraries
+// @Accept json
+// @Produce json
+// @Param library path string true "Library slug"
+// @Param id path string true "Category ID"
+// @Param request body models.UpdateLibraryCategoryRequest true "Update information"
+// @Success 200 {object} models.LibraryCategory
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /libraries/{library}/categories/{id} [put]
+// @Security BearerAuth
+func (h *LibraryHandler) UpdateCategory(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := primitive.ObjectIDFromHex(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid category ID"})
+		return
+	}
+
+	var req models.UpdateLibraryCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	ipAddress := middleware.GetIPAddress(c)
+
+	category, err := h.categoryService.UpdateCategory(c.Request.Context(), librarySlug(c), id, &req, user, ipAddress)
+	if err != nil {
+		c.JSON(libraryErrorStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, category)
+}
+
+// DeleteCategory godoc
+// @Summary Delete a library category
+// @Description Delete a category from database (Dropbox folder remains) (requires the library's manage permission)
+// @Tags libraries
+// @Produce json
+// @Param library path string true "Library slug"
+// @Param id path string true "Category ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /libraries/{library}/categories/{id} [delete]
+// @Security BearerAuth
+func (h *LibraryHandler) DeleteCategory(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := primitive.ObjectIDFromHex(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid category ID"})
+		return
+	}
+
+	user, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	ipAddress := middleware.GetIPAddress(c)
+
+	err = h.categoryService.DeleteCategory(c.Request.Context(), librarySlug(c), id, user, ipAddress)
+	if err != nil {
+		c.JSON(libraryErrorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "category deleted successfully"})
+}
+
+// GetCategoryFiles godoc
+// @Summary List files in a library category
+// @Description List all files in a category's Dropbox folder
+// @Tags libraries
+// @Produce json
+// @Param library path string true "Library slug"
+// @Param id path string true "Category ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /libraries/{library}/categories/{id}/files [get]
+// @Security BearerAuth
+func (h *LibraryHandler) GetCategoryFiles(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := primitive.ObjectIDFromHex(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid category ID"})
+		return
+	}
+
+	user, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	files, err := h.categoryService.GetCategoryFiles(c.Request.Context(), librarySlug(c), id, user)
+	if err != nil {
+		c.JSON(libraryErrorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"files": files,
+	})
+}
+
+// DownloadFile godoc
+// @Summary Get download link for a library file
+// @Description Get a temporary download link for a specific file in a category (requires the library's download permission)
+// @Tags libraries
+// @Produce json
+// @Param library path string true "Library slug"
+// @Param id path string true "Category ID"
+// @Param path query string true "File path within the category folder"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /libraries/{library}/categories/{id}/files/download [get]
+// @Security BearerAuth
+func (h *LibraryHandler) DownloadFile(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := primitive.ObjectIDFromHex(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid category ID"})
+		return
+	}
+
+	filePath := c.Query("path")
+	if filePath == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file path is required"})
+		return
+	}
+
+	user, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	downloadLink, err := h.categoryService.GetFileDownloadLink(c.Request.Context(), librarySlug(c), id, filePath, user)
+	if err != nil {
+		statusCode := libraryErrorStatus(err, http.StatusInternalServerError)
+		if err.Error() == "file not found" {
+			statusCode = http.StatusNotFound
+		}
+		c.JSON(statusCode, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"downloadLink": downloadLink,
+	})
+}
+
+// GetFileRevisions godoc
+// @Summary List revisions of a library file
+// @Description List the recorded revisions of a file in a category, newest first, including revisions Dropbox keeps
+// @Tags libraries
+// @Produce json
+// @Param library path string true "Library slug"
+// @Param id path string true "Category ID"
+// @Param path query string true "File path within the category folder"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /libraries/{library}/categories/{id}/files/revisions [get]
+// @Security BearerAuth
+func (h *LibraryHandler) GetFileRevisions(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid category ID"})
+		return
+	}
+
+	filePath := c.Query("path")
+	if filePath == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file path is required"})
+		return
+	}
+
+	user, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	revisions, err := h.revisionService.History(c.Request.Context(), librarySlug(c), id, filePath, user)
+	if err != nil {
+		c.JSON(libraryErrorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"revisions": revisions,
+		"count":     len(revisions),
+	})
+}
+
+// DownloadFileRevision godoc
+// @Summary Download a previous revision of a library file
+// @Description Stream a recorded revision of a file in a category (requires the library's download permission)
+// @Tags libraries
+// @Produce octet-stream
+// @Param library path string true "Library slug"
+// @Param id path string true "Category ID"
+// @Param rev path string true "Dropbox revision"
+// @Param path query string true "File path within the category folder"
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /libraries/{library}/categories/{id}/files/revisions/{rev}/download [get]
+// @Security BearerAuth
+func (h *LibraryHandler) DownloadFileRevision(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid category ID"})
+		return
+	}
+
+	filePath := c.Query("path")
+	if filePath == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file path is required"})
+		return
+	}
+
+	user, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	info, content, err := h.revisionService.DownloadRevision(c.Request.Context(), librarySlug(c), id, filePath, c.Param("rev"), user)
+	if err != nil {
+		c.JSON(libraryErrorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+	defer content.Close()
+
+	c.DataFromReader(http.StatusOK, int64(info.Size), "application/octet-stream", content, map[string]string{
+		"Content-Disposition": fmt.Sprintf("attachment; filename=%q", info.Name),
+	})
+}
+
+// UploadImage godoc
+// @Summary Upload library category image
+// @Description Upload an image for a library category (requires the library's manage permission)
+// @Tags libraries
+// @Accept multipart/form-data
+// @Produce json
+// @Param library path string true "Library slug"
+// @Param image formData file true "Image file (jpg, png, gif or webp, max 5MB); EXIF metadata is stripped and resized variants are generated"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /libraries/{library}/images/upload [post]
+// @Security BearerAuth
+func (h *LibraryHandler) UploadImage(c *gin.Context) {
+	user, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	data := readImageUpload(c)
+	if data == nil {
+		return
+	}
+
+	image, err := h.categoryService.UploadImage(c.Request.Context(), librarySlug(c), data, user)
+	if err == service.ErrLibraryNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	respondImageUpload(c, image, err)
+}
+
+// SeedCategories godoc
+// @Summary Seed initial library categories
+// @Description Create a built-in library's initial categories if it has none (requires the library's manage permission)
+// @Tags libraries
+// @Produce json
+// @Param library path string true "Library slug"
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /libraries/{library}/seed [post]
+// @Security BearerAuth
+func (h *LibraryHandler) SeedCategories(c *gin.Context) {
+	user, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	slug := librarySlug(c)
+	ipAddress := middleware.GetIPAddress(c)
+
+	created, existing, err := h.categoryService.SeedCategories(c.Request.Context(), slug, user, ipAddress)
+	if err != nil {
+		c.JSON(libraryErrorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	if existing > 0 {
+		req, err := pagination.ParseQuery(url.Values{"limit": {"1"}}, categoryListPagination)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check existing categories"})
+			return
+		}
+		page, _, err := h.categoryService.ListCategories(c.Request.Context(), slug, user, "", req)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check existing categories"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"message":    "categories already exist",
+			"count":      existing,
+			"categories": page.Items,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "categories seeded successfully",
+		"count":      len(created),
+		"categories": created,
+	})
+}
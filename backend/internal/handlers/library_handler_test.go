@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"backend/internal/models"
+	"backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestDownloadFileRejectsPathsOutsideCategory(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := NewLibraryHandler(nil, &service.LibraryCategoryService{}, nil)
+
+	r := gin.New()
+	r.GET("/libraries/:library/categories/:id/files/download", func(c *gin.Context) {
+		c.Set("user", &models.User{ID: primitive.NewObjectID()})
+		h.DownloadFile(c)
+	})
+
+	for _, p := range []string{"../Other/secret.pdf", "Protocols/../../../Submissions/scan.pdf", "/"} {
+		t.Run(p, func(t *testing.T) {
+			target := "/libraries/sops/categories/" + primitive.NewObjectID().Hex() + "/files/download?path=" + url.QueryEscape(p)
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, target, nil))
+			if rr.Code != http.StatusBadRequest {
+				t.Errorf("GET %s = %d %s, want %d", target, rr.Code, rr.Body.String(), http.StatusBadRequest)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"backend/internal/pagination"
+	"backend/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Pagination settings for each list endpoint
+var (
+	userListPagination = pagination.Config{
+		DefaultLimit: 20,
+		MaxLimit:     500,
+		DefaultSort:  repository.UserDefaultSort,
+		Fields:       repository.UserSortFields,
+	}
+	institutionListPagination = pagination.Config{
+		DefaultLimit: 100,
+		MaxLimit:     1000,
+		DefaultSort:  repository.InstitutionDefaultSort,
+		Fields:       repository.InstitutionSortFields,
+	}
+	categoryListPagination = pagination.Config{
+		DefaultLimit: 20,
+		MaxLimit:     100,
+		DefaultSort:  repository.CategoryDefaultSort,
+		Fields:       repository.CategorySortFields,
+	}
+	submissionListPagination = pagination.Config{
+		DefaultLimit: 10,
+		MaxLimit:     100,
+		DefaultSort:  repository.SubmissionDefaultSort,
+		Fields:       repository.SubmissionSortFields,
+	}
+	formSchemaListPagination = pagination.Config{
+		DefaultLimit: 10,
+		MaxLimit:     100,
+		DefaultSort:  repository.FormSchemaDefaultSort,
+		Fields:       repository.FormSchemaSortFields,
+	}
+	institutionImportListPagination = pagination.Config{
+		DefaultLimit: 20,
+		MaxLimit:     100,
+		DefaultSort:  repository.InstitutionImportDefaultSort,
+		Fields:       repository.InstitutionImportSortFields,
+	}
+	auditListPagination = pagination.Config{
+		DefaultLimit: 50,
+		MaxLimit:     200,
+		DefaultSort:  repository.AuditDefaultSort,
+		Fields:       repository.AuditSortFields,
+	}
+)
+
+// parsePagination reads the pagination query parameters. It writes a 400
+// response and returns false if they are invalid.
+func parsePagination(c *gin.Context, cfg pagination.Config) (pagination.Request, bool) {
+	req, err := pagination.ParseQuery(c.Request.URL.Query(), cfg)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return pagination.Request{}, false
+	}
+	return req, true
+}
+
+// isPaginationError reports whether err was caused by an invalid cursor or sort
+func isPaginationError(err error) bool {
+	return errors.Is(err, pagination.ErrInvalidCursor) ||
+		errors.Is(err, pagination.ErrCursorMismatch) ||
+		errors.Is(err, pagination.ErrInvalidSort)
+}
+
+// legacyPage converts a request's offset back into the 1-based page number
+// still returned by page/limit endpoints
+func legacyPage(req pagination.Request) int64 {
+	if req.Limit == 0 {
+		return 1
+	}
+	return req.Skip/req.Limit + 1
+}
@@ -4,11 +4,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
-	"strconv"
 	"time"
 
 	"backend/internal/middleware"
 	"backend/internal/models"
+	"backend/internal/pagination"
 	"backend/internal/repository"
 	"backend/internal/service"
 
@@ -318,34 +318,36 @@ func (h *RegistryHandler) GetActiveFormSchema(c *gin.Context) {
 // @Description List all form schemas with pagination (admin only)
 // @Tags registry
 // @Produce json
-// @Param page query int false "Page number" default(1)
 // @Param limit query int false "Items per page" default(10)
+// @Param sort query string false "Sort keys: createdAt, name, status (prefix - for descending)" default(-createdAt)
+// @Param cursor query string false "Opaque cursor from a previous response's nextCursor"
+// @Param page query int false "Page number (deprecated, use cursor)" default(1)
 // @Success 200 {object} map[string]interface{}
 // @Failure 400 {object} map[string]string
 // @Router /admin/registry/form-schemas [get]
 // @Security BearerAuth
 func (h *RegistryHandler) ListFormSchemas(c *gin.Context) {
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
-
-	if page < 1 {
-		page = 1
-	}
-	if limit < 1 || limit > 100 {
-		limit = 10
+	req, ok := parsePagination(c, formSchemaListPagination)
+	if !ok {
+		return
 	}
 
-	schemas, total, err := h.registryService.ListFormSchemas(c.Request.Context(), page, limit)
+	page, total, err := h.registryService.ListFormSchemas(c.Request.Context(), req)
 	if err != nil {
+		if isPaginationError(err) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"schemas": schemas,
-		"total":   total,
-		"page":    page,
-		"limit":   limit,
+		"schemas":    page.Items,
+		"total":      total,
+		"page":       legacyPage(req),
+		"limit":      req.Limit,
+		"pagination": pagination.NewInfo(req, page, total),
 	})
 }
 
@@ -454,7 +456,8 @@ func (h *RegistryHandler) DeleteFormSchema(c *gin.Context) {
 // @Produce json
 // @Param formData formData string true "Form data as JSON string"
 // @Param formSchemaId formData string true "Form Schema ID"
-// @Param documents formData file true "Documents to upload" collectionFormat(multi)
+// @Param documents formData file false "Documents to upload" collectionFormat(multi)
+// @Param uploadIds formData []string false "IDs of completed resumable uploads to attach" collectionFormat(multi)
 // @Success 201 {object} models.RegistrySubmission
 // @Failure 400 {object} map[string]string
 // @Failure 401 {object} map[string]string
@@ -467,6 +470,13 @@ func (h *RegistryHandler) SubmitForm(c *gin.Context) {
 		return
 	}
 
+	// Documents are passed on to storage while the request is handled,
+	// which can outlast the server's timeouts
+	deadline := time.Now().Add(documentUploadTimeout)
+	controller := http.NewResponseController(c.Writer)
+	_ = controller.SetReadDeadline(deadline)
+	_ = controller.SetWriteDeadline(deadline)
+
 	// Parse multipart form
 	if err := c.Request.ParseMultipartForm(32 << 20); err != nil { // 32 MB max
 		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to parse form"})
@@ -508,6 +518,7 @@ func (h *RegistryHandler) SubmitForm(c *gin.Context) {
 	req := &models.CreateSubmissionRequest{
 		FormSchemaID: formSchemaID,
 		FormData:     formData,
+		UploadIDs:    c.PostFormArray("uploadIds"),
 	}
 
 	ipAddress := middleware.GetIPAddress(c)
@@ -530,8 +541,10 @@ func (h *RegistryHandler) SubmitForm(c *gin.Context) {
 // @Description Get all submissions for the authenticated user
 // @Tags registry
 // @Produce json
-// @Param page query int false "Page number" default(1)
 // @Param limit query int false "Items per page" default(10)
+// @Param sort query string false "Sort keys: createdAt, status (prefix - for descending)" default(-createdAt)
+// @Param cursor query string false "Opaque cursor from a previous response's nextCursor"
+// @Param page query int false "Page number (deprecated, use cursor)" default(1)
 // @Success 200 {object} map[string]interface{}
 // @Failure 400 {object} map[string]string
 // @Failure 401 {object} map[string]string
@@ -544,27 +557,60 @@ func (h *RegistryHandler) GetUserSubmissions(c *gin.Context) {
 		return
 	}
 
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
-
-	if page < 1 {
-		page = 1
-	}
-	if limit < 1 || limit > 100 {
-		limit = 10
+	req, ok := parsePagination(c, submissionListPagination)
+	if !ok {
+		return
 	}
 
-	submissions, total, err := h.registryService.GetUserSubmissions(c.Request.Context(), user.ID, page, limit)
+	page, total, err := h.registryService.GetUserSubmissions(c.Request.Context(), user.ID, req)
 	if err != nil {
+		if isPaginationError(err) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"submissions": submissions,
+		"submissions": page.Items,
 		"total":       total,
-		"page":        page,
-		"limit":       limit,
+		"page":        legacyPage(req),
+		"limit":       req.Limit,
+		"pagination":  pagination.NewInfo(req, page, total),
+	})
+}
+
+// GetSubmissionMap godoc
+// @Summary Get submission counts by institution location
+// @Description Count registry submissions per institution with its coordinates, for mapping where submissions come from (admin only)
+// @Tags registry
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /admin/registry/submissions/map [get]
+// @Security BearerAuth
+func (h *RegistryHandler) GetSubmissionMap(c *gin.Context) {
+	user, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	locations, err := h.registryService.GetSubmissionMap(c.Request.Context(), user)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if err == service.ErrUnauthorizedRegistryAccess {
+			statusCode = http.StatusForbidden
+		}
+		c.JSON(statusCode, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"institutions": locations,
+		"total":        len(locations),
 	})
 }
 
@@ -573,8 +619,10 @@ func (h *RegistryHandler) GetUserSubmissions(c *gin.Context) {
 // @Description Get all submissions with filters (admin only)
 // @Tags registry
 // @Produce json
-// @Param page query int false "Page number" default(1)
 // @Param limit query int false "Items per page" default(10)
+// @Param sort query string false "Sort keys: createdAt, status (prefix - for descending)" default(-createdAt)
+// @Param cursor query string false "Opaque cursor from a previous response's nextCursor"
+// @Param page query int false "Page number (deprecated, use cursor)" default(1)
 // @Param status query string false "Filter by status"
 // @Success 200 {object} map[string]interface{}
 // @Failure 400 {object} map[string]string
@@ -589,14 +637,9 @@ func (h *RegistryHandler) GetAllSubmissions(c *gin.Context) {
 		return
 	}
 
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
-
-	if page < 1 {
-		page = 1
-	}
-	if limit < 1 || limit > 100 {
-		limit = 10
+	req, ok := parsePagination(c, submissionListPagination)
+	if !ok {
+		return
 	}
 
 	// Build filter
@@ -639,21 +682,24 @@ func (h *RegistryHandler) GetAllSubmissions(c *gin.Context) {
 	// Get user search parameter
 	userSearch := c.Query("userSearch")
 
-	submissions, total, err := h.registryService.GetAllSubmissions(c.Request.Context(), user, page, limit, filter, userSearch)
+	page, total, err := h.registryService.GetAllSubmissions(c.Request.Context(), user, req, filter, userSearch)
 	if err != nil {
 		statusCode := http.StatusInternalServerError
 		if err == service.ErrUnauthorizedRegistryAccess {
 			statusCode = http.StatusForbidden
+		} else if isPaginationError(err) {
+			statusCode = http.StatusBadRequest
 		}
 		c.JSON(statusCode, gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"submissions": submissions,
+		"submissions": page.Items,
 		"total":       total,
-		"page":        page,
-		"limit":       limit,
+		"page":        legacyPage(req),
+		"limit":       req.Limit,
+		"pagination":  pagination.NewInfo(req, page, total),
 	})
 }
 
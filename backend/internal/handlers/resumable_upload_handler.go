@@ -0,0 +1,203 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"backend/internal/middleware"
+	"backend/internal/models"
+	"backend/internal/resumable"
+	"backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Headers of the resumable upload protocol, named as in tus
+const (
+	uploadOffsetHeader = "Upload-Offset"
+	uploadLengthHeader = "Upload-Length"
+)
+
+// uploadChunkTimeout replaces the server's read and write timeouts for a
+// chunk, which may take a while to arrive over a slow connection
+const uploadChunkTimeout = 10 * time.Minute
+
+// ResumableUploadHandler handles chunked uploads of registry documents
+type ResumableUploadHandler struct {
+	uploadService *service.ResumableUploadService
+}
+
+// NewResumableUploadHandler creates a new ResumableUploadHandler
+func NewResumableUploadHandler(uploadService *service.ResumableUploadService) *ResumableUploadHandler {
+	return &ResumableUploadHandler{
+		uploadService: uploadService,
+	}
+}
+
+// uploadErrorStatus maps resumable upload errors to HTTP statuses
+func uploadErrorStatus(err error) int {
+	switch err {
+	case service.ErrUploadNotFound:
+		return http.StatusNotFound
+	case service.ErrUploadOffset, service.ErrUploadBusy:
+		return http.StatusConflict
+	case service.ErrUploadTooLarge:
+		return http.StatusRequestEntityTooLarge
+	case service.ErrTooManyUploads, service.ErrUploadQuota:
+		return http.StatusTooManyRequests
+	case service.ErrUploadsUnavailable:
+		return http.StatusServiceUnavailable
+	case resumable.ErrInvalidName, resumable.ErrInvalidSize:
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// setUploadHeaders reports an upload's progress in the protocol headers
+func setUploadHeaders(c *gin.Context, upload *resumable.Upload) {
+	c.Header(uploadOffsetHeader, strconv.FormatInt(upload.Offset, 10))
+	c.Header(uploadLengthHeader, strconv.FormatInt(upload.Size, 10))
+	c.Header("Cache-Control", "no-store")
+}
+
+// CreateUpload godoc
+// @Summary Start a resumable upload
+// @Description Start a chunked upload of a registry document. Send the file with PATCH requests at the returned offset, then submit the form with the upload's ID in uploadIds. Uploads expire after 24 hours.
+// @Tags registry
+// @Accept json
+// @Produce json
+// @Param request body models.CreateUploadRequest true "File name and size"
+// @Success 201 {object} resumable.Upload
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 413 {object} map[string]string
+// @Failure 429 {object} map[string]string
+// @Router /registry/uploads [post]
+// @Security BearerAuth
+func (h *ResumableUploadHandler) CreateUpload(c *gin.Context) {
+	user, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var req models.CreateUploadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	upload, err := h.uploadService.Create(&req, user)
+	if err != nil {
+		c.JSON(uploadErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	setUploadHeaders(c, upload)
+	c.Header("Location", c.Request.URL.Path+"/"+upload.ID)
+	c.JSON(http.StatusCreated, upload)
+}
+
+// GetUpload godoc
+// @Summary Get a resumable upload's progress
+// @Description Get how many bytes of an upload have been received, to resume after a dropped connection. The offset is also returned in the Upload-Offset header, so HEAD works too.
+// @Tags registry
+// @Produce json
+// @Param id path string true "Upload ID"
+// @Success 200 {object} resumable.Upload
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /registry/uploads/{id} [get]
+// @Security BearerAuth
+func (h *ResumableUploadHandler) GetUpload(c *gin.Context) {
+	user, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	upload, err := h.uploadService.Get(c.Param("id"), user)
+	if err != nil {
+		c.JSON(uploadErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	setUploadHeaders(c, upload)
+	c.JSON(http.StatusOK, upload)
+}
+
+// AppendUpload godoc
+// @Summary Send a chunk of a resumable upload
+// @Description Append the request body to an upload. Upload-Offset must equal the bytes received so far; the new offset is returned in the Upload-Offset header. If the connection drops, the bytes that arrived are kept: get the upload's offset and continue from there.
+// @Tags registry
+// @Accept application/offset+octet-stream
+// @Param id path string true "Upload ID"
+// @Param Upload-Offset header int true "Offset of the chunk in the file"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string "Offset does not match, or another chunk is being written"
+// @Failure 413 {object} map[string]string
+// @Router /registry/uploads/{id} [patch]
+// @Security BearerAuth
+func (h *ResumableUploadHandler) AppendUpload(c *gin.Context) {
+	user, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	offset, err := strconv.ParseInt(c.GetHeader(uploadOffsetHeader), 10, 64)
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Upload-Offset header is required"})
+		return
+	}
+
+	deadline := time.Now().Add(uploadChunkTimeout)
+	controller := http.NewResponseController(c.Writer)
+	_ = controller.SetReadDeadline(deadline)
+	_ = controller.SetWriteDeadline(deadline)
+
+	upload, err := h.uploadService.Append(c.Param("id"), offset, c.Request.Body, user)
+	if upload != nil {
+		setUploadHeaders(c, upload)
+	}
+	if err != nil {
+		status := uploadErrorStatus(err)
+		if upload != nil && status == http.StatusInternalServerError {
+			// The chunk was cut short; the client resumes from the new offset
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// CancelUpload godoc
+// @Summary Cancel a resumable upload
+// @Description Remove an upload and the bytes received so far
+// @Tags registry
+// @Param id path string true "Upload ID"
+// @Success 204
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /registry/uploads/{id} [delete]
+// @Security BearerAuth
+func (h *ResumableUploadHandler) CancelUpload(c *gin.Context) {
+	user, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	if err := h.uploadService.Cancel(c.Param("id"), user); err != nil {
+		c.JSON(uploadErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
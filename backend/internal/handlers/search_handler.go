@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"backend/internal/middleware"
+	"backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SearchHandler handles document search requests
+type SearchHandler struct {
+	searchService *service.SearchService
+	indexService  *service.SearchIndexService
+}
+
+// NewSearchHandler creates a new SearchHandler
+func NewSearchHandler(searchService *service.SearchService, indexService *service.SearchIndexService) *SearchHandler {
+	return &SearchHandler{
+		searchService: searchService,
+		indexService:  indexService,
+	}
+}
+
+// Search godoc
+// @Summary Search library documents
+// @Description Full-text search over the PDF, DOCX and text files of the library categories the user can download from
+// @Tags search
+// @Produce json
+// @Param q query string true "Search query (2-200 characters); quote phrases and prefix words with - to exclude them"
+// @Param library query string false "Restrict results to one library slug"
+// @Param limit query int false "Maximum results (max 100)" default(20)
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Router /search [get]
+// @Security BearerAuth
+func (h *SearchHandler) Search(c *gin.Context) {
+	user, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	limit := service.DefaultSearchLimit
+	if value := c.Query("limit"); value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive integer"})
+			return
+		}
+	}
+
+	results, err := h.searchService.Search(c.Request.Context(), user, c.Query("q"), c.Query("library"), limit)
+	if err != nil {
+		if err == service.ErrInvalidSearchQuery {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"results": results,
+		"count":   len(results),
+	})
+}
+
+// GetIndexStatus godoc
+// @Summary Get search index status
+// @Description Get the number of indexed documents and the result of the last index run
+// @Tags admin-search
+// @Produce json
+// @Success 200 {object} service.SearchIndexStatus
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /admin/search/status [get]
+// @Security BearerAuth
+func (h *SearchHandler) GetIndexStatus(c *gin.Context) {
+	status, err := h.indexService.Status(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// Reindex godoc
+// @Summary Re-index library documents
+// @Description Start a search index run in the background without waiting for the next scheduled run
+// @Tags admin-search
+// @Produce json
+// @Success 202 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /admin/search/reindex [post]
+// @Security BearerAuth
+func (h *SearchHandler) Reindex(c *gin.Context) {
+	if err := h.indexService.TriggerReindex(); err != nil {
+		statusCode := http.StatusInternalServerError
+		if err == service.ErrIndexInProgress {
+			statusCode = http.StatusConflict
+		} else if err == service.ErrDropboxNotConfigured {
+			statusCode = http.StatusBadRequest
+		}
+		c.JSON(statusCode, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "search index run started"})
+}
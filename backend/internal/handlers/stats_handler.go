@@ -7,26 +7,28 @@ import (
 
 	"backend/internal/middleware"
 	"backend/internal/models"
+	"backend/internal/pagination"
 	"backend/internal/service"
 
 	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 // StatsHandler handles admin statistics requests
 type StatsHandler struct {
-	userService        *service.UserService
-	institutionService *service.InstitutionService
-	auditService       *service.AuditService
-	sopCategoryService *service.SOPCategoryService
+	userService            *service.UserService
+	institutionService     *service.InstitutionService
+	auditService           *service.AuditService
+	libraryCategoryService *service.LibraryCategoryService
 }
 
 // NewStatsHandler creates a new StatsHandler
-func NewStatsHandler(userService *service.UserService, institutionService *service.InstitutionService, auditService *service.AuditService, sopCategoryService *service.SOPCategoryService) *StatsHandler {
+func NewStatsHandler(userService *service.UserService, institutionService *service.InstitutionService, auditService *service.AuditService, libraryCategoryService *service.LibraryCategoryService) *StatsHandler {
 	return &StatsHandler{
-		userService:        userService,
-		institutionService: institutionService,
-		auditService:       auditService,
-		sopCategoryService: sopCategoryService,
+		userService:            userService,
+		institutionService:     institutionService,
+		auditService:           auditService,
+		libraryCategoryService: libraryCategoryService,
 	}
 }
 
@@ -96,7 +98,7 @@ func (h *StatsHandler) GetAdminStats(c *gin.Context) {
 	}
 
 	// Get total SOPs (active categories only)
-	totalSOPs, err := h.sopCategoryService.CountCategories(ctx, true)
+	totalSOPs, err := h.libraryCategoryService.CountCategories(ctx, models.LibrarySlugSOPs, true)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get total SOPs"})
 		return
@@ -199,3 +201,53 @@ func (h *StatsHandler) GetRecentActivity(c *gin.Context) {
 
 	c.JSON(http.StatusOK, activities)
 }
+
+// ListAuditLogs godoc
+// @Summary List audit logs
+// @Description Get audit log entries using cursor pagination
+// @Tags stats
+// @Produce json
+// @Param action query string false "Filter by action"
+// @Param userId query string false "Filter by the user the entry concerns"
+// @Param limit query int false "Items per page" default(50)
+// @Param sort query string false "Sort keys: createdAt (prefix - for descending)" default(-createdAt)
+// @Param cursor query string false "Opaque cursor from a previous response's nextCursor"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /stats/audit-logs [get]
+// @Security BearerAuth
+func (h *StatsHandler) ListAuditLogs(c *gin.Context) {
+	var userID *primitive.ObjectID
+	if userIDParam := c.Query("userId"); userIDParam != "" {
+		id, err := primitive.ObjectIDFromHex(userIDParam)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+			return
+		}
+		userID = &id
+	}
+
+	req, ok := parsePagination(c, auditListPagination)
+	if !ok {
+		return
+	}
+
+	page, total, err := h.auditService.ListAuditLogs(c.Request.Context(), c.Query("action"), userID, req)
+	if err != nil {
+		if isPaginationError(err) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list audit logs"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"logs":       page.Items,
+		"total":      total,
+		"limit":      req.Limit,
+		"pagination": pagination.NewInfo(req, page, total),
+	})
+}
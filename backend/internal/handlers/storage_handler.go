@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"net/http"
+
+	"backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StorageHandler handles storage driver status and local storage links
+type StorageHandler struct {
+	storageService *service.StorageService
+}
+
+// NewStorageHandler creates a new StorageHandler
+func NewStorageHandler(storageService *service.StorageService) *StorageHandler {
+	return &StorageHandler{
+		storageService: storageService,
+	}
+}
+
+// GetStatus godoc
+// @Summary Get storage driver status
+// @Description List the storage drivers (dropbox, local, s3), whether each is configured, how many libraries use it and whether registry submissions do
+// @Tags admin-storage
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /admin/storage [get]
+// @Security BearerAuth
+func (h *StorageHandler) GetStatus(c *gin.Context) {
+	drivers, err := h.storageService.Status(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"drivers": drivers})
+}
+
+// ServeLocalFile godoc
+// @Summary Download a file from local storage
+// @Description Serve a file kept in local disk storage through a signed, expiring link. Links are issued by the download and share endpoints; no login is needed.
+// @Tags storage
+// @Produce octet-stream
+// @Param path query string true "File path"
+// @Param expires query int true "Expiry time (Unix seconds)"
+// @Param sig query string true "Link signature"
+// @Success 200 {file} file
+// @Failure 403 {string} string "Invalid link"
+// @Failure 404 {string} string "File not found or local storage disabled"
+// @Failure 410 {string} string "Link has expired"
+// @Router /storage/local [get]
+func (h *StorageHandler) ServeLocalFile(c *gin.Context) {
+	links := h.storageService.LocalLinks()
+	if links == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "local storage is not enabled"})
+		return
+	}
+	links.ServeHTTP(c.Writer, c.Request)
+}
@@ -2,10 +2,10 @@ package handlers
 
 import (
 	"net/http"
-	"strconv"
 
 	"backend/internal/middleware"
 	"backend/internal/models"
+	"backend/internal/pagination"
 	"backend/internal/repository"
 	"backend/internal/service"
 
@@ -324,9 +324,11 @@ func (h *UserHandler) ActivateUser(c *gin.Context) {
 // @Produce json
 // @Param role query string false "Filter by role"
 // @Param is_active query bool false "Filter by active status"
-// @Param search query string false "Search by name, email, role, or institution"
+// @Param search query string false "Search by name, email, role, or institution, or an exact registration or phone number"
 // @Param limit query int false "Limit number of results" default(20)
-// @Param skip query int false "Skip number of results" default(0)
+// @Param sort query string false "Sort keys: createdAt, name, lastLoginAt, status (prefix - for descending)" default(-createdAt)
+// @Param cursor query string false "Opaque cursor from a previous response's nextCursor"
+// @Param skip query int false "Skip number of results (deprecated, use cursor)" default(0)
 // @Success 200 {object} map[string]interface{}
 // @Failure 400 {object} map[string]string
 // @Failure 401 {object} map[string]string
@@ -351,30 +353,26 @@ func (h *UserHandler) ListUsers(c *gin.Context) {
 	// Get search query parameter
 	search := c.Query("search")
 
-	limit := int64(20)
-	if limitParam := c.Query("limit"); limitParam != "" {
-		if l, err := strconv.ParseInt(limitParam, 10, 64); err == nil && l > 0 {
-			limit = l
-		}
-	}
-
-	skip := int64(0)
-	if skipParam := c.Query("skip"); skipParam != "" {
-		if s, err := strconv.ParseInt(skipParam, 10, 64); err == nil && s >= 0 {
-			skip = s
-		}
+	req, ok := parsePagination(c, userListPagination)
+	if !ok {
+		return
 	}
 
-	users, count, err := h.userService.ListUsers(c.Request.Context(), role, isActive, search, limit, skip)
+	page, count, err := h.userService.ListUsers(c.Request.Context(), role, isActive, search, req)
 	if err != nil {
+		if isPaginationError(err) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"users": users,
-		"total": count,
-		"limit": limit,
-		"skip":  skip,
+		"users":      page.Items,
+		"total":      count,
+		"limit":      req.Limit,
+		"skip":       req.Skip,
+		"pagination": pagination.NewInfo(req, page, count),
 	})
 }
@@ -0,0 +1,253 @@
+// Package imaging sanitises uploaded images and renders the resized variants
+// served to clients.
+//
+// Uploads are identified by their magic bytes rather than their file name,
+// decoded, and re-encoded from pixels so that EXIF, GPS and any other embedded
+// metadata is dropped. The EXIF orientation is applied before it is discarded
+// so photos keep the rotation the camera intended. Opaque images are written
+// as JPEG and images with transparency as lossless WebP, whatever format was
+// uploaded.
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+
+	// Registered for image.Decode
+	_ "image/gif"
+	_ "image/png"
+
+	"github.com/HugoSmits86/nativewebp"
+	_ "golang.org/x/image/webp"
+)
+
+// Format is an image container format recognised from magic bytes
+type Format string
+
+const (
+	FormatJPEG Format = "jpeg"
+	FormatPNG  Format = "png"
+	FormatGIF  Format = "gif"
+	FormatWebP Format = "webp"
+)
+
+// Variant names
+const (
+	VariantThumbnail = "thumbnail"
+	VariantCard      = "card"
+	VariantFull      = "full"
+)
+
+// Size is the bounding box a variant is scaled to fit. Images are never
+// enlarged.
+type Size struct {
+	Name      string
+	MaxWidth  int
+	MaxHeight int
+}
+
+// DefaultSizes are the variants generated for every upload
+var DefaultSizes = []Size{
+	{Name: VariantThumbnail, MaxWidth: 200, MaxHeight: 200},
+	{Name: VariantCard, MaxWidth: 640, MaxHeight: 640},
+	{Name: VariantFull, MaxWidth: 1600, MaxHeight: 1600},
+}
+
+// MaxPixels bounds the decoded size of an upload so a small file cannot
+// expand into an enormous bitmap
+const MaxPixels = 40_000_000
+
+// jpegQuality is the quality used for re-encoded JPEG variants
+const jpegQuality = 85
+
+var (
+	ErrUnsupportedFormat = errors.New("unsupported image format. Allowed: jpg, jpeg, png, gif, webp")
+	ErrImageTooLarge     = errors.New("image dimensions are too large")
+	ErrCorruptImage      = errors.New("image could not be decoded")
+)
+
+// Variant is one encoded rendition of an image
+type Variant struct {
+	Name        string
+	Data        []byte
+	Width       int
+	Height      int
+	ContentType string
+	Extension   string
+}
+
+// DetectFormat identifies an image from its leading bytes
+func DetectFormat(data []byte) (Format, error) {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xFF, 0xD8, 0xFF}):
+		return FormatJPEG, nil
+	case bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")):
+		return FormatPNG, nil
+	case bytes.HasPrefix(data, []byte("GIF87a")), bytes.HasPrefix(data, []byte("GIF89a")):
+		return FormatGIF, nil
+	case len(data) >= 12 && string(data[0:4]) == "RIFF" && string(data[8:12]) == "WEBP":
+		return FormatWebP, nil
+	}
+	return "", ErrUnsupportedFormat
+}
+
+// Process verifies and sanitises an uploaded image and returns its variants
+// in the order of sizes. Animated GIFs keep only their first frame.
+func Process(data []byte, sizes []Size) ([]Variant, error) {
+	format, err := DetectFormat(data)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrCorruptImage
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > MaxPixels {
+		return nil, ErrImageTooLarge
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrCorruptImage
+	}
+
+	img := toNRGBA(src)
+	if format == FormatJPEG {
+		img = applyOrientation(img, jpegOrientation(data))
+	}
+	opaque := img.Opaque()
+
+	variants := make([]Variant, 0, len(sizes))
+	for _, size := range sizes {
+		w, h := fit(img.Bounds().Dx(), img.Bounds().Dy(), size.MaxWidth, size.MaxHeight)
+		scaled := img
+		if w != img.Bounds().Dx() || h != img.Bounds().Dy() {
+			scaled = resize(img, w, h)
+		}
+
+		variant, err := encode(scaled, opaque)
+		if err != nil {
+			return nil, err
+		}
+		variant.Name = size.Name
+		variants = append(variants, variant)
+	}
+	return variants, nil
+}
+
+// encode writes opaque images as JPEG and transparent ones as lossless WebP,
+// which keeps the alpha channel and is smaller than PNG
+func encode(img *image.NRGBA, opaque bool) (Variant, error) {
+	var buf bytes.Buffer
+	variant := Variant{Width: img.Bounds().Dx(), Height: img.Bounds().Dy()}
+
+	if opaque {
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality}); err != nil {
+			return Variant{}, fmt.Errorf("failed to encode jpeg: %w", err)
+		}
+		variant.ContentType = "image/jpeg"
+		variant.Extension = ".jpg"
+	} else {
+		if err := nativewebp.Encode(&buf, img, nil); err != nil {
+			return Variant{}, fmt.Errorf("failed to encode webp: %w", err)
+		}
+		variant.ContentType = "image/webp"
+		variant.Extension = ".webp"
+	}
+
+	variant.Data = buf.Bytes()
+	return variant, nil
+}
+
+// fit scales width and height down to fit within the bounding box, keeping
+// the aspect ratio
+func fit(width, height, maxWidth, maxHeight int) (int, int) {
+	if width <= maxWidth && height <= maxHeight {
+		return width, height
+	}
+
+	scale := float64(maxWidth) / float64(width)
+	if s := float64(maxHeight) / float64(height); s < scale {
+		scale = s
+	}
+
+	w := int(float64(width)*scale + 0.5)
+	h := int(float64(height)*scale + 0.5)
+	return max(w, 1), max(h, 1)
+}
+
+// toNRGBA copies any decoded image into a zero-based NRGBA bitmap
+func toNRGBA(src image.Image) *image.NRGBA {
+	b := src.Bounds()
+	dst := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), src, b.Min, draw.Src)
+	return dst
+}
+
+// resize downscales with an area average, which avoids the aliasing of
+// nearest-neighbour sampling when shrinking photos to thumbnails. Colour is
+// averaged with alpha weighting so transparent pixels do not bleed.
+func resize(src *image.NRGBA, width, height int) *image.NRGBA {
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+	sw, sh := src.Bounds().Dx(), src.Bounds().Dy()
+	xScale := float64(sw) / float64(width)
+	yScale := float64(sh) / float64(height)
+
+	for y := 0; y < height; y++ {
+		y0, y1 := float64(y)*yScale, float64(y+1)*yScale
+		for x := 0; x < width; x++ {
+			x0, x1 := float64(x)*xScale, float64(x+1)*xScale
+
+			var r, g, b, a, area float64
+			for sy := int(y0); sy < sh && float64(sy) < y1; sy++ {
+				wy := overlap(y0, y1, sy)
+				for sx := int(x0); sx < sw && float64(sx) < x1; sx++ {
+					w := wy * overlap(x0, x1, sx)
+					i := src.PixOffset(sx, sy)
+					pa := float64(src.Pix[i+3]) * w
+					r += float64(src.Pix[i]) * pa
+					g += float64(src.Pix[i+1]) * pa
+					b += float64(src.Pix[i+2]) * pa
+					a += pa
+					area += w
+				}
+			}
+
+			i := dst.PixOffset(x, y)
+			if a > 0 {
+				dst.Pix[i] = clamp(r / a)
+				dst.Pix[i+1] = clamp(g / a)
+				dst.Pix[i+2] = clamp(b / a)
+			}
+			if area > 0 {
+				dst.Pix[i+3] = clamp(a / area)
+			}
+		}
+	}
+	return dst
+}
+
+// overlap returns how much of source pixel p lies within [lo, hi)
+func overlap(lo, hi float64, p int) float64 {
+	start := max(lo, float64(p))
+	end := min(hi, float64(p+1))
+	if end <= start {
+		return 0
+	}
+	return end - start
+}
+
+func clamp(v float64) uint8 {
+	if v <= 0 {
+		return 0
+	}
+	if v >= 255 {
+		return 255
+	}
+	return uint8(v + 0.5)
+}
@@ -0,0 +1,176 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"testing"
+
+	"golang.org/x/image/webp"
+)
+
+// exifSegment builds an APP1 segment carrying only an orientation tag
+func exifSegment(orientation uint16) []byte {
+	tiff := []byte("II*\x00\x08\x00\x00\x00")
+	tiff = binary.LittleEndian.AppendUint16(tiff, 1)
+	tiff = binary.LittleEndian.AppendUint16(tiff, exifOrientationTag)
+	tiff = binary.LittleEndian.AppendUint16(tiff, 3) // SHORT
+	tiff = binary.LittleEndian.AppendUint32(tiff, 1)
+	tiff = binary.LittleEndian.AppendUint16(tiff, orientation)
+	tiff = append(tiff, 0, 0, 0, 0, 0, 0)
+
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	segment := []byte{0xFF, 0xE1}
+	segment = binary.BigEndian.AppendUint16(segment, uint16(len(payload)+2))
+	return append(segment, payload...)
+}
+
+func testJPEG(t *testing.T, w, h int, orientation uint16) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewGray(image.Rect(0, 0, w, h)), nil); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	return append(append(append([]byte{}, data[:2]...), exifSegment(orientation)...), data[2:]...)
+}
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		want    Format
+		wantErr bool
+	}{
+		{"jpeg", []byte{0xFF, 0xD8, 0xFF, 0xE0}, FormatJPEG, false},
+		{"png", []byte("\x89PNG\r\n\x1a\n...."), FormatPNG, false},
+		{"gif", []byte("GIF89a...."), FormatGIF, false},
+		{"webp", []byte("RIFF\x00\x00\x00\x00WEBPVP8 "), FormatWebP, false},
+		{"html renamed to png", []byte("<html><body>"), "", true},
+		{"empty", nil, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DetectFormat(tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DetectFormat() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("DetectFormat() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProcessJPEG(t *testing.T) {
+	data := testJPEG(t, 2000, 1000, 6)
+	if jpegOrientation(data) != 6 {
+		t.Fatalf("test fixture orientation not readable")
+	}
+
+	variants, err := Process(data, DefaultSizes)
+	if err != nil {
+		t.Fatalf("Process() error = %v", err)
+	}
+	if len(variants) != len(DefaultSizes) {
+		t.Fatalf("got %d variants, want %d", len(variants), len(DefaultSizes))
+	}
+
+	want := map[string][2]int{
+		VariantThumbnail: {100, 200},
+		VariantCard:      {320, 640},
+		VariantFull:      {800, 1600},
+	}
+	for _, v := range variants {
+		if got := [2]int{v.Width, v.Height}; got != want[v.Name] {
+			t.Errorf("%s size = %v, want %v (rotated and scaled)", v.Name, got, want[v.Name])
+		}
+		if v.ContentType != "image/jpeg" {
+			t.Errorf("%s content type = %q, want image/jpeg", v.Name, v.ContentType)
+		}
+		if bytes.Contains(v.Data, []byte("Exif")) {
+			t.Errorf("%s still contains EXIF metadata", v.Name)
+		}
+	}
+}
+
+func TestProcessTransparentPNG(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 50, 40))
+	img.Set(10, 10, color.NRGBA{R: 255, A: 255})
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+
+	variants, err := Process(buf.Bytes(), DefaultSizes)
+	if err != nil {
+		t.Fatalf("Process() error = %v", err)
+	}
+	for _, v := range variants {
+		if v.ContentType != "image/webp" || v.Extension != ".webp" {
+			t.Errorf("%s content type = %q %q, want image/webp to keep transparency", v.Name, v.ContentType, v.Extension)
+		}
+		decoded, err := webp.Decode(bytes.NewReader(v.Data))
+		if err != nil {
+			t.Fatalf("%s is not a valid WebP: %v", v.Name, err)
+		}
+		if got := color.NRGBAModel.Convert(decoded.At(10, 10)).(color.NRGBA); got != (color.NRGBA{R: 255, A: 255}) {
+			t.Errorf("%s pixel = %v, want opaque red", v.Name, got)
+		}
+		if _, _, _, a := decoded.At(0, 0).RGBA(); a != 0 {
+			t.Errorf("%s lost its transparency", v.Name)
+		}
+		if v.Width != 50 || v.Height != 40 {
+			t.Errorf("%s size = %dx%d, small images must not be enlarged", v.Name, v.Width, v.Height)
+		}
+	}
+}
+
+func TestProcessWebP(t *testing.T) {
+	rose, err := os.ReadFile("testdata/rose.webp")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Append an EXIF chunk, which must not survive re-encoding
+	exif := append([]byte("EXIF"), binary.LittleEndian.AppendUint32(nil, 10)...)
+	data := append(append([]byte{}, rose...), append(exif, []byte("GPS secret")...)...)
+	binary.LittleEndian.PutUint32(data[4:], uint32(len(data)-8))
+
+	variants, err := Process(data, DefaultSizes)
+	if err != nil {
+		t.Fatalf("Process() error = %v", err)
+	}
+
+	want := []struct {
+		name          string
+		width, height int
+	}{
+		{VariantThumbnail, 200, 151},
+		{VariantCard, 400, 301},
+		{VariantFull, 400, 301},
+	}
+	if len(variants) != len(want) {
+		t.Fatalf("got %d variants, want %d", len(variants), len(want))
+	}
+	for i, w := range want {
+		v := variants[i]
+		if v.Name != w.name || v.Width != w.width || v.Height != w.height {
+			t.Errorf("variant %d = %s %dx%d, want %s %dx%d", i, v.Name, v.Width, v.Height, w.name, w.width, w.height)
+		}
+		if v.ContentType != "image/jpeg" {
+			t.Errorf("%s content type = %q, want image/jpeg", v.Name, v.ContentType)
+		}
+		if _, err := jpeg.Decode(bytes.NewReader(v.Data)); err != nil {
+			t.Errorf("%s is not a valid JPEG: %v", v.Name, err)
+		}
+		if bytes.Contains(v.Data, []byte("GPS secret")) {
+			t.Errorf("%s kept the EXIF chunk", v.Name)
+		}
+	}
+}
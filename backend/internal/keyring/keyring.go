@@ -0,0 +1,221 @@
+// Package keyring encrypts values with AES-256-GCM under a set of named
+// keys, so that keys can be rotated without losing stored data.
+//
+// A ciphertext is the ID of the key that sealed it, a colon, and the
+// base64 of the nonce followed by the sealed value. New values are always
+// sealed with the primary key; any key in the ring can open them. Values
+// sealed before keys had IDs carry no prefix and are opened with whichever
+// key in the ring accepts them.
+package keyring
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// KeySize is the length of an AES-256 key in bytes
+const KeySize = 32
+
+// separator ends the key ID prefixed onto a ciphertext. Base64 never
+// contains it, so unprefixed ciphertexts are told apart.
+const separator = ":"
+
+var (
+	ErrInvalidKey        = errors.New("encryption key must be 32 bytes for AES-256")
+	ErrInvalidKeyID      = errors.New("key ID must be 1 to 32 letters, digits, dashes, dots or underscores")
+	ErrDuplicateKeyID    = errors.New("key ID is used by more than one key")
+	ErrNoKeys            = errors.New("keyring has no keys")
+	ErrUnknownPrimary    = errors.New("primary key is not in the keyring")
+	ErrUnknownKey        = errors.New("ciphertext was encrypted with a key that is not in the keyring")
+	ErrInvalidCiphertext = errors.New("invalid ciphertext: too short or malformed")
+)
+
+var keyIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,32}$`)
+
+// Key is one named key of a keyring
+type Key struct {
+	ID     string
+	Secret []byte
+}
+
+// Keyring holds the keys values can be opened with and the primary key new
+// values are sealed with
+type Keyring struct {
+	ciphers map[string]cipher.AEAD
+	ids     []string // In the order given, for opening unprefixed values
+	primary string
+}
+
+// New creates a keyring. The primary key must be one of the keys.
+func New(keys []Key, primary string) (*Keyring, error) {
+	if len(keys) == 0 {
+		return nil, ErrNoKeys
+	}
+
+	k := &Keyring{ciphers: map[string]cipher.AEAD{}, primary: primary}
+	for _, key := range keys {
+		if !keyIDPattern.MatchString(key.ID) {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidKeyID, key.ID)
+		}
+		if _, ok := k.ciphers[key.ID]; ok {
+			return nil, fmt.Errorf("%w: %q", ErrDuplicateKeyID, key.ID)
+		}
+		if len(key.Secret) != KeySize {
+			return nil, fmt.Errorf("%w: key %q", ErrInvalidKey, key.ID)
+		}
+
+		block, err := aes.NewCipher(key.Secret)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create cipher: %w", err)
+		}
+		gcm, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create GCM: %w", err)
+		}
+		k.ciphers[key.ID] = gcm
+		k.ids = append(k.ids, key.ID)
+	}
+
+	if _, ok := k.ciphers[primary]; !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownPrimary, primary)
+	}
+	return k, nil
+}
+
+// Parse reads keys written as comma-separated ID:base64 pairs, such as
+// "2025-10:3q2+7w...,2024:q83v...". Spaces around entries are ignored.
+func Parse(spec string) ([]Key, error) {
+	keys := []Key{}
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		id, encoded, ok := strings.Cut(entry, separator)
+		if !ok {
+			return nil, fmt.Errorf("key %q must be written as ID:base64", entry)
+		}
+		secret, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode key %q: %w", id, err)
+		}
+		keys = append(keys, Key{ID: strings.TrimSpace(id), Secret: secret})
+	}
+	return keys, nil
+}
+
+// Primary returns the ID of the key new values are sealed with
+func (k *Keyring) Primary() string {
+	return k.primary
+}
+
+// IDs returns the IDs of every key in the ring
+func (k *Keyring) IDs() []string {
+	return append([]string(nil), k.ids...)
+}
+
+// KeyID returns the ID of the key a ciphertext was sealed with, or "" when
+// it was sealed before keys had IDs
+func KeyID(ciphertext string) string {
+	id, _, ok := strings.Cut(ciphertext, separator)
+	if !ok {
+		return ""
+	}
+	return id
+}
+
+// Encrypt seals plaintext with the primary key. The empty string stays
+// empty, so unset fields remain unset.
+func (k *Keyring) Encrypt(plaintext string) (string, error) {
+	if plaintext == "" {
+		return "", nil
+	}
+
+	gcm := k.ciphers[k.primary]
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return k.primary + separator + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt opens a ciphertext with the key it names, or with any key in the
+// ring when it names none
+func (k *Keyring) Decrypt(ciphertext string) (string, error) {
+	if ciphertext == "" {
+		return "", nil
+	}
+
+	id, encoded, prefixed := strings.Cut(ciphertext, separator)
+	if !prefixed {
+		encoded = ciphertext
+	}
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode ciphertext: %w", err)
+	}
+
+	if prefixed {
+		gcm, ok := k.ciphers[id]
+		if !ok {
+			return "", fmt.Errorf("%w: %q", ErrUnknownKey, id)
+		}
+		return open(gcm, data)
+	}
+
+	err = ErrInvalidCiphertext
+	for _, id := range k.ids {
+		var plaintext string
+		if plaintext, err = open(k.ciphers[id], data); err == nil {
+			return plaintext, nil
+		}
+	}
+	return "", err
+}
+
+// NeedsRotation reports whether a ciphertext was sealed with a key other
+// than the primary key, or before keys had IDs
+func (k *Keyring) NeedsRotation(ciphertext string) bool {
+	return ciphertext != "" && KeyID(ciphertext) != k.primary
+}
+
+// Rotate seals a ciphertext again with the primary key
+func (k *Keyring) Rotate(ciphertext string) (string, error) {
+	plaintext, err := k.Decrypt(ciphertext)
+	if err != nil {
+		return "", err
+	}
+	return k.Encrypt(plaintext)
+}
+
+// GenerateKey returns a new random key
+func GenerateKey() ([]byte, error) {
+	key := make([]byte, KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("failed to generate key: %w", err)
+	}
+	return key, nil
+}
+
+func open(gcm cipher.AEAD, data []byte) (string, error) {
+	nonceSize := gcm.NonceSize()
+	if len(data) < nonceSize {
+		return "", ErrInvalidCiphertext
+	}
+
+	plaintext, err := gcm.Open(nil, data[:nonceSize], data[nonceSize:], nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt: %w", err)
+	}
+	return string(plaintext), nil
+}
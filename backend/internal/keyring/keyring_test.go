@@ -0,0 +1,161 @@
+package keyring
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func newKey(t *testing.T, id string) Key {
+	t.Helper()
+	secret, err := GenerateKey()
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	return Key{ID: id, Secret: secret}
+}
+
+// legacyEncrypt seals a value the way values were sealed before keys had
+// IDs: base64 of the nonce and sealed value, without a prefix
+func legacyEncrypt(t *testing.T, secret []byte, plaintext string) string {
+	t.Helper()
+	block, err := aes.NewCipher(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		t.Fatal(err)
+	}
+	return base64.StdEncoding.EncodeToString(gcm.Seal(nonce, nonce, []byte(plaintext), nil))
+}
+
+func TestNew(t *testing.T) {
+	a, b := newKey(t, "a"), newKey(t, "b")
+
+	tests := []struct {
+		name    string
+		keys    []Key
+		primary string
+		wantErr error
+	}{
+		{name: "keyring", keys: []Key{a, b}, primary: "b"},
+		{name: "no keys", primary: "a", wantErr: ErrNoKeys},
+		{name: "unknown primary", keys: []Key{a}, primary: "b", wantErr: ErrUnknownPrimary},
+		{name: "duplicate ID", keys: []Key{a, a}, primary: "a", wantErr: ErrDuplicateKeyID},
+		{name: "ID with separator", keys: []Key{{ID: "a:b", Secret: a.Secret}}, primary: "a:b", wantErr: ErrInvalidKeyID},
+		{name: "empty ID", keys: []Key{{Secret: a.Secret}}, wantErr: ErrInvalidKeyID},
+		{name: "short key", keys: []Key{{ID: "a", Secret: []byte("short")}}, primary: "a", wantErr: ErrInvalidKey},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.keys, tt.primary)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("New() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	secret := bytes.Repeat([]byte{1}, KeySize)
+	encoded := base64.StdEncoding.EncodeToString(secret)
+
+	keys, err := Parse(" 2025-10:" + encoded + ", 2024 : " + encoded + ",")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if len(keys) != 2 || keys[0].ID != "2025-10" || keys[1].ID != "2024" || !bytes.Equal(keys[1].Secret, secret) {
+		t.Errorf("Parse() = %+v", keys)
+	}
+
+	if keys, err := Parse(""); err != nil || len(keys) != 0 {
+		t.Errorf("Parse(\"\") = %v, %v, want no keys", keys, err)
+	}
+	for _, spec := range []string{encoded, "a:not base64!"} {
+		if _, err := Parse(spec); err == nil {
+			t.Errorf("Parse(%q) error = nil, want an error", spec)
+		}
+	}
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	old, current := newKey(t, "2024"), newKey(t, "2025")
+	before, err := New([]Key{old}, "2024")
+	if err != nil {
+		t.Fatal(err)
+	}
+	after, err := New([]Key{current, old}, "2025")
+	if err != nil {
+		t.Fatal(err)
+	}
+	withoutOld, err := New([]Key{current}, "2025")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sealedBefore, err := before.Encrypt("sl.token")
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+	if KeyID(sealedBefore) != "2024" {
+		t.Errorf("KeyID(%q) = %q, want 2024", sealedBefore, KeyID(sealedBefore))
+	}
+	legacy := legacyEncrypt(t, old.Secret, "sl.token")
+
+	tests := []struct {
+		name       string
+		keyring    *Keyring
+		ciphertext string
+		want       string
+		wantErr    bool
+		wantRotate bool
+	}{
+		{name: "old key still opens its values", keyring: after, ciphertext: sealedBefore, want: "sl.token", wantRotate: true},
+		{name: "unprefixed value", keyring: after, ciphertext: legacy, want: "sl.token", wantRotate: true},
+		{name: "dropped key", keyring: withoutOld, ciphertext: sealedBefore, wantErr: true, wantRotate: true},
+		{name: "unprefixed value of a dropped key", keyring: withoutOld, ciphertext: legacy, wantErr: true, wantRotate: true},
+		{name: "empty stays empty", keyring: after, ciphertext: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.keyring.Decrypt(tt.ciphertext)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Decrypt() error = %v, wantErr %t", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Decrypt() = %q, want %q", got, tt.want)
+			}
+			if rotate := tt.keyring.NeedsRotation(tt.ciphertext); rotate != tt.wantRotate {
+				t.Errorf("NeedsRotation() = %v, want %v", rotate, tt.wantRotate)
+			}
+		})
+	}
+
+	if _, err := withoutOld.Decrypt(sealedBefore); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("Decrypt() with a dropped key error = %v, want %v", err, ErrUnknownKey)
+	}
+
+	rotated, err := after.Rotate(sealedBefore)
+	if err != nil {
+		t.Fatalf("Rotate() error = %v", err)
+	}
+	if !strings.HasPrefix(rotated, "2025:") || after.NeedsRotation(rotated) {
+		t.Errorf("Rotate() = %q, want a value sealed with 2025", rotated)
+	}
+	if got, err := withoutOld.Decrypt(rotated); err != nil || got != "sl.token" {
+		t.Errorf("Decrypt(rotated) = %q, %v, want sl.token", got, err)
+	}
+}
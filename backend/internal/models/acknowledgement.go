@@ -0,0 +1,261 @@
+package models
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrInvalidAcknowledgementPath = errors.New("document path is required")
+	ErrInvalidAcknowledgementRole = errors.New("unknown role in audience")
+)
+
+// Acknowledgement statuses
+const (
+	AcknowledgementStatusAcknowledged = "acknowledged"
+	AcknowledgementStatusOutstanding  = "outstanding"
+)
+
+// AcknowledgementAudience narrows who must acknowledge a document. Each
+// non-empty list must match the user; an empty audience means everyone who
+// can view the library.
+type AcknowledgementAudience struct {
+	Roles          []UserRole           `bson:"roles,omitempty" json:"roles,omitempty"`
+	InstitutionIDs []primitive.ObjectID `bson:"institution_ids,omitempty" json:"institutionIds,omitempty"`
+	Specialties    []string             `bson:"specialties,omitempty" json:"specialties,omitempty"`
+}
+
+// Validate checks the audience's roles
+func (a *AcknowledgementAudience) Validate() error {
+	for _, role := range a.Roles {
+		if !role.IsValid() {
+			return ErrInvalidAcknowledgementRole
+		}
+	}
+	return nil
+}
+
+// Matches reports whether the user is in the audience
+func (a *AcknowledgementAudience) Matches(user *User) bool {
+	if len(a.Roles) > 0 {
+		found := false
+		for _, role := range a.Roles {
+			if role == user.Role {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if len(a.InstitutionIDs) > 0 {
+		if user.Profile.InstitutionID == nil {
+			return false
+		}
+		found := false
+		for _, id := range a.InstitutionIDs {
+			if id == *user.Profile.InstitutionID {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if len(a.Specialties) > 0 {
+		specialty := strings.TrimSpace(user.Profile.Specialty)
+		found := false
+		for _, s := range a.Specialties {
+			if strings.EqualFold(strings.TrimSpace(s), specialty) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+// AcknowledgementRequirement marks a document in a library category as one
+// staff must confirm they have read. Acknowledgements are recorded against a
+// revision, so a new revision of the document makes everyone acknowledge it
+// again.
+type AcknowledgementRequirement struct {
+	ID         primitive.ObjectID      `bson:"_id,omitempty" json:"id"`
+	LibraryID  primitive.ObjectID      `bson:"library_id" json:"libraryId"`
+	CategoryID primitive.ObjectID      `bson:"category_id" json:"categoryId"`
+	Path       string                  `bson:"path" json:"path"` // Relative to the category folder
+	PathLower  string                  `bson:"path_lower" json:"-"`
+	Name       string                  `bson:"name" json:"name"`
+	Audience   AcknowledgementAudience `bson:"audience" json:"audience"`
+	CurrentRev string                  `bson:"current_rev" json:"currentRev"`
+	RevisedAt  time.Time               `bson:"revised_at" json:"revisedAt"` // When the current revision was first required
+	IsActive   bool                    `bson:"is_active" json:"isActive"`
+	CreatedAt  time.Time               `bson:"created_at" json:"createdAt"`
+	UpdatedAt  time.Time               `bson:"updated_at" json:"updatedAt"`
+	CreatedBy  *primitive.ObjectID     `bson:"created_by,omitempty" json:"createdBy,omitempty"`
+}
+
+// CreateAcknowledgementRequirementRequest asks for a document to be acknowledged
+type CreateAcknowledgementRequirementRequest struct {
+	Path     string                  `json:"path" binding:"required"`
+	Audience AcknowledgementAudience `json:"audience"`
+}
+
+// UpdateAcknowledgementRequirementRequest changes a requirement's audience or status
+type UpdateAcknowledgementRequirementRequest struct {
+	Audience *AcknowledgementAudience `json:"audience,omitempty"`
+	IsActive *bool                    `json:"isActive,omitempty"`
+}
+
+// AcknowledgeRequest confirms a user has read a specific revision
+type AcknowledgeRequest struct {
+	Rev string `json:"rev" binding:"required"`
+}
+
+// DocumentAcknowledgement records that a user read and understood one
+// revision of a document
+type DocumentAcknowledgement struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	RequirementID  primitive.ObjectID `bson:"requirement_id" json:"requirementId"`
+	UserID         primitive.ObjectID `bson:"user_id" json:"userId"`
+	Rev            string             `bson:"rev" json:"rev"`
+	AcknowledgedAt time.Time          `bson:"acknowledged_at" json:"acknowledgedAt"`
+	IPAddress      string             `bson:"ip_address,omitempty" json:"ipAddress,omitempty"`
+}
+
+// AcknowledgementItem is a requirement as it applies to one user
+type AcknowledgementItem struct {
+	RequirementID   string     `json:"requirementId"`
+	Name            string     `json:"name"`
+	Path            string     `json:"path"`
+	LibrarySlug     string     `json:"librarySlug"`
+	LibraryName     string     `json:"libraryName"`
+	CategoryID      string     `json:"categoryId"`
+	CategoryName    string     `json:"categoryName"`
+	CurrentRev      string     `json:"currentRev"`
+	RevisedAt       time.Time  `json:"revisedAt"`
+	Status          string     `json:"status"`
+	AcknowledgedAt  *time.Time `json:"acknowledgedAt,omitempty"`
+	PreviousRevRead bool       `json:"previousRevRead"` // Acknowledged an earlier revision only
+	DownloadURL     string     `json:"downloadUrl"`
+}
+
+// AcknowledgementReportRow is one user's status for one requirement
+type AcknowledgementReportRow struct {
+	RequirementID   string     `json:"requirementId"`
+	DocumentName    string     `json:"documentName"`
+	DocumentPath    string     `json:"documentPath"`
+	CategoryName    string     `json:"categoryName"`
+	CurrentRev      string     `json:"currentRev"`
+	InstitutionID   string     `json:"institutionId,omitempty"`
+	InstitutionName string     `json:"institutionName"`
+	UserID          string     `json:"userId"`
+	UserName        string     `json:"userName"`
+	Email           string     `json:"email"`
+	Status          string     `json:"status"`
+	AcknowledgedAt  *time.Time `json:"acknowledgedAt,omitempty"`
+}
+
+// AcknowledgementInstitutionSummary totals compliance for one institution
+type AcknowledgementInstitutionSummary struct {
+	InstitutionID   string  `json:"institutionId,omitempty"`
+	InstitutionName string  `json:"institutionName"`
+	Required        int     `json:"required"`
+	Acknowledged    int     `json:"acknowledged"`
+	Outstanding     int     `json:"outstanding"`
+	ComplianceRate  float64 `json:"complianceRate"` // Acknowledged / required, 0-1
+}
+
+// AcknowledgementReport is the compliance report of a library
+type AcknowledgementReport struct {
+	GeneratedAt  time.Time                           `json:"generatedAt"`
+	Institutions []AcknowledgementInstitutionSummary `json:"institutions"`
+	Rows         []AcknowledgementReportRow          `json:"rows"`
+}
+
+// SummariseAcknowledgements totals the report rows per institution, in name order
+func SummariseAcknowledgements(rows []AcknowledgementReportRow) []AcknowledgementInstitutionSummary {
+	index := map[string]int{}
+	summaries := []AcknowledgementInstitutionSummary{}
+	for _, row := range rows {
+		i, ok := index[row.InstitutionID]
+		if !ok {
+			i = len(summaries)
+			index[row.InstitutionID] = i
+			summaries = append(summaries, AcknowledgementInstitutionSummary{
+				InstitutionID:   row.InstitutionID,
+				InstitutionName: row.InstitutionName,
+			})
+		}
+		summaries[i].Required++
+		if row.Status == AcknowledgementStatusAcknowledged {
+			summaries[i].Acknowledged++
+		} else {
+			summaries[i].Outstanding++
+		}
+	}
+
+	for i := range summaries {
+		summaries[i].ComplianceRate = float64(summaries[i].Acknowledged) / float64(summaries[i].Required)
+	}
+	sort.SliceStable(summaries, func(i, j int) bool {
+		return strings.ToLower(summaries[i].InstitutionName) < strings.ToLower(summaries[j].InstitutionName)
+	})
+	return summaries
+}
+
+// acknowledgementCSVHeader is the header row of the compliance CSV export
+var acknowledgementCSVHeader = []string{
+	"Institution", "User", "Email", "Document", "Path", "Category",
+	"Revision", "Status", "Acknowledged At",
+}
+
+// WriteAcknowledgementCSV writes report rows as CSV
+func WriteAcknowledgementCSV(w io.Writer, rows []AcknowledgementReportRow) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(acknowledgementCSVHeader); err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		acknowledgedAt := ""
+		if row.AcknowledgedAt != nil {
+			acknowledgedAt = row.AcknowledgedAt.UTC().Format(time.RFC3339)
+		}
+		record := []string{
+			row.InstitutionName, row.UserName, row.Email, row.DocumentName,
+			row.DocumentPath, row.CategoryName, row.CurrentRev, row.Status, acknowledgedAt,
+		}
+		for i, field := range record {
+			record[i] = csvSafe(field)
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// csvSafe stops spreadsheet applications from evaluating a field as a formula
+func csvSafe(field string) string {
+	if field != "" && strings.ContainsRune("=+-@\t\r", rune(field[0])) {
+		return "'" + field
+	}
+	return field
+}
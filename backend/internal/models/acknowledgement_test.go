@@ -0,0 +1,107 @@
+package models
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestAcknowledgementAudienceMatches(t *testing.T) {
+	hospital := primitive.NewObjectID()
+	clinic := primitive.NewObjectID()
+
+	haematologist := &User{
+		Role:    RoleUser,
+		Profile: UserProfile{InstitutionID: &hospital, Specialty: "Haematology"},
+	}
+	noInstitution := &User{Role: RoleUser, Profile: UserProfile{Specialty: "Haematology"}}
+
+	tests := []struct {
+		name     string
+		audience AcknowledgementAudience
+		user     *User
+		want     bool
+	}{
+		{name: "empty audience", user: haematologist, want: true},
+		{name: "matching role", audience: AcknowledgementAudience{Roles: []UserRole{RoleUser}}, user: haematologist, want: true},
+		{name: "other role", audience: AcknowledgementAudience{Roles: []UserRole{RoleAdmin}}, user: haematologist},
+		{name: "matching institution", audience: AcknowledgementAudience{InstitutionIDs: []primitive.ObjectID{clinic, hospital}}, user: haematologist, want: true},
+		{name: "other institution", audience: AcknowledgementAudience{InstitutionIDs: []primitive.ObjectID{clinic}}, user: haematologist},
+		{name: "user without institution", audience: AcknowledgementAudience{InstitutionIDs: []primitive.ObjectID{hospital}}, user: noInstitution},
+		{name: "specialty ignores case", audience: AcknowledgementAudience{Specialties: []string{" haematology "}}, user: haematologist, want: true},
+		{name: "other specialty", audience: AcknowledgementAudience{Specialties: []string{"Oncology"}}, user: haematologist},
+		{
+			name: "every list must match",
+			audience: AcknowledgementAudience{
+				Roles:          []UserRole{RoleUser},
+				InstitutionIDs: []primitive.ObjectID{hospital},
+				Specialties:    []string{"Oncology"},
+			},
+			user: haematologist,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.audience.Matches(tt.user); got != tt.want {
+				t.Errorf("Matches() = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSummariseAcknowledgements(t *testing.T) {
+	rows := []AcknowledgementReportRow{
+		{InstitutionID: "b", InstitutionName: "Groote Schuur", Status: AcknowledgementStatusAcknowledged},
+		{InstitutionID: "a", InstitutionName: "Chris Hani Baragwanath", Status: AcknowledgementStatusOutstanding},
+		{InstitutionID: "b", InstitutionName: "Groote Schuur", Status: AcknowledgementStatusOutstanding},
+		{InstitutionID: "b", InstitutionName: "Groote Schuur", Status: AcknowledgementStatusAcknowledged},
+		{InstitutionID: "b", InstitutionName: "Groote Schuur", Status: AcknowledgementStatusAcknowledged},
+	}
+
+	got := SummariseAcknowledgements(rows)
+	if len(got) != 2 {
+		t.Fatalf("got %d summaries, want 2", len(got))
+	}
+	if got[0].InstitutionName != "Chris Hani Baragwanath" || got[0].Outstanding != 1 || got[0].ComplianceRate != 0 {
+		t.Errorf("first summary = %+v", got[0])
+	}
+	if got[1].Required != 4 || got[1].Acknowledged != 3 || got[1].ComplianceRate != 0.75 {
+		t.Errorf("second summary = %+v", got[1])
+	}
+}
+
+func TestWriteAcknowledgementCSV(t *testing.T) {
+	at := time.Date(2025, 3, 1, 9, 30, 0, 0, time.UTC)
+	rows := []AcknowledgementReportRow{
+		{
+			InstitutionName: "Groote Schuur", UserName: "Jane Doe", Email: "jane@example.com",
+			DocumentName: "Iron.pdf", DocumentPath: "Anemia/Iron.pdf", CategoryName: "Anemia",
+			CurrentRev: "015f2a", Status: AcknowledgementStatusAcknowledged, AcknowledgedAt: &at,
+		},
+		{
+			InstitutionName: "Groote Schuur", UserName: "=HYPERLINK(\"x\")", Email: "mallory@example.com",
+			DocumentName: "Iron.pdf", DocumentPath: "Anemia/Iron.pdf", CategoryName: "Anemia",
+			CurrentRev: "015f2a", Status: AcknowledgementStatusOutstanding,
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteAcknowledgementCSV(&buf, rows); err != nil {
+		t.Fatalf("WriteAcknowledgementCSV() error = %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("got %d lines, want 3:\n%s", len(lines), buf.String())
+	}
+	if want := "Groote Schuur,Jane Doe,jane@example.com,Iron.pdf,Anemia/Iron.pdf,Anemia,015f2a,acknowledged,2025-03-01T09:30:00Z"; lines[1] != want {
+		t.Errorf("row = %q, want %q", lines[1], want)
+	}
+	if !strings.Contains(lines[2], `"'=HYPERLINK(""x"")"`) {
+		t.Errorf("formula was not neutralised: %q", lines[2])
+	}
+}
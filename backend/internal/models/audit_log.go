@@ -10,30 +10,53 @@ import (
 type AuditAction string
 
 const (
-	AuditActionUserCreated            AuditAction = "user_created"
-	AuditActionUserRegistered         AuditAction = "user_registered"
-	AuditActionUserUpdated            AuditAction = "user_updated"
-	AuditActionUserDeleted            AuditAction = "user_deleted"
-	AuditActionUserDeactivated        AuditAction = "user_deactivated"
-	AuditActionUserActivated          AuditAction = "user_activated"
-	AuditActionRoleChanged            AuditAction = "role_changed"
-	AuditActionAdminLevelChanged      AuditAction = "admin_level_changed"
-	AuditActionLoginSuccess           AuditAction = "login_success"
-	AuditActionLoginFailed            AuditAction = "login_failed"
-	AuditActionLogout                 AuditAction = "logout"
-	AuditActionPasswordChanged        AuditAction = "password_changed"
-	AuditActionAccountLocked          AuditAction = "account_locked"
-	AuditActionAccountUnlocked        AuditAction = "account_unlocked"
-	AuditActionInstitutionCreated     AuditAction = "institution_created"
-	AuditActionInstitutionUpdated     AuditAction = "institution_updated"
-	AuditActionInstitutionDeleted     AuditAction = "institution_deleted"
-	AuditActionInstitutionActivated   AuditAction = "institution_activated"
-	AuditActionInstitutionDeactivated AuditAction = "institution_deactivated"
-	AuditActionReferralConfigUpdated  AuditAction = "referral_config_updated"
-	AuditActionReferralAccessed       AuditAction = "referral_accessed"
-	AuditActionSMTPConfigUpdated      AuditAction = "smtp_config_updated"
-	AuditActionPasswordResetRequested AuditAction = "password_reset_requested"
-	AuditActionPasswordResetCompleted AuditAction = "password_reset_completed"
+	AuditActionUserCreated               AuditAction = "user_created"
+	AuditActionUserRegistered            AuditAction = "user_registered"
+	AuditActionUserUpdated               AuditAction = "user_updated"
+	AuditActionUserDeleted               AuditAction = "user_deleted"
+	AuditActionUserDeactivated           AuditAction = "user_deactivated"
+	AuditActionUserActivated             AuditAction = "user_activated"
+	AuditActionRoleChanged               AuditAction = "role_changed"
+	AuditActionAdminLevelChanged         AuditAction = "admin_level_changed"
+	AuditActionLoginSuccess              AuditAction = "login_success"
+	AuditActionLoginFailed               AuditAction = "login_failed"
+	AuditActionLogout                    AuditAction = "logout"
+	AuditActionPasswordChanged           AuditAction = "password_changed"
+	AuditActionAccountLocked             AuditAction = "account_locked"
+	AuditActionAccountUnlocked           AuditAction = "account_unlocked"
+	AuditActionInstitutionCreated        AuditAction = "institution_created"
+	AuditActionInstitutionUpdated        AuditAction = "institution_updated"
+	AuditActionInstitutionDeleted        AuditAction = "institution_deleted"
+	AuditActionInstitutionActivated      AuditAction = "institution_activated"
+	AuditActionInstitutionDeactivated    AuditAction = "institution_deactivated"
+	AuditActionInstitutionMerged         AuditAction = "institution_merged"
+	AuditActionInstitutionApproved       AuditAction = "institution_approved"
+	AuditActionInstitutionRejected       AuditAction = "institution_rejected"
+	AuditActionInstitutionsImported      AuditAction = "institutions_imported"
+	AuditActionLibraryCreated            AuditAction = "library_created"
+	AuditActionLibraryUpdated            AuditAction = "library_updated"
+	AuditActionLibraryDeleted            AuditAction = "library_deleted"
+	AuditActionLibraryCategoryCreated    AuditAction = "library_category_created"
+	AuditActionLibraryCategoryUpdated    AuditAction = "library_category_updated"
+	AuditActionLibraryCategoryDeleted    AuditAction = "library_category_deleted"
+	AuditActionAcknowledgementRequired   AuditAction = "acknowledgement_required"
+	AuditActionAcknowledgementUpdated    AuditAction = "acknowledgement_updated"
+	AuditActionAcknowledgementRemoved    AuditAction = "acknowledgement_removed"
+	AuditActionDocumentAcknowledged      AuditAction = "document_acknowledged"
+	AuditActionAcknowledgementReminded   AuditAction = "acknowledgement_reminders_sent"
+	AuditActionDocumentMetadataUpdated   AuditAction = "document_metadata_updated"
+	AuditActionDocumentUploaded          AuditAction = "document_uploaded"
+	AuditActionDocumentReplaced          AuditAction = "document_replaced"
+	AuditActionDocumentMoved             AuditAction = "document_moved"
+	AuditActionDocumentDeleted           AuditAction = "document_deleted"
+	AuditActionDocumentFolderCreated     AuditAction = "document_folder_created"
+	AuditActionDocumentArchiveDownloaded AuditAction = "document_archive_downloaded"
+	AuditActionAnalyticsSettingsUpdated  AuditAction = "analytics_settings_updated"
+	AuditActionReferralConfigUpdated     AuditAction = "referral_config_updated"
+	AuditActionReferralAccessed          AuditAction = "referral_accessed"
+	AuditActionSMTPConfigUpdated         AuditAction = "smtp_config_updated"
+	AuditActionPasswordResetRequested    AuditAction = "password_reset_requested"
+	AuditActionPasswordResetCompleted    AuditAction = "password_reset_completed"
 )
 
 // AuditLog represents a log entry for audit trail
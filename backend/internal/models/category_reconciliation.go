@@ -0,0 +1,207 @@
+package models
+
+import (
+	"errors"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrInvalidReconciliationAction = errors.New("action must be relink, create or archive")
+)
+
+// CategoryFolder is a folder directly under a library's Dropbox root
+type CategoryFolder struct {
+	ID   string `bson:"id" json:"id"` // Dropbox file ID, which survives renames
+	Name string `bson:"name" json:"name"`
+}
+
+// ReconciliationIssueType is a way categories and Dropbox folders disagree
+type ReconciliationIssueType string
+
+const (
+	ReconciliationMissingFolder  ReconciliationIssueType = "missing_folder"  // An active category's folder is gone
+	ReconciliationRenamedFolder  ReconciliationIssueType = "renamed_folder"  // A category's folder was renamed in Dropbox
+	ReconciliationUnlinkedFolder ReconciliationIssueType = "unlinked_folder" // A folder has no category
+)
+
+// ReconciliationAction is a fix for a reconciliation issue
+type ReconciliationAction string
+
+const (
+	ReconciliationRelink  ReconciliationAction = "relink"  // Point the category at a folder
+	ReconciliationCreate  ReconciliationAction = "create"  // Create a category for a folder
+	ReconciliationArchive ReconciliationAction = "archive" // Deactivate the category
+)
+
+// ReconciliationIssue is a category or folder that needs an admin's
+// attention, with the fixes that apply to it
+type ReconciliationIssue struct {
+	Type         ReconciliationIssueType `bson:"type" json:"type"`
+	CategoryID   *primitive.ObjectID     `bson:"category_id,omitempty" json:"categoryId,omitempty"`
+	CategoryName string                  `bson:"category_name,omitempty" json:"categoryName,omitempty"`
+	DropboxPath  string                  `bson:"dropbox_path,omitempty" json:"dropboxPath,omitempty"` // Where the category expects its folder
+	Folder       *CategoryFolder         `bson:"folder,omitempty" json:"folder,omitempty"`
+	Fixes        []ReconciliationAction  `bson:"fixes" json:"fixes"`
+}
+
+// CategoryReconciliation is the latest reconciliation report of a library
+type CategoryReconciliation struct {
+	ID          primitive.ObjectID    `bson:"_id,omitempty" json:"id"`
+	LibraryID   primitive.ObjectID    `bson:"library_id" json:"libraryId"`
+	LibrarySlug string                `bson:"library_slug" json:"librarySlug"`
+	LibraryName string                `bson:"library_name" json:"libraryName"`
+	Issues      []ReconciliationIssue `bson:"issues" json:"issues"`
+	Error       string                `bson:"error,omitempty" json:"error,omitempty"` // Set when the folders could not be listed
+	CheckedAt   time.Time             `bson:"checked_at" json:"checkedAt"`
+}
+
+// ReconciliationFixRequest applies a fix. Relink needs a category and a
+// folder, create a folder, and archive a category.
+type ReconciliationFixRequest struct {
+	LibraryID  string               `json:"libraryId" binding:"required"`
+	Action     ReconciliationAction `json:"action" binding:"required"`
+	CategoryID string               `json:"categoryId"`
+	FolderID   string               `json:"folderId"`
+}
+
+// Validate validates the ReconciliationFixRequest
+func (req *ReconciliationFixRequest) Validate() error {
+	switch req.Action {
+	case ReconciliationRelink:
+		if req.CategoryID == "" || req.FolderID == "" {
+			return errors.New("relink needs a categoryId and a folderId")
+		}
+	case ReconciliationCreate:
+		if req.FolderID == "" {
+			return errors.New("create needs a folderId")
+		}
+	case ReconciliationArchive:
+		if req.CategoryID == "" {
+			return errors.New("archive needs a categoryId")
+		}
+	default:
+		return ErrInvalidReconciliationAction
+	}
+	return nil
+}
+
+// CategoryFolderName returns the name of the folder a category expects
+// under its library's root
+func CategoryFolderName(category *LibraryCategory, library *Library) string {
+	p := category.GetDropboxPath(library)
+	if decoded, err := url.PathUnescape(p); err == nil {
+		p = decoded
+	}
+	return path.Base("/" + strings.Trim(p, "/"))
+}
+
+// ReconcileCategories compares a library's categories with the folders
+// under its Dropbox root. Categories are matched to folders by Dropbox ID
+// first, so a folder renamed in Dropbox is still recognised, and then by
+// name, ignoring case as Dropbox does.
+//
+// It returns the issues found and the folder IDs to record for categories
+// that were matched by name, so later renames can be detected. Inactive
+// categories whose folder is missing are not reported.
+func ReconcileCategories(library *Library, categories []*LibraryCategory, folders []CategoryFolder) ([]ReconciliationIssue, map[primitive.ObjectID]string) {
+	byID := make(map[string]CategoryFolder, len(folders))
+	byName := make(map[string]CategoryFolder, len(folders))
+	for _, folder := range folders {
+		byID[folder.ID] = folder
+		byName[strings.ToLower(folder.Name)] = folder
+	}
+
+	issues := []ReconciliationIssue{}
+	links := map[primitive.ObjectID]string{}
+	linked := map[string]bool{}
+	matched := map[primitive.ObjectID]bool{}
+
+	// Match by ID first, so a renamed folder whose old name was reused by
+	// another folder is still linked to the right category
+	for _, category := range categories {
+		folder, ok := byID[category.DropboxFolderID]
+		if category.DropboxFolderID == "" || !ok {
+			continue
+		}
+		linked[folder.ID] = true
+		matched[category.ID] = true
+
+		if !strings.EqualFold(folder.Name, CategoryFolderName(category, library)) {
+			folder := folder
+			issues = append(issues, categoryIssue(ReconciliationRenamedFolder, category, library, &folder,
+				ReconciliationRelink, ReconciliationArchive))
+		}
+	}
+
+	for _, category := range categories {
+		if matched[category.ID] {
+			continue
+		}
+		folder, ok := byName[strings.ToLower(CategoryFolderName(category, library))]
+		if ok && !linked[folder.ID] {
+			linked[folder.ID] = true
+			if category.DropboxFolderID != folder.ID {
+				links[category.ID] = folder.ID
+			}
+			continue
+		}
+		if category.IsActive {
+			issues = append(issues, categoryIssue(ReconciliationMissingFolder, category, library, nil,
+				ReconciliationRelink, ReconciliationArchive))
+		}
+	}
+
+	for _, folder := range folders {
+		if linked[folder.ID] {
+			continue
+		}
+		folder := folder
+		issues = append(issues, ReconciliationIssue{
+			Type:   ReconciliationUnlinkedFolder,
+			Folder: &folder,
+			Fixes:  []ReconciliationAction{ReconciliationCreate},
+		})
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		if issues[i].Type != issues[j].Type {
+			return issues[i].Type < issues[j].Type
+		}
+		return strings.ToLower(issues[i].sortName()) < strings.ToLower(issues[j].sortName())
+	})
+	return issues, links
+}
+
+func categoryIssue(
+	issueType ReconciliationIssueType,
+	category *LibraryCategory,
+	library *Library,
+	folder *CategoryFolder,
+	fixes ...ReconciliationAction,
+) ReconciliationIssue {
+	id := category.ID
+	return ReconciliationIssue{
+		Type:         issueType,
+		CategoryID:   &id,
+		CategoryName: category.Name,
+		DropboxPath:  category.GetDropboxPath(library),
+		Folder:       folder,
+		Fixes:        fixes,
+	}
+}
+
+func (i ReconciliationIssue) sortName() string {
+	if i.CategoryName != "" {
+		return i.CategoryName
+	}
+	if i.Folder != nil {
+		return i.Folder.Name
+	}
+	return ""
+}
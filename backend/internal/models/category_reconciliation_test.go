@@ -0,0 +1,136 @@
+package models
+
+import (
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestReconcileCategories(t *testing.T) {
+	library := &Library{Slug: LibrarySlugSOPs, DropboxRoot: "SOPS"}
+
+	guidelines := &LibraryCategory{ID: primitive.NewObjectID(), Name: "Clinical Guidelines", DropboxPath: "SOPS/Clinical%20Guidelines", IsActive: true}
+	linkedGuidelines := *guidelines
+	linkedGuidelines.DropboxFolderID = "id:guidelines"
+	archived := &LibraryCategory{ID: primitive.NewObjectID(), Name: "Old Forms", DropboxPath: "SOPS/Old%20Forms"}
+	legacy := &LibraryCategory{ID: primitive.NewObjectID(), Name: "Policies", IsActive: true} // No stored path
+
+	tests := []struct {
+		name       string
+		categories []*LibraryCategory
+		folders    []CategoryFolder
+		want       []string // type:category or folder name
+		wantLinks  map[primitive.ObjectID]string
+	}{
+		{
+			name:       "matched by name records the folder ID",
+			categories: []*LibraryCategory{guidelines},
+			folders:    []CategoryFolder{{ID: "id:guidelines", Name: "Clinical Guidelines"}},
+			wantLinks:  map[primitive.ObjectID]string{guidelines.ID: "id:guidelines"},
+		},
+		{
+			name:       "names match ignoring case",
+			categories: []*LibraryCategory{&linkedGuidelines},
+			folders:    []CategoryFolder{{ID: "id:guidelines", Name: "clinical guidelines"}},
+		},
+		{
+			name:       "category without a stored path uses the library's naming",
+			categories: []*LibraryCategory{legacy},
+			folders:    []CategoryFolder{{ID: "id:policies", Name: "Policies"}},
+			wantLinks:  map[primitive.ObjectID]string{legacy.ID: "id:policies"},
+		},
+		{
+			name:       "renamed folder is matched by ID",
+			categories: []*LibraryCategory{&linkedGuidelines},
+			folders:    []CategoryFolder{{ID: "id:guidelines", Name: "Guidelines 2025"}},
+			want:       []string{"renamed_folder:Clinical Guidelines"},
+		},
+		{
+			name:       "renamed folder whose old name was reused",
+			categories: []*LibraryCategory{&linkedGuidelines},
+			folders: []CategoryFolder{
+				{ID: "id:guidelines", Name: "Guidelines 2025"},
+				{ID: "id:new", Name: "Clinical Guidelines"},
+			},
+			want: []string{"renamed_folder:Clinical Guidelines", "unlinked_folder:Clinical Guidelines"},
+		},
+		{
+			name:       "missing folder",
+			categories: []*LibraryCategory{&linkedGuidelines},
+			want:       []string{"missing_folder:Clinical Guidelines"},
+		},
+		{
+			name:       "archived category with a missing folder is not reported",
+			categories: []*LibraryCategory{archived},
+		},
+		{
+			name:       "archived category keeps its folder linked",
+			categories: []*LibraryCategory{archived},
+			folders:    []CategoryFolder{{ID: "id:old", Name: "Old Forms"}},
+			wantLinks:  map[primitive.ObjectID]string{archived.ID: "id:old"},
+		},
+		{
+			name:       "folders without categories",
+			categories: []*LibraryCategory{guidelines},
+			folders: []CategoryFolder{
+				{ID: "id:guidelines", Name: "Clinical Guidelines"},
+				{ID: "id:b", Name: "Protocols"},
+				{ID: "id:a", Name: "Audits"},
+			},
+			want:      []string{"unlinked_folder:Audits", "unlinked_folder:Protocols"},
+			wantLinks: map[primitive.ObjectID]string{guidelines.ID: "id:guidelines"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues, links := ReconcileCategories(library, tt.categories, tt.folders)
+
+			got := []string{}
+			for _, issue := range issues {
+				name := issue.CategoryName
+				if issue.Type == ReconciliationUnlinkedFolder {
+					name = issue.Folder.Name
+				}
+				got = append(got, string(issue.Type)+":"+name)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("issues = %v, want %v", got, tt.want)
+			}
+
+			if len(links) != len(tt.wantLinks) {
+				t.Fatalf("links = %v, want %v", links, tt.wantLinks)
+			}
+			for id, folderID := range tt.wantLinks {
+				if links[id] != folderID {
+					t.Errorf("link of %s = %q, want %q", id.Hex(), links[id], folderID)
+				}
+			}
+		})
+	}
+}
+
+func TestReconciliationFixRequestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     ReconciliationFixRequest
+		wantErr bool
+	}{
+		{name: "relink", req: ReconciliationFixRequest{Action: ReconciliationRelink, CategoryID: "c", FolderID: "f"}},
+		{name: "relink without folder", req: ReconciliationFixRequest{Action: ReconciliationRelink, CategoryID: "c"}, wantErr: true},
+		{name: "create", req: ReconciliationFixRequest{Action: ReconciliationCreate, FolderID: "f"}},
+		{name: "create without folder", req: ReconciliationFixRequest{Action: ReconciliationCreate}, wantErr: true},
+		{name: "archive", req: ReconciliationFixRequest{Action: ReconciliationArchive, CategoryID: "c"}},
+		{name: "archive without category", req: ReconciliationFixRequest{Action: ReconciliationArchive}, wantErr: true},
+		{name: "unknown action", req: ReconciliationFixRequest{Action: "delete", CategoryID: "c"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.req.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %t", err, tt.wantErr)
+			}
+		})
+	}
+}
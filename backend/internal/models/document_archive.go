@@ -0,0 +1,62 @@
+package models
+
+import (
+	"errors"
+	"path"
+	"strings"
+)
+
+var (
+	ErrArchiveTooLarge = errors.New("folder is too large to download as a ZIP; download a subfolder instead")
+	ErrArchiveEmpty    = errors.New("folder has no files to download")
+)
+
+// Limits on a folder downloaded as a ZIP archive
+const (
+	MaxArchiveSize  = 1024 * 1024 * 1024 // Total uncompressed size
+	MaxArchiveFiles = 2000
+)
+
+// compressedDocumentExtensions are formats that are already compressed, so
+// deflating them again in an archive only costs time
+var compressedDocumentExtensions = map[string]bool{
+	".docx": true, ".xlsx": true, ".pptx": true,
+	".odt": true, ".ods": true, ".odp": true,
+	".png": true, ".jpg": true, ".jpeg": true,
+	".zip": true, ".gz": true, ".mp4": true,
+}
+
+// CheckArchiveSize checks a folder against the archive limits
+func CheckArchiveSize(files int, size int64) error {
+	if files == 0 {
+		return ErrArchiveEmpty
+	}
+	if files > MaxArchiveFiles || size > MaxArchiveSize {
+		return ErrArchiveTooLarge
+	}
+	return nil
+}
+
+// IsCompressedDocument reports whether a file's format is already compressed
+func IsCompressedDocument(name string) bool {
+	return compressedDocumentExtensions[strings.ToLower(path.Ext(name))]
+}
+
+// ArchiveFileName returns the download name of a folder's ZIP archive,
+// keeping to characters that are safe in a Content-Disposition header
+func ArchiveFileName(folderName string) string {
+	var b strings.Builder
+	for _, r := range folderName {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			b.WriteRune(r)
+		case r == ' ':
+			b.WriteRune('_')
+		}
+	}
+	name := strings.Trim(b.String(), "._")
+	if name == "" {
+		name = "documents"
+	}
+	return name + ".zip"
+}
@@ -0,0 +1,70 @@
+package models
+
+import "testing"
+
+func TestCheckArchiveSize(t *testing.T) {
+	tests := []struct {
+		name  string
+		files int
+		size  int64
+		want  error
+	}{
+		{name: "within limits", files: 12, size: 40 * 1024 * 1024},
+		{name: "at the limits", files: MaxArchiveFiles, size: MaxArchiveSize},
+		{name: "no files", files: 0, size: 0, want: ErrArchiveEmpty},
+		{name: "too many files", files: MaxArchiveFiles + 1, size: 1024, want: ErrArchiveTooLarge},
+		{name: "too large", files: 3, size: MaxArchiveSize + 1, want: ErrArchiveTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CheckArchiveSize(tt.files, tt.size); got != tt.want {
+				t.Errorf("CheckArchiveSize(%d, %d) = %v, want %v", tt.files, tt.size, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestArchiveFileName(t *testing.T) {
+	tests := []struct {
+		name   string
+		folder string
+		want   string
+	}{
+		{name: "plain", folder: "Haemophilia", want: "Haemophilia.zip"},
+		{name: "spaces", folder: "Sickle Cell Disease", want: "Sickle_Cell_Disease.zip"},
+		{name: "quotes and separators", folder: `Iron "2025"/v2`, want: "Iron_2025v2.zip"},
+		{name: "accents dropped", folder: "Anémie", want: "Anmie.zip"},
+		{name: "nothing safe", folder: "日本", want: "documents.zip"},
+		{name: "leading dots", folder: "..hidden", want: "hidden.zip"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ArchiveFileName(tt.folder); got != tt.want {
+				t.Errorf("ArchiveFileName(%q) = %q, want %q", tt.folder, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsCompressedDocument(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{name: "Dosing.XLSX", want: true},
+		{name: "scan.jpg", want: true},
+		{name: "Iron.pdf", want: false},
+		{name: "notes.txt", want: false},
+		{name: "README", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsCompressedDocument(tt.name); got != tt.want {
+				t.Errorf("IsCompressedDocument(%q) = %t, want %t", tt.name, got, tt.want)
+			}
+		})
+	}
+}
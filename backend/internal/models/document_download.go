@@ -0,0 +1,345 @@
+package models
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrInvalidDownloadRetention = errors.New("download retention must be between 30 and 3650 days")
+	ErrInvalidAnalyticsRange    = errors.New("from and to must be dates (YYYY-MM-DD) or RFC 3339 times, with from before to")
+	ErrInvalidAnalyticsInterval = errors.New("interval must be day, week or month")
+	ErrInvalidAnalyticsLimit    = errors.New("limit must be between 1 and 100")
+)
+
+// Limits on how long download events are kept
+const (
+	DefaultDownloadRetentionDays = 730
+	MinDownloadRetentionDays     = 30
+	MaxDownloadRetentionDays     = 3650
+)
+
+// Defaults and limits of download analytics queries
+const (
+	DefaultAnalyticsPeriod = 90 * 24 * time.Hour
+	DefaultAnalyticsLimit  = 20
+	MaxAnalyticsLimit      = 100
+)
+
+// DownloadSource says how a document was downloaded
+type DownloadSource string
+
+const (
+	DownloadSourceFile     DownloadSource = "file"     // Current version, through a download link
+	DownloadSourceRevision DownloadSource = "revision" // A previous revision
+	DownloadSourceArchive  DownloadSource = "archive"  // Part of a folder ZIP
+)
+
+// DocumentDownload records one download of a library document. The user's
+// institution and specialty are kept as they were at the time.
+type DocumentDownload struct {
+	ID            primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	UserID        primitive.ObjectID  `bson:"user_id" json:"userId"`
+	InstitutionID *primitive.ObjectID `bson:"institution_id,omitempty" json:"institutionId,omitempty"`
+	Specialty     string              `bson:"specialty,omitempty" json:"specialty,omitempty"`
+	LibraryID     primitive.ObjectID  `bson:"library_id" json:"libraryId"`
+	LibrarySlug   string              `bson:"library_slug" json:"librarySlug"`
+	CategoryID    primitive.ObjectID  `bson:"category_id" json:"categoryId"`
+	Path          string              `bson:"path" json:"path"` // Relative to the category folder
+	PathLower     string              `bson:"path_lower" json:"-"`
+	Name          string              `bson:"name" json:"name"`
+	Rev           string              `bson:"rev,omitempty" json:"rev,omitempty"`
+	Source        DownloadSource      `bson:"source" json:"source"`
+	DownloadedAt  time.Time           `bson:"downloaded_at" json:"downloadedAt"`
+}
+
+// NewDocumentDownload records a user downloading a file of a category
+func NewDocumentDownload(user *User, library *Library, category *LibraryCategory, filePath, rev string, source DownloadSource, at time.Time) *DocumentDownload {
+	filePath = strings.Trim(filePath, "/")
+	name := filePath
+	if i := strings.LastIndex(filePath, "/"); i >= 0 {
+		name = filePath[i+1:]
+	}
+	return &DocumentDownload{
+		UserID:        user.ID,
+		InstitutionID: user.Profile.InstitutionID,
+		Specialty:     strings.TrimSpace(user.Profile.Specialty),
+		LibraryID:     library.ID,
+		LibrarySlug:   library.Slug,
+		CategoryID:    category.ID,
+		Path:          filePath,
+		PathLower:     strings.ToLower(filePath),
+		Name:          name,
+		Rev:           rev,
+		Source:        source,
+		DownloadedAt:  at,
+	}
+}
+
+// AnalyticsSettings is the singleton configuration of download analytics
+type AnalyticsSettings struct {
+	ID                    primitive.ObjectID  `bson:"_id,omitempty" json:"-"`
+	DownloadRetentionDays int                 `bson:"download_retention_days" json:"downloadRetentionDays"`
+	UpdatedAt             time.Time           `bson:"updated_at" json:"updatedAt,omitempty"`
+	UpdatedBy             *primitive.ObjectID `bson:"updated_by,omitempty" json:"updatedBy,omitempty"`
+}
+
+// DefaultAnalyticsSettings returns the settings used until an admin saves
+// their own
+func DefaultAnalyticsSettings() *AnalyticsSettings {
+	return &AnalyticsSettings{DownloadRetentionDays: DefaultDownloadRetentionDays}
+}
+
+// RetentionCutoff returns the time before which download events are removed
+func (s *AnalyticsSettings) RetentionCutoff(now time.Time) time.Time {
+	return now.AddDate(0, 0, -s.DownloadRetentionDays)
+}
+
+// UpdateAnalyticsSettingsRequest changes the analytics settings
+type UpdateAnalyticsSettingsRequest struct {
+	DownloadRetentionDays int `json:"downloadRetentionDays" binding:"required"`
+}
+
+// Validate checks the retention is within limits
+func (r *UpdateAnalyticsSettingsRequest) Validate() error {
+	if r.DownloadRetentionDays < MinDownloadRetentionDays || r.DownloadRetentionDays > MaxDownloadRetentionDays {
+		return ErrInvalidDownloadRetention
+	}
+	return nil
+}
+
+// AnalyticsInterval is the period downloads over time are counted by
+type AnalyticsInterval string
+
+const (
+	AnalyticsIntervalDay   AnalyticsInterval = "day"
+	AnalyticsIntervalWeek  AnalyticsInterval = "week" // Starting on Monday
+	AnalyticsIntervalMonth AnalyticsInterval = "month"
+)
+
+// Start returns the start of the period containing t, in UTC
+func (i AnalyticsInterval) Start(t time.Time) time.Time {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	switch i {
+	case AnalyticsIntervalWeek:
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	case AnalyticsIntervalMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+	return day
+}
+
+// Next returns the start of the period after the one starting at start
+func (i AnalyticsInterval) Next(start time.Time) time.Time {
+	switch i {
+	case AnalyticsIntervalWeek:
+		return start.AddDate(0, 0, 7)
+	case AnalyticsIntervalMonth:
+		return start.AddDate(0, 1, 0)
+	}
+	return start.AddDate(0, 0, 1)
+}
+
+// DownloadAnalyticsQuery selects the downloads an analytics report covers
+type DownloadAnalyticsQuery struct {
+	LibrarySlug string
+	From        time.Time // Inclusive; zero for all retained downloads
+	To          time.Time // Exclusive
+	Interval    AnalyticsInterval
+	Limit       int
+}
+
+// ParseDownloadAnalyticsQuery parses analytics query parameters. The range
+// defaults to the 90 days up to now; a to date without a time includes that
+// whole day.
+func ParseDownloadAnalyticsQuery(library, from, to, interval, limit string, now time.Time) (DownloadAnalyticsQuery, error) {
+	query := DownloadAnalyticsQuery{
+		LibrarySlug: strings.TrimSpace(library),
+		To:          now,
+		Interval:    AnalyticsIntervalDay,
+		Limit:       DefaultAnalyticsLimit,
+	}
+
+	if to != "" {
+		t, dateOnly, err := parseAnalyticsTime(to)
+		if err != nil {
+			return query, err
+		}
+		if dateOnly {
+			t = t.AddDate(0, 0, 1)
+		}
+		query.To = t
+	}
+	query.From = query.To.Add(-DefaultAnalyticsPeriod)
+	if from != "" {
+		t, _, err := parseAnalyticsTime(from)
+		if err != nil {
+			return query, err
+		}
+		query.From = t
+	}
+	if !query.From.Before(query.To) {
+		return query, ErrInvalidAnalyticsRange
+	}
+
+	switch AnalyticsInterval(interval) {
+	case "":
+	case AnalyticsIntervalDay, AnalyticsIntervalWeek, AnalyticsIntervalMonth:
+		query.Interval = AnalyticsInterval(interval)
+	default:
+		return query, ErrInvalidAnalyticsInterval
+	}
+
+	if limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > MaxAnalyticsLimit {
+			return query, ErrInvalidAnalyticsLimit
+		}
+		query.Limit = n
+	}
+
+	return query, nil
+}
+
+// parseAnalyticsTime parses a date or an RFC 3339 time
+func parseAnalyticsTime(value string) (time.Time, bool, error) {
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, true, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, false, ErrInvalidAnalyticsRange
+	}
+	return t, false, nil
+}
+
+// DocumentDownloadCount is how often a document was downloaded
+type DocumentDownloadCount struct {
+	LibrarySlug      string             `json:"librarySlug"`
+	CategoryID       primitive.ObjectID `json:"categoryId"`
+	CategoryName     string             `json:"categoryName"`
+	Path             string             `json:"path"`
+	Name             string             `json:"name"`
+	Downloads        int64              `json:"downloads"`
+	Users            int64              `json:"users"`
+	LastDownloadedAt time.Time          `json:"lastDownloadedAt"`
+}
+
+// DownloadPeriodCount is the number of downloads in one period
+type DownloadPeriodCount struct {
+	Period    time.Time `json:"period"`
+	Downloads int64     `json:"downloads"`
+	Users     int64     `json:"users"`
+}
+
+// FillDownloadPeriods returns a count for every period from from up to to,
+// taking counts from the given ones and zero where there were no downloads
+func FillDownloadPeriods(counts []DownloadPeriodCount, from, to time.Time, interval AnalyticsInterval) []DownloadPeriodCount {
+	byPeriod := make(map[time.Time]DownloadPeriodCount, len(counts))
+	for _, count := range counts {
+		byPeriod[count.Period.UTC()] = count
+	}
+
+	filled := []DownloadPeriodCount{}
+	for start := interval.Start(from); start.Before(to); start = interval.Next(start) {
+		count, ok := byPeriod[start]
+		if !ok {
+			count = DownloadPeriodCount{Period: start}
+		}
+		filled = append(filled, count)
+	}
+	return filled
+}
+
+// DownloadGroupCount is the number of downloads by one institution or
+// specialty. Downloads by users without one are grouped with an empty ID.
+type DownloadGroupCount struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Downloads int64  `json:"downloads"`
+	Users     int64  `json:"users"`
+}
+
+// UndownloadedDocument is a document nobody downloaded in a report's range
+type UndownloadedDocument struct {
+	LibrarySlug  string             `json:"librarySlug"`
+	CategoryID   primitive.ObjectID `json:"categoryId"`
+	CategoryName string             `json:"categoryName"`
+	Path         string             `json:"path"`
+	Name         string             `json:"name"`
+	ModifiedTime time.Time          `json:"modifiedTime"`
+}
+
+// WriteTopDocumentsCSV writes document download counts as CSV
+func WriteTopDocumentsCSV(w io.Writer, rows []DocumentDownloadCount) error {
+	records := make([][]string, 0, len(rows))
+	for _, row := range rows {
+		records = append(records, []string{
+			row.LibrarySlug, row.CategoryName, row.Path, row.Name,
+			strconv.FormatInt(row.Downloads, 10), strconv.FormatInt(row.Users, 10),
+			row.LastDownloadedAt.UTC().Format(time.RFC3339),
+		})
+	}
+	return writeCSV(w, []string{"Library", "Category", "Path", "Document", "Downloads", "Users", "Last downloaded"}, records)
+}
+
+// WriteDownloadPeriodsCSV writes downloads over time as CSV
+func WriteDownloadPeriodsCSV(w io.Writer, rows []DownloadPeriodCount) error {
+	records := make([][]string, 0, len(rows))
+	for _, row := range rows {
+		records = append(records, []string{
+			row.Period.UTC().Format("2006-01-02"),
+			strconv.FormatInt(row.Downloads, 10), strconv.FormatInt(row.Users, 10),
+		})
+	}
+	return writeCSV(w, []string{"Period", "Downloads", "Users"}, records)
+}
+
+// WriteDownloadGroupsCSV writes downloads by institution or specialty as CSV
+func WriteDownloadGroupsCSV(w io.Writer, group string, rows []DownloadGroupCount) error {
+	records := make([][]string, 0, len(rows))
+	for _, row := range rows {
+		records = append(records, []string{
+			row.Name, strconv.FormatInt(row.Downloads, 10), strconv.FormatInt(row.Users, 10),
+		})
+	}
+	return writeCSV(w, []string{group, "Downloads", "Users"}, records)
+}
+
+// WriteUndownloadedDocumentsCSV writes documents nobody downloaded as CSV
+func WriteUndownloadedDocumentsCSV(w io.Writer, rows []UndownloadedDocument) error {
+	records := make([][]string, 0, len(rows))
+	for _, row := range rows {
+		records = append(records, []string{
+			row.LibrarySlug, row.CategoryName, row.Path, row.Name,
+			row.ModifiedTime.UTC().Format(time.RFC3339),
+		})
+	}
+	return writeCSV(w, []string{"Library", "Category", "Path", "Document", "Last modified"}, records)
+}
+
+// writeCSV writes a header and records, guarding every field against
+// formula evaluation
+func writeCSV(w io.Writer, header []string, records [][]string) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	for _, record := range records {
+		for i, field := range record {
+			record[i] = csvSafe(field)
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,200 @@
+package models
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseDownloadAnalyticsQuery(t *testing.T) {
+	now := time.Date(2025, 6, 15, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		from     string
+		to       string
+		interval string
+		limit    string
+		want     DownloadAnalyticsQuery
+		wantErr  error
+	}{
+		{
+			name: "defaults",
+			want: DownloadAnalyticsQuery{From: now.Add(-DefaultAnalyticsPeriod), To: now, Interval: AnalyticsIntervalDay, Limit: DefaultAnalyticsLimit},
+		},
+		{
+			name: "dates include the whole to day",
+			from: "2025-01-01", to: "2025-03-31", interval: "month", limit: "5",
+			want: DownloadAnalyticsQuery{
+				From:     time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+				To:       time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC),
+				Interval: AnalyticsIntervalMonth,
+				Limit:    5,
+			},
+		},
+		{
+			name: "times are exact",
+			from: "2025-06-01T08:00:00Z", to: "2025-06-02T08:00:00Z", interval: "week",
+			want: DownloadAnalyticsQuery{
+				From:     time.Date(2025, 6, 1, 8, 0, 0, 0, time.UTC),
+				To:       time.Date(2025, 6, 2, 8, 0, 0, 0, time.UTC),
+				Interval: AnalyticsIntervalWeek,
+				Limit:    DefaultAnalyticsLimit,
+			},
+		},
+		{name: "from after to", from: "2025-06-10", to: "2025-06-01", wantErr: ErrInvalidAnalyticsRange},
+		{name: "bad date", from: "01/06/2025", wantErr: ErrInvalidAnalyticsRange},
+		{name: "bad interval", interval: "year", wantErr: ErrInvalidAnalyticsInterval},
+		{name: "limit too high", limit: "101", wantErr: ErrInvalidAnalyticsLimit},
+		{name: "limit not a number", limit: "ten", wantErr: ErrInvalidAnalyticsLimit},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseDownloadAnalyticsQuery("", tt.from, tt.to, tt.interval, tt.limit, now)
+			if err != tt.wantErr {
+				t.Fatalf("ParseDownloadAnalyticsQuery() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseDownloadAnalyticsQuery() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAnalyticsIntervalStart(t *testing.T) {
+	// A Thursday afternoon
+	at := time.Date(2025, 5, 15, 16, 45, 0, 0, time.UTC)
+
+	tests := []struct {
+		interval AnalyticsInterval
+		want     time.Time
+	}{
+		{interval: AnalyticsIntervalDay, want: time.Date(2025, 5, 15, 0, 0, 0, 0, time.UTC)},
+		{interval: AnalyticsIntervalWeek, want: time.Date(2025, 5, 12, 0, 0, 0, 0, time.UTC)},
+		{interval: AnalyticsIntervalMonth, want: time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.interval), func(t *testing.T) {
+			if got := tt.interval.Start(at); !got.Equal(tt.want) {
+				t.Errorf("Start() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	sunday := time.Date(2025, 5, 18, 23, 0, 0, 0, time.UTC)
+	if got := AnalyticsIntervalWeek.Start(sunday); !got.Equal(time.Date(2025, 5, 12, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("week of Sunday starts %v, want the Monday before", got)
+	}
+}
+
+func TestFillDownloadPeriods(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2025, 5, d, 0, 0, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name     string
+		counts   []DownloadPeriodCount
+		from, to time.Time
+		interval AnalyticsInterval
+		want     []DownloadPeriodCount
+	}{
+		{
+			name:     "gaps are zero",
+			counts:   []DownloadPeriodCount{{Period: day(2), Downloads: 4, Users: 2}},
+			from:     day(1).Add(9 * time.Hour),
+			to:       day(4),
+			interval: AnalyticsIntervalDay,
+			want: []DownloadPeriodCount{
+				{Period: day(1)}, {Period: day(2), Downloads: 4, Users: 2}, {Period: day(3)},
+			},
+		},
+		{
+			name:     "weeks",
+			counts:   []DownloadPeriodCount{{Period: day(12), Downloads: 7, Users: 3}},
+			from:     day(7),
+			to:       day(20),
+			interval: AnalyticsIntervalWeek,
+			want: []DownloadPeriodCount{
+				{Period: day(5)}, {Period: day(12), Downloads: 7, Users: 3}, {Period: day(19)},
+			},
+		},
+		{
+			name:     "empty range",
+			from:     day(3),
+			to:       day(3),
+			interval: AnalyticsIntervalDay,
+			want:     []DownloadPeriodCount{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := FillDownloadPeriods(tt.counts, tt.from, tt.to, tt.interval)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FillDownloadPeriods() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdateAnalyticsSettingsRequestValidate(t *testing.T) {
+	tests := []struct {
+		days int
+		want error
+	}{
+		{days: MinDownloadRetentionDays},
+		{days: 365},
+		{days: MaxDownloadRetentionDays},
+		{days: MinDownloadRetentionDays - 1, want: ErrInvalidDownloadRetention},
+		{days: MaxDownloadRetentionDays + 1, want: ErrInvalidDownloadRetention},
+	}
+
+	for _, tt := range tests {
+		req := UpdateAnalyticsSettingsRequest{DownloadRetentionDays: tt.days}
+		if got := req.Validate(); got != tt.want {
+			t.Errorf("Validate(%d days) = %v, want %v", tt.days, got, tt.want)
+		}
+	}
+}
+
+func TestNewDocumentDownload(t *testing.T) {
+	user := &User{Profile: UserProfile{Specialty: " Haematology "}}
+	library := &Library{Slug: "sops"}
+	category := &LibraryCategory{}
+	at := time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	got := NewDocumentDownload(user, library, category, "/Protocols/Iron Deficiency.pdf", "015f1", DownloadSourceFile, at)
+	if got.Path != "Protocols/Iron Deficiency.pdf" || got.PathLower != "protocols/iron deficiency.pdf" {
+		t.Errorf("path = %q, lower %q", got.Path, got.PathLower)
+	}
+	if got.Name != "Iron Deficiency.pdf" {
+		t.Errorf("name = %q", got.Name)
+	}
+	if got.Specialty != "Haematology" {
+		t.Errorf("specialty = %q", got.Specialty)
+	}
+}
+
+func TestWriteTopDocumentsCSV(t *testing.T) {
+	rows := []DocumentDownloadCount{{
+		LibrarySlug:      "sops",
+		CategoryName:     "Haemophilia",
+		Path:             "Protocols/Factor VIII.pdf",
+		Name:             "=Factor VIII.pdf",
+		Downloads:        12,
+		Users:            5,
+		LastDownloadedAt: time.Date(2025, 5, 1, 9, 0, 0, 0, time.UTC),
+	}}
+
+	var buf bytes.Buffer
+	if err := WriteTopDocumentsCSV(&buf, rows); err != nil {
+		t.Fatalf("WriteTopDocumentsCSV() error = %v", err)
+	}
+	want := "Library,Category,Path,Document,Downloads,Users,Last downloaded\n" +
+		"sops,Haemophilia,Protocols/Factor VIII.pdf,'=Factor VIII.pdf,12,5,2025-05-01T09:00:00Z\n"
+	if got := buf.String(); got != want {
+		t.Errorf("CSV =\n%s\nwant\n%s", got, want)
+	}
+}
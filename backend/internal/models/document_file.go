@@ -0,0 +1,143 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"unicode"
+)
+
+var (
+	ErrInvalidDocumentPath     = errors.New("invalid document path")
+	ErrInvalidDocumentName     = errors.New("invalid file or folder name")
+	ErrUnsupportedDocumentType = errors.New("file type is not allowed")
+	ErrDocumentTooLarge        = errors.New("file must be smaller than 50MB")
+	ErrEmptyDocument           = errors.New("file is empty")
+	ErrInvalidConflictPolicy   = errors.New("conflict must be reject, rename or replace")
+)
+
+// MaxDocumentUploadSize caps files uploaded to a library
+const MaxDocumentUploadSize = 50 * 1024 * 1024
+
+// maxDocumentNameLength is Dropbox's limit on a path component
+const maxDocumentNameLength = 255
+
+// documentExtensions are the file types that may be uploaded to a library
+var documentExtensions = map[string]bool{
+	".pdf": true, ".doc": true, ".docx": true, ".rtf": true, ".odt": true, ".txt": true,
+	".xls": true, ".xlsx": true, ".ods": true, ".csv": true,
+	".ppt": true, ".pptx": true, ".odp": true,
+	".png": true, ".jpg": true, ".jpeg": true,
+}
+
+// UploadConflictPolicy decides what happens when an upload has the name of
+// an existing file
+type UploadConflictPolicy string
+
+const (
+	UploadConflictReject  UploadConflictPolicy = "reject"  // Fail the upload
+	UploadConflictRename  UploadConflictPolicy = "rename"  // Keep both, numbering the new file
+	UploadConflictReplace UploadConflictPolicy = "replace" // Store the upload as a new revision
+)
+
+// ParseUploadConflictPolicy parses a conflict policy, defaulting to reject
+func ParseUploadConflictPolicy(value string) (UploadConflictPolicy, error) {
+	switch policy := UploadConflictPolicy(strings.ToLower(strings.TrimSpace(value))); policy {
+	case "":
+		return UploadConflictReject, nil
+	case UploadConflictReject, UploadConflictRename, UploadConflictReplace:
+		return policy, nil
+	}
+	return "", ErrInvalidConflictPolicy
+}
+
+// NumberedDocumentName returns the name the rename policy gives the nth copy
+// of a file, numbering it before the extension as Dropbox does, for example
+// "Iron (2).pdf"
+func NumberedDocumentName(name string, n int) string {
+	ext := path.Ext(name)
+	return fmt.Sprintf("%s (%d)%s", strings.TrimSuffix(name, ext), n, ext)
+}
+
+// ValidateDocumentName checks a single file or folder name
+func ValidateDocumentName(name string) error {
+	if name == "" || name == "." || name == ".." || len(name) > maxDocumentNameLength {
+		return ErrInvalidDocumentName
+	}
+	if strings.TrimSpace(name) != name || strings.HasSuffix(name, ".") {
+		return ErrInvalidDocumentName
+	}
+	for _, r := range name {
+		if r == '/' || r == '\\' || unicode.IsControl(r) {
+			return ErrInvalidDocumentName
+		}
+	}
+	return nil
+}
+
+// CleanDocumentPath normalises a path relative to a category folder. Every
+// component must be a valid name, so the path cannot leave the folder. The
+// folder itself is "".
+func CleanDocumentPath(p string) (string, error) {
+	p = strings.Trim(strings.TrimSpace(p), "/")
+	if p == "" {
+		return "", nil
+	}
+	for _, name := range strings.Split(p, "/") {
+		if err := ValidateDocumentName(name); err != nil {
+			return "", ErrInvalidDocumentPath
+		}
+	}
+	return p, nil
+}
+
+// CleanDocumentItemPath normalises the path of a file or subfolder in a
+// category folder, which cannot be the folder itself
+func CleanDocumentItemPath(p string) (string, error) {
+	cleaned, err := CleanDocumentPath(p)
+	if err != nil {
+		return "", err
+	}
+	if cleaned == "" {
+		return "", ErrInvalidDocumentPath
+	}
+	return cleaned, nil
+}
+
+// ValidateDocumentType checks a file name and that its type may be stored in
+// a library
+func ValidateDocumentType(name string) error {
+	if err := ValidateDocumentName(name); err != nil {
+		return err
+	}
+	if !documentExtensions[strings.ToLower(path.Ext(name))] {
+		return ErrUnsupportedDocumentType
+	}
+	return nil
+}
+
+// ValidateDocumentUpload checks an uploaded file's name, type and size
+func ValidateDocumentUpload(name string, size int64) error {
+	if err := ValidateDocumentType(name); err != nil {
+		return err
+	}
+	if size <= 0 {
+		return ErrEmptyDocument
+	}
+	if size > MaxDocumentUploadSize {
+		return ErrDocumentTooLarge
+	}
+	return nil
+}
+
+// CreateDocumentFolderRequest creates a subfolder in a category
+type CreateDocumentFolderRequest struct {
+	Path string `json:"path" binding:"required"` // Relative to the category folder
+}
+
+// MoveDocumentRequest moves or renames a file or subfolder within a category
+type MoveDocumentRequest struct {
+	From string `json:"from" binding:"required"`
+	To   string `json:"to" binding:"required"`
+}
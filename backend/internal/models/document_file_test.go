@@ -0,0 +1,138 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCleanDocumentPath(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{name: "root", path: "", want: ""},
+		{name: "slashes only", path: " / ", want: ""},
+		{name: "file", path: "Iron.pdf", want: "Iron.pdf"},
+		{name: "nested with slashes", path: "/Protocols/Iron.pdf/", want: "Protocols/Iron.pdf"},
+		{name: "parent directory", path: "../Other/Iron.pdf", wantErr: true},
+		{name: "nested parent directory", path: "Protocols/../../Iron.pdf", wantErr: true},
+		{name: "current directory", path: "Protocols/./Iron.pdf", wantErr: true},
+		{name: "empty component", path: "Protocols//Iron.pdf", wantErr: true},
+		{name: "backslash", path: `Protocols\Iron.pdf`, wantErr: true},
+		{name: "control character", path: "Iron\n.pdf", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CleanDocumentPath(tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CleanDocumentPath(%q) error = %v, wantErr %t", tt.path, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("CleanDocumentPath(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCleanDocumentItemPath(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{name: "file", path: "/Protocols/Iron.pdf", want: "Protocols/Iron.pdf"},
+		{name: "category folder", path: "/", wantErr: true},
+		{name: "registry submission", path: "../../Submissions/Hospital/Ann Smith/Form/123/scan.pdf", wantErr: true},
+		{name: "parent directory inside", path: "Protocols/../Iron.pdf", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CleanDocumentItemPath(tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CleanDocumentItemPath(%q) error = %v, wantErr %t", tt.path, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("CleanDocumentItemPath(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateDocumentUpload(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		size     int64
+		want     error
+	}{
+		{name: "pdf", filename: "Iron.pdf", size: 1024},
+		{name: "extension ignores case", filename: "Dosing.XLSX", size: 1024},
+		{name: "at the limit", filename: "Iron.pdf", size: MaxDocumentUploadSize},
+		{name: "too large", filename: "Iron.pdf", size: MaxDocumentUploadSize + 1, want: ErrDocumentTooLarge},
+		{name: "empty", filename: "Iron.pdf", size: 0, want: ErrEmptyDocument},
+		{name: "executable", filename: "setup.exe", size: 1024, want: ErrUnsupportedDocumentType},
+		{name: "html", filename: "page.html", size: 1024, want: ErrUnsupportedDocumentType},
+		{name: "no extension", filename: "README", size: 1024, want: ErrUnsupportedDocumentType},
+		{name: "path in name", filename: "../Iron.pdf", size: 1024, want: ErrInvalidDocumentName},
+		{name: "trailing space", filename: "Iron.pdf ", size: 1024, want: ErrInvalidDocumentName},
+		{name: "name too long", filename: strings.Repeat("a", 252) + ".pdf", size: 1024, want: ErrInvalidDocumentName},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateDocumentUpload(tt.filename, tt.size); err != tt.want {
+				t.Errorf("ValidateDocumentUpload(%q, %d) = %v, want %v", tt.filename, tt.size, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseUploadConflictPolicy(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    UploadConflictPolicy
+		wantErr bool
+	}{
+		{value: "", want: UploadConflictReject},
+		{value: "rename", want: UploadConflictRename},
+		{value: " Replace ", want: UploadConflictReplace},
+		{value: "overwrite", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseUploadConflictPolicy(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseUploadConflictPolicy(%q) error = %v, wantErr %t", tt.value, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseUploadConflictPolicy(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNumberedDocumentName(t *testing.T) {
+	tests := []struct {
+		name string
+		n    int
+		want string
+	}{
+		{name: "Iron.pdf", n: 1, want: "Iron (1).pdf"},
+		{name: "Iron deficiency.v2.docx", n: 3, want: "Iron deficiency.v2 (3).docx"},
+		{name: "README", n: 2, want: "README (2)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := NumberedDocumentName(tt.name, tt.n); got != tt.want {
+				t.Errorf("NumberedDocumentName(%q, %d) = %q, want %q", tt.name, tt.n, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrInvalidDocumentStatus = errors.New("status must be draft, approved or retired")
+	ErrInvalidReviewInterval = errors.New("review interval must be between 0 and 60 months")
+)
+
+// DocumentStatus is the publication status of a library document
+type DocumentStatus string
+
+const (
+	DocumentStatusDraft    DocumentStatus = "draft"
+	DocumentStatusApproved DocumentStatus = "approved"
+	DocumentStatusRetired  DocumentStatus = "retired"
+)
+
+// IsValid checks if the status is valid
+func (s DocumentStatus) IsValid() bool {
+	switch s {
+	case DocumentStatusDraft, DocumentStatusApproved, DocumentStatusRetired:
+		return true
+	}
+	return false
+}
+
+// Review states of a document, derived from its next review date
+const (
+	ReviewStateCurrent = "current"
+	ReviewStateDueSoon = "due_soon"
+	ReviewStateOverdue = "overdue"
+)
+
+// ReviewNoticePeriod is how long before a review falls due that its owner is
+// reminded and the document is reported as due soon
+const ReviewNoticePeriod = 14 * 24 * time.Hour
+
+// MaxReviewIntervalMonths bounds a document's review cadence
+const MaxReviewIntervalMonths = 60
+
+// DocumentMetadata holds the ownership and review cadence of a file in a
+// library category. The file itself stays in Dropbox; metadata is matched to
+// it by path.
+type DocumentMetadata struct {
+	ID                   primitive.ObjectID  `bson:"_id,omitempty" json:"id,omitempty"`
+	LibraryID            primitive.ObjectID  `bson:"library_id" json:"libraryId"`
+	CategoryID           primitive.ObjectID  `bson:"category_id" json:"categoryId"`
+	Path                 string              `bson:"path" json:"path"` // Relative to the category folder
+	PathLower            string              `bson:"path_lower" json:"-"`
+	Status               DocumentStatus      `bson:"status" json:"status"`
+	OwnerID              *primitive.ObjectID `bson:"owner_id,omitempty" json:"ownerId,omitempty"`
+	ReviewIntervalMonths int                 `bson:"review_interval_months" json:"reviewIntervalMonths"` // 0 means no periodic review
+	LastReviewedAt       *time.Time          `bson:"last_reviewed_at,omitempty" json:"lastReviewedAt,omitempty"`
+	NextReviewAt         *time.Time          `bson:"next_review_at,omitempty" json:"nextReviewAt,omitempty"`
+	ReminderSentFor      *time.Time          `bson:"reminder_sent_for,omitempty" json:"-"` // The review date the owner was last reminded of
+	CreatedAt            time.Time           `bson:"created_at" json:"createdAt"`
+	UpdatedAt            time.Time           `bson:"updated_at" json:"updatedAt"`
+	UpdatedBy            *primitive.ObjectID `bson:"updated_by,omitempty" json:"updatedBy,omitempty"`
+
+	// Computed when served
+	ReviewState string `bson:"-" json:"reviewState,omitempty"`
+}
+
+// ReviewStateAt returns the review state of the document at the given time,
+// or "" when it has no review date or is retired
+func (m *DocumentMetadata) ReviewStateAt(now time.Time) string {
+	if m.NextReviewAt == nil || m.Status == DocumentStatusRetired {
+		return ""
+	}
+	if !now.Before(*m.NextReviewAt) {
+		return ReviewStateOverdue
+	}
+	if now.Add(ReviewNoticePeriod).After(*m.NextReviewAt) {
+		return ReviewStateDueSoon
+	}
+	return ReviewStateCurrent
+}
+
+// MarkReviewed records a review and schedules the next one from it
+func (m *DocumentMetadata) MarkReviewed(at time.Time) {
+	m.LastReviewedAt = &at
+	if m.ReviewIntervalMonths == 0 {
+		m.setNextReview(nil)
+		return
+	}
+	next := at.AddDate(0, m.ReviewIntervalMonths, 0)
+	m.setNextReview(&next)
+}
+
+// setNextReview changes the next review date, clearing the reminder sent for
+// the previous date
+func (m *DocumentMetadata) setNextReview(next *time.Time) {
+	m.NextReviewAt = next
+	m.ReminderSentFor = nil
+}
+
+// UpdateDocumentMetadataRequest changes a document's metadata. The owner is
+// resolved by the service; the other fields are applied by Apply.
+type UpdateDocumentMetadataRequest struct {
+	Status               *DocumentStatus `json:"status,omitempty"`
+	OwnerID              *string         `json:"ownerId,omitempty"` // Empty clears the owner
+	ReviewIntervalMonths *int            `json:"reviewIntervalMonths,omitempty"`
+	NextReviewAt         *time.Time      `json:"nextReviewAt,omitempty"`
+	Reviewed             bool            `json:"reviewed,omitempty"` // Record a review now
+}
+
+// Validate validates the update request
+func (r *UpdateDocumentMetadataRequest) Validate() error {
+	if r.Status != nil && !r.Status.IsValid() {
+		return ErrInvalidDocumentStatus
+	}
+	if r.ReviewIntervalMonths != nil && (*r.ReviewIntervalMonths < 0 || *r.ReviewIntervalMonths > MaxReviewIntervalMonths) {
+		return ErrInvalidReviewInterval
+	}
+	return nil
+}
+
+// Apply applies the request to the metadata. An explicit next review date
+// wins; otherwise recording a review or changing the interval reschedules
+// the next review from the last one.
+func (r *UpdateDocumentMetadataRequest) Apply(m *DocumentMetadata, now time.Time) {
+	if r.Status != nil {
+		m.Status = *r.Status
+	}
+
+	intervalChanged := r.ReviewIntervalMonths != nil && *r.ReviewIntervalMonths != m.ReviewIntervalMonths
+	if r.ReviewIntervalMonths != nil {
+		m.ReviewIntervalMonths = *r.ReviewIntervalMonths
+	}
+
+	switch {
+	case r.Reviewed:
+		m.MarkReviewed(now)
+	case intervalChanged && m.ReviewIntervalMonths == 0:
+		m.setNextReview(nil)
+	case intervalChanged:
+		from := now
+		if m.LastReviewedAt != nil {
+			from = *m.LastReviewedAt
+		}
+		next := from.AddDate(0, m.ReviewIntervalMonths, 0)
+		m.setNextReview(&next)
+	}
+
+	if r.NextReviewAt != nil {
+		next := *r.NextReviewAt
+		m.setNextReview(&next)
+	}
+}
+
+// DocumentReviewItem is a document in the review report
+type DocumentReviewItem struct {
+	Name         string         `json:"name"`
+	Path         string         `json:"path"`
+	LibrarySlug  string         `json:"librarySlug"`
+	LibraryName  string         `json:"libraryName"`
+	CategoryID   string         `json:"categoryId"`
+	CategoryName string         `json:"categoryName"`
+	Status       DocumentStatus `json:"status"`
+	ReviewState  string         `json:"reviewState"`
+	NextReviewAt time.Time      `json:"nextReviewAt"`
+	DaysOverdue  int            `json:"daysOverdue"`
+	OwnerID      string         `json:"ownerId,omitempty"`
+	OwnerName    string         `json:"ownerName,omitempty"`
+	OwnerEmail   string         `json:"ownerEmail,omitempty"`
+}
@@ -0,0 +1,134 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDocumentMetadataReviewStateAt(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) *time.Time {
+		v := now.Add(d)
+		return &v
+	}
+
+	tests := []struct {
+		name     string
+		metadata DocumentMetadata
+		want     string
+	}{
+		{name: "no review date", metadata: DocumentMetadata{Status: DocumentStatusApproved}, want: ""},
+		{name: "current", metadata: DocumentMetadata{Status: DocumentStatusApproved, NextReviewAt: at(30 * 24 * time.Hour)}, want: ReviewStateCurrent},
+		{name: "due soon", metadata: DocumentMetadata{Status: DocumentStatusApproved, NextReviewAt: at(7 * 24 * time.Hour)}, want: ReviewStateDueSoon},
+		{name: "due now", metadata: DocumentMetadata{Status: DocumentStatusDraft, NextReviewAt: at(0)}, want: ReviewStateOverdue},
+		{name: "overdue", metadata: DocumentMetadata{Status: DocumentStatusApproved, NextReviewAt: at(-time.Hour)}, want: ReviewStateOverdue},
+		{name: "retired", metadata: DocumentMetadata{Status: DocumentStatusRetired, NextReviewAt: at(-time.Hour)}, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.metadata.ReviewStateAt(now); got != tt.want {
+				t.Errorf("ReviewStateAt() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdateDocumentMetadataRequestApply(t *testing.T) {
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	lastReview := time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC)
+	explicit := time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC)
+	intPtr := func(v int) *int { return &v }
+	date := func(y int, m time.Month, d int) *time.Time {
+		v := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+		return &v
+	}
+
+	tests := []struct {
+		name     string
+		metadata DocumentMetadata
+		req      UpdateDocumentMetadataRequest
+		want     *time.Time
+	}{
+		{
+			name: "new interval schedules from now",
+			req:  UpdateDocumentMetadataRequest{ReviewIntervalMonths: intPtr(12)},
+			want: date(2026, 6, 1),
+		},
+		{
+			name:     "new interval schedules from last review",
+			metadata: DocumentMetadata{ReviewIntervalMonths: 12, LastReviewedAt: &lastReview, NextReviewAt: date(2026, 1, 15)},
+			req:      UpdateDocumentMetadataRequest{ReviewIntervalMonths: intPtr(6)},
+			want:     date(2025, 7, 15),
+		},
+		{
+			name:     "same interval keeps the date",
+			metadata: DocumentMetadata{ReviewIntervalMonths: 12, NextReviewAt: date(2026, 1, 15)},
+			req:      UpdateDocumentMetadataRequest{ReviewIntervalMonths: intPtr(12)},
+			want:     date(2026, 1, 15),
+		},
+		{
+			name:     "zero interval clears the date",
+			metadata: DocumentMetadata{ReviewIntervalMonths: 12, NextReviewAt: date(2026, 1, 15)},
+			req:      UpdateDocumentMetadataRequest{ReviewIntervalMonths: intPtr(0)},
+		},
+		{
+			name:     "reviewed reschedules from now",
+			metadata: DocumentMetadata{ReviewIntervalMonths: 24, NextReviewAt: date(2025, 5, 1)},
+			req:      UpdateDocumentMetadataRequest{Reviewed: true},
+			want:     date(2027, 6, 1),
+		},
+		{
+			name:     "explicit date wins",
+			metadata: DocumentMetadata{ReviewIntervalMonths: 12},
+			req:      UpdateDocumentMetadataRequest{Reviewed: true, NextReviewAt: &explicit},
+			want:     &explicit,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := tt.metadata
+			m.ReminderSentFor = m.NextReviewAt
+			tt.req.Apply(&m, now)
+
+			switch {
+			case tt.want == nil && m.NextReviewAt != nil:
+				t.Errorf("NextReviewAt = %v, want none", *m.NextReviewAt)
+			case tt.want != nil && (m.NextReviewAt == nil || !m.NextReviewAt.Equal(*tt.want)):
+				t.Errorf("NextReviewAt = %v, want %v", m.NextReviewAt, *tt.want)
+			}
+			if tt.req.Reviewed && (m.LastReviewedAt == nil || !m.LastReviewedAt.Equal(now)) {
+				t.Errorf("LastReviewedAt = %v, want %v", m.LastReviewedAt, now)
+			}
+			if m.ReminderSentFor != nil && (m.NextReviewAt == nil || !m.ReminderSentFor.Equal(*m.NextReviewAt)) {
+				t.Errorf("reminder for %v kept after rescheduling to %v", *m.ReminderSentFor, m.NextReviewAt)
+			}
+		})
+	}
+}
+
+func TestUpdateDocumentMetadataRequestValidate(t *testing.T) {
+	status := func(s DocumentStatus) *DocumentStatus { return &s }
+	intPtr := func(v int) *int { return &v }
+
+	tests := []struct {
+		name    string
+		req     UpdateDocumentMetadataRequest
+		wantErr error
+	}{
+		{name: "empty", req: UpdateDocumentMetadataRequest{}},
+		{name: "valid", req: UpdateDocumentMetadataRequest{Status: status(DocumentStatusRetired), ReviewIntervalMonths: intPtr(60)}},
+		{name: "unknown status", req: UpdateDocumentMetadataRequest{Status: status("archived")}, wantErr: ErrInvalidDocumentStatus},
+		{name: "negative interval", req: UpdateDocumentMetadataRequest{ReviewIntervalMonths: intPtr(-1)}, wantErr: ErrInvalidReviewInterval},
+		{name: "interval too long", req: UpdateDocumentMetadataRequest{ReviewIntervalMonths: intPtr(61)}, wantErr: ErrInvalidReviewInterval},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.req.Validate(); err != tt.wantErr {
+				t.Errorf("Validate() = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
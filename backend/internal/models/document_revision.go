@@ -0,0 +1,63 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Revision change types. Revisions found in Dropbox's own file history,
+// rather than seen changing by a sync, have no change type.
+const (
+	RevisionAdded   = "added"
+	RevisionUpdated = "updated"
+	RevisionDeleted = "deleted"
+)
+
+// DocumentRevision is one revision of a file in a library category's
+// Dropbox folder
+type DocumentRevision struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	LibraryID    primitive.ObjectID `bson:"library_id" json:"libraryId"`
+	CategoryID   primitive.ObjectID `bson:"category_id" json:"categoryId"`
+	Path         string             `bson:"path" json:"path"` // Relative to the category folder
+	PathLower    string             `bson:"path_lower" json:"-"`
+	Name         string             `bson:"name" json:"name"`
+	Rev          string             `bson:"rev" json:"rev"`
+	ContentHash  string             `bson:"content_hash,omitempty" json:"contentHash,omitempty"`
+	Size         int64              `bson:"size" json:"size"`
+	ModifiedTime time.Time          `bson:"modified_time" json:"modifiedTime"`
+	ChangeType   string             `bson:"change_type,omitempty" json:"changeType,omitempty"`
+	RecordedAt   time.Time          `bson:"recorded_at" json:"recordedAt"`
+}
+
+// DocumentFeedItem is one entry of the recently updated feed
+type DocumentFeedItem struct {
+	Name         string    `json:"name"`
+	Path         string    `json:"path"`
+	LibrarySlug  string    `json:"librarySlug"`
+	LibraryName  string    `json:"libraryName"`
+	CategoryID   string    `json:"categoryId"`
+	CategoryName string    `json:"categoryName"`
+	ChangeType   string    `json:"changeType"`
+	Rev          string    `json:"rev"`
+	Size         int64     `json:"size"`
+	ModifiedTime time.Time `json:"modifiedTime"`
+	RecordedAt   time.Time `json:"recordedAt"`
+	IsNew        bool      `json:"isNew"` // Changed since the user last visited the feed
+	DownloadURL  string    `json:"downloadUrl"`
+}
+
+// DocumentFeed is a page of the recently updated feed for one user
+type DocumentFeed struct {
+	Items       []DocumentFeedItem `json:"items"`
+	NewCount    int64              `json:"newCount"`
+	LastVisitAt *time.Time         `json:"lastVisitAt,omitempty"`
+}
+
+// DocumentFeedVisit records when a user last visited the recently updated feed
+type DocumentFeedVisit struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID      primitive.ObjectID `bson:"user_id" json:"userId"`
+	LastVisitAt time.Time          `bson:"last_visit_at" json:"lastVisitAt"`
+}
@@ -1,19 +1,38 @@
 package models
 
 import (
+	"errors"
+	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
-// DropboxConfig stores Dropbox OAuth configuration and tokens
-// This is a singleton - only one document should exist in the collection
+var (
+	ErrInvalidDropboxConnectionName = errors.New("connection name is required and must be at most 100 characters")
+)
+
+// DefaultDropboxConnectionName is the name of the connection that existed
+// before connections were named
+const DefaultDropboxConnectionName = "Default"
+
+// DropboxConfig stores the OAuth configuration, tokens and health of one
+// named Dropbox connection. Libraries and the registry choose a connection;
+// those that do not use the default connection, of which there is one.
 type DropboxConfig struct {
-	ID primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Name      string             `bson:"name" json:"name"`
+	IsDefault bool               `bson:"is_default" json:"isDefault"`
 
 	// OAuth Configuration
-	AppKey    string `bson:"app_key" json:"appKey"`
-	AppSecret string `bson:"app_secret" json:"-"` // Never expose in JSON, encrypted in DB
+	AppKey     string            `bson:"app_key" json:"appKey"`
+	AppSecret  string            `bson:"app_secret" json:"-"` // Never expose in JSON, encrypted in DB. Empty for PKCE.
+	AuthMethod DropboxAuthMethod `bson:"auth_method,omitempty" json:"authMethod"`
+
+	// AccountID is the Dropbox account the connection is authorized for, as
+	// named in webhook notifications. Empty for connections authorized
+	// before it was recorded.
+	AccountID string `bson:"account_id,omitempty" json:"accountId,omitempty"`
 
 	// Tokens (all encrypted in database)
 	RefreshToken string    `bson:"refresh_token" json:"-"` // Never expose in JSON
@@ -59,6 +78,9 @@ func (d *DropboxConfig) GetPublicStatus() map[string]interface{} {
 	needsReconnection := d.NeedsReconnection() || tokenExpired
 
 	return map[string]interface{}{
+		"id":                  d.ID,
+		"name":                d.Name,
+		"isDefault":           d.IsDefault,
 		"isConnected":         isConnected,
 		"tokenExpiry":         d.TokenExpiry,
 		"lastRefreshSuccess":  d.LastRefreshSuccess,
@@ -67,16 +89,84 @@ func (d *DropboxConfig) GetPublicStatus() map[string]interface{} {
 		"lastError":           d.LastError,
 		"needsReconnection":   needsReconnection,
 		"parentFolder":        d.ParentFolder,
+		"authMethod":          d.Method(),
+	}
+}
+
+// Ref returns how libraries, the registry and other records refer to the
+// connection: nil for the default connection, so records saved before
+// connections were named keep using it
+func (d *DropboxConfig) Ref() *primitive.ObjectID {
+	if d.IsDefault {
+		return nil
+	}
+	id := d.ID
+	return &id
+}
+
+// ValidateDropboxConnectionName validates a connection's name
+func ValidateDropboxConnectionName(name string) error {
+	if name = strings.TrimSpace(name); name == "" || len(name) > 100 {
+		return ErrInvalidDropboxConnectionName
+	}
+	return nil
+}
+
+// DropboxConnectionRef converts a connection ID chosen in a request to the
+// reference stored on a library or the registry. The zero ID, sent as an
+// empty string, means the default connection.
+func DropboxConnectionRef(id primitive.ObjectID) *primitive.ObjectID {
+	if id.IsZero() {
+		return nil
+	}
+	return &id
+}
+
+// SameDropboxConnection reports whether two connection references refer to
+// the same connection
+func SameDropboxConnection(a, b *primitive.ObjectID) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
 	}
+	return *a == *b
 }
 
-// DropboxOAuthResponse represents the response from Dropbox OAuth token endpoint
-type DropboxOAuthResponse struct {
-	AccessToken  string `json:"access_token"`
-	TokenType    string `json:"token_type"`
-	ExpiresIn    int    `json:"expires_in"`    // Seconds until expiry
-	RefreshToken string `json:"refresh_token"` // Only on initial auth
-	Scope        string `json:"scope"`
-	UID          string `json:"uid"`
-	AccountID    string `json:"account_id"`
+// Method returns how the app was authorized. Configurations saved before
+// PKCE was supported used the app secret.
+func (d *DropboxConfig) Method() DropboxAuthMethod {
+	if d.AuthMethod == "" {
+		return DropboxAuthSecret
+	}
+	return d.AuthMethod
+}
+
+// DropboxAuthMethod is how the app authenticates to Dropbox's token endpoint
+type DropboxAuthMethod string
+
+const (
+	DropboxAuthSecret DropboxAuthMethod = "secret" // App key and secret
+	DropboxAuthPKCE   DropboxAuthMethod = "pkce"   // App key and a code verifier; no secret is stored
+)
+
+// DropboxAuthRequest is an authorization an admin has started but not yet
+// completed. The state sent to Dropbox is stored as a hash and is bound to
+// the admin and the session that started the flow, so a callback carrying
+// another user's code, a replayed state or a forged state is rejected.
+// Requests are single-use and expire. A request either re-authorizes an
+// existing connection or creates one with ConnectionName.
+type DropboxAuthRequest struct {
+	ID             primitive.ObjectID  `bson:"_id,omitempty"`
+	StateHash      string              `bson:"state_hash"`
+	UserID         primitive.ObjectID  `bson:"user_id"`
+	SessionHash    string              `bson:"session_hash"`
+	Method         DropboxAuthMethod   `bson:"method"`
+	AppKey         string              `bson:"app_key"`
+	AppSecret      string              `bson:"app_secret,omitempty"`    // Encrypted; secret flow only
+	CodeVerifier   string              `bson:"code_verifier,omitempty"` // Encrypted; PKCE only
+	RedirectURI    string              `bson:"redirect_uri,omitempty"`
+	ParentFolder   string              `bson:"parent_folder"`
+	ConnectionID   *primitive.ObjectID `bson:"connection_id,omitempty"`   // Nil for the default connection or a new one
+	ConnectionName string              `bson:"connection_name,omitempty"` // Set for a new connection
+	ExpiresAt      time.Time           `bson:"expires_at"`
+	CreatedAt      time.Time           `bson:"created_at"`
 }
@@ -0,0 +1,92 @@
+package models
+
+import (
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestValidateDropboxConnectionName(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		wantErr bool
+	}{
+		{name: "name", value: "Registry"},
+		{name: "empty", value: "", wantErr: true},
+		{name: "blank", value: "   ", wantErr: true},
+		{name: "too long", value: strings.Repeat("a", 101), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateDropboxConnectionName(tt.value); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateDropboxConnectionName(%q) error = %v, wantErr %t", tt.value, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDropboxConnectionRef(t *testing.T) {
+	id := primitive.NewObjectID()
+	other := primitive.NewObjectID()
+
+	if ref := (&DropboxConfig{ID: id, IsDefault: true}).Ref(); ref != nil {
+		t.Errorf("default Ref() = %v, want nil", ref)
+	}
+	if ref := (&DropboxConfig{ID: id}).Ref(); ref == nil || *ref != id {
+		t.Errorf("Ref() = %v, want %s", ref, id.Hex())
+	}
+	if ref := DropboxConnectionRef(primitive.NilObjectID); ref != nil {
+		t.Errorf("DropboxConnectionRef(zero) = %v, want nil", ref)
+	}
+
+	tests := []struct {
+		name string
+		a, b *primitive.ObjectID
+		want bool
+	}{
+		{name: "both default", want: true},
+		{name: "default and named", b: &id},
+		{name: "same", a: &id, b: DropboxConnectionRef(id), want: true},
+		{name: "different", a: &id, b: &other},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SameDropboxConnection(tt.a, tt.b); got != tt.want {
+				t.Errorf("SameDropboxConnection() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdateLibraryRequestDropboxConnection(t *testing.T) {
+	id := primitive.NewObjectID()
+	library := (&CreateLibraryRequest{Name: "Registry Files", DropboxConnectionID: id}).Library()
+
+	tests := []struct {
+		name        string
+		connection  primitive.ObjectID
+		wantChange  bool
+		wantDefault bool
+	}{
+		{name: "same connection", connection: id},
+		{name: "back to the default", connection: primitive.NilObjectID, wantChange: true, wantDefault: true},
+		{name: "another connection", connection: primitive.NewObjectID(), wantChange: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			connection := tt.connection
+			updated, changes := (&UpdateLibraryRequest{DropboxConnectionID: &connection}).Apply(*library)
+			if _, changed := changes["dropbox_connection_id"]; changed != tt.wantChange {
+				t.Fatalf("Apply() changes = %v, want change %t", changes, tt.wantChange)
+			}
+			if (updated.DropboxConnectionID == nil) != tt.wantDefault {
+				t.Errorf("DropboxConnectionID = %v, want default %t", updated.DropboxConnectionID, tt.wantDefault)
+			}
+		})
+	}
+}
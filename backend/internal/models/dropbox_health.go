@@ -0,0 +1,138 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DropboxHealthEventType is what happened to the Dropbox connection
+type DropboxHealthEventType string
+
+const (
+	DropboxHealthFailure   DropboxHealthEventType = "failure"   // A token refresh or connection check failed
+	DropboxHealthDegraded  DropboxHealthEventType = "degraded"  // Failures reached the alert threshold
+	DropboxHealthRecovered DropboxHealthEventType = "recovered" // The connection works again after failing
+)
+
+// Defaults for Dropbox connection alerts
+const (
+	DropboxAlertThreshold = 3         // Consecutive failures before the connection counts as degraded
+	DropboxAlertCooldown  = time.Hour // Least time between two "degraded" emails
+)
+
+// DropboxHealthEvent is an entry in the Dropbox connection's health history
+type DropboxHealthEvent struct {
+	ID                  primitive.ObjectID     `bson:"_id,omitempty" json:"id"`
+	Type                DropboxHealthEventType `bson:"type" json:"type"`
+	ConsecutiveFailures int                    `bson:"consecutive_failures" json:"consecutiveFailures"`
+	Error               string                 `bson:"error,omitempty" json:"error,omitempty"`
+	Notified            bool                   `bson:"notified" json:"notified"` // Whether admins were emailed
+	CreatedAt           time.Time              `bson:"created_at" json:"createdAt"`
+
+	// The connection the event is about; nil for the default connection
+	ConnectionID   *primitive.ObjectID `bson:"connection_id,omitempty" json:"connectionId,omitempty"`
+	ConnectionName string              `bson:"connection_name,omitempty" json:"connectionName,omitempty"`
+}
+
+// DropboxAlertState tracks whether the Dropbox connection is degraded and
+// whether admins have been told, so each outage is emailed once and a
+// connection that keeps failing and recovering does not flood inboxes.
+// There is one state per connection.
+type DropboxAlertState struct {
+	Degraded            bool       `bson:"degraded" json:"degraded"`
+	DegradedSince       *time.Time `bson:"degraded_since,omitempty" json:"degradedSince,omitempty"`
+	ConsecutiveFailures int        `bson:"consecutive_failures" json:"consecutiveFailures"`
+	NeedsReconnection   bool       `bson:"needs_reconnection" json:"needsReconnection"`
+	LastError           string     `bson:"last_error,omitempty" json:"lastError,omitempty"`
+	Notified            bool       `bson:"notified" json:"notified"` // A "degraded" email went out for this outage
+	LastNotifiedAt      *time.Time `bson:"last_notified_at,omitempty" json:"lastNotifiedAt,omitempty"`
+	UpdatedAt           time.Time  `bson:"updated_at" json:"updatedAt"`
+
+	// The connection the state is about; nil for the default connection
+	ConnectionID   *primitive.ObjectID `bson:"connection_id,omitempty" json:"connectionId,omitempty"`
+	ConnectionName string              `bson:"connection_name,omitempty" json:"connectionName,omitempty"`
+}
+
+// RecordFailure updates the state for a failed refresh or check and returns
+// the events to add to the history. The connection becomes degraded when
+// failures reach threshold, or at once when Dropbox must be re-authorized.
+// A "degraded" event is marked Notified, meaning admins should be emailed,
+// unless an email went out within cooldown; an outage that was not emailed
+// for that reason is emailed on the first failure after the cooldown.
+func (s *DropboxAlertState) RecordFailure(failures, threshold int, needsReconnection bool, errMsg string, now time.Time, cooldown time.Duration) []DropboxHealthEvent {
+	s.ConsecutiveFailures = failures
+	s.NeedsReconnection = s.NeedsReconnection || needsReconnection
+	s.LastError = errMsg
+	s.UpdatedAt = now
+
+	events := []DropboxHealthEvent{{
+		Type:                DropboxHealthFailure,
+		ConsecutiveFailures: failures,
+		Error:               errMsg,
+		CreatedAt:           now,
+	}}
+
+	wasDegraded := s.Degraded
+	if !wasDegraded && failures < threshold && !needsReconnection {
+		return events
+	}
+	if !wasDegraded {
+		s.Degraded = true
+		s.DegradedSince = &now
+	}
+
+	notify := !s.Notified && (s.LastNotifiedAt == nil || now.Sub(*s.LastNotifiedAt) >= cooldown)
+	if notify {
+		s.Notified = true
+		s.LastNotifiedAt = &now
+	}
+	if wasDegraded && !notify {
+		return events
+	}
+	return append(events, DropboxHealthEvent{
+		Type:                DropboxHealthDegraded,
+		ConsecutiveFailures: failures,
+		Error:               errMsg,
+		Notified:            notify,
+		CreatedAt:           now,
+	})
+}
+
+// RecordSuccess updates the state for a successful refresh or check and
+// returns the events to add to the history. A "recovered" event is marked
+// Notified when admins were emailed that this outage began.
+func (s *DropboxAlertState) RecordSuccess(now time.Time) []DropboxHealthEvent {
+	if !s.Degraded && s.ConsecutiveFailures == 0 {
+		return nil
+	}
+
+	event := DropboxHealthEvent{
+		Type:                DropboxHealthRecovered,
+		ConsecutiveFailures: s.ConsecutiveFailures,
+		Notified:            s.Degraded && s.Notified,
+		CreatedAt:           now,
+	}
+	if event.Notified {
+		s.LastNotifiedAt = &now
+	}
+
+	s.Degraded = false
+	s.DegradedSince = nil
+	s.ConsecutiveFailures = 0
+	s.NeedsReconnection = false
+	s.LastError = ""
+	s.Notified = false
+	s.UpdatedAt = now
+	return []DropboxHealthEvent{event}
+}
+
+// SystemAlert is a notice shown to users in an in-app banner
+type SystemAlert struct {
+	ID        string    `json:"id"`
+	Severity  string    `json:"severity"` // "warning" or "error"
+	Message   string    `json:"message"`
+	Since     time.Time `json:"since"`
+	Detail    string    `json:"detail,omitempty"` // Only shown to system admins
+	ActionURL string    `json:"actionUrl,omitempty"`
+}
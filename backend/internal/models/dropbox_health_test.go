@@ -0,0 +1,111 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// healthStep is a refresh outcome fed to the alert state
+type healthStep struct {
+	after    time.Duration // Since the previous step
+	ok       bool
+	failures int
+	reauth   bool
+}
+
+func eventSummary(events []DropboxHealthEvent) string {
+	parts := []string{}
+	for _, e := range events {
+		if e.Type == DropboxHealthFailure {
+			continue
+		}
+		part := string(e.Type)
+		if e.Notified {
+			part += "+email"
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, ",")
+}
+
+func TestDropboxAlertState(t *testing.T) {
+	tests := []struct {
+		name  string
+		steps []healthStep
+		want  []string // Non-failure events of each step
+	}{
+		{
+			name:  "failures below the threshold are not alerted",
+			steps: []healthStep{{failures: 1}, {failures: 2}, {ok: true}},
+			want:  []string{"", "", "recovered"},
+		},
+		{
+			name:  "degraded then recovered are both emailed",
+			steps: []healthStep{{failures: 1}, {failures: 2}, {failures: 3}, {failures: 4}, {after: 3 * time.Hour, ok: true}},
+			want:  []string{"", "", "degraded+email", "", "recovered+email"},
+		},
+		{
+			name:  "re-authorization degrades at once",
+			steps: []healthStep{{failures: 1, reauth: true}},
+			want:  []string{"degraded+email"},
+		},
+		{
+			name:  "success without failures records nothing",
+			steps: []healthStep{{ok: true}, {ok: true}},
+			want:  []string{"", ""},
+		},
+		{
+			name: "flapping within the cooldown is emailed once each way",
+			steps: []healthStep{
+				{failures: 3}, {after: 10 * time.Minute, ok: true},
+				{after: 10 * time.Minute, failures: 3}, {after: 10 * time.Minute, ok: true},
+			},
+			want: []string{"degraded+email", "recovered+email", "degraded", "recovered"},
+		},
+		{
+			name: "an outage held back by the cooldown is emailed once it passes",
+			steps: []healthStep{
+				{failures: 3}, {after: 10 * time.Minute, ok: true},
+				{after: 10 * time.Minute, failures: 3}, {after: 20 * time.Minute, failures: 4},
+				{after: 30 * time.Minute, failures: 5}, {after: 30 * time.Minute, failures: 6},
+				{after: time.Hour, ok: true},
+			},
+			want: []string{"degraded+email", "recovered+email", "degraded", "", "degraded+email", "", "recovered+email"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := &DropboxAlertState{}
+			now := time.Date(2025, 5, 1, 8, 0, 0, 0, time.UTC)
+			for i, step := range tt.steps {
+				now = now.Add(step.after)
+				var events []DropboxHealthEvent
+				if step.ok {
+					events = state.RecordSuccess(now)
+				} else {
+					events = state.RecordFailure(step.failures, DropboxAlertThreshold, step.reauth, fmt.Sprintf("failure %d", step.failures), now, DropboxAlertCooldown)
+				}
+				if got := eventSummary(events); got != tt.want[i] {
+					t.Errorf("step %d: events = %q, want %q", i, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestDropboxAlertStateReset(t *testing.T) {
+	state := &DropboxAlertState{}
+	now := time.Date(2025, 5, 1, 8, 0, 0, 0, time.UTC)
+	state.RecordFailure(3, DropboxAlertThreshold, true, "invalid_grant", now, DropboxAlertCooldown)
+	if !state.Degraded || !state.NeedsReconnection || state.DegradedSince == nil || state.LastError != "invalid_grant" {
+		t.Fatalf("after failure state = %+v", state)
+	}
+
+	state.RecordSuccess(now.Add(time.Minute))
+	if state.Degraded || state.NeedsReconnection || state.DegradedSince != nil || state.ConsecutiveFailures != 0 || state.LastError != "" || state.Notified {
+		t.Errorf("after success state = %+v", state)
+	}
+}
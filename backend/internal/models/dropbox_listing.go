@@ -0,0 +1,66 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DropboxListingEntry is one file or folder below a cached Dropbox folder
+type DropboxListingEntry struct {
+	PathLower    string    `bson:"path_lower" json:"pathLower"`
+	PathDisplay  string    `bson:"path_display" json:"pathDisplay"`
+	Name         string    `bson:"name" json:"name"`
+	IsFolder     bool      `bson:"is_folder" json:"isFolder"`
+	Size         int64     `bson:"size,omitempty" json:"size,omitempty"`
+	ModifiedTime time.Time `bson:"modified_time,omitempty" json:"modifiedTime,omitempty"`
+	Rev          string    `bson:"rev,omitempty" json:"rev,omitempty"`
+	ContentHash  string    `bson:"content_hash,omitempty" json:"contentHash,omitempty"`
+}
+
+// DropboxListing is the cached recursive listing of a Dropbox folder. The
+// list_folder cursor lets later syncs fetch only what changed since. A
+// folder is cached once per connection.
+type DropboxListing struct {
+	ID           primitive.ObjectID    `bson:"_id,omitempty" json:"id"`
+	Path         string                `bson:"path" json:"path"`                  // Lower-cased full Dropbox path
+	RelativePath string                `bson:"relative_path" json:"relativePath"` // Path as requested, below the app's parent folder
+	Cursor       string                `bson:"cursor" json:"-"`
+	Entries      []DropboxListingEntry `bson:"entries" json:"entries,omitempty"`
+	EntryCount   int                   `bson:"entry_count" json:"entryCount"`
+	Missing      bool                  `bson:"missing" json:"missing"` // The folder did not exist at the last sync
+	LastError    string                `bson:"last_error,omitempty" json:"lastError,omitempty"`
+	SyncedAt     time.Time             `bson:"synced_at" json:"syncedAt"`
+	LastReadAt   time.Time             `bson:"last_read_at" json:"lastReadAt"`
+	CreatedAt    time.Time             `bson:"created_at" json:"createdAt"`
+
+	// ConnectionID is the connection the folder was listed with; nil for the default connection
+	ConnectionID *primitive.ObjectID `bson:"connection_id,omitempty" json:"connectionId,omitempty"`
+}
+
+// HasCursor reports whether the next sync can be incremental
+func (l *DropboxListing) HasCursor() bool {
+	return l.Cursor != ""
+}
+
+// Document event types published when a sync finds changed files
+const (
+	DocumentEventAdded   = "document.added"
+	DocumentEventUpdated = "document.updated"
+	DocumentEventDeleted = "document.deleted"
+)
+
+// DocumentEvent reports a file that changed in a synced Dropbox folder
+type DocumentEvent struct {
+	ConnectionID *primitive.ObjectID `json:"connectionId,omitempty"` // Dropbox connection of the folder; nil for the default
+	Type         string              `json:"type"`
+	Folder       string              `json:"folder"` // Synced folder, relative to the connection's parent folder
+	Path         string              `json:"path"`
+	RelativePath string              `json:"relativePath"` // Path below the synced folder
+	Name         string              `json:"name"`
+	Size         int64               `json:"size,omitempty"`
+	ModifiedTime time.Time           `json:"modifiedTime,omitempty"`
+	Rev          string              `json:"rev,omitempty"`
+	ContentHash  string              `json:"contentHash,omitempty"`
+	OccurredAt   time.Time           `json:"occurredAt"`
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ImageKind names the entity an image was uploaded for. It is also the
+// directory under ./uploads the image files are written to.
+type ImageKind string
+
+const (
+	ImageKindInstitution ImageKind = "institutions"
+	ImageKindLibrary     ImageKind = "libraries"
+)
+
+// ImageVariant is one resized rendition of an uploaded image
+type ImageVariant struct {
+	Name        string `bson:"name" json:"name"`
+	Path        string `bson:"path" json:"path"`
+	Width       int    `bson:"width" json:"width"`
+	Height      int    `bson:"height" json:"height"`
+	ContentType string `bson:"content_type" json:"contentType"`
+	Size        int64  `bson:"size" json:"size"`
+}
+
+// Image records a processed upload and the files written for it. Path is
+// the full-size variant and is what entities store as their ImagePath.
+type Image struct {
+	ID         primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	Kind       ImageKind           `bson:"kind" json:"kind"`
+	Path       string              `bson:"path" json:"path"`
+	Variants   []ImageVariant      `bson:"variants" json:"variants"`
+	UploadedBy *primitive.ObjectID `bson:"uploaded_by,omitempty" json:"uploadedBy,omitempty"`
+	CreatedAt  time.Time           `bson:"created_at" json:"createdAt"`
+}
@@ -4,6 +4,8 @@ import (
 	"errors"
 	"time"
 
+	"backend/internal/geo"
+
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
@@ -22,10 +24,128 @@ type Institution struct {
 	Email      string              `bson:"email,omitempty" json:"email,omitempty"`
 	Website    string              `bson:"website,omitempty" json:"website,omitempty"`
 	ImagePath  string              `bson:"image_path,omitempty" json:"imagePath,omitempty"`
+	Aliases    []string            `bson:"aliases,omitempty" json:"aliases,omitempty"`
 	IsActive   bool                `bson:"is_active" json:"isActive"`
 	CreatedAt  time.Time           `bson:"created_at" json:"createdAt"`
 	UpdatedAt  time.Time           `bson:"updated_at" json:"updatedAt"`
 	CreatedBy  *primitive.ObjectID `bson:"created_by,omitempty" json:"createdBy,omitempty"`
+
+	// ImageVariants are the resized renditions of ImagePath
+	ImageVariants []ImageVariant `bson:"image_variants,omitempty" json:"imageVariants,omitempty"`
+
+	// Location is a GeoJSON point backed by a 2dsphere index. LocationSource
+	// records whether it was entered by hand or looked up in the gazetteer.
+	Location       *GeoPoint `bson:"location,omitempty" json:"location,omitempty"`
+	LocationSource string    `bson:"location_source,omitempty" json:"locationSource,omitempty"`
+
+	// Hierarchy: units (departments, wards, labs) sit under a parent institution.
+	// Ancestors holds every ancestor ID from the root down to the direct parent.
+	ParentID  *primitive.ObjectID  `bson:"parent_id,omitempty" json:"parentId,omitempty"`
+	Ancestors []primitive.ObjectID `bson:"ancestors,omitempty" json:"ancestors,omitempty"`
+
+	// Moderation of user-created institutions. Institutions without a status
+	// predate moderation and are treated as approved.
+	Status      InstitutionStatus   `bson:"status,omitempty" json:"status,omitempty"`
+	ReviewedBy  *primitive.ObjectID `bson:"reviewed_by,omitempty" json:"reviewedBy,omitempty"`
+	ReviewedAt  *time.Time          `bson:"reviewed_at,omitempty" json:"reviewedAt,omitempty"`
+	ReviewNotes string              `bson:"review_notes,omitempty" json:"reviewNotes,omitempty"`
+}
+
+// InstitutionStatus represents the moderation state of an institution
+type InstitutionStatus string
+
+const (
+	InstitutionStatusApproved InstitutionStatus = "approved"
+	InstitutionStatusPending  InstitutionStatus = "pending"
+	InstitutionStatusRejected InstitutionStatus = "rejected"
+)
+
+// IsValid checks if the institution status is valid
+func (s InstitutionStatus) IsValid() bool {
+	switch s {
+	case InstitutionStatusApproved, InstitutionStatusPending, InstitutionStatusRejected:
+		return true
+	}
+	return false
+}
+
+// ModerationAction is the decision taken on a pending institution
+type ModerationAction string
+
+const (
+	ModerationActionApprove ModerationAction = "approve"
+	ModerationActionReject  ModerationAction = "reject"
+)
+
+// ModerateInstitutionRequest represents an admin decision on a pending institution.
+// Changes, if present, are applied before the institution is approved.
+type ModerateInstitutionRequest struct {
+	Action  ModerationAction          `json:"action" binding:"required"`
+	Notes   string                    `json:"notes,omitempty"`
+	Changes *UpdateInstitutionRequest `json:"changes,omitempty"`
+}
+
+// Location sources
+const (
+	LocationSourceManual    = "manual"
+	LocationSourceGazetteer = "gazetteer"
+)
+
+// GeoPoint is a GeoJSON point. Coordinates are [longitude, latitude].
+type GeoPoint struct {
+	Type        string    `bson:"type" json:"type"`
+	Coordinates []float64 `bson:"coordinates" json:"coordinates"`
+}
+
+// NewGeoPoint creates a GeoJSON point from a latitude and longitude
+func NewGeoPoint(lat, lng float64) *GeoPoint {
+	return &GeoPoint{Type: "Point", Coordinates: []float64{lng, lat}}
+}
+
+// Latitude returns the latitude of the point
+func (p *GeoPoint) Latitude() float64 {
+	return p.Coordinates[1]
+}
+
+// Longitude returns the longitude of the point
+func (p *GeoPoint) Longitude() float64 {
+	return p.Coordinates[0]
+}
+
+// NearbyInstitution is an institution returned by a radius search
+type NearbyInstitution struct {
+	Institution `bson:",inline"`
+	DistanceKm  float64 `bson:"distance_km" json:"distanceKm"`
+}
+
+// FacetCount is the number of institutions sharing a province or city
+type FacetCount struct {
+	Value    string `bson:"value" json:"value"`
+	Province string `bson:"province,omitempty" json:"province,omitempty"`
+	Count    int64  `bson:"count" json:"count"`
+}
+
+// InstitutionFacets are the province and city counts offered by the institution picker
+type InstitutionFacets struct {
+	Provinces []FacetCount `bson:"provinces" json:"provinces"`
+	Cities    []FacetCount `bson:"cities" json:"cities"`
+}
+
+// GeocodeResult reports the outcome of geocoding institutions from the gazetteer
+type GeocodeResult struct {
+	Checked   int      `json:"checked"`
+	Geocoded  int      `json:"geocoded"`
+	Unmatched []string `json:"unmatched"`
+}
+
+// SubmissionLocation is the number of registry submissions from one institution
+type SubmissionLocation struct {
+	InstitutionID primitive.ObjectID `json:"institutionId"`
+	Name          string             `json:"name"`
+	City          string             `json:"city"`
+	Province      string             `json:"province,omitempty"`
+	Location      *GeoPoint          `json:"location,omitempty"`
+	Submissions   int64              `json:"submissions"`
 }
 
 // InstitutionType represents the type of institution
@@ -72,6 +192,11 @@ type CreateInstitutionRequest struct {
 	Email      string          `json:"email,omitempty"`
 	Website    string          `json:"website,omitempty"`
 	ImagePath  string          `json:"imagePath,omitempty"`
+	ParentID   string          `json:"parentId,omitempty"`
+	// Latitude and Longitude are optional; when omitted the city is looked up
+	// in the gazetteer if one is loaded
+	Latitude  *float64 `json:"latitude,omitempty"`
+	Longitude *float64 `json:"longitude,omitempty"`
 }
 
 // UpdateInstitutionRequest represents the request to update an institution
@@ -89,6 +214,20 @@ type UpdateInstitutionRequest struct {
 	Website    *string          `json:"website,omitempty"`
 	ImagePath  *string          `json:"imagePath,omitempty"`
 	IsActive   *bool            `json:"isActive,omitempty"`
+	// ParentID moves the institution under another one; an empty string makes it top-level
+	ParentID  *string  `json:"parentId,omitempty"`
+	Latitude  *float64 `json:"latitude,omitempty"`
+	Longitude *float64 `json:"longitude,omitempty"`
+}
+
+// InstitutionStats summarises the users of an institution, including those
+// attached to its units
+type InstitutionStats struct {
+	InstitutionID primitive.ObjectID `json:"institutionId"`
+	DirectUsers   int64              `json:"directUsers"`
+	TotalUsers    int64              `json:"totalUsers"`
+	ActiveUsers   int64              `json:"activeUsers"`
+	Units         int64              `json:"units"`
 }
 
 // Validation errors
@@ -99,8 +238,42 @@ var (
 	ErrInvalidInstitutionType     = errors.New("invalid institution type")
 	ErrInstitutionCountryRequired = errors.New("country is required")
 	ErrInstitutionCityRequired    = errors.New("city is required")
+	ErrInvalidModerationAction    = errors.New("moderation action must be approve or reject")
+	ErrRejectionReasonRequired    = errors.New("a reason is required when rejecting an institution")
+	ErrInstitutionNotPending      = errors.New("institution is not awaiting moderation")
+	ErrInstitutionNotApproved     = errors.New("institution is awaiting moderation")
+	ErrInstitutionRejected        = errors.New("institution was rejected during moderation")
+	ErrInvalidParentInstitution   = errors.New("invalid parent institution ID")
+	ErrInstitutionCycle           = errors.New("an institution cannot be placed under itself or one of its units")
+	ErrInvalidCoordinates         = errors.New("latitude must be between -90 and 90 and longitude between -180 and 180, and both must be given")
 )
 
+// ValidateCoordinates checks an optional latitude/longitude pair. Both or
+// neither must be given.
+func ValidateCoordinates(lat, lng *float64) error {
+	if lat == nil && lng == nil {
+		return nil
+	}
+	if lat == nil || lng == nil || !geo.ValidCoordinates(*lat, *lng) {
+		return ErrInvalidCoordinates
+	}
+	return nil
+}
+
+// Validate validates the ModerateInstitutionRequest
+func (req *ModerateInstitutionRequest) Validate() error {
+	switch req.Action {
+	case ModerationActionApprove:
+		return nil
+	case ModerationActionReject:
+		if req.Notes == "" {
+			return ErrRejectionReasonRequired
+		}
+		return nil
+	}
+	return ErrInvalidModerationAction
+}
+
 // Validate validates the CreateInstitutionRequest
 func (req *CreateInstitutionRequest) Validate() error {
 	// Validate name
@@ -129,9 +302,83 @@ func (req *CreateInstitutionRequest) Validate() error {
 		return ErrInstitutionCityRequired
 	}
 
+	// Validate coordinates
+	if err := ValidateCoordinates(req.Latitude, req.Longitude); err != nil {
+		return err
+	}
+
 	return nil
 }
 
+// IsApproved reports whether the institution is publicly visible
+func (i *Institution) IsApproved() bool {
+	return i.Status == "" || i.Status == InstitutionStatusApproved
+}
+
+// IsVisibleTo reports whether a user may see the institution. Pending
+// institutions are only visible to their creator and to admins.
+func (i *Institution) IsVisibleTo(user *User) bool {
+	if i.IsApproved() || user.HasPermission(PermManageUsers) {
+		return true
+	}
+	return i.Status == InstitutionStatusPending && i.CreatedBy != nil && *i.CreatedBy == user.ID
+}
+
+// CheckSelectableBy checks whether the institution may be set on the profile
+// of the given user. A pending institution may only be chosen by the user
+// who proposed it, and a rejected one by nobody.
+func (i *Institution) CheckSelectableBy(userID primitive.ObjectID) error {
+	switch {
+	case i.IsApproved():
+		return nil
+	case i.Status == InstitutionStatusRejected:
+		return ErrInstitutionRejected
+	case i.Status == InstitutionStatusPending && i.CreatedBy != nil && *i.CreatedBy == userID:
+		return nil
+	default:
+		return ErrInstitutionNotApproved
+	}
+}
+
+// PathIDs returns the IDs from the root institution down to this one
+func (i *Institution) PathIDs() []primitive.ObjectID {
+	path := make([]primitive.ObjectID, 0, len(i.Ancestors)+1)
+	path = append(path, i.Ancestors...)
+	return append(path, i.ID)
+}
+
+// IsDescendantOf reports whether the institution sits anywhere below id
+func (i *Institution) IsDescendantOf(id primitive.ObjectID) bool {
+	for _, ancestor := range i.Ancestors {
+		if ancestor == id {
+			return true
+		}
+	}
+	return false
+}
+
+// CanMoveUnder checks that placing the institution under parent would not
+// create a cycle
+func (i *Institution) CanMoveUnder(parent *Institution) error {
+	if parent.ID == i.ID || parent.IsDescendantOf(i.ID) {
+		return ErrInstitutionCycle
+	}
+	return nil
+}
+
+// RebasedAncestors returns the ancestors of this institution after moved, one
+// of its ancestors, has been given the new ancestors movedAncestors
+func (i *Institution) RebasedAncestors(moved primitive.ObjectID, movedAncestors []primitive.ObjectID) []primitive.ObjectID {
+	for idx, ancestor := range i.Ancestors {
+		if ancestor == moved {
+			rebased := make([]primitive.ObjectID, 0, len(movedAncestors)+len(i.Ancestors)-idx)
+			rebased = append(rebased, movedAncestors...)
+			return append(rebased, i.Ancestors[idx:]...)
+		}
+	}
+	return i.Ancestors
+}
+
 // GetFullLocation returns the full location string
 func (i *Institution) GetFullLocation() string {
 	location := i.City
@@ -0,0 +1,290 @@
+package models
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Import errors
+var (
+	ErrUnsupportedImportFormat = errors.New("unsupported import format; use csv or json")
+	ErrEmptyImport             = errors.New("import file contains no rows")
+	ErrImportNameColumn        = errors.New("import file must have a name column")
+)
+
+// ImportFormat is the file format of an institution import
+type ImportFormat string
+
+const (
+	ImportFormatCSV  ImportFormat = "csv"
+	ImportFormatJSON ImportFormat = "json"
+)
+
+// DetectImportFormat picks the import format from an explicit value or,
+// failing that, the file extension
+func DetectImportFormat(explicit, filename string) (ImportFormat, error) {
+	value := strings.ToLower(strings.TrimSpace(explicit))
+	if value == "" {
+		value = strings.TrimPrefix(strings.ToLower(filepath.Ext(filename)), ".")
+	}
+
+	switch ImportFormat(value) {
+	case ImportFormatCSV, ImportFormatJSON:
+		return ImportFormat(value), nil
+	}
+	return "", ErrUnsupportedImportFormat
+}
+
+// InstitutionImportRow is one institution in an import file. CSV headers may
+// use either the JSON names or snake_case (short_name, postal_code).
+type InstitutionImportRow struct {
+	Name       string          `json:"name"`
+	ShortName  string          `json:"shortName,omitempty"`
+	Type       InstitutionType `json:"type"`
+	Country    string          `json:"country"`
+	Province   string          `json:"province,omitempty"`
+	City       string          `json:"city"`
+	Address    string          `json:"address,omitempty"`
+	PostalCode string          `json:"postalCode,omitempty"`
+	Phone      string          `json:"phone,omitempty"`
+	Email      string          `json:"email,omitempty"`
+	Website    string          `json:"website,omitempty"`
+	Latitude   *float64        `json:"latitude,omitempty"`
+	Longitude  *float64        `json:"longitude,omitempty"`
+	// Parent is the name of the institution this one is a unit of
+	Parent string `json:"parent,omitempty"`
+}
+
+// CreateRequest converts the row into a create request for validation
+func (r *InstitutionImportRow) CreateRequest() *CreateInstitutionRequest {
+	return &CreateInstitutionRequest{
+		Name:       r.Name,
+		ShortName:  r.ShortName,
+		Type:       r.Type,
+		Country:    r.Country,
+		Province:   r.Province,
+		City:       r.City,
+		Address:    r.Address,
+		PostalCode: r.PostalCode,
+		Phone:      r.Phone,
+		Email:      r.Email,
+		Website:    r.Website,
+		Latitude:   r.Latitude,
+		Longitude:  r.Longitude,
+	}
+}
+
+// ChangedFields returns the document fields an import row would change on an
+// existing institution, keyed by BSON field name. Empty cells never clear data.
+func (r *InstitutionImportRow) ChangedFields(existing *Institution) map[string]interface{} {
+	changes := map[string]interface{}{}
+	set := func(field, value, current string) {
+		if value != "" && value != current {
+			changes[field] = value
+		}
+	}
+
+	set("short_name", r.ShortName, existing.ShortName)
+	set("type", string(r.Type), string(existing.Type))
+	set("country", r.Country, existing.Country)
+	set("province", r.Province, existing.Province)
+	set("city", r.City, existing.City)
+	set("address", r.Address, existing.Address)
+	set("postal_code", r.PostalCode, existing.PostalCode)
+	set("phone", r.Phone, existing.Phone)
+	set("email", r.Email, existing.Email)
+	set("website", r.Website, existing.Website)
+
+	if r.Latitude != nil && r.Longitude != nil {
+		if existing.Location == nil ||
+			existing.Location.Latitude() != *r.Latitude ||
+			existing.Location.Longitude() != *r.Longitude {
+			changes["location"] = NewGeoPoint(*r.Latitude, *r.Longitude)
+			changes["location_source"] = LocationSourceManual
+		}
+	}
+
+	return changes
+}
+
+// ParseInstitutionImport reads import rows from CSV or JSON data. JSON may be
+// an array of rows or an object with an "institutions" array.
+func ParseInstitutionImport(data []byte, format ImportFormat) ([]InstitutionImportRow, error) {
+	var rows []InstitutionImportRow
+	var err error
+
+	switch format {
+	case ImportFormatJSON:
+		rows, err = parseImportJSON(data)
+	case ImportFormatCSV:
+		rows, err = parseImportCSV(data)
+	default:
+		return nil, ErrUnsupportedImportFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rows) == 0 {
+		return nil, ErrEmptyImport
+	}
+	for i := range rows {
+		rows[i].trim()
+	}
+	return rows, nil
+}
+
+func parseImportJSON(data []byte) ([]InstitutionImportRow, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '{' {
+		var wrapped struct {
+			Institutions []InstitutionImportRow `json:"institutions"`
+		}
+		if err := json.Unmarshal(data, &wrapped); err != nil {
+			return nil, fmt.Errorf("invalid JSON import: %w", err)
+		}
+		return wrapped.Institutions, nil
+	}
+
+	var rows []InstitutionImportRow
+	if err := json.Unmarshal(data, &rows); err != nil {
+		return nil, fmt.Errorf("invalid JSON import: %w", err)
+	}
+	return rows, nil
+}
+
+func parseImportCSV(data []byte) ([]InstitutionImportRow, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, ErrEmptyImport
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV import: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		key := strings.ToLower(strings.TrimSpace(name))
+		key = strings.NewReplacer("_", "", " ", "", "-", "", "\ufeff", "").Replace(key)
+		columns[key] = i
+	}
+	if _, ok := columns["name"]; !ok {
+		return nil, ErrImportNameColumn
+	}
+
+	rows := []InstitutionImportRow{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV import: %w", err)
+		}
+
+		get := func(column string) string {
+			if i, ok := columns[column]; ok && i < len(record) {
+				return record[i]
+			}
+			return ""
+		}
+		getFloat := func(column string) *float64 {
+			v, err := strconv.ParseFloat(strings.TrimSpace(get(column)), 64)
+			if err != nil {
+				return nil
+			}
+			return &v
+		}
+
+		rows = append(rows, InstitutionImportRow{
+			Name:       get("name"),
+			ShortName:  get("shortname"),
+			Type:       InstitutionType(strings.ToLower(strings.TrimSpace(get("type")))),
+			Country:    get("country"),
+			Province:   get("province"),
+			City:       get("city"),
+			Address:    get("address"),
+			PostalCode: get("postalcode"),
+			Phone:      get("phone"),
+			Email:      get("email"),
+			Website:    get("website"),
+			Latitude:   getFloat("latitude"),
+			Longitude:  getFloat("longitude"),
+			Parent:     get("parent"),
+		})
+	}
+
+	return rows, nil
+}
+
+// trim removes surrounding whitespace from every text field
+func (r *InstitutionImportRow) trim() {
+	for _, f := range []*string{
+		&r.Name, &r.ShortName, &r.Country, &r.Province, &r.City, &r.Address,
+		&r.PostalCode, &r.Phone, &r.Email, &r.Website, &r.Parent,
+	} {
+		*f = strings.TrimSpace(*f)
+	}
+	r.Type = InstitutionType(strings.TrimSpace(string(r.Type)))
+}
+
+// ImportRowStatus is the outcome of importing one row
+type ImportRowStatus string
+
+const (
+	ImportRowCreated ImportRowStatus = "created"
+	ImportRowUpdated ImportRowStatus = "updated"
+	ImportRowSkipped ImportRowStatus = "skipped"
+)
+
+// InstitutionImportRowResult reports what happened to one row. Row numbers
+// are 1-based and exclude the CSV header.
+type InstitutionImportRowResult struct {
+	Row           int                 `bson:"row" json:"row"`
+	Name          string              `bson:"name" json:"name"`
+	Status        ImportRowStatus     `bson:"status" json:"status"`
+	Reason        string              `bson:"reason,omitempty" json:"reason,omitempty"`
+	InstitutionID *primitive.ObjectID `bson:"institution_id,omitempty" json:"institutionId,omitempty"`
+	Changes       []string            `bson:"changes,omitempty" json:"changes,omitempty"`
+}
+
+// InstitutionImport is the record of an import run, kept as import history
+type InstitutionImport struct {
+	ID         primitive.ObjectID           `bson:"_id,omitempty" json:"id"`
+	Source     string                       `bson:"source" json:"source"`
+	Format     ImportFormat                 `bson:"format" json:"format"`
+	DryRun     bool                         `bson:"dry_run" json:"dryRun"`
+	Created    int                          `bson:"created" json:"created"`
+	Updated    int                          `bson:"updated" json:"updated"`
+	Skipped    int                          `bson:"skipped" json:"skipped"`
+	Rows       []InstitutionImportRowResult `bson:"rows" json:"rows"`
+	ImportedBy *primitive.ObjectID          `bson:"imported_by,omitempty" json:"importedBy,omitempty"`
+	CreatedAt  time.Time                    `bson:"created_at" json:"createdAt"`
+}
+
+// Record adds a row result and updates the totals
+func (imp *InstitutionImport) Record(result InstitutionImportRowResult) {
+	switch result.Status {
+	case ImportRowCreated:
+		imp.Created++
+	case ImportRowUpdated:
+		imp.Updated++
+	default:
+		imp.Skipped++
+	}
+	imp.Rows = append(imp.Rows, result)
+}
@@ -0,0 +1,65 @@
+package models
+
+import "testing"
+
+func TestParseInstitutionImportCSV(t *testing.T) {
+	data := []byte("\ufeffName,short_name,Type,Country,Province,City,Latitude,Longitude,Parent\n" +
+		"Groote Schuur Hospital,GSH,Hospital,South Africa,Western Cape,Cape Town,-33.94,18.46,\n" +
+		" Haematology Unit ,,hospital,South Africa,Western Cape,Cape Town,,,Groote Schuur Hospital\n")
+
+	rows, err := ParseInstitutionImport(data, ImportFormatCSV)
+	if err != nil {
+		t.Fatalf("ParseInstitutionImport() error = %v", err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("got %d rows, want 2", len(rows))
+	}
+
+	gsh := rows[0]
+	if gsh.Name != "Groote Schuur Hospital" || gsh.ShortName != "GSH" || gsh.Type != InstitutionTypeHospital {
+		t.Errorf("unexpected first row: %+v", gsh)
+	}
+	if gsh.Latitude == nil || *gsh.Latitude != -33.94 {
+		t.Errorf("latitude not parsed: %v", gsh.Latitude)
+	}
+	if rows[1].Name != "Haematology Unit" || rows[1].Parent != "Groote Schuur Hospital" || rows[1].Latitude != nil {
+		t.Errorf("unexpected second row: %+v", rows[1])
+	}
+}
+
+func TestParseInstitutionImportErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   string
+		format ImportFormat
+		want   error
+	}{
+		{"csv without name column", "city,country\nCape Town,South Africa\n", ImportFormatCSV, ErrImportNameColumn},
+		{"csv header only", "name,city\n", ImportFormatCSV, ErrEmptyImport},
+		{"empty json array", "[]", ImportFormatJSON, ErrEmptyImport},
+		{"unknown format", "name\nx\n", "xlsx", ErrUnsupportedImportFormat},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseInstitutionImport([]byte(tt.data), tt.format); err != tt.want {
+				t.Errorf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestInstitutionImportRowChangedFields(t *testing.T) {
+	existing := &Institution{Name: "Tygerberg Hospital", City: "Cape Town", Phone: "021 938 4911"}
+	row := InstitutionImportRow{Name: "Tygerberg Hospital", City: "Cape Town", Phone: "+27 21 938 4911"}
+
+	changes := row.ChangedFields(existing)
+	if len(changes) != 1 || changes["phone"] != "+27 21 938 4911" {
+		t.Errorf("ChangedFields() = %v, want only phone", changes)
+	}
+
+	// Empty cells never clear existing data
+	if changes := (&InstitutionImportRow{Name: "Tygerberg Hospital"}).ChangedFields(existing); len(changes) != 0 {
+		t.Errorf("ChangedFields() = %v, want none", changes)
+	}
+}
@@ -0,0 +1,310 @@
+package models
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"unicode"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Merge validation errors
+var (
+	ErrMergeTargetRequired  = errors.New("target institution is required")
+	ErrMergeSourcesRequired = errors.New("at least one institution to merge is required")
+	ErrMergeIntoSelf        = errors.New("an institution cannot be merged into itself")
+	ErrInvalidMergeID       = errors.New("invalid institution ID in merge request")
+)
+
+// DefaultDuplicateThreshold is the minimum similarity score reported as a
+// possible duplicate
+const DefaultDuplicateThreshold = 0.8
+
+// MergeInstitutionsRequest represents a request to merge institutions into a canonical one
+type MergeInstitutionsRequest struct {
+	TargetID  string   `json:"targetId" binding:"required"`
+	SourceIDs []string `json:"sourceIds" binding:"required"`
+}
+
+// Validate validates the request and returns the parsed target and source IDs
+func (req *MergeInstitutionsRequest) Validate() (primitive.ObjectID, []primitive.ObjectID, error) {
+	if req.TargetID == "" {
+		return primitive.NilObjectID, nil, ErrMergeTargetRequired
+	}
+	if len(req.SourceIDs) == 0 {
+		return primitive.NilObjectID, nil, ErrMergeSourcesRequired
+	}
+
+	targetID, err := primitive.ObjectIDFromHex(req.TargetID)
+	if err != nil {
+		return primitive.NilObjectID, nil, ErrInvalidMergeID
+	}
+
+	seen := make(map[primitive.ObjectID]bool)
+	sourceIDs := make([]primitive.ObjectID, 0, len(req.SourceIDs))
+	for _, raw := range req.SourceIDs {
+		id, err := primitive.ObjectIDFromHex(raw)
+		if err != nil {
+			return primitive.NilObjectID, nil, ErrInvalidMergeID
+		}
+		if id == targetID {
+			return primitive.NilObjectID, nil, ErrMergeIntoSelf
+		}
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		sourceIDs = append(sourceIDs, id)
+	}
+
+	return targetID, sourceIDs, nil
+}
+
+// InstitutionMergePreview describes the impact of a merge before it is applied
+type InstitutionMergePreview struct {
+	Target              *Institution   `json:"target"`
+	Sources             []*Institution `json:"sources"`
+	AffectedUsers       int64          `json:"affectedUsers"`
+	AffectedSubmissions int64          `json:"affectedSubmissions"`
+	AffectedUnits       int64          `json:"affectedUnits"`
+	NewAliases          []string       `json:"newAliases"`
+}
+
+// InstitutionMergeResult describes a completed merge
+type InstitutionMergeResult struct {
+	Target                *Institution `json:"target"`
+	MergedIDs             []string     `json:"mergedIds"`
+	UsersReassigned       int64        `json:"usersReassigned"`
+	SubmissionsReassigned int64        `json:"submissionsReassigned"`
+	UnitsMoved            int64        `json:"unitsMoved"`
+}
+
+// InstitutionDuplicateCandidate is a pair of institutions that look like duplicates
+type InstitutionDuplicateCandidate struct {
+	Institution *Institution `json:"institution"`
+	Duplicate   *Institution `json:"duplicate"`
+	Score       float64      `json:"score"`
+	Reason      string       `json:"reason"`
+}
+
+// MergeAliases returns the names and short names of the sources that are not
+// already known on the target, for recording as aliases
+func MergeAliases(target *Institution, sources []*Institution) []string {
+	known := map[string]bool{NormalizeInstitutionName(target.Name): true}
+	if target.ShortName != "" {
+		known[NormalizeInstitutionName(target.ShortName)] = true
+	}
+	for _, alias := range target.Aliases {
+		known[NormalizeInstitutionName(alias)] = true
+	}
+
+	aliases := []string{}
+	for _, source := range sources {
+		candidates := append([]string{source.Name, source.ShortName}, source.Aliases...)
+		for _, name := range candidates {
+			key := NormalizeInstitutionName(name)
+			if key == "" || known[key] {
+				continue
+			}
+			known[key] = true
+			aliases = append(aliases, strings.TrimSpace(name))
+		}
+	}
+
+	return aliases
+}
+
+// institutionStopWords are ignored when comparing institution names
+var institutionStopWords = map[string]bool{
+	"the": true, "of": true, "and": true, "for": true, "at": true,
+}
+
+// NormalizeInstitutionName lowercases a name, strips punctuation and collapses whitespace
+func NormalizeInstitutionName(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+		case r == '&':
+			b.WriteString(" and ")
+		default:
+			b.WriteRune(' ')
+		}
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// institutionTokens returns the significant words of a name
+func institutionTokens(name string) []string {
+	tokens := []string{}
+	for _, t := range strings.Fields(NormalizeInstitutionName(name)) {
+		if !institutionStopWords[t] {
+			tokens = append(tokens, t)
+		}
+	}
+	return tokens
+}
+
+// InstitutionNameSimilarity scores how likely two names refer to the same
+// institution, from 0 (unrelated) to 1 (identical). It recognises acronyms
+// ("GSH" for "Groote Schuur Hospital") and names that are a shortened form of
+// another ("Groote Schuur" for "Groote Schuur Hospital").
+func InstitutionNameSimilarity(a, b string) (float64, string) {
+	na, nb := NormalizeInstitutionName(a), NormalizeInstitutionName(b)
+	if na == "" || nb == "" {
+		return 0, ""
+	}
+	if na == nb {
+		return 1, "same name"
+	}
+
+	ta, tb := institutionTokens(a), institutionTokens(b)
+	if isAcronymOf(ta, tb) || isAcronymOf(tb, ta) {
+		return 0.9, "acronym"
+	}
+	if containsAllTokens(ta, tb) || containsAllTokens(tb, ta) {
+		return 0.85, "shortened name"
+	}
+
+	score := levenshteinRatio(strings.Join(ta, " "), strings.Join(tb, " "))
+	if jaccard := tokenJaccard(ta, tb); jaccard > score {
+		score = jaccard
+	}
+	return score, "similar name"
+}
+
+// isAcronymOf reports whether short is a single token made of the initials of long
+func isAcronymOf(short, long []string) bool {
+	if len(short) != 1 || len(long) < 2 || len(short[0]) != len(long) {
+		return false
+	}
+	for i, t := range long {
+		if rune(short[0][i]) != []rune(t)[0] {
+			return false
+		}
+	}
+	return true
+}
+
+// containsAllTokens reports whether every token of short appears in long and
+// short is a meaningful prefix of the name (at least two words)
+func containsAllTokens(short, long []string) bool {
+	if len(short) < 2 || len(short) >= len(long) {
+		return false
+	}
+	set := make(map[string]bool, len(long))
+	for _, t := range long {
+		set[t] = true
+	}
+	for _, t := range short {
+		if !set[t] {
+			return false
+		}
+	}
+	return true
+}
+
+// tokenJaccard returns the Jaccard similarity of two token sets
+func tokenJaccard(a, b []string) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	set := make(map[string]int)
+	for _, t := range a {
+		set[t] |= 1
+	}
+	for _, t := range b {
+		set[t] |= 2
+	}
+	both := 0
+	for _, v := range set {
+		if v == 3 {
+			both++
+		}
+	}
+	return float64(both) / float64(len(set))
+}
+
+// levenshteinRatio returns 1 - editDistance/maxLen
+func levenshteinRatio(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) == 0 && len(rb) == 0 {
+		return 1
+	}
+
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return 1 - float64(prev[len(rb)])/float64(max(len(ra), len(rb)))
+}
+
+// sameCity reports whether two institutions are in the same city. Missing
+// cities are treated as a match so they do not hide candidates.
+func sameCity(a, b *Institution) bool {
+	ca, cb := NormalizeInstitutionName(a.City), NormalizeInstitutionName(b.City)
+	return ca == "" || cb == "" || ca == cb
+}
+
+// institutionNames returns every name an institution is known by
+func institutionNames(i *Institution) []string {
+	names := []string{i.Name}
+	if i.ShortName != "" {
+		names = append(names, i.ShortName)
+	}
+	return append(names, i.Aliases...)
+}
+
+// FindInstitutionDuplicates returns pairs of institutions in the same city
+// whose names score at least threshold, highest score first
+func FindInstitutionDuplicates(institutions []*Institution, threshold float64) []InstitutionDuplicateCandidate {
+	candidates := []InstitutionDuplicateCandidate{}
+
+	for i := 0; i < len(institutions); i++ {
+		for j := i + 1; j < len(institutions); j++ {
+			a, b := institutions[i], institutions[j]
+			if !sameCity(a, b) {
+				continue
+			}
+
+			best, reason := 0.0, ""
+			for _, na := range institutionNames(a) {
+				for _, nb := range institutionNames(b) {
+					if score, r := InstitutionNameSimilarity(na, nb); score > best {
+						best, reason = score, r
+					}
+				}
+			}
+
+			if best >= threshold {
+				candidates = append(candidates, InstitutionDuplicateCandidate{
+					Institution: a,
+					Duplicate:   b,
+					Score:       best,
+					Reason:      reason,
+				})
+			}
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Score > candidates[j].Score
+	})
+
+	return candidates
+}
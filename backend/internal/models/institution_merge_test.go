@@ -0,0 +1,99 @@
+package models
+
+import (
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestInstitutionNameSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		minScore float64
+		maxScore float64
+	}{
+		{"Groote Schuur Hospital", "groote schuur hospital.", 1, 1},
+		{"Groote Schuur Hospital", "GSH", 0.9, 0.9},
+		{"Groote Schuur", "Groote Schuur Hospital", 0.85, 0.85},
+		{"Chris Hani Baragwanath Hospital", "Chris Hani Baragwanth Hospital", 0.9, 1},
+		{"Groote Schuur Hospital", "Tygerberg Hospital", 0, 0.6},
+		{"University of Cape Town", "UCT", 0.9, 0.9},
+	}
+
+	for _, tt := range tests {
+		score, _ := InstitutionNameSimilarity(tt.a, tt.b)
+		if score < tt.minScore || score > tt.maxScore {
+			t.Errorf("InstitutionNameSimilarity(%q, %q) = %.2f, want between %.2f and %.2f",
+				tt.a, tt.b, score, tt.minScore, tt.maxScore)
+		}
+	}
+}
+
+func TestFindInstitutionDuplicates(t *testing.T) {
+	gsh := &Institution{ID: primitive.NewObjectID(), Name: "Groote Schuur Hospital", City: "Cape Town"}
+	short := &Institution{ID: primitive.NewObjectID(), Name: "Groote Schuur", City: "Cape Town"}
+	acronym := &Institution{ID: primitive.NewObjectID(), Name: "GSH", City: "cape town"}
+	elsewhere := &Institution{ID: primitive.NewObjectID(), Name: "Groote Schuur Hospital", City: "Durban"}
+	tyger := &Institution{ID: primitive.NewObjectID(), Name: "Tygerberg Hospital", City: "Cape Town"}
+
+	candidates := FindInstitutionDuplicates([]*Institution{gsh, short, acronym, elsewhere, tyger}, DefaultDuplicateThreshold)
+
+	// Only the full name matches both the shortened form and the acronym;
+	// the Durban institution and Tygerberg must not be reported
+	if len(candidates) != 2 {
+		t.Fatalf("expected 2 candidates, got %d: %+v", len(candidates), candidates)
+	}
+	if candidates[0].Reason != "acronym" {
+		t.Errorf("highest scoring candidate reason = %q, want acronym", candidates[0].Reason)
+	}
+	for _, c := range candidates {
+		if c.Institution == elsewhere || c.Duplicate == elsewhere {
+			t.Errorf("institution in another city reported as duplicate")
+		}
+		if c.Institution == tyger || c.Duplicate == tyger {
+			t.Errorf("unrelated institution reported as duplicate")
+		}
+	}
+}
+
+func TestMergeAliases(t *testing.T) {
+	target := &Institution{Name: "Groote Schuur Hospital", ShortName: "GSH"}
+	sources := []*Institution{
+		{Name: "Groote Schuur", ShortName: "gsh"},
+		{Name: "Groote Schuur Hosp", Aliases: []string{"Groote Schuur"}},
+	}
+
+	aliases := MergeAliases(target, sources)
+	want := []string{"Groote Schuur", "Groote Schuur Hosp"}
+	if len(aliases) != len(want) {
+		t.Fatalf("aliases = %v, want %v", aliases, want)
+	}
+	for i := range want {
+		if aliases[i] != want[i] {
+			t.Errorf("aliases[%d] = %q, want %q", i, aliases[i], want[i])
+		}
+	}
+}
+
+func TestMergeInstitutionsRequestValidate(t *testing.T) {
+	target := primitive.NewObjectID().Hex()
+	source := primitive.NewObjectID().Hex()
+
+	if _, _, err := (&MergeInstitutionsRequest{TargetID: target}).Validate(); err != ErrMergeSourcesRequired {
+		t.Errorf("err = %v, want ErrMergeSourcesRequired", err)
+	}
+	if _, _, err := (&MergeInstitutionsRequest{TargetID: target, SourceIDs: []string{target}}).Validate(); err != ErrMergeIntoSelf {
+		t.Errorf("err = %v, want ErrMergeIntoSelf", err)
+	}
+	if _, _, err := (&MergeInstitutionsRequest{TargetID: target, SourceIDs: []string{"bad"}}).Validate(); err != ErrInvalidMergeID {
+		t.Errorf("err = %v, want ErrInvalidMergeID", err)
+	}
+
+	_, ids, err := (&MergeInstitutionsRequest{TargetID: target, SourceIDs: []string{source, source}}).Validate()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(ids) != 1 {
+		t.Errorf("duplicate source IDs should be collapsed, got %d", len(ids))
+	}
+}
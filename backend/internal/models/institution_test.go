@@ -0,0 +1,160 @@
+package models
+
+import (
+	"math"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestInstitutionIsVisibleTo(t *testing.T) {
+	creator := &User{ID: primitive.NewObjectID(), Role: RoleUser}
+	other := &User{ID: primitive.NewObjectID(), Role: RoleUser}
+	admin := &User{ID: primitive.NewObjectID(), Role: RoleAdmin, AdminLevel: AdminLevelUserManager}
+
+	tests := []struct {
+		name   string
+		status InstitutionStatus
+		user   *User
+		want   bool
+	}{
+		{"legacy institution without status", "", other, true},
+		{"approved", InstitutionStatusApproved, other, true},
+		{"pending visible to creator", InstitutionStatusPending, creator, true},
+		{"pending hidden from other users", InstitutionStatusPending, other, false},
+		{"pending visible to admin", InstitutionStatusPending, admin, true},
+		{"rejected hidden from creator", InstitutionStatusRejected, creator, false},
+		{"rejected visible to admin", InstitutionStatusRejected, admin, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inst := &Institution{Status: tt.status, CreatedBy: &creator.ID}
+			if got := inst.IsVisibleTo(tt.user); got != tt.want {
+				t.Errorf("IsVisibleTo() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInstitutionCheckSelectableBy(t *testing.T) {
+	creator := primitive.NewObjectID()
+	other := primitive.NewObjectID()
+
+	tests := []struct {
+		name   string
+		status InstitutionStatus
+		user   primitive.ObjectID
+		want   error
+	}{
+		{"legacy institution without status", "", other, nil},
+		{"approved", InstitutionStatusApproved, other, nil},
+		{"pending chosen by creator", InstitutionStatusPending, creator, nil},
+		{"pending chosen by other user", InstitutionStatusPending, other, ErrInstitutionNotApproved},
+		{"pending for a new user", InstitutionStatusPending, primitive.NilObjectID, ErrInstitutionNotApproved},
+		{"rejected chosen by creator", InstitutionStatusRejected, creator, ErrInstitutionRejected},
+		{"rejected chosen by other user", InstitutionStatusRejected, other, ErrInstitutionRejected},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inst := &Institution{Status: tt.status, CreatedBy: &creator}
+			if got := inst.CheckSelectableBy(tt.user); got != tt.want {
+				t.Errorf("CheckSelectableBy() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestModerateInstitutionRequestValidate(t *testing.T) {
+	tests := []struct {
+		req  ModerateInstitutionRequest
+		want error
+	}{
+		{ModerateInstitutionRequest{Action: ModerationActionApprove}, nil},
+		{ModerateInstitutionRequest{Action: ModerationActionReject}, ErrRejectionReasonRequired},
+		{ModerateInstitutionRequest{Action: ModerationActionReject, Notes: "Duplicate of GSH"}, nil},
+		{ModerateInstitutionRequest{Action: "archive"}, ErrInvalidModerationAction},
+	}
+
+	for _, tt := range tests {
+		if err := tt.req.Validate(); err != tt.want {
+			t.Errorf("Validate(%+v) = %v, want %v", tt.req, err, tt.want)
+		}
+	}
+}
+
+func TestValidateCoordinates(t *testing.T) {
+	ptr := func(v float64) *float64 { return &v }
+	tests := []struct {
+		name     string
+		lat, lng *float64
+		want     error
+	}{
+		{"neither", nil, nil, nil},
+		{"Johannesburg", ptr(-26.2), ptr(28.04), nil},
+		{"latitude only", ptr(-26.2), nil, ErrInvalidCoordinates},
+		{"latitude out of range", ptr(-91), ptr(28.04), ErrInvalidCoordinates},
+		{"longitude out of range", ptr(-26.2), ptr(181), ErrInvalidCoordinates},
+		{"not a number", ptr(math.NaN()), ptr(28.04), ErrInvalidCoordinates},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateCoordinates(tt.lat, tt.lng); err != tt.want {
+				t.Errorf("ValidateCoordinates() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestInstitutionCanMoveUnder(t *testing.T) {
+	hospital := &Institution{ID: primitive.NewObjectID()}
+	haematology := &Institution{ID: primitive.NewObjectID(), ParentID: &hospital.ID, Ancestors: []primitive.ObjectID{hospital.ID}}
+	lab := &Institution{ID: primitive.NewObjectID(), ParentID: &haematology.ID, Ancestors: haematology.PathIDs()}
+	other := &Institution{ID: primitive.NewObjectID()}
+
+	tests := []struct {
+		name   string
+		moved  *Institution
+		parent *Institution
+		want   error
+	}{
+		{"under itself", hospital, hospital, ErrInstitutionCycle},
+		{"under its own unit", hospital, haematology, ErrInstitutionCycle},
+		{"under a unit several levels down", hospital, lab, ErrInstitutionCycle},
+		{"unit under another institution", haematology, other, nil},
+		{"institution under an unrelated unit", other, lab, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.moved.CanMoveUnder(tt.parent); err != tt.want {
+				t.Errorf("CanMoveUnder() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestInstitutionRebasedAncestors(t *testing.T) {
+	root, hospital, unit := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	newRoot := primitive.NewObjectID()
+	lab := &Institution{ID: primitive.NewObjectID(), Ancestors: []primitive.ObjectID{root, hospital, unit}}
+
+	// Moving the hospital under newRoot keeps the part of the path below it
+	got := lab.RebasedAncestors(hospital, []primitive.ObjectID{newRoot})
+	want := []primitive.ObjectID{newRoot, hospital, unit}
+	if len(got) != len(want) {
+		t.Fatalf("RebasedAncestors() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("RebasedAncestors()[%d] = %v, want %v", i, got[i], want[i])
+		}
+	}
+
+	// Making the hospital top-level drops everything above it
+	if got := lab.RebasedAncestors(hospital, nil); len(got) != 2 || got[0] != hospital {
+		t.Errorf("RebasedAncestors() to top-level = %v, want [hospital unit]", got)
+	}
+}
@@ -0,0 +1,265 @@
+package models
+
+import (
+	"errors"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrInvalidLibraryName        = errors.New("library name is required and must be between 1 and 100 characters")
+	ErrInvalidLibrarySlug        = errors.New("library slug must be 1-50 lowercase letters, digits or hyphens")
+	ErrInvalidLibraryDropboxRoot = errors.New("library dropbox root is required and must be a relative folder path")
+	ErrInvalidLibraryPermission  = errors.New("unknown permission")
+)
+
+// Built-in library slugs. Their routes are also served at /sops and
+// /working-parties for existing clients.
+const (
+	LibrarySlugSOPs           = "sops"
+	LibrarySlugWorkingParties = "working-parties"
+)
+
+var librarySlugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// Library is a document library: a Dropbox root holding one folder per
+// category. Libraries are created at runtime by super admins and served
+// under /libraries/<slug>.
+type Library struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Name        string             `bson:"name" json:"name"`
+	Slug        string             `bson:"slug" json:"slug"`
+	Description string             `bson:"description,omitempty" json:"description,omitempty"`
+	DropboxRoot string             `bson:"dropbox_root" json:"dropboxRoot"`
+
+	// StorageDriver is where the library's files are kept; empty means
+	// Dropbox. DropboxRoot is the library's folder in whichever storage.
+	StorageDriver string `bson:"storage_driver,omitempty" json:"storageDriver,omitempty"`
+
+	// DropboxConnectionID is the Dropbox connection the library uses when
+	// it keeps its files in Dropbox; nil means the default connection
+	DropboxConnectionID *primitive.ObjectID `bson:"dropbox_connection_id,omitempty" json:"dropboxConnectionId,omitempty"`
+
+	// Permissions. An empty view or download permission means any
+	// authenticated user; managing categories defaults to super admins.
+	ViewPermission     Permission `bson:"view_permission,omitempty" json:"viewPermission,omitempty"`
+	DownloadPermission Permission `bson:"download_permission,omitempty" json:"downloadPermission,omitempty"`
+	ManagePermission   Permission `bson:"manage_permission" json:"managePermission"`
+
+	DisplayOrder int                 `bson:"display_order" json:"displayOrder"`
+	IsActive     bool                `bson:"is_active" json:"isActive"`
+	Builtin      bool                `bson:"builtin" json:"builtin"`
+	CreatedAt    time.Time           `bson:"created_at" json:"createdAt"`
+	UpdatedAt    time.Time           `bson:"updated_at" json:"updatedAt"`
+	CreatedBy    *primitive.ObjectID `bson:"created_by,omitempty" json:"createdBy,omitempty"`
+}
+
+// CanView reports whether a user may list the library's categories
+func (l *Library) CanView(user *User) bool {
+	if l.CanManage(user) {
+		return true
+	}
+	if !l.IsActive {
+		return false
+	}
+	return l.ViewPermission == "" || user.HasPermission(l.ViewPermission)
+}
+
+// CanDownload reports whether a user may list and download the library's files
+func (l *Library) CanDownload(user *User) bool {
+	if !l.CanView(user) {
+		return false
+	}
+	return l.DownloadPermission == "" || user.HasPermission(l.DownloadPermission)
+}
+
+// CanSearch reports whether a user may search the library's documents.
+// Results show document text and paths, so searching needs download access.
+func (l *Library) CanSearch(user *User) bool {
+	return l.CanDownload(user)
+}
+
+// CanManage reports whether a user may create and edit the library's categories
+func (l *Library) CanManage(user *User) bool {
+	permission := l.ManagePermission
+	if permission == "" {
+		permission = PermDeleteUsers
+	}
+	return user.HasPermission(permission)
+}
+
+// UsesDropbox reports whether the library's files are kept in Dropbox.
+// Revisions, acknowledgements and search indexing need Dropbox, as they
+// rely on its revision history and change notifications; the other drivers
+// have no equivalent.
+func (l *Library) UsesDropbox() bool {
+	return StorageDriverName(l.StorageDriver) == StorageDriverDropbox
+}
+
+// CategoryDropboxPath returns the Dropbox folder for a category in this library
+func (l *Library) CategoryDropboxPath(categoryName string) string {
+	return l.DropboxRoot + "/" + url.PathEscape(categoryName)
+}
+
+// Validate validates the library fields
+func (l *Library) Validate() error {
+	if name := strings.TrimSpace(l.Name); name == "" || len(name) > 100 {
+		return ErrInvalidLibraryName
+	}
+	if err := ValidateLibrarySlug(l.Slug); err != nil {
+		return err
+	}
+	if err := ValidateDropboxRoot(l.DropboxRoot); err != nil {
+		return err
+	}
+	if err := ValidateStorageDriver(l.StorageDriver); err != nil {
+		return err
+	}
+	if len(l.Description) > 1000 {
+		return ErrInvalidDescription
+	}
+	for _, p := range []Permission{l.ViewPermission, l.DownloadPermission, l.ManagePermission} {
+		if p != "" && !p.IsValid() {
+			return ErrInvalidLibraryPermission
+		}
+	}
+	if l.DisplayOrder < 0 {
+		return ErrInvalidDisplayOrder
+	}
+	return nil
+}
+
+// ValidateLibrarySlug validates a library route slug
+func ValidateLibrarySlug(slug string) error {
+	if len(slug) > 50 || !librarySlugPattern.MatchString(slug) {
+		return ErrInvalidLibrarySlug
+	}
+	return nil
+}
+
+// ValidateDropboxRoot validates a library's Dropbox root folder
+func ValidateDropboxRoot(root string) error {
+	if root == "" || strings.HasPrefix(root, "/") || strings.HasSuffix(root, "/") {
+		return ErrInvalidLibraryDropboxRoot
+	}
+	for _, segment := range strings.Split(root, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return ErrInvalidLibraryDropboxRoot
+		}
+	}
+	return nil
+}
+
+// CreateLibraryRequest represents the request to create a library
+type CreateLibraryRequest struct {
+	Name               string     `json:"name" binding:"required"`
+	Slug               string     `json:"slug"`
+	Description        string     `json:"description"`
+	DropboxRoot        string     `json:"dropboxRoot"`
+	StorageDriver      string     `json:"storageDriver"`
+	ViewPermission     Permission `json:"viewPermission"`
+	DownloadPermission Permission `json:"downloadPermission"`
+	ManagePermission   Permission `json:"managePermission"`
+	DisplayOrder       int        `json:"displayOrder"`
+
+	// Empty means the default Dropbox connection
+	DropboxConnectionID primitive.ObjectID `json:"dropboxConnectionId"`
+}
+
+// Library builds the library described by the request. The slug defaults to
+// one generated from the name and the Dropbox root to the upper-cased slug.
+func (req *CreateLibraryRequest) Library() *Library {
+	slug := strings.TrimSpace(req.Slug)
+	if slug == "" {
+		slug = GenerateSlug(req.Name)
+	}
+	root := strings.Trim(strings.TrimSpace(req.DropboxRoot), "/")
+	if root == "" {
+		root = strings.ToUpper(strings.ReplaceAll(slug, "-", "_"))
+	}
+	manage := req.ManagePermission
+	if manage == "" {
+		manage = PermDeleteUsers
+	}
+
+	return &Library{
+		Name:                strings.TrimSpace(req.Name),
+		Slug:                slug,
+		Description:         req.Description,
+		DropboxRoot:         root,
+		StorageDriver:       req.StorageDriver,
+		DropboxConnectionID: DropboxConnectionRef(req.DropboxConnectionID),
+		ViewPermission:      req.ViewPermission,
+		DownloadPermission:  req.DownloadPermission,
+		ManagePermission:    manage,
+		DisplayOrder:        req.DisplayOrder,
+		IsActive:            true,
+	}
+}
+
+// UpdateLibraryRequest represents the request to update a library. The slug
+// and Dropbox root are fixed once created so links and folders stay valid,
+// and the storage driver and Dropbox connection can only change while the
+// library is empty.
+type UpdateLibraryRequest struct {
+	Name               *string     `json:"name"`
+	Description        *string     `json:"description"`
+	StorageDriver      *string     `json:"storageDriver"`
+	ViewPermission     *Permission `json:"viewPermission"`
+	DownloadPermission *Permission `json:"downloadPermission"`
+	ManagePermission   *Permission `json:"managePermission"`
+	DisplayOrder       *int        `json:"displayOrder"`
+	IsActive           *bool       `json:"isActive"`
+
+	// Empty means the default Dropbox connection
+	DropboxConnectionID *primitive.ObjectID `json:"dropboxConnectionId"`
+}
+
+// Apply returns the library with the requested changes applied, for
+// validation, and the changed document fields
+func (req *UpdateLibraryRequest) Apply(library Library) (*Library, map[string]interface{}) {
+	changes := map[string]interface{}{}
+	if req.Name != nil {
+		library.Name = strings.TrimSpace(*req.Name)
+		changes["name"] = library.Name
+	}
+	if req.Description != nil {
+		library.Description = *req.Description
+		changes["description"] = library.Description
+	}
+	if req.StorageDriver != nil && StorageDriverName(*req.StorageDriver) != StorageDriverName(library.StorageDriver) {
+		library.StorageDriver = *req.StorageDriver
+		changes["storage_driver"] = library.StorageDriver
+	}
+	if req.DropboxConnectionID != nil {
+		if ref := DropboxConnectionRef(*req.DropboxConnectionID); !SameDropboxConnection(ref, library.DropboxConnectionID) {
+			library.DropboxConnectionID = ref
+			changes["dropbox_connection_id"] = ref
+		}
+	}
+	if req.ViewPermission != nil {
+		library.ViewPermission = *req.ViewPermission
+		changes["view_permission"] = library.ViewPermission
+	}
+	if req.DownloadPermission != nil {
+		library.DownloadPermission = *req.DownloadPermission
+		changes["download_permission"] = library.DownloadPermission
+	}
+	if req.ManagePermission != nil && *req.ManagePermission != "" {
+		library.ManagePermission = *req.ManagePermission
+		changes["manage_permission"] = library.ManagePermission
+	}
+	if req.DisplayOrder != nil {
+		library.DisplayOrder = *req.DisplayOrder
+		changes["display_order"] = library.DisplayOrder
+	}
+	if req.IsActive != nil {
+		library.IsActive = *req.IsActive
+		changes["is_active"] = library.IsActive
+	}
+	return &library, changes
+}
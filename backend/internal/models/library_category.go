@@ -17,9 +17,11 @@ var (
 	ErrInvalidDisplayOrder = errors.New("display order must be a positive number")
 )
 
-// SOPCategory represents a category for Standard Operating Procedures
-type SOPCategory struct {
+// LibraryCategory is a category within a document library, backed by a
+// folder under the library's Dropbox root
+type LibraryCategory struct {
 	ID           primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	LibraryID    primitive.ObjectID  `bson:"library_id" json:"libraryId"`
 	Name         string              `bson:"name" json:"name"`
 	Slug         string              `bson:"slug" json:"slug"`
 	Description  string              `bson:"description,omitempty" json:"description,omitempty"`
@@ -30,18 +32,25 @@ type SOPCategory struct {
 	CreatedAt    time.Time           `bson:"created_at" json:"createdAt"`
 	UpdatedAt    time.Time           `bson:"updated_at" json:"updatedAt"`
 	CreatedBy    *primitive.ObjectID `bson:"created_by,omitempty" json:"createdBy,omitempty"`
+
+	// ImageVariants are the resized renditions of ImagePath
+	ImageVariants []ImageVariant `bson:"image_variants,omitempty" json:"imageVariants,omitempty"`
+
+	// DropboxFolderID is the Dropbox ID of the category's folder, recorded
+	// by the reconciliation job so a folder renamed in Dropbox is recognised
+	DropboxFolderID string `bson:"dropbox_folder_id,omitempty" json:"dropboxFolderId,omitempty"`
 }
 
-// CreateSOPCategoryRequest represents the request to create a new SOP category
-type CreateSOPCategoryRequest struct {
+// CreateLibraryCategoryRequest represents the request to create a new category
+type CreateLibraryCategoryRequest struct {
 	Name         string `json:"name" binding:"required"`
 	Description  string `json:"description"`
 	ImagePath    string `json:"imagePath"`
 	DisplayOrder int    `json:"displayOrder"`
 }
 
-// UpdateSOPCategoryRequest represents the request to update an SOP category
-type UpdateSOPCategoryRequest struct {
+// UpdateLibraryCategoryRequest represents the request to update a category
+type UpdateLibraryCategoryRequest struct {
 	Name         *string `json:"name"`
 	Description  *string `json:"description"`
 	ImagePath    *string `json:"imagePath"`
@@ -49,31 +58,26 @@ type UpdateSOPCategoryRequest struct {
 	IsActive     *bool   `json:"isActive"`
 }
 
-// Validate validates the SOPCategory fields
-func (c *SOPCategory) Validate() error {
-	// Validate name
+// Validate validates the LibraryCategory fields
+func (c *LibraryCategory) Validate() error {
 	if err := ValidateCategoryName(c.Name); err != nil {
 		return err
 	}
 
-	// Validate slug
 	if c.Slug == "" {
 		return ErrInvalidSlug
 	}
 
-	// Validate description
 	if len(c.Description) > 1000 {
 		return ErrInvalidDescription
 	}
 
-	// Validate image path format if provided
 	if c.ImagePath != "" {
 		if err := ValidateImagePath(c.ImagePath); err != nil {
 			return err
 		}
 	}
 
-	// Validate display order
 	if c.DisplayOrder < 0 {
 		return ErrInvalidDisplayOrder
 	}
@@ -81,6 +85,63 @@ func (c *SOPCategory) Validate() error {
 	return nil
 }
 
+// GetDropboxPath returns the category's Dropbox folder, falling back to the
+// library's naming scheme for categories stored without one
+func (c *LibraryCategory) GetDropboxPath(library *Library) string {
+	if c.DropboxPath != "" {
+		return c.DropboxPath
+	}
+	return library.DropboxRoot + "/" + c.Name
+}
+
+// Validate validates the CreateLibraryCategoryRequest
+func (req *CreateLibraryCategoryRequest) Validate() error {
+	if err := ValidateCategoryName(req.Name); err != nil {
+		return err
+	}
+
+	if len(req.Description) > 1000 {
+		return ErrInvalidDescription
+	}
+
+	if req.ImagePath != "" {
+		if err := ValidateImagePath(req.ImagePath); err != nil {
+			return err
+		}
+	}
+
+	if req.DisplayOrder < 0 {
+		return ErrInvalidDisplayOrder
+	}
+
+	return nil
+}
+
+// Validate validates the UpdateLibraryCategoryRequest
+func (req *UpdateLibraryCategoryRequest) Validate() error {
+	if req.Name != nil {
+		if err := ValidateCategoryName(*req.Name); err != nil {
+			return err
+		}
+	}
+
+	if req.Description != nil && len(*req.Description) > 1000 {
+		return ErrInvalidDescription
+	}
+
+	if req.ImagePath != nil && *req.ImagePath != "" {
+		if err := ValidateImagePath(*req.ImagePath); err != nil {
+			return err
+		}
+	}
+
+	if req.DisplayOrder != nil && *req.DisplayOrder < 0 {
+		return ErrInvalidDisplayOrder
+	}
+
+	return nil
+}
+
 // ValidateCategoryName validates the category name
 func ValidateCategoryName(name string) error {
 	name = strings.TrimSpace(name)
@@ -136,59 +197,3 @@ func GenerateSlug(name string) string {
 
 	return slug
 }
-
-// GetDropboxPath generates the Dropbox path for the category
-func (c *SOPCategory) GetDropboxPath() string {
-	if c.DropboxPath != "" {
-		return c.DropboxPath
-	}
-	return "SOPS/" + c.Name
-}
-
-// Validate validates the CreateSOPCategoryRequest
-func (req *CreateSOPCategoryRequest) Validate() error {
-	if err := ValidateCategoryName(req.Name); err != nil {
-		return err
-	}
-
-	if len(req.Description) > 1000 {
-		return ErrInvalidDescription
-	}
-
-	if req.ImagePath != "" {
-		if err := ValidateImagePath(req.ImagePath); err != nil {
-			return err
-		}
-	}
-
-	if req.DisplayOrder < 0 {
-		return ErrInvalidDisplayOrder
-	}
-
-	return nil
-}
-
-// Validate validates the UpdateSOPCategoryRequest
-func (req *UpdateSOPCategoryRequest) Validate() error {
-	if req.Name != nil {
-		if err := ValidateCategoryName(*req.Name); err != nil {
-			return err
-		}
-	}
-
-	if req.Description != nil && len(*req.Description) > 1000 {
-		return ErrInvalidDescription
-	}
-
-	if req.ImagePath != nil && *req.ImagePath != "" {
-		if err := ValidateImagePath(*req.ImagePath); err != nil {
-			return err
-		}
-	}
-
-	if req.DisplayOrder != nil && *req.DisplayOrder < 0 {
-		return ErrInvalidDisplayOrder
-	}
-
-	return nil
-}
@@ -0,0 +1,160 @@
+package models
+
+import "testing"
+
+var workingParties = &Library{
+	Name:             "Working Parties",
+	Slug:             LibrarySlugWorkingParties,
+	DropboxRoot:      "WORKING_PARTIES",
+	ManagePermission: PermDeleteUsers,
+	IsActive:         true,
+}
+
+func TestLibraryCategoryValidate(t *testing.T) {
+	category := &LibraryCategory{
+		Name:         "Clinical Guidelines",
+		Slug:         "clinical-guidelines",
+		Description:  "Working party documents",
+		DisplayOrder: 1,
+		DropboxPath:  "WORKING_PARTIES/Clinical%20Guidelines",
+	}
+
+	if err := category.Validate(); err != nil {
+		t.Fatalf("expected valid category, got error: %v", err)
+	}
+
+	if got := category.GetDropboxPath(workingParties); got != "WORKING_PARTIES/Clinical%20Guidelines" {
+		t.Fatalf("GetDropboxPath() = %q, want stored path", got)
+	}
+}
+
+func TestLibraryCategoryGetDropboxPathFallback(t *testing.T) {
+	category := &LibraryCategory{Name: "Ethics Board"}
+	if got := category.GetDropboxPath(workingParties); got != "WORKING_PARTIES/Ethics Board" {
+		t.Fatalf("GetDropboxPath() = %q, want WORKING_PARTIES/Ethics Board", got)
+	}
+	if got := workingParties.CategoryDropboxPath("Ethics Board"); got != "WORKING_PARTIES/Ethics%20Board" {
+		t.Fatalf("CategoryDropboxPath() = %q, want WORKING_PARTIES/Ethics%%20Board", got)
+	}
+}
+
+func TestCreateLibraryCategoryRequestValidate(t *testing.T) {
+	req := &CreateLibraryCategoryRequest{
+		Name:         "Research Committee",
+		Description:  "Committee documents",
+		DisplayOrder: 2,
+	}
+
+	if err := req.Validate(); err != nil {
+		t.Fatalf("expected valid request, got error: %v", err)
+	}
+}
+
+func TestUpdateLibraryCategoryRequestValidate(t *testing.T) {
+	name := "Updated Committee"
+	req := &UpdateLibraryCategoryRequest{Name: &name}
+
+	if err := req.Validate(); err != nil {
+		t.Fatalf("expected valid update request, got error: %v", err)
+	}
+}
+
+func TestLibraryPermissions(t *testing.T) {
+	member := &User{Role: RoleUser}
+	superAdmin := &User{Role: RoleAdmin, AdminLevel: AdminLevelSuperAdmin}
+
+	guidelines := &Library{
+		ViewPermission:     PermViewSOPs,
+		DownloadPermission: PermManageUsers,
+		ManagePermission:   PermDeleteUsers,
+		IsActive:           true,
+	}
+	inactive := &Library{ManagePermission: PermDeleteUsers}
+
+	tests := []struct {
+		name         string
+		library      *Library
+		user         *User
+		wantView     bool
+		wantDownload bool
+		wantManage   bool
+	}{
+		{"member views but cannot download or search", guidelines, member, true, false, false},
+		{"super admin has full access", guidelines, superAdmin, true, true, true},
+		{"inactive hidden from members", inactive, member, false, false, false},
+		{"inactive visible to managers", inactive, superAdmin, true, true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.library.CanView(tt.user); got != tt.wantView {
+				t.Errorf("CanView() = %v, want %v", got, tt.wantView)
+			}
+			if got := tt.library.CanDownload(tt.user); got != tt.wantDownload {
+				t.Errorf("CanDownload() = %v, want %v", got, tt.wantDownload)
+			}
+			if got := tt.library.CanSearch(tt.user); got != tt.wantDownload {
+				t.Errorf("CanSearch() = %v, want %v", got, tt.wantDownload)
+			}
+			if got := tt.library.CanManage(tt.user); got != tt.wantManage {
+				t.Errorf("CanManage() = %v, want %v", got, tt.wantManage)
+			}
+		})
+	}
+}
+
+func TestCreateLibraryRequestDefaults(t *testing.T) {
+	library := (&CreateLibraryRequest{Name: "Training Material"}).Library()
+	if library.Slug != "training-material" || library.DropboxRoot != "TRAINING_MATERIAL" {
+		t.Errorf("got slug %q root %q, want training-material and TRAINING_MATERIAL", library.Slug, library.DropboxRoot)
+	}
+	if library.ManagePermission != PermDeleteUsers {
+		t.Errorf("ManagePermission = %q, want %q", library.ManagePermission, PermDeleteUsers)
+	}
+	if err := library.Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+
+	for _, root := range []string{"/ROOT", "A/../B", "A//B"} {
+		if err := ValidateDropboxRoot(root); err != ErrInvalidLibraryDropboxRoot {
+			t.Errorf("ValidateDropboxRoot(%q) = %v, want ErrInvalidLibraryDropboxRoot", root, err)
+		}
+	}
+}
+
+func TestLibraryStorageDriver(t *testing.T) {
+	tests := []struct {
+		name        string
+		driver      string
+		wantErr     error
+		wantDropbox bool
+	}{
+		{name: "unset means dropbox", driver: "", wantDropbox: true},
+		{name: "dropbox", driver: StorageDriverDropbox, wantDropbox: true},
+		{name: "local", driver: StorageDriverLocal},
+		{name: "s3", driver: StorageDriverS3},
+		{name: "unknown", driver: "ftp", wantErr: ErrInvalidStorageDriver},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			library := (&CreateLibraryRequest{Name: "Training Material", StorageDriver: tt.driver}).Library()
+			if err := library.Validate(); err != tt.wantErr {
+				t.Fatalf("Validate() error = %v, want %v", err, tt.wantErr)
+			}
+			if got := library.UsesDropbox(); got != tt.wantDropbox {
+				t.Errorf("UsesDropbox() = %v, want %v", got, tt.wantDropbox)
+			}
+		})
+	}
+
+	// Switching between empty and "dropbox" is not a change
+	dropbox := StorageDriverDropbox
+	if _, changes := (&UpdateLibraryRequest{StorageDriver: &dropbox}).Apply(*workingParties); len(changes) != 0 {
+		t.Errorf("Apply() changes = %v, want none", changes)
+	}
+	s3 := StorageDriverS3
+	if updated, changes := (&UpdateLibraryRequest{StorageDriver: &s3}).Apply(*workingParties); changes["storage_driver"] != s3 || updated.UsesDropbox() {
+		t.Errorf("Apply() = %+v, %v, want the s3 driver", updated, changes)
+	}
+}
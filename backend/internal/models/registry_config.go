@@ -31,27 +31,31 @@ type SMTPConfig struct {
 
 // RegistryConfig represents the configuration for the African HOPeR Registry
 type RegistryConfig struct {
-	ID                 primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
-	VideoURL           string              `bson:"video_url" json:"videoUrl"`
-	DocumentsPath      string              `bson:"documents_path" json:"documentsPath"`
-	NotificationEmails []string            `bson:"notification_emails" json:"notificationEmails"`
-	SMTPConfig         SMTPConfig          `bson:"smtp_config" json:"smtpConfig"`
-	CreatedAt          time.Time           `bson:"created_at" json:"createdAt"`
-	UpdatedAt          time.Time           `bson:"updated_at" json:"updatedAt"`
-	UpdatedBy          *primitive.ObjectID `bson:"updated_by,omitempty" json:"updatedBy,omitempty"`
+	ID                  primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	VideoURL            string              `bson:"video_url" json:"videoUrl"`
+	DocumentsPath       string              `bson:"documents_path" json:"documentsPath"`
+	StorageDriver       string              `bson:"storage_driver,omitempty" json:"storageDriver,omitempty"`              // Where submissions and example documents are kept; empty means Dropbox
+	DropboxConnectionID *primitive.ObjectID `bson:"dropbox_connection_id,omitempty" json:"dropboxConnectionId,omitempty"` // Dropbox connection used when kept in Dropbox; nil means the default
+	NotificationEmails  []string            `bson:"notification_emails" json:"notificationEmails"`
+	SMTPConfig          SMTPConfig          `bson:"smtp_config" json:"smtpConfig"`
+	CreatedAt           time.Time           `bson:"created_at" json:"createdAt"`
+	UpdatedAt           time.Time           `bson:"updated_at" json:"updatedAt"`
+	UpdatedBy           *primitive.ObjectID `bson:"updated_by,omitempty" json:"updatedBy,omitempty"`
 }
 
 // UpdateRegistryConfigRequest represents the request to update registry configuration
 type UpdateRegistryConfigRequest struct {
-	VideoURL           *string   `json:"videoUrl,omitempty"`
-	DocumentsPath      *string   `json:"documentsPath,omitempty"`
-	NotificationEmails *[]string `json:"notificationEmails,omitempty"`
-	SMTPHost           *string   `json:"smtpHost,omitempty"`
-	SMTPPort           *int      `json:"smtpPort,omitempty"`
-	SMTPUsername       *string   `json:"smtpUsername,omitempty"`
-	SMTPPassword       *string   `json:"smtpPassword,omitempty"`
-	SMTPFromEmail      *string   `json:"smtpFromEmail,omitempty"`
-	SMTPFromName       *string   `json:"smtpFromName,omitempty"`
+	VideoURL            *string             `json:"videoUrl,omitempty"`
+	DocumentsPath       *string             `json:"documentsPath,omitempty"`
+	StorageDriver       *string             `json:"storageDriver,omitempty"`
+	DropboxConnectionID *primitive.ObjectID `json:"dropboxConnectionId,omitempty"` // Empty means the default Dropbox connection
+	NotificationEmails  *[]string           `json:"notificationEmails,omitempty"`
+	SMTPHost            *string             `json:"smtpHost,omitempty"`
+	SMTPPort            *int                `json:"smtpPort,omitempty"`
+	SMTPUsername        *string             `json:"smtpUsername,omitempty"`
+	SMTPPassword        *string             `json:"smtpPassword,omitempty"`
+	SMTPFromEmail       *string             `json:"smtpFromEmail,omitempty"`
+	SMTPFromName        *string             `json:"smtpFromName,omitempty"`
 }
 
 // UpdateSMTPConfigRequest represents the request to update only SMTP configuration
@@ -84,6 +88,10 @@ func (c *RegistryConfig) Validate() error {
 		return ErrInvalidDocumentsPath
 	}
 
+	if err := ValidateStorageDriver(c.StorageDriver); err != nil {
+		return err
+	}
+
 	if len(c.NotificationEmails) == 0 {
 		return ErrNoNotificationEmails
 	}
@@ -19,6 +19,7 @@ var (
 	ErrInvalidSelectOptions = errors.New("select and radio fields must have at least one option")
 	ErrInvalidMaxLength     = errors.New("max length must be greater than 0")
 	ErrInvalidMinValue      = errors.New("min value must be less than or equal to max value")
+	ErrSensitiveFileField   = errors.New("file fields cannot be marked sensitive; their documents are kept in storage")
 )
 
 // FormFieldType represents the type of form field
@@ -66,6 +67,10 @@ type FormField struct {
 	AllowMultiple   bool            `bson:"allow_multiple,omitempty" json:"allowMultiple,omitempty"` // For file fields
 	ValidationRules ValidationRules `bson:"validation_rules,omitempty" json:"validationRules,omitempty"`
 	DisplayOrder    int             `bson:"display_order" json:"displayOrder"`
+
+	// Sensitive marks a field whose submitted values are stored encrypted,
+	// such as patient-adjacent information
+	Sensitive bool `bson:"sensitive,omitempty" json:"sensitive,omitempty"`
 }
 
 // RegistryFormSchema represents the schema for the registry submission form
@@ -135,6 +140,18 @@ func (s *RegistryFormSchema) Validate() error {
 	return nil
 }
 
+// SensitiveFieldIDs returns the IDs of the fields whose values are stored
+// encrypted
+func (s *RegistryFormSchema) SensitiveFieldIDs() []string {
+	ids := []string{}
+	for _, field := range s.Fields {
+		if field.Sensitive {
+			ids = append(ids, field.ID)
+		}
+	}
+	return ids
+}
+
 // Validate validates a FormField
 func (f *FormField) Validate() error {
 	// Validate ID (alphanumeric and underscores only)
@@ -156,6 +173,10 @@ func (f *FormField) Validate() error {
 		return ErrInvalidFieldType
 	}
 
+	if f.Sensitive && f.Type == FieldTypeFile {
+		return ErrSensitiveFileField
+	}
+
 	// Validate options for select/radio fields
 	if (f.Type == FieldTypeSelect || f.Type == FieldTypeRadio) && len(f.Options) == 0 {
 		return ErrInvalidSelectOptions
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestFormFieldValidateSensitive(t *testing.T) {
+	tests := []struct {
+		name    string
+		field   FormField
+		wantErr error
+	}{
+		{name: "sensitive text", field: FormField{ID: "diagnosis", Label: "Diagnosis", Type: FieldTypeText, Sensitive: true}},
+		{name: "sensitive date", field: FormField{ID: "date_of_birth", Label: "Date of birth", Type: FieldTypeDate, Sensitive: true}},
+		{name: "file", field: FormField{ID: "report", Label: "Report", Type: FieldTypeFile}},
+		{name: "sensitive file", field: FormField{ID: "report", Label: "Report", Type: FieldTypeFile, Sensitive: true}, wantErr: ErrSensitiveFileField},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.field.Validate(); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Validate() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSensitiveFieldIDs(t *testing.T) {
+	schema := &RegistryFormSchema{Fields: []FormField{
+		{ID: "hospital"},
+		{ID: "diagnosis", Sensitive: true},
+		{ID: "date_of_birth", Sensitive: true},
+	}}
+	if got, want := schema.SensitiveFieldIDs(), []string{"diagnosis", "date_of_birth"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("SensitiveFieldIDs() = %v, want %v", got, want)
+	}
+	if got := (&RegistryFormSchema{}).SensitiveFieldIDs(); len(got) != 0 {
+		t.Errorf("SensitiveFieldIDs() = %v, want none", got)
+	}
+}
@@ -34,18 +34,25 @@ func (s SubmissionStatus) IsValid() bool {
 
 // RegistrySubmission represents a user's submission to the registry
 type RegistrySubmission struct {
-	ID                primitive.ObjectID     `bson:"_id,omitempty" json:"id"`
-	UserID            primitive.ObjectID     `bson:"user_id" json:"userId"`
-	FormSchemaID      primitive.ObjectID     `bson:"form_schema_id" json:"formSchemaId"`
-	FormData          map[string]interface{} `bson:"form_data" json:"formData"`
-	DocumentsPath     string                 `bson:"documents_path" json:"documentsPath"`
-	UploadedDocuments []string               `bson:"uploaded_documents" json:"uploadedDocuments"`
-	Status            SubmissionStatus       `bson:"status" json:"status"`
-	CreatedAt         time.Time              `bson:"created_at" json:"createdAt"`
-	UpdatedAt         time.Time              `bson:"updated_at" json:"updatedAt"`
-	ReviewedBy        *primitive.ObjectID    `bson:"reviewed_by,omitempty" json:"reviewedBy,omitempty"`
-	ReviewedAt        *time.Time             `bson:"reviewed_at,omitempty" json:"reviewedAt,omitempty"`
-	ReviewNotes       string                 `bson:"review_notes,omitempty" json:"reviewNotes,omitempty"`
+	ID                  primitive.ObjectID     `bson:"_id,omitempty" json:"id"`
+	UserID              primitive.ObjectID     `bson:"user_id" json:"userId"`
+	FormSchemaID        primitive.ObjectID     `bson:"form_schema_id" json:"formSchemaId"`
+	InstitutionID       *primitive.ObjectID    `bson:"institution_id,omitempty" json:"institutionId,omitempty"`
+	FormData            map[string]interface{} `bson:"form_data" json:"formData"` // Values of sensitive fields are stored encrypted
+	DocumentsPath       string                 `bson:"documents_path" json:"documentsPath"`
+	StorageDriver       string                 `bson:"storage_driver,omitempty" json:"storageDriver,omitempty"`              // Empty means Dropbox
+	DropboxConnectionID *primitive.ObjectID    `bson:"dropbox_connection_id,omitempty" json:"dropboxConnectionId,omitempty"` // Nil means the default Dropbox connection
+	UploadedDocuments   []string               `bson:"uploaded_documents" json:"uploadedDocuments"`
+	Status              SubmissionStatus       `bson:"status" json:"status"`
+	CreatedAt           time.Time              `bson:"created_at" json:"createdAt"`
+	UpdatedAt           time.Time              `bson:"updated_at" json:"updatedAt"`
+	ReviewedBy          *primitive.ObjectID    `bson:"reviewed_by,omitempty" json:"reviewedBy,omitempty"`
+	ReviewedAt          *time.Time             `bson:"reviewed_at,omitempty" json:"reviewedAt,omitempty"`
+	ReviewNotes         string                 `bson:"review_notes,omitempty" json:"reviewNotes,omitempty"`
+
+	// SensitiveFields are the form fields whose values are encrypted, set
+	// from the schema when the submission is created
+	SensitiveFields []string `bson:"sensitive_fields,omitempty" json:"-"`
 
 	// Populated fields (not stored in DB, only for API responses)
 	UserName  string `bson:"-" json:"userName,omitempty"`
@@ -57,6 +64,7 @@ type RegistrySubmission struct {
 type CreateSubmissionRequest struct {
 	FormSchemaID string                 `json:"formSchemaId" binding:"required"`
 	FormData     map[string]interface{} `json:"formData" binding:"required"`
+	UploadIDs    []string               `json:"uploadIds,omitempty"` // Completed resumable uploads to attach
 }
 
 // UpdateSubmissionStatusRequest represents the request to update submission status
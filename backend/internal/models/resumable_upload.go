@@ -0,0 +1,7 @@
+package models
+
+// CreateUploadRequest starts a resumable upload of a registry document
+type CreateUploadRequest struct {
+	FileName string `json:"fileName" binding:"required"`
+	Size     int64  `json:"size" binding:"required"` // Total size in bytes
+}
@@ -63,6 +63,17 @@ const (
 	PermDeleteUsers   Permission = "delete_users"
 )
 
+// IsValid checks if the permission is one the system defines
+func (p Permission) IsValid() bool {
+	switch p {
+	case PermViewSOPs, PermDownloadSOPs, PermAccessReferrals, PermViewRegistry,
+		PermUploadEthicsApproval, PermManageUsers, PermAssignRoles,
+		PermViewAuditLogs, PermManageSystem, PermDeleteUsers:
+		return true
+	}
+	return false
+}
+
 // GetPermissionsForRole returns all permissions for a given role and admin level
 func GetPermissionsForRole(role UserRole, adminLevel AdminLevel) []Permission {
 	// Base permissions for all users (non-admin)
@@ -0,0 +1,116 @@
+package models
+
+import (
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SearchDocument is the indexed text of one file in a library category's
+// Dropbox folder
+type SearchDocument struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	LibraryID    primitive.ObjectID `bson:"library_id" json:"libraryId"`
+	CategoryID   primitive.ObjectID `bson:"category_id" json:"categoryId"`
+	Path         string             `bson:"path" json:"path"` // Relative to the category folder
+	Name         string             `bson:"name" json:"name"`
+	Content      string             `bson:"content" json:"-"`
+	Size         int64              `bson:"size" json:"size"`
+	ModifiedTime time.Time          `bson:"modified_time" json:"modifiedTime"`
+	ExtractError string             `bson:"extract_error,omitempty" json:"extractError,omitempty"`
+	IndexedAt    time.Time          `bson:"indexed_at" json:"indexedAt"`
+
+	// Score is the text search relevance, only set on search results
+	Score float64 `bson:"score,omitempty" json:"-"`
+}
+
+// SearchResult is one document matching a search, with the library and
+// category it belongs to and where to download it
+type SearchResult struct {
+	Name         string    `json:"name"`
+	Path         string    `json:"path"`
+	LibrarySlug  string    `json:"librarySlug"`
+	LibraryName  string    `json:"libraryName"`
+	CategoryID   string    `json:"categoryId"`
+	CategoryName string    `json:"categoryName"`
+	Snippet      string    `json:"snippet"`
+	Score        float64   `json:"score"`
+	Size         int64     `json:"size"`
+	ModifiedTime time.Time `json:"modifiedTime"`
+	DownloadURL  string    `json:"downloadUrl"`
+}
+
+// SearchTerms splits a search query into lower-cased words, dropping
+// quotes and the exclusion prefix of the $text query syntax
+func SearchTerms(query string) []string {
+	var terms []string
+	for _, field := range strings.Fields(strings.ToLower(query)) {
+		if strings.HasPrefix(field, "-") {
+			continue
+		}
+		field = strings.Trim(field, `"'.,;:()`)
+		if field != "" {
+			terms = append(terms, field)
+		}
+	}
+	return terms
+}
+
+// BuildSnippet returns about width bytes of content around the first
+// occurrence of any search term, cut on word boundaries. Without a match
+// the start of the content is returned.
+func BuildSnippet(content string, terms []string, width int) string {
+	content = strings.Join(strings.Fields(content), " ")
+	if len(content) <= width {
+		return content
+	}
+
+	match := -1
+	lower := strings.ToLower(content)
+	if len(lower) == len(content) {
+		for _, term := range terms {
+			if i := strings.Index(lower, term); i >= 0 && (match < 0 || i < match) {
+				match = i
+			}
+		}
+	}
+
+	start := 0
+	if match > width/3 {
+		start = match - width/3
+	}
+	end := start + width
+	if end > len(content) {
+		end = len(content)
+		start = max(0, end-width)
+	}
+
+	// Move inwards to word boundaries, keeping runes whole
+	if start > 0 {
+		if i := strings.IndexByte(content[start:end], ' '); i >= 0 && i < width/4 {
+			start += i + 1
+		}
+		for start < end && !utf8.RuneStart(content[start]) {
+			start++
+		}
+	}
+	if end < len(content) {
+		if i := strings.LastIndexByte(content[start:end], ' '); i > width/2 {
+			end = start + i
+		}
+		for end > start && end < len(content) && !utf8.RuneStart(content[end]) {
+			end--
+		}
+	}
+
+	snippet := content[start:end]
+	if start > 0 {
+		snippet = "…" + snippet
+	}
+	if end < len(content) {
+		snippet += "…"
+	}
+	return snippet
+}
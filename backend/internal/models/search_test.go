@@ -0,0 +1,59 @@
+package models
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSearchTerms(t *testing.T) {
+	got := SearchTerms(`"DIC Protocol" -draft  heparin,`)
+	want := []string{"dic", "protocol", "heparin"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("SearchTerms() = %v, want %v", got, want)
+	}
+}
+
+func TestBuildSnippet(t *testing.T) {
+	long := strings.Repeat("filler words here ", 20) + "the DIC protocol applies " + strings.Repeat("more text after ", 20)
+
+	tests := []struct {
+		name    string
+		content string
+		terms   []string
+		check   func(string) bool
+	}{
+		{
+			name:    "short content is returned whole",
+			content: "Short   note",
+			terms:   []string{"note"},
+			check:   func(s string) bool { return s == "Short note" },
+		},
+		{
+			name:    "window contains the match",
+			content: long,
+			terms:   []string{"protocol"},
+			check: func(s string) bool {
+				return strings.Contains(s, "DIC protocol") && strings.HasPrefix(s, "…") && strings.HasSuffix(s, "…")
+			},
+		},
+		{
+			name:    "no match starts at the beginning",
+			content: long,
+			terms:   []string{"absent"},
+			check:   func(s string) bool { return strings.HasPrefix(s, "filler") && strings.HasSuffix(s, "…") },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := BuildSnippet(tt.content, tt.terms, 80)
+			if !tt.check(got) {
+				t.Errorf("BuildSnippet() = %q", got)
+			}
+			if len(got) > 80+2*len("…") {
+				t.Errorf("snippet too long: %d bytes", len(got))
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package models
+
+import "errors"
+
+var ErrInvalidStorageDriver = errors.New("storage driver must be dropbox, local or s3")
+
+// Storage drivers that a library or the registry can keep its files in. An
+// empty driver means Dropbox, so records saved before drivers existed keep
+// working.
+const (
+	StorageDriverDropbox = "dropbox"
+	StorageDriverLocal   = "local"
+	StorageDriverS3      = "s3"
+)
+
+// StorageDrivers lists the known storage drivers
+var StorageDrivers = []string{StorageDriverDropbox, StorageDriverLocal, StorageDriverS3}
+
+// ValidateStorageDriver validates a storage driver name
+func ValidateStorageDriver(driver string) error {
+	if driver == "" {
+		return nil
+	}
+	for _, known := range StorageDrivers {
+		if driver == known {
+			return nil
+		}
+	}
+	return ErrInvalidStorageDriver
+}
+
+// StorageDriverName returns the driver a stored value refers to, mapping
+// the empty value to Dropbox
+func StorageDriverName(driver string) string {
+	if driver == "" {
+		return StorageDriverDropbox
+	}
+	return driver
+}
+
+// StorageDriverStatus reports whether a storage driver can be used
+type StorageDriverStatus struct {
+	Name       string `json:"name"`
+	Configured bool   `json:"configured"`
+	Libraries  int64  `json:"libraries"`
+	Registry   bool   `json:"registry"` // Used for registry submissions
+}
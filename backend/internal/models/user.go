@@ -35,14 +35,21 @@ type User struct {
 	LockedUntil         *time.Time `bson:"locked_until,omitempty" json:"-"`
 }
 
-// UserProfile contains extended profile information for a user
+// UserProfile contains extended profile information for a user. Fields
+// tagged encrypt are personal data, stored encrypted by the user
+// repository; see package fieldcrypt.
 type UserProfile struct {
 	FirstName          string              `bson:"first_name" json:"firstName"`
 	LastName           string              `bson:"last_name" json:"lastName"`
 	InstitutionID      *primitive.ObjectID `bson:"institution_id,omitempty" json:"institutionId,omitempty"`
 	Specialty          string              `bson:"specialty,omitempty" json:"specialty,omitempty"`
-	RegistrationNumber string              `bson:"registration_number,omitempty" json:"registrationNumber,omitempty"`
-	PhoneNumber        string              `bson:"phone_number,omitempty" json:"phoneNumber,omitempty"`
+	RegistrationNumber string              `bson:"registration_number,omitempty" json:"registrationNumber,omitempty" encrypt:"index=RegistrationNumberIndex"`
+	PhoneNumber        string              `bson:"phone_number,omitempty" json:"phoneNumber,omitempty" encrypt:"index=PhoneNumberIndex"`
+
+	// Blind indexes of the encrypted fields, to find users by an exact
+	// registration or phone number
+	RegistrationNumberIndex string `bson:"registration_number_index,omitempty" json:"-"`
+	PhoneNumberIndex        string `bson:"phone_number_index,omitempty" json:"-"`
 }
 
 // CreateUserRequest represents the request to create a new user
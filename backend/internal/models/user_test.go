@@ -0,0 +1,18 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+
+	"backend/internal/fieldcrypt"
+)
+
+func TestUserEncryptedFields(t *testing.T) {
+	want := []fieldcrypt.Field{
+		{Path: "profile.registration_number", Index: "profile.registration_number_index"},
+		{Path: "profile.phone_number", Index: "profile.phone_number_index"},
+	}
+	if got := fieldcrypt.Fields(User{}); !reflect.DeepEqual(got, want) {
+		t.Errorf("Fields(User) = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,401 @@
+// Package pagination provides cursor-based (keyset) pagination and
+// multi-field sorting shared by every list endpoint.
+//
+// Clients pass ?limit=&sort=&cursor= and receive an opaque nextCursor in the
+// response. Cursors encode the sort key values of the last item returned plus
+// its _id as a tie-breaker, so following pages are fetched with an indexed
+// range query instead of an ever-growing skip. The legacy ?skip= and ?page=
+// parameters are still honoured when no cursor is supplied.
+package pagination
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	ErrInvalidCursor  = errors.New("invalid pagination cursor")
+	ErrCursorMismatch = errors.New("pagination cursor does not match the requested sort")
+	ErrInvalidSort    = errors.New("invalid sort key")
+)
+
+// Stable sort keys exposed to API clients
+const (
+	KeyCreatedAt   = "createdAt"
+	KeyName        = "name"
+	KeyLastLoginAt = "lastLoginAt"
+	KeyStatus      = "status"
+)
+
+// Direction is the sort direction of a single field
+type Direction int
+
+const (
+	Asc  Direction = 1
+	Desc Direction = -1
+)
+
+// Field describes how a public sort key maps onto a document field
+type Field struct {
+	// Path is the BSON path of the field, e.g. "created_at" or "profile.last_name"
+	Path string
+	// Nullable marks fields that may be null or missing (e.g. last_login_at)
+	Nullable bool
+}
+
+// Fields maps public sort keys to document fields for one resource
+type Fields map[string]Field
+
+// SortField is one component of a multi-field sort
+type SortField struct {
+	Key       string
+	Direction Direction
+}
+
+// Sort is an ordered list of sort fields
+type Sort []SortField
+
+// String renders the sort in the same syntax ParseSort accepts
+func (s Sort) String() string {
+	parts := make([]string, 0, len(s))
+	for _, f := range s {
+		if f.Direction == Desc {
+			parts = append(parts, "-"+f.Key)
+		} else {
+			parts = append(parts, f.Key)
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// ParseSort parses a comma separated list of sort keys. A leading "-" sorts
+// descending, e.g. "status,-createdAt".
+func ParseSort(raw string, fields Fields) (Sort, error) {
+	var sort Sort
+	seen := make(map[string]bool)
+
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		dir := Asc
+		switch part[0] {
+		case '-':
+			dir = Desc
+			part = part[1:]
+		case '+':
+			part = part[1:]
+		}
+
+		if _, ok := fields[part]; !ok || seen[part] {
+			return nil, ErrInvalidSort
+		}
+		seen[part] = true
+		sort = append(sort, SortField{Key: part, Direction: dir})
+	}
+
+	if len(sort) == 0 {
+		return nil, ErrInvalidSort
+	}
+	return sort, nil
+}
+
+// Config holds the per-endpoint pagination settings
+type Config struct {
+	DefaultLimit int64
+	MaxLimit     int64
+	DefaultSort  string
+	Fields       Fields
+}
+
+// Request is a parsed pagination request
+type Request struct {
+	Limit  int64
+	Cursor string
+	Sort   Sort
+	// Skip is the legacy offset; it is ignored when Cursor is set
+	Skip int64
+}
+
+// ParseQuery reads limit, sort, cursor and the legacy skip/page parameters
+func ParseQuery(q url.Values, cfg Config) (Request, error) {
+	req := Request{Limit: cfg.DefaultLimit}
+
+	if l, err := strconv.ParseInt(q.Get("limit"), 10, 64); err == nil && l > 0 {
+		req.Limit = l
+	}
+	if cfg.MaxLimit > 0 && req.Limit > cfg.MaxLimit {
+		req.Limit = cfg.MaxLimit
+	}
+
+	rawSort := q.Get("sort")
+	if rawSort == "" {
+		rawSort = cfg.DefaultSort
+	}
+	sort, err := ParseSort(rawSort, cfg.Fields)
+	if err != nil {
+		return Request{}, err
+	}
+	req.Sort = sort
+
+	req.Cursor = q.Get("cursor")
+	if req.Cursor != "" {
+		return req, nil
+	}
+
+	if s, err := strconv.ParseInt(q.Get("skip"), 10, 64); err == nil && s > 0 {
+		req.Skip = s
+	} else if p, err := strconv.ParseInt(q.Get("page"), 10, 64); err == nil && p > 1 {
+		req.Skip = (p - 1) * req.Limit
+	}
+
+	return req, nil
+}
+
+// cursorPayload is the decoded form of an opaque cursor
+type cursorPayload struct {
+	Sort   string             `bson:"s"`
+	Values bson.A             `bson:"v"`
+	ID     primitive.ObjectID `bson:"i"`
+}
+
+// encodeCursor serialises a cursor as URL-safe base64 BSON
+func encodeCursor(c cursorPayload) (string, error) {
+	data, err := bson.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decodeCursor parses a cursor and checks it was issued for the same sort
+func decodeCursor(raw string, sort Sort) (*cursorPayload, error) {
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var c cursorPayload
+	if err := bson.Unmarshal(data, &c); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if c.Sort != sort.String() {
+		return nil, ErrCursorMismatch
+	}
+	if c.ID.IsZero() || len(c.Values) != len(sort) {
+		return nil, ErrInvalidCursor
+	}
+
+	return &c, nil
+}
+
+// mongoSort returns the sort document including the _id tie-breaker
+func (r Request) mongoSort(fields Fields) bson.D {
+	sort := make(bson.D, 0, len(r.Sort)+1)
+	for _, f := range r.Sort {
+		sort = append(sort, bson.E{Key: fields[f.Key].Path, Value: int(f.Direction)})
+	}
+	return append(sort, bson.E{Key: "_id", Value: int(r.tieBreakDirection())})
+}
+
+// tieBreakDirection orders _id in the direction of the last sort field
+func (r Request) tieBreakDirection() Direction {
+	if len(r.Sort) == 0 {
+		return Asc
+	}
+	return r.Sort[len(r.Sort)-1].Direction
+}
+
+// after builds the condition matching a single field strictly after value
+// in the given direction. Missing and null values sort first ascending.
+func after(field Field, dir Direction, value interface{}) bson.M {
+	if value == nil {
+		if dir == Asc {
+			return bson.M{field.Path: bson.M{"$ne": nil}}
+		}
+		return nil
+	}
+
+	if dir == Asc {
+		return bson.M{field.Path: bson.M{"$gt": value}}
+	}
+	if field.Nullable {
+		return bson.M{"$or": []bson.M{
+			{field.Path: bson.M{"$lt": value}},
+			{field.Path: nil},
+		}}
+	}
+	return bson.M{field.Path: bson.M{"$lt": value}}
+}
+
+// seekFilter builds the keyset condition selecting documents after the cursor
+func (r Request) seekFilter(fields Fields, c *cursorPayload) bson.M {
+	branches := []bson.M{}
+	equal := bson.M{}
+
+	for i, f := range r.Sort {
+		field := fields[f.Key]
+		if cond := after(field, f.Direction, c.Values[i]); cond != nil {
+			branch := bson.M{}
+			for k, v := range equal {
+				branch[k] = v
+			}
+			branches = append(branches, bson.M{"$and": []bson.M{branch, cond}})
+		}
+		equal[field.Path] = c.Values[i]
+	}
+
+	idOp := "$gt"
+	if r.tieBreakDirection() == Desc {
+		idOp = "$lt"
+	}
+	last := bson.M{"_id": bson.M{idOp: c.ID}}
+	for k, v := range equal {
+		last[k] = v
+	}
+	branches = append(branches, last)
+
+	return bson.M{"$or": branches}
+}
+
+// Page is one page of results
+type Page[T any] struct {
+	Items      []T
+	NextCursor string
+	HasMore    bool
+}
+
+// Find runs a paginated query against the collection. The filter is combined
+// with the keyset condition when a cursor is present.
+func Find[T any](ctx context.Context, collection *mongo.Collection, filter bson.M, req Request, fields Fields) (*Page[T], error) {
+	if filter == nil {
+		filter = bson.M{}
+	}
+
+	query := filter
+	var c *cursorPayload
+	if req.Cursor != "" {
+		var err error
+		c, err = decodeCursor(req.Cursor, req.Sort)
+		if err != nil {
+			return nil, err
+		}
+		query = bson.M{"$and": []bson.M{filter, req.seekFilter(fields, c)}}
+	}
+
+	opts := options.Find().
+		SetSort(req.mongoSort(fields)).
+		SetLimit(req.Limit + 1)
+	if c == nil && req.Skip > 0 {
+		opts.SetSkip(req.Skip)
+	}
+
+	cur, err := collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	page := &Page[T]{Items: make([]T, 0, req.Limit)}
+	var last bson.Raw
+	for cur.Next(ctx) {
+		if int64(len(page.Items)) == req.Limit {
+			page.HasMore = true
+			break
+		}
+
+		var item T
+		if err := cur.Decode(&item); err != nil {
+			return nil, err
+		}
+		page.Items = append(page.Items, item)
+		last = append(last[:0], cur.Current...)
+	}
+	if err := cur.Err(); err != nil {
+		return nil, err
+	}
+
+	if page.HasMore && last != nil {
+		next, err := cursorFor(last, req.Sort, fields)
+		if err != nil {
+			return nil, err
+		}
+		page.NextCursor = next
+	}
+
+	return page, nil
+}
+
+// cursorFor builds the cursor pointing just after the given document
+func cursorFor(doc bson.Raw, sort Sort, fields Fields) (string, error) {
+	c := cursorPayload{Sort: sort.String(), Values: make(bson.A, 0, len(sort))}
+
+	for _, f := range sort {
+		value, err := doc.LookupErr(strings.Split(fields[f.Key].Path, ".")...)
+		if err != nil || value.Type == bson.TypeNull {
+			c.Values = append(c.Values, nil)
+			continue
+		}
+
+		var v interface{}
+		if err := value.Unmarshal(&v); err != nil {
+			return "", err
+		}
+		c.Values = append(c.Values, v)
+	}
+
+	id, ok := doc.Lookup("_id").ObjectIDOK()
+	if !ok {
+		return "", ErrInvalidCursor
+	}
+	c.ID = id
+
+	return encodeCursor(c)
+}
+
+// Info is the pagination metadata returned alongside every list response
+type Info struct {
+	Limit      int64  `json:"limit"`
+	Sort       string `json:"sort"`
+	NextCursor string `json:"nextCursor,omitempty"`
+	HasMore    bool   `json:"hasMore"`
+	Total      int64  `json:"total"`
+}
+
+// NewInfo builds the response metadata for a page
+func NewInfo[T any](req Request, page *Page[T], total int64) Info {
+	return Info{
+		Limit:      req.Limit,
+		Sort:       req.Sort.String(),
+		NextCursor: page.NextCursor,
+		HasMore:    page.HasMore,
+		Total:      total,
+	}
+}
+
+// IndexKeys returns the compound index keys backing a sort, prefixed by any
+// equality fields the endpoint commonly filters on
+func IndexKeys(fields Fields, sort string, prefix ...string) bson.D {
+	keys := bson.D{}
+	for _, p := range prefix {
+		keys = append(keys, bson.E{Key: p, Value: 1})
+	}
+
+	parsed, err := ParseSort(sort, fields)
+	if err != nil {
+		return keys
+	}
+	req := Request{Sort: parsed}
+	return append(keys, req.mongoSort(fields)...)
+}
@@ -0,0 +1,143 @@
+package pagination
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var testFields = Fields{
+	KeyCreatedAt:   {Path: "created_at"},
+	KeyName:        {Path: "name"},
+	KeyLastLoginAt: {Path: "last_login_at", Nullable: true},
+}
+
+func TestParseSort(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		want    string
+		wantErr bool
+	}{
+		{name: "single ascending", raw: "name", want: "name"},
+		{name: "multi field", raw: "-lastLoginAt, name", want: "-lastLoginAt,name"},
+		{name: "explicit plus", raw: "+createdAt", want: "createdAt"},
+		{name: "unknown key", raw: "email", wantErr: true},
+		{name: "duplicate key", raw: "name,-name", wantErr: true},
+		{name: "empty", raw: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sort, err := ParseSort(tt.raw, testFields)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error for %q", tt.raw)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := sort.String(); got != tt.want {
+				t.Errorf("sort = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseQuery(t *testing.T) {
+	cfg := Config{DefaultLimit: 20, MaxLimit: 100, DefaultSort: "-createdAt", Fields: testFields}
+
+	req, err := ParseQuery(url.Values{"limit": {"500"}, "page": {"3"}}, cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if req.Limit != 100 {
+		t.Errorf("limit = %d, want 100", req.Limit)
+	}
+	if req.Skip != 200 {
+		t.Errorf("skip = %d, want 200", req.Skip)
+	}
+	if req.Sort.String() != "-createdAt" {
+		t.Errorf("sort = %q, want default", req.Sort.String())
+	}
+
+	req, err = ParseQuery(url.Values{"cursor": {"abc"}, "skip": {"40"}}, cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if req.Skip != 0 {
+		t.Errorf("skip should be ignored when a cursor is present, got %d", req.Skip)
+	}
+
+	if _, err := ParseQuery(url.Values{"sort": {"password"}}, cfg); err != ErrInvalidSort {
+		t.Errorf("err = %v, want ErrInvalidSort", err)
+	}
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	sort, _ := ParseSort("-lastLoginAt,name", testFields)
+	id := primitive.NewObjectID()
+	login := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	doc, err := bson.Marshal(bson.M{"_id": id, "name": "Ada", "last_login_at": login})
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+
+	raw, err := cursorFor(doc, sort, testFields)
+	if err != nil {
+		t.Fatalf("cursorFor: %v", err)
+	}
+
+	c, err := decodeCursor(raw, sort)
+	if err != nil {
+		t.Fatalf("decodeCursor: %v", err)
+	}
+	if c.ID != id {
+		t.Errorf("id = %s, want %s", c.ID.Hex(), id.Hex())
+	}
+	if got, ok := c.Values[0].(primitive.DateTime); !ok || !got.Time().Equal(login) {
+		t.Errorf("lastLoginAt value = %v, want %v", c.Values[0], login)
+	}
+	if c.Values[1] != "Ada" {
+		t.Errorf("name value = %v, want Ada", c.Values[1])
+	}
+
+	other, _ := ParseSort("name", testFields)
+	if _, err := decodeCursor(raw, other); err != ErrCursorMismatch {
+		t.Errorf("err = %v, want ErrCursorMismatch", err)
+	}
+	if _, err := decodeCursor("not-a-cursor", sort); err != ErrInvalidCursor {
+		t.Errorf("err = %v, want ErrInvalidCursor", err)
+	}
+}
+
+func TestCursorMissingNullableField(t *testing.T) {
+	sort, _ := ParseSort("lastLoginAt", testFields)
+	doc, _ := bson.Marshal(bson.M{"_id": primitive.NewObjectID(), "name": "Never logged in"})
+
+	raw, err := cursorFor(doc, sort, testFields)
+	if err != nil {
+		t.Fatalf("cursorFor: %v", err)
+	}
+	c, err := decodeCursor(raw, sort)
+	if err != nil {
+		t.Fatalf("decodeCursor: %v", err)
+	}
+	if c.Values[0] != nil {
+		t.Errorf("missing field should encode as nil, got %v", c.Values[0])
+	}
+
+	// Ascending with a null cursor value continues with the non-null values
+	req := Request{Sort: sort}
+	filter := req.seekFilter(testFields, c)
+	branches, ok := filter["$or"].([]bson.M)
+	if !ok || len(branches) != 2 {
+		t.Fatalf("expected two seek branches, got %#v", filter)
+	}
+}
@@ -0,0 +1,205 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"backend/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	ErrRequirementNotFound  = errors.New("acknowledgement requirement not found")
+	ErrDuplicateRequirement = errors.New("document already requires acknowledgement")
+)
+
+// AcknowledgementRepository stores acknowledgement requirements and the
+// acknowledgements users record against them
+type AcknowledgementRepository struct {
+	requirements     *mongo.Collection
+	acknowledgements *mongo.Collection
+}
+
+// NewAcknowledgementRepository creates a new AcknowledgementRepository
+func NewAcknowledgementRepository(db *mongo.Database) *AcknowledgementRepository {
+	requirements := db.Collection("acknowledgement_requirements")
+	acknowledgements := db.Collection("document_acknowledgements")
+
+	ensureIndexes(requirements, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "category_id", Value: 1}, {Key: "path_lower", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "library_id", Value: 1}, {Key: "is_active", Value: 1}},
+		},
+	})
+	ensureIndexes(acknowledgements, []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: "requirement_id", Value: 1},
+				{Key: "user_id", Value: 1},
+				{Key: "rev", Value: 1},
+			},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "user_id", Value: 1}},
+		},
+	})
+
+	return &AcknowledgementRepository{
+		requirements:     requirements,
+		acknowledgements: acknowledgements,
+	}
+}
+
+// CreateRequirement stores a new requirement
+func (r *AcknowledgementRepository) CreateRequirement(ctx context.Context, requirement *models.AcknowledgementRequirement) error {
+	requirement.CreatedAt = time.Now()
+	requirement.UpdatedAt = requirement.CreatedAt
+
+	result, err := r.requirements.InsertOne(ctx, requirement)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrDuplicateRequirement
+		}
+		return err
+	}
+	requirement.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// FindRequirement finds a requirement by ID within a library
+func (r *AcknowledgementRepository) FindRequirement(ctx context.Context, libraryID, id primitive.ObjectID) (*models.AcknowledgementRequirement, error) {
+	var requirement models.AcknowledgementRequirement
+	err := r.requirements.FindOne(ctx, bson.M{"_id": id, "library_id": libraryID}).Decode(&requirement)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrRequirementNotFound
+		}
+		return nil, err
+	}
+	return &requirement, nil
+}
+
+// FindRequirements returns the requirements matching a filter, oldest first
+func (r *AcknowledgementRepository) FindRequirements(ctx context.Context, filter bson.M) ([]*models.AcknowledgementRequirement, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+
+	cursor, err := r.requirements.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	requirements := []*models.AcknowledgementRequirement{}
+	if err := cursor.All(ctx, &requirements); err != nil {
+		return nil, err
+	}
+	return requirements, nil
+}
+
+// UpdateRequirement updates a requirement
+func (r *AcknowledgementRepository) UpdateRequirement(ctx context.Context, id primitive.ObjectID, update bson.M) error {
+	update["updated_at"] = time.Now()
+
+	result, err := r.requirements.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": update})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrRequirementNotFound
+	}
+	return nil
+}
+
+// SetCurrentRev moves the requirements of a file to a new revision. Only
+// requirements on a different revision are changed, so replaying the same
+// revision does not reset anything.
+func (r *AcknowledgementRepository) SetCurrentRev(ctx context.Context, categoryIDs []primitive.ObjectID, pathLower, rev string, at time.Time) (int64, error) {
+	result, err := r.requirements.UpdateMany(ctx,
+		bson.M{
+			"category_id": bson.M{"$in": categoryIDs},
+			"path_lower":  pathLower,
+			"current_rev": bson.M{"$ne": rev},
+		},
+		bson.M{"$set": bson.M{"current_rev": rev, "revised_at": at, "updated_at": time.Now()}},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+// DeleteRequirement removes a requirement and its acknowledgements
+func (r *AcknowledgementRepository) DeleteRequirement(ctx context.Context, id primitive.ObjectID) error {
+	result, err := r.requirements.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrRequirementNotFound
+	}
+	_, err = r.acknowledgements.DeleteMany(ctx, bson.M{"requirement_id": id})
+	return err
+}
+
+// Acknowledge records an acknowledgement, keeping the first one if the user
+// acknowledges the same revision again
+func (r *AcknowledgementRepository) Acknowledge(ctx context.Context, ack *models.DocumentAcknowledgement) (*models.DocumentAcknowledgement, error) {
+	if ack.AcknowledgedAt.IsZero() {
+		ack.AcknowledgedAt = time.Now()
+	}
+
+	filter := bson.M{"requirement_id": ack.RequirementID, "user_id": ack.UserID, "rev": ack.Rev}
+	update := bson.M{"$setOnInsert": bson.M{
+		"acknowledged_at": ack.AcknowledgedAt,
+		"ip_address":      ack.IPAddress,
+	}}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var stored models.DocumentAcknowledgement
+	if err := r.acknowledgements.FindOneAndUpdate(ctx, filter, update, opts).Decode(&stored); err != nil {
+		return nil, err
+	}
+	return &stored, nil
+}
+
+// ListAcknowledgements returns the acknowledgements of the given
+// requirements, optionally only those of one user
+func (r *AcknowledgementRepository) ListAcknowledgements(ctx context.Context, requirementIDs []primitive.ObjectID, userID *primitive.ObjectID) ([]*models.DocumentAcknowledgement, error) {
+	filter := bson.M{"requirement_id": bson.M{"$in": requirementIDs}}
+	if userID != nil {
+		filter["user_id"] = *userID
+	}
+
+	cursor, err := r.acknowledgements.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	acks := []*models.DocumentAcknowledgement{}
+	if err := cursor.All(ctx, &acks); err != nil {
+		return nil, err
+	}
+	return acks, nil
+}
+
+// DeactivateByPath deactivates the requirements of a file that was deleted
+func (r *AcknowledgementRepository) DeactivateByPath(ctx context.Context, categoryIDs []primitive.ObjectID, pathLower string) (int64, error) {
+	result, err := r.requirements.UpdateMany(ctx,
+		bson.M{"category_id": bson.M{"$in": categoryIDs}, "path_lower": pathLower, "is_active": true},
+		bson.M{"$set": bson.M{"is_active": false, "updated_at": time.Now()}},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
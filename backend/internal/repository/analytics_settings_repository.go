@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"backend/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	ErrAnalyticsSettingsNotFound = errors.New("analytics settings not found")
+)
+
+// AnalyticsSettingsRepository stores the singleton analytics settings
+type AnalyticsSettingsRepository struct {
+	collection *mongo.Collection
+}
+
+// NewAnalyticsSettingsRepository creates a new AnalyticsSettingsRepository
+func NewAnalyticsSettingsRepository(db *mongo.Database) *AnalyticsSettingsRepository {
+	return &AnalyticsSettingsRepository{
+		collection: db.Collection("analytics_settings"),
+	}
+}
+
+// Get retrieves the analytics settings
+func (r *AnalyticsSettingsRepository) Get(ctx context.Context) (*models.AnalyticsSettings, error) {
+	var settings models.AnalyticsSettings
+	err := r.collection.FindOne(ctx, bson.M{}).Decode(&settings)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrAnalyticsSettingsNotFound
+		}
+		return nil, err
+	}
+	return &settings, nil
+}
+
+// Save creates or replaces the analytics settings
+func (r *AnalyticsSettingsRepository) Save(ctx context.Context, settings *models.AnalyticsSettings) error {
+	settings.UpdatedAt = time.Now()
+
+	_, err := r.collection.UpdateOne(ctx,
+		bson.M{},
+		bson.M{"$set": bson.M{
+			"download_retention_days": settings.DownloadRetentionDays,
+			"updated_at":              settings.UpdatedAt,
+			"updated_by":              settings.UpdatedBy,
+		}},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"backend/internal/models"
+	"backend/internal/pagination"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -17,10 +18,28 @@ type AuditRepository struct {
 	collection *mongo.Collection
 }
 
+// AuditSortFields maps the public audit log sort keys onto document fields
+var AuditSortFields = pagination.Fields{
+	pagination.KeyCreatedAt: {Path: "timestamp"},
+}
+
+// AuditDefaultSort is the sort applied when a client does not request one
+const AuditDefaultSort = "-createdAt"
+
 // NewAuditRepository creates a new AuditRepository
 func NewAuditRepository(db *mongo.Database) *AuditRepository {
+	collection := db.Collection("audit_logs")
+
+	// Compound indexes backing the paginated audit log
+	ensureIndexes(collection, []mongo.IndexModel{
+		{Keys: pagination.IndexKeys(AuditSortFields, AuditDefaultSort)},
+		{Keys: pagination.IndexKeys(AuditSortFields, AuditDefaultSort, "action")},
+		{Keys: pagination.IndexKeys(AuditSortFields, AuditDefaultSort, "user_id")},
+		{Keys: pagination.IndexKeys(AuditSortFields, AuditDefaultSort, "performed_by")},
+	})
+
 	return &AuditRepository{
-		collection: db.Collection("audit_logs"),
+		collection: collection,
 	}
 }
 
@@ -82,6 +101,11 @@ func (r *AuditRepository) List(ctx context.Context, filter bson.M, limit, skip i
 	return logs, nil
 }
 
+// ListPage retrieves one page of audit logs using cursor pagination
+func (r *AuditRepository) ListPage(ctx context.Context, filter bson.M, req pagination.Request) (*pagination.Page[*models.AuditLog], error) {
+	return pagination.Find[*models.AuditLog](ctx, r.collection, filter, req, AuditSortFields)
+}
+
 // Count counts audit logs matching a filter
 func (r *AuditRepository) Count(ctx context.Context, filter bson.M) (int64, error) {
 	return r.collection.CountDocuments(ctx, filter)
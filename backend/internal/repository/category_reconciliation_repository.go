@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"context"
+
+	"backend/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// CategoryReconciliationRepository stores the latest reconciliation report
+// of each library
+type CategoryReconciliationRepository struct {
+	collection *mongo.Collection
+}
+
+// NewCategoryReconciliationRepository creates a new CategoryReconciliationRepository
+func NewCategoryReconciliationRepository(db *mongo.Database) *CategoryReconciliationRepository {
+	collection := db.Collection("category_reconciliations")
+
+	ensureIndexes(collection, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "library_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+	})
+
+	return &CategoryReconciliationRepository{
+		collection: collection,
+	}
+}
+
+// Save creates or replaces a library's report
+func (r *CategoryReconciliationRepository) Save(ctx context.Context, report *models.CategoryReconciliation) error {
+	_, err := r.collection.ReplaceOne(
+		ctx,
+		bson.M{"library_id": report.LibraryID},
+		report,
+		options.Replace().SetUpsert(true),
+	)
+	return err
+}
+
+// List returns every library's report, ordered by library name
+func (r *CategoryReconciliationRepository) List(ctx context.Context) ([]*models.CategoryReconciliation, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "library_name", Value: 1}})
+	cursor, err := r.collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	reports := []*models.CategoryReconciliation{}
+	if err := cursor.All(ctx, &reports); err != nil {
+		return nil, err
+	}
+	return reports, nil
+}
+
+// DeleteOthers removes the reports of libraries not in keep, such as
+// deleted libraries
+func (r *CategoryReconciliationRepository) DeleteOthers(ctx context.Context, keep []primitive.ObjectID) error {
+	_, err := r.collection.DeleteMany(ctx, bson.M{"library_id": bson.M{"$nin": keep}})
+	return err
+}
@@ -0,0 +1,283 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"backend/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// DocumentDownloadRepository stores download events of library documents
+// and aggregates them for analytics
+type DocumentDownloadRepository struct {
+	collection *mongo.Collection
+}
+
+// NewDocumentDownloadRepository creates a new DocumentDownloadRepository
+func NewDocumentDownloadRepository(db *mongo.Database) *DocumentDownloadRepository {
+	collection := db.Collection("document_downloads")
+
+	ensureIndexes(collection, []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "downloaded_at", Value: 1}},
+		},
+		{
+			Keys: bson.D{{Key: "library_id", Value: 1}, {Key: "downloaded_at", Value: 1}},
+		},
+		{
+			Keys: bson.D{{Key: "category_id", Value: 1}, {Key: "path_lower", Value: 1}},
+		},
+	})
+
+	return &DocumentDownloadRepository{
+		collection: collection,
+	}
+}
+
+// DocumentDownloadFilter selects download events
+type DocumentDownloadFilter struct {
+	LibraryID *primitive.ObjectID
+	From      time.Time // Inclusive; zero for no lower bound
+	To        time.Time // Exclusive; zero for no upper bound
+}
+
+// toBSON converts the filter into a MongoDB query
+func (f DocumentDownloadFilter) toBSON() bson.M {
+	mongoFilter := bson.M{}
+
+	if f.LibraryID != nil {
+		mongoFilter["library_id"] = *f.LibraryID
+	}
+
+	downloadedAt := bson.M{}
+	if !f.From.IsZero() {
+		downloadedAt["$gte"] = f.From
+	}
+	if !f.To.IsZero() {
+		downloadedAt["$lt"] = f.To
+	}
+	if len(downloadedAt) > 0 {
+		mongoFilter["downloaded_at"] = downloadedAt
+	}
+
+	return mongoFilter
+}
+
+// CreateMany records download events
+func (r *DocumentDownloadRepository) CreateMany(ctx context.Context, downloads []*models.DocumentDownload) error {
+	if len(downloads) == 0 {
+		return nil
+	}
+	documents := make([]interface{}, len(downloads))
+	for i, download := range downloads {
+		documents[i] = download
+	}
+	_, err := r.collection.InsertMany(ctx, documents, options.InsertMany().SetOrdered(false))
+	return err
+}
+
+// DeleteBefore removes download events older than cutoff
+func (r *DocumentDownloadRepository) DeleteBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	result, err := r.collection.DeleteMany(ctx, bson.M{"downloaded_at": bson.M{"$lt": cutoff}})
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
+
+// TopDocuments returns the most downloaded documents, most downloads first
+func (r *DocumentDownloadRepository) TopDocuments(ctx context.Context, filter DocumentDownloadFilter, limit int) ([]models.DocumentDownloadCount, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: filter.toBSON()}},
+		{{Key: "$sort", Value: bson.D{{Key: "downloaded_at", Value: 1}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":                bson.M{"category_id": "$category_id", "path_lower": "$path_lower"},
+			"library_slug":       bson.M{"$last": "$library_slug"},
+			"path":               bson.M{"$last": "$path"},
+			"name":               bson.M{"$last": "$name"},
+			"downloads":          bson.M{"$sum": 1},
+			"users":              bson.M{"$addToSet": "$user_id"},
+			"last_downloaded_at": bson.M{"$max": "$downloaded_at"},
+		}}},
+		{{Key: "$addFields", Value: bson.M{"users": bson.M{"$size": "$users"}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "downloads", Value: -1}, {Key: "last_downloaded_at", Value: -1}}}},
+		{{Key: "$limit", Value: limit}},
+	}
+
+	var rows []struct {
+		ID struct {
+			CategoryID primitive.ObjectID `bson:"category_id"`
+		} `bson:"_id"`
+		LibrarySlug      string    `bson:"library_slug"`
+		Path             string    `bson:"path"`
+		Name             string    `bson:"name"`
+		Downloads        int64     `bson:"downloads"`
+		Users            int64     `bson:"users"`
+		LastDownloadedAt time.Time `bson:"last_downloaded_at"`
+	}
+	if err := r.aggregate(ctx, pipeline, &rows); err != nil {
+		return nil, err
+	}
+
+	counts := make([]models.DocumentDownloadCount, 0, len(rows))
+	for _, row := range rows {
+		counts = append(counts, models.DocumentDownloadCount{
+			LibrarySlug:      row.LibrarySlug,
+			CategoryID:       row.ID.CategoryID,
+			Path:             row.Path,
+			Name:             row.Name,
+			Downloads:        row.Downloads,
+			Users:            row.Users,
+			LastDownloadedAt: row.LastDownloadedAt,
+		})
+	}
+	return counts, nil
+}
+
+// CountByPeriod counts downloads per day, week (from Monday) or month in
+// UTC. Periods without downloads are left out.
+func (r *DocumentDownloadRepository) CountByPeriod(ctx context.Context, filter DocumentDownloadFilter, interval models.AnalyticsInterval) ([]models.DownloadPeriodCount, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: filter.toBSON()}},
+		{{Key: "$group", Value: bson.M{
+			"_id": bson.M{"$dateTrunc": bson.M{
+				"date":        "$downloaded_at",
+				"unit":        string(interval),
+				"timezone":    "UTC",
+				"startOfWeek": "monday",
+			}},
+			"downloads": bson.M{"$sum": 1},
+			"users":     bson.M{"$addToSet": "$user_id"},
+		}}},
+		{{Key: "$addFields", Value: bson.M{"users": bson.M{"$size": "$users"}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "_id", Value: 1}}}},
+	}
+
+	var rows []struct {
+		Period    time.Time `bson:"_id"`
+		Downloads int64     `bson:"downloads"`
+		Users     int64     `bson:"users"`
+	}
+	if err := r.aggregate(ctx, pipeline, &rows); err != nil {
+		return nil, err
+	}
+
+	counts := make([]models.DownloadPeriodCount, 0, len(rows))
+	for _, row := range rows {
+		counts = append(counts, models.DownloadPeriodCount{Period: row.Period, Downloads: row.Downloads, Users: row.Users})
+	}
+	return counts, nil
+}
+
+// CountByInstitution counts downloads per institution of the downloading
+// user, most downloads first. Users without an institution are grouped
+// under an empty ID.
+func (r *DocumentDownloadRepository) CountByInstitution(ctx context.Context, filter DocumentDownloadFilter) ([]models.DownloadGroupCount, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: filter.toBSON()}},
+		{{Key: "$group", Value: bson.M{
+			"_id":       bson.M{"$ifNull": bson.A{"$institution_id", nil}},
+			"downloads": bson.M{"$sum": 1},
+			"users":     bson.M{"$addToSet": "$user_id"},
+		}}},
+		{{Key: "$addFields", Value: bson.M{"users": bson.M{"$size": "$users"}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "downloads", Value: -1}}}},
+	}
+
+	var rows []struct {
+		ID        *primitive.ObjectID `bson:"_id"`
+		Downloads int64               `bson:"downloads"`
+		Users     int64               `bson:"users"`
+	}
+	if err := r.aggregate(ctx, pipeline, &rows); err != nil {
+		return nil, err
+	}
+
+	counts := make([]models.DownloadGroupCount, 0, len(rows))
+	for _, row := range rows {
+		count := models.DownloadGroupCount{Downloads: row.Downloads, Users: row.Users}
+		if row.ID != nil {
+			count.ID = row.ID.Hex()
+		}
+		counts = append(counts, count)
+	}
+	return counts, nil
+}
+
+// CountBySpecialty counts downloads per specialty of the downloading user,
+// ignoring case, most downloads first. Users without a specialty are
+// grouped under an empty ID.
+func (r *DocumentDownloadRepository) CountBySpecialty(ctx context.Context, filter DocumentDownloadFilter) ([]models.DownloadGroupCount, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: filter.toBSON()}},
+		{{Key: "$group", Value: bson.M{
+			"_id":       bson.M{"$toLower": bson.M{"$ifNull": bson.A{"$specialty", ""}}},
+			"name":      bson.M{"$first": "$specialty"},
+			"downloads": bson.M{"$sum": 1},
+			"users":     bson.M{"$addToSet": "$user_id"},
+		}}},
+		{{Key: "$addFields", Value: bson.M{"users": bson.M{"$size": "$users"}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "downloads", Value: -1}}}},
+	}
+
+	var rows []struct {
+		ID        string `bson:"_id"`
+		Name      string `bson:"name"`
+		Downloads int64  `bson:"downloads"`
+		Users     int64  `bson:"users"`
+	}
+	if err := r.aggregate(ctx, pipeline, &rows); err != nil {
+		return nil, err
+	}
+
+	counts := make([]models.DownloadGroupCount, 0, len(rows))
+	for _, row := range rows {
+		counts = append(counts, models.DownloadGroupCount{ID: row.ID, Name: row.Name, Downloads: row.Downloads, Users: row.Users})
+	}
+	return counts, nil
+}
+
+// DownloadedPaths returns the lower-cased paths of the documents downloaded
+// in each category
+func (r *DocumentDownloadRepository) DownloadedPaths(ctx context.Context, filter DocumentDownloadFilter) (map[primitive.ObjectID]map[string]bool, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: filter.toBSON()}},
+		{{Key: "$group", Value: bson.M{
+			"_id": bson.M{"category_id": "$category_id", "path_lower": "$path_lower"},
+		}}},
+	}
+
+	var rows []struct {
+		ID struct {
+			CategoryID primitive.ObjectID `bson:"category_id"`
+			PathLower  string             `bson:"path_lower"`
+		} `bson:"_id"`
+	}
+	if err := r.aggregate(ctx, pipeline, &rows); err != nil {
+		return nil, err
+	}
+
+	paths := map[primitive.ObjectID]map[string]bool{}
+	for _, row := range rows {
+		if paths[row.ID.CategoryID] == nil {
+			paths[row.ID.CategoryID] = map[string]bool{}
+		}
+		paths[row.ID.CategoryID][row.ID.PathLower] = true
+	}
+	return paths, nil
+}
+
+// aggregate runs a pipeline and decodes every result into out
+func (r *DocumentDownloadRepository) aggregate(ctx context.Context, pipeline mongo.Pipeline, out interface{}) error {
+	cursor, err := r.collection.Aggregate(ctx, pipeline, options.Aggregate().SetAllowDiskUse(true))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+	return cursor.All(ctx, out)
+}
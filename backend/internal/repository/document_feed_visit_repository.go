@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"backend/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// DocumentFeedVisitRepository records when each user last visited the
+// recently updated feed
+type DocumentFeedVisitRepository struct {
+	collection *mongo.Collection
+}
+
+// NewDocumentFeedVisitRepository creates a new DocumentFeedVisitRepository
+func NewDocumentFeedVisitRepository(db *mongo.Database) *DocumentFeedVisitRepository {
+	collection := db.Collection("document_feed_visits")
+
+	ensureIndexes(collection, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "user_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+	})
+
+	return &DocumentFeedVisitRepository{
+		collection: collection,
+	}
+}
+
+// LastVisit returns when the user last visited the feed, or nil if never
+func (r *DocumentFeedVisitRepository) LastVisit(ctx context.Context, userID primitive.ObjectID) (*time.Time, error) {
+	var visit models.DocumentFeedVisit
+	err := r.collection.FindOne(ctx, bson.M{"user_id": userID}).Decode(&visit)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &visit.LastVisitAt, nil
+}
+
+// SetLastVisit records a visit to the feed
+func (r *DocumentFeedVisitRepository) SetLastVisit(ctx context.Context, userID primitive.ObjectID, at time.Time) error {
+	_, err := r.collection.UpdateOne(ctx,
+		bson.M{"user_id": userID},
+		bson.M{"$set": bson.M{"last_visit_at": at}},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
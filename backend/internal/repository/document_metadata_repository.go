@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"backend/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var ErrDocumentMetadataNotFound = errors.New("document metadata not found")
+
+// DocumentMetadataRepository stores the ownership and review metadata of
+// library documents
+type DocumentMetadataRepository struct {
+	collection *mongo.Collection
+}
+
+// NewDocumentMetadataRepository creates a new DocumentMetadataRepository
+func NewDocumentMetadataRepository(db *mongo.Database) *DocumentMetadataRepository {
+	collection := db.Collection("document_metadata")
+
+	ensureIndexes(collection, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "category_id", Value: 1}, {Key: "path_lower", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "next_review_at", Value: 1}},
+		},
+	})
+
+	return &DocumentMetadataRepository{
+		collection: collection,
+	}
+}
+
+// FindByFile finds the metadata of a file in a category
+func (r *DocumentMetadataRepository) FindByFile(ctx context.Context, categoryID primitive.ObjectID, pathLower string) (*models.DocumentMetadata, error) {
+	var metadata models.DocumentMetadata
+	err := r.collection.FindOne(ctx, bson.M{"category_id": categoryID, "path_lower": pathLower}).Decode(&metadata)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrDocumentMetadataNotFound
+		}
+		return nil, err
+	}
+	return &metadata, nil
+}
+
+// FindByCategory returns the metadata of every file in a category
+func (r *DocumentMetadataRepository) FindByCategory(ctx context.Context, categoryID primitive.ObjectID) ([]*models.DocumentMetadata, error) {
+	return r.find(ctx, bson.M{"category_id": categoryID}, nil)
+}
+
+// FindDueBefore returns the metadata of documents that are not retired and
+// whose next review is before the given time, soonest first
+func (r *DocumentMetadataRepository) FindDueBefore(ctx context.Context, before time.Time) ([]*models.DocumentMetadata, error) {
+	filter := bson.M{
+		"next_review_at": bson.M{"$lt": before},
+		"status":         bson.M{"$ne": models.DocumentStatusRetired},
+	}
+	return r.find(ctx, filter, options.Find().SetSort(bson.D{{Key: "next_review_at", Value: 1}}))
+}
+
+func (r *DocumentMetadataRepository) find(ctx context.Context, filter bson.M, opts *options.FindOptions) ([]*models.DocumentMetadata, error) {
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	metadata := []*models.DocumentMetadata{}
+	if err := cursor.All(ctx, &metadata); err != nil {
+		return nil, err
+	}
+	return metadata, nil
+}
+
+// Save creates or replaces the metadata of a file
+func (r *DocumentMetadataRepository) Save(ctx context.Context, metadata *models.DocumentMetadata) error {
+	now := time.Now()
+	if metadata.CreatedAt.IsZero() {
+		metadata.CreatedAt = now
+	}
+	metadata.UpdatedAt = now
+
+	filter := bson.M{"category_id": metadata.CategoryID, "path_lower": metadata.PathLower}
+	opts := options.FindOneAndReplace().SetUpsert(true).SetReturnDocument(options.After)
+
+	replacement := *metadata
+	replacement.ID = primitive.NilObjectID
+
+	var stored models.DocumentMetadata
+	if err := r.collection.FindOneAndReplace(ctx, filter, &replacement, opts).Decode(&stored); err != nil {
+		return err
+	}
+	metadata.ID = stored.ID
+	return nil
+}
+
+// MarkReminderSent records that the owner was reminded of a review date
+func (r *DocumentMetadataRepository) MarkReminderSent(ctx context.Context, id primitive.ObjectID, dueAt time.Time) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"reminder_sent_for": dueAt}})
+	return err
+}
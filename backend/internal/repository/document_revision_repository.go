@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"backend/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// DocumentRevisionRepository stores the revision history of library documents
+type DocumentRevisionRepository struct {
+	collection *mongo.Collection
+}
+
+// NewDocumentRevisionRepository creates a new DocumentRevisionRepository
+func NewDocumentRevisionRepository(db *mongo.Database) *DocumentRevisionRepository {
+	collection := db.Collection("document_revisions")
+
+	ensureIndexes(collection, []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: "category_id", Value: 1},
+				{Key: "path_lower", Value: 1},
+				{Key: "rev", Value: 1},
+				{Key: "change_type", Value: 1},
+			},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "category_id", Value: 1}, {Key: "recorded_at", Value: -1}},
+		},
+	})
+
+	return &DocumentRevisionRepository{
+		collection: collection,
+	}
+}
+
+// Record stores a revision unless the same change of the same revision is
+// already recorded, so replayed changes are ignored. It reports whether the
+// revision was new.
+func (r *DocumentRevisionRepository) Record(ctx context.Context, revision *models.DocumentRevision) (bool, error) {
+	if revision.RecordedAt.IsZero() {
+		revision.RecordedAt = time.Now()
+	}
+
+	filter := bson.M{
+		"category_id": revision.CategoryID,
+		"path_lower":  revision.PathLower,
+		"rev":         revision.Rev,
+		"change_type": revision.ChangeType,
+	}
+	update := bson.M{
+		"$setOnInsert": bson.M{
+			"library_id":    revision.LibraryID,
+			"path":          revision.Path,
+			"name":          revision.Name,
+			"content_hash":  revision.ContentHash,
+			"size":          revision.Size,
+			"modified_time": revision.ModifiedTime,
+			"recorded_at":   revision.RecordedAt,
+		},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return result.UpsertedCount > 0, nil
+}
+
+// ListByFile returns the revisions of one file, newest first
+func (r *DocumentRevisionRepository) ListByFile(ctx context.Context, categoryID primitive.ObjectID, pathLower string) ([]*models.DocumentRevision, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "modified_time", Value: -1}, {Key: "recorded_at", Value: -1}})
+
+	cursor, err := r.collection.Find(ctx, bson.M{"category_id": categoryID, "path_lower": pathLower}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	revisions := []*models.DocumentRevision{}
+	if err := cursor.All(ctx, &revisions); err != nil {
+		return nil, err
+	}
+	return revisions, nil
+}
+
+// HasRevision reports whether a revision of the file is recorded
+func (r *DocumentRevisionRepository) HasRevision(ctx context.Context, categoryID primitive.ObjectID, pathLower, rev string) (bool, error) {
+	count, err := r.collection.CountDocuments(ctx, bson.M{
+		"category_id": categoryID,
+		"path_lower":  pathLower,
+		"rev":         rev,
+	}, options.Count().SetLimit(1))
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// recentFilter matches files added or updated in the given categories
+func recentFilter(categoryIDs []primitive.ObjectID) bson.M {
+	return bson.M{
+		"category_id": bson.M{"$in": categoryIDs},
+		"change_type": bson.M{"$in": []string{models.RevisionAdded, models.RevisionUpdated}},
+	}
+}
+
+// Recent returns the latest additions and updates in the given categories
+func (r *DocumentRevisionRepository) Recent(ctx context.Context, categoryIDs []primitive.ObjectID, limit int) ([]*models.DocumentRevision, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "recorded_at", Value: -1}}).
+		SetLimit(int64(limit))
+
+	cursor, err := r.collection.Find(ctx, recentFilter(categoryIDs), opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	revisions := []*models.DocumentRevision{}
+	if err := cursor.All(ctx, &revisions); err != nil {
+		return nil, err
+	}
+	return revisions, nil
+}
+
+// CountRecentSince counts the additions and updates in the given categories
+// recorded after since
+func (r *DocumentRevisionRepository) CountRecentSince(ctx context.Context, categoryIDs []primitive.ObjectID, since time.Time) (int64, error) {
+	filter := recentFilter(categoryIDs)
+	filter["recorded_at"] = bson.M{"$gt": since}
+	return r.collection.CountDocuments(ctx, filter)
+}
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"backend/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	ErrDropboxAuthRequestNotFound = errors.New("dropbox authorization request not found")
+)
+
+// DropboxAuthRequestRepository stores Dropbox authorizations that have
+// been started but not completed
+type DropboxAuthRequestRepository struct {
+	collection *mongo.Collection
+}
+
+// NewDropboxAuthRequestRepository creates a new DropboxAuthRequestRepository
+func NewDropboxAuthRequestRepository(db *mongo.Database) *DropboxAuthRequestRepository {
+	collection := db.Collection("dropbox_auth_requests")
+
+	ensureIndexes(collection, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "state_hash", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	})
+
+	return &DropboxAuthRequestRepository{
+		collection: collection,
+	}
+}
+
+// Create stores a new authorization request
+func (r *DropboxAuthRequestRepository) Create(ctx context.Context, request *models.DropboxAuthRequest) error {
+	request.CreatedAt = time.Now()
+	_, err := r.collection.InsertOne(ctx, request)
+	return err
+}
+
+// Consume removes and returns the unexpired request with a state hash, so
+// each state can only be used once
+func (r *DropboxAuthRequestRepository) Consume(ctx context.Context, stateHash string) (*models.DropboxAuthRequest, error) {
+	var request models.DropboxAuthRequest
+	err := r.collection.FindOneAndDelete(ctx, bson.M{
+		"state_hash": stateHash,
+		"expires_at": bson.M{"$gt": time.Now()},
+	}).Decode(&request)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrDropboxAuthRequestNotFound
+		}
+		return nil, err
+	}
+	return &request, nil
+}
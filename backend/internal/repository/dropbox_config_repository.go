@@ -14,25 +14,57 @@ import (
 )
 
 var (
-	ErrDropboxConfigNotFound = errors.New("dropbox configuration not found")
+	ErrDropboxConfigNotFound      = errors.New("dropbox configuration not found")
+	ErrDuplicateDropboxConnection = errors.New("a dropbox connection with this name already exists")
 )
 
-// DropboxConfigRepository handles database operations for Dropbox configuration
+// DropboxConfigRepository handles database operations for Dropbox
+// connections, one document per connection
 type DropboxConfigRepository struct {
 	collection *mongo.Collection
 }
 
 // NewDropboxConfigRepository creates a new DropboxConfigRepository
 func NewDropboxConfigRepository(db *mongo.Database) *DropboxConfigRepository {
+	collection := db.Collection("dropbox_config")
+
+	// Names are unique ignoring case
+	ensureIndexes(collection, []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "name", Value: 1}},
+			Options: options.Index().SetUnique(true).
+				SetCollation(&options.Collation{Locale: "en", Strength: 2}),
+		},
+	})
+
 	return &DropboxConfigRepository{
-		collection: db.Collection("dropbox_config"),
+		collection: collection,
 	}
 }
 
-// GetConfig retrieves the singleton Dropbox configuration
-func (r *DropboxConfigRepository) GetConfig(ctx context.Context) (*models.DropboxConfig, error) {
+// NameLegacyConfig makes the configuration saved before connections were
+// named the default connection
+func (r *DropboxConfigRepository) NameLegacyConfig(ctx context.Context) error {
+	_, err := r.collection.UpdateMany(ctx,
+		bson.M{"is_default": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"is_default": true, "name": models.DefaultDropboxConnectionName}},
+	)
+	return err
+}
+
+// GetDefault retrieves the default connection
+func (r *DropboxConfigRepository) GetDefault(ctx context.Context) (*models.DropboxConfig, error) {
+	return r.findOne(ctx, bson.M{"is_default": true})
+}
+
+// FindByID retrieves a connection by ID
+func (r *DropboxConfigRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.DropboxConfig, error) {
+	return r.findOne(ctx, bson.M{"_id": id})
+}
+
+func (r *DropboxConfigRepository) findOne(ctx context.Context, filter bson.M) (*models.DropboxConfig, error) {
 	var config models.DropboxConfig
-	err := r.collection.FindOne(ctx, bson.M{}).Decode(&config)
+	err := r.collection.FindOne(ctx, filter).Decode(&config)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			return nil, ErrDropboxConfigNotFound
@@ -42,18 +74,25 @@ func (r *DropboxConfigRepository) GetConfig(ctx context.Context) (*models.Dropbo
 	return &config, nil
 }
 
-// CreateConfig creates the initial Dropbox configuration
-// This should only be called once during initial setup
-func (r *DropboxConfigRepository) CreateConfig(ctx context.Context, config *models.DropboxConfig) error {
-	// Check if config already exists
-	existing, err := r.GetConfig(ctx)
-	if err != nil && err != ErrDropboxConfigNotFound {
-		return err
+// List returns every connection, the default first and the others in the
+// order they were created
+func (r *DropboxConfigRepository) List(ctx context.Context) ([]*models.DropboxConfig, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "is_default", Value: -1}, {Key: "_id", Value: 1}})
+	cursor, err := r.collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
 	}
-	if existing != nil {
-		return errors.New("dropbox configuration already exists")
+	defer cursor.Close(ctx)
+
+	configs := []*models.DropboxConfig{}
+	if err := cursor.All(ctx, &configs); err != nil {
+		return nil, err
 	}
+	return configs, nil
+}
 
+// CreateConfig creates a connection
+func (r *DropboxConfigRepository) CreateConfig(ctx context.Context, config *models.DropboxConfig) error {
 	now := time.Now()
 	config.CreatedAt = now
 	config.UpdatedAt = now
@@ -61,6 +100,9 @@ func (r *DropboxConfigRepository) CreateConfig(ctx context.Context, config *mode
 
 	result, err := r.collection.InsertOne(ctx, config)
 	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrDuplicateDropboxConnection
+		}
 		return err
 	}
 
@@ -68,8 +110,9 @@ func (r *DropboxConfigRepository) CreateConfig(ctx context.Context, config *mode
 	return nil
 }
 
-// UpdateTokens updates the access token, refresh token (if provided), and expiry
-func (r *DropboxConfigRepository) UpdateTokens(ctx context.Context, accessToken string, refreshToken string, expiresIn int) error {
+// UpdateTokens updates a connection's access token, refresh token (if
+// provided), and expiry
+func (r *DropboxConfigRepository) UpdateTokens(ctx context.Context, id primitive.ObjectID, accessToken string, refreshToken string, expiresIn int) error {
 	update := bson.M{
 		"access_token": accessToken,
 		"token_expiry": time.Now().Add(time.Duration(expiresIn) * time.Second),
@@ -83,7 +126,7 @@ func (r *DropboxConfigRepository) UpdateTokens(ctx context.Context, accessToken
 
 	result, err := r.collection.UpdateOne(
 		ctx,
-		bson.M{},
+		bson.M{"_id": id},
 		bson.M{"$set": update},
 	)
 	if err != nil {
@@ -105,6 +148,9 @@ func (r *DropboxConfigRepository) UpdateConfig(ctx context.Context, config *mode
 		config,
 	)
 	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrDuplicateDropboxConnection
+		}
 		return err
 	}
 	if result.MatchedCount == 0 {
@@ -113,8 +159,8 @@ func (r *DropboxConfigRepository) UpdateConfig(ctx context.Context, config *mode
 	return nil
 }
 
-// UpdateHealth updates the health monitoring fields
-func (r *DropboxConfigRepository) UpdateHealth(ctx context.Context, isConnected bool, lastError string) error {
+// UpdateHealth updates a connection's health monitoring fields
+func (r *DropboxConfigRepository) UpdateHealth(ctx context.Context, id primitive.ObjectID, isConnected bool, lastError string) error {
 	update := bson.M{
 		"is_connected":         isConnected,
 		"last_refresh_attempt": time.Now(),
@@ -128,7 +174,7 @@ func (r *DropboxConfigRepository) UpdateHealth(ctx context.Context, isConnected
 
 	result, err := r.collection.UpdateOne(
 		ctx,
-		bson.M{},
+		bson.M{"_id": id},
 		bson.M{"$set": update},
 	)
 	if err != nil {
@@ -140,11 +186,11 @@ func (r *DropboxConfigRepository) UpdateHealth(ctx context.Context, isConnected
 	return nil
 }
 
-// IncrementFailures increments the consecutive failures counter
-func (r *DropboxConfigRepository) IncrementFailures(ctx context.Context) error {
+// IncrementFailures increments a connection's consecutive failures counter
+func (r *DropboxConfigRepository) IncrementFailures(ctx context.Context, id primitive.ObjectID) error {
 	result, err := r.collection.UpdateOne(
 		ctx,
-		bson.M{},
+		bson.M{"_id": id},
 		bson.M{
 			"$inc": bson.M{"consecutive_failures": 1},
 			"$set": bson.M{
@@ -162,11 +208,11 @@ func (r *DropboxConfigRepository) IncrementFailures(ctx context.Context) error {
 	return nil
 }
 
-// ResetFailures resets the consecutive failures counter
-func (r *DropboxConfigRepository) ResetFailures(ctx context.Context) error {
+// ResetFailures resets a connection's consecutive failures counter
+func (r *DropboxConfigRepository) ResetFailures(ctx context.Context, id primitive.ObjectID) error {
 	result, err := r.collection.UpdateOne(
 		ctx,
-		bson.M{},
+		bson.M{"_id": id},
 		bson.M{
 			"$set": bson.M{
 				"consecutive_failures": 0,
@@ -185,10 +231,10 @@ func (r *DropboxConfigRepository) ResetFailures(ctx context.Context) error {
 	return nil
 }
 
-// DeleteConfig deletes the Dropbox configuration
+// DeleteConfig deletes a connection
 // Use with caution - this will require re-authorization
-func (r *DropboxConfigRepository) DeleteConfig(ctx context.Context) error {
-	result, err := r.collection.DeleteOne(ctx, bson.M{})
+func (r *DropboxConfigRepository) DeleteConfig(ctx context.Context, id primitive.ObjectID) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
 	if err != nil {
 		return err
 	}
@@ -197,16 +243,3 @@ func (r *DropboxConfigRepository) DeleteConfig(ctx context.Context) error {
 	}
 	return nil
 }
-
-// EnsureIndexes creates necessary indexes for the collection
-func (r *DropboxConfigRepository) EnsureIndexes(ctx context.Context) error {
-	// We don't need indexes since this is a singleton collection
-	// But we can add one for good measure
-	indexModel := mongo.IndexModel{
-		Keys:    bson.D{{Key: "created_at", Value: 1}},
-		Options: options.Index().SetUnique(false),
-	}
-
-	_, err := r.collection.Indexes().CreateOne(ctx, indexModel)
-	return err
-}
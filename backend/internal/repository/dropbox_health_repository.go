@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"backend/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// dropboxHealthRetention is how long health history is kept
+const dropboxHealthRetention = 180 * 24 * time.Hour
+
+// DropboxHealthRepository stores the health history of the Dropbox
+// connections and the alert state of each. Records without a connection
+// belong to the default connection.
+type DropboxHealthRepository struct {
+	events *mongo.Collection
+	state  *mongo.Collection
+}
+
+// NewDropboxHealthRepository creates a new DropboxHealthRepository
+func NewDropboxHealthRepository(db *mongo.Database) *DropboxHealthRepository {
+	events := db.Collection("dropbox_health_events")
+
+	ensureIndexes(events, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "created_at", Value: -1}},
+			Options: options.Index().SetExpireAfterSeconds(int32(dropboxHealthRetention.Seconds())),
+		},
+		{
+			Keys: bson.D{{Key: "connection_id", Value: 1}, {Key: "created_at", Value: -1}},
+		},
+	})
+
+	return &DropboxHealthRepository{
+		events: events,
+		state:  db.Collection("dropbox_alert_state"),
+	}
+}
+
+// GetState retrieves a connection's alert state, which is empty until the
+// first failure is recorded
+func (r *DropboxHealthRepository) GetState(ctx context.Context, connectionID *primitive.ObjectID) (*models.DropboxAlertState, error) {
+	var state models.DropboxAlertState
+	err := r.state.FindOne(ctx, bson.M{"connection_id": connectionID}).Decode(&state)
+	if err == mongo.ErrNoDocuments {
+		return &models.DropboxAlertState{ConnectionID: connectionID}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// ListStates returns the alert state of every connection that has one
+func (r *DropboxHealthRepository) ListStates(ctx context.Context) ([]*models.DropboxAlertState, error) {
+	cursor, err := r.state.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	states := []*models.DropboxAlertState{}
+	if err := cursor.All(ctx, &states); err != nil {
+		return nil, err
+	}
+	return states, nil
+}
+
+// SaveState creates or replaces a connection's alert state
+func (r *DropboxHealthRepository) SaveState(ctx context.Context, state *models.DropboxAlertState) error {
+	_, err := r.state.ReplaceOne(ctx, bson.M{"connection_id": state.ConnectionID}, state, options.Replace().SetUpsert(true))
+	return err
+}
+
+// DeleteConnection removes the alert state of a deleted connection. Its
+// health history is kept until it expires.
+func (r *DropboxHealthRepository) DeleteConnection(ctx context.Context, connectionID primitive.ObjectID) error {
+	_, err := r.state.DeleteOne(ctx, bson.M{"connection_id": connectionID})
+	return err
+}
+
+// AddEvents appends events to the health history
+func (r *DropboxHealthRepository) AddEvents(ctx context.Context, events []models.DropboxHealthEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	docs := make([]interface{}, 0, len(events))
+	for _, event := range events {
+		docs = append(docs, event)
+	}
+	_, err := r.events.InsertMany(ctx, docs)
+	return err
+}
+
+// ListEvents returns a connection's most recent health events, newest first
+func (r *DropboxHealthRepository) ListEvents(ctx context.Context, connectionID *primitive.ObjectID, limit int64) ([]models.DropboxHealthEvent, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}).SetLimit(limit)
+	cursor, err := r.events.Find(ctx, bson.M{"connection_id": connectionID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	events := []models.DropboxHealthEvent{}
+	if err := cursor.All(ctx, &events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"backend/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	ErrListingNotFound = errors.New("dropbox listing not cached")
+)
+
+// DropboxListingRepository persists cached Dropbox folder listings
+type DropboxListingRepository struct {
+	collection *mongo.Collection
+}
+
+// NewDropboxListingRepository creates a new DropboxListingRepository
+func NewDropboxListingRepository(db *mongo.Database) *DropboxListingRepository {
+	collection := db.Collection("dropbox_listings")
+
+	// A folder is cached once per connection. Listings saved before there
+	// were connections have none and belong to the default connection, which
+	// the unique path index they were saved under would not allow.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, _ = collection.Indexes().DropOne(ctx, "path_1")
+
+	ensureIndexes(collection, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "connection_id", Value: 1}, {Key: "path", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+	})
+
+	return &DropboxListingRepository{
+		collection: collection,
+	}
+}
+
+// listingFilter matches the listing of a folder by its connection, nil for
+// the default connection, and its lower-cased full path. A nil connection
+// matches listings saved without one.
+func listingFilter(connectionID *primitive.ObjectID, path string) bson.M {
+	return bson.M{"connection_id": connectionID, "path": path}
+}
+
+// FindByPath finds the listing of a folder by its connection and
+// lower-cased full path
+func (r *DropboxListingRepository) FindByPath(ctx context.Context, connectionID *primitive.ObjectID, path string) (*models.DropboxListing, error) {
+	var listing models.DropboxListing
+	err := r.collection.FindOne(ctx, listingFilter(connectionID, path)).Decode(&listing)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrListingNotFound
+		}
+		return nil, err
+	}
+	return &listing, nil
+}
+
+// Save creates or replaces a listing
+func (r *DropboxListingRepository) Save(ctx context.Context, listing *models.DropboxListing) error {
+	if listing.CreatedAt.IsZero() {
+		listing.CreatedAt = time.Now()
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"relative_path": listing.RelativePath,
+			"cursor":        listing.Cursor,
+			"entries":       listing.Entries,
+			"entry_count":   listing.EntryCount,
+			"missing":       listing.Missing,
+			"last_error":    listing.LastError,
+			"synced_at":     listing.SyncedAt,
+			"last_read_at":  listing.LastReadAt,
+		},
+		"$setOnInsert": bson.M{"created_at": listing.CreatedAt},
+	}
+
+	_, err := r.collection.UpdateOne(ctx, listingFilter(listing.ConnectionID, listing.Path), update, options.Update().SetUpsert(true))
+	return err
+}
+
+// SetError records a failed sync without touching the cached entries
+func (r *DropboxListingRepository) SetError(ctx context.Context, connectionID *primitive.ObjectID, path, message string) error {
+	_, err := r.collection.UpdateOne(ctx, listingFilter(connectionID, path), bson.M{"$set": bson.M{"last_error": message}})
+	return err
+}
+
+// TouchRead records that a listing was served
+func (r *DropboxListingRepository) TouchRead(ctx context.Context, connectionID *primitive.ObjectID, path string, at time.Time) error {
+	_, err := r.collection.UpdateOne(ctx, listingFilter(connectionID, path), bson.M{"$set": bson.M{"last_read_at": at}})
+	return err
+}
+
+// List returns every cached listing without its entries
+func (r *DropboxListingRepository) List(ctx context.Context) ([]*models.DropboxListing, error) {
+	opts := options.Find().
+		SetProjection(bson.M{"entries": 0}).
+		SetSort(bson.D{{Key: "path", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	listings := []*models.DropboxListing{}
+	if err := cursor.All(ctx, &listings); err != nil {
+		return nil, err
+	}
+	return listings, nil
+}
+
+// Delete removes the listing of one folder
+func (r *DropboxListingRepository) Delete(ctx context.Context, connectionID *primitive.ObjectID, path string) error {
+	result, err := r.collection.DeleteOne(ctx, listingFilter(connectionID, path))
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrListingNotFound
+	}
+	return nil
+}
+
+// DeleteAll removes every cached listing
+func (r *DropboxListingRepository) DeleteAll(ctx context.Context) (int64, error) {
+	result, err := r.collection.DeleteMany(ctx, bson.M{})
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
+
+// DeleteConnection removes every listing of a connection
+func (r *DropboxListingRepository) DeleteConnection(ctx context.Context, connectionID primitive.ObjectID) (int64, error) {
+	result, err := r.collection.DeleteMany(ctx, bson.M{"connection_id": connectionID})
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
+
+// DeleteUnreadSince removes listings that have not been served since the given time
+func (r *DropboxListingRepository) DeleteUnreadSince(ctx context.Context, since time.Time) (int64, error) {
+	result, err := r.collection.DeleteMany(ctx, bson.M{"last_read_at": bson.M{"$lt": since}})
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
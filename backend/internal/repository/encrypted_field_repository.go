@@ -0,0 +1,164 @@
+package repository
+
+import (
+	"context"
+	"strings"
+
+	"backend/internal/fieldcrypt"
+	"backend/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// EncryptedField is a field stored encrypted with EncryptionService. Path
+// is the field's dotted path in the collection's documents.
+type EncryptedField struct {
+	Collection string
+	Path       string
+	Index      string // Path of the field's blind index, if it has one
+	Personal   bool   // Personal data encrypted by fieldcrypt, which may still be plaintext
+	Map        bool   // Path holds a map whose values are encrypted one by one
+}
+
+// String returns the field as collection.path
+func (f EncryptedField) String() string {
+	return f.Collection + "." + f.Path
+}
+
+// EncryptedFields lists every encrypted field, so that re-encryption after
+// a key rotation reaches every stored ciphertext. Personal data fields of
+// models are found from their encrypt tags; add other encrypted fields
+// here.
+var EncryptedFields = encryptedFields()
+
+func encryptedFields() []EncryptedField {
+	fields := []EncryptedField{
+		{Collection: "dropbox_config", Path: "app_secret"},
+		{Collection: "dropbox_config", Path: "access_token"},
+		{Collection: "dropbox_config", Path: "refresh_token"},
+		{Collection: "dropbox_auth_requests", Path: "app_secret"},
+		{Collection: "dropbox_auth_requests", Path: "code_verifier"},
+		{Collection: "registry_config", Path: "smtp_config.password"},
+	}
+	for _, field := range fieldcrypt.Fields(models.User{}) {
+		fields = append(fields, EncryptedField{Collection: "users", Path: field.Path, Index: field.Index, Personal: true})
+	}
+	// Values of sensitive form fields
+	return append(fields, EncryptedField{Collection: "registry_submissions", Path: "form_data", Personal: true, Map: true})
+}
+
+// EncryptedValue is the value stored in one document's encrypted field,
+// with its blind index
+type EncryptedValue struct {
+	DocumentID interface{}
+	Path       string // The field's path, or the path of the map entry
+	Ciphertext string
+	Index      string
+}
+
+// EncryptedFieldRepository reads and replaces the ciphertexts of encrypted
+// fields in any collection
+type EncryptedFieldRepository struct {
+	db *mongo.Database
+}
+
+// NewEncryptedFieldRepository creates a new EncryptedFieldRepository
+func NewEncryptedFieldRepository(db *mongo.Database) *EncryptedFieldRepository {
+	return &EncryptedFieldRepository{db: db}
+}
+
+// List returns the values stored in a field, skipping documents where it
+// is unset or empty. For a map field it returns each string entry.
+func (r *EncryptedFieldRepository) List(ctx context.Context, field EncryptedField) ([]EncryptedValue, error) {
+	filter := bson.M{field.Path: bson.M{"$type": "string", "$ne": ""}}
+	projection := bson.M{field.Path: 1}
+	if field.Map {
+		filter = bson.M{field.Path: bson.M{"$type": "object"}}
+	}
+	if field.Index != "" {
+		projection[field.Index] = 1
+	}
+
+	opts := options.Find().SetProjection(projection)
+	cursor, err := r.db.Collection(field.Collection).Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	values := []EncryptedValue{}
+	for cursor.Next(ctx) {
+		var document bson.M
+		if err := cursor.Decode(&document); err != nil {
+			return nil, err
+		}
+
+		if field.Map {
+			entries, _ := lookup(document, field.Path).(bson.M)
+			for key, value := range entries {
+				if s, ok := value.(string); ok && s != "" {
+					values = append(values, EncryptedValue{DocumentID: document["_id"], Path: field.Path + "." + key, Ciphertext: s})
+				}
+			}
+			continue
+		}
+
+		if ciphertext, ok := lookup(document, field.Path).(string); ok {
+			index, _ := lookup(document, field.Index).(string)
+			values = append(values, EncryptedValue{DocumentID: document["_id"], Path: field.Path, Ciphertext: ciphertext, Index: index})
+		}
+	}
+	return values, cursor.Err()
+}
+
+// Replace swaps a stored value for another, and sets the field's blind
+// index. It returns false, leaving the document alone, when the field no
+// longer holds the old value, such as a token refreshed in the meantime.
+func (r *EncryptedFieldRepository) Replace(ctx context.Context, field EncryptedField, value EncryptedValue, ciphertext, index string) (bool, error) {
+	set := bson.M{value.Path: ciphertext}
+	if field.Index != "" {
+		set[field.Index] = index
+	}
+
+	result, err := r.db.Collection(field.Collection).UpdateOne(
+		ctx,
+		bson.M{"_id": value.DocumentID, value.Path: value.Ciphertext},
+		bson.M{"$set": set},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount == 1, nil
+}
+
+// lookup returns the value at a dotted path in a document, with nested
+// documents as bson.M
+func lookup(document bson.M, path string) interface{} {
+	var value interface{} = document
+	for _, part := range strings.Split(path, ".") {
+		switch nested := value.(type) {
+		case bson.M:
+			value = nested[part]
+		case bson.D:
+			value = nil
+			for _, element := range nested {
+				if element.Key == part {
+					value = element.Value
+				}
+			}
+		default:
+			return nil
+		}
+	}
+
+	if nested, ok := value.(bson.D); ok {
+		m := bson.M{}
+		for _, element := range nested {
+			m[element.Key] = element.Value
+		}
+		return m
+	}
+	return value
+}
@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"backend/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var ErrImageNotFound = errors.New("image not found")
+
+// ImageRepository stores processed image uploads and their variants
+type ImageRepository struct {
+	collection *mongo.Collection
+}
+
+// NewImageRepository creates a new ImageRepository
+func NewImageRepository(db *mongo.Database) *ImageRepository {
+	collection := db.Collection("images")
+
+	ensureIndexes(collection, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "path", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+	})
+
+	return &ImageRepository{
+		collection: collection,
+	}
+}
+
+// Create records a processed upload
+func (r *ImageRepository) Create(ctx context.Context, image *models.Image) error {
+	image.CreatedAt = time.Now()
+
+	_, err := r.collection.InsertOne(ctx, image)
+	return err
+}
+
+// FindByPath finds an image by the path entities reference it with
+func (r *ImageRepository) FindByPath(ctx context.Context, path string) (*models.Image, error) {
+	var image models.Image
+	err := r.collection.FindOne(ctx, bson.M{"path": path}).Decode(&image)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrImageNotFound
+		}
+		return nil, err
+	}
+	return &image, nil
+}
+
+// DeleteByPath removes the record of an image
+func (r *ImageRepository) DeleteByPath(ctx context.Context, path string) error {
+	_, err := r.collection.DeleteOne(ctx, bson.M{"path": path})
+	return err
+}
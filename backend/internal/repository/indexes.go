@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ensureIndexes creates the given indexes when a repository is constructed.
+// Errors are ignored as the indexes may already exist.
+func ensureIndexes(collection *mongo.Collection, indexes []mongo.IndexModel) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, _ = collection.Indexes().CreateMany(ctx, indexes)
+}
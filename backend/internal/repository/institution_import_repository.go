@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"backend/internal/models"
+	"backend/internal/pagination"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var ErrInstitutionImportNotFound = errors.New("institution import not found")
+
+// InstitutionImportRepository stores the history of institution imports
+type InstitutionImportRepository struct {
+	collection *mongo.Collection
+}
+
+// InstitutionImportSortFields maps the public import history sort keys onto document fields
+var InstitutionImportSortFields = pagination.Fields{
+	pagination.KeyCreatedAt: {Path: "created_at"},
+}
+
+// InstitutionImportDefaultSort is the sort applied when a client does not request one
+const InstitutionImportDefaultSort = "-createdAt"
+
+// NewInstitutionImportRepository creates a new InstitutionImportRepository
+func NewInstitutionImportRepository(db *mongo.Database) *InstitutionImportRepository {
+	collection := db.Collection("institution_imports")
+
+	ensureIndexes(collection, []mongo.IndexModel{
+		{Keys: pagination.IndexKeys(InstitutionImportSortFields, InstitutionImportDefaultSort)},
+	})
+
+	return &InstitutionImportRepository{
+		collection: collection,
+	}
+}
+
+// Create records an import run
+func (r *InstitutionImportRepository) Create(ctx context.Context, imp *models.InstitutionImport) error {
+	imp.ID = primitive.NewObjectID()
+	imp.CreatedAt = time.Now()
+
+	_, err := r.collection.InsertOne(ctx, imp)
+	return err
+}
+
+// FindByID finds an import run by ID
+func (r *InstitutionImportRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.InstitutionImport, error) {
+	var imp models.InstitutionImport
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&imp)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrInstitutionImportNotFound
+		}
+		return nil, err
+	}
+	return &imp, nil
+}
+
+// ListPage retrieves one page of import history using cursor pagination
+func (r *InstitutionImportRepository) ListPage(ctx context.Context, req pagination.Request) (*pagination.Page[*models.InstitutionImport], error) {
+	return pagination.Find[*models.InstitutionImport](ctx, r.collection, bson.M{}, req, InstitutionImportSortFields)
+}
+
+// Count counts recorded imports
+func (r *InstitutionImportRepository) Count(ctx context.Context) (int64, error) {
+	return r.collection.CountDocuments(ctx, bson.M{})
+}
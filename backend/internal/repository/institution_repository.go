@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"backend/internal/models"
+	"backend/internal/pagination"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -23,10 +24,35 @@ type InstitutionRepository struct {
 	collection *mongo.Collection
 }
 
+// InstitutionSortFields maps the public institution sort keys onto document fields
+var InstitutionSortFields = pagination.Fields{
+	pagination.KeyCreatedAt: {Path: "created_at"},
+	pagination.KeyName:      {Path: "name"},
+	pagination.KeyStatus:    {Path: "is_active"},
+}
+
+// InstitutionDefaultSort is the sort applied when a client does not request one
+const InstitutionDefaultSort = "name"
+
 // NewInstitutionRepository creates a new InstitutionRepository
 func NewInstitutionRepository(db *mongo.Database) *InstitutionRepository {
+	collection := db.Collection("institutions")
+
+	// Compound indexes backing the paginated institution list
+	ensureIndexes(collection, []mongo.IndexModel{
+		{Keys: pagination.IndexKeys(InstitutionSortFields, "name")},
+		{Keys: pagination.IndexKeys(InstitutionSortFields, "name", "is_active")},
+		{Keys: pagination.IndexKeys(InstitutionSortFields, "name", "type")},
+		{Keys: pagination.IndexKeys(InstitutionSortFields, "-createdAt")},
+		{Keys: bson.D{{Key: "aliases", Value: 1}}},
+		{Keys: pagination.IndexKeys(InstitutionSortFields, "name", "parent_id")},
+		{Keys: bson.D{{Key: "ancestors", Value: 1}}},
+		{Keys: bson.D{{Key: "location", Value: "2dsphere"}}},
+		{Keys: bson.D{{Key: "province", Value: 1}, {Key: "city", Value: 1}}},
+	})
+
 	return &InstitutionRepository{
-		collection: db.Collection("institutions"),
+		collection: collection,
 	}
 }
 
@@ -59,10 +85,10 @@ func (r *InstitutionRepository) FindByID(ctx context.Context, id primitive.Objec
 	return &institution, nil
 }
 
-// FindByName finds an institution by name
+// FindByName finds an institution by name or by one of its aliases
 func (r *InstitutionRepository) FindByName(ctx context.Context, name string) (*models.Institution, error) {
 	var institution models.Institution
-	err := r.collection.FindOne(ctx, bson.M{"name": name}).Decode(&institution)
+	err := r.collection.FindOne(ctx, bson.M{"$or": []bson.M{{"name": name}, {"aliases": name}}}).Decode(&institution)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			return nil, ErrInstitutionNotFound
@@ -72,6 +98,11 @@ func (r *InstitutionRepository) FindByName(ctx context.Context, name string) (*m
 	return &institution, nil
 }
 
+// FindByShortName finds every institution with the given short name
+func (r *InstitutionRepository) FindByShortName(ctx context.Context, shortName string) ([]*models.Institution, error) {
+	return r.List(ctx, bson.M{"short_name": shortName}, 0, 0)
+}
+
 // Update updates an institution
 func (r *InstitutionRepository) Update(ctx context.Context, id primitive.ObjectID, update bson.M) error {
 	update["updated_at"] = time.Now()
@@ -122,6 +153,213 @@ func (r *InstitutionRepository) List(ctx context.Context, filter bson.M, limit,
 	return institutions, nil
 }
 
+// ListPage retrieves one page of institutions using cursor pagination
+func (r *InstitutionRepository) ListPage(ctx context.Context, filter bson.M, req pagination.Request) (*pagination.Page[*models.Institution], error) {
+	return pagination.Find[*models.Institution](ctx, r.collection, filter, req, InstitutionSortFields)
+}
+
+// FindByIDs finds all institutions with the given IDs
+func (r *InstitutionRepository) FindByIDs(ctx context.Context, ids []primitive.ObjectID) ([]*models.Institution, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var institutions []*models.Institution
+	if err := cursor.All(ctx, &institutions); err != nil {
+		return nil, err
+	}
+
+	return institutions, nil
+}
+
+// AddAliases records additional names an institution is known by
+func (r *InstitutionRepository) AddAliases(ctx context.Context, id primitive.ObjectID, aliases []string) error {
+	if len(aliases) == 0 {
+		return nil
+	}
+
+	result, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"_id": id},
+		bson.M{
+			"$addToSet": bson.M{"aliases": bson.M{"$each": aliases}},
+			"$set":      bson.M{"updated_at": time.Now()},
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrInstitutionNotFound
+	}
+	return nil
+}
+
+// DeleteMany deletes all institutions with the given IDs
+func (r *InstitutionRepository) DeleteMany(ctx context.Context, ids []primitive.ObjectID) (int64, error) {
+	result, err := r.collection.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
+
+// FindDescendants finds every institution below the given one, at any depth
+func (r *InstitutionRepository) FindDescendants(ctx context.Context, id primitive.ObjectID) ([]*models.Institution, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{"ancestors": id})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var institutions []*models.Institution
+	if err := cursor.All(ctx, &institutions); err != nil {
+		return nil, err
+	}
+
+	return institutions, nil
+}
+
+// FindChildren finds the institutions directly below the given one
+func (r *InstitutionRepository) FindChildren(ctx context.Context, id primitive.ObjectID) ([]*models.Institution, error) {
+	return r.List(ctx, bson.M{"parent_id": id}, 0, 0)
+}
+
+// FindPath returns the institution and its ancestors, ordered from the root
+// down to the institution itself
+func (r *InstitutionRepository) FindPath(ctx context.Context, id primitive.ObjectID) ([]*models.Institution, error) {
+	institution, err := r.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(institution.Ancestors) == 0 {
+		return []*models.Institution{institution}, nil
+	}
+
+	ancestors, err := r.FindByIDs(ctx, institution.Ancestors)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[primitive.ObjectID]*models.Institution, len(ancestors))
+	for _, ancestor := range ancestors {
+		byID[ancestor.ID] = ancestor
+	}
+
+	path := make([]*models.Institution, 0, len(institution.Ancestors)+1)
+	for _, ancestorID := range institution.Ancestors {
+		if ancestor, ok := byID[ancestorID]; ok {
+			path = append(path, ancestor)
+		}
+	}
+	return append(path, institution), nil
+}
+
+// SetParent places an institution under parentID with the given ancestors.
+// A nil parentID makes the institution top-level.
+func (r *InstitutionRepository) SetParent(ctx context.Context, id primitive.ObjectID, parentID *primitive.ObjectID, ancestors []primitive.ObjectID) error {
+	update := bson.M{
+		"$set": bson.M{"parent_id": parentID, "ancestors": ancestors, "updated_at": time.Now()},
+	}
+	if parentID == nil {
+		update = bson.M{
+			"$unset": bson.M{"parent_id": "", "ancestors": ""},
+			"$set":   bson.M{"updated_at": time.Now()},
+		}
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrInstitutionNotFound
+	}
+	return nil
+}
+
+// Nearby finds institutions matching filter within maxDistanceKm of a point,
+// closest first
+func (r *InstitutionRepository) Nearby(ctx context.Context, point *models.GeoPoint, maxDistanceKm float64, filter bson.M, limit int64) ([]*models.NearbyInstitution, error) {
+	if filter == nil {
+		filter = bson.M{}
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$geoNear", Value: bson.M{
+			"near":               point,
+			"distanceField":      "distance_km",
+			"distanceMultiplier": 0.001, // metres to kilometres
+			"maxDistance":        maxDistanceKm * 1000,
+			"spherical":          true,
+			"query":              filter,
+		}}},
+		{{Key: "$limit", Value: limit}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	institutions := []*models.NearbyInstitution{}
+	if err := cursor.All(ctx, &institutions); err != nil {
+		return nil, err
+	}
+
+	return institutions, nil
+}
+
+// Facets counts institutions matching filter by province and by city
+func (r *InstitutionRepository) Facets(ctx context.Context, filter bson.M) (*models.InstitutionFacets, error) {
+	if filter == nil {
+		filter = bson.M{}
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$facet", Value: bson.M{
+			"provinces": bson.A{
+				bson.M{"$match": bson.M{"province": bson.M{"$nin": bson.A{nil, ""}}}},
+				bson.M{"$group": bson.M{"_id": "$province", "count": bson.M{"$sum": 1}}},
+				bson.M{"$project": bson.M{"_id": 0, "value": "$_id", "count": 1}},
+				bson.M{"$sort": bson.D{{Key: "value", Value: 1}}},
+			},
+			"cities": bson.A{
+				bson.M{"$group": bson.M{
+					"_id":   bson.M{"city": "$city", "province": "$province"},
+					"count": bson.M{"$sum": 1},
+				}},
+				bson.M{"$project": bson.M{"_id": 0, "value": "$_id.city", "province": "$_id.province", "count": 1}},
+				bson.M{"$sort": bson.D{{Key: "value", Value: 1}, {Key: "province", Value: 1}}},
+			},
+		}}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []models.InstitutionFacets
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return &models.InstitutionFacets{Provinces: []models.FacetCount{}, Cities: []models.FacetCount{}}, nil
+	}
+
+	return &results[0], nil
+}
+
+// SetLocation records the coordinates of an institution
+func (r *InstitutionRepository) SetLocation(ctx context.Context, id primitive.ObjectID, location *models.GeoPoint, source string) error {
+	return r.Update(ctx, id, bson.M{"location": location, "location_source": source})
+}
+
 // Count counts institutions matching a filter
 func (r *InstitutionRepository) Count(ctx context.Context, filter bson.M) (int64, error) {
 	return r.collection.CountDocuments(ctx, filter)
@@ -137,9 +375,9 @@ func (r *InstitutionRepository) Deactivate(ctx context.Context, id primitive.Obj
 	return r.Update(ctx, id, bson.M{"is_active": false})
 }
 
-// NameExists checks if an institution name already exists
+// NameExists checks if an institution name already exists as a name or alias
 func (r *InstitutionRepository) NameExists(ctx context.Context, name string, excludeID *primitive.ObjectID) (bool, error) {
-	filter := bson.M{"name": name}
+	filter := bson.M{"$or": []bson.M{{"name": name}, {"aliases": name}}}
 	if excludeID != nil {
 		filter["_id"] = bson.M{"$ne": *excludeID}
 	}
@@ -0,0 +1,252 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"backend/internal/models"
+	"backend/internal/pagination"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	ErrCategoryNotFound = errors.New("category not found")
+	ErrDuplicateSlug    = errors.New("category with this slug already exists")
+)
+
+const libraryCategoriesCollection = "library_categories"
+
+// KeyDisplayOrder sorts categories by their configured display order
+const KeyDisplayOrder = "displayOrder"
+
+// CategorySortFields maps the public category sort keys onto document fields
+var CategorySortFields = pagination.Fields{
+	KeyDisplayOrder:         {Path: "display_order"},
+	pagination.KeyCreatedAt: {Path: "created_at"},
+	pagination.KeyName:      {Path: "name"},
+	pagination.KeyStatus:    {Path: "is_active"},
+}
+
+// CategoryDefaultSort is the sort applied when a client does not request one
+const CategoryDefaultSort = "displayOrder,name"
+
+// LibraryCategoryRepository handles library category database operations
+type LibraryCategoryRepository struct {
+	db         *mongo.Database
+	collection *mongo.Collection
+}
+
+// NewLibraryCategoryRepository creates a new LibraryCategoryRepository
+func NewLibraryCategoryRepository(db *mongo.Database) *LibraryCategoryRepository {
+	collection := db.Collection(libraryCategoriesCollection)
+
+	ensureIndexes(collection, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "library_id", Value: 1}, {Key: "slug", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "image_path", Value: 1}},
+		},
+		{
+			Keys: pagination.IndexKeys(CategorySortFields, CategoryDefaultSort, "library_id"),
+		},
+		{
+			Keys: pagination.IndexKeys(CategorySortFields, CategoryDefaultSort, "library_id", "is_active"),
+		},
+	})
+
+	return &LibraryCategoryRepository{
+		db:         db,
+		collection: collection,
+	}
+}
+
+// Create creates a new category
+func (r *LibraryCategoryRepository) Create(ctx context.Context, category *models.LibraryCategory) error {
+	category.CreatedAt = time.Now()
+	category.UpdatedAt = time.Now()
+
+	if category.ID.IsZero() {
+		category.ID = primitive.NewObjectID()
+	}
+
+	_, err := r.collection.InsertOne(ctx, category)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrDuplicateSlug
+		}
+		return err
+	}
+
+	return nil
+}
+
+// FindByID finds a category by ID within a library
+func (r *LibraryCategoryRepository) FindByID(ctx context.Context, libraryID, id primitive.ObjectID) (*models.LibraryCategory, error) {
+	var category models.LibraryCategory
+
+	err := r.collection.FindOne(ctx, bson.M{"_id": id, "library_id": libraryID}).Decode(&category)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrCategoryNotFound
+		}
+		return nil, err
+	}
+
+	return &category, nil
+}
+
+// LibraryCategoryFilter represents filters for listing categories
+type LibraryCategoryFilter struct {
+	LibraryID   *primitive.ObjectID
+	IsActive    *bool
+	Search      string
+	ImagePath   string
+	DropboxPath string
+}
+
+// toBSON converts the filter into a MongoDB query
+func (f LibraryCategoryFilter) toBSON() bson.M {
+	mongoFilter := bson.M{}
+
+	if f.LibraryID != nil {
+		mongoFilter["library_id"] = *f.LibraryID
+	}
+
+	if f.IsActive != nil {
+		mongoFilter["is_active"] = *f.IsActive
+	}
+
+	if f.ImagePath != "" {
+		mongoFilter["image_path"] = f.ImagePath
+	}
+
+	if f.DropboxPath != "" {
+		mongoFilter["dropbox_path"] = f.DropboxPath
+	}
+
+	if f.Search != "" {
+		mongoFilter["$or"] = []bson.M{
+			{"name": bson.M{"$regex": f.Search, "$options": "i"}},
+			{"description": bson.M{"$regex": f.Search, "$options": "i"}},
+			{"slug": bson.M{"$regex": f.Search, "$options": "i"}},
+		}
+	}
+
+	return mongoFilter
+}
+
+// List returns one page of categories using cursor pagination
+func (r *LibraryCategoryRepository) List(ctx context.Context, filter LibraryCategoryFilter, req pagination.Request) (*pagination.Page[*models.LibraryCategory], error) {
+	return pagination.Find[*models.LibraryCategory](ctx, r.collection, filter.toBSON(), req, CategorySortFields)
+}
+
+// FindAll returns every category matching the filter in display order
+func (r *LibraryCategoryRepository) FindAll(ctx context.Context, filter LibraryCategoryFilter) ([]*models.LibraryCategory, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "display_order", Value: 1}, {Key: "name", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, filter.toBSON(), opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	categories := []*models.LibraryCategory{}
+	if err := cursor.All(ctx, &categories); err != nil {
+		return nil, err
+	}
+	return categories, nil
+}
+
+// Count returns the total count of categories matching the filter
+func (r *LibraryCategoryRepository) Count(ctx context.Context, filter LibraryCategoryFilter) (int64, error) {
+	return r.collection.CountDocuments(ctx, filter.toBSON())
+}
+
+// Update updates a category
+func (r *LibraryCategoryRepository) Update(ctx context.Context, id primitive.ObjectID, update bson.M) error {
+	update["updated_at"] = time.Now()
+
+	result, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"_id": id},
+		bson.M{"$set": update},
+	)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrDuplicateSlug
+		}
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrCategoryNotFound
+	}
+
+	return nil
+}
+
+// Delete hard deletes a category from the database
+func (r *LibraryCategoryRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return ErrCategoryNotFound
+	}
+
+	return nil
+}
+
+// ExistsBySlug checks if a category with the given slug exists in a library
+func (r *LibraryCategoryRepository) ExistsBySlug(ctx context.Context, libraryID primitive.ObjectID, slug string, excludeID *primitive.ObjectID) (bool, error) {
+	filter := bson.M{"library_id": libraryID, "slug": slug}
+
+	if excludeID != nil {
+		filter["_id"] = bson.M{"$ne": *excludeID}
+	}
+
+	count, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// CopyLegacyCategories copies the categories of a pre-library collection
+// (sop_categories, working_party_categories) into a library, keeping their
+// IDs so existing links stay valid. Copies replace any earlier partial copy,
+// so a failed migration can be re-run. The legacy collection is left in place.
+func (r *LibraryCategoryRepository) CopyLegacyCategories(ctx context.Context, legacyCollection string, libraryID primitive.ObjectID) (int, error) {
+	cursor, err := r.db.Collection(legacyCollection).Find(ctx, bson.M{})
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	copied := 0
+	for cursor.Next(ctx) {
+		var category models.LibraryCategory
+		if err := cursor.Decode(&category); err != nil {
+			return copied, err
+		}
+		category.LibraryID = libraryID
+
+		opts := options.Replace().SetUpsert(true)
+		if _, err := r.collection.ReplaceOne(ctx, bson.M{"_id": category.ID}, category, opts); err != nil {
+			return copied, err
+		}
+		copied++
+	}
+
+	return copied, cursor.Err()
+}
@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"backend/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	ErrLibraryNotFound      = errors.New("library not found")
+	ErrDuplicateLibrarySlug = errors.New("library with this slug already exists")
+)
+
+// LibraryRepository handles document library database operations
+type LibraryRepository struct {
+	collection *mongo.Collection
+}
+
+// NewLibraryRepository creates a new LibraryRepository
+func NewLibraryRepository(db *mongo.Database) *LibraryRepository {
+	collection := db.Collection("libraries")
+
+	ensureIndexes(collection, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "slug", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "display_order", Value: 1}, {Key: "name", Value: 1}},
+		},
+	})
+
+	return &LibraryRepository{
+		collection: collection,
+	}
+}
+
+// Create creates a new library
+func (r *LibraryRepository) Create(ctx context.Context, library *models.Library) error {
+	library.CreatedAt = time.Now()
+	library.UpdatedAt = time.Now()
+
+	if library.ID.IsZero() {
+		library.ID = primitive.NewObjectID()
+	}
+
+	_, err := r.collection.InsertOne(ctx, library)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrDuplicateLibrarySlug
+		}
+		return err
+	}
+
+	return nil
+}
+
+// FindByID finds a library by ID
+func (r *LibraryRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Library, error) {
+	return r.findOne(ctx, bson.M{"_id": id})
+}
+
+// FindBySlug finds a library by its route slug
+func (r *LibraryRepository) FindBySlug(ctx context.Context, slug string) (*models.Library, error) {
+	return r.findOne(ctx, bson.M{"slug": slug})
+}
+
+func (r *LibraryRepository) findOne(ctx context.Context, filter bson.M) (*models.Library, error) {
+	var library models.Library
+	err := r.collection.FindOne(ctx, filter).Decode(&library)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrLibraryNotFound
+		}
+		return nil, err
+	}
+	return &library, nil
+}
+
+// List returns every library in display order
+func (r *LibraryRepository) List(ctx context.Context) ([]*models.Library, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "display_order", Value: 1}, {Key: "name", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	libraries := []*models.Library{}
+	if err := cursor.All(ctx, &libraries); err != nil {
+		return nil, err
+	}
+	return libraries, nil
+}
+
+// Update updates a library
+func (r *LibraryRepository) Update(ctx context.Context, id primitive.ObjectID, update bson.M) error {
+	update["updated_at"] = time.Now()
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": update})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrLibraryNotFound
+	}
+	return nil
+}
+
+// Delete hard deletes a library
+func (r *LibraryRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrLibraryNotFound
+	}
+	return nil
+}
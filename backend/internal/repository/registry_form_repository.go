@@ -6,11 +6,11 @@ import (
 	"time"
 
 	"backend/internal/models"
+	"backend/internal/pagination"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 var (
@@ -18,6 +18,16 @@ var (
 	ErrNoActiveForm       = errors.New("no active form schema found")
 )
 
+// FormSchemaSortFields maps the public form schema sort keys onto document fields
+var FormSchemaSortFields = pagination.Fields{
+	pagination.KeyCreatedAt: {Path: "created_at"},
+	pagination.KeyName:      {Path: "form_name"},
+	pagination.KeyStatus:    {Path: "is_active"},
+}
+
+// FormSchemaDefaultSort is the sort applied when a client does not request one
+const FormSchemaDefaultSort = "-createdAt"
+
 // RegistryFormRepository handles database operations for registry form schemas
 type RegistryFormRepository struct {
 	collection *mongo.Collection
@@ -39,6 +49,9 @@ func NewRegistryFormRepository(db *mongo.Database) *RegistryFormRepository {
 		{
 			Keys: bson.D{{Key: "created_at", Value: -1}},
 		},
+		{
+			Keys: pagination.IndexKeys(FormSchemaSortFields, FormSchemaDefaultSort),
+		},
 	})
 
 	return &RegistryFormRepository{
@@ -88,25 +101,12 @@ func (r *RegistryFormRepository) FindActive(ctx context.Context) (*models.Regist
 	return &schema, nil
 }
 
-// List retrieves all form schemas with pagination
-func (r *RegistryFormRepository) List(ctx context.Context, page, limit int) ([]*models.RegistryFormSchema, int64, error) {
-	skip := (page - 1) * limit
-
-	opts := options.Find().
-		SetSkip(int64(skip)).
-		SetLimit(int64(limit)).
-		SetSort(bson.D{{Key: "created_at", Value: -1}})
-
-	cursor, err := r.collection.Find(ctx, bson.M{}, opts)
+// List retrieves one page of form schemas
+func (r *RegistryFormRepository) List(ctx context.Context, req pagination.Request) (*pagination.Page[*models.RegistryFormSchema], int64, error) {
+	page, err := pagination.Find[*models.RegistryFormSchema](ctx, r.collection, bson.M{}, req, FormSchemaSortFields)
 	if err != nil {
 		return nil, 0, err
 	}
-	defer cursor.Close(ctx)
-
-	var schemas []*models.RegistryFormSchema
-	if err = cursor.All(ctx, &schemas); err != nil {
-		return nil, 0, err
-	}
 
 	// Get total count
 	total, err := r.collection.CountDocuments(ctx, bson.M{})
@@ -114,7 +114,23 @@ func (r *RegistryFormRepository) List(ctx context.Context, page, limit int) ([]*
 		return nil, 0, err
 	}
 
-	return schemas, total, nil
+	return page, total, nil
+}
+
+// FindWithSensitiveFields finds the form schemas with a field marked
+// sensitive
+func (r *RegistryFormRepository) FindWithSensitiveFields(ctx context.Context) ([]*models.RegistryFormSchema, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{"fields.sensitive": true})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var schemas []*models.RegistryFormSchema
+	if err := cursor.All(ctx, &schemas); err != nil {
+		return nil, err
+	}
+	return schemas, nil
 }
 
 // Update updates a form schema
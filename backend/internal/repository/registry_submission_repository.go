@@ -3,27 +3,42 @@ package repository
 import (
 	"context"
 	"errors"
+	"fmt"
 	"time"
 
+	"backend/internal/fieldcrypt"
 	"backend/internal/models"
+	"backend/internal/pagination"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 var (
 	ErrSubmissionNotFound = errors.New("submission not found")
 )
 
-// RegistrySubmissionRepository handles database operations for registry submissions
+// SubmissionSortFields maps the public submission sort keys onto document fields
+var SubmissionSortFields = pagination.Fields{
+	pagination.KeyCreatedAt: {Path: "created_at"},
+	pagination.KeyStatus:    {Path: "status"},
+}
+
+// SubmissionDefaultSort is the sort applied when a client does not request one
+const SubmissionDefaultSort = "-createdAt"
+
+// RegistrySubmissionRepository handles database operations for registry
+// submissions. Values of sensitive form fields are encrypted before they are
+// stored and decrypted when read.
 type RegistrySubmissionRepository struct {
 	collection *mongo.Collection
+	cipher     fieldcrypt.Cipher
 }
 
 // NewRegistrySubmissionRepository creates a new RegistrySubmissionRepository
-func NewRegistrySubmissionRepository(db *mongo.Database) *RegistrySubmissionRepository {
+// that encrypts sensitive form data with cipher
+func NewRegistrySubmissionRepository(db *mongo.Database, cipher fieldcrypt.Cipher) *RegistrySubmissionRepository {
 	collection := db.Collection("registry_submissions")
 
 	// Create indexes
@@ -50,10 +65,32 @@ func NewRegistrySubmissionRepository(db *mongo.Database) *RegistrySubmissionRepo
 				{Key: "created_at", Value: -1},
 			},
 		},
+		{
+			Keys: bson.D{{Key: "institution_id", Value: 1}},
+		},
+		{
+			Keys: pagination.IndexKeys(SubmissionSortFields, SubmissionDefaultSort),
+		},
+		{
+			Keys: pagination.IndexKeys(SubmissionSortFields, SubmissionDefaultSort, "user_id"),
+		},
+		{
+			Keys: pagination.IndexKeys(SubmissionSortFields, SubmissionDefaultSort, "status"),
+		},
 	})
 
 	return &RegistrySubmissionRepository{
 		collection: collection,
+		cipher:     cipher,
+	}
+}
+
+// decrypt decrypts the sensitive form data of a submission after it is
+// read. Other fields are plaintext, even if a value looks encrypted. A
+// value that cannot be decrypted is kept as stored.
+func (r *RegistrySubmissionRepository) decrypt(submission *models.RegistrySubmission) {
+	if err := fieldcrypt.DecryptValues(r.cipher, submission.FormData, submission.SensitiveFields); err != nil {
+		fmt.Printf("Warning: failed to decrypt form data of submission %s: %v\n", submission.ID.Hex(), err)
 	}
 }
 
@@ -64,7 +101,26 @@ func (r *RegistrySubmissionRepository) Create(ctx context.Context, submission *m
 	submission.UpdatedAt = now
 	submission.Status = models.SubmissionStatusSubmitted
 
-	result, err := r.collection.InsertOne(ctx, submission)
+	// Encrypt the sensitive values of a copy, so the caller keeps the
+	// plaintext
+	stored := *submission
+	stored.FormData = make(map[string]interface{}, len(submission.FormData))
+	for key, value := range submission.FormData {
+		stored.FormData[key] = value
+	}
+	for _, key := range submission.SensitiveFields {
+		value, ok := stored.FormData[key]
+		if !ok {
+			continue
+		}
+		encrypted, err := fieldcrypt.EncryptValue(r.cipher, value)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt field %s: %w", key, err)
+		}
+		stored.FormData[key] = encrypted
+	}
+
+	result, err := r.collection.InsertOne(ctx, &stored)
 	if err != nil {
 		return err
 	}
@@ -83,71 +139,114 @@ func (r *RegistrySubmissionRepository) FindByID(ctx context.Context, id primitiv
 		}
 		return nil, err
 	}
+	r.decrypt(&submission)
 	return &submission, nil
 }
 
-// FindByUser finds all submissions for a specific user with pagination
-func (r *RegistrySubmissionRepository) FindByUser(ctx context.Context, userID primitive.ObjectID, page, limit int) ([]*models.RegistrySubmission, int64, error) {
-	skip := (page - 1) * limit
-
-	opts := options.Find().
-		SetSkip(int64(skip)).
-		SetLimit(int64(limit)).
-		SetSort(bson.D{{Key: "created_at", Value: -1}})
+// FindByUser retrieves one page of submissions for a specific user
+func (r *RegistrySubmissionRepository) FindByUser(ctx context.Context, userID primitive.ObjectID, req pagination.Request) (*pagination.Page[*models.RegistrySubmission], int64, error) {
+	return r.List(ctx, bson.M{"user_id": userID}, req)
+}
 
-	filter := bson.M{"user_id": userID}
+// List retrieves one page of submissions with optional filters
+func (r *RegistrySubmissionRepository) List(ctx context.Context, filter bson.M, req pagination.Request) (*pagination.Page[*models.RegistrySubmission], int64, error) {
+	if filter == nil {
+		filter = bson.M{}
+	}
 
-	cursor, err := r.collection.Find(ctx, filter, opts)
+	page, err := pagination.Find[*models.RegistrySubmission](ctx, r.collection, filter, req, SubmissionSortFields)
 	if err != nil {
 		return nil, 0, err
 	}
-	defer cursor.Close(ctx)
-
-	var submissions []*models.RegistrySubmission
-	if err = cursor.All(ctx, &submissions); err != nil {
-		return nil, 0, err
+	for _, submission := range page.Items {
+		r.decrypt(submission)
 	}
 
-	// Get total count for this user
+	// Get total count
 	total, err := r.collection.CountDocuments(ctx, filter)
 	if err != nil {
 		return nil, 0, err
 	}
 
-	return submissions, total, nil
+	return page, total, nil
 }
 
-// List retrieves all submissions with pagination and optional filters
-func (r *RegistrySubmissionRepository) List(ctx context.Context, page, limit int, filter bson.M) ([]*models.RegistrySubmission, int64, error) {
-	skip := (page - 1) * limit
-
-	opts := options.Find().
-		SetSkip(int64(skip)).
-		SetLimit(int64(limit)).
-		SetSort(bson.D{{Key: "created_at", Value: -1}})
+// CountByInstitutions counts submissions referencing any of the given institutions
+func (r *RegistrySubmissionRepository) CountByInstitutions(ctx context.Context, institutionIDs []primitive.ObjectID) (int64, error) {
+	return r.collection.CountDocuments(ctx, bson.M{"institution_id": bson.M{"$in": institutionIDs}})
+}
 
-	if filter == nil {
-		filter = bson.M{}
+// CountGroupedByInstitution counts submissions per institution. Submissions
+// without an institution are not included.
+func (r *RegistrySubmissionRepository) CountGroupedByInstitution(ctx context.Context) (map[primitive.ObjectID]int64, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"institution_id": bson.M{"$ne": nil}}}},
+		{{Key: "$group", Value: bson.M{"_id": "$institution_id", "count": bson.M{"$sum": 1}}}},
 	}
 
-	cursor, err := r.collection.Find(ctx, filter, opts)
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
 	if err != nil {
-		return nil, 0, err
+		return nil, err
 	}
 	defer cursor.Close(ctx)
 
-	var submissions []*models.RegistrySubmission
-	if err = cursor.All(ctx, &submissions); err != nil {
-		return nil, 0, err
+	var rows []struct {
+		ID    primitive.ObjectID `bson:"_id"`
+		Count int64              `bson:"count"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, err
 	}
 
-	// Get total count
-	total, err := r.collection.CountDocuments(ctx, filter)
+	counts := make(map[primitive.ObjectID]int64, len(rows))
+	for _, row := range rows {
+		counts[row.ID] = row.Count
+	}
+	return counts, nil
+}
+
+// UserIDsWithoutInstitution returns the submitters of submissions that do
+// not record an institution, such as those made before submissions did
+func (r *RegistrySubmissionRepository) UserIDsWithoutInstitution(ctx context.Context) ([]primitive.ObjectID, error) {
+	values, err := r.collection.Distinct(ctx, "user_id", bson.M{"institution_id": nil})
 	if err != nil {
-		return nil, 0, err
+		return nil, err
 	}
 
-	return submissions, total, nil
+	userIDs := make([]primitive.ObjectID, 0, len(values))
+	for _, value := range values {
+		if id, ok := value.(primitive.ObjectID); ok {
+			userIDs = append(userIDs, id)
+		}
+	}
+	return userIDs, nil
+}
+
+// SetMissingInstitution sets the institution of a user's submissions that do
+// not record one, and returns the number updated
+func (r *RegistrySubmissionRepository) SetMissingInstitution(ctx context.Context, userID, institutionID primitive.ObjectID) (int64, error) {
+	result, err := r.collection.UpdateMany(
+		ctx,
+		bson.M{"user_id": userID, "institution_id": nil},
+		bson.M{"$set": bson.M{"institution_id": institutionID}},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+// ReassignInstitution re-points submissions from the given institutions to another
+func (r *RegistrySubmissionRepository) ReassignInstitution(ctx context.Context, from []primitive.ObjectID, to primitive.ObjectID) (int64, error) {
+	result, err := r.collection.UpdateMany(
+		ctx,
+		bson.M{"institution_id": bson.M{"$in": from}},
+		bson.M{"$set": bson.M{"institution_id": to, "updated_at": time.Now()}},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
 }
 
 // Update updates a submission
@@ -168,6 +267,58 @@ func (r *RegistrySubmissionRepository) Update(ctx context.Context, id primitive.
 	return nil
 }
 
+// EncryptFormFields encrypts the values of form fields that were marked
+// sensitive after submissions of a schema were stored, and returns the
+// number of submissions updated
+func (r *RegistrySubmissionRepository) EncryptFormFields(ctx context.Context, schemaID primitive.ObjectID, keys []string) (int, error) {
+	if len(keys) == 0 {
+		return 0, nil
+	}
+
+	filter := bson.M{
+		"form_schema_id":   schemaID,
+		"sensitive_fields": bson.M{"$not": bson.M{"$all": keys}},
+	}
+	cursor, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	updated := 0
+	for cursor.Next(ctx) {
+		var submission models.RegistrySubmission
+		if err := cursor.Decode(&submission); err != nil {
+			return updated, err
+		}
+
+		set := bson.M{}
+		for _, key := range keys {
+			value, ok := submission.FormData[key]
+			if !ok {
+				continue
+			}
+			encrypted, err := fieldcrypt.EncryptValue(r.cipher, value)
+			if err != nil {
+				return updated, fmt.Errorf("failed to encrypt field %s: %w", key, err)
+			}
+			if encrypted != value {
+				set["form_data."+key] = encrypted
+			}
+		}
+
+		update := bson.M{"$addToSet": bson.M{"sensitive_fields": bson.M{"$each": keys}}}
+		if len(set) > 0 {
+			update["$set"] = set
+		}
+		if _, err := r.collection.UpdateOne(ctx, bson.M{"_id": submission.ID}, update); err != nil {
+			return updated, err
+		}
+		updated++
+	}
+	return updated, cursor.Err()
+}
+
 // UpdateStatus updates the status of a submission
 func (r *RegistrySubmissionRepository) UpdateStatus(ctx context.Context, id primitive.ObjectID, status models.SubmissionStatus, reviewedBy *primitive.ObjectID, notes string) error {
 	now := time.Now()
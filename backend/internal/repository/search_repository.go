@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"backend/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// SearchRepository handles the full-text index of library documents
+type SearchRepository struct {
+	collection *mongo.Collection
+}
+
+// NewSearchRepository creates a new SearchRepository
+func NewSearchRepository(db *mongo.Database) *SearchRepository {
+	collection := db.Collection("search_documents")
+
+	ensureIndexes(collection, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "category_id", Value: 1}, {Key: "path", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "name", Value: "text"}, {Key: "content", Value: "text"}},
+			Options: options.Index().
+				SetName("search_text").
+				SetWeights(bson.D{{Key: "name", Value: 5}, {Key: "content", Value: 1}}).
+				SetDefaultLanguage("english"),
+		},
+	})
+
+	return &SearchRepository{
+		collection: collection,
+	}
+}
+
+// Upsert stores a document's text, replacing any earlier version of the file
+func (r *SearchRepository) Upsert(ctx context.Context, doc *models.SearchDocument) error {
+	doc.IndexedAt = time.Now()
+
+	filter := bson.M{"category_id": doc.CategoryID, "path": doc.Path}
+	update := bson.M{
+		"$set": bson.M{
+			"library_id":    doc.LibraryID,
+			"name":          doc.Name,
+			"content":       doc.Content,
+			"size":          doc.Size,
+			"modified_time": doc.ModifiedTime,
+			"extract_error": doc.ExtractError,
+			"indexed_at":    doc.IndexedAt,
+		},
+	}
+
+	_, err := r.collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	return err
+}
+
+// ListByCategory returns the indexed documents of a category without their text
+func (r *SearchRepository) ListByCategory(ctx context.Context, categoryID primitive.ObjectID) ([]*models.SearchDocument, error) {
+	opts := options.Find().SetProjection(bson.M{"content": 0})
+
+	cursor, err := r.collection.Find(ctx, bson.M{"category_id": categoryID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	docs := []*models.SearchDocument{}
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	return docs, nil
+}
+
+// DeleteMissing removes a category's documents whose files are no longer present
+func (r *SearchRepository) DeleteMissing(ctx context.Context, categoryID primitive.ObjectID, keepPaths []string) (int64, error) {
+	result, err := r.collection.DeleteMany(ctx, bson.M{
+		"category_id": categoryID,
+		"path":        bson.M{"$nin": keepPaths},
+	})
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
+
+// DeleteOtherCategories removes the documents of categories that no longer exist
+func (r *SearchRepository) DeleteOtherCategories(ctx context.Context, categoryIDs []primitive.ObjectID) (int64, error) {
+	result, err := r.collection.DeleteMany(ctx, bson.M{"category_id": bson.M{"$nin": categoryIDs}})
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
+
+// Search runs a text search restricted to the given categories, best matches first
+func (r *SearchRepository) Search(ctx context.Context, query string, categoryIDs []primitive.ObjectID, limit int) ([]*models.SearchDocument, error) {
+	filter := bson.M{
+		"$text":       bson.M{"$search": query},
+		"category_id": bson.M{"$in": categoryIDs},
+	}
+	opts := options.Find().
+		SetProjection(bson.M{"score": bson.M{"$meta": "textScore"}}).
+		SetSort(bson.D{{Key: "score", Value: bson.M{"$meta": "textScore"}}}).
+		SetLimit(int64(limit))
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	docs := []*models.SearchDocument{}
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	return docs, nil
+}
+
+// Count returns the number of indexed documents
+func (r *SearchRepository) Count(ctx context.Context) (int64, error) {
+	return r.collection.CountDocuments(ctx, bson.M{})
+}
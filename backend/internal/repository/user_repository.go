@@ -3,10 +3,13 @@ package repository
 import (
 	"context"
 	"errors"
+	"fmt"
 	"strings"
 	"time"
 
+	"backend/internal/fieldcrypt"
 	"backend/internal/models"
+	"backend/internal/pagination"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -21,16 +24,71 @@ var (
 	ErrDuplicateUsername = errors.New("username already exists")
 )
 
-// UserRepository handles database operations for users
+// UserRepository handles database operations for users. Personal data in
+// profiles is encrypted before it is stored and decrypted when read.
 type UserRepository struct {
-	collection *mongo.Collection
+	collection      *mongo.Collection
+	cipher          fieldcrypt.Cipher
+	encryptedFields []fieldcrypt.Field
 }
 
-// NewUserRepository creates a new UserRepository
-func NewUserRepository(db *mongo.Database) *UserRepository {
+// UserSortFields maps the public user sort keys onto document fields
+var UserSortFields = pagination.Fields{
+	pagination.KeyCreatedAt:   {Path: "created_at"},
+	pagination.KeyName:        {Path: "profile.last_name"},
+	pagination.KeyLastLoginAt: {Path: "last_login_at", Nullable: true},
+	pagination.KeyStatus:      {Path: "is_active"},
+}
+
+// UserDefaultSort is the sort applied when a client does not request one
+const UserDefaultSort = "-createdAt"
+
+// NewUserRepository creates a new UserRepository that encrypts personal
+// data with cipher
+func NewUserRepository(db *mongo.Database, cipher fieldcrypt.Cipher) *UserRepository {
+	collection := db.Collection("users")
+
+	// Compound indexes backing the paginated user list
+	ensureIndexes(collection, []mongo.IndexModel{
+		{Keys: pagination.IndexKeys(UserSortFields, "-createdAt")},
+		{Keys: pagination.IndexKeys(UserSortFields, "-createdAt", "is_active")},
+		{Keys: pagination.IndexKeys(UserSortFields, "-createdAt", "role")},
+		{Keys: pagination.IndexKeys(UserSortFields, "name")},
+		{Keys: pagination.IndexKeys(UserSortFields, "-lastLoginAt")},
+		{Keys: bson.D{{Key: "profile.registration_number_index", Value: 1}}},
+		{Keys: bson.D{{Key: "profile.phone_number_index", Value: 1}}},
+	})
+
 	return &UserRepository{
-		collection: db.Collection("users"),
+		collection:      collection,
+		cipher:          cipher,
+		encryptedFields: fieldcrypt.Fields(models.User{}),
+	}
+}
+
+// decrypt decrypts a user's personal data after it is read. A field that
+// cannot be decrypted, such as one encrypted with a key since removed, is
+// kept as stored.
+func (r *UserRepository) decrypt(user *models.User) {
+	if err := fieldcrypt.DecryptStruct(r.cipher, user); err != nil {
+		fmt.Printf("Warning: failed to decrypt personal data of user %s: %v\n", user.ID.Hex(), err)
+	}
+}
+
+// PersonalDataFilters returns filters matching users whose registration or
+// phone number equals value, through their blind indexes
+func (r *UserRepository) PersonalDataFilters(value string) []bson.M {
+	filters := []bson.M{}
+	index := fieldcrypt.Index(r.cipher, value)
+	if index == "" {
+		return filters
 	}
+	for _, field := range r.encryptedFields {
+		if field.Index != "" {
+			filters = append(filters, bson.M{field.Index: index})
+		}
+	}
+	return filters
 }
 
 // CreateIndexes creates necessary indexes for the users collection
@@ -64,7 +122,13 @@ func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
 	user.CreatedAt = time.Now()
 	user.UpdatedAt = time.Now()
 
-	result, err := r.collection.InsertOne(ctx, user)
+	// Encrypt a copy, so the caller keeps the plaintext
+	stored := *user
+	if err := fieldcrypt.EncryptStruct(r.cipher, &stored); err != nil {
+		return err
+	}
+
+	result, err := r.collection.InsertOne(ctx, &stored)
 	if err != nil {
 		if mongo.IsDuplicateKeyError(err) {
 			// Check which field caused the duplicate
@@ -89,6 +153,7 @@ func (r *UserRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*
 		}
 		return nil, err
 	}
+	r.decrypt(&user)
 	return &user, nil
 }
 
@@ -104,6 +169,7 @@ func (r *UserRepository) FindByEmail(ctx context.Context, email string) (*models
 		}
 		return nil, err
 	}
+	r.decrypt(&user)
 	return &user, nil
 }
 
@@ -117,12 +183,16 @@ func (r *UserRepository) FindByUsername(ctx context.Context, username string) (*
 		}
 		return nil, err
 	}
+	r.decrypt(&user)
 	return &user, nil
 }
 
 // Update updates a user
 func (r *UserRepository) Update(ctx context.Context, id primitive.ObjectID, update bson.M) error {
 	update["updated_at"] = time.Now()
+	if err := fieldcrypt.EncryptUpdate(r.cipher, r.encryptedFields, update); err != nil {
+		return err
+	}
 
 	result, err := r.collection.UpdateOne(
 		ctx,
@@ -177,15 +247,43 @@ func (r *UserRepository) List(ctx context.Context, filter bson.M, limit, skip in
 	if err := cursor.All(ctx, &users); err != nil {
 		return nil, err
 	}
+	for _, user := range users {
+		r.decrypt(user)
+	}
 
 	return users, nil
 }
 
+// ListPage retrieves one page of users using cursor pagination
+func (r *UserRepository) ListPage(ctx context.Context, filter bson.M, req pagination.Request) (*pagination.Page[*models.User], error) {
+	page, err := pagination.Find[*models.User](ctx, r.collection, filter, req, UserSortFields)
+	if err != nil {
+		return nil, err
+	}
+	for _, user := range page.Items {
+		r.decrypt(user)
+	}
+	return page, nil
+}
+
 // Count counts users matching a filter
 func (r *UserRepository) Count(ctx context.Context, filter bson.M) (int64, error) {
 	return r.collection.CountDocuments(ctx, filter)
 }
 
+// ReassignInstitution re-points users' profiles from the given institutions to another
+func (r *UserRepository) ReassignInstitution(ctx context.Context, from []primitive.ObjectID, to primitive.ObjectID) (int64, error) {
+	result, err := r.collection.UpdateMany(
+		ctx,
+		bson.M{"profile.institution_id": bson.M{"$in": from}},
+		bson.M{"$set": bson.M{"profile.institution_id": to, "updated_at": time.Now()}},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
 // UpdateLastLogin updates the last login timestamp
 func (r *UserRepository) UpdateLastLogin(ctx context.Context, id primitive.ObjectID) error {
 	now := time.Now()
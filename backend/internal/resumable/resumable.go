@@ -0,0 +1,437 @@
+// Package resumable keeps partly uploaded files on disk so that a client can
+// send a large file in chunks and carry on from where it stopped after a
+// dropped connection.
+//
+// A client creates an upload with the file's name and size, then appends
+// chunks at the offset the store reports. Each upload is kept as two files in
+// the store's folder: <id>.json with its details and <id>.part with the bytes
+// received so far. The offset is the size of the .part file, so bytes written
+// before a disconnect or restart are never lost or counted twice.
+//
+// Each owner may only have a limited number of uploads in progress, adding up
+// to a limited size, so that one user cannot fill the disk with uploads they
+// never finish.
+package resumable
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrNotFound       = errors.New("upload not found or expired")
+	ErrOffsetMismatch = errors.New("upload offset does not match the bytes received")
+	ErrTooLarge       = errors.New("upload is larger than the declared or allowed size")
+	ErrInvalidSize    = errors.New("upload size must be greater than zero")
+	ErrInvalidName    = errors.New("upload file name is required")
+	ErrIncomplete     = errors.New("upload is not complete")
+	ErrBusy           = errors.New("another chunk is being written to this upload")
+	ErrTooManyUploads = errors.New("too many uploads in progress; finish or cancel one first")
+	ErrQuotaExceeded  = errors.New("uploads in progress would exceed the allowed total size")
+)
+
+// Defaults used by FromEnv. Single files are capped somewhat above Dropbox's
+// 150MB single upload limit, which is what large scanned documents need.
+const (
+	DefaultMaxSize      = 200 << 20 // 200 MB
+	DefaultMaxOpen      = 10
+	DefaultMaxOwnerSize = 500 << 20 // 500 MB
+	DefaultTTL          = 24 * time.Hour
+)
+
+// Limits bounds the uploads a store accepts. A limit of zero or less is not
+// enforced.
+type Limits struct {
+	MaxSize      int64 // Largest single upload
+	MaxOpen      int   // Most uploads one owner may have in progress
+	MaxOwnerSize int64 // Most bytes one owner's uploads in progress may add up to
+}
+
+// Upload is a file being uploaded in chunks
+type Upload struct {
+	ID        string    `json:"id"`
+	OwnerID   string    `json:"ownerId"`
+	FileName  string    `json:"fileName"`
+	Size      int64     `json:"size"`
+	Offset    int64     `json:"offset"` // Bytes received so far
+	CreatedAt time.Time `json:"createdAt"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// Complete reports whether every byte has been received
+func (u *Upload) Complete() bool {
+	return u.Offset == u.Size
+}
+
+// Store keeps uploads in a folder
+type Store struct {
+	dir    string
+	limits Limits
+	ttl    time.Duration
+	now    func() time.Time
+
+	mu   sync.Mutex
+	busy map[string]bool
+
+	// createMu makes checking an owner's limits and creating their upload
+	// one step
+	createMu sync.Mutex
+}
+
+// New creates a store in dir, creating the folder if needed. Uploads must
+// stay within limits and are removed by Cleanup ttl after they are created.
+func New(dir string, limits Limits, ttl time.Duration) (*Store, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create upload folder: %w", err)
+	}
+	return &Store{
+		dir:    dir,
+		limits: limits,
+		ttl:    ttl,
+		now:    time.Now,
+		busy:   map[string]bool{},
+	}, nil
+}
+
+// FromEnv creates a store from UPLOAD_SESSION_DIR (default: a folder in the
+// system temp folder), UPLOAD_MAX_SIZE_MB (default 200),
+// UPLOAD_MAX_OPEN_PER_USER (default 10) and UPLOAD_MAX_USER_SIZE_MB
+// (default 500)
+func FromEnv() (*Store, error) {
+	dir := os.Getenv("UPLOAD_SESSION_DIR")
+	if dir == "" {
+		dir = filepath.Join(os.TempDir(), "registry-uploads")
+	}
+	maxSize, err := envInt("UPLOAD_MAX_SIZE_MB", DefaultMaxSize>>20)
+	if err != nil {
+		return nil, err
+	}
+	maxOpen, err := envInt("UPLOAD_MAX_OPEN_PER_USER", DefaultMaxOpen)
+	if err != nil {
+		return nil, err
+	}
+	maxOwnerSize, err := envInt("UPLOAD_MAX_USER_SIZE_MB", DefaultMaxOwnerSize>>20)
+	if err != nil {
+		return nil, err
+	}
+	return New(dir, Limits{
+		MaxSize:      maxSize << 20,
+		MaxOpen:      int(maxOpen),
+		MaxOwnerSize: maxOwnerSize << 20,
+	}, DefaultTTL)
+}
+
+// envInt reads a positive number from an environment variable
+func envInt(name string, fallback int64) (int64, error) {
+	v := os.Getenv(name)
+	if v == "" {
+		return fallback, nil
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid %s %q", name, v)
+	}
+	return n, nil
+}
+
+// MaxSize returns the largest upload the store accepts
+func (s *Store) MaxSize() int64 {
+	return s.limits.MaxSize
+}
+
+// Create starts an upload of a file of the given name and size. It fails
+// with ErrTooManyUploads or ErrQuotaExceeded when the owner's uploads in
+// progress are already at their limits.
+func (s *Store) Create(ownerID, fileName string, size int64) (*Upload, error) {
+	fileName = cleanName(fileName)
+	if fileName == "" {
+		return nil, ErrInvalidName
+	}
+	if size <= 0 {
+		return nil, ErrInvalidSize
+	}
+	if s.limits.MaxSize > 0 && size > s.limits.MaxSize {
+		return nil, ErrTooLarge
+	}
+
+	s.createMu.Lock()
+	defer s.createMu.Unlock()
+
+	open, reserved, err := s.ownerUsage(ownerID)
+	if err != nil {
+		return nil, err
+	}
+	if s.limits.MaxOpen > 0 && open >= s.limits.MaxOpen {
+		return nil, ErrTooManyUploads
+	}
+	if s.limits.MaxOwnerSize > 0 && reserved+size > s.limits.MaxOwnerSize {
+		return nil, ErrQuotaExceeded
+	}
+
+	id, err := newID()
+	if err != nil {
+		return nil, err
+	}
+	now := s.now().UTC()
+	upload := &Upload{
+		ID:        id,
+		OwnerID:   ownerID,
+		FileName:  fileName,
+		Size:      size,
+		CreatedAt: now,
+		ExpiresAt: now.Add(s.ttl),
+	}
+
+	part, err := os.OpenFile(s.partPath(id), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create upload: %w", err)
+	}
+	part.Close()
+
+	data, err := json.Marshal(upload)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(s.infoPath(id), data, 0o600); err != nil {
+		os.Remove(s.partPath(id))
+		return nil, fmt.Errorf("failed to create upload: %w", err)
+	}
+	return upload, nil
+}
+
+// ownerUsage returns how many uploads an owner has in progress and the
+// declared size they add up to
+func (s *Store) ownerUsage(ownerID string) (int, int64, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to list uploads: %w", err)
+	}
+
+	open, reserved := 0, int64(0)
+	for _, entry := range entries {
+		id, ok := strings.CutSuffix(entry.Name(), ".json")
+		if !ok || !validID(id) {
+			continue
+		}
+		upload, err := s.Get(id)
+		if err == ErrNotFound {
+			continue
+		}
+		if err != nil {
+			return 0, 0, err
+		}
+		if upload.OwnerID == ownerID {
+			open++
+			reserved += upload.Size
+		}
+	}
+	return open, reserved, nil
+}
+
+// Get returns an upload with its current offset
+func (s *Store) Get(id string) (*Upload, error) {
+	if !validID(id) {
+		return nil, ErrNotFound
+	}
+	data, err := os.ReadFile(s.infoPath(id))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to read upload: %w", err)
+	}
+	var upload Upload
+	if err := json.Unmarshal(data, &upload); err != nil {
+		return nil, fmt.Errorf("failed to read upload: %w", err)
+	}
+	if !s.now().Before(upload.ExpiresAt) {
+		return nil, ErrNotFound
+	}
+
+	info, err := os.Stat(s.partPath(id))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to read upload: %w", err)
+	}
+	upload.Offset = info.Size()
+	return &upload, nil
+}
+
+// Append writes a chunk read from r at offset, which must be the upload's
+// current offset. If r fails part way, the bytes already read are kept and
+// the upload is returned with its new offset along with the error, so the
+// client can resume from there. A chunk running past the declared size is
+// rejected whole with ErrTooLarge.
+func (s *Store) Append(id string, offset int64, r io.Reader) (*Upload, error) {
+	if !s.lock(id) {
+		return nil, ErrBusy
+	}
+	defer s.unlock(id)
+
+	upload, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if offset != upload.Offset {
+		return upload, ErrOffsetMismatch
+	}
+
+	part, err := os.OpenFile(s.partPath(id), os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open upload: %w", err)
+	}
+	defer part.Close()
+	if _, err := part.Seek(offset, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to open upload: %w", err)
+	}
+
+	written, copyErr := io.Copy(part, io.LimitReader(r, upload.Size-offset))
+	if copyErr == nil {
+		// Anything left in the chunk runs past the declared size
+		var extra [1]byte
+		if n, _ := io.ReadFull(r, extra[:]); n > 0 {
+			if err := part.Truncate(offset); err != nil {
+				return nil, fmt.Errorf("failed to reject chunk: %w", err)
+			}
+			return upload, ErrTooLarge
+		}
+	}
+	if err := part.Sync(); err != nil && copyErr == nil {
+		copyErr = err
+	}
+
+	upload.Offset = offset + written
+	if copyErr != nil {
+		return upload, fmt.Errorf("upload interrupted at offset %d: %w", upload.Offset, copyErr)
+	}
+	return upload, nil
+}
+
+// Open opens a complete upload for reading. The caller closes the file.
+func (s *Store) Open(id string) (*os.File, *Upload, error) {
+	upload, err := s.Get(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !upload.Complete() {
+		return nil, upload, ErrIncomplete
+	}
+	file, err := os.Open(s.partPath(id))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open upload: %w", err)
+	}
+	return file, upload, nil
+}
+
+// Delete removes an upload
+func (s *Store) Delete(id string) error {
+	if !validID(id) {
+		return ErrNotFound
+	}
+	err := os.Remove(s.infoPath(id))
+	os.Remove(s.partPath(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// Cleanup removes expired uploads, and data files whose details are missing,
+// returning how many uploads were removed
+func (s *Store) Cleanup() (int, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list uploads: %w", err)
+	}
+
+	removed := 0
+	for _, entry := range entries {
+		name := entry.Name()
+		id, ext := strings.TrimSuffix(name, path.Ext(name)), path.Ext(name)
+		if !validID(id) || (ext != ".json" && ext != ".part") {
+			continue
+		}
+		if ext == ".part" {
+			if _, err := os.Stat(s.infoPath(id)); errors.Is(err, os.ErrNotExist) {
+				os.Remove(s.partPath(id))
+			}
+			continue
+		}
+		if _, err := s.Get(id); err == ErrNotFound && !s.isBusy(id) {
+			os.Remove(s.infoPath(id))
+			os.Remove(s.partPath(id))
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+func (s *Store) infoPath(id string) string {
+	return filepath.Join(s.dir, id+".json")
+}
+
+func (s *Store) partPath(id string) string {
+	return filepath.Join(s.dir, id+".part")
+}
+
+func (s *Store) lock(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.busy[id] {
+		return false
+	}
+	s.busy[id] = true
+	return true
+}
+
+func (s *Store) unlock(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.busy, id)
+}
+
+func (s *Store) isBusy(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.busy[id]
+}
+
+// cleanName reduces a client-supplied file name to its last element
+func cleanName(name string) string {
+	name = path.Base(strings.ReplaceAll(strings.TrimSpace(name), "\\", "/"))
+	if name == "." || name == "/" || name == ".." {
+		return ""
+	}
+	return name
+}
+
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate upload ID: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// validID reports whether id could have come from newID, so that it is safe
+// to use in a file name
+func validID(id string) bool {
+	if len(id) != 32 {
+		return false
+	}
+	_, err := hex.DecodeString(id)
+	return err == nil && strings.ToLower(id) == id
+}
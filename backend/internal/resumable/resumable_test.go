@@ -0,0 +1,207 @@
+package resumable
+
+import (
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newStore(t *testing.T) *Store {
+	t.Helper()
+	s, err := New(t.TempDir(), Limits{MaxSize: 16}, time.Hour)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return s
+}
+
+// brokenReader returns its data and then fails, like a dropped connection
+type brokenReader struct{ data io.Reader }
+
+func (r brokenReader) Read(p []byte) (int, error) {
+	n, err := r.data.Read(p)
+	if err == io.EOF {
+		return n, errors.New("connection reset")
+	}
+	return n, err
+}
+
+func TestCreate(t *testing.T) {
+	s := newStore(t)
+
+	tests := []struct {
+		name     string
+		fileName string
+		size     int64
+		want     string
+		wantErr  error
+	}{
+		{name: "valid", fileName: "Scan.pdf", size: 10, want: "Scan.pdf"},
+		{name: "path is dropped", fileName: "../../etc/Scan.pdf", size: 10, want: "Scan.pdf"},
+		{name: "windows path is dropped", fileName: `C:\Users\me\Scan.pdf`, size: 10, want: "Scan.pdf"},
+		{name: "largest allowed", fileName: "Scan.pdf", size: 16, want: "Scan.pdf"},
+		{name: "too large", fileName: "Scan.pdf", size: 17, wantErr: ErrTooLarge},
+		{name: "empty", fileName: "Scan.pdf", size: 0, wantErr: ErrInvalidSize},
+		{name: "no name", fileName: " ", size: 10, wantErr: ErrInvalidName},
+		{name: "dot dot", fileName: "..", size: 10, wantErr: ErrInvalidName},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upload, err := s.Create("user", tt.fileName, tt.size)
+			if err != tt.wantErr {
+				t.Fatalf("Create() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if upload.FileName != tt.want || upload.Offset != 0 || upload.Size != tt.size || !validID(upload.ID) {
+				t.Errorf("Create() = %+v", upload)
+			}
+		})
+	}
+}
+
+func TestCreateOwnerLimits(t *testing.T) {
+	s, err := New(t.TempDir(), Limits{MaxSize: 16, MaxOpen: 2, MaxOwnerSize: 24}, time.Hour)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	now := time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+
+	first, err := s.Create("user", "One.pdf", 16)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		owner   string
+		size    int64
+		wantErr error
+	}{
+		{name: "past the owner's total size", owner: "user", size: 9, wantErr: ErrQuotaExceeded},
+		{name: "within the owner's total size", owner: "user", size: 8},
+		{name: "past the owner's open uploads", owner: "user", size: 1, wantErr: ErrTooManyUploads},
+		{name: "other owners are counted apart", owner: "other", size: 16},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := s.Create(tt.owner, "Scan.pdf", tt.size); err != tt.wantErr {
+				t.Fatalf("Create() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	// Cancelled and expired uploads no longer count
+	if err := s.Delete(first.ID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := s.Create("user", "Two.pdf", 16); err != nil {
+		t.Errorf("Create() after a cancel error = %v", err)
+	}
+	now = now.Add(2 * time.Hour)
+	if _, err := s.Create("user", "Three.pdf", 16); err != nil {
+		t.Errorf("Create() after uploads expired error = %v", err)
+	}
+}
+
+func TestAppend(t *testing.T) {
+	s := newStore(t)
+	upload, err := s.Create("user", "Scan.pdf", 10)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	// Each step runs against the state the previous steps left
+	steps := []struct {
+		name       string
+		offset     int64
+		chunk      io.Reader
+		wantOffset int64
+		wantErr    error
+		wantFail   bool // an error other than the store's own
+	}{
+		{name: "first chunk", offset: 0, chunk: strings.NewReader("hel"), wantOffset: 3},
+		{name: "stale offset", offset: 0, chunk: strings.NewReader("hel"), wantOffset: 3, wantErr: ErrOffsetMismatch},
+		{name: "offset ahead", offset: 5, chunk: strings.NewReader("lo"), wantOffset: 3, wantErr: ErrOffsetMismatch},
+		{name: "dropped connection keeps bytes", offset: 3, chunk: brokenReader{strings.NewReader("lo")}, wantOffset: 5, wantFail: true},
+		{name: "past the size is rejected whole", offset: 5, chunk: strings.NewReader("world!"), wantOffset: 5, wantErr: ErrTooLarge},
+		{name: "resume", offset: 5, chunk: strings.NewReader("world"), wantOffset: 10},
+		{name: "nothing left", offset: 10, chunk: strings.NewReader(""), wantOffset: 10},
+	}
+	for _, step := range steps {
+		got, err := s.Append(upload.ID, step.offset, step.chunk)
+		switch {
+		case step.wantFail && (err == nil || errors.Is(err, ErrOffsetMismatch) || errors.Is(err, ErrTooLarge)):
+			t.Fatalf("%s: Append() error = %v, want a read error", step.name, err)
+		case !step.wantFail && err != step.wantErr:
+			t.Fatalf("%s: Append() error = %v, want %v", step.name, err, step.wantErr)
+		}
+		if current, _ := s.Get(upload.ID); got == nil || got.Offset != step.wantOffset || current.Offset != step.wantOffset {
+			t.Fatalf("%s: offset = %+v, stored %+v, want %d", step.name, got, current, step.wantOffset)
+		}
+	}
+
+	file, done, err := s.Open(upload.ID)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer file.Close()
+	data, _ := io.ReadAll(file)
+	if string(data) != "helloworld" || !done.Complete() {
+		t.Errorf("Open() = %q, %+v, want %q", data, done, "helloworld")
+	}
+}
+
+func TestOpenIncomplete(t *testing.T) {
+	s := newStore(t)
+	upload, _ := s.Create("user", "Scan.pdf", 10)
+	if _, err := s.Append(upload.ID, 0, strings.NewReader("hello")); err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+	if _, got, err := s.Open(upload.ID); err != ErrIncomplete || got.Offset != 5 {
+		t.Errorf("Open() = %+v, %v, want offset 5 and %v", got, err, ErrIncomplete)
+	}
+}
+
+func TestGetInvalidID(t *testing.T) {
+	s := newStore(t)
+	for _, id := range []string{"", "../secrets", strings.Repeat("A", 32), strings.Repeat("0", 31)} {
+		if _, err := s.Get(id); err != ErrNotFound {
+			t.Errorf("Get(%q) error = %v, want %v", id, err, ErrNotFound)
+		}
+	}
+}
+
+func TestCleanup(t *testing.T) {
+	s := newStore(t)
+	now := time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+
+	old, _ := s.Create("user", "Old.pdf", 10)
+	now = now.Add(30 * time.Minute)
+	recent, _ := s.Create("user", "Recent.pdf", 10)
+	orphan := strings.Repeat("ab", 16)
+	os.WriteFile(s.partPath(orphan), []byte("x"), 0o600)
+	now = now.Add(45 * time.Minute)
+
+	if _, err := s.Get(old.ID); err != ErrNotFound {
+		t.Errorf("Get() of an expired upload error = %v, want %v", err, ErrNotFound)
+	}
+	removed, err := s.Cleanup()
+	if err != nil || removed != 1 {
+		t.Fatalf("Cleanup() = %d, %v, want 1", removed, err)
+	}
+	if _, err := s.Get(recent.ID); err != nil {
+		t.Errorf("Cleanup() removed an upload that has not expired: %v", err)
+	}
+	for _, p := range []string{s.infoPath(old.ID), s.partPath(old.ID), s.partPath(orphan)} {
+		if _, err := os.Stat(p); !os.IsNotExist(err) {
+			t.Errorf("Cleanup() left %s", p)
+		}
+	}
+}
@@ -1,9 +1,13 @@
 package server
 
 import (
+	"context"
+	"fmt"
 	"net/http"
 	"os"
+	"time"
 
+	"backend/internal/geo"
 	"backend/internal/handlers"
 	"backend/internal/middleware"
 	"backend/internal/models"
@@ -14,6 +18,10 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// institutionBackfillTimeout bounds the startup backfill of submission
+// institutions
+const institutionBackfillTimeout = 10 * time.Minute
+
 func (s *Server) RegisterRoutes() http.Handler {
 	r := gin.Default()
 
@@ -36,46 +44,97 @@ func (s *Server) RegisterRoutes() http.Handler {
 	// Get MongoDB database
 	db := s.db.GetDB()
 
+	// Initialize encryption service
+	encryptionService, err := service.NewEncryptionService()
+	if err != nil {
+		panic("Failed to initialize encryption service: " + err.Error())
+	}
+
 	// Initialize repositories
-	userRepo := repository.NewUserRepository(db)
+	userRepo := repository.NewUserRepository(db, encryptionService)
 	sessionRepo := repository.NewSessionRepository(db)
 	auditRepo := repository.NewAuditRepository(db)
 	institutionRepo := repository.NewInstitutionRepository(db)
-	sopCategoryRepo := repository.NewSOPCategoryRepository(db)
-	workingPartyCategoryRepo := repository.NewWorkingPartyCategoryRepository(db)
+	libraryRepo := repository.NewLibraryRepository(db)
+	libraryCategoryRepo := repository.NewLibraryCategoryRepository(db)
 	dropboxConfigRepo := repository.NewDropboxConfigRepository(db)
+	dropboxAuthRequestRepo := repository.NewDropboxAuthRequestRepository(db)
 	registryConfigRepo := repository.NewRegistryConfigRepository(db)
 	registryFormRepo := repository.NewRegistryFormRepository(db)
-	registrySubmissionRepo := repository.NewRegistrySubmissionRepository(db)
+	registrySubmissionRepo := repository.NewRegistrySubmissionRepository(db, encryptionService)
 	referralConfigRepo := repository.NewReferralConfigRepository(db)
 	passwordResetRepo := repository.NewPasswordResetRepository(db)
+	institutionImportRepo := repository.NewInstitutionImportRepository(db)
+	imageRepo := repository.NewImageRepository(db)
+	searchRepo := repository.NewSearchRepository(db)
+	dropboxListingRepo := repository.NewDropboxListingRepository(db)
+	documentRevisionRepo := repository.NewDocumentRevisionRepository(db)
+	documentFeedVisitRepo := repository.NewDocumentFeedVisitRepository(db)
+	acknowledgementRepo := repository.NewAcknowledgementRepository(db)
+	documentMetadataRepo := repository.NewDocumentMetadataRepository(db)
+	documentDownloadRepo := repository.NewDocumentDownloadRepository(db)
+	analyticsSettingsRepo := repository.NewAnalyticsSettingsRepository(db)
+	dropboxHealthRepo := repository.NewDropboxHealthRepository(db)
+	categoryReconciliationRepo := repository.NewCategoryReconciliationRepository(db)
 
 	// Initialize services
 	authService := service.NewAuthService(userRepo, sessionRepo, auditRepo)
-	institutionService := service.NewInstitutionService(institutionRepo, userRepo, auditRepo)
 	auditService := service.NewAuditService(auditRepo, userRepo)
 
-	// Initialize encryption service
-	encryptionService, err := service.NewEncryptionService()
-	if err != nil {
-		panic("Failed to initialize encryption service: " + err.Error())
+	// Initialize Dropbox services, one client per named Dropbox connection
+	dropboxConnections := service.NewDropboxConnectionService(dropboxConfigRepo, encryptionService)
+	documentEvents := service.NewDocumentEventBus()
+	dropboxListingService := service.NewDropboxListingService(dropboxListingRepo, dropboxConnections, documentEvents)
+	dropboxOAuthService := service.NewDropboxOAuthService(dropboxConfigRepo, dropboxAuthRequestRepo, auditRepo, libraryRepo, registryConfigRepo, encryptionService, dropboxConnections, dropboxListingService)
+	dropboxWebhookService := service.NewDropboxWebhookService(dropboxConnections, dropboxListingService, libraryRepo, libraryCategoryRepo)
+	imageService := service.NewImageService(imageRepo, institutionRepo, libraryCategoryRepo)
+
+	// Storage drivers for libraries and registry submissions: Dropbox, plus
+	// local disk and S3 storage when configured in the environment
+	storageService := service.NewStorageService(dropboxConnections, dropboxListingService, libraryRepo, registryConfigRepo)
+
+	libraryService := service.NewLibraryService(libraryRepo, libraryCategoryRepo, storageService, auditRepo)
+	downloadAnalyticsService := service.NewDownloadAnalyticsService(documentDownloadRepo, analyticsSettingsRepo, institutionRepo, libraryRepo, libraryCategoryRepo, storageService, auditRepo)
+	libraryCategoryService := service.NewLibraryCategoryService(libraryService, libraryCategoryRepo, storageService, dropboxListingService, documentMetadataRepo, auditRepo, imageService, downloadAnalyticsService)
+	documentFileService := service.NewDocumentFileService(libraryCategoryService, storageService, dropboxListingService, auditRepo)
+	documentRevisionService := service.NewDocumentRevisionService(documentRevisionRepo, documentFeedVisitRepo, libraryRepo, libraryCategoryRepo, libraryCategoryService, dropboxConnections)
+	documentEvents.Subscribe(documentRevisionService.HandleDocumentEvents)
+
+	// Create the built-in SOP and working party libraries, migrating their
+	// categories from the legacy collections on first start
+	if err := libraryService.EnsureBuiltinLibraries(context.Background()); err != nil {
+		fmt.Printf("Warning: Failed to set up built-in libraries: %v\n", err)
 	}
 
-	// Initialize Dropbox services
-	dropboxService := service.NewDropboxService(dropboxConfigRepo, encryptionService)
-	dropboxOAuthService := service.NewDropboxOAuthService(dropboxConfigRepo, auditRepo, encryptionService, dropboxService)
-	sopCategoryService := service.NewSOPCategoryService(sopCategoryRepo, dropboxService, auditRepo, userRepo)
-	workingPartyCategoryService := service.NewWorkingPartyCategoryService(workingPartyCategoryRepo, dropboxService, auditRepo, userRepo)
-
 	// Initialize Dropbox background refresh service
-	dropboxRefreshService := service.NewDropboxRefreshService(dropboxService)
+	dropboxRefreshService := service.NewDropboxRefreshService(dropboxConnections)
 	dropboxRefreshService.Start()
 
 	// Store reference for graceful shutdown
 	s.dropboxRefreshService = dropboxRefreshService
 
-	// Initialize email and registry services
+	// Keep cached Dropbox folder listings in sync
+	dropboxListingService.Start()
+	s.dropboxListingService = dropboxListingService
+
+	// Initialize document search and its background indexer
+	searchService := service.NewSearchService(searchRepo, libraryRepo, libraryCategoryRepo)
+	searchIndexService := service.NewSearchIndexService(searchRepo, libraryRepo, libraryCategoryRepo, dropboxConnections, dropboxListingService)
+	searchIndexService.Start()
+	documentEvents.Subscribe(searchIndexService.HandleDocumentEvents)
+	s.searchIndexService = searchIndexService
+
+	// Report categories whose Dropbox folders were renamed or deleted
+	categoryReconciliationService := service.NewCategoryReconciliationService(categoryReconciliationRepo, libraryRepo, libraryCategoryRepo, auditRepo, dropboxConnections, dropboxListingService)
+	categoryReconciliationService.Start()
+	s.categoryReconciliationService = categoryReconciliationService
+
+	// Initialize email and registry services. Large registry documents can
+	// be uploaded in chunks ahead of a submission.
 	emailService := service.NewEmailService(encryptionService)
+	resumableUploadService := service.NewResumableUploadService()
+	resumableUploadService.Start()
+	s.resumableUploadService = resumableUploadService
 	registryService := service.NewRegistryService(
 		registryConfigRepo,
 		registryFormRepo,
@@ -83,11 +142,81 @@ func (s *Server) RegisterRoutes() http.Handler {
 		userRepo,
 		institutionRepo,
 		auditRepo,
-		dropboxService,
+		storageService,
+		resumableUploadService,
 		emailService,
 	)
+
+	// Encrypt personal data and sensitive form data still stored in
+	// plaintext, such as data saved before it was encrypted
+	personalDataResults, err := service.NewReencryptionService(repository.NewEncryptedFieldRepository(db), encryptionService).EncryptPersonalData(context.Background())
+	if err != nil {
+		fmt.Printf("Warning: Failed to encrypt personal data: %v\n", err)
+	}
+	for _, result := range personalDataResults {
+		if result.Failed > 0 {
+			fmt.Printf("Warning: %d values of %s could not be decrypted\n", result.Failed, result.Field)
+		}
+	}
+	if err := registryService.EncryptSensitiveSubmissions(context.Background()); err != nil {
+		fmt.Printf("Warning: Failed to encrypt sensitive form data: %v\n", err)
+	}
+
+	// Email admins and show a banner when a Dropbox connection degrades
+	dropboxHealthService := service.NewDropboxHealthService(dropboxHealthRepo, userRepo, registryService, emailService)
+	dropboxConnections.SetHealthService(dropboxHealthService)
+
 	userService := service.NewUserService(userRepo, institutionRepo, auditRepo, authService, emailService, registryService)
+
+	// Optional offline gazetteer for geocoding institutions
+	gazetteer, err := geo.LoadGazetteerFromEnv()
+	if err != nil {
+		fmt.Printf("Warning: Failed to load gazetteer, geocoding disabled: %v\n", err)
+	}
+	institutionService := service.NewInstitutionService(institutionRepo, userRepo, registrySubmissionRepo, auditRepo, institutionImportRepo, emailService, registryService, imageService, gazetteer)
+
+	// Record the institution of submissions made before submissions did, so
+	// institution merges reach them. It runs in the background so a large
+	// registry does not hold up startup; later starts only find what is left.
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), institutionBackfillTimeout)
+		defer cancel()
+		if _, err := institutionService.BackfillSubmissionInstitutions(ctx); err != nil {
+			fmt.Printf("Warning: Failed to backfill submission institutions: %v\n", err)
+		}
+	}()
 	referralService := service.NewReferralService(referralConfigRepo, auditRepo)
+	acknowledgementService := service.NewAcknowledgementService(
+		acknowledgementRepo,
+		userRepo,
+		institutionRepo,
+		libraryRepo,
+		libraryCategoryRepo,
+		auditRepo,
+		libraryCategoryService,
+		dropboxConnections,
+		emailService,
+		registryService,
+	)
+	documentEvents.Subscribe(acknowledgementService.HandleDocumentEvents)
+
+	// Initialize document review reminders
+	documentReviewService := service.NewDocumentReviewService(
+		documentMetadataRepo,
+		userRepo,
+		libraryRepo,
+		libraryCategoryRepo,
+		auditRepo,
+		libraryCategoryService,
+		emailService,
+		registryService,
+	)
+	documentReviewService.Start()
+	s.documentReviewService = documentReviewService
+
+	// Remove download events past the analytics retention period
+	downloadAnalyticsService.Start()
+	s.downloadAnalyticsService = downloadAnalyticsService
 
 	// Initialize password reset service
 	jwtSecret := os.Getenv("JWT_SECRET")
@@ -108,14 +237,30 @@ func (s *Server) RegisterRoutes() http.Handler {
 	authHandler := handlers.NewAuthHandler(authService)
 	userHandler := handlers.NewUserHandler(userService)
 	institutionHandler := handlers.NewInstitutionHandler(institutionService)
-	statsHandler := handlers.NewStatsHandler(userService, institutionService, auditService, sopCategoryService)
-	sopCategoryHandler := handlers.NewSOPCategoryHandler(sopCategoryService)
-	workingPartyCategoryHandler := handlers.NewWorkingPartyCategoryHandler(workingPartyCategoryService)
+	statsHandler := handlers.NewStatsHandler(userService, institutionService, auditService, libraryCategoryService)
+	libraryHandler := handlers.NewLibraryHandler(libraryService, libraryCategoryService, documentRevisionService)
+	documentFeedHandler := handlers.NewDocumentFeedHandler(documentRevisionService)
+	acknowledgementHandler := handlers.NewAcknowledgementHandler(acknowledgementService)
+	documentReviewHandler := handlers.NewDocumentReviewHandler(documentReviewService)
+	downloadAnalyticsHandler := handlers.NewDownloadAnalyticsHandler(downloadAnalyticsService)
+	libraryRoutes := libraryRouteHandlers{
+		library:          libraryHandler,
+		files:            handlers.NewDocumentFileHandler(documentFileService),
+		reviews:          documentReviewHandler,
+		acknowledgements: acknowledgementHandler,
+	}
+	searchHandler := handlers.NewSearchHandler(searchService, searchIndexService)
 	dropboxAdminHandler := handlers.NewDropboxAdminHandler(dropboxOAuthService)
+	dropboxCacheHandler := handlers.NewDropboxCacheHandler(dropboxListingService)
+	dropboxWebhookHandler := handlers.NewDropboxWebhookHandler(dropboxWebhookService)
+	storageHandler := handlers.NewStorageHandler(storageService)
 	registryHandler := handlers.NewRegistryHandler(registryService, encryptionService)
+	resumableUploadHandler := handlers.NewResumableUploadHandler(resumableUploadService)
 	referralHandler := handlers.NewReferralHandler(referralService)
 	passwordResetHandler := handlers.NewPasswordResetHandler(passwordResetService)
 	smtpHandler := handlers.NewSMTPHandler(registryService)
+	alertHandler := handlers.NewAlertHandler(dropboxHealthService)
+	categoryReconciliationHandler := handlers.NewCategoryReconciliationHandler(categoryReconciliationService)
 
 	// API routes group
 	api := r.Group("/api")
@@ -157,6 +302,15 @@ func (s *Server) RegisterRoutes() http.Handler {
 
 		// Public institution routes (for registration)
 		api.GET("/institutions/public", institutionHandler.ListPublicInstitutions)
+		api.GET("/institutions/public/facets", institutionHandler.GetInstitutionFacets)
+		api.GET("/institutions/public/nearby", institutionHandler.NearbyInstitutions)
+
+		// Dropbox webhook (public; notifications are verified by signature)
+		api.GET("/webhooks/dropbox", dropboxWebhookHandler.Verify)
+		api.POST("/webhooks/dropbox", dropboxWebhookHandler.Notify)
+
+		// Local storage downloads (public; links are signed and expire)
+		api.GET("/storage/local", storageHandler.ServeLocalFile)
 
 		// Public SMTP status route (for forgot password)
 		api.GET("/smtp/status", smtpHandler.CheckSMTPConfiguration)
@@ -166,13 +320,32 @@ func (s *Server) RegisterRoutes() http.Handler {
 		institutions.Use(middleware.AuthMiddleware(authService))
 		{
 			institutions.GET("", institutionHandler.ListInstitutions)
+			institutions.GET("/nearby", institutionHandler.NearbyInstitutions)
+			institutions.POST("/geocode", middleware.RequirePermission(models.PermManageUsers), institutionHandler.GeocodeInstitutions)
+
+			// Bulk import from CSV/JSON and import history (requires manage users permission)
+			institutions.POST("/import", middleware.RequirePermission(models.PermManageUsers), institutionHandler.ImportInstitutions)
+			institutions.GET("/imports", middleware.RequirePermission(models.PermManageUsers), institutionHandler.ListInstitutionImports)
+			institutions.GET("/imports/:id", middleware.RequirePermission(models.PermManageUsers), institutionHandler.GetInstitutionImport)
 			institutions.GET("/:id", institutionHandler.GetInstitution)
+			institutions.GET("/:id/units", institutionHandler.ListInstitutionUnits)
+			institutions.GET("/:id/path", institutionHandler.GetInstitutionPath)
+			institutions.GET("/:id/stats", middleware.RequirePermission(models.PermManageUsers), institutionHandler.GetInstitutionStats)
 			institutions.POST("", middleware.RequirePermission(models.PermManageUsers), institutionHandler.CreateInstitution)
 			institutions.PUT("/:id", middleware.RequirePermission(models.PermManageUsers), institutionHandler.UpdateInstitution)
 			institutions.DELETE("/:id", middleware.RequirePermission(models.PermDeleteUsers), institutionHandler.DeleteInstitution)
 			institutions.POST("/:id/activate", middleware.RequirePermission(models.PermManageUsers), institutionHandler.ActivateInstitution)
 			institutions.POST("/:id/deactivate", middleware.RequirePermission(models.PermManageUsers), institutionHandler.DeactivateInstitution)
 
+			// Moderation of user-created institutions (requires manage users permission)
+			institutions.GET("/moderation", middleware.RequirePermission(models.PermManageUsers), institutionHandler.ListPendingInstitutions)
+			institutions.POST("/:id/moderate", middleware.RequirePermission(models.PermManageUsers), institutionHandler.ModerateInstitution)
+
+			// Duplicate detection and merging (requires delete users permission)
+			institutions.GET("/duplicates", middleware.RequirePermission(models.PermDeleteUsers), institutionHandler.ListDuplicateCandidates)
+			institutions.POST("/merge/preview", middleware.RequirePermission(models.PermDeleteUsers), institutionHandler.PreviewMerge)
+			institutions.POST("/merge", middleware.RequirePermission(models.PermDeleteUsers), institutionHandler.MergeInstitutions)
+
 			// Institution image upload (requires manage users permission)
 			institutions.POST("/images/upload", middleware.RequirePermission(models.PermManageUsers), institutionHandler.UploadImage)
 
@@ -192,51 +365,52 @@ func (s *Server) RegisterRoutes() http.Handler {
 		{
 			stats.GET("/admin", middleware.RequirePermission(models.PermManageUsers), statsHandler.GetAdminStats)
 			stats.GET("/recent-activity", middleware.RequirePermission(models.PermManageUsers), statsHandler.GetRecentActivity)
+			stats.GET("/audit-logs", middleware.RequirePermission(models.PermViewAuditLogs), statsHandler.ListAuditLogs)
 		}
 
-		// SOP routes
-		sops := api.Group("/sops")
-		sops.Use(middleware.AuthMiddleware(authService))
+		// Document library routes. Category permissions are configured per
+		// library and checked by the library services.
+		libraries := api.Group("/libraries")
+		libraries.Use(middleware.AuthMiddleware(authService))
 		{
-			// Categories (read for all authenticated users, write for super admins)
-			categories := sops.Group("/categories")
+			libraries.GET("", libraryHandler.ListLibraries)
+			libraries.POST("", middleware.RequirePermission(models.PermManageSystem), libraryHandler.CreateLibrary)
+
+			library := libraries.Group("/:library")
 			{
-				categories.GET("", sopCategoryHandler.ListCategories)
-				categories.GET("/:id", sopCategoryHandler.GetCategory)
-				categories.GET("/:id/files", sopCategoryHandler.GetCategoryFiles)
-				categories.GET("/:id/files/download", sopCategoryHandler.DownloadFile)
-
-				categories.POST("", middleware.RequirePermission(models.PermDeleteUsers), sopCategoryHandler.CreateCategory)
-				categories.PUT("/:id", middleware.RequirePermission(models.PermDeleteUsers), sopCategoryHandler.UpdateCategory)
-				categories.DELETE("/:id", middleware.RequirePermission(models.PermDeleteUsers), sopCategoryHandler.DeleteCategory)
+				library.GET("", libraryHandler.GetLibrary)
+				library.PUT("", middleware.RequirePermission(models.PermManageSystem), libraryHandler.UpdateLibrary)
+				library.DELETE("", middleware.RequirePermission(models.PermManageSystem), libraryHandler.DeleteLibrary)
+				registerLibraryRoutes(library, libraryRoutes)
 			}
-
-			// Image upload (super admin only)
-			sops.POST("/images/upload", middleware.RequirePermission(models.PermDeleteUsers), sopCategoryHandler.UploadImage)
-
-			// Seeding (super admin only)
-			sops.POST("/seed", middleware.RequirePermission(models.PermDeleteUsers), sopCategoryHandler.SeedCategories)
 		}
 
-		// Working Parties routes
+		// SOP and Working Parties routes, kept as aliases of their libraries
+		sops := api.Group("/sops")
+		sops.Use(middleware.AuthMiddleware(authService), handlers.LibraryAlias(models.LibrarySlugSOPs))
+		registerLibraryRoutes(sops, libraryRoutes)
+
 		workingParties := api.Group("/working-parties")
-		workingParties.Use(middleware.AuthMiddleware(authService))
-		{
-			wpCategories := workingParties.Group("/categories")
-			{
-				wpCategories.GET("", workingPartyCategoryHandler.ListCategories)
-				wpCategories.GET("/:id", workingPartyCategoryHandler.GetCategory)
-				wpCategories.GET("/:id/files", workingPartyCategoryHandler.GetCategoryFiles)
-				wpCategories.GET("/:id/files/download", workingPartyCategoryHandler.DownloadFile)
-
-				wpCategories.POST("", middleware.RequirePermission(models.PermDeleteUsers), workingPartyCategoryHandler.CreateCategory)
-				wpCategories.PUT("/:id", middleware.RequirePermission(models.PermDeleteUsers), workingPartyCategoryHandler.UpdateCategory)
-				wpCategories.DELETE("/:id", middleware.RequirePermission(models.PermDeleteUsers), workingPartyCategoryHandler.DeleteCategory)
-			}
+		workingParties.Use(middleware.AuthMiddleware(authService), handlers.LibraryAlias(models.LibrarySlugWorkingParties))
+		registerLibraryRoutes(workingParties, libraryRoutes)
+
+		// In-app alert banners
+		api.GET("/alerts", middleware.AuthMiddleware(authService), alertHandler.GetAlerts)
+
+		// Document search (results limited to categories the user can see)
+		api.GET("/search", middleware.AuthMiddleware(authService), searchHandler.Search)
 
-			workingParties.POST("/images/upload", middleware.RequirePermission(models.PermDeleteUsers), workingPartyCategoryHandler.UploadImage)
+		// Recently updated documents across libraries
+		documents := api.Group("/documents")
+		documents.Use(middleware.AuthMiddleware(authService))
+		{
+			documents.GET("/recent", documentFeedHandler.GetRecent)
+			documents.POST("/recent/visit", documentFeedHandler.MarkVisited)
 		}
 
+		// Documents the current user must acknowledge across libraries
+		api.GET("/acknowledgements", middleware.AuthMiddleware(authService), acknowledgementHandler.GetMine)
+
 		// Admin routes (super admin only)
 		admin := api.Group("/admin")
 		admin.Use(middleware.AuthMiddleware(authService))
@@ -251,6 +425,46 @@ func (s *Server) RegisterRoutes() http.Handler {
 				dropbox.POST("/refresh", dropboxAdminHandler.ForceRefresh)
 				dropbox.POST("/test", dropboxAdminHandler.TestConnection)
 				dropbox.DELETE("/configuration", dropboxAdminHandler.DeleteConfiguration)
+
+				// Cached folder listings
+				dropbox.GET("/cache", dropboxCacheHandler.ListCache)
+				dropbox.GET("/cache/entry", dropboxCacheHandler.GetCacheEntry)
+				dropbox.DELETE("/cache", dropboxCacheHandler.InvalidateCache)
+				dropbox.POST("/cache/sync", dropboxCacheHandler.SyncCache)
+				dropbox.GET("/webhook", dropboxWebhookHandler.GetStatus)
+			}
+
+			// Storage drivers
+			admin.GET("/storage", storageHandler.GetStatus)
+
+			// Documents overdue for review
+			admin.GET("/documents/reviews", documentReviewHandler.GetOverdueReport)
+
+			// Document download analytics
+			analytics := admin.Group("/analytics")
+			{
+				analytics.GET("/downloads/top", downloadAnalyticsHandler.GetTopDocuments)
+				analytics.GET("/downloads/timeline", downloadAnalyticsHandler.GetDownloadsOverTime)
+				analytics.GET("/downloads/institutions", downloadAnalyticsHandler.GetDownloadsByInstitution)
+				analytics.GET("/downloads/specialties", downloadAnalyticsHandler.GetDownloadsBySpecialty)
+				analytics.GET("/downloads/never", downloadAnalyticsHandler.GetNeverDownloaded)
+				analytics.GET("/settings", downloadAnalyticsHandler.GetSettings)
+				analytics.PUT("/settings", downloadAnalyticsHandler.UpdateSettings)
+			}
+
+			// Search index
+			search := admin.Group("/search")
+			{
+				search.GET("/status", searchHandler.GetIndexStatus)
+				search.POST("/reindex", searchHandler.Reindex)
+			}
+
+			// Category and Dropbox folder reconciliation
+			reconciliation := admin.Group("/reconciliation")
+			{
+				reconciliation.GET("", categoryReconciliationHandler.GetReports)
+				reconciliation.POST("/run", categoryReconciliationHandler.Run)
+				reconciliation.POST("/fix", categoryReconciliationHandler.Fix)
 			}
 
 			// Registry configuration (super admin only)
@@ -260,6 +474,7 @@ func (s *Server) RegisterRoutes() http.Handler {
 				registry.PUT("/config", registryHandler.UpdateConfiguration)
 				registry.POST("/test-email", registryHandler.SendTestEmail)
 				registry.GET("/submissions", registryHandler.GetAllSubmissions)
+				registry.GET("/submissions/map", registryHandler.GetSubmissionMap)
 				registry.PATCH("/submissions/:id/status", registryHandler.UpdateSubmissionStatus)
 
 				// SMTP-only configuration endpoints
@@ -294,6 +509,11 @@ func (s *Server) RegisterRoutes() http.Handler {
 			registry.GET("/config", registryHandler.GetPublicConfiguration)
 			registry.GET("/form-schema", registryHandler.GetActiveFormSchema)
 			registry.POST("/submit", registryHandler.SubmitForm)
+			registry.POST("/uploads", resumableUploadHandler.CreateUpload)
+			registry.GET("/uploads/:id", resumableUploadHandler.GetUpload)
+			registry.HEAD("/uploads/:id", resumableUploadHandler.GetUpload)
+			registry.PATCH("/uploads/:id", resumableUploadHandler.AppendUpload)
+			registry.DELETE("/uploads/:id", resumableUploadHandler.CancelUpload)
 			registry.GET("/submissions", registryHandler.GetUserSubmissions)
 			registry.GET("/submissions/:id", registryHandler.GetSubmission)
 			registry.GET("/example-documents", registryHandler.GetExampleDocuments)
@@ -323,3 +543,53 @@ func (s *Server) HelloWorldHandler(c *gin.Context) {
 func (s *Server) healthHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, s.db.Health())
 }
+
+// libraryRouteHandlers are the handlers serving a library's routes
+type libraryRouteHandlers struct {
+	library          *handlers.LibraryHandler
+	files            *handlers.DocumentFileHandler
+	reviews          *handlers.DocumentReviewHandler
+	acknowledgements *handlers.AcknowledgementHandler
+}
+
+// registerLibraryRoutes registers a library's category, file, document
+// review, acknowledgement, image and seeding routes on a group addressing
+// one library
+func registerLibraryRoutes(group *gin.RouterGroup, routes libraryRouteHandlers) {
+	h, files, review, ack := routes.library, routes.files, routes.reviews, routes.acknowledgements
+
+	categories := group.Group("/categories")
+	{
+		categories.GET("", h.ListCategories)
+		categories.GET("/:id", h.GetCategory)
+		categories.GET("/:id/files", h.GetCategoryFiles)
+		categories.GET("/:id/files/download", h.DownloadFile)
+		categories.GET("/:id/files/archive", files.DownloadArchive)
+		categories.GET("/:id/files/revisions", h.GetFileRevisions)
+		categories.GET("/:id/files/revisions/:rev/download", h.DownloadFileRevision)
+		categories.GET("/:id/files/metadata", review.GetMetadata)
+		categories.PUT("/:id/files/metadata", review.UpdateMetadata)
+		categories.POST("/:id/files", files.UploadFile)
+		categories.POST("/:id/files/move", files.MoveFile)
+		categories.DELETE("/:id/files", files.DeleteFile)
+		categories.POST("/:id/folders", files.CreateFolder)
+
+		categories.POST("", h.CreateCategory)
+		categories.PUT("/:id", h.UpdateCategory)
+		categories.DELETE("/:id", h.DeleteCategory)
+		categories.POST("/:id/acknowledgements", ack.CreateRequirement)
+	}
+
+	acknowledgements := group.Group("/acknowledgements")
+	{
+		acknowledgements.GET("", ack.ListRequirements)
+		acknowledgements.GET("/report", ack.GetReport)
+		acknowledgements.POST("/reminders", ack.SendReminders)
+		acknowledgements.PUT("/:requirementId", ack.UpdateRequirement)
+		acknowledgements.DELETE("/:requirementId", ack.DeleteRequirement)
+		acknowledgements.POST("/:requirementId/acknowledge", ack.Acknowledge)
+	}
+
+	group.POST("/images/upload", h.UploadImage)
+	group.POST("/seed", h.SeedCategories)
+}
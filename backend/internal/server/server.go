@@ -16,8 +16,14 @@ import (
 type Server struct {
 	port int
 
-	db                    database.Service
-	dropboxRefreshService *service.DropboxRefreshService
+	db                            database.Service
+	dropboxRefreshService         *service.DropboxRefreshService
+	searchIndexService            *service.SearchIndexService
+	dropboxListingService         *service.DropboxListingService
+	documentReviewService         *service.DocumentReviewService
+	downloadAnalyticsService      *service.DownloadAnalyticsService
+	resumableUploadService        *service.ResumableUploadService
+	categoryReconciliationService *service.CategoryReconciliationService
 }
 
 func NewServer() *Server {
@@ -45,3 +51,39 @@ func (s *Server) StopDropboxRefreshService() {
 		s.dropboxRefreshService.Stop()
 	}
 }
+
+func (s *Server) StopSearchIndexService() {
+	if s.searchIndexService != nil {
+		s.searchIndexService.Stop()
+	}
+}
+
+func (s *Server) StopDropboxListingService() {
+	if s.dropboxListingService != nil {
+		s.dropboxListingService.Stop()
+	}
+}
+
+func (s *Server) StopDocumentReviewService() {
+	if s.documentReviewService != nil {
+		s.documentReviewService.Stop()
+	}
+}
+
+func (s *Server) StopDownloadAnalyticsService() {
+	if s.downloadAnalyticsService != nil {
+		s.downloadAnalyticsService.Stop()
+	}
+}
+
+func (s *Server) StopResumableUploadService() {
+	if s.resumableUploadService != nil {
+		s.resumableUploadService.Stop()
+	}
+}
+
+func (s *Server) StopCategoryReconciliationService() {
+	if s.categoryReconciliationService != nil {
+		s.categoryReconciliationService.Stop()
+	}
+}
@@ -0,0 +1,714 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"backend/internal/models"
+	"backend/internal/repository"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrRequirementNotFound  = errors.New("acknowledgement requirement not found")
+	ErrDuplicateRequirement = errors.New("document already requires acknowledgement")
+	ErrNotInAudience        = errors.New("this document does not require your acknowledgement")
+	ErrRevisionOutdated     = errors.New("the document has been revised; read the current revision before acknowledging")
+)
+
+// noInstitutionName labels report rows of users without an institution
+const noInstitutionName = "No institution"
+
+// AcknowledgementReportFilter narrows a compliance report
+type AcknowledgementReportFilter struct {
+	RequirementID *primitive.ObjectID
+	InstitutionID *primitive.ObjectID
+}
+
+// AcknowledgementReminderResult summarises a round of reminder emails
+type AcknowledgementReminderResult struct {
+	Recipients int `json:"recipients"`
+	Sent       int `json:"sent"`
+	Failed     int `json:"failed"`
+}
+
+// AcknowledgementService handles documents that staff must confirm they have
+// read and understood.
+//
+// A requirement follows the current revision of its file: when a listing sync
+// reports a new revision, the requirement moves to it and everyone in its
+// audience must acknowledge again. Earlier acknowledgements are kept as the
+// record of what each user read.
+type AcknowledgementService struct {
+	ackRepo         *repository.AcknowledgementRepository
+	userRepo        *repository.UserRepository
+	institutionRepo *repository.InstitutionRepository
+	libraryRepo     *repository.LibraryRepository
+	categoryRepo    *repository.LibraryCategoryRepository
+	auditRepo       *repository.AuditRepository
+	categoryService *LibraryCategoryService
+	connections     *DropboxConnectionService
+	emailService    *EmailService
+	registryService *RegistryService
+}
+
+// NewAcknowledgementService creates a new AcknowledgementService
+func NewAcknowledgementService(
+	ackRepo *repository.AcknowledgementRepository,
+	userRepo *repository.UserRepository,
+	institutionRepo *repository.InstitutionRepository,
+	libraryRepo *repository.LibraryRepository,
+	categoryRepo *repository.LibraryCategoryRepository,
+	auditRepo *repository.AuditRepository,
+	categoryService *LibraryCategoryService,
+	connections *DropboxConnectionService,
+	emailService *EmailService,
+	registryService *RegistryService,
+) *AcknowledgementService {
+	return &AcknowledgementService{
+		ackRepo:         ackRepo,
+		userRepo:        userRepo,
+		institutionRepo: institutionRepo,
+		libraryRepo:     libraryRepo,
+		categoryRepo:    categoryRepo,
+		auditRepo:       auditRepo,
+		categoryService: categoryService,
+		connections:     connections,
+		emailService:    emailService,
+		registryService: registryService,
+	}
+}
+
+// CreateRequirement marks a file in a category as requiring acknowledgement
+// at its current revision
+func (s *AcknowledgementService) CreateRequirement(
+	ctx context.Context,
+	librarySlug string,
+	categoryID primitive.ObjectID,
+	req *models.CreateAcknowledgementRequirementRequest,
+	createdBy *models.User,
+	ipAddress string,
+) (*models.AcknowledgementRequirement, error) {
+	if strings.Trim(strings.TrimSpace(req.Path), "/") == "" {
+		return nil, models.ErrInvalidAcknowledgementPath
+	}
+	// The path must stay inside the category folder
+	filePath, err := models.CleanDocumentItemPath(req.Path)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Audience.Validate(); err != nil {
+		return nil, err
+	}
+
+	library, category, err := s.categoryService.managedCategory(ctx, librarySlug, categoryID, createdBy)
+	if err != nil {
+		return nil, err
+	}
+
+	// Acknowledgements pin a Dropbox revision and are renewed when Dropbox
+	// reports a change, which the other drivers cannot do
+	if !library.UsesDropbox() {
+		return nil, ErrStorageUnsupported
+	}
+	dropbox := s.connections.ForLibrary(library)
+	if !dropbox.IsConfigured() {
+		return nil, ErrDropboxNotConfigured
+	}
+	revisions, err := dropbox.ListRevisions(categoryFilePath(category.DropboxPath, filePath))
+	if err != nil {
+		if err == ErrFileNotFound {
+			return nil, ErrDocumentNotFound
+		}
+		return nil, fmt.Errorf("failed to get current revision: %w", err)
+	}
+	if len(revisions) == 0 {
+		return nil, ErrDocumentNotFound
+	}
+
+	now := time.Now()
+	requirement := &models.AcknowledgementRequirement{
+		LibraryID:  library.ID,
+		CategoryID: category.ID,
+		Path:       filePath,
+		PathLower:  strings.ToLower(filePath),
+		Name:       path.Base(filePath),
+		Audience:   req.Audience,
+		CurrentRev: revisions[0].Rev,
+		RevisedAt:  now,
+		IsActive:   true,
+		CreatedBy:  &createdBy.ID,
+	}
+	if err := s.ackRepo.CreateRequirement(ctx, requirement); err != nil {
+		if err == repository.ErrDuplicateRequirement {
+			return nil, ErrDuplicateRequirement
+		}
+		return nil, fmt.Errorf("failed to create requirement: %w", err)
+	}
+
+	s.auditRepo.Create(ctx, &models.AuditLog{
+		UserID:      &createdBy.ID,
+		PerformedBy: &createdBy.ID,
+		Action:      models.AuditActionAcknowledgementRequired,
+		Details: bson.M{
+			"library":        library.Slug,
+			"requirement_id": requirement.ID.Hex(),
+			"category_id":    category.ID.Hex(),
+			"path":           requirement.Path,
+			"rev":            requirement.CurrentRev,
+		},
+		IPAddress: ipAddress,
+	})
+
+	return requirement, nil
+}
+
+// ListRequirements returns a library's requirements, optionally only those of
+// one category
+func (s *AcknowledgementService) ListRequirements(
+	ctx context.Context,
+	librarySlug string,
+	categoryID *primitive.ObjectID,
+	user *models.User,
+) ([]*models.AcknowledgementRequirement, error) {
+	library, err := s.categoryService.managedLibrary(ctx, librarySlug, user)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := bson.M{"library_id": library.ID}
+	if categoryID != nil {
+		filter["category_id"] = *categoryID
+	}
+	requirements, err := s.ackRepo.FindRequirements(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list requirements: %w", err)
+	}
+	return requirements, nil
+}
+
+// UpdateRequirement changes a requirement's audience or active status
+func (s *AcknowledgementService) UpdateRequirement(
+	ctx context.Context,
+	librarySlug string,
+	id primitive.ObjectID,
+	req *models.UpdateAcknowledgementRequirementRequest,
+	updatedBy *models.User,
+	ipAddress string,
+) (*models.AcknowledgementRequirement, error) {
+	library, err := s.categoryService.managedLibrary(ctx, librarySlug, updatedBy)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.findRequirement(ctx, library.ID, id); err != nil {
+		return nil, err
+	}
+
+	update := bson.M{}
+	if req.Audience != nil {
+		if err := req.Audience.Validate(); err != nil {
+			return nil, err
+		}
+		update["audience"] = *req.Audience
+	}
+	if req.IsActive != nil {
+		update["is_active"] = *req.IsActive
+	}
+
+	if len(update) > 0 {
+		if err := s.ackRepo.UpdateRequirement(ctx, id, update); err != nil {
+			if err == repository.ErrRequirementNotFound {
+				return nil, ErrRequirementNotFound
+			}
+			return nil, fmt.Errorf("failed to update requirement: %w", err)
+		}
+
+		s.auditRepo.Create(ctx, &models.AuditLog{
+			UserID:      &updatedBy.ID,
+			PerformedBy: &updatedBy.ID,
+			Action:      models.AuditActionAcknowledgementUpdated,
+			Details: bson.M{
+				"library":        library.Slug,
+				"requirement_id": id.Hex(),
+				"changes":        update,
+			},
+			IPAddress: ipAddress,
+		})
+	}
+
+	return s.findRequirement(ctx, library.ID, id)
+}
+
+// DeleteRequirement removes a requirement and the acknowledgements recorded
+// against it
+func (s *AcknowledgementService) DeleteRequirement(
+	ctx context.Context,
+	librarySlug string,
+	id primitive.ObjectID,
+	deletedBy *models.User,
+	ipAddress string,
+) error {
+	library, err := s.categoryService.managedLibrary(ctx, librarySlug, deletedBy)
+	if err != nil {
+		return err
+	}
+	requirement, err := s.findRequirement(ctx, library.ID, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.ackRepo.DeleteRequirement(ctx, id); err != nil {
+		if err == repository.ErrRequirementNotFound {
+			return ErrRequirementNotFound
+		}
+		return fmt.Errorf("failed to delete requirement: %w", err)
+	}
+
+	s.auditRepo.Create(ctx, &models.AuditLog{
+		UserID:      &deletedBy.ID,
+		PerformedBy: &deletedBy.ID,
+		Action:      models.AuditActionAcknowledgementRemoved,
+		Details: bson.M{
+			"library":        library.Slug,
+			"requirement_id": id.Hex(),
+			"path":           requirement.Path,
+		},
+		IPAddress: ipAddress,
+	})
+
+	return nil
+}
+
+// Acknowledge records that the user has read and understood a revision of a
+// document. Only the requirement's current revision can be acknowledged.
+func (s *AcknowledgementService) Acknowledge(
+	ctx context.Context,
+	librarySlug string,
+	id primitive.ObjectID,
+	rev string,
+	user *models.User,
+	ipAddress string,
+) (*models.DocumentAcknowledgement, error) {
+	library, err := s.categoryService.libraryService.GetLibrary(ctx, librarySlug, user)
+	if err != nil {
+		return nil, err
+	}
+	requirement, err := s.findRequirement(ctx, library.ID, id)
+	if err != nil {
+		return nil, err
+	}
+	if !requirement.IsActive {
+		return nil, ErrRequirementNotFound
+	}
+	if _, _, err := s.categoryService.getCategory(ctx, librarySlug, requirement.CategoryID, user); err != nil {
+		return nil, err
+	}
+	if !requirement.Audience.Matches(user) {
+		return nil, ErrNotInAudience
+	}
+	if rev != requirement.CurrentRev {
+		return nil, ErrRevisionOutdated
+	}
+
+	ack, err := s.ackRepo.Acknowledge(ctx, &models.DocumentAcknowledgement{
+		RequirementID: requirement.ID,
+		UserID:        user.ID,
+		Rev:           rev,
+		IPAddress:     ipAddress,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to record acknowledgement: %w", err)
+	}
+
+	s.auditRepo.Create(ctx, &models.AuditLog{
+		UserID:      &user.ID,
+		PerformedBy: &user.ID,
+		Action:      models.AuditActionDocumentAcknowledged,
+		Details: bson.M{
+			"library":        library.Slug,
+			"requirement_id": requirement.ID.Hex(),
+			"path":           requirement.Path,
+			"rev":            rev,
+		},
+		IPAddress: ipAddress,
+	})
+
+	return ack, nil
+}
+
+// MyAcknowledgements returns the documents the user must acknowledge across
+// the libraries they can see, outstanding ones first
+func (s *AcknowledgementService) MyAcknowledgements(ctx context.Context, user *models.User, librarySlug string) ([]models.AcknowledgementItem, error) {
+	visible, err := visibleCategories(ctx, s.libraryRepo, s.categoryRepo, user, librarySlug, (*models.Library).CanView)
+	if err != nil {
+		return nil, err
+	}
+	items := []models.AcknowledgementItem{}
+	if len(visible) == 0 {
+		return items, nil
+	}
+
+	categoryIDs := make([]primitive.ObjectID, 0, len(visible))
+	for id := range visible {
+		categoryIDs = append(categoryIDs, id)
+	}
+	requirements, err := s.ackRepo.FindRequirements(ctx, bson.M{
+		"category_id": bson.M{"$in": categoryIDs},
+		"is_active":   true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list requirements: %w", err)
+	}
+
+	applicable := []*models.AcknowledgementRequirement{}
+	requirementIDs := []primitive.ObjectID{}
+	for _, requirement := range requirements {
+		if requirement.Audience.Matches(user) {
+			applicable = append(applicable, requirement)
+			requirementIDs = append(requirementIDs, requirement.ID)
+		}
+	}
+	if len(applicable) == 0 {
+		return items, nil
+	}
+
+	acks, err := s.ackRepo.ListAcknowledgements(ctx, requirementIDs, &user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list acknowledgements: %w", err)
+	}
+	byRequirement := map[primitive.ObjectID][]*models.DocumentAcknowledgement{}
+	for _, ack := range acks {
+		byRequirement[ack.RequirementID] = append(byRequirement[ack.RequirementID], ack)
+	}
+
+	for _, requirement := range applicable {
+		owner := visible[requirement.CategoryID]
+		item := models.AcknowledgementItem{
+			RequirementID: requirement.ID.Hex(),
+			Name:          requirement.Name,
+			Path:          requirement.Path,
+			LibrarySlug:   owner.library.Slug,
+			LibraryName:   owner.library.Name,
+			CategoryID:    owner.category.ID.Hex(),
+			CategoryName:  owner.category.Name,
+			CurrentRev:    requirement.CurrentRev,
+			RevisedAt:     requirement.RevisedAt,
+			Status:        models.AcknowledgementStatusOutstanding,
+			DownloadURL:   fileDownloadURL(owner.library, owner.category, requirement.Path),
+		}
+		for _, ack := range byRequirement[requirement.ID] {
+			if ack.Rev == requirement.CurrentRev {
+				acknowledgedAt := ack.AcknowledgedAt
+				item.Status = models.AcknowledgementStatusAcknowledged
+				item.AcknowledgedAt = &acknowledgedAt
+			}
+		}
+		item.PreviousRevRead = item.Status == models.AcknowledgementStatusOutstanding && len(byRequirement[requirement.ID]) > 0
+		items = append(items, item)
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].Status != items[j].Status {
+			return items[i].Status == models.AcknowledgementStatusOutstanding
+		}
+		return items[i].RevisedAt.After(items[j].RevisedAt)
+	})
+	return items, nil
+}
+
+// Report builds the compliance report of a library: one row per active user
+// and requirement that applies to them, totalled per institution
+func (s *AcknowledgementService) Report(
+	ctx context.Context,
+	librarySlug string,
+	filter AcknowledgementReportFilter,
+	user *models.User,
+) (*models.AcknowledgementReport, error) {
+	library, err := s.categoryService.managedLibrary(ctx, librarySlug, user)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.reportRows(ctx, library, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.AcknowledgementReport{
+		GeneratedAt:  time.Now(),
+		Institutions: models.SummariseAcknowledgements(rows),
+		Rows:         rows,
+	}, nil
+}
+
+// SendReminders emails every user with outstanding acknowledgements in the
+// library a single message listing their outstanding documents
+func (s *AcknowledgementService) SendReminders(
+	ctx context.Context,
+	librarySlug string,
+	filter AcknowledgementReportFilter,
+	sentBy *models.User,
+	ipAddress string,
+) (*AcknowledgementReminderResult, error) {
+	library, err := s.categoryService.managedLibrary(ctx, librarySlug, sentBy)
+	if err != nil {
+		return nil, err
+	}
+
+	smtpConfig, err := s.registryService.GetPublicSMTPConfig(ctx)
+	if err != nil || smtpConfig == nil || !smtpConfig.IsComplete() {
+		return nil, ErrIncompleteSMTPConfig
+	}
+
+	rows, err := s.reportRows(ctx, library, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	type recipient struct {
+		email string
+		name  string
+		items []AcknowledgementReminderItem
+	}
+	recipients := map[string]*recipient{}
+	order := []string{}
+	for _, row := range rows {
+		if row.Status != models.AcknowledgementStatusOutstanding || row.Email == "" {
+			continue
+		}
+		r, ok := recipients[row.UserID]
+		if !ok {
+			r = &recipient{email: row.Email, name: row.UserName}
+			recipients[row.UserID] = r
+			order = append(order, row.UserID)
+		}
+		r.items = append(r.items, AcknowledgementReminderItem{
+			DocumentName: row.DocumentName,
+			CategoryName: row.CategoryName,
+			LibraryName:  library.Name,
+		})
+	}
+
+	result := &AcknowledgementReminderResult{Recipients: len(order)}
+	for _, userID := range order {
+		r := recipients[userID]
+		if err := s.emailService.SendAcknowledgementReminderEmail(*smtpConfig, r.email, r.name, r.items); err != nil {
+			fmt.Printf("Warning: Failed to send acknowledgement reminder to %s: %v\n", r.email, err)
+			result.Failed++
+			continue
+		}
+		result.Sent++
+	}
+
+	s.auditRepo.Create(ctx, &models.AuditLog{
+		UserID:      &sentBy.ID,
+		PerformedBy: &sentBy.ID,
+		Action:      models.AuditActionAcknowledgementReminded,
+		Details: bson.M{
+			"library":    library.Slug,
+			"recipients": result.Recipients,
+			"sent":       result.Sent,
+			"failed":     result.Failed,
+		},
+		IPAddress: ipAddress,
+	})
+
+	return result, nil
+}
+
+// reportRows lists each active user's status for each active requirement in
+// the library that applies to them and whose category they can see
+func (s *AcknowledgementService) reportRows(
+	ctx context.Context,
+	library *models.Library,
+	filter AcknowledgementReportFilter,
+) ([]models.AcknowledgementReportRow, error) {
+	rows := []models.AcknowledgementReportRow{}
+
+	requirementFilter := bson.M{"library_id": library.ID, "is_active": true}
+	if filter.RequirementID != nil {
+		requirementFilter["_id"] = *filter.RequirementID
+	}
+	requirements, err := s.ackRepo.FindRequirements(ctx, requirementFilter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list requirements: %w", err)
+	}
+	if len(requirements) == 0 {
+		return rows, nil
+	}
+
+	categories, err := s.categoryRepo.FindAll(ctx, repository.LibraryCategoryFilter{LibraryID: &library.ID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list categories: %w", err)
+	}
+	categoryByID := map[primitive.ObjectID]*models.LibraryCategory{}
+	for _, category := range categories {
+		categoryByID[category.ID] = category
+	}
+
+	userFilter := bson.M{"is_active": true}
+	if filter.InstitutionID != nil {
+		userFilter["profile.institution_id"] = *filter.InstitutionID
+	}
+	users, err := s.userRepo.List(ctx, userFilter, 0, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+
+	institutionNames, err := s.institutionNames(ctx, users)
+	if err != nil {
+		return nil, err
+	}
+
+	requirementIDs := make([]primitive.ObjectID, 0, len(requirements))
+	for _, requirement := range requirements {
+		requirementIDs = append(requirementIDs, requirement.ID)
+	}
+	acks, err := s.ackRepo.ListAcknowledgements(ctx, requirementIDs, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list acknowledgements: %w", err)
+	}
+	type ackKey struct {
+		requirementID primitive.ObjectID
+		userID        primitive.ObjectID
+		rev           string
+	}
+	acknowledged := map[ackKey]time.Time{}
+	for _, ack := range acks {
+		acknowledged[ackKey{ack.RequirementID, ack.UserID, ack.Rev}] = ack.AcknowledgedAt
+	}
+
+	for _, user := range users {
+		if !library.CanView(user) {
+			continue
+		}
+		institutionID, institutionName := "", noInstitutionName
+		if user.Profile.InstitutionID != nil {
+			institutionID = user.Profile.InstitutionID.Hex()
+			if name, ok := institutionNames[*user.Profile.InstitutionID]; ok {
+				institutionName = name
+			}
+		}
+		userName := strings.TrimSpace(user.FullName())
+		if userName == "" {
+			userName = user.Username
+		}
+
+		for _, requirement := range requirements {
+			category, ok := categoryByID[requirement.CategoryID]
+			if !ok || (!category.IsActive && !library.CanManage(user)) {
+				continue
+			}
+			if !requirement.Audience.Matches(user) {
+				continue
+			}
+
+			row := models.AcknowledgementReportRow{
+				RequirementID:   requirement.ID.Hex(),
+				DocumentName:    requirement.Name,
+				DocumentPath:    requirement.Path,
+				CategoryName:    category.Name,
+				CurrentRev:      requirement.CurrentRev,
+				InstitutionID:   institutionID,
+				InstitutionName: institutionName,
+				UserID:          user.ID.Hex(),
+				UserName:        userName,
+				Email:           user.Email,
+				Status:          models.AcknowledgementStatusOutstanding,
+			}
+			if at, ok := acknowledged[ackKey{requirement.ID, user.ID, requirement.CurrentRev}]; ok {
+				row.Status = models.AcknowledgementStatusAcknowledged
+				row.AcknowledgedAt = &at
+			}
+			rows = append(rows, row)
+		}
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool {
+		a, b := rows[i], rows[j]
+		if a.InstitutionName != b.InstitutionName {
+			return strings.ToLower(a.InstitutionName) < strings.ToLower(b.InstitutionName)
+		}
+		if a.UserName != b.UserName {
+			return strings.ToLower(a.UserName) < strings.ToLower(b.UserName)
+		}
+		return strings.ToLower(a.DocumentPath) < strings.ToLower(b.DocumentPath)
+	})
+	return rows, nil
+}
+
+// institutionNames looks up the names of the users' institutions
+func (s *AcknowledgementService) institutionNames(ctx context.Context, users []*models.User) (map[primitive.ObjectID]string, error) {
+	names := map[primitive.ObjectID]string{}
+	ids := []primitive.ObjectID{}
+	seen := map[primitive.ObjectID]bool{}
+	for _, user := range users {
+		if id := user.Profile.InstitutionID; id != nil && !seen[*id] {
+			seen[*id] = true
+			ids = append(ids, *id)
+		}
+	}
+	if len(ids) == 0 {
+		return names, nil
+	}
+
+	institutions, err := s.institutionRepo.FindByIDs(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get institutions: %w", err)
+	}
+	for _, institution := range institutions {
+		names[institution.ID] = institution.Name
+	}
+	return names, nil
+}
+
+// HandleDocumentEvents moves requirements to the new revision of a changed
+// file, so everyone must acknowledge it again, and deactivates the
+// requirements of deleted files
+func (s *AcknowledgementService) HandleDocumentEvents(events []models.DocumentEvent) {
+	ctx := context.Background()
+	categories := newEventCategories(s.libraryRepo, s.categoryRepo)
+
+	for _, event := range events {
+		owners, err := categories.find(ctx, event)
+		if err != nil {
+			fmt.Printf("Warning: failed to find categories for %s: %v\n", event.Folder, err)
+			continue
+		}
+		if len(owners) == 0 {
+			continue
+		}
+		categoryIDs := make([]primitive.ObjectID, 0, len(owners))
+		for _, category := range owners {
+			categoryIDs = append(categoryIDs, category.ID)
+		}
+
+		pathLower := strings.ToLower(event.RelativePath)
+		if event.Type == models.DocumentEventDeleted {
+			_, err = s.ackRepo.DeactivateByPath(ctx, categoryIDs, pathLower)
+		} else if event.Rev != "" {
+			_, err = s.ackRepo.SetCurrentRev(ctx, categoryIDs, pathLower, event.Rev, event.OccurredAt)
+		}
+		if err != nil {
+			fmt.Printf("Warning: failed to update acknowledgement requirements of %s: %v\n", event.Path, err)
+		}
+	}
+}
+
+// findRequirement finds a requirement within a library
+func (s *AcknowledgementService) findRequirement(ctx context.Context, libraryID, id primitive.ObjectID) (*models.AcknowledgementRequirement, error) {
+	requirement, err := s.ackRepo.FindRequirement(ctx, libraryID, id)
+	if err != nil {
+		if err == repository.ErrRequirementNotFound {
+			return nil, ErrRequirementNotFound
+		}
+		return nil, fmt.Errorf("failed to get requirement: %w", err)
+	}
+	return requirement, nil
+}
@@ -6,9 +6,11 @@ import (
 	"time"
 
 	"backend/internal/models"
+	"backend/internal/pagination"
 	"backend/internal/repository"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 // AuditService handles audit log operations
@@ -54,6 +56,30 @@ func (s *AuditService) GetRecentActivity(ctx context.Context, limit int64) ([]Re
 	return activities, nil
 }
 
+// ListAuditLogs retrieves one page of raw audit logs, optionally filtered by
+// action and by the user the entry concerns
+func (s *AuditService) ListAuditLogs(ctx context.Context, action string, userID *primitive.ObjectID, req pagination.Request) (*pagination.Page[*models.AuditLog], int64, error) {
+	filter := bson.M{}
+	if action != "" {
+		filter["action"] = action
+	}
+	if userID != nil {
+		filter["user_id"] = *userID
+	}
+
+	page, err := s.auditRepo.ListPage(ctx, filter, req)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total, err := s.auditRepo.Count(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return page, total, nil
+}
+
 // enrichAuditLog converts an audit log to a user-friendly activity item
 func (s *AuditService) enrichAuditLog(ctx context.Context, log *models.AuditLog) RecentActivityItem {
 	activity := RecentActivityItem{
@@ -199,6 +225,52 @@ func (s *AuditService) enrichAuditLog(ctx context.Context, log *models.AuditLog)
 		activity.IconBg = "bg-red-100"
 		activity.IconColor = "text-red-600"
 
+	case models.AuditActionInstitutionMerged:
+		institutionName := s.getInstitutionNameFromDetails(log.Details)
+		activity.Title = "Institutions merged"
+		activity.Description = "Duplicate institutions were merged into " + institutionName
+		activity.Icon = "settings"
+		activity.IconBg = "bg-purple-100"
+		activity.IconColor = "text-purple-600"
+
+	case models.AuditActionInstitutionApproved:
+		institutionName := s.getInstitutionNameFromDetails(log.Details)
+		activity.Title = "Institution approved"
+		activity.Description = institutionName + " was approved and is now public"
+		activity.Icon = "user-check"
+		activity.IconBg = "bg-green-100"
+		activity.IconColor = "text-green-600"
+
+	case models.AuditActionInstitutionRejected:
+		institutionName := s.getInstitutionNameFromDetails(log.Details)
+		activity.Title = "Institution rejected"
+		activity.Description = institutionName + " was not approved"
+		activity.Icon = "user-x"
+		activity.IconBg = "bg-red-100"
+		activity.IconColor = "text-red-600"
+
+	case models.AuditActionInstitutionsImported:
+		activity.Title = "Institutions imported"
+		activity.Description = fmt.Sprintf("%v created and %v updated from %v", log.Details["created"], log.Details["updated"], log.Details["source"])
+		activity.Icon = "settings"
+		activity.IconBg = "bg-blue-100"
+		activity.IconColor = "text-blue-600"
+
+	// Library actions
+	case models.AuditActionLibraryCreated:
+		activity.Title = "Library created"
+		activity.Description = fmt.Sprintf("%v was added", log.Details["library_name"])
+		activity.Icon = "settings"
+		activity.IconBg = "bg-green-100"
+		activity.IconColor = "text-green-600"
+
+	case models.AuditActionLibraryDeleted:
+		activity.Title = "Library deleted"
+		activity.Description = fmt.Sprintf("%v was removed", log.Details["library_name"])
+		activity.Icon = "trash"
+		activity.IconBg = "bg-red-100"
+		activity.IconColor = "text-red-600"
+
 	default:
 		activity.Title = "System activity"
 		activity.Description = string(log.Action)
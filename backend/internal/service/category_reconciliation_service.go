@@ -0,0 +1,485 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"backend/internal/models"
+	"backend/internal/repository"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrReconciliationFolderNotFound = errors.New("folder not found under the library's Dropbox root")
+	ErrReconciliationNotDropbox     = errors.New("library does not keep its files in Dropbox")
+	ErrFolderAlreadyLinked          = errors.New("folder is already linked to another category")
+)
+
+const (
+	// CategoryReconcileIntervalEnv overrides how often categories are
+	// reconciled with their Dropbox folders, as a Go duration such as "1h"
+	CategoryReconcileIntervalEnv = "CATEGORY_RECONCILE_INTERVAL"
+
+	defaultCategoryReconcileInterval = 6 * time.Hour
+)
+
+// CategoryReconciliationService compares each Dropbox library's categories
+// with the folders under its root in the library's Dropbox connection, so folders renamed or deleted directly
+// in Dropbox show up in an admin report instead of as silently empty
+// categories. Admins resolve each issue with a fix: re-link a category to
+// a folder, create a category for a folder, or archive a category.
+type CategoryReconciliationService struct {
+	reportRepo     *repository.CategoryReconciliationRepository
+	libraryRepo    *repository.LibraryRepository
+	categoryRepo   *repository.LibraryCategoryRepository
+	auditRepo      *repository.AuditRepository
+	connections    *DropboxConnectionService
+	listingService *DropboxListingService
+
+	interval  time.Duration
+	ticker    *time.Ticker
+	done      chan bool
+	isRunning bool
+
+	// One reconciliation or fix at a time
+	mu sync.Mutex
+}
+
+// NewCategoryReconciliationService creates a new CategoryReconciliationService
+func NewCategoryReconciliationService(
+	reportRepo *repository.CategoryReconciliationRepository,
+	libraryRepo *repository.LibraryRepository,
+	categoryRepo *repository.LibraryCategoryRepository,
+	auditRepo *repository.AuditRepository,
+	connections *DropboxConnectionService,
+	listingService *DropboxListingService,
+) *CategoryReconciliationService {
+	interval := defaultCategoryReconcileInterval
+	if value := os.Getenv(CategoryReconcileIntervalEnv); value != "" {
+		if parsed, err := time.ParseDuration(value); err == nil && parsed > 0 {
+			interval = parsed
+		} else {
+			fmt.Printf("Warning: invalid %s %q, using %s\n", CategoryReconcileIntervalEnv, value, interval)
+		}
+	}
+
+	return &CategoryReconciliationService{
+		reportRepo:     reportRepo,
+		libraryRepo:    libraryRepo,
+		categoryRepo:   categoryRepo,
+		auditRepo:      auditRepo,
+		connections:    connections,
+		listingService: listingService,
+		interval:       interval,
+		done:           make(chan bool),
+	}
+}
+
+// Start begins reconciling in the background, once on startup and then every interval
+func (s *CategoryReconciliationService) Start() {
+	if s.isRunning {
+		fmt.Println("Category reconciliation is already running")
+		return
+	}
+
+	s.ticker = time.NewTicker(s.interval)
+	s.isRunning = true
+
+	fmt.Printf("Starting category reconciliation (every %s)\n", s.interval)
+
+	go func() {
+		s.reconcile()
+		for {
+			select {
+			case <-s.ticker.C:
+				s.reconcile()
+			case <-s.done:
+				fmt.Println("Category reconciliation stopped")
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the background reconciliation
+func (s *CategoryReconciliationService) Stop() {
+	if !s.isRunning {
+		return
+	}
+
+	if s.ticker != nil {
+		s.ticker.Stop()
+	}
+	s.done <- true
+	s.isRunning = false
+	fmt.Println("Stopping category reconciliation")
+}
+
+func (s *CategoryReconciliationService) reconcile() {
+	if !s.connections.AnyConfigured() {
+		return
+	}
+	reports, err := s.Run(context.Background())
+	if err != nil {
+		fmt.Printf("Warning: failed to reconcile categories: %v\n", err)
+		return
+	}
+	issues := 0
+	for _, report := range reports {
+		issues += len(report.Issues)
+	}
+	if issues > 0 {
+		fmt.Printf("Category reconciliation found %d issue(s)\n", issues)
+	}
+}
+
+// Reports returns the latest report of each library
+func (s *CategoryReconciliationService) Reports(ctx context.Context) ([]*models.CategoryReconciliation, error) {
+	reports, err := s.reportRepo.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reconciliation reports: %w", err)
+	}
+	return reports, nil
+}
+
+// Run reconciles every Dropbox library and returns the new reports. A
+// library whose connection is not authorized gets a report with the error.
+func (s *CategoryReconciliationService) Run(ctx context.Context) ([]*models.CategoryReconciliation, error) {
+	if !s.connections.AnyConfigured() {
+		return nil, ErrDropboxNotConfigured
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	libraries, err := s.libraryRepo.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list libraries: %w", err)
+	}
+
+	reports := []*models.CategoryReconciliation{}
+	keep := []primitive.ObjectID{}
+	for _, library := range libraries {
+		if !library.UsesDropbox() {
+			continue
+		}
+		report, err := s.reconcileLibrary(ctx, library)
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, report)
+		keep = append(keep, library.ID)
+	}
+
+	if err := s.reportRepo.DeleteOthers(ctx, keep); err != nil {
+		fmt.Printf("Warning: failed to remove old reconciliation reports: %v\n", err)
+	}
+	return reports, nil
+}
+
+// reconcileLibrary compares a library's categories with its folders, records
+// the folder IDs of categories matched by name, and saves the report
+func (s *CategoryReconciliationService) reconcileLibrary(ctx context.Context, library *models.Library) (*models.CategoryReconciliation, error) {
+	report := &models.CategoryReconciliation{
+		LibraryID:   library.ID,
+		LibrarySlug: library.Slug,
+		LibraryName: library.Name,
+		Issues:      []models.ReconciliationIssue{},
+		CheckedAt:   time.Now(),
+	}
+
+	categories, err := s.categoryRepo.FindAll(ctx, repository.LibraryCategoryFilter{LibraryID: &library.ID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list categories of %s: %w", library.Slug, err)
+	}
+
+	folders, err := s.folders(library)
+	if err != nil {
+		// Report the failure rather than every category as missing
+		report.Error = err.Error()
+	} else {
+		issues, links := models.ReconcileCategories(library, categories, folders)
+		report.Issues = issues
+		for categoryID, folderID := range links {
+			if err := s.categoryRepo.Update(ctx, categoryID, bson.M{"dropbox_folder_id": folderID}); err != nil {
+				fmt.Printf("Warning: failed to record Dropbox folder of category %s: %v\n", categoryID.Hex(), err)
+			}
+		}
+	}
+
+	if err := s.reportRepo.Save(ctx, report); err != nil {
+		return nil, fmt.Errorf("failed to save reconciliation report of %s: %w", library.Slug, err)
+	}
+	return report, nil
+}
+
+// folders lists the folders directly under a library's Dropbox root. A
+// root that does not exist yet has no folders.
+func (s *CategoryReconciliationService) folders(library *models.Library) ([]models.CategoryFolder, error) {
+	entries, err := s.connections.ForLibrary(library).ListFiles(storagePath(library.DropboxRoot))
+	if err == ErrFolderNotFound {
+		return []models.CategoryFolder{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	folders := []models.CategoryFolder{}
+	for _, entry := range entries {
+		if entry.IsFolder && entry.ID != "" {
+			folders = append(folders, models.CategoryFolder{ID: entry.ID, Name: entry.Name})
+		}
+	}
+	return folders, nil
+}
+
+// Fix applies a fix to a library and returns its updated report
+func (s *CategoryReconciliationService) Fix(
+	ctx context.Context,
+	req *models.ReconciliationFixRequest,
+	performedBy *models.User,
+	ipAddress string,
+) (*models.CategoryReconciliation, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	libraryID, err := primitive.ObjectIDFromHex(req.LibraryID)
+	if err != nil {
+		return nil, ErrLibraryNotFound
+	}
+	library, err := s.libraryRepo.FindByID(ctx, libraryID)
+	if err != nil {
+		if err == repository.ErrLibraryNotFound {
+			return nil, ErrLibraryNotFound
+		}
+		return nil, fmt.Errorf("failed to get library: %w", err)
+	}
+	if !library.UsesDropbox() {
+		return nil, ErrReconciliationNotDropbox
+	}
+	if !s.connections.ForLibrary(library).IsConfigured() {
+		return nil, ErrDropboxNotConfigured
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var category *models.LibraryCategory
+	if req.CategoryID != "" {
+		categoryID, err := primitive.ObjectIDFromHex(req.CategoryID)
+		if err != nil {
+			return nil, ErrCategoryNotFound
+		}
+		category, err = s.categoryRepo.FindByID(ctx, library.ID, categoryID)
+		if err != nil {
+			if err == repository.ErrCategoryNotFound {
+				return nil, ErrCategoryNotFound
+			}
+			return nil, fmt.Errorf("failed to get category: %w", err)
+		}
+	}
+
+	// Look the folder up again, as it may have changed since the report
+	var folder *models.CategoryFolder
+	if req.FolderID != "" {
+		folders, err := s.folders(library)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list folders: %w", err)
+		}
+		for i := range folders {
+			if folders[i].ID == req.FolderID {
+				folder = &folders[i]
+				break
+			}
+		}
+		if folder == nil {
+			return nil, ErrReconciliationFolderNotFound
+		}
+	}
+
+	switch req.Action {
+	case models.ReconciliationRelink:
+		err = s.relink(ctx, library, category, folder, performedBy, ipAddress)
+	case models.ReconciliationCreate:
+		err = s.create(ctx, library, folder, performedBy, ipAddress)
+	case models.ReconciliationArchive:
+		err = s.archive(ctx, library, category, performedBy, ipAddress)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return s.reconcileLibrary(ctx, library)
+}
+
+// folderLinked reports whether another category of the library already uses a folder
+func (s *CategoryReconciliationService) folderLinked(
+	ctx context.Context,
+	library *models.Library,
+	folder *models.CategoryFolder,
+	except *primitive.ObjectID,
+) (bool, error) {
+	categories, err := s.categoryRepo.FindAll(ctx, repository.LibraryCategoryFilter{LibraryID: &library.ID})
+	if err != nil {
+		return false, fmt.Errorf("failed to list categories: %w", err)
+	}
+	path := library.CategoryDropboxPath(folder.Name)
+	for _, other := range categories {
+		if except != nil && other.ID == *except {
+			continue
+		}
+		if other.DropboxFolderID == folder.ID || other.GetDropboxPath(library) == path {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// relink points a category at a folder, keeping the category's name
+func (s *CategoryReconciliationService) relink(
+	ctx context.Context,
+	library *models.Library,
+	category *models.LibraryCategory,
+	folder *models.CategoryFolder,
+	performedBy *models.User,
+	ipAddress string,
+) error {
+	linked, err := s.folderLinked(ctx, library, folder, &category.ID)
+	if err != nil {
+		return err
+	}
+	if linked {
+		return ErrFolderAlreadyLinked
+	}
+
+	oldPath := category.GetDropboxPath(library)
+	newPath := library.CategoryDropboxPath(folder.Name)
+	update := bson.M{
+		"dropbox_path":      newPath,
+		"dropbox_folder_id": folder.ID,
+	}
+	if err := s.categoryRepo.Update(ctx, category.ID, update); err != nil {
+		return fmt.Errorf("failed to update category: %w", err)
+	}
+	s.listingService.InvalidateFolder(ctx, s.connections.ForLibrary(library), oldPath)
+
+	s.auditRepo.Create(ctx, &models.AuditLog{
+		UserID:      &performedBy.ID,
+		PerformedBy: &performedBy.ID,
+		Action:      models.AuditActionLibraryCategoryUpdated,
+		Details: bson.M{
+			"library":       library.Slug,
+			"category_id":   category.ID.Hex(),
+			"category_name": category.Name,
+			"reconciliation": bson.M{
+				"action":   string(models.ReconciliationRelink),
+				"old_path": oldPath,
+				"new_path": newPath,
+			},
+		},
+		IPAddress: ipAddress,
+	})
+	return nil
+}
+
+// create adds a category for a folder that has none, named after the folder
+func (s *CategoryReconciliationService) create(
+	ctx context.Context,
+	library *models.Library,
+	folder *models.CategoryFolder,
+	performedBy *models.User,
+	ipAddress string,
+) error {
+	linked, err := s.folderLinked(ctx, library, folder, nil)
+	if err != nil {
+		return err
+	}
+	if linked {
+		return ErrFolderAlreadyLinked
+	}
+
+	slug := models.GenerateSlug(folder.Name)
+	exists, err := s.categoryRepo.ExistsBySlug(ctx, library.ID, slug, nil)
+	if err != nil {
+		return fmt.Errorf("failed to check slug existence: %w", err)
+	}
+	if exists {
+		return ErrDuplicateSlug
+	}
+
+	count, err := s.categoryRepo.Count(ctx, repository.LibraryCategoryFilter{LibraryID: &library.ID})
+	if err != nil {
+		return fmt.Errorf("failed to count categories: %w", err)
+	}
+
+	category := &models.LibraryCategory{
+		LibraryID:       library.ID,
+		Name:            folder.Name,
+		Slug:            slug,
+		DropboxPath:     library.CategoryDropboxPath(folder.Name),
+		DropboxFolderID: folder.ID,
+		DisplayOrder:    int(count) + 1,
+		IsActive:        true,
+		CreatedBy:       &performedBy.ID,
+	}
+	if err := category.Validate(); err != nil {
+		return err
+	}
+	if err := s.categoryRepo.Create(ctx, category); err != nil {
+		if err == repository.ErrDuplicateSlug {
+			return ErrDuplicateSlug
+		}
+		return fmt.Errorf("failed to create category: %w", err)
+	}
+
+	s.auditRepo.Create(ctx, &models.AuditLog{
+		UserID:      &performedBy.ID,
+		PerformedBy: &performedBy.ID,
+		Action:      models.AuditActionLibraryCategoryCreated,
+		Details: bson.M{
+			"library":        library.Slug,
+			"category_id":    category.ID.Hex(),
+			"category_name":  category.Name,
+			"slug":           category.Slug,
+			"reconciliation": string(models.ReconciliationCreate),
+		},
+		IPAddress: ipAddress,
+	})
+	return nil
+}
+
+// archive deactivates a category, hiding it from users who cannot manage
+// the library. The category can be reactivated once its folder is restored.
+func (s *CategoryReconciliationService) archive(
+	ctx context.Context,
+	library *models.Library,
+	category *models.LibraryCategory,
+	performedBy *models.User,
+	ipAddress string,
+) error {
+	if err := s.categoryRepo.Update(ctx, category.ID, bson.M{"is_active": false}); err != nil {
+		return fmt.Errorf("failed to archive category: %w", err)
+	}
+
+	s.auditRepo.Create(ctx, &models.AuditLog{
+		UserID:      &performedBy.ID,
+		PerformedBy: &performedBy.ID,
+		Action:      models.AuditActionLibraryCategoryUpdated,
+		Details: bson.M{
+			"library":        library.Slug,
+			"category_id":    category.ID.Hex(),
+			"category_name":  category.Name,
+			"changes":        bson.M{"is_active": false},
+			"reconciliation": string(models.ReconciliationArchive),
+		},
+		IPAddress: ipAddress,
+	})
+	return nil
+}
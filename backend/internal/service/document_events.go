@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"backend/internal/models"
+	"backend/internal/repository"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DocumentEventHandler receives the document events found by one sync
+type DocumentEventHandler func(events []models.DocumentEvent)
+
+// DocumentEventBus delivers document added/updated/deleted events to
+// in-process subscribers. Handlers run on the publishing goroutine and
+// should hand long work off to their own.
+type DocumentEventBus struct {
+	mu       sync.RWMutex
+	handlers []DocumentEventHandler
+}
+
+// NewDocumentEventBus creates a new DocumentEventBus
+func NewDocumentEventBus() *DocumentEventBus {
+	return &DocumentEventBus{}
+}
+
+// Subscribe registers a handler for every later batch of events
+func (b *DocumentEventBus) Subscribe(handler DocumentEventHandler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers = append(b.handlers, handler)
+}
+
+// Publish delivers a batch of events to every subscriber
+func (b *DocumentEventBus) Publish(events []models.DocumentEvent) {
+	if len(events) == 0 {
+		return
+	}
+
+	b.mu.RLock()
+	handlers := append([]DocumentEventHandler(nil), b.handlers...)
+	b.mu.RUnlock()
+
+	for _, handler := range handlers {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					fmt.Printf("Warning: document event handler panicked: %v\n", r)
+				}
+			}()
+			handler(events)
+		}()
+	}
+}
+
+// eventCategories finds the categories whose folder document events are in,
+// remembering the answers for one batch of events. The same path can exist
+// under more than one Dropbox connection, so categories of libraries on
+// another connection, or not kept in Dropbox, are left out.
+type eventCategories struct {
+	libraryRepo  *repository.LibraryRepository
+	categoryRepo *repository.LibraryCategoryRepository
+
+	libraries  map[primitive.ObjectID]*models.Library
+	categories map[string][]*models.LibraryCategory
+}
+
+func newEventCategories(libraryRepo *repository.LibraryRepository, categoryRepo *repository.LibraryCategoryRepository) *eventCategories {
+	return &eventCategories{
+		libraryRepo:  libraryRepo,
+		categoryRepo: categoryRepo,
+		libraries:    map[primitive.ObjectID]*models.Library{},
+		categories:   map[string][]*models.LibraryCategory{},
+	}
+}
+
+// find returns the categories an event's folder belongs to
+func (c *eventCategories) find(ctx context.Context, event models.DocumentEvent) ([]*models.LibraryCategory, error) {
+	key := event.Folder
+	if event.ConnectionID != nil {
+		key = event.ConnectionID.Hex() + ":" + key
+	}
+	if owners, ok := c.categories[key]; ok {
+		return owners, nil
+	}
+
+	candidates, err := c.categoryRepo.FindAll(ctx, repository.LibraryCategoryFilter{DropboxPath: event.Folder})
+	if err != nil {
+		return nil, err
+	}
+	owners := []*models.LibraryCategory{}
+	for _, category := range candidates {
+		library, ok := c.libraries[category.LibraryID]
+		if !ok {
+			if library, err = c.libraryRepo.FindByID(ctx, category.LibraryID); err != nil {
+				if err == repository.ErrLibraryNotFound {
+					continue
+				}
+				return nil, err
+			}
+			c.libraries[category.LibraryID] = library
+		}
+		if library.UsesDropbox() && models.SameDropboxConnection(library.DropboxConnectionID, event.ConnectionID) {
+			owners = append(owners, category)
+		}
+	}
+	c.categories[key] = owners
+	return owners, nil
+}
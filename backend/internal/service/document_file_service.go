@@ -0,0 +1,535 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"backend/internal/models"
+	"backend/internal/repository"
+	"backend/internal/storage"
+	"backend/internal/ziparchive"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DocumentFileService publishes and organises the files of library
+// categories, so content managers do not need direct access to the storage.
+// Every change requires the library's manage permission and is audited.
+// For Dropbox libraries it also resyncs the category's listing, which
+// records the change as a document event. It also packages category folders
+// as ZIP archives for offline use. Files are kept in the storage driver of
+// the category's library.
+type DocumentFileService struct {
+	categoryService *LibraryCategoryService
+	storageService  *StorageService
+	listingService  *DropboxListingService
+	auditRepo       *repository.AuditRepository
+}
+
+// NewDocumentFileService creates a new DocumentFileService
+func NewDocumentFileService(
+	categoryService *LibraryCategoryService,
+	storageService *StorageService,
+	listingService *DropboxListingService,
+	auditRepo *repository.AuditRepository,
+) *DocumentFileService {
+	return &DocumentFileService{
+		categoryService: categoryService,
+		storageService:  storageService,
+		listingService:  listingService,
+		auditRepo:       auditRepo,
+	}
+}
+
+// DocumentUpload is a file uploaded to a category
+type DocumentUpload struct {
+	Folder   string // Relative to the category folder; "" for the folder itself
+	Name     string
+	Size     int64
+	Content  io.ReadSeeker
+	Conflict models.UploadConflictPolicy
+}
+
+// maxUploadRenames caps the numbered names tried by the rename policy
+const maxUploadRenames = 100
+
+// managedCategory resolves a category the user may change files in, and
+// the storage its files are kept in
+func (s *DocumentFileService) managedCategory(
+	ctx context.Context,
+	librarySlug string,
+	categoryID primitive.ObjectID,
+	user *models.User,
+) (*models.Library, *models.LibraryCategory, storage.Driver, error) {
+	library, category, err := s.categoryService.managedCategory(ctx, librarySlug, categoryID, user)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	driver := s.storageService.ForLibrary(library)
+	if !driver.IsConfigured() {
+		return nil, nil, nil, ErrStorageNotConfigured
+	}
+	return library, category, driver, nil
+}
+
+// categoryItemPath returns the driver path of a file or folder given
+// relative to a category folder
+func categoryItemPath(category *models.LibraryCategory, relativePath string) string {
+	return storagePath(categoryFilePath(category.DropboxPath, relativePath))
+}
+
+// fromDocumentStorageError maps storage errors on an existing file or
+// folder to the errors the service returns
+func fromDocumentStorageError(err error) error {
+	if err == storage.ErrNotFound {
+		return ErrDocumentNotFound
+	}
+	return fromStorageError(err)
+}
+
+// Upload stores a file in a category folder or one of its subfolders. With
+// the replace policy an existing file gets the upload as a new revision.
+func (s *DocumentFileService) Upload(
+	ctx context.Context,
+	librarySlug string,
+	categoryID primitive.ObjectID,
+	upload DocumentUpload,
+	uploadedBy *models.User,
+	ipAddress string,
+) (*DropboxFileInfo, error) {
+	if err := models.ValidateDocumentUpload(upload.Name, upload.Size); err != nil {
+		return nil, err
+	}
+	folder, err := models.CleanDocumentPath(upload.Folder)
+	if err != nil {
+		return nil, err
+	}
+
+	library, category, driver, err := s.managedCategory(ctx, librarySlug, categoryID, uploadedBy)
+	if err != nil {
+		return nil, err
+	}
+
+	name, action, err := s.uploadName(ctx, driver, category, folder, upload.Name, upload.Conflict)
+	if err != nil {
+		return nil, err
+	}
+	relativePath := path.Join(folder, name)
+
+	object, err := driver.Upload(ctx, upload.Content, categoryItemPath(category, relativePath))
+	if err != nil {
+		return nil, fromStorageError(err)
+	}
+	info := fileInfoFromObject(*object)
+	info.Path = relativePath
+
+	s.auditRepo.Create(ctx, &models.AuditLog{
+		UserID:      &uploadedBy.ID,
+		PerformedBy: &uploadedBy.ID,
+		Action:      action,
+		Details: bson.M{
+			"library":     library.Slug,
+			"category_id": category.ID.Hex(),
+			"path":        info.Path,
+			"rev":         info.Rev,
+			"size":        info.Size,
+			"conflict":    upload.Conflict,
+		},
+		IPAddress: ipAddress,
+	})
+
+	s.refresh(ctx, library, category)
+	return info, nil
+}
+
+// uploadName applies the conflict policy to an upload, returning the name
+// to store it under and the audit action it records. With the rename
+// policy the file is numbered as Dropbox numbers it.
+func (s *DocumentFileService) uploadName(
+	ctx context.Context,
+	driver storage.Driver,
+	category *models.LibraryCategory,
+	folder string,
+	name string,
+	policy models.UploadConflictPolicy,
+) (string, models.AuditAction, error) {
+	for n := 0; n <= maxUploadRenames; n++ {
+		candidate := name
+		if n > 0 {
+			candidate = models.NumberedDocumentName(name, n)
+		}
+		existing, err := driver.Metadata(ctx, categoryItemPath(category, path.Join(folder, candidate)))
+		switch {
+		case err == storage.ErrNotFound:
+			return candidate, models.AuditActionDocumentUploaded, nil
+		case err != nil:
+			return "", "", fromStorageError(err)
+		case policy == models.UploadConflictReplace && !existing.IsFolder:
+			return candidate, models.AuditActionDocumentReplaced, nil
+		case policy != models.UploadConflictRename:
+			return "", "", ErrFileExists
+		}
+	}
+	return "", "", ErrFileExists
+}
+
+// CreateFolder creates a subfolder in a category folder
+func (s *DocumentFileService) CreateFolder(
+	ctx context.Context,
+	librarySlug string,
+	categoryID primitive.ObjectID,
+	folderPath string,
+	createdBy *models.User,
+	ipAddress string,
+) error {
+	folderPath, err := models.CleanDocumentItemPath(folderPath)
+	if err != nil {
+		return err
+	}
+
+	library, category, driver, err := s.managedCategory(ctx, librarySlug, categoryID, createdBy)
+	if err != nil {
+		return err
+	}
+
+	if err := driver.CreateFolder(ctx, categoryItemPath(category, folderPath)); err != nil {
+		return fromStorageError(err)
+	}
+
+	s.auditRepo.Create(ctx, &models.AuditLog{
+		UserID:      &createdBy.ID,
+		PerformedBy: &createdBy.ID,
+		Action:      models.AuditActionDocumentFolderCreated,
+		Details: bson.M{
+			"library":     library.Slug,
+			"category_id": category.ID.Hex(),
+			"path":        folderPath,
+		},
+		IPAddress: ipAddress,
+	})
+
+	s.refresh(ctx, library, category)
+	return nil
+}
+
+// Delete deletes a file, or a subfolder with everything in it. Dropbox keeps
+// deleted files restorable for its retention period; the local disk and S3
+// drivers delete them outright.
+func (s *DocumentFileService) Delete(
+	ctx context.Context,
+	librarySlug string,
+	categoryID primitive.ObjectID,
+	itemPath string,
+	deletedBy *models.User,
+	ipAddress string,
+) error {
+	itemPath, err := models.CleanDocumentItemPath(itemPath)
+	if err != nil {
+		return err
+	}
+
+	library, category, driver, err := s.managedCategory(ctx, librarySlug, categoryID, deletedBy)
+	if err != nil {
+		return err
+	}
+
+	fullPath := categoryItemPath(category, itemPath)
+	info, err := driver.Metadata(ctx, fullPath)
+	if err != nil {
+		return fromDocumentStorageError(err)
+	}
+	if err := driver.Delete(ctx, fullPath); err != nil {
+		return fromDocumentStorageError(err)
+	}
+
+	s.auditRepo.Create(ctx, &models.AuditLog{
+		UserID:      &deletedBy.ID,
+		PerformedBy: &deletedBy.ID,
+		Action:      models.AuditActionDocumentDeleted,
+		Details: bson.M{
+			"library":     library.Slug,
+			"category_id": category.ID.Hex(),
+			"path":        itemPath,
+			"is_folder":   info.IsFolder,
+			"rev":         info.Rev,
+		},
+		IPAddress: ipAddress,
+	})
+
+	s.refresh(ctx, library, category)
+	return nil
+}
+
+// Move moves or renames a file or subfolder within a category folder
+func (s *DocumentFileService) Move(
+	ctx context.Context,
+	librarySlug string,
+	categoryID primitive.ObjectID,
+	req *models.MoveDocumentRequest,
+	movedBy *models.User,
+	ipAddress string,
+) (*DropboxFileInfo, error) {
+	from, err := models.CleanDocumentItemPath(req.From)
+	if err != nil {
+		return nil, err
+	}
+	to, err := models.CleanDocumentItemPath(req.To)
+	if err != nil {
+		return nil, err
+	}
+	if from == to {
+		return nil, models.ErrInvalidDocumentPath
+	}
+	// A folder cannot be moved into itself
+	if strings.HasPrefix(strings.ToLower(to)+"/", strings.ToLower(from)+"/") && !strings.EqualFold(from, to) {
+		return nil, models.ErrInvalidDocumentPath
+	}
+
+	library, category, driver, err := s.managedCategory(ctx, librarySlug, categoryID, movedBy)
+	if err != nil {
+		return nil, err
+	}
+
+	fromPath, toPath := categoryItemPath(category, from), categoryItemPath(category, to)
+	existing, err := driver.Metadata(ctx, fromPath)
+	if err != nil {
+		return nil, fromDocumentStorageError(err)
+	}
+	// Renaming must not get round the upload type restrictions
+	if !existing.IsFolder {
+		if err := models.ValidateDocumentType(path.Base(to)); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := driver.Rename(ctx, fromPath, toPath); err != nil {
+		return nil, fromDocumentStorageError(err)
+	}
+	moved, err := driver.Metadata(ctx, toPath)
+	if err != nil {
+		return nil, fromDocumentStorageError(err)
+	}
+	info := fileInfoFromObject(*moved)
+	info.Path = to
+
+	s.auditRepo.Create(ctx, &models.AuditLog{
+		UserID:      &movedBy.ID,
+		PerformedBy: &movedBy.ID,
+		Action:      models.AuditActionDocumentMoved,
+		Details: bson.M{
+			"library":     library.Slug,
+			"category_id": category.ID.Hex(),
+			"from":        from,
+			"to":          to,
+			"is_folder":   existing.IsFolder,
+		},
+		IPAddress: ipAddress,
+	})
+
+	s.refresh(ctx, library, category)
+	return info, nil
+}
+
+// refresh resyncs the listing of a Dropbox category so the change is served
+// and published straight away. If the sync fails the listing is dropped and
+// rebuilt on the next request. Other drivers are listed directly and have
+// nothing to resync.
+func (s *DocumentFileService) refresh(ctx context.Context, library *models.Library, category *models.LibraryCategory) {
+	if !library.UsesDropbox() {
+		return
+	}
+	dropbox := s.storageService.connections.ForLibrary(library)
+	if err := s.listingService.SyncFolder(ctx, dropbox, category.DropboxPath); err != nil {
+		fmt.Printf("Warning: failed to resync listing of %s: %v\n", category.DropboxPath, err)
+		s.listingService.InvalidateFolder(ctx, dropbox, category.DropboxPath)
+	}
+}
+
+// archiveWorkers is how many files an archive fetches from storage at once
+const archiveWorkers = 4
+
+// DocumentArchive is a category folder prepared for download as a ZIP
+type DocumentArchive struct {
+	Name  string // Download file name
+	Files int
+	Size  int64 // Total uncompressed size
+
+	library  *models.Library
+	category *models.LibraryCategory
+	driver   storage.Driver
+	folder   string
+	entries  []ziparchive.Entry
+	paths    []string // Of each entry, relative to the category folder
+	revs     []string // Of each entry as listed
+}
+
+// PrepareArchive lists the files of a category folder, or one of its
+// subfolders, for download as a ZIP archive, checking them against the
+// archive limits before anything is fetched
+func (s *DocumentFileService) PrepareArchive(
+	ctx context.Context,
+	librarySlug string,
+	categoryID primitive.ObjectID,
+	folder string,
+	user *models.User,
+) (*DocumentArchive, error) {
+	folder, err := models.CleanDocumentPath(folder)
+	if err != nil {
+		return nil, err
+	}
+
+	library, category, err := s.categoryService.getCategory(ctx, librarySlug, categoryID, user)
+	if err != nil {
+		return nil, err
+	}
+	if !library.CanDownload(user) {
+		return nil, ErrUnauthorized
+	}
+	driver := s.storageService.ForLibrary(library)
+	if !driver.IsConfigured() {
+		return nil, ErrStorageNotConfigured
+	}
+
+	files, err := s.storageService.CategoryFiles(ctx, library, category)
+	if err != nil {
+		if err == ErrFolderNotFound {
+			return nil, models.ErrArchiveEmpty
+		}
+		return nil, fmt.Errorf("failed to list files: %w", err)
+	}
+
+	rootName := category.Name
+	if folder != "" {
+		node := findListedPath(files, folder)
+		if node == nil {
+			return nil, ErrDocumentNotFound
+		}
+		if !node.IsFolder {
+			return nil, models.ErrInvalidDocumentPath
+		}
+		// Continue with the folder's path as the storage has it
+		files, rootName, folder = node.Children, node.Name, node.Path
+	}
+
+	archive := &DocumentArchive{
+		Name:     models.ArchiveFileName(rootName),
+		library:  library,
+		category: category,
+		driver:   driver,
+		folder:   folder,
+	}
+	root := strings.TrimSuffix(archive.Name, ".zip")
+	archive.addEntries(files, folder, root)
+
+	if err := models.CheckArchiveSize(archive.Files, archive.Size); err != nil {
+		return nil, err
+	}
+	return archive, nil
+}
+
+// addEntries adds the listed files below folder to the archive, keeping
+// their folder structure under root
+func (a *DocumentArchive) addEntries(files []DropboxFileInfo, folder, root string) {
+	for _, file := range files {
+		if file.IsFolder {
+			a.addEntries(file.Children, folder, root)
+			continue
+		}
+		relative := file.Path
+		if folder != "" {
+			relative = file.Path[len(folder)+1:]
+		}
+		a.entries = append(a.entries, ziparchive.Entry{
+			Path:     path.Join(root, relative),
+			Source:   categoryItemPath(a.category, file.Path),
+			Size:     int64(file.Size),
+			Modified: file.ModifiedTime,
+			Store:    models.IsCompressedDocument(file.Name),
+		})
+		a.paths = append(a.paths, file.Path)
+		a.revs = append(a.revs, file.Rev)
+		a.Files++
+		a.Size += int64(file.Size)
+	}
+}
+
+// findListedPath finds a file or folder in a listing by its path, ignoring
+// case as Dropbox does
+func findListedPath(files []DropboxFileInfo, itemPath string) *DropboxFileInfo {
+	for i := range files {
+		if strings.EqualFold(files[i].Path, itemPath) {
+			return &files[i]
+		}
+		if files[i].IsFolder && strings.HasPrefix(strings.ToLower(itemPath), strings.ToLower(files[i].Path)+"/") {
+			return findListedPath(files[i].Children, itemPath)
+		}
+	}
+	return nil
+}
+
+// WriteArchive fetches the files of a prepared archive from storage and
+// streams the ZIP to w. One audit entry records the files included and
+// whether the download completed; a completed download also counts as a
+// download of each file for analytics.
+func (s *DocumentFileService) WriteArchive(
+	ctx context.Context,
+	archive *DocumentArchive,
+	w io.Writer,
+	downloadedBy *models.User,
+	ipAddress string,
+) error {
+	started := time.Now()
+	writer := &ziparchive.Archive{
+		// A file changed since the archive was prepared is fetched as it is
+		// now; the size cap is checked again against what was fetched
+		Open: func(ctx context.Context, entry ziparchive.Entry) (io.ReadCloser, error) {
+			content, _, err := archive.driver.Open(ctx, entry.Source)
+			return content, fromStorageError(err)
+		},
+		Workers:  archiveWorkers,
+		MaxBytes: models.MaxArchiveSize,
+	}
+	err := writer.Write(ctx, w, archive.entries)
+
+	paths := make([]string, 0, len(archive.entries))
+	for _, entry := range archive.entries {
+		paths = append(paths, entry.Path)
+	}
+	details := bson.M{
+		"library":     archive.library.Slug,
+		"category_id": archive.category.ID.Hex(),
+		"folder":      archive.folder,
+		"files":       paths,
+		"file_count":  archive.Files,
+		"size":        archive.Size,
+		"completed":   err == nil,
+		"duration_ms": time.Since(started).Milliseconds(),
+	}
+	if err != nil {
+		details["error"] = err.Error()
+	}
+	// The request context may be cancelled by the client going away
+	s.auditRepo.Create(context.Background(), &models.AuditLog{
+		UserID:      &downloadedBy.ID,
+		PerformedBy: &downloadedBy.ID,
+		Action:      models.AuditActionDocumentArchiveDownloaded,
+		Details:     details,
+		IPAddress:   ipAddress,
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+
+	revs := make(map[string]string, len(archive.entries))
+	for i := range archive.entries {
+		revs[archive.paths[i]] = archive.revs[i]
+	}
+	s.categoryService.recordDownloads(context.Background(), models.DownloadSourceArchive, downloadedBy, archive.library, archive.category, revs)
+	return nil
+}
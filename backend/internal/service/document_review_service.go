@@ -0,0 +1,429 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"backend/internal/models"
+	"backend/internal/repository"
+	"backend/internal/storage"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var ErrInvalidDocumentOwner = errors.New("document owner must be an active user who can view the library")
+
+// reviewCheckInterval is how often owners of documents due for review are
+// reminded
+const reviewCheckInterval = 6 * time.Hour
+
+// DocumentReviewService manages the ownership and review cadence of library
+// documents. A background job emails owners once per review date when the
+// review falls within models.ReviewNoticePeriod.
+type DocumentReviewService struct {
+	metadataRepo    *repository.DocumentMetadataRepository
+	userRepo        *repository.UserRepository
+	libraryRepo     *repository.LibraryRepository
+	categoryRepo    *repository.LibraryCategoryRepository
+	auditRepo       *repository.AuditRepository
+	categoryService *LibraryCategoryService
+	emailService    *EmailService
+	registryService *RegistryService
+	ticker          *time.Ticker
+	done            chan bool
+	isRunning       bool
+}
+
+// NewDocumentReviewService creates a new DocumentReviewService
+func NewDocumentReviewService(
+	metadataRepo *repository.DocumentMetadataRepository,
+	userRepo *repository.UserRepository,
+	libraryRepo *repository.LibraryRepository,
+	categoryRepo *repository.LibraryCategoryRepository,
+	auditRepo *repository.AuditRepository,
+	categoryService *LibraryCategoryService,
+	emailService *EmailService,
+	registryService *RegistryService,
+) *DocumentReviewService {
+	return &DocumentReviewService{
+		metadataRepo:    metadataRepo,
+		userRepo:        userRepo,
+		libraryRepo:     libraryRepo,
+		categoryRepo:    categoryRepo,
+		auditRepo:       auditRepo,
+		categoryService: categoryService,
+		emailService:    emailService,
+		registryService: registryService,
+		done:            make(chan bool),
+	}
+}
+
+// Start begins sending review reminders in the background
+func (s *DocumentReviewService) Start() {
+	if s.isRunning {
+		fmt.Println("Document review reminders are already running")
+		return
+	}
+
+	s.ticker = time.NewTicker(reviewCheckInterval)
+	s.isRunning = true
+
+	fmt.Printf("Starting document review reminders (every %s)\n", reviewCheckInterval)
+
+	go func() {
+		s.sendReminders()
+		for {
+			select {
+			case <-s.ticker.C:
+				s.sendReminders()
+			case <-s.done:
+				fmt.Println("Document review reminders stopped")
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the background reminders
+func (s *DocumentReviewService) Stop() {
+	if !s.isRunning {
+		return
+	}
+
+	if s.ticker != nil {
+		s.ticker.Stop()
+	}
+	s.done <- true
+	s.isRunning = false
+	fmt.Println("Stopping document review reminders")
+}
+
+func (s *DocumentReviewService) sendReminders() {
+	sent, err := s.SendDueReminders(context.Background())
+	if err != nil {
+		fmt.Printf("Warning: failed to send document review reminders: %v\n", err)
+		return
+	}
+	if sent > 0 {
+		fmt.Printf("Sent %d document review reminder(s)\n", sent)
+	}
+}
+
+// GetMetadata returns the review metadata of a file in a category. Files
+// without metadata are reported as approved with no owner or review date.
+func (s *DocumentReviewService) GetMetadata(
+	ctx context.Context,
+	librarySlug string,
+	categoryID primitive.ObjectID,
+	filePath string,
+	user *models.User,
+) (*models.DocumentMetadata, error) {
+	_, category, err := s.categoryService.getCategory(ctx, librarySlug, categoryID, user)
+	if err != nil {
+		return nil, err
+	}
+
+	filePath, err = models.CleanDocumentItemPath(filePath)
+	if err != nil {
+		return nil, err
+	}
+	metadata, err := s.findMetadata(ctx, category, filePath)
+	if err != nil {
+		return nil, err
+	}
+	metadata.ReviewState = metadata.ReviewStateAt(time.Now())
+	return metadata, nil
+}
+
+// UpdateMetadata changes the status, owner or review cadence of a file, or
+// records a review of it
+func (s *DocumentReviewService) UpdateMetadata(
+	ctx context.Context,
+	librarySlug string,
+	categoryID primitive.ObjectID,
+	filePath string,
+	req *models.UpdateDocumentMetadataRequest,
+	updatedBy *models.User,
+	ipAddress string,
+) (*models.DocumentMetadata, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	library, category, err := s.categoryService.managedCategory(ctx, librarySlug, categoryID, updatedBy)
+	if err != nil {
+		return nil, err
+	}
+
+	// The path must stay inside the category folder
+	filePath, err = models.CleanDocumentItemPath(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if driver := s.categoryService.storageService.ForLibrary(library); driver.IsConfigured() {
+		object, err := driver.Metadata(ctx, storagePath(categoryFilePath(category.DropboxPath, filePath)))
+		if err == storage.ErrNotFound || (err == nil && object.IsFolder) {
+			return nil, ErrDocumentNotFound
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to find document: %w", err)
+		}
+	}
+
+	metadata, err := s.findMetadata(ctx, category, filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.OwnerID != nil {
+		metadata.OwnerID = nil
+		if *req.OwnerID != "" {
+			owner, err := s.resolveOwner(ctx, library, *req.OwnerID)
+			if err != nil {
+				return nil, err
+			}
+			metadata.OwnerID = &owner.ID
+		}
+	}
+	req.Apply(metadata, time.Now())
+	metadata.UpdatedBy = &updatedBy.ID
+
+	if err := s.metadataRepo.Save(ctx, metadata); err != nil {
+		return nil, fmt.Errorf("failed to save document metadata: %w", err)
+	}
+
+	s.auditRepo.Create(ctx, &models.AuditLog{
+		UserID:      &updatedBy.ID,
+		PerformedBy: &updatedBy.ID,
+		Action:      models.AuditActionDocumentMetadataUpdated,
+		Details: bson.M{
+			"library":     library.Slug,
+			"category_id": category.ID.Hex(),
+			"path":        metadata.Path,
+			"status":      metadata.Status,
+			"reviewed":    req.Reviewed,
+		},
+		IPAddress: ipAddress,
+	})
+
+	metadata.ReviewState = metadata.ReviewStateAt(time.Now())
+	return metadata, nil
+}
+
+// findMetadata returns the stored metadata of a file, or new approved
+// metadata when it has none
+func (s *DocumentReviewService) findMetadata(ctx context.Context, category *models.LibraryCategory, filePath string) (*models.DocumentMetadata, error) {
+	if filePath == "" {
+		return nil, ErrDocumentNotFound
+	}
+
+	metadata, err := s.metadataRepo.FindByFile(ctx, category.ID, strings.ToLower(filePath))
+	if err == repository.ErrDocumentMetadataNotFound {
+		return &models.DocumentMetadata{
+			LibraryID:  category.LibraryID,
+			CategoryID: category.ID,
+			Path:       filePath,
+			PathLower:  strings.ToLower(filePath),
+			Status:     models.DocumentStatusApproved,
+		}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get document metadata: %w", err)
+	}
+	return metadata, nil
+}
+
+// resolveOwner checks that a user can own documents in the library
+func (s *DocumentReviewService) resolveOwner(ctx context.Context, library *models.Library, ownerID string) (*models.User, error) {
+	id, err := primitive.ObjectIDFromHex(ownerID)
+	if err != nil {
+		return nil, ErrInvalidDocumentOwner
+	}
+	owner, err := s.userRepo.FindByID(ctx, id)
+	if err != nil {
+		if err == repository.ErrUserNotFound {
+			return nil, ErrInvalidDocumentOwner
+		}
+		return nil, fmt.Errorf("failed to get owner: %w", err)
+	}
+	if !owner.IsActive || !library.CanView(owner) {
+		return nil, ErrInvalidDocumentOwner
+	}
+	return owner, nil
+}
+
+// SendDueReminders emails the owners of documents due for review within the
+// notice period, once per review date. It returns the number of emails sent.
+func (s *DocumentReviewService) SendDueReminders(ctx context.Context) (int, error) {
+	now := time.Now()
+	due, err := s.metadataRepo.FindDueBefore(ctx, now.Add(models.ReviewNoticePeriod))
+	if err != nil {
+		return 0, fmt.Errorf("failed to find documents due for review: %w", err)
+	}
+
+	pending := map[primitive.ObjectID][]*models.DocumentMetadata{}
+	owners := []primitive.ObjectID{}
+	for _, metadata := range due {
+		if metadata.OwnerID == nil {
+			continue
+		}
+		if metadata.ReminderSentFor != nil && metadata.ReminderSentFor.Equal(*metadata.NextReviewAt) {
+			continue
+		}
+		if _, ok := pending[*metadata.OwnerID]; !ok {
+			owners = append(owners, *metadata.OwnerID)
+		}
+		pending[*metadata.OwnerID] = append(pending[*metadata.OwnerID], metadata)
+	}
+	if len(owners) == 0 {
+		return 0, nil
+	}
+
+	smtpConfig, err := s.registryService.GetPublicSMTPConfig(ctx)
+	if err != nil || smtpConfig == nil || !smtpConfig.IsComplete() {
+		return 0, ErrIncompleteSMTPConfig
+	}
+
+	places, err := s.documentPlaces(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, ownerID := range owners {
+		owner, err := s.userRepo.FindByID(ctx, ownerID)
+		if err != nil || !owner.IsActive {
+			continue
+		}
+
+		items := []DocumentReviewReminderItem{}
+		for _, metadata := range pending[ownerID] {
+			place, ok := places[metadata.CategoryID]
+			if !ok {
+				continue
+			}
+			items = append(items, DocumentReviewReminderItem{
+				DocumentName: path.Base(metadata.Path),
+				CategoryName: place.category.Name,
+				LibraryName:  place.library.Name,
+				DueAt:        *metadata.NextReviewAt,
+			})
+		}
+		if len(items) == 0 {
+			continue
+		}
+
+		userName := strings.TrimSpace(owner.FullName())
+		if userName == "" {
+			userName = owner.Username
+		}
+		if err := s.emailService.SendDocumentReviewReminderEmail(*smtpConfig, owner.Email, userName, items); err != nil {
+			fmt.Printf("Warning: Failed to send document review reminder to %s: %v\n", owner.Email, err)
+			continue
+		}
+		sent++
+
+		for _, metadata := range pending[ownerID] {
+			if err := s.metadataRepo.MarkReminderSent(ctx, metadata.ID, *metadata.NextReviewAt); err != nil {
+				fmt.Printf("Warning: failed to record review reminder for %s: %v\n", metadata.Path, err)
+			}
+		}
+	}
+
+	return sent, nil
+}
+
+// OverdueReport lists the documents overdue for review across every library,
+// most overdue first. With includeDueSoon, documents due within the notice
+// period are listed after them.
+func (s *DocumentReviewService) OverdueReport(ctx context.Context, includeDueSoon bool) ([]models.DocumentReviewItem, error) {
+	now := time.Now()
+	before := now
+	if includeDueSoon {
+		before = now.Add(models.ReviewNoticePeriod)
+	}
+
+	due, err := s.metadataRepo.FindDueBefore(ctx, before)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find documents due for review: %w", err)
+	}
+	items := []models.DocumentReviewItem{}
+	if len(due) == 0 {
+		return items, nil
+	}
+
+	places, err := s.documentPlaces(ctx)
+	if err != nil {
+		return nil, err
+	}
+	owners := map[primitive.ObjectID]*models.User{}
+
+	for _, metadata := range due {
+		place, ok := places[metadata.CategoryID]
+		if !ok {
+			continue
+		}
+
+		item := models.DocumentReviewItem{
+			Name:         path.Base(metadata.Path),
+			Path:         metadata.Path,
+			LibrarySlug:  place.library.Slug,
+			LibraryName:  place.library.Name,
+			CategoryID:   place.category.ID.Hex(),
+			CategoryName: place.category.Name,
+			Status:       metadata.Status,
+			ReviewState:  metadata.ReviewStateAt(now),
+			NextReviewAt: *metadata.NextReviewAt,
+		}
+		if item.ReviewState == models.ReviewStateOverdue {
+			item.DaysOverdue = int(now.Sub(*metadata.NextReviewAt).Hours() / 24)
+		}
+
+		if metadata.OwnerID != nil {
+			owner, ok := owners[*metadata.OwnerID]
+			if !ok {
+				owner, _ = s.userRepo.FindByID(ctx, *metadata.OwnerID)
+				owners[*metadata.OwnerID] = owner
+			}
+			item.OwnerID = metadata.OwnerID.Hex()
+			if owner != nil {
+				item.OwnerName = strings.TrimSpace(owner.FullName())
+				item.OwnerEmail = owner.Email
+			}
+		}
+
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+// documentPlaces maps every category to itself and its library
+func (s *DocumentReviewService) documentPlaces(ctx context.Context) (map[primitive.ObjectID]visibleCategory, error) {
+	libraries, err := s.libraryRepo.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list libraries: %w", err)
+	}
+	byID := map[primitive.ObjectID]*models.Library{}
+	for _, library := range libraries {
+		byID[library.ID] = library
+	}
+
+	categories, err := s.categoryRepo.FindAll(ctx, repository.LibraryCategoryFilter{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list categories: %w", err)
+	}
+
+	places := map[primitive.ObjectID]visibleCategory{}
+	for _, category := range categories {
+		if library, ok := byID[category.LibraryID]; ok {
+			places[category.ID] = visibleCategory{library: library, category: category}
+		}
+	}
+	return places, nil
+}
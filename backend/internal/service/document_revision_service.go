@@ -0,0 +1,318 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"backend/internal/models"
+	"backend/internal/repository"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrDocumentNotFound = errors.New("document not found")
+	ErrRevisionNotFound = errors.New("revision not found")
+)
+
+const (
+	// DefaultFeedLimit and MaxFeedLimit bound the recently updated feed
+	DefaultFeedLimit = 20
+	MaxFeedLimit     = 100
+)
+
+// DocumentRevisionService records the revisions of library documents and
+// serves their history and the recently updated feed.
+//
+// Revisions are recorded from the document events published when listing
+// syncs find changed files. A file's history is topped up from Dropbox's own
+// revision list when it is requested, so files that have not changed since
+// they were first synced still have a history.
+type DocumentRevisionService struct {
+	revisionRepo    *repository.DocumentRevisionRepository
+	visitRepo       *repository.DocumentFeedVisitRepository
+	libraryRepo     *repository.LibraryRepository
+	categoryRepo    *repository.LibraryCategoryRepository
+	categoryService *LibraryCategoryService
+	connections     *DropboxConnectionService
+}
+
+// NewDocumentRevisionService creates a new DocumentRevisionService
+func NewDocumentRevisionService(
+	revisionRepo *repository.DocumentRevisionRepository,
+	visitRepo *repository.DocumentFeedVisitRepository,
+	libraryRepo *repository.LibraryRepository,
+	categoryRepo *repository.LibraryCategoryRepository,
+	categoryService *LibraryCategoryService,
+	connections *DropboxConnectionService,
+) *DocumentRevisionService {
+	return &DocumentRevisionService{
+		revisionRepo:    revisionRepo,
+		visitRepo:       visitRepo,
+		libraryRepo:     libraryRepo,
+		categoryRepo:    categoryRepo,
+		categoryService: categoryService,
+		connections:     connections,
+	}
+}
+
+// HandleDocumentEvents records a revision for each changed file in a
+// category folder
+func (s *DocumentRevisionService) HandleDocumentEvents(events []models.DocumentEvent) {
+	ctx := context.Background()
+	categories := newEventCategories(s.libraryRepo, s.categoryRepo)
+
+	for _, event := range events {
+		owners, err := categories.find(ctx, event)
+		if err != nil {
+			fmt.Printf("Warning: failed to find categories for %s: %v\n", event.Folder, err)
+			continue
+		}
+
+		for _, category := range owners {
+			revision := &models.DocumentRevision{
+				LibraryID:    category.LibraryID,
+				CategoryID:   category.ID,
+				Path:         event.RelativePath,
+				PathLower:    strings.ToLower(event.RelativePath),
+				Name:         event.Name,
+				Rev:          event.Rev,
+				ContentHash:  event.ContentHash,
+				Size:         event.Size,
+				ModifiedTime: event.ModifiedTime,
+				ChangeType:   revisionChangeType(event.Type),
+				RecordedAt:   event.OccurredAt,
+			}
+			if _, err := s.revisionRepo.Record(ctx, revision); err != nil {
+				fmt.Printf("Warning: failed to record revision of %s: %v\n", event.Path, err)
+			}
+		}
+	}
+}
+
+func revisionChangeType(eventType string) string {
+	switch eventType {
+	case models.DocumentEventAdded:
+		return models.RevisionAdded
+	case models.DocumentEventDeleted:
+		return models.RevisionDeleted
+	}
+	return models.RevisionUpdated
+}
+
+// History returns the revisions of a file in a category, newest first
+func (s *DocumentRevisionService) History(
+	ctx context.Context,
+	librarySlug string,
+	categoryID primitive.ObjectID,
+	filePath string,
+	user *models.User,
+) ([]*models.DocumentRevision, error) {
+	library, category, err := s.categoryService.getCategory(ctx, librarySlug, categoryID, user)
+	if err != nil {
+		return nil, err
+	}
+	// Past revisions are only kept by Dropbox
+	if !library.UsesDropbox() {
+		return nil, ErrStorageUnsupported
+	}
+
+	// The path must stay inside the category folder, or revisions of any
+	// file in the Dropbox account would be recorded under the category
+	filePath, err = models.CleanDocumentItemPath(filePath)
+	if err != nil {
+		return nil, err
+	}
+	s.backfill(ctx, s.connections.ForLibrary(library), category, filePath)
+
+	revisions, err := s.revisionRepo.ListByFile(ctx, category.ID, strings.ToLower(filePath))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list revisions: %w", err)
+	}
+	if len(revisions) == 0 {
+		return nil, ErrDocumentNotFound
+	}
+	return revisions, nil
+}
+
+// DownloadRevision opens a previous revision of a file in a category. The
+// caller must close the returned content.
+func (s *DocumentRevisionService) DownloadRevision(
+	ctx context.Context,
+	librarySlug string,
+	categoryID primitive.ObjectID,
+	filePath string,
+	rev string,
+	user *models.User,
+) (*DropboxFileInfo, io.ReadCloser, error) {
+	library, category, err := s.categoryService.getCategory(ctx, librarySlug, categoryID, user)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !library.CanDownload(user) {
+		return nil, nil, ErrUnauthorized
+	}
+	if !library.UsesDropbox() {
+		return nil, nil, ErrStorageUnsupported
+	}
+
+	dropbox := s.connections.ForLibrary(library)
+
+	// Only revisions known to belong to this file may be downloaded; a bare
+	// rev would otherwise open any file in the Dropbox account
+	filePath, err = models.CleanDocumentItemPath(filePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	pathLower := strings.ToLower(filePath)
+	known, err := s.revisionRepo.HasRevision(ctx, category.ID, pathLower, rev)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to find revision: %w", err)
+	}
+	if !known {
+		s.backfill(ctx, dropbox, category, filePath)
+		if known, err = s.revisionRepo.HasRevision(ctx, category.ID, pathLower, rev); err != nil {
+			return nil, nil, fmt.Errorf("failed to find revision: %w", err)
+		}
+	}
+	if !known {
+		return nil, nil, ErrRevisionNotFound
+	}
+
+	info, content, err := dropbox.DownloadRevision(rev)
+	if err != nil {
+		if err == ErrFileNotFound {
+			return nil, nil, ErrRevisionNotFound
+		}
+		return nil, nil, err
+	}
+
+	s.categoryService.recordDownloads(ctx, models.DownloadSourceRevision, user, library, category, map[string]string{filePath: rev})
+	return info, content, nil
+}
+
+// backfill records the revisions Dropbox keeps of a file that are not yet
+// recorded. Failures are logged; the recorded history is still served.
+func (s *DocumentRevisionService) backfill(ctx context.Context, dropbox *DropboxService, category *models.LibraryCategory, filePath string) {
+	if !dropbox.IsConfigured() || filePath == "" {
+		return
+	}
+
+	revisions, err := dropbox.ListRevisions(categoryFilePath(category.DropboxPath, filePath))
+	if err != nil {
+		if err != ErrFileNotFound {
+			fmt.Printf("Warning: failed to list Dropbox revisions of %s: %v\n", filePath, err)
+		}
+		return
+	}
+
+	pathLower := strings.ToLower(filePath)
+	now := time.Now()
+	for _, rev := range revisions {
+		known, err := s.revisionRepo.HasRevision(ctx, category.ID, pathLower, rev.Rev)
+		if err != nil || known {
+			continue
+		}
+		_, err = s.revisionRepo.Record(ctx, &models.DocumentRevision{
+			LibraryID:    category.LibraryID,
+			CategoryID:   category.ID,
+			Path:         filePath,
+			PathLower:    pathLower,
+			Name:         path.Base(filePath),
+			Rev:          rev.Rev,
+			ContentHash:  rev.ContentHash,
+			Size:         int64(rev.Size),
+			ModifiedTime: rev.ModifiedTime,
+			RecordedAt:   now,
+		})
+		if err != nil {
+			fmt.Printf("Warning: failed to record revision of %s: %v\n", filePath, err)
+		}
+	}
+}
+
+// Feed returns the files most recently added or updated in the categories
+// the user can see, marking those changed since the user's last visit.
+// Users who have never visited see changes since their account was created
+// as new.
+func (s *DocumentRevisionService) Feed(ctx context.Context, user *models.User, librarySlug string, limit int) (*models.DocumentFeed, error) {
+	if limit <= 0 {
+		limit = DefaultFeedLimit
+	}
+	if limit > MaxFeedLimit {
+		limit = MaxFeedLimit
+	}
+
+	visible, err := visibleCategories(ctx, s.libraryRepo, s.categoryRepo, user, librarySlug, (*models.Library).CanView)
+	if err != nil {
+		return nil, err
+	}
+
+	lastVisit, err := s.visitRepo.LastVisit(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last visit: %w", err)
+	}
+	newSince := user.CreatedAt
+	if lastVisit != nil {
+		newSince = *lastVisit
+	}
+
+	feed := &models.DocumentFeed{
+		Items:       []models.DocumentFeedItem{},
+		LastVisitAt: lastVisit,
+	}
+	if len(visible) == 0 {
+		return feed, nil
+	}
+
+	categoryIDs := make([]primitive.ObjectID, 0, len(visible))
+	for id := range visible {
+		categoryIDs = append(categoryIDs, id)
+	}
+
+	revisions, err := s.revisionRepo.Recent(ctx, categoryIDs, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list recent revisions: %w", err)
+	}
+	feed.NewCount, err = s.revisionRepo.CountRecentSince(ctx, categoryIDs, newSince)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count new revisions: %w", err)
+	}
+
+	for _, revision := range revisions {
+		owner, ok := visible[revision.CategoryID]
+		if !ok {
+			continue
+		}
+		feed.Items = append(feed.Items, models.DocumentFeedItem{
+			Name:         revision.Name,
+			Path:         revision.Path,
+			LibrarySlug:  owner.library.Slug,
+			LibraryName:  owner.library.Name,
+			CategoryID:   owner.category.ID.Hex(),
+			CategoryName: owner.category.Name,
+			ChangeType:   revision.ChangeType,
+			Rev:          revision.Rev,
+			Size:         revision.Size,
+			ModifiedTime: revision.ModifiedTime,
+			RecordedAt:   revision.RecordedAt,
+			IsNew:        revision.RecordedAt.After(newSince),
+			DownloadURL:  fileDownloadURL(owner.library, owner.category, revision.Path),
+		})
+	}
+
+	return feed, nil
+}
+
+// MarkFeedVisited records that the user has seen the feed, clearing its new markers
+func (s *DocumentRevisionService) MarkFeedVisited(ctx context.Context, user *models.User) error {
+	if err := s.visitRepo.SetLastVisit(ctx, user.ID, time.Now()); err != nil {
+		return fmt.Errorf("failed to record visit: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,374 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"backend/internal/models"
+	"backend/internal/repository"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// downloadPurgeInterval is how often download events past the retention
+// period are removed
+const downloadPurgeInterval = 24 * time.Hour
+
+// DownloadAnalyticsService records downloads of library documents and
+// reports which documents are used, when, and by whom. Download events are
+// kept for the retention period in the analytics settings; a background job
+// removes older ones daily.
+type DownloadAnalyticsService struct {
+	downloadRepo    *repository.DocumentDownloadRepository
+	settingsRepo    *repository.AnalyticsSettingsRepository
+	institutionRepo *repository.InstitutionRepository
+	libraryRepo     *repository.LibraryRepository
+	categoryRepo    *repository.LibraryCategoryRepository
+	storageService  *StorageService
+	auditRepo       *repository.AuditRepository
+	ticker          *time.Ticker
+	done            chan bool
+	isRunning       bool
+}
+
+// NewDownloadAnalyticsService creates a new DownloadAnalyticsService
+func NewDownloadAnalyticsService(
+	downloadRepo *repository.DocumentDownloadRepository,
+	settingsRepo *repository.AnalyticsSettingsRepository,
+	institutionRepo *repository.InstitutionRepository,
+	libraryRepo *repository.LibraryRepository,
+	categoryRepo *repository.LibraryCategoryRepository,
+	storageService *StorageService,
+	auditRepo *repository.AuditRepository,
+) *DownloadAnalyticsService {
+	return &DownloadAnalyticsService{
+		downloadRepo:    downloadRepo,
+		settingsRepo:    settingsRepo,
+		institutionRepo: institutionRepo,
+		libraryRepo:     libraryRepo,
+		categoryRepo:    categoryRepo,
+		storageService:  storageService,
+		auditRepo:       auditRepo,
+		done:            make(chan bool),
+	}
+}
+
+// Start begins removing expired download events in the background
+func (s *DownloadAnalyticsService) Start() {
+	if s.isRunning {
+		fmt.Println("Download analytics purge is already running")
+		return
+	}
+
+	s.ticker = time.NewTicker(downloadPurgeInterval)
+	s.isRunning = true
+
+	fmt.Printf("Starting download analytics purge (every %s)\n", downloadPurgeInterval)
+
+	go func() {
+		s.purge()
+		for {
+			select {
+			case <-s.ticker.C:
+				s.purge()
+			case <-s.done:
+				fmt.Println("Download analytics purge stopped")
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the background purge
+func (s *DownloadAnalyticsService) Stop() {
+	if !s.isRunning {
+		return
+	}
+
+	if s.ticker != nil {
+		s.ticker.Stop()
+	}
+	s.done <- true
+	s.isRunning = false
+	fmt.Println("Stopping download analytics purge")
+}
+
+func (s *DownloadAnalyticsService) purge() {
+	removed, err := s.PurgeExpired(context.Background())
+	if err != nil {
+		fmt.Printf("Warning: failed to purge expired download events: %v\n", err)
+		return
+	}
+	if removed > 0 {
+		fmt.Printf("Removed %d expired download event(s)\n", removed)
+	}
+}
+
+// PurgeExpired removes download events older than the retention period
+func (s *DownloadAnalyticsService) PurgeExpired(ctx context.Context) (int64, error) {
+	settings, err := s.GetSettings(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return s.downloadRepo.DeleteBefore(ctx, settings.RetentionCutoff(time.Now()))
+}
+
+// Record stores download events. Failures are logged so they never stop a
+// download.
+func (s *DownloadAnalyticsService) Record(ctx context.Context, downloads ...*models.DocumentDownload) {
+	if err := s.downloadRepo.CreateMany(ctx, downloads); err != nil {
+		fmt.Printf("Warning: failed to record %d document download(s): %v\n", len(downloads), err)
+	}
+}
+
+// GetSettings returns the analytics settings, or the defaults if none were
+// saved
+func (s *DownloadAnalyticsService) GetSettings(ctx context.Context) (*models.AnalyticsSettings, error) {
+	settings, err := s.settingsRepo.Get(ctx)
+	if err != nil {
+		if err == repository.ErrAnalyticsSettingsNotFound {
+			return models.DefaultAnalyticsSettings(), nil
+		}
+		return nil, fmt.Errorf("failed to get analytics settings: %w", err)
+	}
+	return settings, nil
+}
+
+// UpdateSettings saves the analytics settings and removes download events
+// that fall outside a shortened retention period straight away
+func (s *DownloadAnalyticsService) UpdateSettings(
+	ctx context.Context,
+	req *models.UpdateAnalyticsSettingsRequest,
+	userID primitive.ObjectID,
+	ipAddress string,
+) (*models.AnalyticsSettings, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	settings, err := s.GetSettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	previous := settings.DownloadRetentionDays
+	settings.DownloadRetentionDays = req.DownloadRetentionDays
+	settings.UpdatedBy = &userID
+	if err := s.settingsRepo.Save(ctx, settings); err != nil {
+		return nil, fmt.Errorf("failed to save analytics settings: %w", err)
+	}
+
+	s.auditRepo.Create(ctx, &models.AuditLog{
+		UserID:      &userID,
+		PerformedBy: &userID,
+		Action:      models.AuditActionAnalyticsSettingsUpdated,
+		Details: bson.M{
+			"previous_retention_days": previous,
+			"retention_days":          settings.DownloadRetentionDays,
+		},
+		IPAddress: ipAddress,
+	})
+
+	if settings.DownloadRetentionDays < previous {
+		s.purge()
+	}
+	return settings, nil
+}
+
+// filter turns a query into a download filter, resolving its library
+func (s *DownloadAnalyticsService) filter(ctx context.Context, query models.DownloadAnalyticsQuery) (repository.DocumentDownloadFilter, error) {
+	filter := repository.DocumentDownloadFilter{From: query.From, To: query.To}
+	if query.LibrarySlug != "" {
+		library, err := s.libraryRepo.FindBySlug(ctx, query.LibrarySlug)
+		if err != nil {
+			if err == repository.ErrLibraryNotFound {
+				return filter, ErrLibraryNotFound
+			}
+			return filter, fmt.Errorf("failed to get library: %w", err)
+		}
+		filter.LibraryID = &library.ID
+	}
+	return filter, nil
+}
+
+// TopDocuments returns the most downloaded documents in the query's range
+func (s *DownloadAnalyticsService) TopDocuments(ctx context.Context, query models.DownloadAnalyticsQuery) ([]models.DocumentDownloadCount, error) {
+	filter, err := s.filter(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	counts, err := s.downloadRepo.TopDocuments(ctx, filter, query.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count downloads: %w", err)
+	}
+
+	categories, err := s.categoryRepo.FindAll(ctx, repository.LibraryCategoryFilter{LibraryID: filter.LibraryID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list categories: %w", err)
+	}
+	names := make(map[primitive.ObjectID]string, len(categories))
+	for _, category := range categories {
+		names[category.ID] = category.Name
+	}
+	for i := range counts {
+		counts[i].CategoryName = names[counts[i].CategoryID]
+	}
+	return counts, nil
+}
+
+// DownloadsOverTime counts downloads per period of the query's interval,
+// including periods without downloads
+func (s *DownloadAnalyticsService) DownloadsOverTime(ctx context.Context, query models.DownloadAnalyticsQuery) ([]models.DownloadPeriodCount, error) {
+	filter, err := s.filter(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	counts, err := s.downloadRepo.CountByPeriod(ctx, filter, query.Interval)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count downloads: %w", err)
+	}
+	return models.FillDownloadPeriods(counts, query.From, query.To, query.Interval), nil
+}
+
+// DownloadsByInstitution counts downloads per institution of the
+// downloading users
+func (s *DownloadAnalyticsService) DownloadsByInstitution(ctx context.Context, query models.DownloadAnalyticsQuery) ([]models.DownloadGroupCount, error) {
+	filter, err := s.filter(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	counts, err := s.downloadRepo.CountByInstitution(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count downloads: %w", err)
+	}
+
+	ids := []primitive.ObjectID{}
+	for _, count := range counts {
+		if id, err := primitive.ObjectIDFromHex(count.ID); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	names := map[string]string{}
+	if len(ids) > 0 {
+		institutions, err := s.institutionRepo.FindByIDs(ctx, ids)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get institutions: %w", err)
+		}
+		for _, institution := range institutions {
+			names[institution.ID.Hex()] = institution.Name
+		}
+	}
+
+	for i := range counts {
+		switch name, ok := names[counts[i].ID]; {
+		case counts[i].ID == "":
+			counts[i].Name = "No institution"
+		case ok:
+			counts[i].Name = name
+		default:
+			counts[i].Name = "Removed institution"
+		}
+	}
+	return counts, nil
+}
+
+// DownloadsBySpecialty counts downloads per specialty of the downloading
+// users
+func (s *DownloadAnalyticsService) DownloadsBySpecialty(ctx context.Context, query models.DownloadAnalyticsQuery) ([]models.DownloadGroupCount, error) {
+	filter, err := s.filter(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	counts, err := s.downloadRepo.CountBySpecialty(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count downloads: %w", err)
+	}
+	for i := range counts {
+		if counts[i].ID == "" {
+			counts[i].Name = "No specialty"
+		}
+	}
+	return counts, nil
+}
+
+// NeverDownloaded lists the documents in active categories that nobody
+// downloaded in the query's range. A zero From covers every retained
+// download.
+func (s *DownloadAnalyticsService) NeverDownloaded(ctx context.Context, query models.DownloadAnalyticsQuery) ([]models.UndownloadedDocument, error) {
+	filter, err := s.filter(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	libraries, err := s.libraryRepo.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list libraries: %w", err)
+	}
+	byID := make(map[primitive.ObjectID]*models.Library, len(libraries))
+	for _, library := range libraries {
+		byID[library.ID] = library
+	}
+
+	active := true
+	categories, err := s.categoryRepo.FindAll(ctx, repository.LibraryCategoryFilter{LibraryID: filter.LibraryID, IsActive: &active})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list categories: %w", err)
+	}
+
+	downloaded, err := s.downloadRepo.DownloadedPaths(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find downloaded documents: %w", err)
+	}
+
+	documents := []models.UndownloadedDocument{}
+	for _, category := range categories {
+		library, ok := byID[category.LibraryID]
+		if !ok {
+			continue
+		}
+		files, err := s.storageService.CategoryFiles(ctx, library, category)
+		if err != nil {
+			if err != ErrFolderNotFound {
+				fmt.Printf("Warning: failed to list files of category %s: %v\n", category.ID.Hex(), err)
+			}
+			continue
+		}
+
+		forEachListedFile(files, func(file DropboxFileInfo) {
+			if downloaded[category.ID][strings.ToLower(file.Path)] {
+				return
+			}
+			documents = append(documents, models.UndownloadedDocument{
+				LibrarySlug:  library.Slug,
+				CategoryID:   category.ID,
+				CategoryName: category.Name,
+				Path:         file.Path,
+				Name:         file.Name,
+				ModifiedTime: file.ModifiedTime,
+			})
+		})
+	}
+
+	sort.SliceStable(documents, func(i, j int) bool {
+		return documents[i].ModifiedTime.Before(documents[j].ModifiedTime)
+	})
+	return documents, nil
+}
+
+// forEachListedFile calls fn for every file in a listing tree
+func forEachListedFile(files []DropboxFileInfo, fn func(DropboxFileInfo)) {
+	for _, file := range files {
+		if file.IsFolder {
+			forEachListedFile(file.Children, fn)
+			continue
+		}
+		fn(file)
+	}
+}
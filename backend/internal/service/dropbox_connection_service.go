@@ -0,0 +1,186 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"backend/internal/models"
+	"backend/internal/repository"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrDropboxConnectionNotFound  = errors.New("dropbox connection not found")
+	ErrDropboxConnectionInUse     = errors.New("dropbox connection is used by a library or the registry")
+	ErrDuplicateDropboxConnection = errors.New("a dropbox connection with this name already exists")
+)
+
+// DropboxConnectionService holds a DropboxService for each named Dropbox
+// connection. Libraries and the registry refer to a connection by ID; a nil
+// ID is the default connection, which is the single connection that
+// existed before connections were named.
+type DropboxConnectionService struct {
+	configRepo        *repository.DropboxConfigRepository
+	encryptionService *EncryptionService
+	healthService     *DropboxHealthService
+
+	defaultService *DropboxService
+
+	mu       sync.RWMutex
+	services map[primitive.ObjectID]*DropboxService // Other connections by ID
+}
+
+// NewDropboxConnectionService creates a new DropboxConnectionService and
+// loads every saved connection
+func NewDropboxConnectionService(configRepo *repository.DropboxConfigRepository, encryptionService *EncryptionService) *DropboxConnectionService {
+	ctx := context.Background()
+
+	// A configuration saved before connections were named becomes the default
+	if err := configRepo.NameLegacyConfig(ctx); err != nil {
+		fmt.Printf("Warning: failed to name the existing Dropbox connection: %v\n", err)
+	}
+
+	s := &DropboxConnectionService{
+		configRepo:        configRepo,
+		encryptionService: encryptionService,
+		defaultService:    NewDropboxService(configRepo, encryptionService),
+		services:          map[primitive.ObjectID]*DropboxService{},
+	}
+
+	configs, err := configRepo.List(ctx)
+	if err != nil {
+		fmt.Printf("Warning: failed to list Dropbox connections: %v\n", err)
+		return s
+	}
+	for _, config := range configs {
+		if !config.IsDefault {
+			s.services[config.ID] = newDropboxService(configRepo, encryptionService, config.ID, false)
+		}
+	}
+	return s
+}
+
+// SetHealthService sets the service told the outcome of token refreshes on
+// every connection
+func (s *DropboxConnectionService) SetHealthService(healthService *DropboxHealthService) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.healthService = healthService
+	s.defaultService.SetHealthService(healthService)
+	for _, service := range s.services {
+		service.SetHealthService(healthService)
+	}
+}
+
+// Default returns the default connection
+func (s *DropboxConnectionService) Default() *DropboxService {
+	return s.defaultService
+}
+
+// Get returns a connection by ID, nil being the default. An unknown
+// connection is returned unconfigured, so its operations fail with
+// ErrDropboxNotConfigured.
+func (s *DropboxConnectionService) Get(connectionID *primitive.ObjectID) *DropboxService {
+	if connectionID == nil || *connectionID == s.defaultService.id() {
+		return s.defaultService
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if service, ok := s.services[*connectionID]; ok {
+		return service
+	}
+	return &DropboxService{configRepo: s.configRepo, encryptionService: s.encryptionService, connectionID: *connectionID}
+}
+
+// ForLibrary returns the connection a library keeps its files in
+func (s *DropboxConnectionService) ForLibrary(library *models.Library) *DropboxService {
+	return s.Get(library.DropboxConnectionID)
+}
+
+// All returns every connection, the default first
+func (s *DropboxConnectionService) All() []*DropboxService {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ids := make([]primitive.ObjectID, 0, len(s.services))
+	for id := range s.services {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i].Hex() < ids[j].Hex() })
+
+	all := []*DropboxService{s.defaultService}
+	for _, id := range ids {
+		all = append(all, s.services[id])
+	}
+	return all
+}
+
+// AnyConfigured returns whether at least one connection is authorized
+func (s *DropboxConnectionService) AnyConfigured() bool {
+	for _, service := range s.All() {
+		if service.IsConfigured() {
+			return true
+		}
+	}
+	return false
+}
+
+// Resolve checks that a chosen connection exists and returns how records
+// refer to it: nil for the default connection, whether it was chosen as nil
+// or by its ID
+func (s *DropboxConnectionService) Resolve(ctx context.Context, connectionID *primitive.ObjectID) (*primitive.ObjectID, error) {
+	if connectionID == nil {
+		return nil, nil
+	}
+	config, err := s.configRepo.FindByID(ctx, *connectionID)
+	if err == repository.ErrDropboxConfigNotFound {
+		return nil, ErrDropboxConnectionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get dropbox connection: %w", err)
+	}
+	return config.Ref(), nil
+}
+
+// reload loads a connection's configuration after it was authorized,
+// adding the connection if it is new
+func (s *DropboxConnectionService) reload(ctx context.Context, config *models.DropboxConfig) (*DropboxService, error) {
+	if config.IsDefault {
+		return s.defaultService, s.defaultService.loadConfigFromDB(ctx)
+	}
+
+	s.mu.Lock()
+	service, ok := s.services[config.ID]
+	if !ok {
+		service = &DropboxService{
+			configRepo:        s.configRepo,
+			encryptionService: s.encryptionService,
+			connectionID:      config.ID,
+			healthService:     s.healthService,
+		}
+		s.services[config.ID] = service
+	}
+	s.mu.Unlock()
+
+	return service, service.loadConfigFromDB(ctx)
+}
+
+// remove forgets a deleted connection
+func (s *DropboxConnectionService) remove(config *models.DropboxConfig) {
+	if config.IsDefault {
+		s.defaultService.unload()
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if service, ok := s.services[config.ID]; ok {
+		service.unload()
+		delete(s.services, config.ID)
+	}
+}
@@ -0,0 +1,256 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"backend/internal/models"
+	"backend/internal/repository"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// DropboxAlertCooldownEnv overrides the least time between two "degraded"
+	// emails, as a Go duration such as "30m"
+	DropboxAlertCooldownEnv = "DROPBOX_ALERT_COOLDOWN"
+
+	// dropboxStatusURL is the admin page linked from health emails
+	dropboxStatusURL = "https://workspace.bloodsa.org.za/admin/dropbox"
+
+	// dropboxHealthAlertID identifies the Dropbox banner in the alerts feed
+	dropboxHealthAlertID = "dropbox-connection"
+)
+
+// DropboxHealthService records the outcome of Dropbox token refreshes,
+// keeps a health history of each connection, and emails super admins and
+// the registry notification list when a connection degrades and when it
+// recovers. Each outage is emailed once, and no more often than the
+// cooldown, so a flapping connection does not flood inboxes. Connections
+// are identified as elsewhere: nil is the default connection.
+type DropboxHealthService struct {
+	healthRepo      *repository.DropboxHealthRepository
+	userRepo        *repository.UserRepository
+	registryService *RegistryService
+	emailService    *EmailService
+	threshold       int
+	cooldown        time.Duration
+
+	// Serialises updates of the alert state
+	mu sync.Mutex
+}
+
+// NewDropboxHealthService creates a new DropboxHealthService
+func NewDropboxHealthService(
+	healthRepo *repository.DropboxHealthRepository,
+	userRepo *repository.UserRepository,
+	registryService *RegistryService,
+	emailService *EmailService,
+) *DropboxHealthService {
+	cooldown := models.DropboxAlertCooldown
+	if value := os.Getenv(DropboxAlertCooldownEnv); value != "" {
+		if parsed, err := time.ParseDuration(value); err == nil && parsed >= 0 {
+			cooldown = parsed
+		} else {
+			fmt.Printf("Warning: invalid %s %q, using %s\n", DropboxAlertCooldownEnv, value, cooldown)
+		}
+	}
+
+	return &DropboxHealthService{
+		healthRepo:      healthRepo,
+		userRepo:        userRepo,
+		registryService: registryService,
+		emailService:    emailService,
+		threshold:       models.DropboxAlertThreshold,
+		cooldown:        cooldown,
+	}
+}
+
+// RecordFailure records a failed refresh or connection check of a
+// connection. failures is the number of failures in a row, including this one.
+func (s *DropboxHealthService) RecordFailure(ctx context.Context, connectionID *primitive.ObjectID, name string, err error, failures int, needsReconnection bool) {
+	s.record(ctx, connectionID, name, func(state *models.DropboxAlertState, now time.Time) []models.DropboxHealthEvent {
+		return state.RecordFailure(failures, s.threshold, needsReconnection, err.Error(), now, s.cooldown)
+	})
+}
+
+// RecordSuccess records a successful refresh, connection check or
+// reconnection of a connection
+func (s *DropboxHealthService) RecordSuccess(ctx context.Context, connectionID *primitive.ObjectID, name string) {
+	s.record(ctx, connectionID, name, func(state *models.DropboxAlertState, now time.Time) []models.DropboxHealthEvent {
+		return state.RecordSuccess(now)
+	})
+}
+
+// record applies an outcome to a connection's alert state, saves the events
+// it produces and sends the emails they call for. Emails are sent in the
+// background so token refreshes are not held up by SMTP.
+func (s *DropboxHealthService) record(
+	ctx context.Context,
+	connectionID *primitive.ObjectID,
+	name string,
+	update func(*models.DropboxAlertState, time.Time) []models.DropboxHealthEvent,
+) {
+	// Refreshes run under request contexts that may end at any moment
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+	defer cancel()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, err := s.healthRepo.GetState(ctx, connectionID)
+	if err != nil {
+		fmt.Printf("Warning: failed to load Dropbox alert state: %v\n", err)
+		return
+	}
+	since := state.DegradedSince
+	if name != "" {
+		state.ConnectionName = name
+	}
+
+	events := update(state, time.Now())
+	if len(events) == 0 {
+		return
+	}
+	for i := range events {
+		events[i].ConnectionID = connectionID
+		events[i].ConnectionName = state.ConnectionName
+	}
+	if err := s.healthRepo.SaveState(ctx, state); err != nil {
+		fmt.Printf("Warning: failed to save Dropbox alert state: %v\n", err)
+	}
+	if err := s.healthRepo.AddEvents(ctx, events); err != nil {
+		fmt.Printf("Warning: failed to record Dropbox health events: %v\n", err)
+	}
+
+	for _, event := range events {
+		if !event.Notified {
+			continue
+		}
+		data := DropboxHealthEmailData{
+			Connection:          state.ConnectionName,
+			Recovered:           event.Type == models.DropboxHealthRecovered,
+			ConsecutiveFailures: event.ConsecutiveFailures,
+			LastError:           event.Error,
+			NeedsReconnection:   state.NeedsReconnection,
+			At:                  event.CreatedAt,
+			StatusURL:           dropboxStatusURL,
+		}
+		if since != nil {
+			data.Since = *since
+		}
+		go s.notify(data)
+	}
+}
+
+// notify emails a health change to super admins and the registry
+// notification list
+func (s *DropboxHealthService) notify(data DropboxHealthEmailData) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	config, err := s.registryService.GetConfiguration(ctx)
+	if err != nil || !config.SMTPConfig.IsComplete() {
+		fmt.Println("Warning: SMTP is not configured, Dropbox health email not sent")
+		return
+	}
+
+	recipients := []string{}
+	seen := map[string]bool{}
+	add := func(email string) {
+		key := strings.ToLower(strings.TrimSpace(email))
+		if key != "" && !seen[key] {
+			seen[key] = true
+			recipients = append(recipients, strings.TrimSpace(email))
+		}
+	}
+	admins, err := s.userRepo.List(ctx, bson.M{
+		"role":        models.RoleAdmin,
+		"admin_level": models.AdminLevelSuperAdmin,
+		"is_active":   true,
+	}, 0, 0)
+	if err != nil {
+		fmt.Printf("Warning: failed to list super admins for Dropbox health email: %v\n", err)
+	}
+	for _, admin := range admins {
+		add(admin.Email)
+	}
+	for _, email := range config.NotificationEmails {
+		add(email)
+	}
+
+	if err := s.emailService.SendDropboxHealthEmail(config.SMTPConfig, recipients, data); err != nil {
+		fmt.Printf("Warning: failed to send Dropbox health email: %v\n", err)
+	}
+}
+
+// State returns whether a connection is degraded
+func (s *DropboxHealthService) State(ctx context.Context, connectionID *primitive.ObjectID) (*models.DropboxAlertState, error) {
+	return s.healthRepo.GetState(ctx, connectionID)
+}
+
+// History returns a connection's most recent health events, newest first
+func (s *DropboxHealthService) History(ctx context.Context, connectionID *primitive.ObjectID, limit int64) ([]models.DropboxHealthEvent, error) {
+	return s.healthRepo.ListEvents(ctx, connectionID, limit)
+}
+
+// Forget removes the alert state of a deleted connection, so it no longer
+// shows in the banner
+func (s *DropboxHealthService) Forget(ctx context.Context, connectionID primitive.ObjectID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.healthRepo.DeleteConnection(ctx, connectionID); err != nil {
+		fmt.Printf("Warning: failed to remove Dropbox alert state: %v\n", err)
+	}
+}
+
+// Alerts returns the notices to show a user in the in-app banner, one per
+// degraded connection. Users with the manage system permission also see
+// the error and a link to the Dropbox status page.
+func (s *DropboxHealthService) Alerts(ctx context.Context, user *models.User) ([]models.SystemAlert, error) {
+	alerts := []models.SystemAlert{}
+
+	states, err := s.healthRepo.ListStates(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load Dropbox alert state: %w", err)
+	}
+	for _, state := range states {
+		if !state.Degraded {
+			continue
+		}
+
+		// The default connection keeps the banner ID and wording it had
+		// before connections were named
+		id := dropboxHealthAlertID
+		storage := "Document storage"
+		if state.ConnectionID != nil {
+			id += "-" + state.ConnectionID.Hex()
+			storage = fmt.Sprintf("Document storage (%s)", state.ConnectionName)
+		}
+
+		alert := models.SystemAlert{
+			ID:       id,
+			Severity: "warning",
+			Message:  storage + " is having connection problems. Downloads and registry submissions may fail.",
+			Since:    state.UpdatedAt,
+		}
+		if state.DegradedSince != nil {
+			alert.Since = *state.DegradedSince
+		}
+		if state.NeedsReconnection {
+			alert.Severity = "error"
+			alert.Message = storage + " is disconnected. Downloads and registry submissions are unavailable until it is reconnected."
+		}
+		if user.HasPermission(models.PermManageSystem) {
+			alert.Detail = state.LastError
+			alert.ActionURL = "/admin/dropbox"
+		}
+		alerts = append(alerts, alert)
+	}
+	return alerts, nil
+}
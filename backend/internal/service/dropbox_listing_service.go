@@ -0,0 +1,417 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"backend/internal/dropboxsync"
+	"backend/internal/models"
+	"backend/internal/repository"
+
+	"github.com/dropbox/dropbox-sdk-go-unofficial/v6/dropbox/files"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// listingFreshFor is how long a cached listing is served without
+	// checking Dropbox for changes
+	listingFreshFor = time.Minute
+
+	// listingSyncInterval is how often every cached listing is synced in the background
+	listingSyncInterval = 5 * time.Minute
+
+	// listingUnreadExpiry drops listings nobody has opened for this long
+	listingUnreadExpiry = 30 * 24 * time.Hour
+
+	// listingTouchEvery limits how often reads are recorded
+	listingTouchEvery = time.Hour
+)
+
+// DropboxListingService serves recursive Dropbox folder listings from a
+// cache persisted in MongoDB. Stale listings are returned immediately while
+// they are revalidated in the background, and listings are kept fresh with
+// list_folder cursors so unchanged folders cost one small API call. Each
+// connection has its own listings.
+type DropboxListingService struct {
+	listingRepo *repository.DropboxListingRepository
+	connections *DropboxConnectionService
+	events      *DocumentEventBus
+
+	ticker    *time.Ticker
+	done      chan bool
+	isRunning bool
+
+	// inflight shares one sync between concurrent requests for a folder
+	mu       sync.Mutex
+	inflight map[string]*listingSync
+}
+
+type listingSync struct {
+	done    chan struct{}
+	listing *models.DropboxListing
+	err     error
+}
+
+// NewDropboxListingService creates a new DropboxListingService
+func NewDropboxListingService(listingRepo *repository.DropboxListingRepository, connections *DropboxConnectionService, events *DocumentEventBus) *DropboxListingService {
+	return &DropboxListingService{
+		listingRepo: listingRepo,
+		connections: connections,
+		events:      events,
+		done:        make(chan bool),
+		inflight:    map[string]*listingSync{},
+	}
+}
+
+// Start begins syncing cached listings in the background
+func (s *DropboxListingService) Start() {
+	if s.isRunning {
+		fmt.Println("Dropbox listing sync is already running")
+		return
+	}
+
+	s.ticker = time.NewTicker(listingSyncInterval)
+	s.isRunning = true
+
+	fmt.Printf("Starting Dropbox listing sync (every %s)\n", listingSyncInterval)
+
+	go func() {
+		for {
+			select {
+			case <-s.ticker.C:
+				s.SyncAll(context.Background())
+			case <-s.done:
+				fmt.Println("Dropbox listing sync stopped")
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the background sync
+func (s *DropboxListingService) Stop() {
+	if !s.isRunning {
+		return
+	}
+
+	if s.ticker != nil {
+		s.ticker.Stop()
+	}
+	s.done <- true
+	s.isRunning = false
+	fmt.Println("Stopping Dropbox listing sync")
+}
+
+// Listing returns the file tree of a folder of a connection, relative to
+// the connection's parent folder. A cached listing older than
+// listingFreshFor is returned as is and revalidated in the background.
+func (s *DropboxListingService) Listing(ctx context.Context, dropbox *DropboxService, relativePath string) ([]DropboxFileInfo, error) {
+	fullPath, err := dropbox.resolvePath(relativePath)
+	if err != nil {
+		return nil, err
+	}
+	key := strings.ToLower(fullPath)
+
+	listing, err := s.listingRepo.FindByPath(ctx, dropbox.ref(), key)
+	switch {
+	case err == repository.ErrListingNotFound:
+		listing, err = s.sync(ctx, dropbox, relativePath)
+		if err != nil {
+			return nil, err
+		}
+	case err != nil:
+		return nil, fmt.Errorf("failed to read listing cache: %w", err)
+	default:
+		if time.Since(listing.SyncedAt) > listingFreshFor {
+			s.revalidate(dropbox, relativePath)
+		}
+		if time.Since(listing.LastReadAt) > listingTouchEvery {
+			_ = s.listingRepo.TouchRead(ctx, dropbox.ref(), key, time.Now())
+		}
+	}
+
+	return s.tree(dropbox, listing)
+}
+
+// FreshListing syncs a folder of a connection before returning its file tree
+func (s *DropboxListingService) FreshListing(ctx context.Context, dropbox *DropboxService, relativePath string) ([]DropboxFileInfo, error) {
+	listing, err := s.sync(ctx, dropbox, relativePath)
+	if err != nil {
+		return nil, err
+	}
+	return s.tree(dropbox, listing)
+}
+
+// SyncFolder brings the cached listing of a folder of a connection up to
+// date, publishing document events for any files that changed since the
+// previous sync
+func (s *DropboxListingService) SyncFolder(ctx context.Context, dropbox *DropboxService, relativePath string) error {
+	_, err := s.sync(ctx, dropbox, relativePath)
+	return err
+}
+
+// CachedFolder is a folder with a cached listing
+type CachedFolder struct {
+	ConnectionID *primitive.ObjectID // Nil for the default connection
+	RelativePath string
+}
+
+// CachedFolders returns the folder of every cached listing
+func (s *DropboxListingService) CachedFolders(ctx context.Context) ([]CachedFolder, error) {
+	listings, err := s.listingRepo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	folders := make([]CachedFolder, 0, len(listings))
+	for _, listing := range listings {
+		folders = append(folders, CachedFolder{ConnectionID: listing.ConnectionID, RelativePath: listing.RelativePath})
+	}
+	return folders, nil
+}
+
+// SyncAll syncs every cached listing and drops listings nobody has read recently
+func (s *DropboxListingService) SyncAll(ctx context.Context) {
+	if !s.connections.AnyConfigured() {
+		return
+	}
+
+	if removed, err := s.listingRepo.DeleteUnreadSince(ctx, time.Now().Add(-listingUnreadExpiry)); err != nil {
+		fmt.Printf("Warning: failed to prune Dropbox listings: %v\n", err)
+	} else if removed > 0 {
+		fmt.Printf("Pruned %d unread Dropbox listings\n", removed)
+	}
+
+	listings, err := s.listingRepo.List(ctx)
+	if err != nil {
+		fmt.Printf("Warning: failed to list cached Dropbox listings: %v\n", err)
+		return
+	}
+	for _, listing := range listings {
+		dropbox := s.connections.Get(listing.ConnectionID)
+		if !dropbox.IsConfigured() {
+			continue
+		}
+		if _, err := s.sync(ctx, dropbox, listing.RelativePath); err != nil {
+			fmt.Printf("Warning: failed to sync Dropbox listing %s: %v\n", listing.Path, err)
+		}
+	}
+}
+
+// TriggerSync starts syncing every cached listing in the background
+func (s *DropboxListingService) TriggerSync() error {
+	if !s.connections.AnyConfigured() {
+		return ErrDropboxNotConfigured
+	}
+	go s.SyncAll(context.Background())
+	return nil
+}
+
+// ListCached returns every cached listing without its entries
+func (s *DropboxListingService) ListCached(ctx context.Context) ([]*models.DropboxListing, error) {
+	return s.listingRepo.List(ctx)
+}
+
+// GetCached returns a cached listing of a connection by its full Dropbox path
+func (s *DropboxListingService) GetCached(ctx context.Context, connectionID *primitive.ObjectID, fullPath string) (*models.DropboxListing, error) {
+	return s.listingRepo.FindByPath(ctx, connectionID, strings.ToLower(fullPath))
+}
+
+// Invalidate drops the cached listing of a folder of a connection by its
+// full Dropbox path, or every cached listing when the path is empty. The
+// next read lists the folder in full again.
+func (s *DropboxListingService) Invalidate(ctx context.Context, connectionID *primitive.ObjectID, fullPath string) (int64, error) {
+	if fullPath == "" {
+		return s.listingRepo.DeleteAll(ctx)
+	}
+	if err := s.listingRepo.Delete(ctx, connectionID, strings.ToLower(fullPath)); err != nil {
+		return 0, err
+	}
+	return 1, nil
+}
+
+// InvalidateFolder drops the cached listing of a folder of a connection,
+// relative to the connection's parent folder
+func (s *DropboxListingService) InvalidateFolder(ctx context.Context, dropbox *DropboxService, relativePath string) {
+	fullPath, err := dropbox.resolvePath(relativePath)
+	if err != nil {
+		return
+	}
+	if _, err := s.Invalidate(ctx, dropbox.ref(), fullPath); err != nil && err != repository.ErrListingNotFound {
+		fmt.Printf("Warning: failed to invalidate Dropbox listing %s: %v\n", fullPath, err)
+	}
+}
+
+// ForgetConnection drops the cached listings of a deleted connection
+func (s *DropboxListingService) ForgetConnection(ctx context.Context, connectionID primitive.ObjectID) {
+	if _, err := s.listingRepo.DeleteConnection(ctx, connectionID); err != nil {
+		fmt.Printf("Warning: failed to drop Dropbox listings of a deleted connection: %v\n", err)
+	}
+}
+
+// revalidate syncs a folder in the background
+func (s *DropboxListingService) revalidate(dropbox *DropboxService, relativePath string) {
+	go func() {
+		if _, err := s.sync(context.Background(), dropbox, relativePath); err != nil {
+			fmt.Printf("Warning: failed to revalidate Dropbox listing %s: %v\n", relativePath, err)
+		}
+	}()
+}
+
+// sync brings a folder's cached listing up to date, sharing the work with
+// any sync of the same folder already in progress
+func (s *DropboxListingService) sync(ctx context.Context, dropbox *DropboxService, relativePath string) (*models.DropboxListing, error) {
+	key := dropbox.id().Hex() + ":" + relativePath
+
+	s.mu.Lock()
+	if call, ok := s.inflight[key]; ok {
+		s.mu.Unlock()
+		<-call.done
+		return call.listing, call.err
+	}
+	call := &listingSync{done: make(chan struct{})}
+	s.inflight[key] = call
+	s.mu.Unlock()
+
+	call.listing, call.err = s.syncNow(ctx, dropbox, relativePath)
+
+	s.mu.Lock()
+	delete(s.inflight, key)
+	s.mu.Unlock()
+	close(call.done)
+
+	return call.listing, call.err
+}
+
+func (s *DropboxListingService) syncNow(ctx context.Context, dropbox *DropboxService, relativePath string) (*models.DropboxListing, error) {
+	var listing *models.DropboxListing
+	var events []models.DocumentEvent
+	connectionID := dropbox.ref()
+	err := dropbox.withClientRetry(ctx, func(client files.Client, parentFolder string) error {
+		fullPath := dropbox.getFullPath(relativePath, parentFolder)
+		key := strings.ToLower(fullPath)
+
+		cached, err := s.listingRepo.FindByPath(ctx, connectionID, key)
+		if err == repository.ErrListingNotFound {
+			cached = &models.DropboxListing{Path: key, ConnectionID: connectionID}
+		} else if err != nil {
+			return fmt.Errorf("failed to read listing cache: %w", err)
+		}
+		cached.RelativePath = relativePath
+		previouslySynced := !cached.ID.IsZero()
+
+		result, err := dropboxsync.Sync(client, fullPath, cached)
+		if err != nil {
+			if !cached.ID.IsZero() {
+				_ = s.listingRepo.SetError(ctx, connectionID, key, err.Error())
+			}
+			return fmt.Errorf("failed to list folder: %w", err)
+		}
+
+		now := time.Now()
+		cached.SyncedAt = now
+		cached.LastError = ""
+		if cached.LastReadAt.IsZero() {
+			cached.LastReadAt = now
+		}
+		if err := s.listingRepo.Save(ctx, cached); err != nil {
+			return fmt.Errorf("failed to save listing cache: %w", err)
+		}
+
+		// A folder seen for the first time has nothing to compare against
+		if previouslySynced {
+			events = documentEvents(connectionID, relativePath, key, result.Changes, now)
+		}
+		listing = cached
+		return nil
+	})
+	if err == nil && s.events != nil {
+		s.events.Publish(events)
+	}
+	return listing, err
+}
+
+// belowFolder returns an entry's display path relative to the folder at root
+func belowFolder(entry models.DropboxListingEntry, root string) string {
+	relative := strings.TrimPrefix(entry.PathLower, root)
+	if len(relative) <= len(entry.PathDisplay) {
+		relative = entry.PathDisplay[len(entry.PathDisplay)-len(relative):]
+	}
+	return strings.TrimPrefix(relative, "/")
+}
+
+// documentEvents turns the file changes of a sync of the folder at root, a
+// lower-cased full path, into document events
+func documentEvents(connectionID *primitive.ObjectID, folder, root string, changes []dropboxsync.Change, at time.Time) []models.DocumentEvent {
+	var events []models.DocumentEvent
+	for _, change := range changes {
+		if change.Entry.IsFolder {
+			continue
+		}
+		event := models.DocumentEvent{
+			ConnectionID: connectionID,
+			Folder:       folder,
+			Path:         change.Entry.PathDisplay,
+			RelativePath: belowFolder(change.Entry, root),
+			Name:         change.Entry.Name,
+			Size:         change.Entry.Size,
+			ModifiedTime: change.Entry.ModifiedTime,
+			Rev:          change.Entry.Rev,
+			ContentHash:  change.Entry.ContentHash,
+			OccurredAt:   at,
+		}
+		switch change.Type {
+		case dropboxsync.ChangeAdded:
+			event.Type = models.DocumentEventAdded
+		case dropboxsync.ChangeUpdated:
+			event.Type = models.DocumentEventUpdated
+		case dropboxsync.ChangeDeleted:
+			event.Type = models.DocumentEventDeleted
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+// tree builds the file tree of a listing, leaving out empty folders as the
+// uncached listing did
+func (s *DropboxListingService) tree(dropbox *DropboxService, listing *models.DropboxListing) ([]DropboxFileInfo, error) {
+	if listing.Missing {
+		return nil, ErrFolderNotFound
+	}
+
+	children := map[string][]models.DropboxListingEntry{}
+	for _, entry := range listing.Entries {
+		parent := path.Dir(entry.PathLower)
+		children[parent] = append(children[parent], entry)
+	}
+
+	var build func(parent string) []DropboxFileInfo
+	build = func(parent string) []DropboxFileInfo {
+		var out []DropboxFileInfo
+		for _, entry := range children[parent] {
+			info := DropboxFileInfo{
+				Name:         entry.Name,
+				Path:         entry.PathDisplay,
+				Size:         uint64(entry.Size),
+				ModifiedTime: entry.ModifiedTime,
+				IsFolder:     entry.IsFolder,
+				Rev:          entry.Rev,
+			}
+			if entry.IsFolder {
+				info.Children = build(entry.PathLower)
+			}
+			out = append(out, info)
+		}
+		sort.Slice(out, func(i, j int) bool {
+			return strings.ToLower(out[i].Name) < strings.ToLower(out[j].Name)
+		})
+		return out
+	}
+
+	return dropbox.filterEmptyFolders(build(listing.Path)), nil
+}
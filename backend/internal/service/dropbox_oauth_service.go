@@ -1,211 +1,311 @@
 package service
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
-	"net/http"
-	"net/url"
+	"strings"
+	"sync"
 	"time"
 
+	"backend/internal/dropboxoauth"
 	"backend/internal/models"
 	"backend/internal/repository"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 var (
 	ErrOAuthConfigNotFound = errors.New("oauth configuration not found in environment")
 	ErrInvalidAuthCode     = errors.New("invalid authorization code")
+	ErrInvalidOAuthState   = errors.New("invalid or expired authorization state")
 )
 
-// DropboxOAuthService handles Dropbox OAuth operations for admin
+const (
+	// dropboxHealthHistoryLimit is how many health events the status includes
+	dropboxHealthHistoryLimit = 50
+
+	// dropboxAuthRequestTTL is how long an admin has to complete an authorization
+	dropboxAuthRequestTTL = 15 * time.Minute
+)
+
+// DropboxOAuthService handles Dropbox OAuth operations for admin. Each
+// operation applies to one connection; a nil connection ID is the default.
 type DropboxOAuthService struct {
-	configRepo        *repository.DropboxConfigRepository
-	auditRepo         *repository.AuditRepository
-	encryptionService *EncryptionService
-	dropboxService    *DropboxService
+	configRepo         *repository.DropboxConfigRepository
+	authRequestRepo    *repository.DropboxAuthRequestRepository
+	auditRepo          *repository.AuditRepository
+	libraryRepo        *repository.LibraryRepository
+	registryConfigRepo *repository.RegistryConfigRepository
+	encryptionService  *EncryptionService
+	connections        *DropboxConnectionService
+	listingService     *DropboxListingService
 }
 
 // NewDropboxOAuthService creates a new DropboxOAuthService
 func NewDropboxOAuthService(
 	configRepo *repository.DropboxConfigRepository,
+	authRequestRepo *repository.DropboxAuthRequestRepository,
 	auditRepo *repository.AuditRepository,
+	libraryRepo *repository.LibraryRepository,
+	registryConfigRepo *repository.RegistryConfigRepository,
 	encryptionService *EncryptionService,
-	dropboxService *DropboxService,
+	connections *DropboxConnectionService,
+	listingService *DropboxListingService,
 ) *DropboxOAuthService {
 	return &DropboxOAuthService{
-		configRepo:        configRepo,
-		auditRepo:         auditRepo,
-		encryptionService: encryptionService,
-		dropboxService:    dropboxService,
+		configRepo:         configRepo,
+		authRequestRepo:    authRequestRepo,
+		auditRepo:          auditRepo,
+		libraryRepo:        libraryRepo,
+		registryConfigRepo: registryConfigRepo,
+		encryptionService:  encryptionService,
+		connections:        connections,
+		listingService:     listingService,
 	}
 }
 
-// GenerateAuthorizationURL generates the Dropbox OAuth authorization URL for admin to visit
-func (s *DropboxOAuthService) GenerateAuthorizationURL(appKey, redirectURI string) (string, error) {
-	if appKey == "" {
-		return "", ErrOAuthConfigNotFound
-	}
-
-	// Build authorization URL
-	// Using code flow with offline access (to get refresh token)
-	params := url.Values{}
-	params.Add("client_id", appKey)
-	params.Add("response_type", "code")
-	params.Add("token_access_type", "offline") // Request refresh token
-
-	// Include redirect_uri if provided
-	// If redirect_uri is provided, it must match exactly in token exchange
-	if redirectURI != "" {
-		params.Add("redirect_uri", redirectURI)
-		fmt.Printf("DEBUG: Authorization URL includes redirect_uri: %s\n", redirectURI)
+// getConfig returns a connection's configuration, nil being the default
+func (s *DropboxOAuthService) getConfig(ctx context.Context, connectionID *primitive.ObjectID) (*models.DropboxConfig, error) {
+	var config *models.DropboxConfig
+	var err error
+	if connectionID == nil {
+		config, err = s.configRepo.GetDefault(ctx)
 	} else {
-		fmt.Println("DEBUG: Authorization URL without redirect_uri - user will copy code manually")
+		config, err = s.configRepo.FindByID(ctx, *connectionID)
 	}
+	if err == repository.ErrDropboxConfigNotFound {
+		return nil, ErrDropboxConnectionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get config: %w", err)
+	}
+	return config, nil
+}
 
-	authURL := fmt.Sprintf("https://www.dropbox.com/oauth2/authorize?%s", params.Encode())
-	fmt.Printf("DEBUG: Generated authorization URL (length: %d)\n", len(authURL))
-	return authURL, nil
+// DropboxAuthorization is an authorization an admin has started. The admin
+// visits URL, then completes the flow with the code and State.
+type DropboxAuthorization struct {
+	URL       string                   `json:"authUrl"`
+	State     string                   `json:"state"`
+	Method    models.DropboxAuthMethod `json:"method"`
+	ExpiresAt time.Time                `json:"expiresAt"`
 }
 
-// ExchangeCodeForTokens exchanges the authorization code for access and refresh tokens
-func (s *DropboxOAuthService) ExchangeCodeForTokens(
+// StartAuthorization begins authorizing the app and returns the URL for
+// the admin to visit. Without an app secret the PKCE flow is used, so no
+// secret is stored; the code verifier stays on the server. The state is
+// bound to the admin and their session and must be sent back with the code.
+//
+// The authorization re-authorizes the connection with connectionID, or
+// with neither an ID nor a name the default connection. With only a name
+// it creates a new connection; with both it also renames the connection.
+func (s *DropboxOAuthService) StartAuthorization(
 	ctx context.Context,
-	code, appKey, appSecret, redirectURI, parentFolder string,
-	createdBy *models.User,
-	ipAddress string,
-) (*models.DropboxConfig, error) {
-	if code == "" {
-		return nil, ErrInvalidAuthCode
-	}
-	if appKey == "" || appSecret == "" {
+	appKey, appSecret, redirectURI, parentFolder string,
+	connectionID *primitive.ObjectID,
+	connectionName string,
+	user *models.User,
+	sessionToken string,
+) (*DropboxAuthorization, error) {
+	if appKey == "" {
 		return nil, ErrOAuthConfigNotFound
 	}
+	connectionName = strings.TrimSpace(connectionName)
+	if connectionName != "" {
+		if err := models.ValidateDropboxConnectionName(connectionName); err != nil {
+			return nil, err
+		}
+	}
+	if connectionID != nil {
+		if _, err := s.getConfig(ctx, connectionID); err != nil {
+			return nil, err
+		}
+	}
 
-	// Prepare token request (form-urlencoded)
-	formData := url.Values{}
-	formData.Set("code", code)
-	formData.Set("grant_type", "authorization_code")
-	formData.Set("client_id", appKey)
-	formData.Set("client_secret", appSecret)
+	state, err := dropboxoauth.NewState()
+	if err != nil {
+		return nil, err
+	}
 
-	// Include redirect_uri if it was provided (must match authorization URL)
-	// According to Dropbox docs: if redirect_uri was used in authorization, it must be included here
-	if redirectURI != "" {
-		formData.Set("redirect_uri", redirectURI)
-		fmt.Printf("DEBUG: Including redirect_uri in token exchange: %s\n", redirectURI)
-	} else {
-		fmt.Println("DEBUG: No redirect_uri provided - using manual code flow")
+	request := &models.DropboxAuthRequest{
+		StateHash:    dropboxoauth.Hash(state),
+		UserID:       user.ID,
+		SessionHash:  dropboxoauth.Hash(sessionToken),
+		Method:       models.DropboxAuthSecret,
+		AppKey:       appKey,
+		RedirectURI:  redirectURI,
+		ParentFolder: parentFolder,
+		ExpiresAt:    time.Now().Add(dropboxAuthRequestTTL),
+
+		ConnectionID:   connectionID,
+		ConnectionName: connectionName,
 	}
 
-	fmt.Printf("DEBUG: Exchanging authorization code (length: %d)\n", len(code))
-	fmt.Printf("DEBUG: Request form data (without secrets): grant_type=authorization_code&client_id=%s&redirect_uri=%s\n", appKey, redirectURI)
+	challenge := ""
+	if appSecret == "" {
+		verifier, err := dropboxoauth.NewVerifier()
+		if err != nil {
+			return nil, err
+		}
+		request.Method = models.DropboxAuthPKCE
+		if request.CodeVerifier, err = s.encryptionService.Encrypt(verifier); err != nil {
+			return nil, fmt.Errorf("failed to encrypt code verifier: %w", err)
+		}
+		challenge = dropboxoauth.Challenge(verifier)
+	} else if request.AppSecret, err = s.encryptionService.Encrypt(appSecret); err != nil {
+		return nil, fmt.Errorf("failed to encrypt app secret: %w", err)
+	}
 
-	// Encode form data
-	formDataStr := formData.Encode()
-	fmt.Printf("DEBUG: Form data encoded (length: %d bytes)\n", len(formDataStr))
+	client := &dropboxoauth.Client{AppKey: appKey}
+	authURL, err := client.AuthorizationURL(redirectURI, state, challenge)
+	if err != nil {
+		return nil, err
+	}
 
-	// Call Dropbox token endpoint with form-urlencoded data and timeout
-	fmt.Println("DEBUG: Creating HTTP request to Dropbox token endpoint...")
+	if err := s.authRequestRepo.Create(ctx, request); err != nil {
+		return nil, fmt.Errorf("failed to save authorization request: %w", err)
+	}
 
-	// Create context with timeout to ensure we don't hang
-	reqCtx, cancel := context.WithTimeout(ctx, 20*time.Second)
-	defer cancel()
+	return &DropboxAuthorization{
+		URL:       authURL,
+		State:     state,
+		Method:    request.Method,
+		ExpiresAt: request.ExpiresAt,
+	}, nil
+}
 
-	httpClient := &http.Client{
-		Timeout: 20 * time.Second,
-		Transport: &http.Transport{
-			DisableKeepAlives: true, // Prevent connection pooling issues
-		},
+// CompleteAuthorization exchanges the code from Dropbox for tokens and
+// saves the configuration. The state must be one this admin started in the
+// same session; it is used up whether or not the exchange succeeds.
+func (s *DropboxOAuthService) CompleteAuthorization(
+	ctx context.Context,
+	code, state string,
+	user *models.User,
+	sessionToken string,
+	ipAddress string,
+) (*models.DropboxConfig, error) {
+	if code == "" {
+		return nil, ErrInvalidAuthCode
+	}
+	if state == "" {
+		return nil, ErrInvalidOAuthState
 	}
 
-	req, err := http.NewRequestWithContext(reqCtx, http.MethodPost, "https://api.dropbox.com/oauth2/token", bytes.NewBufferString(formDataStr))
+	request, err := s.authRequestRepo.Consume(ctx, dropboxoauth.Hash(state))
+	if err == repository.ErrDropboxAuthRequestNotFound {
+		return nil, ErrInvalidOAuthState
+	}
 	if err != nil {
-		fmt.Printf("ERROR: Failed to create HTTP request: %v\n", err)
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to get authorization request: %w", err)
+	}
+	if request.UserID != user.ID || request.SessionHash != dropboxoauth.Hash(sessionToken) {
+		return nil, ErrInvalidOAuthState
 	}
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	req.Header.Set("User-Agent", "DoctorsWorkspace/1.0")
-
-	fmt.Println("DEBUG: Sending HTTP request to Dropbox...")
-	startTime := time.Now()
-	resp, err := httpClient.Do(req)
-	duration := time.Since(startTime)
 
+	appSecret, err := s.encryptionService.Decrypt(request.AppSecret)
 	if err != nil {
-		fmt.Printf("ERROR: Failed to call Dropbox token endpoint after %v: %v\n", duration, err)
-		fmt.Printf("ERROR: Error type: %T\n", err)
-		if urlErr, ok := err.(*url.Error); ok {
-			fmt.Printf("ERROR: URL error details - Op: %s, URL: %s, Err: %v, Timeout: %v\n",
-				urlErr.Op, urlErr.URL, urlErr.Err, urlErr.Timeout())
-		}
-		return nil, fmt.Errorf("failed to call token endpoint: %w", err)
+		return nil, fmt.Errorf("failed to decrypt app secret: %w", err)
 	}
-	defer resp.Body.Close()
-	fmt.Printf("DEBUG: Received response from Dropbox (status %d) after %v\n", resp.StatusCode, duration)
-
-	body, err := io.ReadAll(resp.Body)
+	verifier, err := s.encryptionService.Decrypt(request.CodeVerifier)
 	if err != nil {
-		fmt.Printf("ERROR: Failed to read Dropbox response body: %v\n", err)
-		return nil, fmt.Errorf("failed to read response: %w", err)
+		return nil, fmt.Errorf("failed to decrypt code verifier: %w", err)
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		errMsg := fmt.Sprintf("token exchange failed (status %d): %s", resp.StatusCode, string(body))
-		fmt.Printf("ERROR: Dropbox token exchange failed:\n")
-		fmt.Printf("  Status: %d\n", resp.StatusCode)
-		fmt.Printf("  Response: %s\n", string(body))
-		fmt.Printf("  Code length: %d\n", len(code))
-		fmt.Printf("  Redirect URI: %s\n", redirectURI)
-		return nil, errors.New(errMsg)
+	client := &dropboxoauth.Client{AppKey: request.AppKey, AppSecret: appSecret}
+	token, err := client.Exchange(ctx, code, request.RedirectURI, verifier)
+	if err != nil {
+		return nil, fmt.Errorf("token exchange failed: %w", err)
 	}
 
-	// Parse response
-	var tokenResp models.DropboxOAuthResponse
-	if err := json.Unmarshal(body, &tokenResp); err != nil {
-		return nil, fmt.Errorf("failed to parse token response: %w", err)
+	return s.saveAuthorization(ctx, token, request.Method, request.AppKey, appSecret, request.ParentFolder,
+		request.ConnectionID, request.ConnectionName, user, ipAddress)
+}
+
+// ExchangeCodeForTokens exchanges an authorization code for tokens with the
+// app key and secret, for clients that did not start the flow with
+// StartAuthorization. The tokens are saved to the default connection.
+func (s *DropboxOAuthService) ExchangeCodeForTokens(
+	ctx context.Context,
+	code, appKey, appSecret, redirectURI, parentFolder string,
+	createdBy *models.User,
+	ipAddress string,
+) (*models.DropboxConfig, error) {
+	if code == "" {
+		return nil, ErrInvalidAuthCode
+	}
+	if appKey == "" || appSecret == "" {
+		return nil, ErrOAuthConfigNotFound
 	}
 
-	// Validate response
-	if tokenResp.AccessToken == "" || tokenResp.RefreshToken == "" {
-		return nil, errors.New("invalid token response: missing tokens")
+	client := &dropboxoauth.Client{AppKey: appKey, AppSecret: appSecret}
+	token, err := client.Exchange(ctx, code, redirectURI, "")
+	if err != nil {
+		return nil, fmt.Errorf("token exchange failed: %w", err)
 	}
 
+	return s.saveAuthorization(ctx, token, models.DropboxAuthSecret, appKey, appSecret, parentFolder, nil, "", createdBy, ipAddress)
+}
+
+// saveAuthorization stores the tokens from a completed authorization on
+// the connection it was started for, creating the connection if it is new,
+// and reloads the connection's Dropbox service. The first connection
+// becomes the default.
+func (s *DropboxOAuthService) saveAuthorization(
+	ctx context.Context,
+	token *dropboxoauth.Token,
+	method models.DropboxAuthMethod,
+	appKey, appSecret, parentFolder string,
+	connectionID *primitive.ObjectID,
+	connectionName string,
+	createdBy *models.User,
+	ipAddress string,
+) (*models.DropboxConfig, error) {
 	// Encrypt tokens before storing
-	encryptedAccessToken, err := s.encryptionService.Encrypt(tokenResp.AccessToken)
+	encryptedAccessToken, err := s.encryptionService.Encrypt(token.AccessToken)
 	if err != nil {
 		return nil, fmt.Errorf("failed to encrypt access token: %w", err)
 	}
 
-	encryptedRefreshToken, err := s.encryptionService.Encrypt(tokenResp.RefreshToken)
+	encryptedRefreshToken, err := s.encryptionService.Encrypt(token.RefreshToken)
 	if err != nil {
 		return nil, fmt.Errorf("failed to encrypt refresh token: %w", err)
 	}
 
+	// Empty for PKCE, which removes a secret stored by an earlier authorization
 	encryptedAppSecret, err := s.encryptionService.Encrypt(appSecret)
 	if err != nil {
 		return nil, fmt.Errorf("failed to encrypt app secret: %w", err)
 	}
 
-	// Check if config already exists
-	existingConfig, err := s.configRepo.GetConfig(ctx)
-	if err != nil && err != repository.ErrDropboxConfigNotFound {
-		return nil, fmt.Errorf("failed to check existing config: %w", err)
+	// Find the connection being re-authorized. A name without an ID asks
+	// for a new connection.
+	var existingConfig *models.DropboxConfig
+	if connectionID != nil || connectionName == "" {
+		existingConfig, err = s.getConfig(ctx, connectionID)
+		if err == ErrDropboxConnectionNotFound && connectionID == nil {
+			err = nil
+		}
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	var config *models.DropboxConfig
 
 	if existingConfig != nil {
 		// Update existing configuration
+		if connectionName != "" {
+			existingConfig.Name = connectionName
+		}
 		existingConfig.AppKey = appKey
 		existingConfig.AppSecret = encryptedAppSecret
+		existingConfig.AuthMethod = method
+		existingConfig.AccountID = token.AccountID
 		existingConfig.RefreshToken = encryptedRefreshToken
 		existingConfig.AccessToken = encryptedAccessToken
-		existingConfig.TokenExpiry = time.Now().Add(time.Duration(tokenResp.ExpiresIn) * time.Second)
+		existingConfig.TokenExpiry = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
 		existingConfig.ParentFolder = parentFolder
 		existingConfig.IsConnected = true
 		existingConfig.ConsecutiveFailures = 0
@@ -213,18 +313,36 @@ func (s *DropboxOAuthService) ExchangeCodeForTokens(
 		existingConfig.LastError = ""
 
 		if err := s.configRepo.UpdateConfig(ctx, existingConfig); err != nil {
+			if err == repository.ErrDuplicateDropboxConnection {
+				return nil, ErrDuplicateDropboxConnection
+			}
 			return nil, fmt.Errorf("failed to update config: %w", err)
 		}
 
 		config = existingConfig
 	} else {
+		// The default connection is created when there is none, including
+		// when the first connection is given a name
+		_, err := s.configRepo.GetDefault(ctx)
+		if err != nil && err != repository.ErrDropboxConfigNotFound {
+			return nil, fmt.Errorf("failed to check existing config: %w", err)
+		}
+		isDefault := err == repository.ErrDropboxConfigNotFound
+		if connectionName == "" {
+			connectionName = models.DefaultDropboxConnectionName
+		}
+
 		// Create new configuration
 		config = &models.DropboxConfig{
+			Name:                connectionName,
+			IsDefault:           isDefault,
 			AppKey:              appKey,
 			AppSecret:           encryptedAppSecret,
+			AuthMethod:          method,
+			AccountID:           token.AccountID,
 			RefreshToken:        encryptedRefreshToken,
 			AccessToken:         encryptedAccessToken,
-			TokenExpiry:         time.Now().Add(time.Duration(tokenResp.ExpiresIn) * time.Second),
+			TokenExpiry:         time.Now().Add(time.Duration(token.ExpiresIn) * time.Second),
 			ParentFolder:        parentFolder,
 			IsConnected:         true,
 			ConsecutiveFailures: 0,
@@ -234,20 +352,26 @@ func (s *DropboxOAuthService) ExchangeCodeForTokens(
 		}
 
 		if err := s.configRepo.CreateConfig(ctx, config); err != nil {
+			if err == repository.ErrDuplicateDropboxConnection {
+				return nil, ErrDuplicateDropboxConnection
+			}
 			return nil, fmt.Errorf("failed to create config: %w", err)
 		}
 	}
 
-	// Reload dropbox service configuration
-	if err := s.dropboxService.loadConfigFromDB(ctx); err != nil {
+	// Reload the connection's dropbox service configuration
+	dropboxService, err := s.connections.reload(ctx, config)
+	if err != nil {
 		fmt.Printf("Warning: Failed to reload Dropbox service: %v\n", err)
+	} else {
+		dropboxService.recordHealthSuccess(ctx, config.Name)
 	}
 
 	// Create parent folder if it doesn't exist (skip if using root)
-	if s.dropboxService.IsConfigured() && parentFolder != "" {
+	if dropboxService.IsConfigured() && parentFolder != "" {
 		fmt.Printf("Ensuring parent folder exists: %s\n", parentFolder)
 		// Use empty relative path to create just the parent folder
-		if err := s.dropboxService.CreateFolder(""); err != nil {
+		if err := dropboxService.CreateFolder(""); err != nil {
 			fmt.Printf("Warning: Failed to create parent folder: %v\n", err)
 			// Don't fail the authorization, just log the warning
 		} else {
@@ -264,8 +388,10 @@ func (s *DropboxOAuthService) ExchangeCodeForTokens(
 		Action:      "dropbox.authorize",
 		Details: bson.M{
 			"config_id":     config.ID.Hex(),
-			"account_id":    tokenResp.AccountID,
+			"name":          config.Name,
+			"account_id":    token.AccountID,
 			"parent_folder": parentFolder,
+			"method":        string(method),
 		},
 		IPAddress: ipAddress,
 	})
@@ -274,38 +400,62 @@ func (s *DropboxOAuthService) ExchangeCodeForTokens(
 	return config, nil
 }
 
-// GetStatus returns the current Dropbox connection status
+// GetStatus returns the status of the default connection, as before
+// connections were named, and the status of every connection under
+// "connections"
 func (s *DropboxOAuthService) GetStatus(ctx context.Context) (map[string]interface{}, error) {
-	config, err := s.configRepo.GetConfig(ctx)
+	configs, err := s.configRepo.List(ctx)
 	if err != nil {
-		if err == repository.ErrDropboxConfigNotFound {
-			return map[string]interface{}{
-				"configured": false,
-				"message":    "Dropbox not configured. Please authorize the app.",
-			}, nil
+		return nil, fmt.Errorf("failed to list connections: %w", err)
+	}
+
+	// Check the connections side by side so a slow one does not hold up the rest
+	statuses := make([]map[string]interface{}, len(configs))
+	var wg sync.WaitGroup
+	for i, config := range configs {
+		wg.Add(1)
+		go func(i int, config *models.DropboxConfig) {
+			defer wg.Done()
+			statuses[i] = s.connectionStatus(ctx, config)
+		}(i, config)
+	}
+	wg.Wait()
+
+	status := map[string]interface{}{
+		"configured": false,
+		"message":    "Dropbox not configured. Please authorize the app.",
+	}
+	if len(configs) > 0 && configs[0].IsDefault {
+		// Copied, as the default's status is also listed under connections
+		status = make(map[string]interface{}, len(statuses[0])+1)
+		for key, value := range statuses[0] {
+			status[key] = value
 		}
-		return nil, fmt.Errorf("failed to get config: %w", err)
 	}
+	status["connections"] = statuses
 
+	return status, nil
+}
+
+// connectionStatus returns the status of a connection, checking it is live
+// when its token appears valid
+func (s *DropboxOAuthService) connectionStatus(ctx context.Context, config *models.DropboxConfig) map[string]interface{} {
 	status := config.GetPublicStatus()
+	dropboxService := s.connections.Get(config.Ref())
 
-	// Add refresh service status if available
-	if s.dropboxService != nil {
-		// We can't directly access the refresh service from here, but we can indicate
-		// that background refresh is available
-		status["backgroundRefreshAvailable"] = true
-	}
+	// Background refresh covers every connection
+	status["backgroundRefreshAvailable"] = true
 
 	// Perform a quick live check ONLY if token appears valid
 	// Skip live check if token is expired to avoid triggering refresh in status endpoint
-	if s.dropboxService != nil && !config.IsTokenExpired() {
+	if !config.IsTokenExpired() {
 		// Keep this very short to avoid UI timeouts if Dropbox is slow/unreachable
 		ctxCheck, cancel := context.WithTimeout(ctx, 2*time.Second)
 		defer cancel()
 		// Use goroutine to make this truly non-blocking - if it times out, status still returns
 		done := make(chan error, 1)
 		go func() {
-			done <- s.dropboxService.TestConnection(ctxCheck)
+			done <- dropboxService.TestConnection(ctxCheck)
 		}()
 
 		select {
@@ -323,21 +473,37 @@ func (s *DropboxOAuthService) GetStatus(ctx context.Context) (map[string]interfa
 		}
 	}
 
-	return status, nil
+	// Alert state and recent health history for the status page
+	if health := dropboxService.healthService; health != nil {
+		if state, err := health.State(ctx, config.Ref()); err == nil {
+			status["alert"] = state
+		}
+		if history, err := health.History(ctx, config.Ref(), dropboxHealthHistoryLimit); err == nil {
+			status["healthHistory"] = history
+		}
+	}
+
+	return status
 }
 
-// ForceRefresh manually triggers a token refresh
+// ForceRefresh manually triggers a token refresh of a connection
 func (s *DropboxOAuthService) ForceRefresh(
 	ctx context.Context,
+	connectionID *primitive.ObjectID,
 	performedBy *models.User,
 	ipAddress string,
 ) error {
+	config, err := s.getConfig(ctx, connectionID)
+	if err != nil {
+		return err
+	}
+
 	// Apply a timeout to avoid proxy timeouts/hangs
 	ctxWithTimeout, cancel := context.WithTimeout(ctx, 20*time.Second)
 	defer cancel()
 
 	// This will trigger a refresh through the dropbox service
-	if err := s.dropboxService.ensureValidToken(ctxWithTimeout); err != nil {
+	if err := s.connections.Get(config.Ref()).ensureValidToken(ctxWithTimeout); err != nil {
 		return fmt.Errorf("failed to refresh token: %w", err)
 	}
 
@@ -346,31 +512,41 @@ func (s *DropboxOAuthService) ForceRefresh(
 		UserID:      &performedBy.ID,
 		PerformedBy: &performedBy.ID,
 		Action:      "dropbox.force_refresh",
-		Details:     bson.M{},
-		IPAddress:   ipAddress,
+		Details: bson.M{
+			"config_id": config.ID.Hex(),
+		},
+		IPAddress: ipAddress,
 	})
 
 	return nil
 }
 
-// TestConnection tests if the Dropbox connection is working
+// TestConnection tests if a Dropbox connection is working
 func (s *DropboxOAuthService) TestConnection(
 	ctx context.Context,
+	connectionID *primitive.ObjectID,
 	performedBy *models.User,
 	ipAddress string,
 ) error {
+	config, err := s.getConfig(ctx, connectionID)
+	if err != nil {
+		return err
+	}
+	dropboxService := s.connections.Get(config.Ref())
+
 	// Apply a timeout to avoid proxy timeouts/hangs
 	ctxWithTimeout, cancel := context.WithTimeout(ctx, 20*time.Second)
 	defer cancel()
 
-	if err := s.dropboxService.TestConnection(ctxWithTimeout); err != nil {
+	if err := dropboxService.TestConnection(ctxWithTimeout); err != nil {
 		// Update health status
-		s.configRepo.UpdateHealth(ctxWithTimeout, false, err.Error())
+		s.configRepo.UpdateHealth(ctxWithTimeout, config.ID, false, err.Error())
 		return fmt.Errorf("connection test failed: %w", err)
 	}
 
 	// Update health status
-	s.configRepo.UpdateHealth(ctxWithTimeout, true, "")
+	s.configRepo.UpdateHealth(ctxWithTimeout, config.ID, true, "")
+	dropboxService.recordHealthSuccess(ctx, config.Name)
 
 	// Audit log
 	s.auditRepo.Create(ctx, &models.AuditLog{
@@ -378,7 +554,8 @@ func (s *DropboxOAuthService) TestConnection(
 		PerformedBy: &performedBy.ID,
 		Action:      "dropbox.test_connection",
 		Details: bson.M{
-			"success": true,
+			"config_id": config.ID.Hex(),
+			"success":   true,
 		},
 		IPAddress: ipAddress,
 	})
@@ -386,24 +563,42 @@ func (s *DropboxOAuthService) TestConnection(
 	return nil
 }
 
-// DeleteConfiguration deletes the Dropbox configuration
-// This will require re-authorization
+// DeleteConfiguration deletes a Dropbox connection. The default connection
+// requires re-authorization; another connection can only be deleted once
+// no library or the registry uses it.
 func (s *DropboxOAuthService) DeleteConfiguration(
 	ctx context.Context,
+	connectionID *primitive.ObjectID,
 	performedBy *models.User,
 	ipAddress string,
 ) error {
-	config, err := s.configRepo.GetConfig(ctx)
+	config, err := s.getConfig(ctx, connectionID)
 	if err != nil {
-		return fmt.Errorf("failed to get config: %w", err)
+		return err
 	}
 
-	if err := s.configRepo.DeleteConfig(ctx); err != nil {
+	if !config.IsDefault {
+		inUse, err := s.connectionInUse(ctx, config.ID)
+		if err != nil {
+			return err
+		}
+		if inUse {
+			return ErrDropboxConnectionInUse
+		}
+	}
+
+	if err := s.configRepo.DeleteConfig(ctx, config.ID); err != nil {
 		return fmt.Errorf("failed to delete config: %w", err)
 	}
 
-	// Mark dropbox service as not configured
-	s.dropboxService.isConfigured = false
+	// Mark the connection's dropbox service as not configured
+	s.connections.remove(config)
+	if !config.IsDefault {
+		s.listingService.ForgetConnection(ctx, config.ID)
+		if health := s.connections.healthService; health != nil {
+			health.Forget(ctx, config.ID)
+		}
+	}
 
 	// Audit log
 	s.auditRepo.Create(ctx, &models.AuditLog{
@@ -418,3 +613,25 @@ func (s *DropboxOAuthService) DeleteConfiguration(
 
 	return nil
 }
+
+// connectionInUse reports whether a library or the registry uses a connection
+func (s *DropboxOAuthService) connectionInUse(ctx context.Context, connectionID primitive.ObjectID) (bool, error) {
+	libraries, err := s.libraryRepo.List(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to list libraries: %w", err)
+	}
+	for _, library := range libraries {
+		if library.DropboxConnectionID != nil && *library.DropboxConnectionID == connectionID {
+			return true, nil
+		}
+	}
+
+	config, err := s.registryConfigRepo.GetConfig(ctx)
+	if err == repository.ErrRegistryConfigNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get registry config: %w", err)
+	}
+	return config.DropboxConnectionID != nil && *config.DropboxConnectionID == connectionID, nil
+}
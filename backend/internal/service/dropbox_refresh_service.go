@@ -2,24 +2,26 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 )
 
-// DropboxRefreshService handles automatic background refresh of Dropbox tokens
+// DropboxRefreshService handles automatic background refresh of the tokens
+// of every Dropbox connection
 type DropboxRefreshService struct {
-	dropboxService *DropboxService
-	ticker         *time.Ticker
-	done           chan bool
-	isRunning      bool
+	connections *DropboxConnectionService
+	ticker      *time.Ticker
+	done        chan bool
+	isRunning   bool
 }
 
 // NewDropboxRefreshService creates a new DropboxRefreshService
-func NewDropboxRefreshService(dropboxService *DropboxService) *DropboxRefreshService {
+func NewDropboxRefreshService(connections *DropboxConnectionService) *DropboxRefreshService {
 	return &DropboxRefreshService{
-		dropboxService: dropboxService,
-		done:           make(chan bool),
-		isRunning:      false,
+		connections: connections,
+		done:        make(chan bool),
+		isRunning:   false,
 	}
 }
 
@@ -39,12 +41,12 @@ func (s *DropboxRefreshService) Start() {
 
 	go func() {
 		// Do an initial refresh check on startup
-		s.refreshIfNeeded()
+		s.refreshAll()
 
 		for {
 			select {
 			case <-s.ticker.C:
-				s.refreshIfNeeded()
+				s.refreshAll()
 			case <-s.done:
 				fmt.Println("Dropbox refresh service stopped")
 				return
@@ -72,50 +74,70 @@ func (s *DropboxRefreshService) IsRunning() bool {
 	return s.isRunning
 }
 
-// refreshIfNeeded checks if Dropbox is configured and refreshes the token if needed
-func (s *DropboxRefreshService) refreshIfNeeded() {
-	if !s.dropboxService.IsConfigured() {
+// refreshAll refreshes the token of every configured connection. A failing
+// connection does not hold up the others.
+func (s *DropboxRefreshService) refreshAll() {
+	configured := 0
+	for _, dropboxService := range s.connections.All() {
+		if dropboxService.IsConfigured() {
+			configured++
+			s.refreshIfNeeded(dropboxService)
+		}
+	}
+	if configured == 0 {
 		fmt.Println("Dropbox not configured, skipping background refresh")
-		return
 	}
+}
 
+// refreshIfNeeded refreshes a connection's token if needed
+func (s *DropboxRefreshService) refreshIfNeeded(dropboxService *DropboxService) {
 	ctx := context.Background()
 	// Log current expiry state before attempting
-	s.dropboxService.cacheMutex.RLock()
-	beforeExpiry := s.dropboxService.cachedConfig.TokenExpiry
-	expiredBefore := s.dropboxService.cachedConfig.IsTokenExpired()
-	s.dropboxService.cacheMutex.RUnlock()
-	fmt.Printf("Performing background Dropbox token refresh... (expiredBefore=%t, expiry=%s)\n", expiredBefore, beforeExpiry.Format(time.RFC3339))
-
-	if err := s.dropboxService.ensureValidToken(ctx); err != nil {
-		fmt.Printf("Background token refresh failed: %v\n", err)
+	dropboxService.cacheMutex.RLock()
+	name := dropboxService.cachedConfig.Name
+	beforeExpiry := dropboxService.cachedConfig.TokenExpiry
+	expiredBefore := dropboxService.cachedConfig.IsTokenExpired()
+	dropboxService.cacheMutex.RUnlock()
+	fmt.Printf("Performing background Dropbox token refresh of %q... (expiredBefore=%t, expiry=%s)\n", name, expiredBefore, beforeExpiry.Format(time.RFC3339))
+
+	if err := dropboxService.ensureValidToken(ctx); err != nil {
+		fmt.Printf("Background token refresh of %q failed: %v\n", name, err)
 		return
 	}
 
 	// After ensureValidToken, log new expiry and verify quick connectivity
-	s.dropboxService.cacheMutex.RLock()
-	afterExpiry := s.dropboxService.cachedConfig.TokenExpiry
-	expiredAfter := s.dropboxService.cachedConfig.IsTokenExpired()
-	s.dropboxService.cacheMutex.RUnlock()
-	fmt.Printf("Background refresh completed (expiredAfter=%t, newExpiry=%s)\n", expiredAfter, afterExpiry.Format(time.RFC3339))
+	dropboxService.cacheMutex.RLock()
+	afterExpiry := dropboxService.cachedConfig.TokenExpiry
+	expiredAfter := dropboxService.cachedConfig.IsTokenExpired()
+	dropboxService.cacheMutex.RUnlock()
+	fmt.Printf("Background refresh of %q completed (expiredAfter=%t, newExpiry=%s)\n", name, expiredAfter, afterExpiry.Format(time.RFC3339))
 
 	verifyCtx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
-	if err := s.dropboxService.quickLiveCheck(verifyCtx); err != nil {
-		fmt.Printf("Background refresh live check FAILED: %v\n", err)
-		_ = s.dropboxService.configRepo.UpdateHealth(ctx, false, "background live check failed: "+err.Error())
+	if err := dropboxService.quickLiveCheck(verifyCtx); err != nil {
+		fmt.Printf("Background refresh live check of %q FAILED: %v\n", name, err)
+		_ = dropboxService.configRepo.UpdateHealth(ctx, dropboxService.id(), false, "background live check failed: "+err.Error())
 		return
 	}
 
-	fmt.Println("Background token refresh verified successfully")
+	fmt.Printf("Background token refresh of %q verified successfully\n", name)
 }
 
-// ForceRefreshNow manually triggers a refresh (useful for testing)
+// ForceRefreshNow manually triggers a refresh of every configured
+// connection (useful for testing)
 func (s *DropboxRefreshService) ForceRefreshNow() error {
-	if !s.dropboxService.IsConfigured() {
+	if !s.connections.AnyConfigured() {
 		return ErrDropboxNotConfigured
 	}
 
 	ctx := context.Background()
-	return s.dropboxService.ensureValidToken(ctx)
+	var errs []error
+	for _, dropboxService := range s.connections.All() {
+		if dropboxService.IsConfigured() {
+			if err := dropboxService.ensureValidToken(ctx); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
 }
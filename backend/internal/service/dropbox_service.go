@@ -1,25 +1,24 @@
 package service
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
-	"net/http"
 	"net/url"
 	"path/filepath"
 	"strings"
 	"sync"
 	"time"
 
+	"backend/internal/dropboxoauth"
 	"backend/internal/models"
 	"backend/internal/repository"
 
 	"github.com/dropbox/dropbox-sdk-go-unofficial/v6/dropbox"
 	"github.com/dropbox/dropbox-sdk-go-unofficial/v6/dropbox/files"
 	"github.com/dropbox/dropbox-sdk-go-unofficial/v6/dropbox/sharing"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 var (
@@ -27,24 +26,38 @@ var (
 	ErrFolderCreationFailed = errors.New("failed to create folder in dropbox")
 	ErrFolderNotFound       = errors.New("folder not found in dropbox")
 	ErrFileNotFound         = errors.New("file not found in dropbox")
+	ErrFileTooLarge         = errors.New("file is too large to download")
+	ErrFileExists           = errors.New("a file or folder with this name already exists")
 	ErrTokenRefreshFailed   = errors.New("failed to refresh access token")
 )
 
 // DropboxFileInfo represents metadata about a file in Dropbox
 type DropboxFileInfo struct {
+	ID           string            `json:"id,omitempty"` // Dropbox file ID, which survives renames
 	Name         string            `json:"name"`
 	Path         string            `json:"path"`
 	Size         uint64            `json:"size"`
 	ModifiedTime time.Time         `json:"modifiedTime"`
 	IsFolder     bool              `json:"isFolder"`
+	Rev          string            `json:"rev,omitempty"`
 	Children     []DropboxFileInfo `json:"children,omitempty"`
+
+	// Review metadata, set on library listings
+	Review        *models.DocumentMetadata `json:"review,omitempty"`
+	ReviewOverdue bool                     `json:"reviewOverdue,omitempty"`
 }
 
-// DropboxService handles Dropbox operations with automatic token refresh
+// DropboxService handles the Dropbox operations of one connection with
+// automatic token refresh
 type DropboxService struct {
 	configRepo        *repository.DropboxConfigRepository
 	encryptionService *EncryptionService
 
+	// The connection. The default connection's ID is zero until it is
+	// first authorized.
+	connectionID primitive.ObjectID
+	isDefault    bool
+
 	// In-memory cache to avoid DB hits on every request
 	cachedConfig     *models.DropboxConfig
 	cachedClient     files.Client
@@ -52,19 +65,32 @@ type DropboxService struct {
 	cacheMutex       sync.RWMutex
 
 	// Configuration
-	isConfigured   bool
-	alertThreshold int // Number of consecutive failures before alerting
+	isConfigured bool
+
+	// Told the outcome of token refreshes, to alert admins; may be nil
+	healthService *DropboxHealthService
 
 	// Single-flight guard so only one refresh runs at a time
 	refreshMutex sync.Mutex
 }
 
-// NewDropboxService creates a new DropboxService with DB-backed configuration
+// NewDropboxService creates the DropboxService of the default connection
+// with DB-backed configuration
 func NewDropboxService(configRepo *repository.DropboxConfigRepository, encryptionService *EncryptionService) *DropboxService {
+	return newDropboxService(configRepo, encryptionService, primitive.NilObjectID, true)
+}
+
+func newDropboxService(
+	configRepo *repository.DropboxConfigRepository,
+	encryptionService *EncryptionService,
+	connectionID primitive.ObjectID,
+	isDefault bool,
+) *DropboxService {
 	service := &DropboxService{
 		configRepo:        configRepo,
 		encryptionService: encryptionService,
-		alertThreshold:    3,
+		connectionID:      connectionID,
+		isDefault:         isDefault,
 		isConfigured:      false,
 	}
 
@@ -81,6 +107,49 @@ func NewDropboxService(configRepo *repository.DropboxConfigRepository, encryptio
 	return service
 }
 
+// SetHealthService sets the service told the outcome of token refreshes.
+// It is set after construction as it depends on services created later.
+func (s *DropboxService) SetHealthService(healthService *DropboxHealthService) {
+	s.healthService = healthService
+}
+
+// recordHealthSuccess tells the health service that the connection named
+// name is reachable
+func (s *DropboxService) recordHealthSuccess(ctx context.Context, name string) {
+	if s.healthService != nil {
+		s.healthService.RecordSuccess(ctx, s.ref(), name)
+	}
+}
+
+// ref returns how records refer to the connection, nil for the default
+func (s *DropboxService) ref() *primitive.ObjectID {
+	if s.isDefault {
+		return nil
+	}
+	id := s.connectionID
+	return &id
+}
+
+// id returns the connection's ID
+func (s *DropboxService) id() primitive.ObjectID {
+	s.cacheMutex.RLock()
+	defer s.cacheMutex.RUnlock()
+	return s.connectionID
+}
+
+// unload forgets the connection's configuration after it was deleted
+func (s *DropboxService) unload() {
+	s.cacheMutex.Lock()
+	defer s.cacheMutex.Unlock()
+	s.isConfigured = false
+	s.cachedConfig = nil
+	s.cachedClient = nil
+	s.cachedSharingClient = nil
+	if s.isDefault {
+		s.connectionID = primitive.NilObjectID
+	}
+}
+
 // IsConfigured returns whether Dropbox is properly configured
 func (s *DropboxService) IsConfigured() bool {
 	s.cacheMutex.RLock()
@@ -96,7 +165,13 @@ func (s *DropboxService) loadConfigFromDB(ctx context.Context) error {
 	fmt.Println("DEBUG: loadConfigFromDB: Cache lock acquired")
 
 	fmt.Println("DEBUG: loadConfigFromDB: Fetching config from database...")
-	config, err := s.configRepo.GetConfig(ctx)
+	var config *models.DropboxConfig
+	var err error
+	if s.isDefault {
+		config, err = s.configRepo.GetDefault(ctx)
+	} else {
+		config, err = s.configRepo.FindByID(ctx, s.connectionID)
+	}
 	if err != nil {
 		fmt.Printf("ERROR: loadConfigFromDB: Failed to get config: %v\n", err)
 		return err
@@ -131,6 +206,7 @@ func (s *DropboxService) loadConfigFromDB(ctx context.Context) error {
 		LogLevel: dropbox.LogOff,
 	}
 
+	s.connectionID = config.ID
 	s.cachedConfig = config
 	s.cachedClient = files.New(dropboxConfig)
 	s.cachedSharingClient = sharing.New(dropboxConfig)
@@ -168,7 +244,8 @@ func (s *DropboxService) refreshAccessToken(ctx context.Context) error {
 
 	fmt.Println("Refreshing Dropbox access token...")
 
-	// Decrypt the app secret before using it
+	// Decrypt the app secret before using it. There is none for PKCE, in
+	// which case the refresh is authenticated by the app key alone.
 	decryptedAppSecret, err := s.encryptionService.Decrypt(s.cachedConfig.AppSecret)
 	if err != nil {
 		fmt.Printf("ERROR: Failed to decrypt app secret: %v\n", err)
@@ -176,75 +253,34 @@ func (s *DropboxService) refreshAccessToken(ctx context.Context) error {
 		return fmt.Errorf("failed to decrypt app secret: %w", err)
 	}
 
-	// Debug logging (remove in production)
-	fmt.Printf("DEBUG: Using AppKey: %s\n", s.cachedConfig.AppKey)
-	fmt.Printf("DEBUG: Using AppSecret: %s (length: %d)\n", decryptedAppSecret, len(decryptedAppSecret))
-	fmt.Printf("DEBUG: Using RefreshToken: %s (length: %d)\n", s.cachedConfig.RefreshToken, len(s.cachedConfig.RefreshToken))
-
-	// Prepare refresh request (form-urlencoded)
-	formData := url.Values{}
-	formData.Set("grant_type", "refresh_token")
-	formData.Set("refresh_token", s.cachedConfig.RefreshToken)
-	formData.Set("client_id", s.cachedConfig.AppKey)
-	formData.Set("client_secret", decryptedAppSecret)
+	fmt.Printf("DEBUG: Using AppKey: %s (auth method: %s)\n", s.cachedConfig.AppKey, s.cachedConfig.Method())
 
 	fmt.Println("DEBUG: Calling Dropbox token endpoint...")
-	// Call Dropbox token endpoint with context-aware request and timeout
-	httpClient := &http.Client{Timeout: 20 * time.Second}
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://api.dropbox.com/oauth2/token", bytes.NewBufferString(formData.Encode()))
-	if err != nil {
-		fmt.Printf("ERROR: Failed to build refresh request: %v\n", err)
-		s.handleRefreshFailure(ctx, err)
-		return fmt.Errorf("%w: failed to build refresh request: %v", ErrTokenRefreshFailed, err)
-	}
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-
-	fmt.Println("DEBUG: Sending HTTP request to Dropbox...")
-	resp, err := httpClient.Do(req)
-	if err != nil {
-		fmt.Printf("ERROR: HTTP request failed: %v\n", err)
-		s.handleRefreshFailure(ctx, err)
-		return fmt.Errorf("%w: %v", ErrTokenRefreshFailed, err)
-	}
-	defer resp.Body.Close()
-	fmt.Printf("DEBUG: Received response from Dropbox (status %d)\n", resp.StatusCode)
-
-	body, err := io.ReadAll(resp.Body)
+	client := &dropboxoauth.Client{AppKey: s.cachedConfig.AppKey, AppSecret: decryptedAppSecret}
+	tokenResp, err := client.Refresh(ctx, s.cachedConfig.RefreshToken)
 	if err != nil {
-		fmt.Printf("ERROR: Failed to read response body: %v\n", err)
-		s.handleRefreshFailure(ctx, err)
-		return fmt.Errorf("%w: failed to read response: %v", ErrTokenRefreshFailed, err)
-	}
-	fmt.Printf("DEBUG: Response body length: %d bytes\n", len(body))
+		var oauthErr *dropboxoauth.Error
+		if !errors.As(err, &oauthErr) {
+			fmt.Printf("ERROR: HTTP request failed: %v\n", err)
+			s.handleRefreshFailure(ctx, err)
+			return fmt.Errorf("%w: %v", ErrTokenRefreshFailed, err)
+		}
 
-	if resp.StatusCode != http.StatusOK {
-		errMsg := fmt.Sprintf("status %d: %s", resp.StatusCode, string(body))
-		fmt.Printf("ERROR: Dropbox token refresh failed with status %d\n", resp.StatusCode)
-		fmt.Printf("ERROR: Response body: %s\n", string(body))
-		fmt.Printf("DEBUG: Request form data (without secrets): grant_type=refresh_token&client_id=%s\n", s.cachedConfig.AppKey)
-		fmt.Printf("DEBUG: Refresh token length: %d\n", len(s.cachedConfig.RefreshToken))
+		fmt.Printf("ERROR: Dropbox token refresh failed with status %d\n", oauthErr.StatusCode)
+		fmt.Printf("ERROR: Response body: %s\n", oauthErr.Body)
 
 		// Check for specific error types
-		if strings.Contains(string(body), "invalid_grant") {
+		if oauthErr.InvalidGrant() {
 			fmt.Printf("ERROR: Refresh token is invalid or expired. User may need to re-authorize the app.\n")
 			// Mark needs reconnection explicitly for UI
-			_ = s.configRepo.UpdateHealth(ctx, false, "invalid_grant during refresh - re-authorization required")
-		} else if strings.Contains(string(body), "expired") {
+			_ = s.configRepo.UpdateHealth(ctx, s.connectionID, false, "invalid_grant during refresh - re-authorization required")
+		} else if strings.Contains(oauthErr.Body, "expired") {
 			fmt.Printf("ERROR: Refresh token has expired. User needs to re-authorize the app.\n")
-			_ = s.configRepo.UpdateHealth(ctx, false, "refresh token expired - re-authorization required")
+			_ = s.configRepo.UpdateHealth(ctx, s.connectionID, false, "refresh token expired - re-authorization required")
 		}
 
-		s.handleRefreshFailure(ctx, errors.New(errMsg))
-		return fmt.Errorf("%w: %s", ErrTokenRefreshFailed, errMsg)
-	}
-
-	// Parse response
-	fmt.Println("DEBUG: Parsing token response...")
-	var tokenResp models.DropboxOAuthResponse
-	if err := json.Unmarshal(body, &tokenResp); err != nil {
-		fmt.Printf("ERROR: Failed to parse token response: %v\n", err)
 		s.handleRefreshFailure(ctx, err)
-		return fmt.Errorf("%w: failed to parse response: %v", ErrTokenRefreshFailed, err)
+		return fmt.Errorf("%w: %s", ErrTokenRefreshFailed, err.Error())
 	}
 	fmt.Printf("DEBUG: Token response parsed successfully (expires_in: %d)\n", tokenResp.ExpiresIn)
 
@@ -260,7 +296,7 @@ func (s *DropboxService) refreshAccessToken(ctx context.Context) error {
 
 	// Update database with new token (refresh token not included in response for refresh grant)
 	fmt.Println("DEBUG: Updating tokens in database...")
-	if err := s.configRepo.UpdateTokens(ctx, encryptedAccessToken, "", tokenResp.ExpiresIn); err != nil {
+	if err := s.configRepo.UpdateTokens(ctx, s.connectionID, encryptedAccessToken, "", tokenResp.ExpiresIn); err != nil {
 		fmt.Printf("ERROR: Failed to update tokens in database: %v\n", err)
 		s.handleRefreshFailure(ctx, err)
 		return fmt.Errorf("failed to update tokens in database: %w", err)
@@ -283,9 +319,11 @@ func (s *DropboxService) refreshAccessToken(ctx context.Context) error {
 	fmt.Println("DEBUG: Dropbox client created successfully")
 
 	// Reset failure count on success
-	if err := s.configRepo.ResetFailures(ctx); err != nil {
+	if err := s.configRepo.ResetFailures(ctx, s.connectionID); err != nil {
 		fmt.Printf("Warning: Failed to reset failure count: %v\n", err)
 	}
+	s.cachedConfig.ConsecutiveFailures = 0
+	s.cachedConfig.IsConnected = true
 
 	// Note: We don't reload from DB here because:
 	// 1. We already have the fresh token and updated the cache
@@ -311,12 +349,15 @@ func (s *DropboxService) refreshAccessToken(ctx context.Context) error {
 		}
 		fmt.Printf("ERROR: Post-refresh live check failed: %v\n", err)
 		// Mark health degraded so status reflects reality
-		_ = s.configRepo.UpdateHealth(ctx, false, "post-refresh live check failed: "+err.Error())
+		_ = s.configRepo.UpdateHealth(ctx, s.connectionID, false, "post-refresh live check failed: "+err.Error())
+		s.handleRefreshFailure(ctx, fmt.Errorf("post-refresh live check failed: %w", err))
 		// Treat this as a refresh failure from caller's perspective
 		return fmt.Errorf("%w: post-refresh live check failed: %v", ErrTokenRefreshFailed, err)
 	}
 	fmt.Println("DEBUG: Live check verification passed")
 
+	s.recordHealthSuccess(ctx, s.cachedConfig.Name)
+
 	fmt.Println("Successfully refreshed Dropbox access token and verified connectivity")
 	return nil
 }
@@ -372,33 +413,38 @@ func isAuthError(err error) bool {
 		strings.Contains(msg, "401")
 }
 
-// handleRefreshFailure handles token refresh failures
+// handleRefreshFailure handles token refresh failures. The caller holds
+// cacheMutex.
 func (s *DropboxService) handleRefreshFailure(ctx context.Context, err error) {
 	fmt.Printf("ERROR: Dropbox token refresh failed: %v\n", err)
 
 	// Increment failure count in database
-	if dbErr := s.configRepo.IncrementFailures(ctx); dbErr != nil {
+	if dbErr := s.configRepo.IncrementFailures(ctx, s.connectionID); dbErr != nil {
 		fmt.Printf("ERROR: Failed to increment failure count: %v\n", dbErr)
 	}
 
 	// Update health status
-	if dbErr := s.configRepo.UpdateHealth(ctx, false, err.Error()); dbErr != nil {
+	if dbErr := s.configRepo.UpdateHealth(ctx, s.connectionID, false, err.Error()); dbErr != nil {
 		fmt.Printf("ERROR: Failed to update health status: %v\n", dbErr)
 	}
 
-	// Check if we should send alert
-	if s.cachedConfig != nil && s.cachedConfig.ConsecutiveFailures >= s.alertThreshold {
-		s.sendAlert(err)
+	failures := 1
+	name := ""
+	if s.cachedConfig != nil {
+		s.cachedConfig.ConsecutiveFailures++
+		s.cachedConfig.IsConnected = false
+		failures = s.cachedConfig.ConsecutiveFailures
+		name = s.cachedConfig.Name
 	}
-}
 
-// sendAlert sends an alert when Dropbox connection fails
-// TODO: Implement email notifications
-func (s *DropboxService) sendAlert(err error) {
-	fmt.Printf("ALERT: Dropbox has failed %d times. Last error: %v\n", s.cachedConfig.ConsecutiveFailures, err)
-	fmt.Println("TODO: Send email notification to admin")
-	// TODO: Implement email service integration
-	// emailService.SendAlert(adminEmail, "Dropbox Token Refresh Failed", message)
+	// Tell the health service, which alerts admins once failures reach its
+	// threshold, or at once if Dropbox must be re-authorized
+	if s.healthService != nil {
+		msg := err.Error()
+		needsReconnection := strings.Contains(msg, "invalid_grant") ||
+			(strings.HasPrefix(msg, "status ") && strings.Contains(msg, "expired"))
+		s.healthService.RecordFailure(ctx, s.ref(), name, err, failures, needsReconnection)
+	}
 }
 
 // CreateFolder creates a folder in Dropbox
@@ -424,18 +470,20 @@ func (s *DropboxService) CreateFolder(relativePath string) error {
 	})
 }
 
-// UploadFile uploads a file to Dropbox
+// UploadFile uploads a file to Dropbox, replacing any file at the path.
+// Large files are sent in chunks through an upload session (see
+// uploadContent).
 func (s *DropboxService) UploadFile(ctx context.Context, file io.Reader, remotePath string) error {
-	return s.withClientRetry(ctx, func(client files.Client, parentFolder string) error {
-		fullPath := s.getFullPath(remotePath, parentFolder)
-		uploadArg := files.NewUploadArg(fullPath)
-		uploadArg.Mode = &files.WriteMode{Tagged: dropbox.Tagged{Tag: "overwrite"}}
-		_, err := client.Upload(uploadArg, file)
-		if err != nil {
-			return fmt.Errorf("failed to upload file to Dropbox: %w", err)
-		}
-		return nil
+	_, err := s.uploadContent(ctx, file, remotePath, func(commit *files.CommitInfo) {
+		commit.Mode = &files.WriteMode{Tagged: dropbox.Tagged{Tag: "overwrite"}}
 	})
+	if err != nil {
+		if err == ErrDropboxNotConfigured {
+			return err
+		}
+		return fmt.Errorf("failed to upload file to Dropbox: %w", err)
+	}
+	return nil
 }
 
 // ListFiles lists all files in a Dropbox folder
@@ -642,8 +690,16 @@ func (s *DropboxService) folderHasFiles(children []DropboxFileInfo) bool {
 
 // GetFileDownloadLink generates a temporary download link for a file
 func (s *DropboxService) GetFileDownloadLink(relativePath string) (string, error) {
+	link, _, err := s.GetTemporaryLink(relativePath)
+	return link, err
+}
+
+// GetTemporaryLink generates a temporary download link for a file, along
+// with the metadata of the version it serves
+func (s *DropboxService) GetTemporaryLink(relativePath string) (string, *DropboxFileInfo, error) {
 	ctx := context.Background()
 	var link string
+	var info *DropboxFileInfo
 	err := s.withClientRetry(ctx, func(client files.Client, parentFolder string) error {
 		fullPath := s.getFullPath(relativePath, parentFolder)
 		arg := files.NewGetTemporaryLinkArg(fullPath)
@@ -655,9 +711,126 @@ func (s *DropboxService) GetFileDownloadLink(relativePath string) (string, error
 			return fmt.Errorf("failed to get download link: %w", err)
 		}
 		link = result.Link
+		if result.Metadata != nil {
+			info = s.metadataToFileInfo(result.Metadata)
+		}
 		return nil
 	})
-	return link, err
+	return link, info, err
+}
+
+// DownloadFile downloads a file's contents, refusing files larger than maxSize bytes
+func (s *DropboxService) DownloadFile(relativePath string, maxSize int64) ([]byte, error) {
+	ctx := context.Background()
+	var data []byte
+	err := s.withClientRetry(ctx, func(client files.Client, parentFolder string) error {
+		fullPath := s.getFullPath(relativePath, parentFolder)
+		metadata, content, err := client.Download(files.NewDownloadArg(fullPath))
+		if err != nil {
+			if strings.Contains(err.Error(), "path/not_found") {
+				return ErrFileNotFound
+			}
+			return fmt.Errorf("failed to download file: %w", err)
+		}
+		defer content.Close()
+
+		if int64(metadata.Size) > maxSize {
+			return ErrFileTooLarge
+		}
+		data, err = io.ReadAll(io.LimitReader(content, maxSize+1))
+		if err != nil {
+			return fmt.Errorf("failed to download file: %w", err)
+		}
+		if int64(len(data)) > maxSize {
+			return ErrFileTooLarge
+		}
+		return nil
+	})
+	return data, err
+}
+
+// OpenFile opens a file's current content. The caller must close the
+// returned content.
+func (s *DropboxService) OpenFile(relativePath string) (*DropboxFileInfo, io.ReadCloser, error) {
+	ctx := context.Background()
+	var info *DropboxFileInfo
+	var content io.ReadCloser
+	err := s.withClientRetry(ctx, func(client files.Client, parentFolder string) error {
+		fullPath := s.getFullPath(relativePath, parentFolder)
+		metadata, body, err := client.Download(files.NewDownloadArg(fullPath))
+		if err != nil {
+			if strings.Contains(err.Error(), "path/not_found") || strings.Contains(err.Error(), "path/not_file") {
+				return ErrFileNotFound
+			}
+			return fmt.Errorf("failed to download file: %w", err)
+		}
+		info = s.metadataToFileInfo(metadata)
+		content = body
+		return nil
+	})
+	return info, content, err
+}
+
+// DropboxRevision is one stored revision of a Dropbox file
+type DropboxRevision struct {
+	Rev          string
+	ContentHash  string
+	Size         uint64
+	ModifiedTime time.Time
+}
+
+// maxListedRevisions is the most revisions Dropbox returns per file
+const maxListedRevisions = 100
+
+// ListRevisions returns the revisions Dropbox keeps of a file, newest first
+func (s *DropboxService) ListRevisions(relativePath string) ([]DropboxRevision, error) {
+	ctx := context.Background()
+	var revisions []DropboxRevision
+	err := s.withClientRetry(ctx, func(client files.Client, parentFolder string) error {
+		fullPath := s.getFullPath(relativePath, parentFolder)
+		arg := files.NewListRevisionsArg(fullPath)
+		arg.Limit = maxListedRevisions
+		result, err := client.ListRevisions(arg)
+		if err != nil {
+			if strings.Contains(err.Error(), "path/not_found") || strings.Contains(err.Error(), "path/not_file") {
+				return ErrFileNotFound
+			}
+			return fmt.Errorf("failed to list revisions: %w", err)
+		}
+
+		revisions = make([]DropboxRevision, 0, len(result.Entries))
+		for _, entry := range result.Entries {
+			revisions = append(revisions, DropboxRevision{
+				Rev:          entry.Rev,
+				ContentHash:  entry.ContentHash,
+				Size:         entry.Size,
+				ModifiedTime: entry.ServerModified,
+			})
+		}
+		return nil
+	})
+	return revisions, err
+}
+
+// DownloadRevision opens a stored revision of a file. The caller must close
+// the returned content.
+func (s *DropboxService) DownloadRevision(rev string) (*DropboxFileInfo, io.ReadCloser, error) {
+	ctx := context.Background()
+	var info *DropboxFileInfo
+	var content io.ReadCloser
+	err := s.withClientRetry(ctx, func(client files.Client, parentFolder string) error {
+		metadata, body, err := client.Download(files.NewDownloadArg("rev:" + rev))
+		if err != nil {
+			if strings.Contains(err.Error(), "not_found") {
+				return ErrFileNotFound
+			}
+			return fmt.Errorf("failed to download revision: %w", err)
+		}
+		info = s.metadataToFileInfo(metadata)
+		content = body
+		return nil
+	})
+	return info, content, err
 }
 
 // GetFolderShareLink generates a shared link for a folder
@@ -757,6 +930,73 @@ func (s *DropboxService) RenameFolder(oldRelativePath, newRelativePath string) e
 	})
 }
 
+// UploadDocument uploads a file, applying the conflict policy if a file
+// already exists at the path, and returns the stored file. Large files are
+// sent in chunks through an upload session (see uploadContent).
+func (s *DropboxService) UploadDocument(ctx context.Context, content io.ReadSeeker, relativePath string, policy models.UploadConflictPolicy) (*DropboxFileInfo, error) {
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	metadata, err := s.uploadContent(ctx, content, relativePath, func(commit *files.CommitInfo) {
+		commit.Mode = &files.WriteMode{Tagged: dropbox.Tagged{Tag: "add"}}
+		switch policy {
+		case models.UploadConflictReplace:
+			commit.Mode = &files.WriteMode{Tagged: dropbox.Tagged{Tag: "overwrite"}}
+		case models.UploadConflictRename:
+			commit.Autorename = true
+		}
+	})
+	if err != nil {
+		if err == ErrDropboxNotConfigured {
+			return nil, err
+		}
+		if strings.Contains(err.Error(), "path/conflict") {
+			return nil, ErrFileExists
+		}
+		return nil, fmt.Errorf("failed to upload file to Dropbox: %w", err)
+	}
+	return s.metadataToFileInfo(metadata), nil
+}
+
+// DeletePath deletes a file or folder and everything in it
+func (s *DropboxService) DeletePath(relativePath string) error {
+	ctx := context.Background()
+	return s.withClientRetry(ctx, func(client files.Client, parentFolder string) error {
+		_, err := client.DeleteV2(files.NewDeleteArg(s.getFullPath(relativePath, parentFolder)))
+		if err != nil {
+			if strings.Contains(err.Error(), "not_found") {
+				return ErrFileNotFound
+			}
+			return fmt.Errorf("failed to delete from Dropbox: %w", err)
+		}
+		return nil
+	})
+}
+
+// MovePath moves or renames a file or folder, failing if the destination
+// exists, and returns the moved entry
+func (s *DropboxService) MovePath(fromRelativePath, toRelativePath string) (*DropboxFileInfo, error) {
+	ctx := context.Background()
+	var info *DropboxFileInfo
+	err := s.withClientRetry(ctx, func(client files.Client, parentFolder string) error {
+		moveArg := files.NewRelocationArg(s.getFullPath(fromRelativePath, parentFolder), s.getFullPath(toRelativePath, parentFolder))
+		result, err := client.MoveV2(moveArg)
+		if err != nil {
+			switch {
+			case strings.Contains(err.Error(), "from_lookup/not_found"):
+				return ErrFileNotFound
+			case strings.Contains(err.Error(), "to/conflict"):
+				return ErrFileExists
+			}
+			return fmt.Errorf("failed to move in Dropbox: %w", err)
+		}
+		info = s.metadataToFileInfo(result.Metadata)
+		return nil
+	})
+	return info, err
+}
+
 // TestConnection tests the Dropbox connection
 func (s *DropboxService) TestConnection(ctx context.Context) error {
 	return s.withClientRetry(ctx, func(client files.Client, parentFolder string) error {
@@ -791,6 +1031,47 @@ func (s *DropboxService) quickLiveCheck(ctx context.Context) error {
 
 // Helper methods
 
+// appSecret returns the decrypted app secret, used to verify webhook signatures
+func (s *DropboxService) appSecret() (string, error) {
+	s.cacheMutex.RLock()
+	config := s.cachedConfig
+	s.cacheMutex.RUnlock()
+
+	if config == nil || config.AppSecret == "" {
+		return "", ErrDropboxNotConfigured
+	}
+
+	secret, err := s.encryptionService.Decrypt(config.AppSecret)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt app secret: %w", err)
+	}
+	return secret, nil
+}
+
+// accountID returns the Dropbox account the connection is authorized for,
+// or "" if it is not known
+func (s *DropboxService) accountID() string {
+	s.cacheMutex.RLock()
+	defer s.cacheMutex.RUnlock()
+	if s.cachedConfig == nil {
+		return ""
+	}
+	return s.cachedConfig.AccountID
+}
+
+// resolvePath returns the full Dropbox path of a path relative to the app's parent folder
+func (s *DropboxService) resolvePath(relativePath string) (string, error) {
+	if !s.IsConfigured() {
+		return "", ErrDropboxNotConfigured
+	}
+
+	s.cacheMutex.RLock()
+	parentFolder := s.cachedConfig.ParentFolder
+	s.cacheMutex.RUnlock()
+
+	return s.getFullPath(relativePath, parentFolder), nil
+}
+
 func (s *DropboxService) getFullPath(relativePath string, parentFolder string) string {
 	// Clean the relative path
 	relativePath = strings.TrimPrefix(relativePath, "/")
@@ -824,14 +1105,17 @@ func (s *DropboxService) entryToFileInfo(entry files.IsMetadata) *DropboxFileInf
 	switch meta := entry.(type) {
 	case *files.FileMetadata:
 		return &DropboxFileInfo{
+			ID:           meta.Id,
 			Name:         meta.Name,
 			Path:         meta.PathDisplay,
 			Size:         meta.Size,
 			ModifiedTime: meta.ServerModified,
 			IsFolder:     false,
+			Rev:          meta.Rev,
 		}
 	case *files.FolderMetadata:
 		return &DropboxFileInfo{
+			ID:       meta.Id,
 			Name:     meta.Name,
 			Path:     meta.PathDisplay,
 			IsFolder: true,
@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+
+	"backend/internal/models"
+	"backend/internal/storage"
+)
+
+// DropboxStorage adapts DropboxService to the storage driver interface.
+// Paths are relative to the app's parent folder, as elsewhere in
+// DropboxService.
+type DropboxStorage struct {
+	dropboxService *DropboxService
+}
+
+// NewDropboxStorage creates a storage driver backed by Dropbox
+func NewDropboxStorage(dropboxService *DropboxService) *DropboxStorage {
+	return &DropboxStorage{dropboxService: dropboxService}
+}
+
+// IsConfigured reports whether Dropbox is connected
+func (d *DropboxStorage) IsConfigured() bool {
+	return d.dropboxService.IsConfigured()
+}
+
+// CreateFolder creates a folder
+func (d *DropboxStorage) CreateFolder(ctx context.Context, folderPath string) error {
+	return fromDropboxError(d.dropboxService.CreateFolder(folderPath))
+}
+
+// Upload stores a file, replacing any file at the path
+func (d *DropboxStorage) Upload(ctx context.Context, content io.Reader, filePath string) (*storage.Object, error) {
+	if seeker, ok := content.(io.ReadSeeker); ok {
+		info, err := d.dropboxService.UploadDocument(ctx, seeker, filePath, models.UploadConflictReplace)
+		if err != nil {
+			return nil, fromDropboxError(err)
+		}
+		return d.object(*info), nil
+	}
+	if err := d.dropboxService.UploadFile(ctx, content, filePath); err != nil {
+		return nil, fromDropboxError(err)
+	}
+	return d.Metadata(ctx, filePath)
+}
+
+// List returns the files and folders in a folder. Recursive listings leave
+// out folders without files.
+func (d *DropboxStorage) List(ctx context.Context, folderPath string, recursive bool) ([]storage.Object, error) {
+	list := d.dropboxService.ListFiles
+	if recursive {
+		list = d.dropboxService.ListFilesRecursive
+	}
+	files, err := list(folderPath)
+	if err != nil {
+		return nil, fromDropboxError(err)
+	}
+	return d.objects(files), nil
+}
+
+// Metadata returns a file or folder
+func (d *DropboxStorage) Metadata(ctx context.Context, itemPath string) (*storage.Object, error) {
+	info, err := d.dropboxService.GetFileMetadata(itemPath)
+	if err != nil {
+		return nil, fromDropboxError(err)
+	}
+	return d.object(*info), nil
+}
+
+// Rename moves a file or folder
+func (d *DropboxStorage) Rename(ctx context.Context, fromPath, toPath string) error {
+	_, err := d.dropboxService.MovePath(fromPath, toPath)
+	return fromDropboxError(err)
+}
+
+// Delete deletes a file or folder. Dropbox keeps deleted files restorable
+// for its retention period.
+func (d *DropboxStorage) Delete(ctx context.Context, itemPath string) error {
+	return fromDropboxError(d.dropboxService.DeletePath(itemPath))
+}
+
+// Open downloads a file
+func (d *DropboxStorage) Open(ctx context.Context, filePath string) (io.ReadCloser, *storage.Object, error) {
+	info, content, err := d.dropboxService.OpenFile(filePath)
+	if err != nil {
+		return nil, nil, fromDropboxError(err)
+	}
+	return content, d.object(*info), nil
+}
+
+// ShareLink returns a shared link to a file or folder
+func (d *DropboxStorage) ShareLink(ctx context.Context, itemPath string) (string, error) {
+	link, err := d.dropboxService.GetFolderShareLink(itemPath)
+	return link, fromDropboxError(err)
+}
+
+// TemporaryLink returns a temporary download link to a file
+func (d *DropboxStorage) TemporaryLink(ctx context.Context, filePath string) (string, *storage.Object, error) {
+	link, info, err := d.dropboxService.GetTemporaryLink(filePath)
+	if err != nil {
+		return "", nil, fromDropboxError(err)
+	}
+	if info == nil {
+		return link, &storage.Object{Path: strings.Trim(filePath, "/")}, nil
+	}
+	return link, d.object(*info), nil
+}
+
+// object converts a Dropbox entry, whose path includes the parent folder
+func (d *DropboxStorage) object(info DropboxFileInfo) *storage.Object {
+	return &storage.Object{
+		Name:         info.Name,
+		Path:         d.dropboxService.relativePath(info.Path),
+		Size:         int64(info.Size),
+		ModifiedTime: info.ModifiedTime,
+		IsFolder:     info.IsFolder,
+		Rev:          info.Rev,
+		Children:     d.objects(info.Children),
+	}
+}
+
+func (d *DropboxStorage) objects(files []DropboxFileInfo) []storage.Object {
+	if files == nil {
+		return nil
+	}
+	objects := make([]storage.Object, 0, len(files))
+	for _, file := range files {
+		objects = append(objects, *d.object(file))
+	}
+	return objects
+}
+
+// relativePath strips the app's parent folder from a Dropbox path
+func (s *DropboxService) relativePath(fullPath string) string {
+	s.cacheMutex.RLock()
+	parentFolder := ""
+	if s.cachedConfig != nil {
+		parentFolder = s.cachedConfig.ParentFolder
+	}
+	s.cacheMutex.RUnlock()
+
+	fullPath = strings.Trim(fullPath, "/")
+	parentFolder = strings.Trim(parentFolder, "/")
+	if parentFolder == "" || len(fullPath) < len(parentFolder) || !strings.EqualFold(fullPath[:len(parentFolder)], parentFolder) {
+		return fullPath
+	}
+	if rest := fullPath[len(parentFolder):]; rest == "" || rest[0] == '/' {
+		return strings.TrimPrefix(rest, "/")
+	}
+	return fullPath
+}
+
+// fromDropboxError maps Dropbox errors to storage errors
+func fromDropboxError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, ErrFileNotFound), errors.Is(err, ErrFolderNotFound):
+		return storage.ErrNotFound
+	case errors.Is(err, ErrFileExists):
+		return storage.ErrExists
+	case errors.Is(err, ErrDropboxNotConfigured):
+		return storage.ErrNotConfigured
+	}
+	return err
+}
@@ -0,0 +1,160 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/dropbox/dropbox-sdk-go-unofficial/v6/dropbox/auth"
+	"github.com/dropbox/dropbox-sdk-go-unofficial/v6/dropbox/files"
+)
+
+// Files are sent to Dropbox in chunks of dropboxChunkSize. A file that fits
+// in one chunk is uploaded with a single request; anything larger goes
+// through an upload session (start, append, finish), which also lifts the
+// 150MB limit of a single upload. Each request is retried on network errors,
+// server errors and rate limiting, so a flaky connection only repeats the
+// chunk that failed.
+const (
+	dropboxChunkSize     = 8 << 20
+	dropboxChunkAttempts = 5
+)
+
+// dropboxRetryDelay is the wait before the first retry of a chunk; it
+// doubles on each further attempt
+var dropboxRetryDelay = time.Second
+
+// uploadContent uploads content to a file, committing it with the options
+// set by commit. Content is read once, a chunk at a time.
+func (s *DropboxService) uploadContent(ctx context.Context, content io.Reader, relativePath string, commit func(*files.CommitInfo)) (*files.FileMetadata, error) {
+	commitInfo := func(parentFolder string) *files.CommitInfo {
+		info := files.NewCommitInfo(s.getFullPath(relativePath, parentFolder))
+		commit(info)
+		return info
+	}
+
+	chunk := make([]byte, dropboxChunkSize)
+	n, err := io.ReadFull(content, chunk)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		var metadata *files.FileMetadata
+		err := s.withChunkRetry(ctx, func(client files.Client, parentFolder string) error {
+			var err error
+			metadata, err = client.Upload(&files.UploadArg{CommitInfo: *commitInfo(parentFolder)}, bytes.NewReader(chunk[:n]))
+			return err
+		})
+		return metadata, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read upload: %w", err)
+	}
+
+	var sessionID string
+	err = s.withChunkRetry(ctx, func(client files.Client, _ string) error {
+		result, err := client.UploadSessionStart(files.NewUploadSessionStartArg(), bytes.NewReader(chunk[:n]))
+		if err == nil {
+			sessionID = result.SessionId
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	offset := uint64(n)
+
+	for {
+		n, err := io.ReadFull(content, chunk)
+		if n > 0 {
+			if err := s.appendChunk(ctx, sessionID, offset, chunk[:n]); err != nil {
+				return nil, err
+			}
+			offset += uint64(n)
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read upload: %w", err)
+		}
+	}
+
+	var metadata *files.FileMetadata
+	err = s.withChunkRetry(ctx, func(client files.Client, parentFolder string) error {
+		cursor := files.NewUploadSessionCursor(sessionID, offset)
+		var err error
+		metadata, err = client.UploadSessionFinish(files.NewUploadSessionFinishArg(cursor, commitInfo(parentFolder)), nil)
+		return err
+	})
+	return metadata, err
+}
+
+// appendChunk appends a chunk to an upload session. If a retried append
+// reports that Dropbox already has the chunk (its earlier response was
+// lost), the chunk is not sent again.
+func (s *DropboxService) appendChunk(ctx context.Context, sessionID string, offset uint64, chunk []byte) error {
+	return s.withChunkRetry(ctx, func(client files.Client, _ string) error {
+		arg := files.NewUploadSessionAppendArg(files.NewUploadSessionCursor(sessionID, offset))
+		err := client.UploadSessionAppendV2(arg, bytes.NewReader(chunk))
+
+		var appendErr files.UploadSessionAppendV2APIError
+		if errors.As(err, &appendErr) && appendErr.EndpointError != nil && appendErr.EndpointError.IncorrectOffset != nil {
+			if appendErr.EndpointError.IncorrectOffset.CorrectOffset == offset+uint64(len(chunk)) {
+				return nil
+			}
+		}
+		return err
+	})
+}
+
+// withChunkRetry runs one upload request with the Dropbox client, retrying
+// it with backoff while it fails with a transient error
+func (s *DropboxService) withChunkRetry(ctx context.Context, op func(files.Client, string) error) error {
+	delay := dropboxRetryDelay
+	for attempt := 1; ; attempt++ {
+		err := s.withClientRetry(ctx, op)
+		if err == nil {
+			return nil
+		}
+		wait, retry := transientUploadError(err, delay)
+		if !retry || attempt == dropboxChunkAttempts {
+			return err
+		}
+		fmt.Printf("Warning: Dropbox upload request failed (attempt %d of %d), retrying in %s: %v\n", attempt, dropboxChunkAttempts, wait, err)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+		delay *= 2
+	}
+}
+
+// transientUploadError reports whether a failed upload request is worth
+// retrying, and how long to wait first: the time Dropbox asks for when rate
+// limiting, otherwise delay
+func transientUploadError(err error, delay time.Duration) (time.Duration, bool) {
+	var rateLimit auth.RateLimitAPIError
+	if errors.As(err, &rateLimit) {
+		if rateLimit.RateLimitError != nil && rateLimit.RateLimitError.RetryAfter > 0 {
+			return time.Duration(rateLimit.RateLimitError.RetryAfter) * time.Second, true
+		}
+		return delay, true
+	}
+
+	var serverErr auth.ServerError
+	var urlErr *url.Error
+	var netErr net.Error
+	switch {
+	case errors.As(err, &serverErr), errors.As(err, &urlErr), errors.As(err, &netErr):
+		return delay, true
+	case errors.Is(err, io.ErrUnexpectedEOF), strings.Contains(err.Error(), "connection reset"):
+		return delay, true
+	}
+	return delay, false
+}
@@ -0,0 +1,238 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"backend/internal/dropboxwebhook"
+	"backend/internal/models"
+	"backend/internal/repository"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DropboxWebhookSecretEnv is the secret webhook notifications are signed
+// with: the app secret of the Dropbox app that sends them. It is needed for
+// connections authorized with PKCE, which keep no app secret.
+const DropboxWebhookSecretEnv = "DROPBOX_WEBHOOK_SECRET"
+
+var (
+	ErrInvalidWebhookSignature    = errors.New("invalid webhook signature")
+	ErrWebhookSecretNotConfigured = errors.New("no secret to verify Dropbox webhooks: set " + DropboxWebhookSecretEnv + " to the Dropbox app secret")
+)
+
+// DropboxWebhookStatus reports webhook activity for administrators
+type DropboxWebhookStatus struct {
+	Notifications      int       `json:"notifications"`
+	LastNotificationAt time.Time `json:"lastNotificationAt,omitempty"`
+	Syncing            bool      `json:"syncing"`
+	LastSyncAt         time.Time `json:"lastSyncAt,omitempty"`
+	LastSyncFolders    int       `json:"lastSyncFolders"`
+	LastSyncFailures   int       `json:"lastSyncFailures"`
+	LastError          string    `json:"lastError,omitempty"`
+}
+
+// DropboxWebhookService receives Dropbox change notifications and syncs the
+// folders of the library categories kept in the notified accounts, each in
+// the Dropbox connection of its library. The syncs update the
+// listing cache from its cursors and publish document events for the files
+// that changed; folders that fail to sync have their cached listing dropped.
+//
+// Notifications are coalesced, so a burst or a replay of notifications causes
+// at most one extra sync, and a sync that finds nothing new does nothing.
+type DropboxWebhookService struct {
+	connections    *DropboxConnectionService
+	listingService *DropboxListingService
+	libraryRepo    *repository.LibraryRepository
+	categoryRepo   *repository.LibraryCategoryRepository
+	secret         string
+
+	coalescer *dropboxwebhook.Coalescer
+	pending   dropboxwebhook.Pending
+
+	mu     sync.RWMutex
+	status DropboxWebhookStatus
+}
+
+// NewDropboxWebhookService creates a new DropboxWebhookService
+func NewDropboxWebhookService(
+	connections *DropboxConnectionService,
+	listingService *DropboxListingService,
+	libraryRepo *repository.LibraryRepository,
+	categoryRepo *repository.LibraryCategoryRepository,
+) *DropboxWebhookService {
+	s := &DropboxWebhookService{
+		connections:    connections,
+		listingService: listingService,
+		libraryRepo:    libraryRepo,
+		categoryRepo:   categoryRepo,
+		secret:         strings.TrimSpace(os.Getenv(DropboxWebhookSecretEnv)),
+	}
+	s.coalescer = dropboxwebhook.NewCoalescer(s.syncLibraries)
+	return s
+}
+
+// HandleNotification verifies a notification's signature with the webhook
+// secret or the app secret of any connection, and schedules a sync of the
+// accounts it names
+func (s *DropboxWebhookService) HandleNotification(body []byte, signature string) error {
+	secrets := s.secrets()
+	if len(secrets) == 0 {
+		return ErrWebhookSecretNotConfigured
+	}
+	verified := false
+	for _, secret := range secrets {
+		if dropboxwebhook.VerifySignature(secret, body, signature) {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return ErrInvalidWebhookSignature
+	}
+	notification, err := dropboxwebhook.ParseNotification(body)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.status.Notifications++
+	s.status.LastNotificationAt = time.Now()
+	s.mu.Unlock()
+
+	s.pending.Add(notification.ListFolder.Accounts)
+	s.coalescer.Trigger()
+	return nil
+}
+
+// secrets returns the secrets a notification may be signed with
+func (s *DropboxWebhookService) secrets() []string {
+	secrets := []string{}
+	if s.secret != "" {
+		secrets = append(secrets, s.secret)
+	}
+	for _, dropboxService := range s.connections.All() {
+		if secret, err := dropboxService.appSecret(); err == nil && secret != "" {
+			secrets = append(secrets, secret)
+		}
+	}
+	return secrets
+}
+
+// Status reports webhook activity
+func (s *DropboxWebhookService) Status() DropboxWebhookStatus {
+	s.mu.RLock()
+	status := s.status
+	s.mu.RUnlock()
+
+	status.Syncing = s.coalescer.Running()
+	return status
+}
+
+// syncLibraries syncs the category folders and other cached folders of the
+// connections authorized for the notified accounts
+func (s *DropboxWebhookService) syncLibraries() {
+	ctx := context.Background()
+	accounts := s.pending.Take()
+
+	folders, err := s.folders(ctx)
+	if err != nil {
+		fmt.Printf("Warning: Dropbox webhook sync failed: %v\n", err)
+		s.finishSync(0, 0, err)
+		return
+	}
+
+	notified := map[*DropboxService]bool{}
+	for _, dropboxService := range s.connections.All() {
+		if dropboxService.IsConfigured() && accounts.Includes(dropboxService.accountID()) {
+			notified[dropboxService] = true
+		}
+	}
+
+	synced := 0
+	failures := 0
+	var lastErr error
+	for _, folder := range folders {
+		dropboxService := s.connections.Get(folder.ConnectionID)
+		if !notified[dropboxService] {
+			continue
+		}
+		synced++
+		if err := s.listingService.SyncFolder(ctx, dropboxService, folder.RelativePath); err != nil {
+			fmt.Printf("Warning: Dropbox webhook sync of %s failed: %v\n", folder.RelativePath, err)
+			s.listingService.InvalidateFolder(ctx, dropboxService, folder.RelativePath)
+			failures++
+			lastErr = err
+		}
+	}
+
+	s.finishSync(synced, failures, lastErr)
+}
+
+// folders returns the folders to sync, without duplicates: the category
+// folders of libraries kept in Dropbox and every cached folder
+func (s *DropboxWebhookService) folders(ctx context.Context) ([]CachedFolder, error) {
+	libraries, err := s.libraryRepo.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list libraries: %w", err)
+	}
+	categories, err := s.categoryRepo.FindAll(ctx, repository.LibraryCategoryFilter{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list categories: %w", err)
+	}
+	cached, err := s.listingService.CachedFolders(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cached folders: %w", err)
+	}
+
+	libraryByID := map[primitive.ObjectID]*models.Library{}
+	for _, library := range libraries {
+		libraryByID[library.ID] = library
+	}
+
+	seen := map[string]bool{}
+	folders := []CachedFolder{}
+	add := func(folder CachedFolder) {
+		key := ""
+		if folder.ConnectionID != nil {
+			key = folder.ConnectionID.Hex()
+		}
+		key += ":" + folder.RelativePath
+		if folder.RelativePath != "" && !seen[key] {
+			seen[key] = true
+			folders = append(folders, folder)
+		}
+	}
+	for _, category := range categories {
+		library, ok := libraryByID[category.LibraryID]
+		if ok && library.UsesDropbox() {
+			add(CachedFolder{ConnectionID: library.DropboxConnectionID, RelativePath: category.DropboxPath})
+		}
+	}
+	for _, folder := range cached {
+		add(folder)
+	}
+	sort.Slice(folders, func(i, j int) bool {
+		return folders[i].RelativePath < folders[j].RelativePath
+	})
+	return folders, nil
+}
+
+func (s *DropboxWebhookService) finishSync(folders, failures int, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.status.LastSyncAt = time.Now()
+	s.status.LastSyncFolders = folders
+	s.status.LastSyncFailures = failures
+	s.status.LastError = ""
+	if err != nil {
+		s.status.LastError = err.Error()
+	}
+}
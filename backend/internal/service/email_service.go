@@ -4,7 +4,9 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"html"
 	"html/template"
+	"strings"
 	"time"
 
 	"backend/internal/models"
@@ -647,6 +649,520 @@ func (s *EmailService) generateAccountActivatedEmailHTML(userName string) string
 `, userName, currentYear)
 }
 
+// SendInstitutionModerationEmail tells the creator of an institution whether it was approved or rejected
+func (s *EmailService) SendInstitutionModerationEmail(smtpConfig models.SMTPConfig, userEmail, userName, institutionName string, approved bool, notes string) error {
+	// Validate SMTP config
+	if !smtpConfig.IsComplete() {
+		return ErrIncompleteSMTPConfig
+	}
+
+	// Decrypt password
+	decryptedPassword, err := s.encryptionService.Decrypt(smtpConfig.Password)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt SMTP password: %w", err)
+	}
+
+	subject := "Your Institution Has Been Approved - BLOODSA Doctor's Workspace"
+	if !approved {
+		subject = "Your Institution Was Not Approved - BLOODSA Doctor's Workspace"
+	}
+	htmlBody := s.generateInstitutionModerationEmailHTML(userName, institutionName, approved, notes)
+
+	m := gomail.NewMessage()
+	m.SetHeader("From", smtpConfig.FromEmail)
+	m.SetHeader("To", userEmail)
+	m.SetHeader("Subject", subject)
+	m.SetBody("text/html", htmlBody)
+
+	d := gomail.NewDialer(smtpConfig.Host, smtpConfig.Port, smtpConfig.Username, decryptedPassword)
+	if err := d.DialAndSend(m); err != nil {
+		return fmt.Errorf("%w: %v", ErrEmailSendFailed, err)
+	}
+
+	return nil
+}
+
+// generateInstitutionModerationEmailHTML generates the HTML body for the institution moderation email
+func (s *EmailService) generateInstitutionModerationEmailHTML(userName, institutionName string, approved bool, notes string) string {
+	currentYear := time.Now().Year()
+
+	title := "Institution Approved"
+	message := fmt.Sprintf("The institution <strong>%s</strong> you added has been reviewed and approved by an administrator. It is now visible to all users.", html.EscapeString(institutionName))
+	highlightColor := "#059669"
+	highlightBg := "#f0fdf4"
+	if !approved {
+		title = "Institution Not Approved"
+		message = fmt.Sprintf("The institution <strong>%s</strong> you added has been reviewed by an administrator and was not approved. You can edit it from your profile to submit it for review again.", html.EscapeString(institutionName))
+		highlightColor = "#dc2626"
+		highlightBg = "#fef2f2"
+	}
+
+	notesBlock := ""
+	if notes != "" {
+		notesBlock = fmt.Sprintf(`
+            <div class="highlight">
+                <p style="margin: 0;"><strong>Reviewer notes:</strong> %s</p>
+            </div>`, html.EscapeString(notes))
+	}
+
+	return fmt.Sprintf(`
+<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+    <style>
+        body {
+            font-family: Arial, sans-serif;
+            line-height: 1.6;
+            color: #333;
+            margin: 0;
+            padding: 0;
+        }
+        .container {
+            max-width: 600px;
+            margin: 0 auto;
+            padding: 20px;
+        }
+        .header {
+            background-color: #8B0000;
+            color: white;
+            padding: 30px;
+            text-align: center;
+            border-radius: 8px 8px 0 0;
+        }
+        .content {
+            background-color: #f9f9f9;
+            padding: 40px;
+            border: 1px solid #ddd;
+            border-radius: 0 0 8px 8px;
+        }
+        .highlight {
+            background-color: %s;
+            border-left: 4px solid %s;
+            padding: 15px;
+            margin: 20px 0;
+            border-radius: 4px;
+        }
+        .footer {
+            margin-top: 30px;
+            text-align: center;
+            color: #777;
+            font-size: 12px;
+        }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            <h1>%s</h1>
+            <p>BLOODSA Doctor's Workspace</p>
+        </div>
+        <div class="content">
+            <p>Dear %s,</p>
+
+            <p>%s</p>
+            %s
+
+            <p>If you have any questions or need assistance, please contact the system administrator.</p>
+
+            <div class="footer">
+                <p>This is an automated message from the BLOODSA Doctor's Workspace system.</p>
+                <p>© %d BLOODSA. All rights reserved.</p>
+            </div>
+        </div>
+    </div>
+</body>
+</html>
+`, highlightBg, highlightColor, title, html.EscapeString(userName), message, notesBlock, currentYear)
+}
+
+// AcknowledgementReminderItem is one document a reminder email lists
+type AcknowledgementReminderItem struct {
+	DocumentName string
+	CategoryName string
+	LibraryName  string
+}
+
+// SendAcknowledgementReminderEmail reminds a user of documents they still have to acknowledge
+func (s *EmailService) SendAcknowledgementReminderEmail(smtpConfig models.SMTPConfig, userEmail, userName string, items []AcknowledgementReminderItem) error {
+	// Validate SMTP config
+	if !smtpConfig.IsComplete() {
+		return ErrIncompleteSMTPConfig
+	}
+
+	// Decrypt password
+	decryptedPassword, err := s.encryptionService.Decrypt(smtpConfig.Password)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt SMTP password: %w", err)
+	}
+
+	subject := "Documents Awaiting Your Acknowledgement - BLOODSA Doctor's Workspace"
+	htmlBody := s.generateAcknowledgementReminderEmailHTML(userName, items)
+
+	m := gomail.NewMessage()
+	m.SetHeader("From", smtpConfig.FromEmail)
+	m.SetHeader("To", userEmail)
+	m.SetHeader("Subject", subject)
+	m.SetBody("text/html", htmlBody)
+
+	d := gomail.NewDialer(smtpConfig.Host, smtpConfig.Port, smtpConfig.Username, decryptedPassword)
+	if err := d.DialAndSend(m); err != nil {
+		return fmt.Errorf("%w: %v", ErrEmailSendFailed, err)
+	}
+
+	return nil
+}
+
+// generateAcknowledgementReminderEmailHTML generates the HTML body for the acknowledgement reminder email
+func (s *EmailService) generateAcknowledgementReminderEmailHTML(userName string, items []AcknowledgementReminderItem) string {
+	currentYear := time.Now().Year()
+
+	var list strings.Builder
+	for _, item := range items {
+		fmt.Fprintf(&list, "                <li><strong>%s</strong> &mdash; %s, %s</li>\n",
+			html.EscapeString(item.DocumentName), html.EscapeString(item.LibraryName), html.EscapeString(item.CategoryName))
+	}
+
+	return fmt.Sprintf(`
+<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+    <style>
+        body {
+            font-family: Arial, sans-serif;
+            line-height: 1.6;
+            color: #333;
+            margin: 0;
+            padding: 0;
+        }
+        .container {
+            max-width: 600px;
+            margin: 0 auto;
+            padding: 20px;
+        }
+        .header {
+            background-color: #8B0000;
+            color: white;
+            padding: 30px;
+            text-align: center;
+            border-radius: 8px 8px 0 0;
+        }
+        .content {
+            background-color: #f9f9f9;
+            padding: 40px;
+            border: 1px solid #ddd;
+            border-radius: 0 0 8px 8px;
+        }
+        .highlight {
+            background-color: #fffbeb;
+            border-left: 4px solid #d97706;
+            padding: 15px;
+            margin: 20px 0;
+            border-radius: 4px;
+        }
+        .footer {
+            margin-top: 30px;
+            text-align: center;
+            color: #777;
+            font-size: 12px;
+        }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            <h1>Acknowledgement Required</h1>
+            <p>BLOODSA Doctor's Workspace</p>
+        </div>
+        <div class="content">
+            <p>Dear %s,</p>
+
+            <p>The following documents are waiting for you to confirm that you have read and understood them:</p>
+
+            <div class="highlight">
+                <ul style="margin: 0;">
+%s                </ul>
+            </div>
+
+            <p>Please sign in to the Doctor's Workspace, read each document and record your acknowledgement.</p>
+
+            <div class="footer">
+                <p>This is an automated message from the BLOODSA Doctor's Workspace system.</p>
+                <p>© %d BLOODSA. All rights reserved.</p>
+            </div>
+        </div>
+    </div>
+</body>
+</html>
+`, html.EscapeString(userName), list.String(), currentYear)
+}
+
+// DocumentReviewReminderItem is one document a review reminder email lists
+type DocumentReviewReminderItem struct {
+	DocumentName string
+	CategoryName string
+	LibraryName  string
+	DueAt        time.Time
+}
+
+// SendDocumentReviewReminderEmail reminds a document owner of reviews that are due
+func (s *EmailService) SendDocumentReviewReminderEmail(smtpConfig models.SMTPConfig, userEmail, userName string, items []DocumentReviewReminderItem) error {
+	// Validate SMTP config
+	if !smtpConfig.IsComplete() {
+		return ErrIncompleteSMTPConfig
+	}
+
+	// Decrypt password
+	decryptedPassword, err := s.encryptionService.Decrypt(smtpConfig.Password)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt SMTP password: %w", err)
+	}
+
+	subject := "Document Reviews Due - BLOODSA Doctor's Workspace"
+	htmlBody := s.generateDocumentReviewReminderEmailHTML(userName, items)
+
+	m := gomail.NewMessage()
+	m.SetHeader("From", smtpConfig.FromEmail)
+	m.SetHeader("To", userEmail)
+	m.SetHeader("Subject", subject)
+	m.SetBody("text/html", htmlBody)
+
+	d := gomail.NewDialer(smtpConfig.Host, smtpConfig.Port, smtpConfig.Username, decryptedPassword)
+	if err := d.DialAndSend(m); err != nil {
+		return fmt.Errorf("%w: %v", ErrEmailSendFailed, err)
+	}
+
+	return nil
+}
+
+// generateDocumentReviewReminderEmailHTML generates the HTML body for the document review reminder email
+func (s *EmailService) generateDocumentReviewReminderEmailHTML(userName string, items []DocumentReviewReminderItem) string {
+	currentYear := time.Now().Year()
+
+	var list strings.Builder
+	for _, item := range items {
+		fmt.Fprintf(&list, "                <li><strong>%s</strong> &mdash; %s, %s (review due %s)</li>\n",
+			html.EscapeString(item.DocumentName), html.EscapeString(item.LibraryName), html.EscapeString(item.CategoryName),
+			item.DueAt.Format("2 January 2006"))
+	}
+
+	return fmt.Sprintf(`
+<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+    <style>
+        body {
+            font-family: Arial, sans-serif;
+            line-height: 1.6;
+            color: #333;
+            margin: 0;
+            padding: 0;
+        }
+        .container {
+            max-width: 600px;
+            margin: 0 auto;
+            padding: 20px;
+        }
+        .header {
+            background-color: #8B0000;
+            color: white;
+            padding: 30px;
+            text-align: center;
+            border-radius: 8px 8px 0 0;
+        }
+        .content {
+            background-color: #f9f9f9;
+            padding: 40px;
+            border: 1px solid #ddd;
+            border-radius: 0 0 8px 8px;
+        }
+        .highlight {
+            background-color: #fffbeb;
+            border-left: 4px solid #d97706;
+            padding: 15px;
+            margin: 20px 0;
+            border-radius: 4px;
+        }
+        .footer {
+            margin-top: 30px;
+            text-align: center;
+            color: #777;
+            font-size: 12px;
+        }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            <h1>Document Review Due</h1>
+            <p>BLOODSA Doctor's Workspace</p>
+        </div>
+        <div class="content">
+            <p>Dear %s,</p>
+
+            <p>You are the owner of the following documents, which are due or overdue for review:</p>
+
+            <div class="highlight">
+                <ul style="margin: 0;">
+%s                </ul>
+            </div>
+
+            <p>Please review each document, update it in Dropbox if needed, and record the review in the Doctor's Workspace.</p>
+
+            <div class="footer">
+                <p>This is an automated message from the BLOODSA Doctor's Workspace system.</p>
+                <p>© %d BLOODSA. All rights reserved.</p>
+            </div>
+        </div>
+    </div>
+</body>
+</html>
+`, html.EscapeString(userName), list.String(), currentYear)
+}
+
+// DropboxHealthEmailData describes a change in the Dropbox connection's health
+type DropboxHealthEmailData struct {
+	Connection          string // Name of the connection, empty for a single unnamed connection
+	Recovered           bool
+	ConsecutiveFailures int
+	LastError           string
+	NeedsReconnection   bool
+	Since               time.Time // When the outage began
+	At                  time.Time
+	StatusURL           string
+}
+
+// SendDropboxHealthEmail tells admins that the Dropbox connection has
+// degraded or recovered
+func (s *EmailService) SendDropboxHealthEmail(smtpConfig models.SMTPConfig, recipients []string, data DropboxHealthEmailData) error {
+	if len(recipients) == 0 {
+		return nil
+	}
+	if !smtpConfig.IsComplete() {
+		return ErrIncompleteSMTPConfig
+	}
+
+	decryptedPassword, err := s.encryptionService.Decrypt(smtpConfig.Password)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt SMTP password: %w", err)
+	}
+
+	subject := "Dropbox Connection Problem - BLOODSA Doctor's Workspace"
+	if data.Recovered {
+		subject = "Dropbox Connection Restored - BLOODSA Doctor's Workspace"
+	}
+	htmlBody := s.generateDropboxHealthEmailHTML(data)
+
+	m := gomail.NewMessage()
+	m.SetHeader("From", smtpConfig.FromEmail)
+	m.SetHeader("To", recipients...)
+	m.SetHeader("Subject", subject)
+	m.SetBody("text/html", htmlBody)
+
+	d := gomail.NewDialer(smtpConfig.Host, smtpConfig.Port, smtpConfig.Username, decryptedPassword)
+	if err := d.DialAndSend(m); err != nil {
+		return fmt.Errorf("%w: %v", ErrEmailSendFailed, err)
+	}
+
+	return nil
+}
+
+// generateDropboxHealthEmailHTML generates the HTML body for the Dropbox health email
+func (s *EmailService) generateDropboxHealthEmailHTML(data DropboxHealthEmailData) string {
+	currentYear := time.Now().Year()
+
+	title := "Dropbox Connection Problem"
+	accent := "#d97706"
+	var body strings.Builder
+	if data.Connection != "" {
+		fmt.Fprintf(&body, "            <p><strong>Connection:</strong> %s</p>\n", html.EscapeString(data.Connection))
+	}
+	if data.Recovered {
+		title = "Dropbox Connection Restored"
+		accent = "#16a34a"
+		fmt.Fprintf(&body, "            <p>The connection to Dropbox is working again as of %s.</p>\n", data.At.Format("2 January 2006 at 15:04 MST"))
+		fmt.Fprintf(&body, "            <div class=\"highlight\">The outage began %s. Documents, downloads and registry submissions are available again.</div>\n", data.Since.Format("2 January 2006 at 15:04 MST"))
+	} else {
+		body.WriteString("            <p>The Doctor's Workspace cannot reach Dropbox. Document downloads and registry submissions may fail until the connection is restored.</p>\n")
+		fmt.Fprintf(&body, "            <div class=\"highlight\"><strong>Failures in a row:</strong> %d<br><strong>Last error:</strong> %s</div>\n",
+			data.ConsecutiveFailures, html.EscapeString(data.LastError))
+		if data.NeedsReconnection {
+			body.WriteString("            <p>Dropbox no longer accepts the app's authorization. A super admin must reconnect Dropbox from the Dropbox status page.</p>\n")
+		} else {
+			body.WriteString("            <p>The workspace keeps retrying. If the problem persists, check the Dropbox status page and reconnect if needed.</p>\n")
+		}
+	}
+	if data.StatusURL != "" {
+		fmt.Fprintf(&body, "            <p><a href=\"%s\">Open the Dropbox status page</a></p>\n", html.EscapeString(data.StatusURL))
+	}
+
+	return fmt.Sprintf(`
+<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+    <style>
+        body {
+            font-family: Arial, sans-serif;
+            line-height: 1.6;
+            color: #333;
+            margin: 0;
+            padding: 0;
+        }
+        .container {
+            max-width: 600px;
+            margin: 0 auto;
+            padding: 20px;
+        }
+        .header {
+            background-color: #8B0000;
+            color: white;
+            padding: 30px;
+            text-align: center;
+            border-radius: 8px 8px 0 0;
+        }
+        .content {
+            background-color: #f9f9f9;
+            padding: 40px;
+            border: 1px solid #ddd;
+            border-radius: 0 0 8px 8px;
+        }
+        .highlight {
+            background-color: #fffbeb;
+            border-left: 4px solid %s;
+            padding: 15px;
+            margin: 20px 0;
+            border-radius: 4px;
+        }
+        .footer {
+            margin-top: 30px;
+            text-align: center;
+            color: #777;
+            font-size: 12px;
+        }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            <h1>%s</h1>
+            <p>BLOODSA Doctor's Workspace</p>
+        </div>
+        <div class="content">
+%s
+            <div class="footer">
+                <p>This is an automated message from the BLOODSA Doctor's Workspace system.</p>
+                <p>© %d BLOODSA. All rights reserved.</p>
+            </div>
+        </div>
+    </div>
+</body>
+</html>
+`, accent, title, body.String(), currentYear)
+}
+
 // generatePasswordResetEmailHTML generates the HTML body for password reset email
 func (s *EmailService) generatePasswordResetEmailHTML(code, userName string) string {
 	currentYear := time.Now().Year()
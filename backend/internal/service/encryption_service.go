@@ -1,134 +1,192 @@
 package service
 
 import (
-	"crypto/aes"
-	"crypto/cipher"
-	"crypto/rand"
+	"crypto/hmac"
+	"crypto/sha256"
 	"encoding/base64"
 	"errors"
 	"fmt"
-	"io"
 	"os"
+	"strings"
+
+	"backend/internal/fieldcrypt"
+	"backend/internal/keyring"
 )
 
 var (
-	ErrInvalidEncryptionKey = errors.New("encryption key must be 32 bytes for AES-256")
-	ErrInvalidCiphertext    = errors.New("invalid ciphertext: too short or malformed")
+	ErrInvalidEncryptionKey  = keyring.ErrInvalidKey
+	ErrInvalidCiphertext     = keyring.ErrInvalidCiphertext
+	ErrEncryptionKeyRequired = errors.New("ENCRYPTION_KEYS or ENCRYPTION_KEY must be set in production")
+	ErrIndexKeyRequired      = errors.New("ENCRYPTION_INDEX_KEY must be set in production")
+	ErrUnknownIndexKey       = errors.New("no key to derive blind indexes from: set ENCRYPTION_INDEX_KEY, or ENCRYPTION_INDEX_KEY_ID to a key in the keyring")
 )
 
-// EncryptionService handles encryption and decryption of sensitive data
+// legacyKeyID is the key ID of ENCRYPTION_KEY, the single key used before
+// keys were rotated
+const legacyKeyID = "default"
+
+// EncryptionService handles encryption and decryption of sensitive data.
+// Values are encrypted with the primary key of a keyring and carry the ID
+// of their key, so keys can be rotated: add a new primary key, keep the old
+// one until cmd/reencrypt has re-encrypted every stored value, then drop it.
+//
+// It also computes the blind indexes of encrypted personal data, keyed
+// hashes that let encrypted fields be matched exactly.
 type EncryptionService struct {
-	key []byte
+	keys     *keyring.Keyring
+	indexKey []byte
 }
 
-// NewEncryptionService creates a new encryption service
-// Reads encryption key from ENCRYPTION_KEY environment variable
+// NewEncryptionService creates a new encryption service from the
+// environment:
+//
+//   - ENCRYPTION_KEYS: comma-separated ID:base64 keys, such as
+//     "2025-10:<key>,2024:<key>"
+//   - ENCRYPTION_PRIMARY_KEY: the ID of the key new values are encrypted
+//     with, the first of ENCRYPTION_KEYS by default
+//   - ENCRYPTION_KEY: a single base64 key, with the ID "default". It is the
+//     primary key when ENCRYPTION_KEYS is not set.
+//   - ENCRYPTION_INDEX_KEY: a base64 key for blind indexes. It is kept when
+//     the primary key changes, so lookups on encrypted fields keep working
+//     while keys are rotated.
+//   - ENCRYPTION_INDEX_KEY_ID: outside production, the ID of the key that
+//     blind indexes are derived from when ENCRYPTION_INDEX_KEY is not set,
+//     "default" by default. It is a fixed key rather than the primary one,
+//     so rotating the primary key does not change the blind indexes.
+//
+// With APP_ENV=production a keyring and ENCRYPTION_INDEX_KEY are required.
+// Elsewhere a random key is used when there is no key, so encrypted data
+// does not survive a restart.
 func NewEncryptionService() (*EncryptionService, error) {
-	keyString := os.Getenv("ENCRYPTION_KEY")
+	keys, err := keyring.Parse(os.Getenv("ENCRYPTION_KEYS"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ENCRYPTION_KEYS: %w", err)
+	}
+	primary := strings.TrimSpace(os.Getenv("ENCRYPTION_PRIMARY_KEY"))
+	if primary == "" && len(keys) > 0 {
+		primary = keys[0].ID
+	}
+
+	if keyString := os.Getenv("ENCRYPTION_KEY"); keyString != "" {
+		// Decode the base64 key
+		key, err := base64.StdEncoding.DecodeString(keyString)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode encryption key: %w", err)
+		}
+		keys = append(keys, keyring.Key{ID: legacyKeyID, Secret: key})
+		if primary == "" {
+			primary = legacyKeyID
+		}
+	}
+
+	if len(keys) == 0 {
+		if os.Getenv("APP_ENV") == "production" {
+			return nil, ErrEncryptionKeyRequired
+		}
 
-	// If no key set, generate a warning but allow operation (for development)
-	if keyString == "" {
 		// Generate a random key for development (NOT for production)
-		key := make([]byte, 32)
-		if _, err := rand.Read(key); err != nil {
+		key, err := keyring.GenerateKey()
+		if err != nil {
 			return nil, fmt.Errorf("failed to generate encryption key: %w", err)
 		}
-		fmt.Println("WARNING: ENCRYPTION_KEY not set. Using random key (data will not persist across restarts)")
-		return &EncryptionService{key: key}, nil
+		fmt.Println("WARNING: ENCRYPTION_KEYS and ENCRYPTION_KEY not set. Using random key (data will not persist across restarts)")
+		keys, primary = []keyring.Key{{ID: legacyKeyID, Secret: key}}, legacyKeyID
 	}
 
-	// Decode the base64 key
-	key, err := base64.StdEncoding.DecodeString(keyString)
+	ring, err := keyring.New(keys, primary)
 	if err != nil {
-		return nil, fmt.Errorf("failed to decode encryption key: %w", err)
+		return nil, err
 	}
 
-	if len(key) != 32 {
-		return nil, ErrInvalidEncryptionKey
+	indexKey, err := blindIndexKey(keys)
+	if err != nil {
+		return nil, err
 	}
-
-	return &EncryptionService{key: key}, nil
+	return &EncryptionService{keys: ring, indexKey: indexKey}, nil
 }
 
-// Encrypt encrypts plaintext using AES-256-GCM
-func (s *EncryptionService) Encrypt(plaintext string) (string, error) {
-	if plaintext == "" {
-		return "", nil
+// blindIndexKey returns ENCRYPTION_INDEX_KEY. Outside production it falls
+// back to a key derived from the key named by ENCRYPTION_INDEX_KEY_ID,
+// never from the primary key, so blind indexes survive a key rotation.
+func blindIndexKey(keys []keyring.Key) ([]byte, error) {
+	if keyString := os.Getenv("ENCRYPTION_INDEX_KEY"); keyString != "" {
+		key, err := base64.StdEncoding.DecodeString(keyString)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode ENCRYPTION_INDEX_KEY: %w", err)
+		}
+		if len(key) != keyring.KeySize {
+			return nil, ErrInvalidEncryptionKey
+		}
+		return key, nil
 	}
-
-	// Create cipher
-	block, err := aes.NewCipher(s.key)
-	if err != nil {
-		return "", fmt.Errorf("failed to create cipher: %w", err)
+	if os.Getenv("APP_ENV") == "production" {
+		return nil, ErrIndexKeyRequired
 	}
 
-	// Create GCM
-	gcm, err := cipher.NewGCM(block)
-	if err != nil {
-		return "", fmt.Errorf("failed to create GCM: %w", err)
+	id := strings.TrimSpace(os.Getenv("ENCRYPTION_INDEX_KEY_ID"))
+	if id == "" {
+		id = legacyKeyID
 	}
-
-	// Generate nonce
-	nonce := make([]byte, gcm.NonceSize())
-	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
-		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	for _, key := range keys {
+		if key.ID == id {
+			mac := hmac.New(sha256.New, key.Secret)
+			mac.Write([]byte("blind-index"))
+			return mac.Sum(nil), nil
+		}
 	}
+	return nil, ErrUnknownIndexKey
+}
 
-	// Encrypt and append nonce
-	ciphertext := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+// PrimaryKeyID returns the ID of the key new values are encrypted with
+func (s *EncryptionService) PrimaryKeyID() string {
+	return s.keys.Primary()
+}
 
-	// Encode as base64 for storage
-	return base64.StdEncoding.EncodeToString(ciphertext), nil
+// Encrypt encrypts plaintext using AES-256-GCM with the primary key
+func (s *EncryptionService) Encrypt(plaintext string) (string, error) {
+	return s.keys.Encrypt(plaintext)
 }
 
-// Decrypt decrypts ciphertext using AES-256-GCM
+// Decrypt decrypts ciphertext using AES-256-GCM with the key it was
+// encrypted with
 func (s *EncryptionService) Decrypt(ciphertext string) (string, error) {
-	if ciphertext == "" {
-		return "", nil
-	}
+	return s.keys.Decrypt(ciphertext)
+}
 
-	// Decode from base64
-	data, err := base64.StdEncoding.DecodeString(ciphertext)
-	if err != nil {
-		return "", fmt.Errorf("failed to decode ciphertext: %w", err)
-	}
+// BlindIndex returns the blind index of a value: an HMAC-SHA256 that is the
+// same for equal values, so encrypted fields can be matched exactly
+func (s *EncryptionService) BlindIndex(value string) string {
+	mac := hmac.New(sha256.New, s.indexKey)
+	mac.Write([]byte(value))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
 
-	// Create cipher
-	block, err := aes.NewCipher(s.key)
-	if err != nil {
-		return "", fmt.Errorf("failed to create cipher: %w", err)
-	}
+// NeedsReencryption reports whether ciphertext was encrypted with a key
+// other than the primary key. Encrypted personal data is recognized by its
+// fieldcrypt prefix.
+func (s *EncryptionService) NeedsReencryption(ciphertext string) bool {
+	return s.keys.NeedsRotation(strings.TrimPrefix(ciphertext, fieldcrypt.Prefix))
+}
 
-	// Create GCM
-	gcm, err := cipher.NewGCM(block)
+// Reencrypt encrypts ciphertext again with the primary key, keeping the
+// fieldcrypt prefix of encrypted personal data
+func (s *EncryptionService) Reencrypt(ciphertext string) (string, error) {
+	rotated, err := s.keys.Rotate(strings.TrimPrefix(ciphertext, fieldcrypt.Prefix))
 	if err != nil {
-		return "", fmt.Errorf("failed to create GCM: %w", err)
+		return "", err
 	}
-
-	// Check minimum length
-	nonceSize := gcm.NonceSize()
-	if len(data) < nonceSize {
-		return "", ErrInvalidCiphertext
+	if fieldcrypt.IsEncrypted(ciphertext) {
+		rotated = fieldcrypt.Prefix + rotated
 	}
-
-	// Extract nonce and ciphertext
-	nonce, ciphertextBytes := data[:nonceSize], data[nonceSize:]
-
-	// Decrypt
-	plaintext, err := gcm.Open(nil, nonce, ciphertextBytes, nil)
-	if err != nil {
-		return "", fmt.Errorf("failed to decrypt: %w", err)
-	}
-
-	return string(plaintext), nil
+	return rotated, nil
 }
 
 // GenerateKey generates a new random 32-byte key and returns it as base64
 // This is a helper function for initial setup
 func GenerateKey() (string, error) {
-	key := make([]byte, 32)
-	if _, err := rand.Read(key); err != nil {
-		return "", fmt.Errorf("failed to generate key: %w", err)
+	key, err := keyring.GenerateKey()
+	if err != nil {
+		return "", err
 	}
 	return base64.StdEncoding.EncodeToString(key), nil
 }
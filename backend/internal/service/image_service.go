@@ -0,0 +1,196 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"backend/internal/imaging"
+	"backend/internal/models"
+	"backend/internal/repository"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// uploadDir is where uploaded files are written; it is served at uploadURLPrefix
+	uploadDir       = "./uploads"
+	uploadURLPrefix = "/uploads/"
+)
+
+// ImageService processes image uploads into sanitised, resized variants and
+// removes image files once no institution or category references them
+type ImageService struct {
+	imageRepo           *repository.ImageRepository
+	institutionRepo     *repository.InstitutionRepository
+	libraryCategoryRepo *repository.LibraryCategoryRepository
+}
+
+// NewImageService creates a new ImageService
+func NewImageService(
+	imageRepo *repository.ImageRepository,
+	institutionRepo *repository.InstitutionRepository,
+	libraryCategoryRepo *repository.LibraryCategoryRepository,
+) *ImageService {
+	return &ImageService{
+		imageRepo:           imageRepo,
+		institutionRepo:     institutionRepo,
+		libraryCategoryRepo: libraryCategoryRepo,
+	}
+}
+
+// Upload verifies, sanitises and resizes an uploaded image and writes its
+// variants under ./uploads/<kind>. The returned image's Path is the value
+// entities store as their ImagePath.
+func (s *ImageService) Upload(ctx context.Context, kind models.ImageKind, data []byte, uploadedBy *models.User) (*models.Image, error) {
+	variants, err := imaging.Process(data, imaging.DefaultSizes)
+	if err != nil {
+		return nil, err
+	}
+
+	dir := filepath.Join(uploadDir, string(kind))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create upload directory: %w", err)
+	}
+
+	image := &models.Image{
+		ID:         primitive.NewObjectID(),
+		Kind:       kind,
+		UploadedBy: &uploadedBy.ID,
+	}
+	for _, v := range variants {
+		filename := fmt.Sprintf("%s_%s%s", image.ID.Hex(), v.Name, v.Extension)
+		if err := os.WriteFile(filepath.Join(dir, filename), v.Data, 0644); err != nil {
+			s.removeFiles(image)
+			return nil, fmt.Errorf("failed to save image: %w", err)
+		}
+
+		variant := models.ImageVariant{
+			Name:        v.Name,
+			Path:        uploadURLPrefix + string(kind) + "/" + filename,
+			Width:       v.Width,
+			Height:      v.Height,
+			ContentType: v.ContentType,
+			Size:        int64(len(v.Data)),
+		}
+		image.Variants = append(image.Variants, variant)
+		if v.Name == imaging.VariantFull {
+			image.Path = variant.Path
+		}
+	}
+
+	if err := s.imageRepo.Create(ctx, image); err != nil {
+		s.removeFiles(image)
+		return nil, fmt.Errorf("failed to record image: %w", err)
+	}
+
+	return image, nil
+}
+
+// Variants returns the recorded variants of an image path, or nil for images
+// uploaded before variants were generated
+func (s *ImageService) Variants(ctx context.Context, imagePath string) []models.ImageVariant {
+	if imagePath == "" {
+		return nil
+	}
+	image, err := s.imageRepo.FindByPath(ctx, imagePath)
+	if err != nil {
+		return nil
+	}
+	return image.Variants
+}
+
+// ApplyUpdate adds a changed image path and its variants to an update
+// document. It returns the path being replaced, which the caller passes to
+// Release once the update has been saved.
+func (s *ImageService) ApplyUpdate(ctx context.Context, current string, next *string, update bson.M) string {
+	if next == nil || *next == current {
+		return ""
+	}
+	update["image_path"] = *next
+	update["image_variants"] = s.Variants(ctx, *next)
+	return current
+}
+
+// Release deletes an image's files and record once no institution or
+// category references it any more. Failures are logged rather than returned
+// as the entity change that orphaned the image has already been saved.
+func (s *ImageService) Release(ctx context.Context, imagePath string) {
+	if imagePath == "" {
+		return
+	}
+
+	referenced, err := s.isReferenced(ctx, imagePath)
+	if err != nil {
+		fmt.Printf("Warning: failed to check references to image %s: %v\n", imagePath, err)
+		return
+	}
+	if referenced {
+		return
+	}
+
+	image, err := s.imageRepo.FindByPath(ctx, imagePath)
+	if errors.Is(err, repository.ErrImageNotFound) {
+		// Uploaded before variants were recorded: the path is the only file
+		image = &models.Image{Path: imagePath, Variants: []models.ImageVariant{{Path: imagePath}}}
+	} else if err != nil {
+		fmt.Printf("Warning: failed to look up image %s: %v\n", imagePath, err)
+		return
+	}
+
+	s.removeFiles(image)
+	if err := s.imageRepo.DeleteByPath(ctx, imagePath); err != nil {
+		fmt.Printf("Warning: failed to delete image record %s: %v\n", imagePath, err)
+	}
+}
+
+// isReferenced reports whether any institution or category uses the image
+func (s *ImageService) isReferenced(ctx context.Context, imagePath string) (bool, error) {
+	counts := []func() (int64, error){
+		func() (int64, error) {
+			return s.institutionRepo.Count(ctx, bson.M{"image_path": imagePath})
+		},
+		func() (int64, error) {
+			return s.libraryCategoryRepo.Count(ctx, repository.LibraryCategoryFilter{ImagePath: imagePath})
+		},
+	}
+
+	for _, count := range counts {
+		n, err := count()
+		if err != nil {
+			return false, err
+		}
+		if n > 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// removeFiles deletes the variant files of an image. Only paths inside the
+// upload directory are touched.
+func (s *ImageService) removeFiles(image *models.Image) {
+	for _, v := range image.Variants {
+		file, ok := uploadFile(v.Path)
+		if !ok {
+			continue
+		}
+		if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+			fmt.Printf("Warning: failed to delete image file %s: %v\n", file, err)
+		}
+	}
+}
+
+// uploadFile maps a served /uploads/ path onto its file on disk
+func uploadFile(urlPath string) (string, bool) {
+	cleaned := path.Clean("/" + urlPath)
+	if !strings.HasPrefix(cleaned, uploadURLPrefix) {
+		return "", false
+	}
+	return filepath.Join(uploadDir, filepath.FromSlash(strings.TrimPrefix(cleaned, uploadURLPrefix))), true
+}
@@ -3,8 +3,13 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
+	"sort"
+	"time"
 
+	"backend/internal/geo"
 	"backend/internal/models"
+	"backend/internal/pagination"
 	"backend/internal/repository"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -12,22 +17,48 @@ import (
 )
 
 var (
-	ErrInstitutionHasUsers = errors.New("cannot delete institution: users are still associated with it")
+	ErrInstitutionHasUsers      = errors.New("cannot delete institution: users are still associated with it")
+	ErrMergeInstitutionNotFound = errors.New("one or more institutions to merge were not found")
+	ErrInstitutionHasUnits      = errors.New("cannot delete institution: it still has units below it")
+	ErrGazetteerNotLoaded       = errors.New("no gazetteer is loaded; set GAZETTEER_PATH to enable geocoding")
+	errImportShortNameAmbiguous = errors.New("short name matches more than one institution")
 )
 
 // InstitutionService handles business logic for institutions
 type InstitutionService struct {
 	institutionRepo *repository.InstitutionRepository
 	userRepo        *repository.UserRepository
+	submissionRepo  *repository.RegistrySubmissionRepository
 	auditRepo       *repository.AuditRepository
+	importRepo      *repository.InstitutionImportRepository
+	emailService    *EmailService
+	registryService *RegistryService
+	imageService    *ImageService
+	gazetteer       *geo.Gazetteer
 }
 
 // NewInstitutionService creates a new InstitutionService
-func NewInstitutionService(institutionRepo *repository.InstitutionRepository, userRepo *repository.UserRepository, auditRepo *repository.AuditRepository) *InstitutionService {
+func NewInstitutionService(
+	institutionRepo *repository.InstitutionRepository,
+	userRepo *repository.UserRepository,
+	submissionRepo *repository.RegistrySubmissionRepository,
+	auditRepo *repository.AuditRepository,
+	importRepo *repository.InstitutionImportRepository,
+	emailService *EmailService,
+	registryService *RegistryService,
+	imageService *ImageService,
+	gazetteer *geo.Gazetteer,
+) *InstitutionService {
 	return &InstitutionService{
 		institutionRepo: institutionRepo,
 		userRepo:        userRepo,
+		submissionRepo:  submissionRepo,
 		auditRepo:       auditRepo,
+		importRepo:      importRepo,
+		emailService:    emailService,
+		registryService: registryService,
+		imageService:    imageService,
+		gazetteer:       gazetteer,
 	}
 }
 
@@ -58,6 +89,25 @@ func (s *InstitutionService) CreateInstitution(ctx context.Context, req *models.
 		ImagePath:  req.ImagePath,
 		IsActive:   true,
 		CreatedBy:  &createdBy.ID,
+		Status:     models.InstitutionStatusApproved,
+	}
+
+	institution.ImageVariants = s.imageService.Variants(ctx, req.ImagePath)
+
+	if req.ParentID != "" {
+		parent, err := s.resolveParent(ctx, req.ParentID, createdBy)
+		if err != nil {
+			return nil, err
+		}
+		institution.ParentID = &parent.ID
+		institution.Ancestors = parent.PathIDs()
+	}
+
+	if req.Latitude != nil {
+		institution.Location = models.NewGeoPoint(*req.Latitude, *req.Longitude)
+		institution.LocationSource = models.LocationSourceManual
+	} else {
+		s.geocode(institution)
 	}
 
 	if err := s.institutionRepo.Create(ctx, institution); err != nil {
@@ -74,6 +124,7 @@ func (s *InstitutionService) CreateInstitution(ctx context.Context, req *models.
 			"institution_name": institution.Name,
 			"type":             string(institution.Type),
 			"city":             institution.City,
+			"parent_id":        hexOrEmpty(institution.ParentID),
 		},
 	})
 
@@ -81,7 +132,8 @@ func (s *InstitutionService) CreateInstitution(ctx context.Context, req *models.
 }
 
 // CreateUserInstitution creates a new institution by a regular user (for profile page)
-// Institutions created by users are created as active
+// Institutions created by users are active so the creator can use them straight
+// away, but stay pending and hidden from other users until an admin approves them
 func (s *InstitutionService) CreateUserInstitution(ctx context.Context, req *models.CreateInstitutionRequest, createdBy *models.User, ipAddress string) (*models.Institution, error) {
 	// Validate request
 	if err := req.Validate(); err != nil {
@@ -113,6 +165,25 @@ func (s *InstitutionService) CreateUserInstitution(ctx context.Context, req *mod
 		ImagePath:  req.ImagePath,
 		IsActive:   true, // Created as active
 		CreatedBy:  &createdBy.ID,
+		Status:     models.InstitutionStatusPending,
+	}
+
+	institution.ImageVariants = s.imageService.Variants(ctx, req.ImagePath)
+
+	if req.ParentID != "" {
+		parent, err := s.resolveParent(ctx, req.ParentID, createdBy)
+		if err != nil {
+			return nil, err
+		}
+		institution.ParentID = &parent.ID
+		institution.Ancestors = parent.PathIDs()
+	}
+
+	if req.Latitude != nil {
+		institution.Location = models.NewGeoPoint(*req.Latitude, *req.Longitude)
+		institution.LocationSource = models.LocationSourceManual
+	} else {
+		s.geocode(institution)
 	}
 
 	if err := s.institutionRepo.Create(ctx, institution); err != nil {
@@ -131,15 +202,25 @@ func (s *InstitutionService) CreateUserInstitution(ctx context.Context, req *mod
 			"city":             institution.City,
 			"created_by_user":  true, // Flag to indicate this was created by a regular user
 			"is_active":        true,
+			"status":           string(models.InstitutionStatusPending),
+			"parent_id":        hexOrEmpty(institution.ParentID),
 		},
 	})
 
 	return institution, nil
 }
 
-// GetInstitution retrieves an institution by ID
-func (s *InstitutionService) GetInstitution(ctx context.Context, id primitive.ObjectID) (*models.Institution, error) {
-	return s.institutionRepo.FindByID(ctx, id)
+// GetInstitution retrieves an institution by ID. Pending institutions are
+// reported as not found to users other than their creator and admins.
+func (s *InstitutionService) GetInstitution(ctx context.Context, id primitive.ObjectID, viewer *models.User) (*models.Institution, error) {
+	institution, err := s.institutionRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !institution.IsVisibleTo(viewer) {
+		return nil, repository.ErrInstitutionNotFound
+	}
+	return institution, nil
 }
 
 // UpdateInstitution updates an institution
@@ -155,6 +236,16 @@ func (s *InstitutionService) UpdateInstitution(ctx context.Context, id primitive
 		return nil, err
 	}
 
+	// Validate a move before writing anything
+	parent, move, err := s.parentChange(ctx, institution, req.ParentID, updatedBy)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := models.ValidateCoordinates(req.Latitude, req.Longitude); err != nil {
+		return nil, err
+	}
+
 	// Build update document
 	update := bson.M{}
 	if req.Name != nil {
@@ -203,19 +294,28 @@ func (s *InstitutionService) UpdateInstitution(ctx context.Context, id primitive
 	if req.Website != nil {
 		update["website"] = *req.Website
 	}
-	if req.ImagePath != nil {
-		update["image_path"] = *req.ImagePath
-	}
+	replacedImage := s.imageService.ApplyUpdate(ctx, institution.ImagePath, req.ImagePath, update)
 	if req.IsActive != nil {
 		update["is_active"] = *req.IsActive
 	}
+	s.locationUpdate(institution, req, update)
 
-	if len(update) == 0 {
+	if len(update) == 0 && !move {
 		return institution, nil
 	}
 
-	if err := s.institutionRepo.Update(ctx, id, update); err != nil {
-		return nil, err
+	if len(update) > 0 {
+		if err := s.institutionRepo.Update(ctx, id, update); err != nil {
+			return nil, err
+		}
+		s.imageService.Release(ctx, replacedImage)
+	}
+
+	if move {
+		if err := s.moveInstitution(ctx, institution, parent); err != nil {
+			return nil, err
+		}
+		update["parent_id"] = hexOrEmpty(newParentID(parent))
 	}
 
 	// Log audit
@@ -246,6 +346,16 @@ func (s *InstitutionService) UpdateUserInstitution(ctx context.Context, id primi
 		return nil, ErrUnauthorized
 	}
 
+	// Validate a move before writing anything
+	parent, move, err := s.parentChange(ctx, institution, req.ParentID, updatedBy)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := models.ValidateCoordinates(req.Latitude, req.Longitude); err != nil {
+		return nil, err
+	}
+
 	// Build update document
 	update := bson.M{}
 	if req.Name != nil {
@@ -294,17 +404,31 @@ func (s *InstitutionService) UpdateUserInstitution(ctx context.Context, id primi
 	if req.Website != nil {
 		update["website"] = *req.Website
 	}
-	if req.ImagePath != nil {
-		update["image_path"] = *req.ImagePath
-	}
+	replacedImage := s.imageService.ApplyUpdate(ctx, institution.ImagePath, req.ImagePath, update)
 	// Users cannot change IsActive status
+	s.locationUpdate(institution, req, update)
+
+	// Editing a rejected institution resubmits it for moderation
+	if (len(update) > 0 || move) && institution.Status == models.InstitutionStatusRejected {
+		update["status"] = models.InstitutionStatusPending
+	}
 
-	if len(update) == 0 {
+	if len(update) == 0 && !move {
 		return institution, nil
 	}
 
-	if err := s.institutionRepo.Update(ctx, id, update); err != nil {
-		return nil, err
+	if len(update) > 0 {
+		if err := s.institutionRepo.Update(ctx, id, update); err != nil {
+			return nil, err
+		}
+		s.imageService.Release(ctx, replacedImage)
+	}
+
+	if move {
+		if err := s.moveInstitution(ctx, institution, parent); err != nil {
+			return nil, err
+		}
+		update["parent_id"] = hexOrEmpty(newParentID(parent))
 	}
 
 	// Log audit
@@ -323,6 +447,12 @@ func (s *InstitutionService) UpdateUserInstitution(ctx context.Context, id primi
 	return s.institutionRepo.FindByID(ctx, id)
 }
 
+// UploadImage processes an uploaded institution logo into its variants. Any
+// authenticated user may upload; the handler enforces stricter checks where needed.
+func (s *InstitutionService) UploadImage(ctx context.Context, data []byte, uploadedBy *models.User) (*models.Image, error) {
+	return s.imageService.Upload(ctx, models.ImageKindInstitution, data, uploadedBy)
+}
+
 // DeleteInstitution deletes an institution
 func (s *InstitutionService) DeleteInstitution(ctx context.Context, id primitive.ObjectID, deletedBy *models.User, ipAddress string) error {
 	// Check if user has permission
@@ -345,9 +475,19 @@ func (s *InstitutionService) DeleteInstitution(ctx context.Context, id primitive
 		return ErrInstitutionHasUsers
 	}
 
+	// Units must be moved or deleted first so they are not left orphaned
+	unitCount, err := s.institutionRepo.Count(ctx, bson.M{"parent_id": id})
+	if err != nil {
+		return err
+	}
+	if unitCount > 0 {
+		return ErrInstitutionHasUnits
+	}
+
 	if err := s.institutionRepo.Delete(ctx, id); err != nil {
 		return err
 	}
+	s.imageService.Release(ctx, institution.ImagePath)
 
 	// Log audit
 	s.auditRepo.Create(ctx, &models.AuditLog{
@@ -425,29 +565,98 @@ func (s *InstitutionService) DeactivateInstitution(ctx context.Context, id primi
 	return nil
 }
 
-// ListInstitutions retrieves institutions with pagination and filtering
-func (s *InstitutionService) ListInstitutions(ctx context.Context, institutionType *models.InstitutionType, isActive *bool, search string, limit, skip int64) ([]*models.Institution, int64, error) {
+// InstitutionListFilter holds the optional filters for listing institutions
+type InstitutionListFilter struct {
+	Type     *models.InstitutionType
+	IsActive *bool
+	Status   *models.InstitutionStatus
+	Search   string
+	// ParentID restricts the list to direct children; NilObjectID selects
+	// top-level institutions
+	ParentID *primitive.ObjectID
+	// AncestorID restricts the list to every institution below it, at any depth
+	AncestorID *primitive.ObjectID
+	Province   string
+	City       string
+}
+
+// visibilityFilter restricts a listing to the institutions a viewer may see.
+// A nil viewer is an anonymous (public) request and only sees approved ones.
+func visibilityFilter(viewer *models.User) bson.M {
+	approved := bson.M{"status": bson.M{"$nin": []models.InstitutionStatus{
+		models.InstitutionStatusPending,
+		models.InstitutionStatusRejected,
+	}}}
+
+	if viewer == nil {
+		return approved
+	}
+	if viewer.HasPermission(models.PermManageUsers) {
+		return nil
+	}
+	return bson.M{"$or": []bson.M{
+		approved,
+		{"status": models.InstitutionStatusPending, "created_by": viewer.ID},
+	}}
+}
+
+// ListInstitutions retrieves one page of the institutions visible to the
+// viewer, with filtering and sorting
+func (s *InstitutionService) ListInstitutions(ctx context.Context, viewer *models.User, listFilter InstitutionListFilter, req pagination.Request) (*pagination.Page[*models.Institution], int64, error) {
 	filter := bson.M{}
+	conditions := []bson.M{}
 
-	if institutionType != nil {
-		filter["type"] = *institutionType
+	if listFilter.Type != nil {
+		filter["type"] = *listFilter.Type
 	}
-	if isActive != nil {
-		filter["is_active"] = *isActive
+	if listFilter.IsActive != nil {
+		filter["is_active"] = *listFilter.IsActive
+	}
+	if listFilter.Status != nil {
+		if *listFilter.Status == models.InstitutionStatusApproved {
+			conditions = append(conditions, bson.M{"status": bson.M{"$in": []interface{}{models.InstitutionStatusApproved, nil}}})
+		} else {
+			filter["status"] = *listFilter.Status
+		}
+	}
+	if listFilter.ParentID != nil {
+		if listFilter.ParentID.IsZero() {
+			filter["parent_id"] = nil
+		} else {
+			filter["parent_id"] = *listFilter.ParentID
+		}
+	}
+	if listFilter.AncestorID != nil {
+		filter["ancestors"] = *listFilter.AncestorID
+	}
+	if listFilter.Province != "" {
+		filter["province"] = listFilter.Province
+	}
+	if listFilter.City != "" {
+		filter["city"] = listFilter.City
+	}
+	if visibility := visibilityFilter(viewer); visibility != nil {
+		conditions = append(conditions, visibility)
 	}
 
 	// Add search filter if search query is provided
+	search := listFilter.Search
 	if search != "" {
-		filter["$or"] = []bson.M{
+		conditions = append(conditions, bson.M{"$or": []bson.M{
 			{"name": bson.M{"$regex": search, "$options": "i"}},
 			{"short_name": bson.M{"$regex": search, "$options": "i"}},
 			{"city": bson.M{"$regex": search, "$options": "i"}},
 			{"province": bson.M{"$regex": search, "$options": "i"}},
 			{"country": bson.M{"$regex": search, "$options": "i"}},
-		}
+			{"aliases": bson.M{"$regex": search, "$options": "i"}},
+		}})
+	}
+
+	if len(conditions) > 0 {
+		filter["$and"] = conditions
 	}
 
-	institutions, err := s.institutionRepo.List(ctx, filter, limit, skip)
+	page, err := s.institutionRepo.ListPage(ctx, filter, req)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -457,26 +666,313 @@ func (s *InstitutionService) ListInstitutions(ctx context.Context, institutionTy
 		return nil, 0, err
 	}
 
-	return institutions, count, nil
+	return page, count, nil
 }
 
-// ValidateInstitutionID checks if an institution ID exists and is active
-func (s *InstitutionService) ValidateInstitutionID(ctx context.Context, id primitive.ObjectID) error {
-	institution, err := s.institutionRepo.FindByID(ctx, id)
+// GetInstitutionPath returns the institution and the ancestors the viewer may
+// see, ordered from the root down to the institution itself
+func (s *InstitutionService) GetInstitutionPath(ctx context.Context, id primitive.ObjectID, viewer *models.User) ([]*models.Institution, error) {
+	path, err := s.institutionRepo.FindPath(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !path[len(path)-1].IsVisibleTo(viewer) {
+		return nil, repository.ErrInstitutionNotFound
+	}
+
+	visible := make([]*models.Institution, 0, len(path))
+	for _, inst := range path {
+		if inst.IsVisibleTo(viewer) {
+			visible = append(visible, inst)
+		}
+	}
+	return visible, nil
+}
+
+// GetInstitutionStats counts the users of an institution. Users attached to
+// any of its units count towards the institution's totals.
+func (s *InstitutionService) GetInstitutionStats(ctx context.Context, id primitive.ObjectID, viewer *models.User) (*models.InstitutionStats, error) {
+	if !viewer.HasPermission(models.PermManageUsers) {
+		return nil, ErrUnauthorized
+	}
+
+	if _, err := s.institutionRepo.FindByID(ctx, id); err != nil {
+		return nil, err
+	}
+
+	units, err := s.institutionRepo.FindDescendants(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	subtree := append([]primitive.ObjectID{id}, institutionIDs(units)...)
+
+	directUsers, err := s.userRepo.Count(ctx, bson.M{"profile.institution_id": id})
+	if err != nil {
+		return nil, err
+	}
+	totalUsers, err := s.userRepo.Count(ctx, bson.M{"profile.institution_id": bson.M{"$in": subtree}})
+	if err != nil {
+		return nil, err
+	}
+	activeUsers, err := s.userRepo.Count(ctx, bson.M{"profile.institution_id": bson.M{"$in": subtree}, "is_active": true})
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.InstitutionStats{
+		InstitutionID: id,
+		DirectUsers:   directUsers,
+		TotalUsers:    totalUsers,
+		ActiveUsers:   activeUsers,
+		Units:         int64(len(units)),
+	}, nil
+}
+
+// resolveParent loads the institution a new or moved institution should sit
+// under. The parent must be visible to the user placing it.
+func (s *InstitutionService) resolveParent(ctx context.Context, rawID string, user *models.User) (*models.Institution, error) {
+	parentID, err := primitive.ObjectIDFromHex(rawID)
+	if err != nil {
+		return nil, models.ErrInvalidParentInstitution
+	}
+
+	parent, err := s.institutionRepo.FindByID(ctx, parentID)
 	if err != nil {
 		if err == repository.ErrInstitutionNotFound {
-			return errors.New("institution not found")
+			return nil, models.ErrInvalidParentInstitution
 		}
+		return nil, err
+	}
+	if !parent.IsVisibleTo(user) {
+		return nil, models.ErrInvalidParentInstitution
+	}
+
+	return parent, nil
+}
+
+// parentChange works out whether an update moves the institution. It returns
+// the new parent (nil for top-level) and whether the parent changes.
+func (s *InstitutionService) parentChange(ctx context.Context, institution *models.Institution, rawID *string, user *models.User) (*models.Institution, bool, error) {
+	if rawID == nil {
+		return nil, false, nil
+	}
+
+	if *rawID == "" {
+		return nil, institution.ParentID != nil, nil
+	}
+
+	parent, err := s.resolveParent(ctx, *rawID, user)
+	if err != nil {
+		return nil, false, err
+	}
+	if err := institution.CanMoveUnder(parent); err != nil {
+		return nil, false, err
+	}
+
+	return parent, institution.ParentID == nil || *institution.ParentID != parent.ID, nil
+}
+
+// moveInstitution places an institution under parent (nil for top-level) and
+// rewrites the ancestors of every unit below it
+func (s *InstitutionService) moveInstitution(ctx context.Context, institution *models.Institution, parent *models.Institution) error {
+	var parentID *primitive.ObjectID
+	var ancestors []primitive.ObjectID
+	if parent != nil {
+		if err := institution.CanMoveUnder(parent); err != nil {
+			return err
+		}
+		parentID = &parent.ID
+		ancestors = parent.PathIDs()
+	}
+
+	if err := s.institutionRepo.SetParent(ctx, institution.ID, parentID, ancestors); err != nil {
 		return err
 	}
 
-	if !institution.IsActive {
-		return errors.New("institution is not active")
+	descendants, err := s.institutionRepo.FindDescendants(ctx, institution.ID)
+	if err != nil {
+		return err
+	}
+	for _, descendant := range descendants {
+		rebased := descendant.RebasedAncestors(institution.ID, ancestors)
+		if err := s.institutionRepo.SetParent(ctx, descendant.ID, descendant.ParentID, rebased); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
+// geocode sets an institution's location from the gazetteer, if one is
+// loaded and the city can be matched unambiguously
+func (s *InstitutionService) geocode(institution *models.Institution) bool {
+	place, ok := s.gazetteer.Lookup(institution.City, institution.Province, institution.Country)
+	if !ok {
+		return false
+	}
+	institution.Location = models.NewGeoPoint(place.Latitude, place.Longitude)
+	institution.LocationSource = models.LocationSourceGazetteer
+	return true
+}
+
+// locationUpdate adds location changes to an update document. Explicit
+// coordinates always win; otherwise a change of city, province or country
+// re-geocodes institutions whose location was not entered by hand.
+func (s *InstitutionService) locationUpdate(institution *models.Institution, req *models.UpdateInstitutionRequest, update bson.M) {
+	if req.Latitude != nil {
+		update["location"] = models.NewGeoPoint(*req.Latitude, *req.Longitude)
+		update["location_source"] = models.LocationSourceManual
+		return
+	}
+
+	_, cityChanged := update["city"]
+	_, provinceChanged := update["province"]
+	_, countryChanged := update["country"]
+	if !cityChanged && !provinceChanged && !countryChanged {
+		return
+	}
+	if institution.LocationSource == models.LocationSourceManual {
+		return
+	}
+
+	moved := *institution
+	if req.City != nil {
+		moved.City = *req.City
+	}
+	if req.Province != nil {
+		moved.Province = *req.Province
+	}
+	if req.Country != nil {
+		moved.Country = *req.Country
+	}
+	if s.geocode(&moved) {
+		update["location"] = moved.Location
+		update["location_source"] = moved.LocationSource
+	} else if institution.LocationSource == models.LocationSourceGazetteer {
+		// The old gazetteer match no longer describes the institution
+		update["location"] = nil
+		update["location_source"] = ""
+	}
+}
+
+// newParentID returns the ID of a parent, or nil for top-level
+func newParentID(parent *models.Institution) *primitive.ObjectID {
+	if parent == nil {
+		return nil
+	}
+	return &parent.ID
+}
+
+// hexOrEmpty renders an optional ObjectID for audit details
+func hexOrEmpty(id *primitive.ObjectID) string {
+	if id == nil {
+		return ""
+	}
+	return id.Hex()
+}
+
+// NearbyInstitutions finds active institutions visible to the viewer within
+// radiusKm of a point, closest first. A nil viewer is a public request.
+func (s *InstitutionService) NearbyInstitutions(ctx context.Context, viewer *models.User, lat, lng, radiusKm float64, limit int64) ([]*models.NearbyInstitution, error) {
+	if !geo.ValidCoordinates(lat, lng) {
+		return nil, models.ErrInvalidCoordinates
+	}
+
+	filter := bson.M{"is_active": true}
+	if visibility := visibilityFilter(viewer); visibility != nil {
+		filter["$and"] = []bson.M{visibility}
+	}
+
+	return s.institutionRepo.Nearby(ctx, models.NewGeoPoint(lat, lng), radiusKm, filter, limit)
+}
+
+// GetInstitutionFacets counts the active, approved institutions by province
+// and city for the public institution picker
+func (s *InstitutionService) GetInstitutionFacets(ctx context.Context) (*models.InstitutionFacets, error) {
+	filter := visibilityFilter(nil)
+	filter["is_active"] = true
+	return s.institutionRepo.Facets(ctx, filter)
+}
+
+// GeocodeInstitutions looks up coordinates in the gazetteer for institutions
+// without a location. With overwrite, earlier gazetteer matches are refreshed
+// too; locations entered by hand are never replaced.
+func (s *InstitutionService) GeocodeInstitutions(ctx context.Context, overwrite bool, user *models.User, ipAddress string) (*models.GeocodeResult, error) {
+	if !user.HasPermission(models.PermManageUsers) {
+		return nil, ErrUnauthorized
+	}
+	if s.gazetteer == nil {
+		return nil, ErrGazetteerNotLoaded
+	}
+
+	filter := bson.M{"location": nil}
+	if overwrite {
+		filter = bson.M{"location_source": bson.M{"$ne": models.LocationSourceManual}}
+	}
+
+	institutions, err := s.institutionRepo.List(ctx, filter, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.GeocodeResult{Checked: len(institutions), Unmatched: []string{}}
+	for _, institution := range institutions {
+		if !s.geocode(institution) {
+			result.Unmatched = append(result.Unmatched, institution.Name)
+			continue
+		}
+		if err := s.institutionRepo.SetLocation(ctx, institution.ID, institution.Location, institution.LocationSource); err != nil {
+			return nil, err
+		}
+		result.Geocoded++
+	}
+
+	// Log audit
+	s.auditRepo.Create(ctx, &models.AuditLog{
+		PerformedBy: &user.ID,
+		Action:      models.AuditActionInstitutionUpdated,
+		IPAddress:   ipAddress,
+		Details: map[string]interface{}{
+			"institution_name": "all institutions",
+			"geocoded":         result.Geocoded,
+			"unmatched":        len(result.Unmatched),
+			"overwrite":        overwrite,
+		},
+	})
+
+	return result, nil
+}
+
+// ValidateInstitutionID checks that an institution exists, is active and
+// may be chosen by the given user. Pending institutions are only accepted
+// for the user who proposed them.
+func (s *InstitutionService) ValidateInstitutionID(ctx context.Context, id, userID primitive.ObjectID) error {
+	_, err := findSelectableInstitution(ctx, s.institutionRepo, id, userID)
+	return err
+}
+
+// findSelectableInstitution loads an institution for a user's profile and
+// rejects it when it is inactive or has not passed moderation
+func findSelectableInstitution(ctx context.Context, repo *repository.InstitutionRepository, id, userID primitive.ObjectID) (*models.Institution, error) {
+	institution, err := repo.FindByID(ctx, id)
+	if err != nil {
+		if err == repository.ErrInstitutionNotFound {
+			return nil, errors.New("institution not found")
+		}
+		return nil, err
+	}
+
+	if !institution.IsActive {
+		return nil, errors.New("institution is not active")
+	}
+
+	if err := institution.CheckSelectableBy(userID); err != nil {
+		return nil, err
+	}
+
+	return institution, nil
+}
+
 // CountInstitutions counts institutions with optional filtering
 func (s *InstitutionService) CountInstitutions(ctx context.Context, isActive *bool) (int64, error) {
 	filter := bson.M{}
@@ -485,3 +981,646 @@ func (s *InstitutionService) CountInstitutions(ctx context.Context, isActive *bo
 	}
 	return s.institutionRepo.Count(ctx, filter)
 }
+
+// loadMergeInstitutions fetches the target and sources of a merge
+func (s *InstitutionService) loadMergeInstitutions(ctx context.Context, req *models.MergeInstitutionsRequest) (*models.Institution, []*models.Institution, error) {
+	targetID, sourceIDs, err := req.Validate()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	target, err := s.institutionRepo.FindByID(ctx, targetID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sources, err := s.institutionRepo.FindByIDs(ctx, sourceIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(sources) != len(sourceIDs) {
+		return nil, nil, ErrMergeInstitutionNotFound
+	}
+
+	// The target cannot absorb an institution it sits under
+	for _, source := range sources {
+		if target.IsDescendantOf(source.ID) {
+			return nil, nil, models.ErrInstitutionCycle
+		}
+	}
+
+	return target, sources, nil
+}
+
+// institutionIDs returns the IDs of the given institutions
+func institutionIDs(institutions []*models.Institution) []primitive.ObjectID {
+	ids := make([]primitive.ObjectID, 0, len(institutions))
+	for _, inst := range institutions {
+		ids = append(ids, inst.ID)
+	}
+	return ids
+}
+
+// BackfillSubmissionInstitutions sets the institution of submissions made
+// before submissions recorded one, so that merges reassign them, and returns
+// the number of submissions updated. It runs in the background at startup.
+//
+// The institution a submitter belonged to when submitting was never stored,
+// so their current institution is used instead. This is an approximation:
+// submissions of users who have since moved are attributed to their new
+// institution, and those of deleted users or users without an institution
+// are left without one.
+func (s *InstitutionService) BackfillSubmissionInstitutions(ctx context.Context) (int64, error) {
+	userIDs, err := s.submissionRepo.UserIDsWithoutInstitution(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to find submissions without an institution: %w", err)
+	}
+
+	var updated int64
+	for _, userID := range userIDs {
+		user, err := s.userRepo.FindByID(ctx, userID)
+		if err != nil {
+			if errors.Is(err, repository.ErrUserNotFound) {
+				continue
+			}
+			return updated, err
+		}
+		if user.Profile.InstitutionID == nil {
+			continue
+		}
+
+		count, err := s.submissionRepo.SetMissingInstitution(ctx, userID, *user.Profile.InstitutionID)
+		if err != nil {
+			return updated, fmt.Errorf("failed to set the institution of submissions: %w", err)
+		}
+		updated += count
+	}
+	return updated, nil
+}
+
+// PreviewMerge reports what merging the sources into the target would change
+func (s *InstitutionService) PreviewMerge(ctx context.Context, req *models.MergeInstitutionsRequest, user *models.User) (*models.InstitutionMergePreview, error) {
+	if !user.HasPermission(models.PermDeleteUsers) {
+		return nil, ErrUnauthorized
+	}
+
+	target, sources, err := s.loadMergeInstitutions(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	sourceIDs := institutionIDs(sources)
+
+	affectedUsers, err := s.userRepo.Count(ctx, bson.M{"profile.institution_id": bson.M{"$in": sourceIDs}})
+	if err != nil {
+		return nil, err
+	}
+
+	affectedSubmissions, err := s.submissionRepo.CountByInstitutions(ctx, sourceIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	affectedUnits, err := s.institutionRepo.Count(ctx, bson.M{"parent_id": bson.M{"$in": sourceIDs}, "_id": bson.M{"$nin": sourceIDs}})
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.InstitutionMergePreview{
+		Target:              target,
+		Sources:             sources,
+		AffectedUsers:       affectedUsers,
+		AffectedSubmissions: affectedSubmissions,
+		AffectedUnits:       affectedUnits,
+		NewAliases:          models.MergeAliases(target, sources),
+	}, nil
+}
+
+// MergeInstitutions merges the source institutions into the target. Users and
+// registry submissions are re-pointed to the target, the sources' names are
+// kept as aliases for search and the sources are deleted.
+func (s *InstitutionService) MergeInstitutions(ctx context.Context, req *models.MergeInstitutionsRequest, mergedBy *models.User, ipAddress string) (*models.InstitutionMergeResult, error) {
+	if !mergedBy.HasPermission(models.PermDeleteUsers) {
+		return nil, ErrUnauthorized
+	}
+
+	target, sources, err := s.loadMergeInstitutions(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	sourceIDs := institutionIDs(sources)
+	aliases := models.MergeAliases(target, sources)
+
+	// Record aliases first so the retired names stay searchable even if a
+	// later step fails and the merge has to be re-run
+	if err := s.institutionRepo.AddAliases(ctx, target.ID, aliases); err != nil {
+		return nil, err
+	}
+
+	usersReassigned, err := s.userRepo.ReassignInstitution(ctx, sourceIDs, target.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	submissionsReassigned, err := s.submissionRepo.ReassignInstitution(ctx, sourceIDs, target.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	unitsMoved, err := s.moveUnitsToTarget(ctx, sourceIDs, target)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.institutionRepo.DeleteMany(ctx, sourceIDs); err != nil {
+		return nil, err
+	}
+	for _, source := range sources {
+		s.imageService.Release(ctx, source.ImagePath)
+	}
+
+	mergedIDs := make([]string, 0, len(sources))
+	mergedNames := make([]string, 0, len(sources))
+	for _, source := range sources {
+		mergedIDs = append(mergedIDs, source.ID.Hex())
+		mergedNames = append(mergedNames, source.Name)
+	}
+
+	// Log audit
+	s.auditRepo.Create(ctx, &models.AuditLog{
+		PerformedBy: &mergedBy.ID,
+		Action:      models.AuditActionInstitutionMerged,
+		IPAddress:   ipAddress,
+		Details: map[string]interface{}{
+			"institution_id":         target.ID.Hex(),
+			"institution_name":       target.Name,
+			"merged_ids":             mergedIDs,
+			"merged_names":           mergedNames,
+			"aliases_added":          aliases,
+			"users_reassigned":       usersReassigned,
+			"submissions_reassigned": submissionsReassigned,
+			"units_moved":            unitsMoved,
+		},
+	})
+
+	updated, err := s.institutionRepo.FindByID(ctx, target.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.InstitutionMergeResult{
+		Target:                updated,
+		MergedIDs:             mergedIDs,
+		UsersReassigned:       usersReassigned,
+		SubmissionsReassigned: submissionsReassigned,
+		UnitsMoved:            unitsMoved,
+	}, nil
+}
+
+// moveUnitsToTarget re-parents the units of merged institutions onto the
+// target. Units that are themselves being merged are left to be deleted.
+func (s *InstitutionService) moveUnitsToTarget(ctx context.Context, sourceIDs []primitive.ObjectID, target *models.Institution) (int64, error) {
+	merging := make(map[primitive.ObjectID]bool, len(sourceIDs))
+	for _, id := range sourceIDs {
+		merging[id] = true
+	}
+
+	var moved int64
+	for _, sourceID := range sourceIDs {
+		children, err := s.institutionRepo.FindChildren(ctx, sourceID)
+		if err != nil {
+			return moved, err
+		}
+		for _, child := range children {
+			if merging[child.ID] {
+				continue
+			}
+			if err := s.moveInstitution(ctx, child, target); err != nil {
+				return moved, err
+			}
+			moved++
+		}
+	}
+
+	return moved, nil
+}
+
+// FindDuplicateCandidates reports pairs of institutions in the same city with
+// similar names, for review before merging
+func (s *InstitutionService) FindDuplicateCandidates(ctx context.Context, threshold float64, user *models.User) ([]models.InstitutionDuplicateCandidate, error) {
+	if !user.HasPermission(models.PermDeleteUsers) {
+		return nil, ErrUnauthorized
+	}
+
+	institutions, err := s.institutionRepo.List(ctx, bson.M{}, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return models.FindInstitutionDuplicates(institutions, threshold), nil
+}
+
+// ModerateInstitution approves or rejects a pending user-created institution
+// and notifies its creator. Changes in the request are applied before approval.
+func (s *InstitutionService) ModerateInstitution(ctx context.Context, id primitive.ObjectID, req *models.ModerateInstitutionRequest, moderator *models.User, ipAddress string) (*models.Institution, error) {
+	if !moderator.HasPermission(models.PermManageUsers) {
+		return nil, ErrUnauthorized
+	}
+
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	institution, err := s.institutionRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if institution.Status != models.InstitutionStatusPending {
+		return nil, models.ErrInstitutionNotPending
+	}
+
+	if req.Action == models.ModerationActionApprove && req.Changes != nil {
+		if institution, err = s.UpdateInstitution(ctx, id, req.Changes, moderator, ipAddress); err != nil {
+			return nil, err
+		}
+	}
+
+	status := models.InstitutionStatusApproved
+	action := models.AuditActionInstitutionApproved
+	if req.Action == models.ModerationActionReject {
+		status = models.InstitutionStatusRejected
+		action = models.AuditActionInstitutionRejected
+	}
+
+	if err := s.institutionRepo.Update(ctx, id, bson.M{
+		"status":       status,
+		"reviewed_by":  moderator.ID,
+		"reviewed_at":  time.Now(),
+		"review_notes": req.Notes,
+	}); err != nil {
+		return nil, err
+	}
+
+	// Log audit
+	s.auditRepo.Create(ctx, &models.AuditLog{
+		UserID:      institution.CreatedBy,
+		PerformedBy: &moderator.ID,
+		Action:      action,
+		IPAddress:   ipAddress,
+		Details: map[string]interface{}{
+			"institution_id":   id.Hex(),
+			"institution_name": institution.Name,
+			"notes":            req.Notes,
+		},
+	})
+
+	s.notifyInstitutionCreator(ctx, institution, status, req.Notes)
+
+	return s.institutionRepo.FindByID(ctx, id)
+}
+
+// notifyInstitutionCreator emails the creator of an institution about a
+// moderation decision (non-blocking; failures are logged only)
+func (s *InstitutionService) notifyInstitutionCreator(ctx context.Context, institution *models.Institution, status models.InstitutionStatus, notes string) {
+	if s.emailService == nil || s.registryService == nil || institution.CreatedBy == nil {
+		return
+	}
+
+	creator, err := s.userRepo.FindByID(ctx, *institution.CreatedBy)
+	if err != nil {
+		return
+	}
+
+	smtpConfig, err := s.registryService.GetPublicSMTPConfig(ctx)
+	if err != nil || smtpConfig == nil || !smtpConfig.IsComplete() {
+		return
+	}
+
+	userName := creator.Profile.FirstName + " " + creator.Profile.LastName
+	if userName == " " {
+		userName = creator.Username
+	}
+
+	approved := status == models.InstitutionStatusApproved
+	if err := s.emailService.SendInstitutionModerationEmail(*smtpConfig, creator.Email, userName, institution.Name, approved, notes); err != nil {
+		fmt.Printf("Warning: Failed to send institution moderation email to %s: %v\n", creator.Email, err)
+	}
+}
+
+// InstitutionImportOptions describes where an import came from and whether
+// it should be applied
+type InstitutionImportOptions struct {
+	Source string
+	Format models.ImportFormat
+	DryRun bool
+}
+
+// ImportInstitutions upserts institutions from a CSV or JSON file. Rows are
+// matched to existing institutions by name or alias, then by short name;
+// matches are updated with the non-empty cells of the row and the rest are
+// created. A dry run reports the same outcome without writing anything.
+// Applied imports are kept as import history. importedBy is nil when run from
+// the command line.
+func (s *InstitutionService) ImportInstitutions(ctx context.Context, data []byte, opts InstitutionImportOptions, importedBy *models.User, ipAddress string) (*models.InstitutionImport, error) {
+	if importedBy != nil && !importedBy.HasPermission(models.PermManageUsers) {
+		return nil, ErrUnauthorized
+	}
+
+	rows, err := models.ParseInstitutionImport(data, opts.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	imp := &models.InstitutionImport{
+		Source: opts.Source,
+		Format: opts.Format,
+		DryRun: opts.DryRun,
+		Rows:   make([]models.InstitutionImportRowResult, 0, len(rows)),
+	}
+	if importedBy != nil {
+		imp.ImportedBy = &importedBy.ID
+	}
+
+	// Institutions created by earlier rows, so later rows can name them as
+	// parent even in a dry run where nothing is written
+	planned := map[string]*models.Institution{}
+	seen := map[string]bool{}
+
+	for i := range rows {
+		result, err := s.importRow(ctx, &rows[i], opts.DryRun, importedBy, planned, seen)
+		if err != nil {
+			return nil, err
+		}
+		result.Row = i + 1
+		imp.Record(result)
+	}
+
+	if opts.DryRun {
+		return imp, nil
+	}
+
+	if err := s.importRepo.Create(ctx, imp); err != nil {
+		return nil, err
+	}
+
+	// Log audit
+	var performedBy *primitive.ObjectID
+	if importedBy != nil {
+		performedBy = &importedBy.ID
+	}
+	s.auditRepo.Create(ctx, &models.AuditLog{
+		PerformedBy: performedBy,
+		Action:      models.AuditActionInstitutionsImported,
+		IPAddress:   ipAddress,
+		Details: map[string]interface{}{
+			"import_id": imp.ID.Hex(),
+			"source":    imp.Source,
+			"format":    string(imp.Format),
+			"created":   imp.Created,
+			"updated":   imp.Updated,
+			"skipped":   imp.Skipped,
+		},
+	})
+
+	return imp, nil
+}
+
+// importRow creates or updates the institution for one import row. Problems
+// with the row itself are reported as a skipped result; only database
+// failures are returned as errors.
+func (s *InstitutionService) importRow(ctx context.Context, row *models.InstitutionImportRow, dryRun bool, importedBy *models.User, planned map[string]*models.Institution, seen map[string]bool) (models.InstitutionImportRowResult, error) {
+	result := models.InstitutionImportRowResult{Name: row.Name, Status: models.ImportRowSkipped}
+
+	key := models.NormalizeInstitutionName(row.Name)
+	if key == "" {
+		result.Reason = models.ErrInstitutionNameRequired.Error()
+		return result, nil
+	}
+	if seen[key] {
+		result.Reason = "duplicate row in file"
+		return result, nil
+	}
+	seen[key] = true
+
+	if err := models.ValidateCoordinates(row.Latitude, row.Longitude); err != nil {
+		result.Reason = err.Error()
+		return result, nil
+	}
+	if row.Type != "" && !row.Type.IsValid() {
+		result.Reason = models.ErrInvalidInstitutionType.Error()
+		return result, nil
+	}
+
+	var parent *models.Institution
+	if row.Parent != "" {
+		p, err := s.findImportParent(ctx, row.Parent, planned)
+		if err != nil {
+			return result, err
+		}
+		if p == nil {
+			result.Reason = "parent institution " + row.Parent + " not found"
+			return result, nil
+		}
+		parent = p
+	}
+
+	existing, err := s.matchImportRow(ctx, row)
+	if err == errImportShortNameAmbiguous {
+		result.Reason = err.Error()
+		return result, nil
+	}
+	if err != nil {
+		return result, err
+	}
+
+	if existing == nil {
+		return s.importCreate(ctx, row, parent, dryRun, importedBy, planned, result)
+	}
+	return s.importUpdate(ctx, row, existing, parent, dryRun, result)
+}
+
+// matchImportRow finds the institution an import row refers to, by name or
+// alias first and then by short name. A short name shared by several
+// institutions is not a match.
+func (s *InstitutionService) matchImportRow(ctx context.Context, row *models.InstitutionImportRow) (*models.Institution, error) {
+	exists, err := s.institutionRepo.NameExists(ctx, row.Name, nil)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return s.institutionRepo.FindByName(ctx, row.Name)
+	}
+
+	if row.ShortName == "" {
+		return nil, nil
+	}
+	matches, err := s.institutionRepo.FindByShortName(ctx, row.ShortName)
+	if err != nil {
+		return nil, err
+	}
+	switch len(matches) {
+	case 0:
+		return nil, nil
+	case 1:
+		return matches[0], nil
+	}
+	return nil, errImportShortNameAmbiguous
+}
+
+// findImportParent resolves a parent named in an import row, including
+// institutions created by earlier rows of the same import
+func (s *InstitutionService) findImportParent(ctx context.Context, name string, planned map[string]*models.Institution) (*models.Institution, error) {
+	if p, ok := planned[models.NormalizeInstitutionName(name)]; ok {
+		return p, nil
+	}
+
+	parent, err := s.institutionRepo.FindByName(ctx, name)
+	if err == repository.ErrInstitutionNotFound {
+		return nil, nil
+	}
+	return parent, err
+}
+
+// importCreate creates a new institution from an import row
+func (s *InstitutionService) importCreate(ctx context.Context, row *models.InstitutionImportRow, parent *models.Institution, dryRun bool, importedBy *models.User, planned map[string]*models.Institution, result models.InstitutionImportRowResult) (models.InstitutionImportRowResult, error) {
+	req := row.CreateRequest()
+	if err := req.Validate(); err != nil {
+		result.Reason = err.Error()
+		return result, nil
+	}
+
+	institution := &models.Institution{
+		Name:       req.Name,
+		ShortName:  req.ShortName,
+		Type:       req.Type,
+		Country:    req.Country,
+		Province:   req.Province,
+		City:       req.City,
+		Address:    req.Address,
+		PostalCode: req.PostalCode,
+		Phone:      req.Phone,
+		Email:      req.Email,
+		Website:    req.Website,
+		IsActive:   true,
+		Status:     models.InstitutionStatusApproved,
+	}
+	if importedBy != nil {
+		institution.CreatedBy = &importedBy.ID
+	}
+	if parent != nil {
+		institution.ParentID = &parent.ID
+		institution.Ancestors = parent.PathIDs()
+	}
+	if req.Latitude != nil {
+		institution.Location = models.NewGeoPoint(*req.Latitude, *req.Longitude)
+		institution.LocationSource = models.LocationSourceManual
+	} else {
+		s.geocode(institution)
+	}
+
+	if dryRun {
+		// Give the planned institution an ID so later rows can nest under it
+		institution.ID = primitive.NewObjectID()
+	} else {
+		if err := s.institutionRepo.Create(ctx, institution); err != nil {
+			if err == repository.ErrDuplicateInstitution {
+				result.Reason = err.Error()
+				return result, nil
+			}
+			return result, err
+		}
+		result.InstitutionID = &institution.ID
+	}
+	planned[models.NormalizeInstitutionName(institution.Name)] = institution
+
+	result.Status = models.ImportRowCreated
+	return result, nil
+}
+
+// importUpdate applies the non-empty cells of an import row to an existing institution
+func (s *InstitutionService) importUpdate(ctx context.Context, row *models.InstitutionImportRow, existing *models.Institution, parent *models.Institution, dryRun bool, result models.InstitutionImportRowResult) (models.InstitutionImportRowResult, error) {
+	result.InstitutionID = &existing.ID
+
+	update := bson.M{}
+	for field, value := range row.ChangedFields(existing) {
+		update[field] = value
+	}
+
+	// Re-geocode when the place changes and the location was not set by hand
+	_, hasLocation := update["location"]
+	if !hasLocation && existing.LocationSource != models.LocationSourceManual {
+		moved := *existing
+		moved.City, moved.Province, moved.Country = pick(row.City, existing.City), pick(row.Province, existing.Province), pick(row.Country, existing.Country)
+		if moved.City != existing.City || moved.Province != existing.Province || moved.Country != existing.Country {
+			if s.geocode(&moved) {
+				update["location"] = moved.Location
+				update["location_source"] = moved.LocationSource
+			}
+		}
+	}
+
+	move := false
+	if parent != nil && (existing.ParentID == nil || *existing.ParentID != parent.ID) {
+		if err := existing.CanMoveUnder(parent); err != nil {
+			result.Reason = err.Error()
+			return result, nil
+		}
+		move = true
+	}
+
+	if len(update) == 0 && !move {
+		result.Reason = "no changes"
+		return result, nil
+	}
+
+	for field := range update {
+		result.Changes = append(result.Changes, field)
+	}
+	sort.Strings(result.Changes)
+	if move {
+		result.Changes = append(result.Changes, "parent_id")
+	}
+
+	if !dryRun {
+		if len(update) > 0 {
+			if err := s.institutionRepo.Update(ctx, existing.ID, update); err != nil {
+				return result, err
+			}
+		}
+		if move {
+			if err := s.moveInstitution(ctx, existing, parent); err != nil {
+				return result, err
+			}
+		}
+	}
+
+	result.Status = models.ImportRowUpdated
+	return result, nil
+}
+
+// pick returns value, or fallback when value is empty
+func pick(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+// ListImports retrieves one page of the institution import history
+func (s *InstitutionService) ListImports(ctx context.Context, req pagination.Request) (*pagination.Page[*models.InstitutionImport], int64, error) {
+	page, err := s.importRepo.ListPage(ctx, req)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	count, err := s.importRepo.Count(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return page, count, nil
+}
+
+// GetImport retrieves one recorded import with its row results
+func (s *InstitutionService) GetImport(ctx context.Context, id primitive.ObjectID) (*models.InstitutionImport, error) {
+	return s.importRepo.FindByID(ctx, id)
+}
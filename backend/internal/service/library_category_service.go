@@ -0,0 +1,636 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"backend/internal/models"
+	"backend/internal/pagination"
+	"backend/internal/repository"
+	"backend/internal/storage"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrCategoryNotFound = errors.New("category not found")
+	ErrDuplicateSlug    = errors.New("category with this name already exists")
+)
+
+// LibraryCategoryService handles business logic for the categories of every
+// document library. Each call names its library by slug; permissions come
+// from the library rather than being fixed per route.
+type LibraryCategoryService struct {
+	libraryService *LibraryService
+	categoryRepo   *repository.LibraryCategoryRepository
+	storageService *StorageService
+	listingService *DropboxListingService
+	metadataRepo   *repository.DocumentMetadataRepository
+	auditRepo      *repository.AuditRepository
+	imageService   *ImageService
+	downloads      *DownloadAnalyticsService
+}
+
+// NewLibraryCategoryService creates a new LibraryCategoryService
+func NewLibraryCategoryService(
+	libraryService *LibraryService,
+	categoryRepo *repository.LibraryCategoryRepository,
+	storageService *StorageService,
+	listingService *DropboxListingService,
+	metadataRepo *repository.DocumentMetadataRepository,
+	auditRepo *repository.AuditRepository,
+	imageService *ImageService,
+	downloads *DownloadAnalyticsService,
+) *LibraryCategoryService {
+	return &LibraryCategoryService{
+		libraryService: libraryService,
+		categoryRepo:   categoryRepo,
+		storageService: storageService,
+		listingService: listingService,
+		metadataRepo:   metadataRepo,
+		auditRepo:      auditRepo,
+		imageService:   imageService,
+		downloads:      downloads,
+	}
+}
+
+// managedLibrary resolves a library the user may manage categories in
+func (s *LibraryCategoryService) managedLibrary(ctx context.Context, slug string, user *models.User) (*models.Library, error) {
+	library, err := s.libraryService.GetLibrary(ctx, slug, user)
+	if err != nil {
+		return nil, err
+	}
+	if !library.CanManage(user) {
+		return nil, ErrUnauthorized
+	}
+	return library, nil
+}
+
+// managedCategory resolves a category in a library the user may manage
+func (s *LibraryCategoryService) managedCategory(
+	ctx context.Context,
+	slug string,
+	id primitive.ObjectID,
+	user *models.User,
+) (*models.Library, *models.LibraryCategory, error) {
+	library, err := s.managedLibrary(ctx, slug, user)
+	if err != nil {
+		return nil, nil, err
+	}
+	category, err := s.categoryRepo.FindByID(ctx, library.ID, id)
+	if err != nil {
+		if err == repository.ErrCategoryNotFound {
+			return nil, nil, ErrCategoryNotFound
+		}
+		return nil, nil, fmt.Errorf("failed to get category: %w", err)
+	}
+	return library, category, nil
+}
+
+// CreateCategory creates a new category and its folder in the library's
+// storage
+func (s *LibraryCategoryService) CreateCategory(
+	ctx context.Context,
+	librarySlug string,
+	req *models.CreateLibraryCategoryRequest,
+	createdBy *models.User,
+	ipAddress string,
+) (*models.LibraryCategory, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	library, err := s.managedLibrary(ctx, librarySlug, createdBy)
+	if err != nil {
+		return nil, err
+	}
+
+	slug := models.GenerateSlug(req.Name)
+	exists, err := s.categoryRepo.ExistsBySlug(ctx, library.ID, slug, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check slug existence: %w", err)
+	}
+	if exists {
+		return nil, ErrDuplicateSlug
+	}
+
+	category := &models.LibraryCategory{
+		LibraryID:    library.ID,
+		Name:         req.Name,
+		Slug:         slug,
+		Description:  req.Description,
+		ImagePath:    req.ImagePath,
+		DropboxPath:  library.CategoryDropboxPath(req.Name),
+		DisplayOrder: req.DisplayOrder,
+		IsActive:     true,
+		CreatedBy:    &createdBy.ID,
+	}
+	category.ImageVariants = s.imageService.Variants(ctx, req.ImagePath)
+
+	if err := category.Validate(); err != nil {
+		return nil, err
+	}
+
+	storageName := models.StorageDriverName(library.StorageDriver)
+	if driver := s.storageService.ForLibrary(library); driver.IsConfigured() {
+		if err := driver.CreateFolder(ctx, storagePath(library.DropboxRoot)); err != nil {
+			fmt.Printf("ERROR: Failed to create %s parent folder '%s': %v\n", storageName, library.DropboxRoot, err)
+		}
+		fmt.Printf("Attempting to create %s folder at path: %s\n", storageName, category.DropboxPath)
+		if err := driver.CreateFolder(ctx, storagePath(category.DropboxPath)); err != nil {
+			// Log the error but don't fail the category creation
+			fmt.Printf("ERROR: Failed to create %s folder for category '%s' at path '%s': %v\n", storageName, category.Name, category.DropboxPath, err)
+		} else {
+			fmt.Printf("SUCCESS: Created %s folder for category '%s' at path '%s'\n", storageName, category.Name, category.DropboxPath)
+		}
+	} else {
+		fmt.Printf("WARNING: %s storage is not configured - skipping folder creation for category '%s'\n", storageName, category.Name)
+	}
+
+	if err := s.categoryRepo.Create(ctx, category); err != nil {
+		if err == repository.ErrDuplicateSlug {
+			return nil, ErrDuplicateSlug
+		}
+		return nil, fmt.Errorf("failed to create category: %w", err)
+	}
+
+	s.auditRepo.Create(ctx, &models.AuditLog{
+		UserID:      &createdBy.ID,
+		PerformedBy: &createdBy.ID,
+		Action:      models.AuditActionLibraryCategoryCreated,
+		Details: bson.M{
+			"library":       library.Slug,
+			"category_id":   category.ID.Hex(),
+			"category_name": category.Name,
+			"slug":          category.Slug,
+		},
+		IPAddress: ipAddress,
+	})
+
+	return category, nil
+}
+
+// getCategory retrieves a category the user may view, with its library
+func (s *LibraryCategoryService) getCategory(
+	ctx context.Context,
+	librarySlug string,
+	id primitive.ObjectID,
+	user *models.User,
+) (*models.Library, *models.LibraryCategory, error) {
+	library, err := s.libraryService.GetLibrary(ctx, librarySlug, user)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	category, err := s.categoryRepo.FindByID(ctx, library.ID, id)
+	if err != nil {
+		if err == repository.ErrCategoryNotFound {
+			return nil, nil, ErrCategoryNotFound
+		}
+		return nil, nil, fmt.Errorf("failed to get category: %w", err)
+	}
+
+	// Only library managers can see inactive categories
+	if !category.IsActive && !library.CanManage(user) {
+		return nil, nil, ErrCategoryNotFound
+	}
+
+	return library, category, nil
+}
+
+// GetCategory retrieves a category by ID
+func (s *LibraryCategoryService) GetCategory(ctx context.Context, librarySlug string, id primitive.ObjectID, user *models.User) (*models.LibraryCategory, error) {
+	_, category, err := s.getCategory(ctx, librarySlug, id, user)
+	return category, err
+}
+
+// ListCategories lists a library's categories with filters
+func (s *LibraryCategoryService) ListCategories(
+	ctx context.Context,
+	librarySlug string,
+	user *models.User,
+	search string,
+	req pagination.Request,
+) (*pagination.Page[*models.LibraryCategory], int64, error) {
+	library, err := s.libraryService.GetLibrary(ctx, librarySlug, user)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	filter := repository.LibraryCategoryFilter{
+		LibraryID: &library.ID,
+		Search:    search,
+	}
+
+	// Only library managers can see inactive categories
+	if !library.CanManage(user) {
+		isActive := true
+		filter.IsActive = &isActive
+	}
+
+	page, err := s.categoryRepo.List(ctx, filter, req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list categories: %w", err)
+	}
+
+	total, err := s.categoryRepo.Count(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count categories: %w", err)
+	}
+
+	return page, total, nil
+}
+
+// UpdateCategory updates a category, renaming its Dropbox folder with it
+func (s *LibraryCategoryService) UpdateCategory(
+	ctx context.Context,
+	librarySlug string,
+	id primitive.ObjectID,
+	req *models.UpdateLibraryCategoryRequest,
+	updatedBy *models.User,
+	ipAddress string,
+) (*models.LibraryCategory, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	library, err := s.managedLibrary(ctx, librarySlug, updatedBy)
+	if err != nil {
+		return nil, err
+	}
+
+	category, err := s.categoryRepo.FindByID(ctx, library.ID, id)
+	if err != nil {
+		if err == repository.ErrCategoryNotFound {
+			return nil, ErrCategoryNotFound
+		}
+		return nil, fmt.Errorf("failed to get category: %w", err)
+	}
+
+	update := bson.M{}
+	oldDropboxPath := category.DropboxPath
+	nameChanged := false
+
+	if req.Name != nil && *req.Name != category.Name {
+		update["name"] = *req.Name
+		slug := models.GenerateSlug(*req.Name)
+
+		exists, err := s.categoryRepo.ExistsBySlug(ctx, library.ID, slug, &id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check slug existence: %w", err)
+		}
+		if exists {
+			return nil, ErrDuplicateSlug
+		}
+
+		update["slug"] = slug
+		update["dropbox_path"] = library.CategoryDropboxPath(*req.Name)
+		nameChanged = true
+	}
+
+	if req.Description != nil {
+		update["description"] = *req.Description
+	}
+
+	replacedImage := s.imageService.ApplyUpdate(ctx, category.ImagePath, req.ImagePath, update)
+
+	if req.DisplayOrder != nil {
+		update["display_order"] = *req.DisplayOrder
+	}
+
+	if req.IsActive != nil {
+		update["is_active"] = *req.IsActive
+	}
+
+	if len(update) == 0 {
+		return category, nil
+	}
+
+	if err := s.categoryRepo.Update(ctx, id, update); err != nil {
+		if err == repository.ErrDuplicateSlug {
+			return nil, ErrDuplicateSlug
+		}
+		return nil, fmt.Errorf("failed to update category: %w", err)
+	}
+	s.imageService.Release(ctx, replacedImage)
+
+	if driver := s.storageService.ForLibrary(library); nameChanged && driver.IsConfigured() {
+		newDropboxPath := update["dropbox_path"].(string)
+		fmt.Printf("Attempting to rename folder from '%s' to '%s'\n", oldDropboxPath, newDropboxPath)
+		if err := driver.Rename(ctx, storagePath(oldDropboxPath), storagePath(newDropboxPath)); err != nil {
+			fmt.Printf("ERROR: Failed to rename folder from '%s' to '%s': %v\n", oldDropboxPath, newDropboxPath, err)
+			return nil, fmt.Errorf("category updated in database but failed to rename its folder: %w", fromStorageError(err))
+		}
+		fmt.Printf("SUCCESS: Renamed folder from '%s' to '%s'\n", oldDropboxPath, newDropboxPath)
+		if library.UsesDropbox() {
+			s.listingService.InvalidateFolder(ctx, s.storageService.connections.ForLibrary(library), oldDropboxPath)
+		}
+	}
+
+	updatedCategory, err := s.categoryRepo.FindByID(ctx, library.ID, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get updated category: %w", err)
+	}
+
+	s.auditRepo.Create(ctx, &models.AuditLog{
+		UserID:      &updatedBy.ID,
+		PerformedBy: &updatedBy.ID,
+		Action:      models.AuditActionLibraryCategoryUpdated,
+		Details: bson.M{
+			"library":       library.Slug,
+			"category_id":   id.Hex(),
+			"category_name": updatedCategory.Name,
+			"changes":       update,
+		},
+		IPAddress: ipAddress,
+	})
+
+	return updatedCategory, nil
+}
+
+// DeleteCategory deletes a category from the database (Dropbox folder remains)
+func (s *LibraryCategoryService) DeleteCategory(
+	ctx context.Context,
+	librarySlug string,
+	id primitive.ObjectID,
+	deletedBy *models.User,
+	ipAddress string,
+) error {
+	library, err := s.managedLibrary(ctx, librarySlug, deletedBy)
+	if err != nil {
+		return err
+	}
+
+	category, err := s.categoryRepo.FindByID(ctx, library.ID, id)
+	if err != nil {
+		if err == repository.ErrCategoryNotFound {
+			return ErrCategoryNotFound
+		}
+		return fmt.Errorf("failed to get category: %w", err)
+	}
+
+	if err := s.categoryRepo.Delete(ctx, id); err != nil {
+		if err == repository.ErrCategoryNotFound {
+			return ErrCategoryNotFound
+		}
+		return fmt.Errorf("failed to delete category: %w", err)
+	}
+	s.imageService.Release(ctx, category.ImagePath)
+
+	s.auditRepo.Create(ctx, &models.AuditLog{
+		UserID:      &deletedBy.ID,
+		PerformedBy: &deletedBy.ID,
+		Action:      models.AuditActionLibraryCategoryDeleted,
+		Details: bson.M{
+			"library":       library.Slug,
+			"category_id":   id.Hex(),
+			"category_name": category.Name,
+			"slug":          category.Slug,
+			"dropbox_path":  category.DropboxPath,
+		},
+		IPAddress: ipAddress,
+	})
+
+	return nil
+}
+
+// UploadImage processes an uploaded category image into its variants
+func (s *LibraryCategoryService) UploadImage(ctx context.Context, librarySlug string, data []byte, uploadedBy *models.User) (*models.Image, error) {
+	if _, err := s.managedLibrary(ctx, librarySlug, uploadedBy); err != nil {
+		return nil, err
+	}
+	return s.imageService.Upload(ctx, models.ImageKindLibrary, data, uploadedBy)
+}
+
+// SeedCategories creates a built-in library's initial categories when it has
+// none. It returns the created categories, or the existing count when the
+// library already has categories.
+func (s *LibraryCategoryService) SeedCategories(
+	ctx context.Context,
+	librarySlug string,
+	user *models.User,
+	ipAddress string,
+) ([]*models.LibraryCategory, int64, error) {
+	library, err := s.managedLibrary(ctx, librarySlug, user)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	existing, err := s.categoryRepo.Count(ctx, repository.LibraryCategoryFilter{LibraryID: &library.ID})
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to check existing categories: %w", err)
+	}
+	if existing > 0 {
+		return nil, existing, nil
+	}
+
+	builtin, _ := findBuiltinLibrary(library.Slug)
+	created := []*models.LibraryCategory{}
+	for _, req := range builtin.seed {
+		category, err := s.CreateCategory(ctx, library.Slug, &req, user, ipAddress)
+		if err != nil {
+			// Log error but continue with other categories
+			fmt.Printf("Warning: failed to seed category '%s' in library '%s': %v\n", req.Name, library.Slug, err)
+			continue
+		}
+		created = append(created, category)
+	}
+
+	return created, 0, nil
+}
+
+// GetCategoryFiles lists all files in a category's folder, with the review
+// metadata of the files that have it
+func (s *LibraryCategoryService) GetCategoryFiles(
+	ctx context.Context,
+	librarySlug string,
+	id primitive.ObjectID,
+	user *models.User,
+) ([]DropboxFileInfo, error) {
+	library, category, err := s.getCategory(ctx, librarySlug, id, user)
+	if err != nil {
+		return nil, err
+	}
+
+	if !s.storageService.ForLibrary(library).IsConfigured() {
+		return nil, ErrStorageNotConfigured
+	}
+
+	// Serve the nested folder structure, from the listing cache for Dropbox
+	files, err := s.storageService.CategoryFiles(ctx, library, category)
+	if err != nil {
+		if err == ErrFolderNotFound {
+			// Return empty list if folder doesn't exist yet
+			return []DropboxFileInfo{}, nil
+		}
+		return nil, fmt.Errorf("failed to list files: %w", err)
+	}
+
+	metadata, err := s.metadataRepo.FindByCategory(ctx, category.ID)
+	if err != nil {
+		fmt.Printf("Warning: failed to get document metadata for category %s: %v\n", category.ID.Hex(), err)
+	} else if len(metadata) > 0 {
+		byPath := make(map[string]*models.DocumentMetadata, len(metadata))
+		for _, m := range metadata {
+			byPath[m.PathLower] = m
+		}
+		attachReviews(files, byPath, time.Now())
+	}
+
+	return files, nil
+}
+
+// attachReviews recursively sets the review metadata of listed files,
+// flagging those overdue for review
+func attachReviews(files []DropboxFileInfo, byPath map[string]*models.DocumentMetadata, now time.Time) {
+	for i := range files {
+		if files[i].IsFolder {
+			attachReviews(files[i].Children, byPath, now)
+			continue
+		}
+		m, ok := byPath[strings.ToLower(files[i].Path)]
+		if !ok {
+			continue
+		}
+		m.ReviewState = m.ReviewStateAt(now)
+		files[i].Review = m
+		files[i].ReviewOverdue = m.ReviewState == models.ReviewStateOverdue
+	}
+}
+
+// GetFileDownloadLink generates a download link for a specific file
+func (s *LibraryCategoryService) GetFileDownloadLink(
+	ctx context.Context,
+	librarySlug string,
+	categoryID primitive.ObjectID,
+	filePath string,
+	user *models.User,
+) (string, error) {
+	// The path must stay inside the category folder, or a link to any file
+	// in the storage could be handed out and recorded as a download
+	filePath, err := models.CleanDocumentItemPath(filePath)
+	if err != nil {
+		return "", err
+	}
+
+	library, category, err := s.getCategory(ctx, librarySlug, categoryID, user)
+	if err != nil {
+		return "", err
+	}
+	if !library.CanDownload(user) {
+		return "", ErrUnauthorized
+	}
+
+	driver := s.storageService.ForLibrary(library)
+	if !driver.IsConfigured() {
+		return "", ErrStorageNotConfigured
+	}
+
+	fullPath := categoryFilePath(category.DropboxPath, filePath)
+
+	link, info, err := driver.TemporaryLink(ctx, storagePath(fullPath))
+	if err != nil {
+		if err == storage.ErrNotFound {
+			return "", errors.New("file not found")
+		}
+		return "", fmt.Errorf("failed to get download link: %w", err)
+	}
+
+	rev := ""
+	if info != nil {
+		rev = info.Rev
+	}
+	s.recordDownloads(ctx, models.DownloadSourceFile, user, library, category, map[string]string{filePath: rev})
+
+	return link, nil
+}
+
+// recordDownloads records a user downloading files of a category, given as
+// paths relative to the category folder mapped to the revision downloaded
+func (s *LibraryCategoryService) recordDownloads(
+	ctx context.Context,
+	source models.DownloadSource,
+	user *models.User,
+	library *models.Library,
+	category *models.LibraryCategory,
+	revs map[string]string,
+) {
+	now := time.Now()
+	downloads := make([]*models.DocumentDownload, 0, len(revs))
+	for filePath, rev := range revs {
+		downloads = append(downloads, models.NewDocumentDownload(user, library, category, filePath, rev, source, now))
+	}
+	s.downloads.Record(ctx, downloads...)
+}
+
+// CountCategories returns the number of categories in a library
+func (s *LibraryCategoryService) CountCategories(ctx context.Context, librarySlug string, activeOnly bool) (int64, error) {
+	library, err := s.libraryService.libraryRepo.FindBySlug(ctx, librarySlug)
+	if err != nil {
+		if err == repository.ErrLibraryNotFound {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to get library: %w", err)
+	}
+
+	filter := repository.LibraryCategoryFilter{LibraryID: &library.ID}
+	if activeOnly {
+		isActive := true
+		filter.IsActive = &isActive
+	}
+
+	count, err := s.categoryRepo.Count(ctx, filter)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count categories: %w", err)
+	}
+
+	return count, nil
+}
+
+// categoryFilePath joins a category's Dropbox folder with a file path
+// relative to it
+func categoryFilePath(categoryPath, filePath string) string {
+	// Decode URL-encoded DropboxPath if needed
+	dropboxPath := categoryPath
+	if decoded, err := url.PathUnescape(dropboxPath); err == nil {
+		dropboxPath = decoded
+	}
+
+	// Normalize paths
+	dropboxPath = strings.TrimPrefix(dropboxPath, "/")
+	dropboxPath = strings.TrimSuffix(dropboxPath, "/")
+	filePath = strings.TrimPrefix(filePath, "/")
+
+	// Join category path with file path (filePath should be relative to category folder)
+	fullPath := "/" + filepath.Join(dropboxPath, filePath)
+
+	// Normalize the path (remove double slashes, handle Windows separators)
+	fullPath = strings.ReplaceAll(fullPath, "\\", "/")
+	fullPath = strings.ReplaceAll(fullPath, "//", "/")
+
+	return fullPath
+}
+
+// makePathsRelative recursively converts full Dropbox paths to paths relative to category folder
+func makePathsRelative(files []DropboxFileInfo, categoryPath string) {
+	for i := range files {
+		fullPath := strings.TrimPrefix(files[i].Path, "/")
+		if strings.HasPrefix(fullPath, categoryPath+"/") {
+			// Path includes category, make it relative
+			files[i].Path = strings.TrimPrefix(fullPath, categoryPath+"/")
+		} else if fullPath == categoryPath {
+			// This is the category folder itself, use empty path
+			files[i].Path = ""
+		}
+
+		if files[i].IsFolder && len(files[i].Children) > 0 {
+			makePathsRelative(files[i].Children, categoryPath)
+		}
+	}
+}
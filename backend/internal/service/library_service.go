@@ -0,0 +1,345 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"backend/internal/models"
+	"backend/internal/repository"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+var (
+	ErrLibraryNotFound      = errors.New("library not found")
+	ErrDuplicateLibrarySlug = errors.New("a library with this slug already exists")
+	ErrLibraryNotEmpty      = errors.New("cannot delete library: it still has categories")
+	ErrBuiltinLibrary       = errors.New("built-in libraries cannot be deleted")
+)
+
+// builtinLibrary describes a library that existed before libraries could be
+// created at runtime, the collection its categories used to live in, and the
+// categories seeded into an empty library
+type builtinLibrary struct {
+	library          models.Library
+	legacyCollection string
+	seed             []models.CreateLibraryCategoryRequest
+}
+
+var builtinLibraries = []builtinLibrary{
+	{
+		library: models.Library{
+			Name:               "Standard Operating Procedures",
+			Slug:               models.LibrarySlugSOPs,
+			DropboxRoot:        "SOPS",
+			ViewPermission:     models.PermViewSOPs,
+			DownloadPermission: models.PermDownloadSOPs,
+			ManagePermission:   models.PermDeleteUsers,
+			DisplayOrder:       1,
+		},
+		legacyCollection: "sop_categories",
+		seed: []models.CreateLibraryCategoryRequest{
+			{Name: "Anemia", Description: "Standard operating procedures for anemia diagnosis and treatment", DisplayOrder: 1},
+			{Name: "Lymphoma", Description: "Standard operating procedures for lymphoma management", DisplayOrder: 2},
+			{Name: "Myeloma", Description: "Standard operating procedures for multiple myeloma treatment", DisplayOrder: 3},
+			{Name: "General Business", Description: "General business procedures and administrative guidelines", DisplayOrder: 4},
+		},
+	},
+	{
+		library: models.Library{
+			Name:             "Working Parties",
+			Slug:             models.LibrarySlugWorkingParties,
+			DropboxRoot:      "WORKING_PARTIES",
+			ManagePermission: models.PermDeleteUsers,
+			DisplayOrder:     2,
+		},
+		legacyCollection: "working_party_categories",
+	},
+}
+
+// findBuiltinLibrary returns the built-in definition for a slug, if any
+func findBuiltinLibrary(slug string) (builtinLibrary, bool) {
+	for _, b := range builtinLibraries {
+		if b.library.Slug == slug {
+			return b, true
+		}
+	}
+	return builtinLibrary{}, false
+}
+
+// LibraryService handles business logic for document libraries
+type LibraryService struct {
+	libraryRepo    *repository.LibraryRepository
+	categoryRepo   *repository.LibraryCategoryRepository
+	storageService *StorageService
+	auditRepo      *repository.AuditRepository
+}
+
+// NewLibraryService creates a new LibraryService
+func NewLibraryService(
+	libraryRepo *repository.LibraryRepository,
+	categoryRepo *repository.LibraryCategoryRepository,
+	storageService *StorageService,
+	auditRepo *repository.AuditRepository,
+) *LibraryService {
+	return &LibraryService{
+		libraryRepo:    libraryRepo,
+		categoryRepo:   categoryRepo,
+		storageService: storageService,
+		auditRepo:      auditRepo,
+	}
+}
+
+// EnsureBuiltinLibraries creates the SOP and working party libraries on
+// first start and migrates their categories from the legacy collections.
+// Libraries that already exist are left untouched.
+func (s *LibraryService) EnsureBuiltinLibraries(ctx context.Context) error {
+	for _, b := range builtinLibraries {
+		_, err := s.libraryRepo.FindBySlug(ctx, b.library.Slug)
+		if err == nil {
+			continue
+		}
+		if err != repository.ErrLibraryNotFound {
+			return err
+		}
+
+		library := b.library
+		library.IsActive = true
+		library.Builtin = true
+		if err := s.libraryRepo.Create(ctx, &library); err != nil {
+			if err == repository.ErrDuplicateLibrarySlug {
+				// Created concurrently by another instance
+				continue
+			}
+			return fmt.Errorf("failed to create library %s: %w", library.Slug, err)
+		}
+
+		copied, err := s.categoryRepo.CopyLegacyCategories(ctx, b.legacyCollection, library.ID)
+		if err != nil {
+			// Remove the library so the migration runs again on next start
+			_ = s.libraryRepo.Delete(ctx, library.ID)
+			return fmt.Errorf("failed to migrate %s categories: %w", b.legacyCollection, err)
+		}
+		fmt.Printf("Created library '%s' and migrated %d categories from %s\n", library.Slug, copied, b.legacyCollection)
+	}
+	return nil
+}
+
+// ListLibraries lists the libraries a user can view
+func (s *LibraryService) ListLibraries(ctx context.Context, user *models.User) ([]*models.Library, error) {
+	libraries, err := s.libraryRepo.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list libraries: %w", err)
+	}
+
+	visible := make([]*models.Library, 0, len(libraries))
+	for _, library := range libraries {
+		if library.CanView(user) {
+			visible = append(visible, library)
+		}
+	}
+	return visible, nil
+}
+
+// GetLibrary retrieves a library by slug. Inactive libraries are hidden from
+// users who cannot manage them; users lacking the view permission are refused.
+func (s *LibraryService) GetLibrary(ctx context.Context, slug string, user *models.User) (*models.Library, error) {
+	library, err := s.libraryRepo.FindBySlug(ctx, slug)
+	if err != nil {
+		if err == repository.ErrLibraryNotFound {
+			return nil, ErrLibraryNotFound
+		}
+		return nil, fmt.Errorf("failed to get library: %w", err)
+	}
+
+	if !library.CanView(user) {
+		if !library.IsActive {
+			return nil, ErrLibraryNotFound
+		}
+		return nil, ErrUnauthorized
+	}
+
+	return library, nil
+}
+
+// CreateLibrary creates a new library and its root folder in the library's
+// storage
+func (s *LibraryService) CreateLibrary(
+	ctx context.Context,
+	req *models.CreateLibraryRequest,
+	createdBy *models.User,
+	ipAddress string,
+) (*models.Library, error) {
+	if !createdBy.HasPermission(models.PermManageSystem) {
+		return nil, ErrUnauthorized
+	}
+
+	connectionID, err := s.storageService.CheckConnection(ctx, req.DropboxConnectionID)
+	if err != nil {
+		return nil, err
+	}
+	req.DropboxConnectionID = connectionID
+
+	library := req.Library()
+	library.CreatedBy = &createdBy.ID
+	if err := library.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.storageService.CheckDriver(library.StorageDriver); err != nil {
+		return nil, err
+	}
+
+	if err := s.libraryRepo.Create(ctx, library); err != nil {
+		if err == repository.ErrDuplicateLibrarySlug {
+			return nil, ErrDuplicateLibrarySlug
+		}
+		return nil, fmt.Errorf("failed to create library: %w", err)
+	}
+
+	if driver := s.storageService.ForLibrary(library); driver.IsConfigured() {
+		if err := driver.CreateFolder(ctx, storagePath(library.DropboxRoot)); err != nil {
+			fmt.Printf("ERROR: Failed to create %s folder for library '%s' at path '%s': %v\n", models.StorageDriverName(library.StorageDriver), library.Slug, library.DropboxRoot, err)
+		}
+	}
+
+	s.auditRepo.Create(ctx, &models.AuditLog{
+		UserID:      &createdBy.ID,
+		PerformedBy: &createdBy.ID,
+		Action:      models.AuditActionLibraryCreated,
+		Details: bson.M{
+			"library_id":   library.ID.Hex(),
+			"library_name": library.Name,
+			"slug":         library.Slug,
+			"dropbox_root": library.DropboxRoot,
+			"storage":      models.StorageDriverName(library.StorageDriver),
+			"connection":   library.DropboxConnectionID,
+		},
+		IPAddress: ipAddress,
+	})
+
+	return library, nil
+}
+
+// UpdateLibrary updates a library's name, description, permissions and status
+func (s *LibraryService) UpdateLibrary(
+	ctx context.Context,
+	slug string,
+	req *models.UpdateLibraryRequest,
+	updatedBy *models.User,
+	ipAddress string,
+) (*models.Library, error) {
+	if !updatedBy.HasPermission(models.PermManageSystem) {
+		return nil, ErrUnauthorized
+	}
+
+	library, err := s.GetLibrary(ctx, slug, updatedBy)
+	if err != nil {
+		return nil, err
+	}
+	if req.DropboxConnectionID != nil {
+		connectionID, err := s.storageService.CheckConnection(ctx, *req.DropboxConnectionID)
+		if err != nil {
+			return nil, err
+		}
+		req.DropboxConnectionID = &connectionID
+	}
+
+	updated, changes := req.Apply(*library)
+	if len(changes) == 0 {
+		return library, nil
+	}
+	if err := updated.Validate(); err != nil {
+		return nil, err
+	}
+	_, driverChanged := changes["storage_driver"]
+	_, connectionChanged := changes["dropbox_connection_id"]
+	if driverChanged || (connectionChanged && updated.UsesDropbox()) {
+		if err := s.changeStorage(ctx, updated); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.libraryRepo.Update(ctx, library.ID, bson.M(changes)); err != nil {
+		return nil, fmt.Errorf("failed to update library: %w", err)
+	}
+
+	s.auditRepo.Create(ctx, &models.AuditLog{
+		UserID:      &updatedBy.ID,
+		PerformedBy: &updatedBy.ID,
+		Action:      models.AuditActionLibraryUpdated,
+		Details: bson.M{
+			"library_id":   library.ID.Hex(),
+			"library_name": updated.Name,
+			"changes":      changes,
+		},
+		IPAddress: ipAddress,
+	})
+
+	return s.libraryRepo.FindByID(ctx, library.ID)
+}
+
+// changeStorage checks that a library can move to another storage driver
+// or Dropbox connection, which is only allowed while it has no categories,
+// and creates its root folder there
+func (s *LibraryService) changeStorage(ctx context.Context, library *models.Library) error {
+	if err := s.storageService.CheckDriver(library.StorageDriver); err != nil {
+		return err
+	}
+	count, err := s.categoryRepo.Count(ctx, repository.LibraryCategoryFilter{LibraryID: &library.ID})
+	if err != nil {
+		return fmt.Errorf("failed to count categories: %w", err)
+	}
+	if count > 0 {
+		return ErrLibraryHasCategories
+	}
+
+	if driver := s.storageService.ForLibrary(library); driver.IsConfigured() {
+		if err := driver.CreateFolder(ctx, storagePath(library.DropboxRoot)); err != nil {
+			return fmt.Errorf("failed to create library folder: %w", err)
+		}
+	}
+	return nil
+}
+
+// DeleteLibrary deletes an empty, non built-in library. Its Dropbox folder remains.
+func (s *LibraryService) DeleteLibrary(ctx context.Context, slug string, deletedBy *models.User, ipAddress string) error {
+	if !deletedBy.HasPermission(models.PermManageSystem) {
+		return ErrUnauthorized
+	}
+
+	library, err := s.GetLibrary(ctx, slug, deletedBy)
+	if err != nil {
+		return err
+	}
+	if library.Builtin {
+		return ErrBuiltinLibrary
+	}
+
+	count, err := s.categoryRepo.Count(ctx, repository.LibraryCategoryFilter{LibraryID: &library.ID})
+	if err != nil {
+		return fmt.Errorf("failed to count categories: %w", err)
+	}
+	if count > 0 {
+		return ErrLibraryNotEmpty
+	}
+
+	if err := s.libraryRepo.Delete(ctx, library.ID); err != nil {
+		return fmt.Errorf("failed to delete library: %w", err)
+	}
+
+	s.auditRepo.Create(ctx, &models.AuditLog{
+		UserID:      &deletedBy.ID,
+		PerformedBy: &deletedBy.ID,
+		Action:      models.AuditActionLibraryDeleted,
+		Details: bson.M{
+			"library_id":   library.ID.Hex(),
+			"library_name": library.Name,
+			"slug":         library.Slug,
+			"dropbox_root": library.DropboxRoot,
+		},
+		IPAddress: ipAddress,
+	})
+
+	return nil
+}
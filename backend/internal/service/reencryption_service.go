@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"backend/internal/fieldcrypt"
+	"backend/internal/repository"
+)
+
+// ReencryptionResult reports what re-encryption did to one encrypted field
+type ReencryptionResult struct {
+	Field       string   `json:"field"`
+	Checked     int      `json:"checked"`
+	Reencrypted int      `json:"reencrypted"`
+	Changed     int      `json:"changed"` // Written by the app in the meantime and left alone
+	Failed      int      `json:"failed"`
+	Errors      []string `json:"errors,omitempty"`
+}
+
+// ReencryptionService re-encrypts stored values with the primary key after
+// a key rotation, so older keys can be dropped from the keyring. It also
+// encrypts personal data stored before it was encrypted, and rebuilds
+// blind indexes.
+type ReencryptionService struct {
+	encryptedFieldRepo *repository.EncryptedFieldRepository
+	encryptionService  *EncryptionService
+}
+
+// NewReencryptionService creates a new ReencryptionService
+func NewReencryptionService(encryptedFieldRepo *repository.EncryptedFieldRepository, encryptionService *EncryptionService) *ReencryptionService {
+	return &ReencryptionService{
+		encryptedFieldRepo: encryptedFieldRepo,
+		encryptionService:  encryptionService,
+	}
+}
+
+// Run re-encrypts every value of every encrypted field that was not
+// encrypted with the primary key, encrypts plaintext personal data and
+// rebuilds stale blind indexes. With dryRun it only counts them. Values that
+// cannot be decrypted are reported and left alone.
+func (s *ReencryptionService) Run(ctx context.Context, dryRun bool) ([]ReencryptionResult, error) {
+	results := []ReencryptionResult{}
+	for _, field := range repository.EncryptedFields {
+		result, err := s.reencryptField(ctx, field, dryRun)
+		if err != nil {
+			return results, fmt.Errorf("failed to re-encrypt %s: %w", field, err)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// EncryptPersonalData encrypts personal data stored in plaintext, such as
+// profiles saved before their fields were encrypted, and sets their blind
+// indexes. It runs at startup; form data is encrypted by the registry.
+func (s *ReencryptionService) EncryptPersonalData(ctx context.Context) ([]ReencryptionResult, error) {
+	results := []ReencryptionResult{}
+	for _, field := range repository.EncryptedFields {
+		if !field.Personal || field.Map {
+			continue
+		}
+		result, err := s.reencryptField(ctx, field, false)
+		if err != nil {
+			return results, fmt.Errorf("failed to encrypt %s: %w", field, err)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func (s *ReencryptionService) reencryptField(ctx context.Context, field repository.EncryptedField, dryRun bool) (ReencryptionResult, error) {
+	result := ReencryptionResult{Field: field.String()}
+
+	values, err := s.encryptedFieldRepo.List(ctx, field)
+	if err != nil {
+		return result, err
+	}
+
+	for _, value := range values {
+		result.Checked++
+		ciphertext, index, err := s.reencrypt(field, value)
+		if err != nil {
+			result.Failed++
+			result.Errors = append(result.Errors, fmt.Sprintf("%v: %v", value.DocumentID, err))
+			continue
+		}
+		if ciphertext == value.Ciphertext && index == value.Index {
+			continue
+		}
+		if dryRun {
+			result.Reencrypted++
+			continue
+		}
+
+		replaced, err := s.encryptedFieldRepo.Replace(ctx, field, value, ciphertext, index)
+		if err != nil {
+			return result, err
+		}
+		if replaced {
+			result.Reencrypted++
+		} else {
+			result.Changed++
+		}
+	}
+	return result, nil
+}
+
+// reencrypt returns the value as it should be stored, with its blind index
+func (s *ReencryptionService) reencrypt(field repository.EncryptedField, value repository.EncryptedValue) (string, string, error) {
+	ciphertext := value.Ciphertext
+	switch {
+	case field.Personal && !fieldcrypt.IsEncrypted(ciphertext):
+		// Map entries are only encrypted for sensitive form fields, which
+		// the registry encrypts when a field is marked sensitive
+		if field.Map {
+			return ciphertext, value.Index, nil
+		}
+		encrypted, err := fieldcrypt.EncryptString(s.encryptionService, ciphertext)
+		if err != nil {
+			return "", "", err
+		}
+		ciphertext = encrypted
+	case s.encryptionService.NeedsReencryption(ciphertext):
+		rotated, err := s.encryptionService.Reencrypt(ciphertext)
+		if err != nil {
+			return "", "", err
+		}
+		ciphertext = rotated
+	}
+
+	if field.Index == "" {
+		return ciphertext, value.Index, nil
+	}
+	plaintext, err := fieldcrypt.DecryptString(s.encryptionService, ciphertext)
+	if err != nil {
+		return "", "", err
+	}
+	return ciphertext, fieldcrypt.Index(s.encryptionService, plaintext), nil
+}
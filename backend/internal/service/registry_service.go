@@ -4,14 +4,19 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"mime/multipart"
 	"net/url"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
 	"time"
 
 	"backend/internal/models"
+	"backend/internal/pagination"
 	"backend/internal/repository"
+	"backend/internal/storage"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -33,7 +38,8 @@ type RegistryService struct {
 	userRepo        *repository.UserRepository
 	institutionRepo *repository.InstitutionRepository
 	auditRepo       *repository.AuditRepository
-	dropboxService  *DropboxService
+	storageService  *StorageService
+	uploadService   *ResumableUploadService
 	emailService    *EmailService
 }
 
@@ -45,7 +51,8 @@ func NewRegistryService(
 	userRepo *repository.UserRepository,
 	institutionRepo *repository.InstitutionRepository,
 	auditRepo *repository.AuditRepository,
-	dropboxService *DropboxService,
+	storageService *StorageService,
+	uploadService *ResumableUploadService,
 	emailService *EmailService,
 ) *RegistryService {
 	return &RegistryService{
@@ -55,11 +62,31 @@ func NewRegistryService(
 		userRepo:        userRepo,
 		institutionRepo: institutionRepo,
 		auditRepo:       auditRepo,
-		dropboxService:  dropboxService,
+		storageService:  storageService,
+		uploadService:   uploadService,
 		emailService:    emailService,
 	}
 }
 
+// submissionFile is a document attached to a submission, either sent with
+// the form or uploaded beforehand with a resumable upload
+type submissionFile struct {
+	name string
+	open func() (io.ReadCloser, error)
+}
+
+// formFiles wraps the documents sent with a form
+func formFiles(headers []*multipart.FileHeader) []submissionFile {
+	files := make([]submissionFile, 0, len(headers))
+	for _, header := range headers {
+		files = append(files, submissionFile{
+			name: header.Filename,
+			open: func() (io.ReadCloser, error) { return header.Open() },
+		})
+	}
+	return files
+}
+
 // Configuration Management
 
 // GetConfiguration retrieves the registry configuration
@@ -110,14 +137,36 @@ func (s *RegistryService) UpdateConfiguration(
 	if req.VideoURL != nil {
 		config.VideoURL = *req.VideoURL
 	}
-	if req.DocumentsPath != nil {
-		newPath := *req.DocumentsPath
-		// Check if the path changed and if Dropbox is configured
-		if newPath != config.DocumentsPath && newPath != "" && s.dropboxService.IsConfigured() {
-			// Create the folder in Dropbox if it doesn't exist
+	driverChanged := false
+	if req.StorageDriver != nil && models.StorageDriverName(*req.StorageDriver) != models.StorageDriverName(config.StorageDriver) {
+		if err := s.storageService.CheckDriver(*req.StorageDriver); err != nil {
+			return nil, err
+		}
+		config.StorageDriver = *req.StorageDriver
+		driverChanged = true
+	}
+	if req.DropboxConnectionID != nil {
+		connectionID, err := s.storageService.CheckConnection(ctx, *req.DropboxConnectionID)
+		if err != nil {
+			return nil, err
+		}
+		if ref := models.DropboxConnectionRef(connectionID); !models.SameDropboxConnection(ref, config.DropboxConnectionID) {
+			config.DropboxConnectionID = ref
+			driverChanged = driverChanged || models.StorageDriverName(config.StorageDriver) == models.StorageDriverDropbox
+		}
+	}
+	if req.DocumentsPath != nil || driverChanged {
+		newPath := config.DocumentsPath
+		if req.DocumentsPath != nil {
+			newPath = *req.DocumentsPath
+		}
+		// Check if the path or storage changed and if the storage is configured
+		driver := s.storageService.ForRegistry(config)
+		if (newPath != config.DocumentsPath || driverChanged) && newPath != "" && driver.IsConfigured() {
+			// Create the folder if it doesn't exist
 			// CreateFolder is idempotent - it returns nil if folder already exists
-			if err := s.dropboxService.CreateFolder(newPath); err != nil {
-				return nil, fmt.Errorf("failed to create example documents folder in Dropbox: %w", err)
+			if err := driver.CreateFolder(ctx, storagePath(newPath)); err != nil {
+				return nil, fmt.Errorf("failed to create example documents folder: %w", err)
 			}
 		}
 		config.DocumentsPath = newPath
@@ -456,6 +505,7 @@ func (s *RegistryService) UpdateFormSchema(
 	if err != nil {
 		return nil, err
 	}
+	s.encryptSensitiveFields(ctx, schema)
 
 	// Audit log
 	s.auditRepo.Create(ctx, &models.AuditLog{
@@ -473,6 +523,32 @@ func (s *RegistryService) UpdateFormSchema(
 	return schema, nil
 }
 
+// EncryptSensitiveSubmissions encrypts the values of sensitive fields in
+// submissions stored before the fields were marked sensitive. It runs at
+// startup, so that values left in plaintext when encryption was interrupted
+// are encrypted too.
+func (s *RegistryService) EncryptSensitiveSubmissions(ctx context.Context) error {
+	schemas, err := s.formRepo.FindWithSensitiveFields(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to find form schemas: %w", err)
+	}
+	for _, schema := range schemas {
+		if _, err := s.submissionRepo.EncryptFormFields(ctx, schema.ID, schema.SensitiveFieldIDs()); err != nil {
+			return fmt.Errorf("failed to encrypt submissions of form %s: %w", schema.ID.Hex(), err)
+		}
+	}
+	return nil
+}
+
+// encryptSensitiveFields encrypts the values of a schema's sensitive fields
+// in its existing submissions. A failure is logged; the values are encrypted
+// at the next startup.
+func (s *RegistryService) encryptSensitiveFields(ctx context.Context, schema *models.RegistryFormSchema) {
+	if _, err := s.submissionRepo.EncryptFormFields(ctx, schema.ID, schema.SensitiveFieldIDs()); err != nil {
+		fmt.Printf("Warning: Failed to encrypt sensitive fields of form %s: %v\n", schema.ID.Hex(), err)
+	}
+}
+
 // GetFormSchema retrieves a form schema by ID
 func (s *RegistryService) GetFormSchema(ctx context.Context, id primitive.ObjectID) (*models.RegistryFormSchema, error) {
 	return s.formRepo.FindByID(ctx, id)
@@ -483,9 +559,9 @@ func (s *RegistryService) GetActiveFormSchema(ctx context.Context) (*models.Regi
 	return s.formRepo.FindActive(ctx)
 }
 
-// ListFormSchemas retrieves all form schemas with pagination
-func (s *RegistryService) ListFormSchemas(ctx context.Context, page, limit int) ([]*models.RegistryFormSchema, int64, error) {
-	return s.formRepo.List(ctx, page, limit)
+// ListFormSchemas retrieves one page of form schemas
+func (s *RegistryService) ListFormSchemas(ctx context.Context, req pagination.Request) (*pagination.Page[*models.RegistryFormSchema], int64, error) {
+	return s.formRepo.List(ctx, req)
 }
 
 // DeleteFormSchema deletes a form schema
@@ -556,6 +632,11 @@ func (s *RegistryService) ValidatePreSubmission(ctx context.Context) error {
 		return errors.New("SMTP configuration is incomplete")
 	}
 
+	// Check the storage submitted documents are kept in
+	if !s.storageService.ForRegistry(config).IsConfigured() {
+		return fmt.Errorf("%s storage for submitted documents is not configured", models.StorageDriverName(config.StorageDriver))
+	}
+
 	return nil
 }
 
@@ -589,17 +670,37 @@ func (s *RegistryService) SubmitForm(
 		return nil, err
 	}
 
+	// Documents uploaded beforehand must be complete and the user's own
+	documents := formFiles(files)
+	if len(req.UploadIDs) > 0 {
+		uploaded, err := s.uploadService.completed(req.UploadIDs, user)
+		if err != nil {
+			return nil, err
+		}
+		documents = append(documents, uploaded...)
+	}
+
 	// Validate files based on schema requirements
-	if err := s.validateFiles(files, schema); err != nil {
+	if err := s.validateFiles(documents, schema); err != nil {
+		return nil, err
+	}
+
+	config, err := s.configRepo.GetConfig(ctx)
+	if err != nil {
 		return nil, err
 	}
+	driver := s.storageService.ForRegistry(config)
 
 	// Create submission record
 	submission := &models.RegistrySubmission{
-		UserID:       user.ID,
-		FormSchemaID: formSchemaID,
-		FormData:     req.FormData,
-		Status:       models.SubmissionStatusSubmitted,
+		UserID:              user.ID,
+		FormSchemaID:        formSchemaID,
+		InstitutionID:       user.Profile.InstitutionID,
+		FormData:            req.FormData,
+		SensitiveFields:     schema.SensitiveFieldIDs(),
+		StorageDriver:       config.StorageDriver,
+		DropboxConnectionID: config.DropboxConnectionID,
+		Status:              models.SubmissionStatusSubmitted,
 	}
 
 	// Create submission to get ID
@@ -607,11 +708,15 @@ func (s *RegistryService) SubmitForm(
 		return nil, err
 	}
 
-	// Build new Dropbox path: Submissions/{institution name}/{firstName lastName}/{formId}/{submissionId}
-	institutionName := "Unknown Institution"
+	// Build new Dropbox path: Submissions/{institution path}/{firstName lastName}/{formId}/{submissionId}
+	// Units are nested under their parent institutions, e.g. Submissions/Hospital/Haematology Unit/...
+	institutionPath := []string{"Unknown Institution"}
 	if user.Profile.InstitutionID != nil {
-		if inst, err := s.institutionRepo.FindByID(ctx, *user.Profile.InstitutionID); err == nil && inst != nil {
-			institutionName = inst.Name
+		if path, err := s.institutionRepo.FindPath(ctx, *user.Profile.InstitutionID); err == nil {
+			institutionPath = institutionPath[:0]
+			for _, inst := range path {
+				institutionPath = append(institutionPath, inst.Name)
+			}
 		}
 	}
 	userFullName := user.Profile.FirstName + " " + user.Profile.LastName
@@ -619,14 +724,17 @@ func (s *RegistryService) SubmitForm(
 	submissionID := submission.ID.Hex()
 
 	// URL-escape path segments to handle spaces/special characters
-	escInstitution := url.PathEscape(institutionName)
+	escInstitution := make([]string, 0, len(institutionPath))
+	for _, name := range institutionPath {
+		escInstitution = append(escInstitution, url.PathEscape(name))
+	}
 	escUser := url.PathEscape(userFullName)
 	escForm := url.PathEscape(formName)
-	dropboxPath := fmt.Sprintf("Submissions/%s/%s/%s/%s", escInstitution, escUser, escForm, submissionID)
+	dropboxPath := fmt.Sprintf("Submissions/%s/%s/%s/%s", strings.Join(escInstitution, "/"), escUser, escForm, submissionID)
 
-	// Ensure the folder exists in Dropbox
-	if err := s.dropboxService.CreateFolder(dropboxPath); err != nil {
-		return nil, fmt.Errorf("failed to create submission folder in Dropbox: %w", err)
+	// Ensure the folder exists in storage
+	if err := driver.CreateFolder(ctx, storagePath(dropboxPath)); err != nil {
+		return nil, fmt.Errorf("failed to create submission folder: %w", err)
 	}
 
 	submission.DocumentsPath = dropboxPath
@@ -635,15 +743,15 @@ func (s *RegistryService) SubmitForm(
 	// Track used filenames to handle duplicates
 	usedFilenames := make(map[string]bool)
 
-	for _, fileHeader := range files {
-		file, err := fileHeader.Open()
+	for _, document := range documents {
+		file, err := document.open()
 		if err != nil {
 			return nil, fmt.Errorf("failed to open file: %w", err)
 		}
 		defer file.Close()
 
 		// Generate a unique filename if duplicates exist
-		originalFilename := fileHeader.Filename
+		originalFilename := document.name
 		uniqueFilename := originalFilename
 
 		// If filename already used, append a counter
@@ -663,16 +771,17 @@ func (s *RegistryService) SubmitForm(
 		// Mark this filename as used
 		usedFilenames[uniqueFilename] = true
 
-		// Upload to Dropbox with unique filename
-		remotePath := filepath.Join(dropboxPath, uniqueFilename)
-		if err := s.dropboxService.UploadFile(ctx, file, remotePath); err != nil {
-			return nil, fmt.Errorf("failed to upload file to Dropbox: %w", err)
+		// Upload to storage with unique filename
+		remotePath := filepath.Join(storagePath(dropboxPath), uniqueFilename)
+		if _, err := driver.Upload(ctx, file, remotePath); err != nil {
+			return nil, fmt.Errorf("failed to upload file: %w", err)
 		}
 
 		uploadedFiles = append(uploadedFiles, uniqueFilename)
 	}
 
 	submission.UploadedDocuments = uploadedFiles
+	s.uploadService.remove(req.UploadIDs)
 
 	// Update submission with documents info
 	if err := s.submissionRepo.Update(ctx, submission.ID, bson.M{
@@ -722,7 +831,7 @@ func (s *RegistryService) validateFormData(data map[string]interface{}, schema *
 }
 
 // validateFiles validates uploaded files against the form schema
-func (s *RegistryService) validateFiles(files []*multipart.FileHeader, schema *models.RegistryFormSchema) error {
+func (s *RegistryService) validateFiles(files []submissionFile, schema *models.RegistryFormSchema) error {
 	// Check if any file fields are required
 	hasRequiredFileField := false
 	for _, field := range schema.Fields {
@@ -753,13 +862,17 @@ func (s *RegistryService) sendSubmissionNotification(
 		return err
 	}
 
-	// Get Dropbox shared link for the folder using the Dropbox API
-	dropboxLink, err := s.dropboxService.GetFolderShareLink(submission.DocumentsPath)
+	// Get a shared link for the folder from the submission's storage
+	storageName := models.StorageDriverName(submission.StorageDriver)
+	dropboxLink, err := s.storageService.ForSubmission(submission).ShareLink(ctx, storagePath(submission.DocumentsPath))
 	if err != nil {
-		// Log error but don't fail the email - use a fallback message
-		fmt.Printf("Warning: Failed to get Dropbox shared link for path %s: %v\n", submission.DocumentsPath, err)
+		// Log error but don't fail the email - use a fallback message.
+		// Local and S3 storage cannot share folders.
+		if err != storage.ErrUnsupported {
+			fmt.Printf("Warning: Failed to get %s shared link for path %s: %v\n", storageName, submission.DocumentsPath, err)
+		}
 		// Fallback: construct a basic path (though this won't be a working link)
-		dropboxLink = fmt.Sprintf("Dropbox path: %s (shared link unavailable)", submission.DocumentsPath)
+		dropboxLink = fmt.Sprintf("%s path: %s (shared link unavailable)", storageName, submission.DocumentsPath)
 	}
 
 	// Prepare email data
@@ -817,36 +930,105 @@ func (s *RegistryService) sendStatusChangeNotification(
 	)
 }
 
-// GetUserSubmissions retrieves submissions for a specific user
+// GetUserSubmissions retrieves one page of submissions for a specific user
 func (s *RegistryService) GetUserSubmissions(
 	ctx context.Context,
 	userID primitive.ObjectID,
-	page, limit int,
-) ([]*models.RegistrySubmission, int64, error) {
-	return s.submissionRepo.FindByUser(ctx, userID, page, limit)
+	req pagination.Request,
+) (*pagination.Page[*models.RegistrySubmission], int64, error) {
+	return s.submissionRepo.FindByUser(ctx, userID, req)
+}
+
+// GetSubmissionMap counts registry submissions per institution, with the
+// institution's coordinates where known, for mapping where submissions come from
+func (s *RegistryService) GetSubmissionMap(ctx context.Context, user *models.User) ([]*models.SubmissionLocation, error) {
+	if !user.HasPermission(models.PermManageUsers) {
+		return nil, ErrUnauthorizedRegistryAccess
+	}
+
+	counts, err := s.submissionRepo.CountGroupedByInstitution(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(counts))
+	for id := range counts {
+		ids = append(ids, id)
+	}
+	institutions, err := s.institutionRepo.FindByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	locations := make([]*models.SubmissionLocation, 0, len(institutions))
+	for _, inst := range institutions {
+		locations = append(locations, &models.SubmissionLocation{
+			InstitutionID: inst.ID,
+			Name:          inst.Name,
+			City:          inst.City,
+			Province:      inst.Province,
+			Location:      inst.Location,
+			Submissions:   counts[inst.ID],
+		})
+	}
+
+	sort.Slice(locations, func(i, j int) bool {
+		return locations[i].Submissions > locations[j].Submissions
+	})
+
+	return locations, nil
 }
 
-// GetAllSubmissions retrieves all submissions (admin only)
+// GetAllSubmissions retrieves one page of all submissions (admin only)
 func (s *RegistryService) GetAllSubmissions(
 	ctx context.Context,
 	user *models.User,
-	page, limit int,
+	req pagination.Request,
 	filter bson.M,
 	userSearch string,
-) ([]*models.RegistrySubmission, int64, error) {
+) (*pagination.Page[*models.RegistrySubmission], int64, error) {
 	// Check admin permission
 	if !user.HasPermission(models.PermManageUsers) {
 		return nil, 0, ErrUnauthorizedRegistryAccess
 	}
 
-	submissions, total, err := s.submissionRepo.List(ctx, page, limit, filter)
+	if filter == nil {
+		filter = bson.M{}
+	}
+
+	// Resolve the user search to user IDs up front so it is applied by the
+	// query itself and pagination stays consistent
+	if strings.TrimSpace(userSearch) != "" {
+		// Every word must match the first name, last name or email, so
+		// that a full name such as "John Smith" matches
+		terms := []bson.M{}
+		for _, term := range strings.Fields(userSearch) {
+			pattern := regexp.QuoteMeta(term)
+			terms = append(terms, bson.M{"$or": []bson.M{
+				{"profile.first_name": bson.M{"$regex": pattern, "$options": "i"}},
+				{"profile.last_name": bson.M{"$regex": pattern, "$options": "i"}},
+				{"email": bson.M{"$regex": pattern, "$options": "i"}},
+			}})
+		}
+		matchingUsers, err := s.userRepo.List(ctx, bson.M{"$and": terms}, 0, 0)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		userIDs := make([]primitive.ObjectID, 0, len(matchingUsers))
+		for _, u := range matchingUsers {
+			userIDs = append(userIDs, u.ID)
+		}
+		filter["user_id"] = bson.M{"$in": userIDs}
+	}
+
+	page, total, err := s.submissionRepo.List(ctx, filter, req)
 	if err != nil {
 		return nil, 0, err
 	}
 
 	// Populate user and form information for each submission
-	filteredSubmissions := []*models.RegistrySubmission{}
-	for _, submission := range submissions {
+	for _, submission := range page.Items {
 		// Get user information
 		submittedUser, err := s.userRepo.FindByID(ctx, submission.UserID)
 		if err == nil {
@@ -859,28 +1041,9 @@ func (s *RegistryService) GetAllSubmissions(
 		if err == nil {
 			submission.FormName = formSchema.FormName
 		}
-
-		// Apply user search filter if provided
-		if userSearch != "" {
-			searchLower := strings.ToLower(userSearch)
-			userNameLower := strings.ToLower(submission.UserName)
-			userEmailLower := strings.ToLower(submission.UserEmail)
-
-			if strings.Contains(userNameLower, searchLower) || strings.Contains(userEmailLower, searchLower) {
-				filteredSubmissions = append(filteredSubmissions, submission)
-			}
-		} else {
-			filteredSubmissions = append(filteredSubmissions, submission)
-		}
 	}
 
-	// Update total if filtering was applied
-	filteredTotal := int64(len(filteredSubmissions))
-	if userSearch != "" {
-		return filteredSubmissions, filteredTotal, nil
-	}
-
-	return filteredSubmissions, total, nil
+	return page, total, nil
 }
 
 // GetSubmission retrieves a specific submission
@@ -965,35 +1128,49 @@ func (s *RegistryService) GetExampleDocuments(ctx context.Context) ([]DropboxFil
 		return nil, errors.New("example documents path not configured")
 	}
 
-	// Check if Dropbox is configured
-	if !s.dropboxService.IsConfigured() {
-		return nil, errors.New("dropbox is not configured")
+	// Check if the storage is configured
+	driver := s.storageService.ForRegistry(config)
+	if !driver.IsConfigured() {
+		return nil, ErrStorageNotConfigured
 	}
 
-	// List files recursively from Dropbox to get nested folder structure
-	files, err := s.dropboxService.ListFilesRecursive(config.DocumentsPath)
+	// List files recursively to get nested folder structure
+	objects, err := driver.List(ctx, storagePath(config.DocumentsPath), true)
 	if err != nil {
-		if err == ErrFolderNotFound {
+		if err == storage.ErrNotFound {
 			// Return empty list if folder doesn't exist yet
 			return []DropboxFileInfo{}, nil
 		}
 		return nil, fmt.Errorf("failed to list documents: %w", err)
 	}
 
-	return files, nil
+	return s.storageService.connections.Default().filterEmptyFolders(fileInfosFromObjects(objects)), nil
 }
 
 // GetExampleDocumentDownloadLink generates a temporary download link for an example document
 func (s *RegistryService) GetExampleDocumentDownloadLink(ctx context.Context, filePath string) (string, error) {
-	// Check if Dropbox is configured
-	if !s.dropboxService.IsConfigured() {
-		return "", errors.New("dropbox is not configured")
+	config, err := s.configRepo.GetConfig(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get configuration: %w", err)
+	}
+
+	// Check if the storage is configured
+	driver := s.storageService.ForRegistry(config)
+	if !driver.IsConfigured() {
+		return "", ErrStorageNotConfigured
+	}
+
+	// Only files in the example documents folder may be downloaded
+	filePath = storagePath(filePath)
+	documentsPath := strings.ToLower(storagePath(config.DocumentsPath))
+	if !strings.HasPrefix(strings.ToLower(filePath), documentsPath+"/") {
+		return "", errors.New("file not found")
 	}
 
-	// Get download link from Dropbox
-	link, err := s.dropboxService.GetFileDownloadLink(filePath)
+	// Get download link from storage
+	link, _, err := driver.TemporaryLink(ctx, filePath)
 	if err != nil {
-		if err == ErrFileNotFound {
+		if err == storage.ErrNotFound {
 			return "", errors.New("file not found")
 		}
 		return "", fmt.Errorf("failed to get download link: %w", err)
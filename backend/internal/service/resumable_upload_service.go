@@ -0,0 +1,169 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"backend/internal/models"
+	"backend/internal/resumable"
+)
+
+// uploadCleanupInterval is how often expired resumable uploads are removed
+const uploadCleanupInterval = time.Hour
+
+var (
+	ErrUploadsUnavailable = errors.New("resumable uploads are not available")
+	ErrUploadNotFound     = resumable.ErrNotFound
+	ErrUploadOffset       = resumable.ErrOffsetMismatch
+	ErrUploadTooLarge     = resumable.ErrTooLarge
+	ErrUploadIncomplete   = resumable.ErrIncomplete
+	ErrUploadBusy         = resumable.ErrBusy
+	ErrTooManyUploads     = resumable.ErrTooManyUploads
+	ErrUploadQuota        = resumable.ErrQuotaExceeded
+)
+
+// ResumableUploadService lets users upload large registry documents in
+// chunks ahead of submitting a form, resuming from the last chunk received
+// after a dropped connection. Uploads belong to the user who started them
+// and are removed once attached to a submission, or by a background job
+// when they expire.
+type ResumableUploadService struct {
+	store     *resumable.Store
+	ticker    *time.Ticker
+	done      chan bool
+	isRunning bool
+}
+
+// NewResumableUploadService creates a new ResumableUploadService. Uploads
+// are unavailable if the upload folder cannot be created.
+func NewResumableUploadService() *ResumableUploadService {
+	store, err := resumable.FromEnv()
+	if err != nil {
+		fmt.Printf("Warning: resumable uploads are disabled: %v\n", err)
+	}
+	return &ResumableUploadService{
+		store: store,
+		done:  make(chan bool),
+	}
+}
+
+// Start begins removing expired uploads in the background
+func (s *ResumableUploadService) Start() {
+	if s.isRunning || s.store == nil {
+		return
+	}
+
+	s.ticker = time.NewTicker(uploadCleanupInterval)
+	s.isRunning = true
+
+	go func() {
+		s.cleanup()
+		for {
+			select {
+			case <-s.ticker.C:
+				s.cleanup()
+			case <-s.done:
+				fmt.Println("Resumable upload cleanup stopped")
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the background cleanup
+func (s *ResumableUploadService) Stop() {
+	if !s.isRunning {
+		return
+	}
+
+	if s.ticker != nil {
+		s.ticker.Stop()
+	}
+	s.done <- true
+	s.isRunning = false
+}
+
+func (s *ResumableUploadService) cleanup() {
+	removed, err := s.store.Cleanup()
+	if err != nil {
+		fmt.Printf("Warning: failed to remove expired uploads: %v\n", err)
+		return
+	}
+	if removed > 0 {
+		fmt.Printf("Removed %d expired upload(s)\n", removed)
+	}
+}
+
+// Create starts an upload, within the limits on the user's uploads in
+// progress
+func (s *ResumableUploadService) Create(req *models.CreateUploadRequest, user *models.User) (*resumable.Upload, error) {
+	if s.store == nil {
+		return nil, ErrUploadsUnavailable
+	}
+	return s.store.Create(user.ID.Hex(), req.FileName, req.Size)
+}
+
+// Get returns one of the user's uploads with the offset to resume from
+func (s *ResumableUploadService) Get(id string, user *models.User) (*resumable.Upload, error) {
+	if s.store == nil {
+		return nil, ErrUploadsUnavailable
+	}
+	upload, err := s.store.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if upload.OwnerID != user.ID.Hex() {
+		return nil, ErrUploadNotFound
+	}
+	return upload, nil
+}
+
+// Append writes a chunk at offset. When the chunk is cut short the upload
+// is returned with the offset reached, along with the error.
+func (s *ResumableUploadService) Append(id string, offset int64, chunk io.Reader, user *models.User) (*resumable.Upload, error) {
+	if _, err := s.Get(id, user); err != nil {
+		return nil, err
+	}
+	return s.store.Append(id, offset, chunk)
+}
+
+// Cancel removes one of the user's uploads
+func (s *ResumableUploadService) Cancel(id string, user *models.User) error {
+	if _, err := s.Get(id, user); err != nil {
+		return err
+	}
+	return s.store.Delete(id)
+}
+
+// completed returns the user's complete uploads as submission documents
+func (s *ResumableUploadService) completed(ids []string, user *models.User) ([]submissionFile, error) {
+	files := make([]submissionFile, 0, len(ids))
+	for _, id := range ids {
+		upload, err := s.Get(id, user)
+		if err != nil {
+			return nil, err
+		}
+		if !upload.Complete() {
+			return nil, fmt.Errorf("%w: %s", ErrUploadIncomplete, upload.FileName)
+		}
+		files = append(files, submissionFile{
+			name: upload.FileName,
+			open: func() (io.ReadCloser, error) {
+				file, _, err := s.store.Open(id)
+				return file, err
+			},
+		})
+	}
+	return files, nil
+}
+
+// remove deletes uploads that have been attached to a submission
+func (s *ResumableUploadService) remove(ids []string) {
+	for _, id := range ids {
+		if err := s.store.Delete(id); err != nil && err != resumable.ErrNotFound {
+			fmt.Printf("Warning: failed to remove upload %s: %v\n", id, err)
+		}
+	}
+}
@@ -0,0 +1,374 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"backend/internal/models"
+	"backend/internal/repository"
+	"backend/internal/textextract"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrIndexInProgress = errors.New("a search index run is already in progress")
+)
+
+const (
+	// SearchIndexIntervalEnv overrides how often documents are re-indexed,
+	// as a Go duration such as "30m"
+	SearchIndexIntervalEnv = "SEARCH_INDEX_INTERVAL"
+
+	defaultSearchIndexInterval = time.Hour
+
+	// maxIndexedFileSize skips files too large to download for indexing
+	maxIndexedFileSize = 25 << 20
+)
+
+// SearchIndexRun summarises one pass of the search indexer
+type SearchIndexRun struct {
+	StartedAt  time.Time `json:"startedAt"`
+	FinishedAt time.Time `json:"finishedAt"`
+	Categories int       `json:"categories"`
+	Indexed    int       `json:"indexed"`
+	Unchanged  int       `json:"unchanged"`
+	Failed     int       `json:"failed"`
+	Removed    int64     `json:"removed"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// SearchIndexStatus reports the indexer's state for administrators
+type SearchIndexStatus struct {
+	Running   bool            `json:"running"`
+	Interval  string          `json:"interval"`
+	Documents int64           `json:"documents"`
+	LastRun   *SearchIndexRun `json:"lastRun,omitempty"`
+}
+
+// SearchIndexService periodically pulls the documents of every library
+// category from its library's Dropbox connection and stores their text for
+// search. Files whose size
+// and modification time are unchanged since the last pass are not
+// downloaded again.
+type SearchIndexService struct {
+	searchRepo     *repository.SearchRepository
+	libraryRepo    *repository.LibraryRepository
+	categoryRepo   *repository.LibraryCategoryRepository
+	connections    *DropboxConnectionService
+	listingService *DropboxListingService
+
+	interval  time.Duration
+	ticker    *time.Ticker
+	done      chan bool
+	isRunning bool
+
+	// runMutex allows one index pass at a time; mu guards lastRun, indexing
+	// and rerun
+	runMutex sync.Mutex
+	mu       sync.RWMutex
+	indexing bool
+	rerun    bool
+	lastRun  *SearchIndexRun
+}
+
+// NewSearchIndexService creates a new SearchIndexService
+func NewSearchIndexService(
+	searchRepo *repository.SearchRepository,
+	libraryRepo *repository.LibraryRepository,
+	categoryRepo *repository.LibraryCategoryRepository,
+	connections *DropboxConnectionService,
+	listingService *DropboxListingService,
+) *SearchIndexService {
+	interval := defaultSearchIndexInterval
+	if value := os.Getenv(SearchIndexIntervalEnv); value != "" {
+		if parsed, err := time.ParseDuration(value); err == nil && parsed > 0 {
+			interval = parsed
+		} else {
+			fmt.Printf("Warning: invalid %s %q, using %s\n", SearchIndexIntervalEnv, value, interval)
+		}
+	}
+
+	return &SearchIndexService{
+		searchRepo:     searchRepo,
+		libraryRepo:    libraryRepo,
+		categoryRepo:   categoryRepo,
+		connections:    connections,
+		listingService: listingService,
+		interval:       interval,
+		done:           make(chan bool),
+	}
+}
+
+// Start begins indexing in the background, once on startup and then every interval
+func (s *SearchIndexService) Start() {
+	if s.isRunning {
+		fmt.Println("Search index service is already running")
+		return
+	}
+
+	s.ticker = time.NewTicker(s.interval)
+	s.isRunning = true
+
+	fmt.Printf("Starting search index service (every %s)\n", s.interval)
+
+	go func() {
+		s.indexInBackground()
+
+		for {
+			select {
+			case <-s.ticker.C:
+				s.indexInBackground()
+			case <-s.done:
+				fmt.Println("Search index service stopped")
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the background indexer
+func (s *SearchIndexService) Stop() {
+	if !s.isRunning {
+		return
+	}
+
+	if s.ticker != nil {
+		s.ticker.Stop()
+	}
+	s.done <- true
+	s.isRunning = false
+	fmt.Println("Stopping search index service")
+}
+
+// Status reports whether a pass is running, the index size and the last pass
+func (s *SearchIndexService) Status(ctx context.Context) (*SearchIndexStatus, error) {
+	documents, err := s.searchRepo.Count(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count indexed documents: %w", err)
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return &SearchIndexStatus{
+		Running:   s.indexing,
+		Interval:  s.interval.String(),
+		Documents: documents,
+		LastRun:   s.lastRun,
+	}, nil
+}
+
+// TriggerReindex starts an index pass in the background
+func (s *SearchIndexService) TriggerReindex() error {
+	if !s.connections.AnyConfigured() {
+		return ErrDropboxNotConfigured
+	}
+
+	s.mu.RLock()
+	indexing := s.indexing
+	s.mu.RUnlock()
+	if indexing {
+		return ErrIndexInProgress
+	}
+
+	go s.indexInBackground()
+	return nil
+}
+
+// HandleDocumentEvents re-indexes when documents change in Dropbox. Changes
+// that arrive during a pass are picked up by one more pass once it finishes.
+func (s *SearchIndexService) HandleDocumentEvents(events []models.DocumentEvent) {
+	s.mu.Lock()
+	if s.indexing {
+		s.rerun = true
+		s.mu.Unlock()
+		return
+	}
+	s.mu.Unlock()
+
+	go s.indexInBackground()
+}
+
+func (s *SearchIndexService) indexInBackground() {
+	if !s.connections.AnyConfigured() {
+		fmt.Println("Dropbox not configured, skipping search indexing")
+		return
+	}
+
+	run, err := s.Reindex(context.Background())
+	if err == ErrIndexInProgress {
+		return
+	}
+	if err != nil {
+		fmt.Printf("Search indexing failed: %v\n", err)
+		return
+	}
+	fmt.Printf("Search indexing finished: %d indexed, %d unchanged, %d failed, %d removed\n",
+		run.Indexed, run.Unchanged, run.Failed, run.Removed)
+
+	s.mu.Lock()
+	rerun := s.rerun
+	s.rerun = false
+	s.mu.Unlock()
+	if rerun {
+		s.indexInBackground()
+	}
+}
+
+// Reindex runs one index pass over every category of every library
+func (s *SearchIndexService) Reindex(ctx context.Context) (*SearchIndexRun, error) {
+	if !s.runMutex.TryLock() {
+		return nil, ErrIndexInProgress
+	}
+	defer s.runMutex.Unlock()
+
+	s.mu.Lock()
+	s.indexing = true
+	s.mu.Unlock()
+
+	run := &SearchIndexRun{StartedAt: time.Now()}
+	defer func() {
+		run.FinishedAt = time.Now()
+		s.mu.Lock()
+		s.indexing = false
+		s.lastRun = run
+		s.mu.Unlock()
+	}()
+
+	err := s.indexLibraries(ctx, run)
+	if err != nil {
+		run.Error = err.Error()
+	}
+	return run, err
+}
+
+func (s *SearchIndexService) indexLibraries(ctx context.Context, run *SearchIndexRun) error {
+	libraries, err := s.libraryRepo.List(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list libraries: %w", err)
+	}
+
+	categoryIDs := []primitive.ObjectID{}
+	for _, library := range libraries {
+		// File content is only read from Dropbox
+		if !library.UsesDropbox() {
+			continue
+		}
+		categories, err := s.categoryRepo.FindAll(ctx, repository.LibraryCategoryFilter{LibraryID: &library.ID})
+		if err != nil {
+			return fmt.Errorf("failed to list categories of %s: %w", library.Slug, err)
+		}
+
+		// The documents of a library whose connection is not authorized are
+		// kept until it is
+		dropbox := s.connections.ForLibrary(library)
+		for _, category := range categories {
+			categoryIDs = append(categoryIDs, category.ID)
+			if !dropbox.IsConfigured() {
+				continue
+			}
+			run.Categories++
+			if err := s.indexCategory(ctx, dropbox, category, run); err != nil {
+				// Keep the category's existing documents and carry on
+				fmt.Printf("Warning: failed to index category '%s' in library '%s': %v\n", category.Name, library.Slug, err)
+			}
+		}
+	}
+
+	removed, err := s.searchRepo.DeleteOtherCategories(ctx, categoryIDs)
+	if err != nil {
+		return fmt.Errorf("failed to remove documents of deleted categories: %w", err)
+	}
+	run.Removed += removed
+	return nil
+}
+
+// indexCategory indexes the changed files of one category and removes the
+// documents of files that were deleted from its folder
+func (s *SearchIndexService) indexCategory(ctx context.Context, dropbox *DropboxService, category *models.LibraryCategory, run *SearchIndexRun) error {
+	listing, err := s.listingService.FreshListing(ctx, dropbox, category.DropboxPath)
+	if err != nil && err != ErrFolderNotFound {
+		return err
+	}
+
+	// Listings carry decoded paths while categories store URL-escaped ones
+	categoryPath := category.DropboxPath
+	if decoded, err := url.PathUnescape(categoryPath); err == nil {
+		categoryPath = decoded
+	}
+	makePathsRelative(listing, strings.Trim(categoryPath, "/"))
+
+	indexed, err := s.searchRepo.ListByCategory(ctx, category.ID)
+	if err != nil {
+		return err
+	}
+	existing := make(map[string]*models.SearchDocument, len(indexed))
+	for _, doc := range indexed {
+		existing[doc.Path] = doc
+	}
+
+	keep := []string{}
+	for _, file := range flattenFiles(listing) {
+		if !textextract.Supported(file.Name) || file.Size > maxIndexedFileSize {
+			continue
+		}
+		keep = append(keep, file.Path)
+
+		if doc, ok := existing[file.Path]; ok && doc.Size == int64(file.Size) && doc.ModifiedTime.Equal(file.ModifiedTime) {
+			run.Unchanged++
+			continue
+		}
+
+		doc := &models.SearchDocument{
+			LibraryID:    category.LibraryID,
+			CategoryID:   category.ID,
+			Path:         file.Path,
+			Name:         file.Name,
+			Size:         int64(file.Size),
+			ModifiedTime: file.ModifiedTime,
+		}
+
+		// Failed extractions are stored without text so the file name is
+		// still searchable and the file is not downloaded again until it changes
+		data, err := dropbox.DownloadFile(categoryFilePath(category.DropboxPath, file.Path), maxIndexedFileSize)
+		if err == nil {
+			doc.Content, err = textextract.Extract(file.Name, data)
+		}
+		if err != nil {
+			doc.ExtractError = err.Error()
+			run.Failed++
+		} else {
+			run.Indexed++
+		}
+
+		if err := s.searchRepo.Upsert(ctx, doc); err != nil {
+			return fmt.Errorf("failed to store %s: %w", file.Path, err)
+		}
+	}
+
+	removed, err := s.searchRepo.DeleteMissing(ctx, category.ID, keep)
+	if err != nil {
+		return err
+	}
+	run.Removed += removed
+	return nil
+}
+
+// flattenFiles returns the files of a listing tree, without folders
+func flattenFiles(entries []DropboxFileInfo) []DropboxFileInfo {
+	var out []DropboxFileInfo
+	for _, entry := range entries {
+		if entry.IsFolder {
+			out = append(out, flattenFiles(entry.Children)...)
+			continue
+		}
+		out = append(out, entry)
+	}
+	return out
+}
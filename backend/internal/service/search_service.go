@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"backend/internal/models"
+	"backend/internal/repository"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrInvalidSearchQuery = errors.New("search query must be between 2 and 200 characters")
+)
+
+const (
+	// DefaultSearchLimit and MaxSearchLimit bound the number of results
+	DefaultSearchLimit = 20
+	MaxSearchLimit     = 100
+
+	searchSnippetWidth = 200
+)
+
+// SearchService searches the indexed text of library documents, returning
+// only documents in libraries the user can download from
+type SearchService struct {
+	searchRepo   *repository.SearchRepository
+	libraryRepo  *repository.LibraryRepository
+	categoryRepo *repository.LibraryCategoryRepository
+}
+
+// NewSearchService creates a new SearchService
+func NewSearchService(
+	searchRepo *repository.SearchRepository,
+	libraryRepo *repository.LibraryRepository,
+	categoryRepo *repository.LibraryCategoryRepository,
+) *SearchService {
+	return &SearchService{
+		searchRepo:   searchRepo,
+		libraryRepo:  libraryRepo,
+		categoryRepo: categoryRepo,
+	}
+}
+
+// visibleCategory is a category a user may see, with its library
+type visibleCategory struct {
+	library  *models.Library
+	category *models.LibraryCategory
+}
+
+// visibleCategories returns the categories of the libraries the user can
+// access, optionally restricted to one library. canAccess decides which
+// libraries, such as (*models.Library).CanView. Inactive categories are only
+// visible to the library's managers, matching the category listings.
+func visibleCategories(
+	ctx context.Context,
+	libraryRepo *repository.LibraryRepository,
+	categoryRepo *repository.LibraryCategoryRepository,
+	user *models.User,
+	librarySlug string,
+	canAccess func(library *models.Library, user *models.User) bool,
+) (map[primitive.ObjectID]visibleCategory, error) {
+	libraries, err := libraryRepo.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list libraries: %w", err)
+	}
+
+	visible := map[primitive.ObjectID]visibleCategory{}
+	for _, library := range libraries {
+		if librarySlug != "" && library.Slug != librarySlug {
+			continue
+		}
+		if !canAccess(library, user) {
+			continue
+		}
+
+		filter := repository.LibraryCategoryFilter{LibraryID: &library.ID}
+		if !library.CanManage(user) {
+			isActive := true
+			filter.IsActive = &isActive
+		}
+		categories, err := categoryRepo.FindAll(ctx, filter)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list categories: %w", err)
+		}
+		for _, category := range categories {
+			visible[category.ID] = visibleCategory{library: library, category: category}
+		}
+	}
+	return visible, nil
+}
+
+// Search finds documents matching the query in the categories of libraries
+// the user can download from, since results show document text and paths
+func (s *SearchService) Search(ctx context.Context, user *models.User, query, librarySlug string, limit int) ([]models.SearchResult, error) {
+	query = strings.TrimSpace(query)
+	if len(query) < 2 || len(query) > 200 {
+		return nil, ErrInvalidSearchQuery
+	}
+	if limit <= 0 {
+		limit = DefaultSearchLimit
+	}
+	if limit > MaxSearchLimit {
+		limit = MaxSearchLimit
+	}
+
+	visible, err := visibleCategories(ctx, s.libraryRepo, s.categoryRepo, user, librarySlug, (*models.Library).CanSearch)
+	if err != nil {
+		return nil, err
+	}
+	results := []models.SearchResult{}
+	if len(visible) == 0 {
+		return results, nil
+	}
+
+	categoryIDs := make([]primitive.ObjectID, 0, len(visible))
+	for id := range visible {
+		categoryIDs = append(categoryIDs, id)
+	}
+
+	docs, err := s.searchRepo.Search(ctx, query, categoryIDs, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search documents: %w", err)
+	}
+
+	terms := models.SearchTerms(query)
+	for _, doc := range docs {
+		owner, ok := visible[doc.CategoryID]
+		if !ok {
+			continue
+		}
+		results = append(results, models.SearchResult{
+			Name:         doc.Name,
+			Path:         doc.Path,
+			LibrarySlug:  owner.library.Slug,
+			LibraryName:  owner.library.Name,
+			CategoryID:   owner.category.ID.Hex(),
+			CategoryName: owner.category.Name,
+			Snippet:      models.BuildSnippet(doc.Content, terms, searchSnippetWidth),
+			Score:        doc.Score,
+			Size:         doc.Size,
+			ModifiedTime: doc.ModifiedTime,
+			DownloadURL:  fileDownloadURL(owner.library, owner.category, doc.Path),
+		})
+	}
+
+	return results, nil
+}
+
+// fileDownloadURL links to the download endpoint of a file in a category,
+// which applies the library's download permission
+func fileDownloadURL(library *models.Library, category *models.LibraryCategory, filePath string) string {
+	return fmt.Sprintf("/api/libraries/%s/categories/%s/files/download?path=%s",
+		library.Slug, category.ID.Hex(), url.QueryEscape(filePath))
+}
@@ -1,481 +0,0 @@
-package service
-
-import (
-	"context"
-	"errors"
-	"fmt"
-	"net/url"
-	"path/filepath"
-	"strings"
-
-	"backend/internal/models"
-	"backend/internal/repository"
-
-	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/bson/primitive"
-)
-
-var (
-	ErrCategoryNotFound = errors.New("category not found")
-	ErrDuplicateSlug    = errors.New("category with this name already exists")
-)
-
-// SOPCategoryService handles business logic for SOP categories
-type SOPCategoryService struct {
-	categoryRepo   *repository.SOPCategoryRepository
-	dropboxService *DropboxService
-	auditRepo      *repository.AuditRepository
-	userRepo       *repository.UserRepository
-}
-
-// NewSOPCategoryService creates a new SOPCategoryService
-func NewSOPCategoryService(
-	categoryRepo *repository.SOPCategoryRepository,
-	dropboxService *DropboxService,
-	auditRepo *repository.AuditRepository,
-	userRepo *repository.UserRepository,
-) *SOPCategoryService {
-	return &SOPCategoryService{
-		categoryRepo:   categoryRepo,
-		dropboxService: dropboxService,
-		auditRepo:      auditRepo,
-		userRepo:       userRepo,
-	}
-}
-
-// CreateCategory creates a new SOP category
-func (s *SOPCategoryService) CreateCategory(
-	ctx context.Context,
-	req *models.CreateSOPCategoryRequest,
-	createdBy *models.User,
-	ipAddress string,
-) (*models.SOPCategory, error) {
-	// Validate request
-	if err := req.Validate(); err != nil {
-		return nil, err
-	}
-
-	// Check permission - only super admins can manage categories
-	if !createdBy.HasPermission(models.PermDeleteUsers) {
-		return nil, ErrUnauthorized
-	}
-
-	// Generate slug from name
-	slug := models.GenerateSlug(req.Name)
-
-	// Check if slug already exists
-	exists, err := s.categoryRepo.ExistsBySlug(ctx, slug, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to check slug existence: %w", err)
-	}
-	if exists {
-		return nil, ErrDuplicateSlug
-	}
-
-	// Create category object
-	category := &models.SOPCategory{
-		Name:         req.Name,
-		Slug:         slug,
-		Description:  req.Description,
-		ImagePath:    req.ImagePath,
-		DropboxPath:  "SOPS/" + url.PathEscape(req.Name),
-		DisplayOrder: req.DisplayOrder,
-		IsActive:     true,
-		CreatedBy:    &createdBy.ID,
-	}
-
-	// Validate category
-	if err := category.Validate(); err != nil {
-		return nil, err
-	}
-
-	// Create folder in Dropbox
-	if s.dropboxService.IsConfigured() {
-		fmt.Printf("Attempting to create Dropbox folder at path: %s\n", category.DropboxPath)
-		err := s.dropboxService.CreateFolder(category.DropboxPath)
-		if err != nil {
-			// Log the error but don't fail the category creation
-			fmt.Printf("ERROR: Failed to create Dropbox folder for category '%s' at path '%s': %v\n", category.Name, category.DropboxPath, err)
-		} else {
-			fmt.Printf("SUCCESS: Created Dropbox folder for category '%s' at path '%s'\n", category.Name, category.DropboxPath)
-		}
-	} else {
-		fmt.Printf("WARNING: Dropbox is not configured - skipping folder creation for category '%s'\n", category.Name)
-	}
-
-	// Create category in database
-	if err := s.categoryRepo.Create(ctx, category); err != nil {
-		if err == repository.ErrDuplicateSlug {
-			return nil, ErrDuplicateSlug
-		}
-		return nil, fmt.Errorf("failed to create category: %w", err)
-	}
-
-	// Audit log
-	s.auditRepo.Create(ctx, &models.AuditLog{
-		UserID:      &createdBy.ID,
-		PerformedBy: &createdBy.ID,
-		Action:      "sop_category.create",
-		Details: bson.M{
-			"category_id":   category.ID.Hex(),
-			"category_name": category.Name,
-			"slug":          category.Slug,
-		},
-		IPAddress: ipAddress,
-	})
-
-	return category, nil
-}
-
-// GetCategory retrieves a category by ID
-func (s *SOPCategoryService) GetCategory(ctx context.Context, id primitive.ObjectID, user *models.User) (*models.SOPCategory, error) {
-	category, err := s.categoryRepo.FindByID(ctx, id)
-	if err != nil {
-		if err == repository.ErrCategoryNotFound {
-			return nil, ErrCategoryNotFound
-		}
-		return nil, fmt.Errorf("failed to get category: %w", err)
-	}
-
-	// Non-admins can only see active categories
-	if !category.IsActive && !user.HasPermission(models.PermDeleteUsers) {
-		return nil, ErrCategoryNotFound
-	}
-
-	return category, nil
-}
-
-// GetCategoryBySlug retrieves a category by slug
-func (s *SOPCategoryService) GetCategoryBySlug(ctx context.Context, slug string, user *models.User) (*models.SOPCategory, error) {
-	category, err := s.categoryRepo.FindBySlug(ctx, slug)
-	if err != nil {
-		if err == repository.ErrCategoryNotFound {
-			return nil, ErrCategoryNotFound
-		}
-		return nil, fmt.Errorf("failed to get category: %w", err)
-	}
-
-	// Non-admins can only see active categories
-	if !category.IsActive && !user.HasPermission(models.PermDeleteUsers) {
-		return nil, ErrCategoryNotFound
-	}
-
-	return category, nil
-}
-
-// ListCategories lists categories with filters
-func (s *SOPCategoryService) ListCategories(
-	ctx context.Context,
-	user *models.User,
-	search string,
-	page, limit int,
-) ([]*models.SOPCategory, int64, error) {
-	// Build filter
-	filter := repository.SOPCategoryFilter{
-		Search: search,
-	}
-
-	// Non-admins can only see active categories
-	if !user.HasPermission(models.PermDeleteUsers) {
-		isActive := true
-		filter.IsActive = &isActive
-	}
-
-	// Get categories
-	categories, err := s.categoryRepo.List(ctx, filter, page, limit)
-	if err != nil {
-		return nil, 0, fmt.Errorf("failed to list categories: %w", err)
-	}
-
-	// Get total count
-	total, err := s.categoryRepo.Count(ctx, filter)
-	if err != nil {
-		return nil, 0, fmt.Errorf("failed to count categories: %w", err)
-	}
-
-	return categories, total, nil
-}
-
-// UpdateCategory updates a category
-func (s *SOPCategoryService) UpdateCategory(
-	ctx context.Context,
-	id primitive.ObjectID,
-	req *models.UpdateSOPCategoryRequest,
-	updatedBy *models.User,
-	ipAddress string,
-) (*models.SOPCategory, error) {
-	// Validate request
-	if err := req.Validate(); err != nil {
-		return nil, err
-	}
-
-	// Check permission
-	if !updatedBy.HasPermission(models.PermDeleteUsers) {
-		return nil, ErrUnauthorized
-	}
-
-	// Get existing category
-	category, err := s.categoryRepo.FindByID(ctx, id)
-	if err != nil {
-		if err == repository.ErrCategoryNotFound {
-			return nil, ErrCategoryNotFound
-		}
-		return nil, fmt.Errorf("failed to get category: %w", err)
-	}
-
-	// Build update map
-	update := bson.M{}
-	oldDropboxPath := category.DropboxPath
-	nameChanged := false
-
-	if req.Name != nil && *req.Name != category.Name {
-		update["name"] = *req.Name
-		slug := models.GenerateSlug(*req.Name)
-
-		// Check if new slug already exists
-		exists, err := s.categoryRepo.ExistsBySlug(ctx, slug, &id)
-		if err != nil {
-			return nil, fmt.Errorf("failed to check slug existence: %w", err)
-		}
-		if exists {
-			return nil, ErrDuplicateSlug
-		}
-
-		update["slug"] = slug
-		update["dropbox_path"] = "SOPS/" + url.PathEscape(*req.Name)
-		nameChanged = true
-	}
-
-	if req.Description != nil {
-		update["description"] = *req.Description
-	}
-
-	if req.ImagePath != nil {
-		update["image_path"] = *req.ImagePath
-	}
-
-	if req.DisplayOrder != nil {
-		update["display_order"] = *req.DisplayOrder
-	}
-
-	if req.IsActive != nil {
-		update["is_active"] = *req.IsActive
-	}
-
-	// If nothing to update, return existing category
-	if len(update) == 0 {
-		return category, nil
-	}
-
-	// Update in database
-	if err := s.categoryRepo.Update(ctx, id, update); err != nil {
-		if err == repository.ErrDuplicateSlug {
-			return nil, ErrDuplicateSlug
-		}
-		return nil, fmt.Errorf("failed to update category: %w", err)
-	}
-
-	// If name changed, rename Dropbox folder
-	if nameChanged && s.dropboxService.IsConfigured() {
-		newDropboxPath := update["dropbox_path"].(string)
-		fmt.Printf("Attempting to rename Dropbox folder from '%s' to '%s'\n", oldDropboxPath, newDropboxPath)
-		err := s.dropboxService.RenameFolder(oldDropboxPath, newDropboxPath)
-		if err != nil {
-			// Log the error with more details
-			fmt.Printf("ERROR: Failed to rename Dropbox folder from '%s' to '%s': %v\n", oldDropboxPath, newDropboxPath, err)
-			// Return error to user so they know it failed
-			return nil, fmt.Errorf("category updated in database but failed to rename Dropbox folder: %w", err)
-		}
-		fmt.Printf("SUCCESS: Renamed Dropbox folder from '%s' to '%s'\n", oldDropboxPath, newDropboxPath)
-	}
-
-	// Get updated category
-	updatedCategory, err := s.categoryRepo.FindByID(ctx, id)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get updated category: %w", err)
-	}
-
-	// Audit log
-	s.auditRepo.Create(ctx, &models.AuditLog{
-		UserID:      &updatedBy.ID,
-		PerformedBy: &updatedBy.ID,
-		Action:      "sop_category.update",
-		Details: bson.M{
-			"category_id":   id.Hex(),
-			"category_name": updatedCategory.Name,
-			"changes":       update,
-		},
-		IPAddress: ipAddress,
-	})
-
-	return updatedCategory, nil
-}
-
-// DeleteCategory deletes a category from the database (Dropbox folder remains)
-func (s *SOPCategoryService) DeleteCategory(
-	ctx context.Context,
-	id primitive.ObjectID,
-	deletedBy *models.User,
-	ipAddress string,
-) error {
-	// Check permission
-	if !deletedBy.HasPermission(models.PermDeleteUsers) {
-		return ErrUnauthorized
-	}
-
-	// Get category for audit log
-	category, err := s.categoryRepo.FindByID(ctx, id)
-	if err != nil {
-		if err == repository.ErrCategoryNotFound {
-			return ErrCategoryNotFound
-		}
-		return fmt.Errorf("failed to get category: %w", err)
-	}
-
-	// Delete from database (NOT from Dropbox)
-	if err := s.categoryRepo.Delete(ctx, id); err != nil {
-		if err == repository.ErrCategoryNotFound {
-			return ErrCategoryNotFound
-		}
-		return fmt.Errorf("failed to delete category: %w", err)
-	}
-
-	// Audit log
-	s.auditRepo.Create(ctx, &models.AuditLog{
-		UserID:      &deletedBy.ID,
-		PerformedBy: &deletedBy.ID,
-		Action:      "sop_category.delete",
-		Details: bson.M{
-			"category_id":   id.Hex(),
-			"category_name": category.Name,
-			"slug":          category.Slug,
-			"dropbox_path":  category.DropboxPath,
-		},
-		IPAddress: ipAddress,
-	})
-
-	return nil
-}
-
-// GetCategoryFiles lists all files in a category's Dropbox folder
-func (s *SOPCategoryService) GetCategoryFiles(
-	ctx context.Context,
-	id primitive.ObjectID,
-	user *models.User,
-) ([]DropboxFileInfo, error) {
-	// Get category
-	category, err := s.GetCategory(ctx, id, user)
-	if err != nil {
-		return nil, err
-	}
-
-	// Check if Dropbox is configured
-	if !s.dropboxService.IsConfigured() {
-		return nil, errors.New("dropbox is not configured")
-	}
-
-	// List files recursively from Dropbox to get nested folder structure
-	files, err := s.dropboxService.ListFilesRecursive(category.DropboxPath)
-	if err != nil {
-		if err == ErrFolderNotFound {
-			// Return empty list if folder doesn't exist yet
-			return []DropboxFileInfo{}, nil
-		}
-		return nil, fmt.Errorf("failed to list files: %w", err)
-	}
-
-	// Make paths relative to category folder for easier handling
-	// Dropbox returns full paths, but we want paths relative to the category folder
-	categoryPath := strings.TrimPrefix(category.DropboxPath, "/")
-	categoryPath = strings.TrimSuffix(categoryPath, "/")
-
-	// Recursively make paths relative
-	s.makePathsRelative(files, categoryPath)
-
-	return files, nil
-}
-
-// GetFileDownloadLink generates a download link for a specific file
-func (s *SOPCategoryService) GetFileDownloadLink(
-	ctx context.Context,
-	categoryID primitive.ObjectID,
-	filePath string,
-	user *models.User,
-) (string, error) {
-	// Get category to verify it exists and user has access
-	category, err := s.GetCategory(ctx, categoryID, user)
-	if err != nil {
-		return "", err
-	}
-
-	// Check if Dropbox is configured
-	if !s.dropboxService.IsConfigured() {
-		return "", errors.New("dropbox is not configured")
-	}
-
-	// Construct full path - decode URL-encoded DropboxPath if needed
-	dropboxPath := category.DropboxPath
-	if decoded, err := url.PathUnescape(dropboxPath); err == nil {
-		dropboxPath = decoded
-	}
-
-	// Normalize paths
-	dropboxPath = strings.TrimPrefix(dropboxPath, "/")
-	dropboxPath = strings.TrimSuffix(dropboxPath, "/")
-	filePath = strings.TrimPrefix(filePath, "/")
-
-	// Join category path with file path (filePath should be relative to category folder)
-	fullPath := "/" + filepath.Join(dropboxPath, filePath)
-
-	// Normalize the path (remove double slashes, handle Windows separators)
-	fullPath = strings.ReplaceAll(fullPath, "\\", "/")
-	fullPath = strings.ReplaceAll(fullPath, "//", "/")
-
-	// Get download link
-	link, err := s.dropboxService.GetFileDownloadLink(fullPath)
-	if err != nil {
-		if err == ErrFileNotFound {
-			return "", errors.New("file not found")
-		}
-		return "", fmt.Errorf("failed to get download link: %w", err)
-	}
-
-	return link, nil
-}
-
-// makePathsRelative recursively converts full Dropbox paths to paths relative to category folder
-func (s *SOPCategoryService) makePathsRelative(files []DropboxFileInfo, categoryPath string) {
-	for i := range files {
-		// Remove category path prefix from file path
-		fullPath := strings.TrimPrefix(files[i].Path, "/")
-		if strings.HasPrefix(fullPath, categoryPath+"/") {
-			// Path includes category, make it relative
-			files[i].Path = strings.TrimPrefix(fullPath, categoryPath+"/")
-		} else if fullPath == categoryPath {
-			// This is the category folder itself, use empty path
-			files[i].Path = ""
-		}
-
-		// Recursively process children
-		if files[i].IsFolder && len(files[i].Children) > 0 {
-			s.makePathsRelative(files[i].Children, categoryPath)
-		}
-	}
-}
-
-// CountCategories returns the total count of SOP categories
-func (s *SOPCategoryService) CountCategories(ctx context.Context, activeOnly bool) (int64, error) {
-	filter := repository.SOPCategoryFilter{}
-
-	if activeOnly {
-		isActive := true
-		filter.IsActive = &isActive
-	}
-
-	count, err := s.categoryRepo.Count(ctx, filter)
-	if err != nil {
-		return 0, fmt.Errorf("failed to count categories: %w", err)
-	}
-
-	return count, nil
-}
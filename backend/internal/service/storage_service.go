@@ -0,0 +1,240 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"backend/internal/models"
+	"backend/internal/repository"
+	"backend/internal/storage"
+	"backend/internal/storage/local"
+	"backend/internal/storage/s3"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrStorageNotConfigured = errors.New("the selected storage is not configured")
+	ErrStorageUnsupported   = errors.New("this feature is only available for libraries stored in Dropbox")
+	ErrLibraryHasCategories = errors.New("cannot change storage: the library still has categories")
+)
+
+// StorageService picks the storage driver that a library or the registry
+// keeps its files in, and for Dropbox the connection. Dropbox is always
+// registered and connected from the admin UI; the local disk and
+// S3-compatible drivers are enabled from the environment.
+type StorageService struct {
+	drivers        map[string]storage.Driver
+	localDriver    *local.Driver
+	connections    *DropboxConnectionService
+	listingService *DropboxListingService
+	libraryRepo    *repository.LibraryRepository
+	configRepo     *repository.RegistryConfigRepository
+}
+
+// NewStorageService creates a new StorageService
+func NewStorageService(
+	connections *DropboxConnectionService,
+	listingService *DropboxListingService,
+	libraryRepo *repository.LibraryRepository,
+	configRepo *repository.RegistryConfigRepository,
+) *StorageService {
+	s := &StorageService{
+		drivers:        map[string]storage.Driver{models.StorageDriverDropbox: NewDropboxStorage(connections.Default())},
+		connections:    connections,
+		listingService: listingService,
+		libraryRepo:    libraryRepo,
+		configRepo:     configRepo,
+	}
+
+	if driver, err := local.FromEnv(); err != nil {
+		fmt.Printf("Warning: local storage is disabled: %v\n", err)
+	} else if driver != nil {
+		s.drivers[models.StorageDriverLocal] = driver
+		s.localDriver = driver
+	}
+	if driver, err := s3.FromEnv(); err != nil {
+		fmt.Printf("Warning: S3 storage is disabled: %v\n", err)
+	} else if driver != nil {
+		s.drivers[models.StorageDriverS3] = driver
+	}
+
+	return s
+}
+
+// Driver returns a storage driver by name, the empty name being Dropbox
+// through the default connection. A driver that is not set up is returned
+// as storage.Unconfigured.
+func (s *StorageService) Driver(name string) storage.Driver {
+	if driver, ok := s.drivers[models.StorageDriverName(name)]; ok {
+		return driver
+	}
+	return storage.Unconfigured{}
+}
+
+// driverFor returns a storage driver by name, using the given Dropbox
+// connection for Dropbox
+func (s *StorageService) driverFor(name string, connectionID *primitive.ObjectID) storage.Driver {
+	if models.StorageDriverName(name) == models.StorageDriverDropbox {
+		return NewDropboxStorage(s.connections.Get(connectionID))
+	}
+	return s.Driver(name)
+}
+
+// ForLibrary returns the driver a library keeps its files in
+func (s *StorageService) ForLibrary(library *models.Library) storage.Driver {
+	return s.driverFor(library.StorageDriver, library.DropboxConnectionID)
+}
+
+// ForRegistry returns the driver new registry submissions and the example
+// documents are kept in
+func (s *StorageService) ForRegistry(config *models.RegistryConfig) storage.Driver {
+	return s.driverFor(config.StorageDriver, config.DropboxConnectionID)
+}
+
+// ForSubmission returns the driver a registry submission's documents were
+// stored in
+func (s *StorageService) ForSubmission(submission *models.RegistrySubmission) storage.Driver {
+	return s.driverFor(submission.StorageDriver, submission.DropboxConnectionID)
+}
+
+// CheckDriver checks that a library or the registry can be switched to a
+// driver. Dropbox is always accepted as it may be connected later.
+func (s *StorageService) CheckDriver(name string) error {
+	if err := models.ValidateStorageDriver(name); err != nil {
+		return err
+	}
+	if models.StorageDriverName(name) != models.StorageDriverDropbox && !s.Driver(name).IsConfigured() {
+		return ErrStorageNotConfigured
+	}
+	return nil
+}
+
+// CheckConnection checks that a library or the registry can use a Dropbox
+// connection, the zero ID being the default. It returns the ID to store,
+// which is zero for the default connection however it was chosen.
+func (s *StorageService) CheckConnection(ctx context.Context, connectionID primitive.ObjectID) (primitive.ObjectID, error) {
+	ref, err := s.connections.Resolve(ctx, models.DropboxConnectionRef(connectionID))
+	if err != nil || ref == nil {
+		return primitive.NilObjectID, err
+	}
+	return *ref, nil
+}
+
+// LocalLinks returns the handler that serves local storage links, or nil
+// if local storage is disabled
+func (s *StorageService) LocalLinks() http.Handler {
+	if s.localDriver == nil {
+		return nil
+	}
+	return s.localDriver
+}
+
+// Status reports which drivers are configured and what uses them
+func (s *StorageService) Status(ctx context.Context) ([]models.StorageDriverStatus, error) {
+	libraries, err := s.libraryRepo.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list libraries: %w", err)
+	}
+	registryDriver := ""
+	if config, err := s.configRepo.GetConfig(ctx); err == nil {
+		registryDriver = config.StorageDriver
+	}
+
+	statuses := make([]models.StorageDriverStatus, 0, len(models.StorageDrivers))
+	for _, name := range models.StorageDrivers {
+		status := models.StorageDriverStatus{
+			Name:       name,
+			Configured: s.Driver(name).IsConfigured(),
+			Registry:   models.StorageDriverName(registryDriver) == name,
+		}
+		for _, library := range libraries {
+			if models.StorageDriverName(library.StorageDriver) == name {
+				status.Libraries++
+			}
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// CategoryFiles lists a category's files with paths relative to its
+// folder, leaving out folders without files. Dropbox listings come from the
+// listing cache.
+func (s *StorageService) CategoryFiles(ctx context.Context, library *models.Library, category *models.LibraryCategory) ([]DropboxFileInfo, error) {
+	if library.UsesDropbox() {
+		files, err := s.listingService.Listing(ctx, s.connections.ForLibrary(library), category.DropboxPath)
+		if err != nil {
+			return nil, err
+		}
+		makePathsRelative(files, strings.Trim(category.DropboxPath, "/"))
+		return files, nil
+	}
+
+	folder := storagePath(category.DropboxPath)
+	objects, err := s.ForLibrary(library).List(ctx, folder, true)
+	if err != nil {
+		if err == storage.ErrNotFound {
+			return nil, ErrFolderNotFound
+		}
+		return nil, fromStorageError(err)
+	}
+	files := fileInfosFromObjects(objects)
+	makePathsRelative(files, folder)
+	return s.connections.Default().filterEmptyFolders(files), nil
+}
+
+// storagePath converts a stored folder or file path, whose segments may be
+// URL-escaped as category folders are, to a driver path. Dropbox decodes
+// paths the same way (see getFullPath).
+func storagePath(p string) string {
+	if decoded, err := url.PathUnescape(p); err == nil {
+		p = decoded
+	}
+	return strings.Trim(p, "/")
+}
+
+// fileInfosFromObjects converts storage objects to the file listing served
+// by the API
+func fileInfosFromObjects(objects []storage.Object) []DropboxFileInfo {
+	files := make([]DropboxFileInfo, 0, len(objects))
+	for _, object := range objects {
+		files = append(files, *fileInfoFromObject(object))
+	}
+	return files
+}
+
+// fileInfoFromObject converts one storage object, with its children
+func fileInfoFromObject(object storage.Object) *DropboxFileInfo {
+	file := &DropboxFileInfo{
+		Name:         object.Name,
+		Path:         object.Path,
+		ModifiedTime: object.ModifiedTime,
+		IsFolder:     object.IsFolder,
+		Rev:          object.Rev,
+	}
+	if object.Size > 0 {
+		file.Size = uint64(object.Size)
+	}
+	if object.IsFolder {
+		file.Children = fileInfosFromObjects(object.Children)
+	}
+	return file
+}
+
+// fromStorageError maps storage errors to the errors the services return
+func fromStorageError(err error) error {
+	switch err {
+	case storage.ErrNotFound:
+		return ErrFileNotFound
+	case storage.ErrExists:
+		return ErrFileExists
+	case storage.ErrNotConfigured:
+		return ErrStorageNotConfigured
+	}
+	return err
+}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"backend/internal/models"
+	"backend/internal/pagination"
 	"backend/internal/repository"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -98,6 +99,12 @@ func (s *UserService) CreateUser(ctx context.Context, req *models.CreateUserRequ
 		return nil, errors.New("invalid institution ID format")
 	}
 
+	// The account does not exist yet, so it cannot have proposed a pending
+	// institution
+	if _, err := findSelectableInstitution(ctx, s.institutionRepo, institutionID, primitive.NilObjectID); err != nil {
+		return nil, err
+	}
+
 	// Create user
 	user := &models.User{
 		Username:     req.Username,
@@ -178,6 +185,12 @@ func (s *UserService) RegisterUser(ctx context.Context, req *models.RegisterUser
 		return nil, errors.New("invalid institution ID format")
 	}
 
+	// The account does not exist yet, so it cannot have proposed a pending
+	// institution
+	if _, err := findSelectableInstitution(ctx, s.institutionRepo, institutionID, primitive.NilObjectID); err != nil {
+		return nil, err
+	}
+
 	// Create user (deactivated by default for self-registration)
 	user := &models.User{
 		Username:     req.Username,
@@ -303,6 +316,12 @@ func (s *UserService) UpdateUser(ctx context.Context, userID primitive.ObjectID,
 			}
 		}
 
+		// Pending institutions can only be chosen for the user who proposed
+		// them, rejected ones not at all
+		if err := institution.CheckSelectableBy(targetUser.ID); err != nil {
+			return nil, err
+		}
+
 		update["profile.institution_id"] = institutionID
 		details["institution_id"] = *req.InstitutionID
 	}
@@ -310,13 +329,15 @@ func (s *UserService) UpdateUser(ctx context.Context, userID primitive.ObjectID,
 		update["profile.specialty"] = *req.Specialty
 		details["specialty"] = *req.Specialty
 	}
+	// Personal data is stored encrypted, so the audit log only records
+	// that it changed
 	if req.RegistrationNumber != nil {
 		update["profile.registration_number"] = *req.RegistrationNumber
-		details["registration_number"] = *req.RegistrationNumber
+		details["registration_number"] = "updated"
 	}
 	if req.PhoneNumber != nil {
 		update["profile.phone_number"] = *req.PhoneNumber
-		details["phone_number"] = *req.PhoneNumber
+		details["phone_number"] = "updated"
 	}
 
 	// Only admins can change role, admin level, and active status
@@ -504,8 +525,8 @@ func (s *UserService) GetUser(ctx context.Context, userID primitive.ObjectID) (*
 	return s.userRepo.FindByID(ctx, userID)
 }
 
-// ListUsers retrieves users with pagination, filtering, and searching
-func (s *UserService) ListUsers(ctx context.Context, role *models.UserRole, isActive *bool, search string, limit, skip int64) ([]*models.User, int64, error) {
+// ListUsers retrieves one page of users with filtering, searching and sorting
+func (s *UserService) ListUsers(ctx context.Context, role *models.UserRole, isActive *bool, search string, req pagination.Request) (*pagination.Page[*models.User], int64, error) {
 	filter := bson.M{}
 
 	if role != nil {
@@ -517,17 +538,19 @@ func (s *UserService) ListUsers(ctx context.Context, role *models.UserRole, isAc
 
 	// Add search filter if search query is provided
 	if search != "" {
-		// Search across multiple fields: firstName, lastName, email, role
+		// Search across multiple fields: firstName, lastName, email, role,
+		// and an exact registration or phone number, which are encrypted
+		// and matched by their blind indexes
 		// Note: Institution search requires a join/lookup with institutions collection
-		filter["$or"] = []bson.M{
+		filter["$or"] = append([]bson.M{
 			{"profile.first_name": bson.M{"$regex": search, "$options": "i"}},
 			{"profile.last_name": bson.M{"$regex": search, "$options": "i"}},
 			{"email": bson.M{"$regex": search, "$options": "i"}},
 			{"role": bson.M{"$regex": search, "$options": "i"}},
-		}
+		}, s.userRepo.PersonalDataFilters(search)...)
 	}
 
-	users, err := s.userRepo.List(ctx, filter, limit, skip)
+	page, err := s.userRepo.ListPage(ctx, filter, req)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -537,7 +560,7 @@ func (s *UserService) ListUsers(ctx context.Context, role *models.UserRole, isAc
 		return nil, 0, err
 	}
 
-	return users, count, nil
+	return page, count, nil
 }
 
 // CountUsers counts users with optional filtering
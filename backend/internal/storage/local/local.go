@@ -0,0 +1,386 @@
+// Package local stores files in a folder on the server's disk.
+//
+// Files are served through links signed with an HMAC of the path and an
+// expiry time, handled by the driver's ServeHTTP, so a link works without
+// logging in but cannot be altered or used after it expires. Folders cannot
+// be shared.
+package local
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"backend/internal/storage"
+)
+
+// Defaults for how long links stay valid
+const (
+	DefaultLinkTTL  = 4 * time.Hour
+	DefaultShareTTL = 7 * 24 * time.Hour
+)
+
+// Config configures a local driver
+type Config struct {
+	Root     string        // Folder files are stored in; created if missing
+	LinkURL  string        // Absolute URL that ServeHTTP is mounted at
+	Secret   []byte        // Key that links are signed with
+	LinkTTL  time.Duration // Lifetime of temporary links; DefaultLinkTTL if unset
+	ShareTTL time.Duration // Lifetime of share links; DefaultShareTTL if unset
+}
+
+// Driver stores files on the local disk
+type Driver struct {
+	root     string
+	linkURL  string
+	secret   []byte
+	linkTTL  time.Duration
+	shareTTL time.Duration
+	now      func() time.Time
+}
+
+// New creates a local driver, creating its root folder if needed
+func New(cfg Config) (*Driver, error) {
+	if cfg.Root == "" || cfg.LinkURL == "" || len(cfg.Secret) == 0 {
+		return nil, errors.New("local storage needs a root folder, a link URL and a signing secret")
+	}
+	root, err := filepath.Abs(cfg.Root)
+	if err != nil {
+		return nil, fmt.Errorf("invalid local storage root: %w", err)
+	}
+	if err := os.MkdirAll(root, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create local storage root: %w", err)
+	}
+
+	d := &Driver{
+		root:     root,
+		linkURL:  cfg.LinkURL,
+		secret:   cfg.Secret,
+		linkTTL:  cfg.LinkTTL,
+		shareTTL: cfg.ShareTTL,
+		now:      time.Now,
+	}
+	if d.linkTTL <= 0 {
+		d.linkTTL = DefaultLinkTTL
+	}
+	if d.shareTTL <= 0 {
+		d.shareTTL = DefaultShareTTL
+	}
+	return d, nil
+}
+
+// FromEnv creates a local driver from STORAGE_LOCAL_ROOT,
+// STORAGE_LOCAL_LINK_URL and STORAGE_LOCAL_SECRET. It returns nil if
+// STORAGE_LOCAL_ROOT is unset.
+func FromEnv() (*Driver, error) {
+	root := os.Getenv("STORAGE_LOCAL_ROOT")
+	if root == "" {
+		return nil, nil
+	}
+	return New(Config{
+		Root:    root,
+		LinkURL: os.Getenv("STORAGE_LOCAL_LINK_URL"),
+		Secret:  []byte(os.Getenv("STORAGE_LOCAL_SECRET")),
+	})
+}
+
+// IsConfigured reports whether the driver can be used
+func (d *Driver) IsConfigured() bool {
+	return d != nil
+}
+
+// resolve turns a storage path into a path on disk
+func (d *Driver) resolve(p string) (string, string, error) {
+	clean, err := storage.CleanPath(p)
+	if err != nil {
+		return "", "", err
+	}
+	return clean, filepath.Join(d.root, filepath.FromSlash(clean)), nil
+}
+
+// object describes a file or folder on disk
+func object(p string, info fs.FileInfo) storage.Object {
+	o := storage.Object{
+		Name:         info.Name(),
+		Path:         p,
+		ModifiedTime: info.ModTime().UTC(),
+		IsFolder:     info.IsDir(),
+	}
+	if !o.IsFolder {
+		o.Size = info.Size()
+		o.Rev = strconv.FormatInt(info.ModTime().UnixNano(), 36) + "-" + strconv.FormatInt(info.Size(), 36)
+	}
+	return o
+}
+
+// CreateFolder creates a folder and any missing parents
+func (d *Driver) CreateFolder(ctx context.Context, folderPath string) error {
+	_, full, err := d.resolve(folderPath)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(full, 0o750); err != nil {
+		return fmt.Errorf("failed to create folder: %w", err)
+	}
+	return nil
+}
+
+// Upload stores a file, writing it beside its destination first so readers
+// never see a partial file
+func (d *Driver) Upload(ctx context.Context, content io.Reader, filePath string) (*storage.Object, error) {
+	clean, full, err := d.resolve(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if clean == "" {
+		return nil, storage.ErrInvalidPath
+	}
+	if info, err := os.Stat(full); err == nil && info.IsDir() {
+		return nil, storage.ErrExists
+	}
+	if err := os.MkdirAll(filepath.Dir(full), 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create folder: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(full), ".upload-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to store file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, content); err != nil {
+		tmp.Close()
+		return nil, fmt.Errorf("failed to store file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, fmt.Errorf("failed to store file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), full); err != nil {
+		return nil, fmt.Errorf("failed to store file: %w", err)
+	}
+
+	return d.Metadata(ctx, clean)
+}
+
+// List returns the files and folders in a folder
+func (d *Driver) List(ctx context.Context, folderPath string, recursive bool) ([]storage.Object, error) {
+	clean, full, err := d.resolve(folderPath)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(full)
+	if err != nil || !info.IsDir() {
+		return nil, storage.ErrNotFound
+	}
+	return d.list(clean, full, recursive)
+}
+
+func (d *Driver) list(folderPath, full string, recursive bool) ([]storage.Object, error) {
+	entries, err := os.ReadDir(full)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list folder: %w", err)
+	}
+
+	objects := []storage.Object{}
+	for _, entry := range entries {
+		// Skip partial uploads and anything that is not a file or folder
+		if strings.HasPrefix(entry.Name(), ".upload-") || !(entry.Type().IsRegular() || entry.IsDir()) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		o := object(path.Join(folderPath, entry.Name()), info)
+		if o.IsFolder && recursive {
+			if o.Children, err = d.list(o.Path, filepath.Join(full, entry.Name()), true); err != nil {
+				return nil, err
+			}
+		}
+		objects = append(objects, o)
+	}
+	storage.SortObjects(objects)
+	return objects, nil
+}
+
+// Metadata returns a file or folder
+func (d *Driver) Metadata(ctx context.Context, itemPath string) (*storage.Object, error) {
+	clean, full, err := d.resolve(itemPath)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(full)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, storage.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get metadata: %w", err)
+	}
+	o := object(clean, info)
+	return &o, nil
+}
+
+// Rename moves a file or folder
+func (d *Driver) Rename(ctx context.Context, fromPath, toPath string) error {
+	_, from, err := d.resolve(fromPath)
+	if err != nil {
+		return err
+	}
+	to, toFull, err := d.resolve(toPath)
+	if err != nil {
+		return err
+	}
+	if to == "" {
+		return storage.ErrInvalidPath
+	}
+	if _, err := os.Stat(from); err != nil {
+		return storage.ErrNotFound
+	}
+	if _, err := os.Stat(toFull); err == nil {
+		return storage.ErrExists
+	}
+	if err := os.MkdirAll(filepath.Dir(toFull), 0o750); err != nil {
+		return fmt.Errorf("failed to create folder: %w", err)
+	}
+	if err := os.Rename(from, toFull); err != nil {
+		return fmt.Errorf("failed to rename: %w", err)
+	}
+	return nil
+}
+
+// Delete removes a file, or a folder and everything in it
+func (d *Driver) Delete(ctx context.Context, itemPath string) error {
+	clean, full, err := d.resolve(itemPath)
+	if err != nil {
+		return err
+	}
+	if clean == "" {
+		return storage.ErrInvalidPath
+	}
+	if _, err := os.Lstat(full); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return storage.ErrNotFound
+		}
+		return fmt.Errorf("failed to delete: %w", err)
+	}
+	if err := os.RemoveAll(full); err != nil {
+		return fmt.Errorf("failed to delete: %w", err)
+	}
+	return nil
+}
+
+// Open reads a file
+func (d *Driver) Open(ctx context.Context, filePath string) (io.ReadCloser, *storage.Object, error) {
+	clean, full, err := d.resolve(filePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	file, err := os.Open(full)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil, storage.ErrNotFound
+		}
+		return nil, nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	if info.IsDir() {
+		file.Close()
+		return nil, nil, storage.ErrNotFound
+	}
+	o := object(clean, info)
+	return file, &o, nil
+}
+
+// ShareLink returns a signed link to a file that lasts the share lifetime
+func (d *Driver) ShareLink(ctx context.Context, itemPath string) (string, error) {
+	o, err := d.Metadata(ctx, itemPath)
+	if err != nil {
+		return "", err
+	}
+	if o.IsFolder {
+		return "", storage.ErrUnsupported
+	}
+	return d.link(o.Path, d.now().Add(d.shareTTL)), nil
+}
+
+// TemporaryLink returns a signed download link to a file
+func (d *Driver) TemporaryLink(ctx context.Context, filePath string) (string, *storage.Object, error) {
+	o, err := d.Metadata(ctx, filePath)
+	if err != nil {
+		return "", nil, err
+	}
+	if o.IsFolder {
+		return "", nil, storage.ErrNotFound
+	}
+	return d.link(o.Path, d.now().Add(d.linkTTL)), o, nil
+}
+
+// link builds a signed link to a file
+func (d *Driver) link(p string, expires time.Time) string {
+	exp := strconv.FormatInt(expires.Unix(), 10)
+	query := url.Values{
+		"path":    {p},
+		"expires": {exp},
+		"sig":     {d.signature(p, exp)},
+	}
+	return d.linkURL + "?" + query.Encode()
+}
+
+// signature signs a path and expiry time
+func (d *Driver) signature(p, expires string) string {
+	mac := hmac.New(sha256.New, d.secret)
+	mac.Write([]byte(p + "\n" + expires))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// ServeHTTP serves a file through a signed link
+func (d *Driver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	p, exp, sig := query.Get("path"), query.Get("expires"), query.Get("sig")
+
+	expires, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil || !hmac.Equal([]byte(sig), []byte(d.signature(p, exp))) {
+		http.Error(w, "invalid link", http.StatusForbidden)
+		return
+	}
+	if d.now().Unix() > expires {
+		http.Error(w, "link has expired", http.StatusGone)
+		return
+	}
+
+	_, full, err := d.resolve(p)
+	if err != nil {
+		http.Error(w, "invalid link", http.StatusForbidden)
+		return
+	}
+	file, err := os.Open(full)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil || info.IsDir() {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", info.Name()))
+	http.ServeContent(w, r, info.Name(), info.ModTime(), file)
+}
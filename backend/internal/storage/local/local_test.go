@@ -0,0 +1,176 @@
+package local
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"backend/internal/storage"
+)
+
+func newDriver(t *testing.T) *Driver {
+	t.Helper()
+	d, err := New(Config{Root: t.TempDir(), LinkURL: "https://api.example.org/api/storage/local", Secret: []byte("secret")})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return d
+}
+
+func upload(t *testing.T, d *Driver, p, content string) {
+	t.Helper()
+	if _, err := d.Upload(context.Background(), strings.NewReader(content), p); err != nil {
+		t.Fatalf("Upload(%s) error = %v", p, err)
+	}
+}
+
+func names(objects []storage.Object) []string {
+	out := []string{}
+	for _, o := range objects {
+		out = append(out, o.Path)
+		out = append(out, names(o.Children)...)
+	}
+	return out
+}
+
+func TestDriverFiles(t *testing.T) {
+	ctx := context.Background()
+	d := newDriver(t)
+
+	upload(t, d, "SOPs/Anemia/Iron.pdf", "iron")
+	upload(t, d, "SOPs/Anemia/Old/2019.docx", "old")
+	if err := d.CreateFolder(ctx, "SOPs/Empty"); err != nil {
+		t.Fatalf("CreateFolder() error = %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		folder    string
+		recursive bool
+		want      []string
+		wantErr   error
+	}{
+		{name: "recursive", folder: "SOPs", recursive: true, want: []string{"SOPs/Anemia", "SOPs/Anemia/Iron.pdf", "SOPs/Anemia/Old", "SOPs/Anemia/Old/2019.docx", "SOPs/Empty"}},
+		{name: "one level", folder: "SOPs/Anemia", want: []string{"SOPs/Anemia/Iron.pdf", "SOPs/Anemia/Old"}},
+		{name: "missing folder", folder: "SOPs/Missing", wantErr: storage.ErrNotFound},
+		{name: "a file", folder: "SOPs/Anemia/Iron.pdf", wantErr: storage.ErrNotFound},
+		{name: "outside the root", folder: "SOPs/../..", wantErr: storage.ErrInvalidPath},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objects, err := d.List(ctx, tt.folder, tt.recursive)
+			if err != tt.wantErr {
+				t.Fatalf("List() error = %v, want %v", err, tt.wantErr)
+			}
+			if got := names(objects); err == nil && strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("List() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// Replacing a file changes its size and revision
+	before, _ := d.Metadata(ctx, "SOPs/Anemia/Iron.pdf")
+	upload(t, d, "SOPs/Anemia/Iron.pdf", "iron, revised")
+	after, err := d.Metadata(ctx, "SOPs/Anemia/Iron.pdf")
+	if err != nil || after.Size != int64(len("iron, revised")) || after.Rev == before.Rev {
+		t.Errorf("replaced file = %+v, %v; was %+v", after, err, before)
+	}
+
+	if err := d.Rename(ctx, "SOPs/Anemia", "SOPs/Anaemia"); err != nil {
+		t.Fatalf("Rename() error = %v", err)
+	}
+	if _, err := d.Metadata(ctx, "SOPs/Anaemia/Old/2019.docx"); err != nil {
+		t.Errorf("renamed folder lost its files: %v", err)
+	}
+	if err := d.Rename(ctx, "SOPs/Anaemia", "SOPs/Empty"); err != storage.ErrExists {
+		t.Errorf("Rename() onto an existing folder error = %v, want %v", err, storage.ErrExists)
+	}
+	if err := d.Rename(ctx, "SOPs/Missing", "SOPs/Other"); err != storage.ErrNotFound {
+		t.Errorf("Rename() of a missing folder error = %v, want %v", err, storage.ErrNotFound)
+	}
+	if _, err := d.ShareLink(ctx, "SOPs/Empty"); err != storage.ErrUnsupported {
+		t.Errorf("ShareLink() of a folder error = %v, want %v", err, storage.ErrUnsupported)
+	}
+
+	content, file, err := d.Open(ctx, "SOPs/Anaemia/Iron.pdf")
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	data, _ := io.ReadAll(content)
+	content.Close()
+	if string(data) != "iron, revised" || file.Path != "SOPs/Anaemia/Iron.pdf" || file.Rev == "" {
+		t.Errorf("Open() = %q, %+v", data, file)
+	}
+	if _, _, err := d.Open(ctx, "SOPs/Anaemia"); err != storage.ErrNotFound {
+		t.Errorf("Open() of a folder error = %v, want %v", err, storage.ErrNotFound)
+	}
+
+	if err := d.Delete(ctx, "SOPs/Anaemia"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := d.Metadata(ctx, "SOPs/Anaemia/Old/2019.docx"); err != storage.ErrNotFound {
+		t.Errorf("deleted folder kept its files: %v", err)
+	}
+	if err := d.Delete(ctx, "SOPs/Anaemia"); err != storage.ErrNotFound {
+		t.Errorf("Delete() of a missing folder error = %v, want %v", err, storage.ErrNotFound)
+	}
+	if err := d.Delete(ctx, ""); err != storage.ErrInvalidPath {
+		t.Errorf("Delete() of the root error = %v, want %v", err, storage.ErrInvalidPath)
+	}
+}
+
+func TestDriverLinks(t *testing.T) {
+	ctx := context.Background()
+	d := newDriver(t)
+	now := time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC)
+	d.now = func() time.Time { return now }
+	upload(t, d, "Registry/Consent form.pdf", "consent")
+
+	link, o, err := d.TemporaryLink(ctx, "Registry/Consent form.pdf")
+	if err != nil || o.Name != "Consent form.pdf" {
+		t.Fatalf("TemporaryLink() = %+v, %v", o, err)
+	}
+	if _, _, err := d.TemporaryLink(ctx, "Registry/Missing.pdf"); err != storage.ErrNotFound {
+		t.Errorf("TemporaryLink() of a missing file error = %v, want %v", err, storage.ErrNotFound)
+	}
+
+	parsed, _ := url.Parse(link)
+	tampered := parsed.Query()
+	tampered.Set("path", "Registry/Other.pdf")
+
+	tests := []struct {
+		name   string
+		query  string
+		after  time.Duration
+		status int
+		body   string
+	}{
+		{name: "valid", query: parsed.RawQuery, status: http.StatusOK, body: "consent"},
+		{name: "expired", query: parsed.RawQuery, after: DefaultLinkTTL + time.Second, status: http.StatusGone},
+		{name: "altered path", query: tampered.Encode(), status: http.StatusForbidden},
+		{name: "unsigned", query: "path=Registry%2FConsent+form.pdf&expires=9999999999", status: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d.now = func() time.Time { return now.Add(tt.after) }
+			rec := httptest.NewRecorder()
+			d.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/storage/local?"+tt.query, nil))
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.status)
+			}
+			if tt.body != "" {
+				body, _ := io.ReadAll(rec.Body)
+				if string(body) != tt.body {
+					t.Errorf("body = %q, want %q", body, tt.body)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,554 @@
+// Package s3 stores files in an S3-compatible object store such as Amazon
+// S3 or MinIO.
+//
+// Object stores have no real folders: a folder is the common prefix of the
+// keys below it, and empty folders are kept as zero-byte objects whose key
+// ends in "/". Renaming a folder copies and deletes every object below it.
+// Links are presigned GET URLs, so folders cannot be shared.
+package s3
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"backend/internal/storage"
+)
+
+// Defaults for how long links stay valid. Presigned URLs cannot last longer
+// than seven days.
+const (
+	DefaultLinkTTL  = 4 * time.Hour
+	DefaultShareTTL = 7 * 24 * time.Hour
+)
+
+// Config configures an S3 driver
+type Config struct {
+	Endpoint        string // e.g. http://localhost:9000; Amazon S3 for the region if empty
+	Region          string
+	Bucket          string
+	AccessKeyID     string
+	SecretAccessKey string
+	Prefix          string // Folder in the bucket that paths are relative to
+	ForcePathStyle  bool   // Address the bucket in the path, as MinIO needs
+	LinkTTL         time.Duration
+	ShareTTL        time.Duration
+}
+
+// Driver stores files in an S3-compatible bucket
+type Driver struct {
+	endpoint  *url.URL
+	bucket    string
+	prefix    string // "" or ends in "/"
+	pathStyle bool
+	signer    signer
+	client    *http.Client
+	linkTTL   time.Duration
+	shareTTL  time.Duration
+	pageSize  int // Keys per list request; the server's default if 0
+	now       func() time.Time
+}
+
+// New creates an S3 driver
+func New(cfg Config) (*Driver, error) {
+	if cfg.Bucket == "" || cfg.Region == "" || cfg.AccessKeyID == "" || cfg.SecretAccessKey == "" {
+		return nil, errors.New("S3 storage needs a bucket, region and access key")
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = "https://s3." + cfg.Region + ".amazonaws.com"
+	}
+	endpoint, err := url.Parse(strings.TrimRight(cfg.Endpoint, "/"))
+	if err != nil || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid S3 endpoint %q", cfg.Endpoint)
+	}
+	prefix, err := storage.CleanPath(cfg.Prefix)
+	if err != nil {
+		return nil, fmt.Errorf("invalid S3 prefix: %w", err)
+	}
+	if prefix != "" {
+		prefix += "/"
+	}
+
+	d := &Driver{
+		endpoint:  endpoint,
+		bucket:    cfg.Bucket,
+		prefix:    prefix,
+		pathStyle: cfg.ForcePathStyle,
+		signer:    signer{accessKeyID: cfg.AccessKeyID, secretAccessKey: cfg.SecretAccessKey, region: cfg.Region},
+		client:    &http.Client{},
+		linkTTL:   cfg.LinkTTL,
+		shareTTL:  cfg.ShareTTL,
+		now:       time.Now,
+	}
+	if d.linkTTL <= 0 {
+		d.linkTTL = DefaultLinkTTL
+	}
+	if d.shareTTL <= 0 || d.shareTTL > DefaultShareTTL {
+		d.shareTTL = DefaultShareTTL
+	}
+	return d, nil
+}
+
+// FromEnv creates an S3 driver from the AWS_* environment variables. It
+// returns nil if AWS_S3_BUCKET is unset.
+func FromEnv() (*Driver, error) {
+	bucket := os.Getenv("AWS_S3_BUCKET")
+	if bucket == "" {
+		return nil, nil
+	}
+	pathStyle, _ := strconv.ParseBool(os.Getenv("AWS_S3_FORCE_PATH_STYLE"))
+	return New(Config{
+		Endpoint:        os.Getenv("AWS_S3_ENDPOINT"),
+		Region:          os.Getenv("AWS_REGION"),
+		Bucket:          bucket,
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		Prefix:          os.Getenv("AWS_S3_PREFIX"),
+		ForcePathStyle:  pathStyle,
+	})
+}
+
+// IsConfigured reports whether the driver can be used
+func (d *Driver) IsConfigured() bool {
+	return d != nil
+}
+
+// key returns the object key of a cleaned path
+func (d *Driver) key(p string) string {
+	return d.prefix + p
+}
+
+// folderKey returns the key prefix of the objects in a folder
+func (d *Driver) folderKey(p string) string {
+	if p == "" {
+		return d.prefix
+	}
+	return d.prefix + p + "/"
+}
+
+// pathOf returns the path of an object key
+func (d *Driver) pathOf(key string) string {
+	return strings.TrimSuffix(strings.TrimPrefix(key, d.prefix), "/")
+}
+
+// objectURL returns the URL of an object, or of the bucket if key is empty
+func (d *Driver) objectURL(key string, query url.Values) *url.URL {
+	u := *d.endpoint
+	p := "/" + key
+	if d.pathStyle {
+		p = "/" + d.bucket + p
+	} else {
+		u.Host = d.bucket + "." + u.Host
+	}
+	u.Path = p
+	u.RawPath = uriEncode(p, true)
+	u.RawQuery = canonicalQuery(query)
+	return &u
+}
+
+// apiError is the error document S3 returns
+type apiError struct {
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+}
+
+// do sends a signed request, returning ErrNotFound for a 404 and the
+// server's error for any other failure
+func (d *Driver) do(ctx context.Context, method, key string, query url.Values, header http.Header, body io.Reader, size int64) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, d.objectURL(key, query).String(), body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create S3 request: %w", err)
+	}
+	// Keep the canonical path rather than the one NewRequest re-parsed
+	req.URL = d.objectURL(key, query)
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	if body != nil {
+		req.ContentLength = size
+		if size == 0 {
+			req.Body = http.NoBody
+		}
+	}
+	d.signer.sign(req, unsignedPayload, d.now())
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("S3 request failed: %w", err)
+	}
+	if resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, storage.ErrNotFound
+	}
+	var apiErr apiError
+	xml.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&apiErr)
+	if apiErr.Code == "" {
+		apiErr.Code = resp.Status
+	}
+	return nil, fmt.Errorf("S3 %s %s failed: %s %s", method, key, apiErr.Code, apiErr.Message)
+}
+
+// discard sends a request whose response body is not needed
+func (d *Driver) discard(ctx context.Context, method, key string, query url.Values, header http.Header, body io.Reader, size int64) error {
+	resp, err := d.do(ctx, method, key, query, header, body, size)
+	if err != nil {
+		return err
+	}
+	io.Copy(io.Discard, resp.Body)
+	return resp.Body.Close()
+}
+
+// CreateFolder stores a folder marker. Parent folders need no markers as
+// they are implied by the key.
+func (d *Driver) CreateFolder(ctx context.Context, folderPath string) error {
+	clean, err := storage.CleanPath(folderPath)
+	if err != nil {
+		return err
+	}
+	if clean == "" {
+		return nil
+	}
+	return d.discard(ctx, http.MethodPut, d.folderKey(clean), nil, nil, strings.NewReader(""), 0)
+}
+
+// Upload stores a file. Content that cannot seek is spooled to a temporary
+// file first, as S3 needs the length up front.
+func (d *Driver) Upload(ctx context.Context, content io.Reader, filePath string) (*storage.Object, error) {
+	clean, err := storage.CleanPath(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if clean == "" {
+		return nil, storage.ErrInvalidPath
+	}
+
+	body, size, cleanup, err := sized(content)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	contentType := mime.TypeByExtension(path.Ext(clean))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	header := http.Header{"Content-Type": {contentType}}
+	if err := d.discard(ctx, http.MethodPut, d.key(clean), nil, header, body, size); err != nil {
+		return nil, err
+	}
+	return d.Metadata(ctx, clean)
+}
+
+// sized returns content with its remaining length
+func sized(content io.Reader) (io.Reader, int64, func(), error) {
+	if seeker, ok := content.(io.Seeker); ok {
+		start, err := seeker.Seek(0, io.SeekCurrent)
+		if err == nil {
+			end, err := seeker.Seek(0, io.SeekEnd)
+			if err == nil {
+				if _, err := seeker.Seek(start, io.SeekStart); err == nil {
+					return content, end - start, func() {}, nil
+				}
+			}
+		}
+	}
+
+	spool, err := os.CreateTemp("", "s3-upload-*")
+	if err != nil {
+		return nil, 0, nil, fmt.Errorf("failed to buffer upload: %w", err)
+	}
+	cleanup := func() {
+		spool.Close()
+		os.Remove(spool.Name())
+	}
+	size, err := io.Copy(spool, content)
+	if err == nil {
+		_, err = spool.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		cleanup()
+		return nil, 0, nil, fmt.Errorf("failed to buffer upload: %w", err)
+	}
+	return spool, size, cleanup, nil
+}
+
+// listResult is a page of a ListObjectsV2 response
+type listResult struct {
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+	Contents              []struct {
+		Key          string    `xml:"Key"`
+		LastModified time.Time `xml:"LastModified"`
+		ETag         string    `xml:"ETag"`
+		Size         int64     `xml:"Size"`
+	} `xml:"Contents"`
+	CommonPrefixes []struct {
+		Prefix string `xml:"Prefix"`
+	} `xml:"CommonPrefixes"`
+}
+
+// listAll lists every key below a prefix, following continuation tokens
+func (d *Driver) listAll(ctx context.Context, prefix, delimiter string) (*listResult, error) {
+	all := &listResult{}
+	token := ""
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+		if delimiter != "" {
+			query.Set("delimiter", delimiter)
+		}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		if d.pageSize > 0 {
+			query.Set("max-keys", strconv.Itoa(d.pageSize))
+		}
+
+		resp, err := d.do(ctx, http.MethodGet, "", query, nil, nil, 0)
+		if err != nil {
+			return nil, err
+		}
+		var page listResult
+		err = xml.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read S3 listing: %w", err)
+		}
+
+		all.Contents = append(all.Contents, page.Contents...)
+		all.CommonPrefixes = append(all.CommonPrefixes, page.CommonPrefixes...)
+		if !page.IsTruncated || page.NextContinuationToken == "" {
+			return all, nil
+		}
+		token = page.NextContinuationToken
+	}
+}
+
+// List returns the files and folders in a folder
+func (d *Driver) List(ctx context.Context, folderPath string, recursive bool) ([]storage.Object, error) {
+	clean, err := storage.CleanPath(folderPath)
+	if err != nil {
+		return nil, err
+	}
+	prefix := d.folderKey(clean)
+	delimiter := "/"
+	if recursive {
+		delimiter = ""
+	}
+	listed, err := d.listAll(ctx, prefix, delimiter)
+	if err != nil {
+		return nil, err
+	}
+
+	found := clean == ""
+	objects := []storage.Object{}
+	for _, c := range listed.Contents {
+		found = true
+		if c.Key == prefix {
+			continue
+		}
+		p := d.pathOf(c.Key)
+		object := storage.Object{Name: path.Base(p), Path: p, ModifiedTime: c.LastModified.UTC()}
+		if strings.HasSuffix(c.Key, "/") {
+			object.IsFolder = true
+		} else {
+			object.Size = c.Size
+			object.Rev = strings.Trim(c.ETag, `"`)
+		}
+		objects = append(objects, object)
+	}
+	for _, cp := range listed.CommonPrefixes {
+		found = true
+		p := d.pathOf(cp.Prefix)
+		objects = append(objects, storage.Object{Name: path.Base(p), Path: p, IsFolder: true})
+	}
+	if !found {
+		return nil, storage.ErrNotFound
+	}
+
+	if recursive {
+		return storage.BuildTree(clean, objects), nil
+	}
+	storage.SortObjects(objects)
+	return objects, nil
+}
+
+// Metadata returns a file or folder
+func (d *Driver) Metadata(ctx context.Context, itemPath string) (*storage.Object, error) {
+	clean, err := storage.CleanPath(itemPath)
+	if err != nil {
+		return nil, err
+	}
+	if clean == "" {
+		return &storage.Object{IsFolder: true}, nil
+	}
+
+	resp, err := d.do(ctx, http.MethodHead, d.key(clean), nil, nil, nil, 0)
+	if err == nil {
+		resp.Body.Close()
+		return fileObject(clean, resp), nil
+	}
+	if err != storage.ErrNotFound {
+		return nil, err
+	}
+
+	// Not a file; a folder if anything is stored below it
+	query := url.Values{"list-type": {"2"}, "prefix": {d.folderKey(clean)}, "max-keys": {"1"}}
+	resp, err = d.do(ctx, http.MethodGet, "", query, nil, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	var page listResult
+	err = xml.NewDecoder(resp.Body).Decode(&page)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read S3 listing: %w", err)
+	}
+	if len(page.Contents) == 0 && len(page.CommonPrefixes) == 0 {
+		return nil, storage.ErrNotFound
+	}
+	return &storage.Object{Name: path.Base(clean), Path: clean, IsFolder: true}, nil
+}
+
+// fileObject describes a file from the response to a HEAD or GET of it
+func fileObject(p string, resp *http.Response) *storage.Object {
+	modified, _ := http.ParseTime(resp.Header.Get("Last-Modified"))
+	return &storage.Object{
+		Name:         path.Base(p),
+		Path:         p,
+		Size:         resp.ContentLength,
+		ModifiedTime: modified.UTC(),
+		Rev:          strings.Trim(resp.Header.Get("ETag"), `"`),
+	}
+}
+
+// Rename moves a file or folder by copying and deleting its objects
+func (d *Driver) Rename(ctx context.Context, fromPath, toPath string) error {
+	from, err := storage.CleanPath(fromPath)
+	if err != nil {
+		return err
+	}
+	to, err := storage.CleanPath(toPath)
+	if err != nil {
+		return err
+	}
+	if from == "" || to == "" {
+		return storage.ErrInvalidPath
+	}
+
+	if _, err := d.Metadata(ctx, to); err == nil {
+		return storage.ErrExists
+	} else if err != storage.ErrNotFound {
+		return err
+	}
+	source, err := d.Metadata(ctx, from)
+	if err != nil {
+		return err
+	}
+	if !source.IsFolder {
+		return d.move(ctx, d.key(from), d.key(to))
+	}
+
+	listed, err := d.listAll(ctx, d.folderKey(from), "")
+	if err != nil {
+		return err
+	}
+	for _, c := range listed.Contents {
+		if err := d.move(ctx, c.Key, d.folderKey(to)+strings.TrimPrefix(c.Key, d.folderKey(from))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// move copies an object to a new key and deletes the original
+func (d *Driver) move(ctx context.Context, fromKey, toKey string) error {
+	header := http.Header{"X-Amz-Copy-Source": {uriEncode("/"+d.bucket+"/"+fromKey, true)}}
+	if err := d.discard(ctx, http.MethodPut, toKey, nil, header, strings.NewReader(""), 0); err != nil {
+		return err
+	}
+	return d.discard(ctx, http.MethodDelete, fromKey, nil, nil, nil, 0)
+}
+
+// Delete removes a file, or every object below a folder
+func (d *Driver) Delete(ctx context.Context, itemPath string) error {
+	clean, err := storage.CleanPath(itemPath)
+	if err != nil {
+		return err
+	}
+	if clean == "" {
+		return storage.ErrInvalidPath
+	}
+
+	object, err := d.Metadata(ctx, clean)
+	if err != nil {
+		return err
+	}
+	if !object.IsFolder {
+		return d.discard(ctx, http.MethodDelete, d.key(clean), nil, nil, nil, 0)
+	}
+
+	listed, err := d.listAll(ctx, d.folderKey(clean), "")
+	if err != nil {
+		return err
+	}
+	for _, c := range listed.Contents {
+		if err := d.discard(ctx, http.MethodDelete, c.Key, nil, nil, nil, 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Open reads a file
+func (d *Driver) Open(ctx context.Context, filePath string) (io.ReadCloser, *storage.Object, error) {
+	clean, err := storage.CleanPath(filePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	if clean == "" {
+		return nil, nil, storage.ErrNotFound
+	}
+	resp, err := d.do(ctx, http.MethodGet, d.key(clean), nil, nil, nil, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	return resp.Body, fileObject(clean, resp), nil
+}
+
+// ShareLink returns a presigned link to a file that lasts the share
+// lifetime
+func (d *Driver) ShareLink(ctx context.Context, itemPath string) (string, error) {
+	object, err := d.Metadata(ctx, itemPath)
+	if err != nil {
+		return "", err
+	}
+	if object.IsFolder {
+		return "", storage.ErrUnsupported
+	}
+	return d.signer.presign(http.MethodGet, d.objectURL(d.key(object.Path), nil), d.shareTTL, d.now()), nil
+}
+
+// TemporaryLink returns a presigned download link to a file
+func (d *Driver) TemporaryLink(ctx context.Context, filePath string) (string, *storage.Object, error) {
+	object, err := d.Metadata(ctx, filePath)
+	if err != nil {
+		return "", nil, err
+	}
+	if object.IsFolder {
+		return "", nil, storage.ErrNotFound
+	}
+	return d.signer.presign(http.MethodGet, d.objectURL(d.key(object.Path), nil), d.linkTTL, d.now()), object, nil
+}
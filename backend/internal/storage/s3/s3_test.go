@@ -0,0 +1,303 @@
+package s3
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"backend/internal/storage"
+)
+
+// fakeS3 serves the subset of the S3 API the driver uses, for one bucket
+// addressed in the path
+type fakeS3 struct {
+	mu      sync.Mutex
+	bucket  string
+	objects map[string][]byte
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=") && r.URL.Query().Get("X-Amz-Signature") == "" {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	key, ok := strings.CutPrefix(r.URL.Path, "/"+f.bucket+"/")
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodGet && r.URL.Query().Get("list-type") == "2":
+		f.list(w, r.URL.Query())
+	case r.Method == http.MethodPut && r.Header.Get("X-Amz-Copy-Source") != "":
+		source, _ := url.PathUnescape(r.Header.Get("X-Amz-Copy-Source"))
+		data, ok := f.objects[strings.TrimPrefix(source, "/"+f.bucket+"/")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		f.objects[key] = data
+	case r.Method == http.MethodPut:
+		data, _ := io.ReadAll(r.Body)
+		if int64(len(data)) != r.ContentLength {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f.objects[key] = data
+	case r.Method == http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodGet, r.Method == http.MethodHead:
+		data, ok := f.objects[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("ETag", fmt.Sprintf(`"%x"`, len(data)))
+		w.Header().Set("Last-Modified", time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC).Format(http.TimeFormat))
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		w.Write(data)
+	}
+}
+
+func (f *fakeS3) list(w http.ResponseWriter, query url.Values) {
+	prefix, delimiter := query.Get("prefix"), query.Get("delimiter")
+	keys := []string{}
+	for key := range f.objects {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	type content struct {
+		Key          string
+		LastModified string
+		ETag         string
+		Size         int
+	}
+	type commonPrefix struct{ Prefix string }
+	result := struct {
+		XMLName               xml.Name `xml:"ListBucketResult"`
+		IsTruncated           bool
+		NextContinuationToken string `xml:",omitempty"`
+		Contents              []content
+		CommonPrefixes        []commonPrefix
+	}{}
+
+	start, _ := strconv.Atoi(query.Get("continuation-token"))
+	limit, _ := strconv.Atoi(query.Get("max-keys"))
+	seen := map[string]bool{}
+	for i := start; i < len(keys); i++ {
+		if limit > 0 && len(result.Contents)+len(result.CommonPrefixes) == limit {
+			result.IsTruncated = true
+			result.NextContinuationToken = strconv.Itoa(i)
+			break
+		}
+		key := keys[i]
+		if delimiter != "" {
+			if j := strings.Index(key[len(prefix):], delimiter); j >= 0 && len(prefix)+j+1 < len(key) {
+				p := key[:len(prefix)+j+1]
+				if !seen[p] {
+					seen[p] = true
+					result.CommonPrefixes = append(result.CommonPrefixes, commonPrefix{p})
+				}
+				continue
+			}
+		}
+		result.Contents = append(result.Contents, content{
+			Key:          key,
+			LastModified: "2025-05-01T00:00:00.000Z",
+			ETag:         fmt.Sprintf(`"%x"`, len(f.objects[key])),
+			Size:         len(f.objects[key]),
+		})
+	}
+	xml.NewEncoder(w).Encode(result)
+}
+
+func newFakeDriver(t *testing.T, prefix string) *Driver {
+	t.Helper()
+	server := httptest.NewServer(&fakeS3{bucket: "sops", objects: map[string][]byte{}})
+	t.Cleanup(server.Close)
+
+	d, err := New(Config{
+		Endpoint:        server.URL,
+		Region:          "af-south-1",
+		Bucket:          "sops",
+		AccessKeyID:     "key",
+		SecretAccessKey: "secret",
+		Prefix:          prefix,
+		ForcePathStyle:  true,
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	d.pageSize = 2
+	return d
+}
+
+func names(objects []storage.Object) []string {
+	out := []string{}
+	for _, o := range objects {
+		out = append(out, o.Path)
+		out = append(out, names(o.Children)...)
+	}
+	return out
+}
+
+// exerciseDriver runs the same checks against the fake and a real server
+func exerciseDriver(t *testing.T, d *Driver) {
+	ctx := context.Background()
+
+	// A reader that cannot seek is spooled before upload
+	if _, err := d.Upload(ctx, io.MultiReader(strings.NewReader("iron")), "SOPs/Anemia/Iron deficiency.pdf"); err != nil {
+		t.Fatalf("Upload() error = %v", err)
+	}
+	for _, p := range []string{"SOPs/Anemia/Old/2019.docx", "SOPs/Index.pdf"} {
+		if _, err := d.Upload(ctx, strings.NewReader(p), p); err != nil {
+			t.Fatalf("Upload(%s) error = %v", p, err)
+		}
+	}
+	if err := d.CreateFolder(ctx, "SOPs/Empty"); err != nil {
+		t.Fatalf("CreateFolder() error = %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		folder    string
+		recursive bool
+		want      []string
+		wantErr   error
+	}{
+		{name: "recursive", folder: "SOPs", recursive: true, want: []string{"SOPs/Anemia", "SOPs/Anemia/Iron deficiency.pdf", "SOPs/Anemia/Old", "SOPs/Anemia/Old/2019.docx", "SOPs/Empty", "SOPs/Index.pdf"}},
+		{name: "one level", folder: "SOPs", want: []string{"SOPs/Anemia", "SOPs/Empty", "SOPs/Index.pdf"}},
+		{name: "empty folder", folder: "SOPs/Empty", want: []string{}},
+		{name: "missing folder", folder: "SOPs/Missing", wantErr: storage.ErrNotFound},
+		{name: "invalid path", folder: "SOPs/../x", wantErr: storage.ErrInvalidPath},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objects, err := d.List(ctx, tt.folder, tt.recursive)
+			if err != tt.wantErr {
+				t.Fatalf("List() error = %v, want %v", err, tt.wantErr)
+			}
+			if got := names(objects); err == nil && strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("List() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	file, err := d.Metadata(ctx, "SOPs/Anemia/Iron deficiency.pdf")
+	if err != nil || file.IsFolder || file.Size != 4 || file.Rev == "" {
+		t.Errorf("Metadata() of a file = %+v, %v", file, err)
+	}
+	folder, err := d.Metadata(ctx, "SOPs/Anemia")
+	if err != nil || !folder.IsFolder {
+		t.Errorf("Metadata() of a folder = %+v, %v", folder, err)
+	}
+
+	if err := d.Rename(ctx, "SOPs/Anemia", "SOPs/Anaemia"); err != nil {
+		t.Fatalf("Rename() error = %v", err)
+	}
+	if _, err := d.Metadata(ctx, "SOPs/Anaemia/Old/2019.docx"); err != nil {
+		t.Errorf("renamed folder lost its files: %v", err)
+	}
+	if _, err := d.Metadata(ctx, "SOPs/Anemia"); err != storage.ErrNotFound {
+		t.Errorf("renamed folder still exists: %v", err)
+	}
+	if err := d.Rename(ctx, "SOPs/Anaemia", "SOPs/Empty"); err != storage.ErrExists {
+		t.Errorf("Rename() onto an existing folder error = %v, want %v", err, storage.ErrExists)
+	}
+	if _, err := d.ShareLink(ctx, "SOPs/Empty"); err != storage.ErrUnsupported {
+		t.Errorf("ShareLink() of a folder error = %v, want %v", err, storage.ErrUnsupported)
+	}
+
+	link, _, err := d.TemporaryLink(ctx, "SOPs/Anaemia/Iron deficiency.pdf")
+	if err != nil {
+		t.Fatalf("TemporaryLink() error = %v", err)
+	}
+	resp, err := http.Get(link)
+	if err != nil {
+		t.Fatalf("GET link error = %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || string(body) != "iron" {
+		t.Errorf("GET link = %d %q, want 200 %q", resp.StatusCode, body, "iron")
+	}
+
+	content, file, err := d.Open(ctx, "SOPs/Anaemia/Iron deficiency.pdf")
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	data, _ := io.ReadAll(content)
+	content.Close()
+	if string(data) != "iron" || file.Size != 4 || file.Rev == "" {
+		t.Errorf("Open() = %q, %+v", data, file)
+	}
+	if _, _, err := d.Open(ctx, "SOPs/Anaemia"); err != storage.ErrNotFound {
+		t.Errorf("Open() of a folder error = %v, want %v", err, storage.ErrNotFound)
+	}
+
+	if err := d.Delete(ctx, "SOPs/Index.pdf"); err != nil {
+		t.Fatalf("Delete() of a file error = %v", err)
+	}
+	if err := d.Delete(ctx, "SOPs/Anaemia"); err != nil {
+		t.Fatalf("Delete() of a folder error = %v", err)
+	}
+	objects, err := d.List(ctx, "SOPs", true)
+	if got := names(objects); err != nil || strings.Join(got, ",") != "SOPs/Empty" {
+		t.Errorf("List() after Delete() = %v, %v, want [SOPs/Empty]", got, err)
+	}
+	if err := d.Delete(ctx, "SOPs/Anaemia"); err != storage.ErrNotFound {
+		t.Errorf("Delete() of a missing folder error = %v, want %v", err, storage.ErrNotFound)
+	}
+}
+
+func TestDriver(t *testing.T) {
+	for _, prefix := range []string{"", "tenant/library"} {
+		t.Run("prefix "+prefix, func(t *testing.T) {
+			exerciseDriver(t, newFakeDriver(t, prefix))
+		})
+	}
+}
+
+// TestDriverMinIO runs against a real server when S3_TEST_ENDPOINT is set,
+// for example a local MinIO:
+//
+//	S3_TEST_ENDPOINT=http://localhost:9000 S3_TEST_BUCKET=test \
+//	S3_TEST_ACCESS_KEY_ID=minioadmin S3_TEST_SECRET_ACCESS_KEY=minioadmin go test ./internal/storage/s3
+func TestDriverMinIO(t *testing.T) {
+	endpoint := os.Getenv("S3_TEST_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("S3_TEST_ENDPOINT is not set")
+	}
+	d, err := New(Config{
+		Endpoint:        endpoint,
+		Region:          "us-east-1",
+		Bucket:          os.Getenv("S3_TEST_BUCKET"),
+		AccessKeyID:     os.Getenv("S3_TEST_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("S3_TEST_SECRET_ACCESS_KEY"),
+		Prefix:          "driver-test-" + strconv.FormatInt(time.Now().UnixNano(), 36),
+		ForcePathStyle:  true,
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	exerciseDriver(t, d)
+}
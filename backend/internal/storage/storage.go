@@ -0,0 +1,193 @@
+// Package storage defines the file storage that document libraries and
+// registry submissions are kept in, so the same features can run on
+// Dropbox, a local disk or an S3-compatible object store.
+//
+// Paths are relative to the driver's root and use forward slashes, for
+// example "SOPs/Anemia/Iron.pdf". Drivers never resolve a path outside
+// their root.
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNotFound      = errors.New("file or folder not found")
+	ErrExists        = errors.New("a file or folder with this name already exists")
+	ErrNotConfigured = errors.New("storage is not configured")
+	ErrUnsupported   = errors.New("operation is not supported by this storage")
+	ErrInvalidPath   = errors.New("invalid storage path")
+)
+
+// Object is a file or folder in storage
+type Object struct {
+	Name         string
+	Path         string // Relative to the driver's root
+	Size         int64
+	ModifiedTime time.Time
+	IsFolder     bool
+	Rev          string   // Changes whenever the file's content does
+	Children     []Object // Set on folders in recursive listings
+}
+
+// Driver stores files and folders
+type Driver interface {
+	// IsConfigured reports whether the driver can be used
+	IsConfigured() bool
+
+	// CreateFolder creates a folder and any missing parents. An existing
+	// folder is not an error.
+	CreateFolder(ctx context.Context, folderPath string) error
+
+	// Upload stores a file, replacing any file at the path and creating
+	// missing parent folders
+	Upload(ctx context.Context, content io.Reader, filePath string) (*Object, error)
+
+	// List returns the files and folders in a folder, with their children
+	// nested when recursive. It returns ErrNotFound if the folder does not
+	// exist.
+	List(ctx context.Context, folderPath string, recursive bool) ([]Object, error)
+
+	// Metadata returns a file or folder, or ErrNotFound
+	Metadata(ctx context.Context, itemPath string) (*Object, error)
+
+	// Rename moves a file or folder. It returns ErrExists if the
+	// destination exists.
+	Rename(ctx context.Context, fromPath, toPath string) error
+
+	// Delete removes a file, or a folder and everything in it. It returns
+	// ErrNotFound if nothing is at the path.
+	Delete(ctx context.Context, itemPath string) error
+
+	// Open reads a file along with the file it serves, or returns
+	// ErrNotFound. The caller must close the content.
+	Open(ctx context.Context, filePath string) (io.ReadCloser, *Object, error)
+
+	// ShareLink returns a long-lived link for viewing a file or folder.
+	// Drivers that cannot share folders return ErrUnsupported.
+	ShareLink(ctx context.Context, itemPath string) (string, error)
+
+	// TemporaryLink returns a short-lived download link for a file along
+	// with the file it serves, or ErrNotFound
+	TemporaryLink(ctx context.Context, filePath string) (string, *Object, error)
+}
+
+// CleanPath normalises a path relative to a driver's root. Empty, "." and
+// ".." components are rejected rather than resolved, so a path can never
+// leave the root. The root itself is "".
+func CleanPath(p string) (string, error) {
+	p = strings.Trim(p, "/")
+	if p == "" {
+		return "", nil
+	}
+	for _, name := range strings.Split(p, "/") {
+		if name == "" || name == "." || name == ".." || strings.ContainsAny(name, "\\\x00") {
+			return "", ErrInvalidPath
+		}
+	}
+	return p, nil
+}
+
+// BuildTree nests a flat list of objects, as returned by a recursive scan,
+// under their parent folders. Folders implied by a file's path but not
+// listed themselves are added. Objects are sorted by name, ignoring case.
+func BuildTree(root string, objects []Object) []Object {
+	byPath := map[string]*Object{}
+	children := map[string][]string{}
+
+	var ensureFolder func(p string)
+	ensureFolder = func(p string) {
+		if p == root || byPath[p] != nil {
+			return
+		}
+		byPath[p] = &Object{Name: path.Base(p), Path: p, IsFolder: true}
+		parent := parentOf(p, root)
+		children[parent] = append(children[parent], p)
+		ensureFolder(parent)
+	}
+
+	for i := range objects {
+		object := objects[i]
+		if object.Path == root {
+			continue
+		}
+		if existing := byPath[object.Path]; existing != nil {
+			// A folder seen first as a file's parent
+			*existing = object
+			continue
+		}
+		byPath[object.Path] = &object
+		parent := parentOf(object.Path, root)
+		children[parent] = append(children[parent], object.Path)
+		ensureFolder(parent)
+	}
+
+	var build func(parent string) []Object
+	build = func(parent string) []Object {
+		out := []Object{}
+		for _, p := range children[parent] {
+			object := *byPath[p]
+			if object.IsFolder {
+				object.Children = build(p)
+			}
+			out = append(out, object)
+		}
+		SortObjects(out)
+		return out
+	}
+	return build(root)
+}
+
+// parentOf returns the parent folder of p, stopping at root
+func parentOf(p, root string) string {
+	parent := path.Dir(p)
+	if parent == "." || len(parent) < len(root) {
+		return root
+	}
+	return parent
+}
+
+// SortObjects sorts objects by name, ignoring case
+func SortObjects(objects []Object) {
+	sort.Slice(objects, func(i, j int) bool {
+		return strings.ToLower(objects[i].Name) < strings.ToLower(objects[j].Name)
+	})
+}
+
+// Unconfigured stands in for a driver that is not set up. IsConfigured
+// reports false and every operation returns ErrNotConfigured.
+type Unconfigured struct{}
+
+func (Unconfigured) IsConfigured() bool { return false }
+
+func (Unconfigured) CreateFolder(context.Context, string) error { return ErrNotConfigured }
+
+func (Unconfigured) Upload(context.Context, io.Reader, string) (*Object, error) {
+	return nil, ErrNotConfigured
+}
+
+func (Unconfigured) List(context.Context, string, bool) ([]Object, error) {
+	return nil, ErrNotConfigured
+}
+
+func (Unconfigured) Metadata(context.Context, string) (*Object, error) { return nil, ErrNotConfigured }
+
+func (Unconfigured) Rename(context.Context, string, string) error { return ErrNotConfigured }
+
+func (Unconfigured) Delete(context.Context, string) error { return ErrNotConfigured }
+
+func (Unconfigured) Open(context.Context, string) (io.ReadCloser, *Object, error) {
+	return nil, nil, ErrNotConfigured
+}
+
+func (Unconfigured) ShareLink(context.Context, string) (string, error) { return "", ErrNotConfigured }
+
+func (Unconfigured) TemporaryLink(context.Context, string) (string, *Object, error) {
+	return "", nil, ErrNotConfigured
+}
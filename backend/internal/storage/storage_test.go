@@ -0,0 +1,91 @@
+package storage
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCleanPath(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		want    string
+		wantErr error
+	}{
+		{name: "root", path: "", want: ""},
+		{name: "slash", path: "/", want: ""},
+		{name: "trims slashes", path: "/SOPs/Anemia/", want: "SOPs/Anemia"},
+		{name: "keeps spaces", path: "SOPs/Iron deficiency.pdf", want: "SOPs/Iron deficiency.pdf"},
+		{name: "parent component", path: "SOPs/../secrets", wantErr: ErrInvalidPath},
+		{name: "dot component", path: "SOPs/./Anemia", wantErr: ErrInvalidPath},
+		{name: "empty component", path: "SOPs//Anemia", wantErr: ErrInvalidPath},
+		{name: "backslash", path: "SOPs\\..\\secrets", wantErr: ErrInvalidPath},
+		{name: "nul byte", path: "SOPs/a\x00b", wantErr: ErrInvalidPath},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CleanPath(tt.path)
+			if err != tt.wantErr {
+				t.Fatalf("CleanPath() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("CleanPath() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func flatten(objects []Object, depth int) []string {
+	out := []string{}
+	for _, o := range objects {
+		out = append(out, strings.Repeat(" ", depth)+o.Name)
+		out = append(out, flatten(o.Children, depth+1)...)
+	}
+	return out
+}
+
+func TestBuildTree(t *testing.T) {
+	tests := []struct {
+		name    string
+		root    string
+		objects []Object
+		want    []string
+	}{
+		{
+			name: "nests under listed folders",
+			root: "SOPs",
+			objects: []Object{
+				{Name: "SOPs", Path: "SOPs", IsFolder: true},
+				{Name: "Iron.pdf", Path: "SOPs/Anemia/Iron.pdf"},
+				{Name: "Anemia", Path: "SOPs/Anemia", IsFolder: true},
+				{Name: "b12.pdf", Path: "SOPs/Anemia/b12.pdf"},
+				{Name: "Index.pdf", Path: "SOPs/Index.pdf"},
+			},
+			want: []string{"Anemia", " b12.pdf", " Iron.pdf", "Index.pdf"},
+		},
+		{
+			name: "adds implied folders",
+			root: "",
+			objects: []Object{
+				{Name: "Form.pdf", Path: "Registry/2025/Form.pdf"},
+			},
+			want: []string{"Registry", " 2025", "  Form.pdf"},
+		},
+		{
+			name:    "empty",
+			root:    "SOPs",
+			objects: nil,
+			want:    []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := flatten(BuildTree(tt.root, tt.objects), 0)
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("BuildTree() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
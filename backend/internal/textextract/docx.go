@@ -0,0 +1,76 @@
+package textextract
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+)
+
+// extractDOCX reads the paragraphs of a Word document's main part
+func extractDOCX(data []byte) (string, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", ErrCorruptDocument
+	}
+
+	for _, file := range archive.File {
+		if file.Name != "word/document.xml" {
+			continue
+		}
+		part, err := file.Open()
+		if err != nil {
+			return "", ErrCorruptDocument
+		}
+		defer part.Close()
+		return wordprocessingText(io.LimitReader(part, maxDecompressedSize))
+	}
+
+	return "", ErrCorruptDocument
+}
+
+// wordprocessingText walks WordprocessingML, keeping the contents of text
+// runs and turning paragraphs, breaks and tabs into whitespace
+func wordprocessingText(r io.Reader) (string, error) {
+	decoder := xml.NewDecoder(r)
+	var b strings.Builder
+	inText := false
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return b.String(), nil
+		}
+		if err != nil {
+			return "", ErrCorruptDocument
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "t":
+				inText = true
+			case "tab":
+				b.WriteByte('\t')
+			case "br", "cr":
+				b.WriteByte('\n')
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				b.WriteByte('\n')
+			}
+		case xml.CharData:
+			if inText {
+				b.Write(t)
+			}
+		}
+
+		if b.Len() > MaxTextLength {
+			return b.String(), nil
+		}
+	}
+}
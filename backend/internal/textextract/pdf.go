@@ -0,0 +1,339 @@
+package textextract
+
+import (
+	"bytes"
+	"compress/zlib"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	pdfHeader      = []byte("%PDF-")
+	pdfStreamStart = regexp.MustCompile(`stream\r?\n`)
+	pdfEndStream   = []byte("endstream")
+)
+
+// extractPDF recovers the text shown by a PDF's content streams. Streams
+// are found by scanning for stream/endstream rather than by resolving the
+// object graph, which also copes with files whose xref table is damaged.
+func extractPDF(data []byte) (string, error) {
+	if !bytes.HasPrefix(bytes.TrimLeft(data, " \t\r\n"), pdfHeader) {
+		return "", ErrCorruptDocument
+	}
+
+	var b strings.Builder
+	offset := 0
+	for b.Len() < MaxTextLength {
+		loc := pdfStreamStart.FindIndex(data[offset:])
+		if loc == nil {
+			break
+		}
+		start := offset + loc[1]
+		end := bytes.Index(data[start:], pdfEndStream)
+		if end < 0 {
+			break
+		}
+		dict := streamDictionary(data[offset : offset+loc[0]])
+		content := bytes.TrimRight(data[start:start+end], "\r\n")
+		offset = start + end + len(pdfEndStream)
+
+		content, ok := decodeStream(dict, content)
+		if !ok {
+			continue
+		}
+		contentStreamText(content, &b)
+	}
+
+	return b.String(), nil
+}
+
+// streamDictionary returns the dictionary written before a stream keyword
+func streamDictionary(before []byte) []byte {
+	if i := bytes.LastIndex(before, []byte(" obj")); i >= 0 {
+		return before[i:]
+	}
+	return before
+}
+
+// decodeStream undoes a stream's filter. Only unfiltered and Flate streams
+// can hold text this package understands; images, fonts and metadata
+// streams are skipped.
+func decodeStream(dict, content []byte) ([]byte, bool) {
+	for _, skip := range []string{"/Image", "/FontFile", "/XML", "/XRef", "/ObjStm", "/Length1"} {
+		if bytes.Contains(dict, []byte(skip)) {
+			return nil, false
+		}
+	}
+
+	if !bytes.Contains(dict, []byte("/Filter")) {
+		return content, true
+	}
+	if !bytes.Contains(dict, []byte("/FlateDecode")) || bytes.Contains(dict, []byte("/DecodeParms")) {
+		return nil, false
+	}
+
+	r, err := zlib.NewReader(bytes.NewReader(content))
+	if err != nil {
+		return nil, false
+	}
+	defer r.Close()
+
+	// Truncated streams are common; keep whatever decompressed
+	decoded, _ := io.ReadAll(io.LimitReader(r, maxDecompressedSize))
+	return decoded, len(decoded) > 0
+}
+
+// contentStreamText interprets the text operators of a content stream,
+// writing the strings shown by Tj, TJ, ' and " and a newline wherever the
+// text position moves to a new line
+func contentStreamText(content []byte, b *strings.Builder) {
+	var operands [][]byte
+	var array [][]byte
+	inArray, inText := false, false
+	start := b.Len()
+
+	s := &pdfScanner{data: content}
+	for {
+		token, kind := s.next()
+		if kind == pdfEOF {
+			break
+		}
+
+		switch kind {
+		case pdfString:
+			if inArray {
+				array = append(array, token)
+			} else {
+				operands = append(operands, token)
+			}
+		case pdfNumber:
+			// Large negative TJ adjustments separate words
+			if inArray {
+				if n, err := strconv.ParseFloat(string(token), 64); err == nil && n < -200 {
+					array = append(array, []byte(" "))
+				}
+			}
+		case pdfArrayStart:
+			inArray, array = true, nil
+		case pdfArrayEnd:
+			inArray = false
+		case pdfOperator:
+			switch string(token) {
+			case "BT":
+				inText = true
+			case "ET":
+				inText = false
+				b.WriteByte('\n')
+			case "Tj", "'", "\"":
+				if inText && len(operands) > 0 {
+					if string(token) != "Tj" {
+						b.WriteByte('\n')
+					}
+					writePDFString(b, operands[len(operands)-1])
+				}
+			case "TJ":
+				if inText {
+					for _, part := range array {
+						writePDFString(b, part)
+					}
+				}
+				array = nil
+			case "Td", "TD", "T*", "Tm":
+				if inText && b.Len() > start {
+					b.WriteByte('\n')
+				}
+			}
+			operands = operands[:0]
+		}
+
+		if b.Len() > MaxTextLength {
+			return
+		}
+	}
+}
+
+// writePDFString writes a decoded string operand, dropping strings that are
+// mostly unprintable, which is how glyph IDs of CID fonts appear
+func writePDFString(b *strings.Builder, raw []byte) {
+	printable := 0
+	for _, c := range raw {
+		if c >= 0x20 && c != 0x7f {
+			printable++
+		}
+	}
+	if len(raw) == 0 || printable*4 < len(raw)*3 {
+		return
+	}
+	b.WriteString(latin1(raw))
+}
+
+type pdfTokenKind int
+
+const (
+	pdfEOF pdfTokenKind = iota
+	pdfString
+	pdfNumber
+	pdfArrayStart
+	pdfArrayEnd
+	pdfOperator
+	pdfOther
+)
+
+// pdfScanner tokenizes a content stream
+type pdfScanner struct {
+	data []byte
+	pos  int
+}
+
+func (s *pdfScanner) next() ([]byte, pdfTokenKind) {
+	for s.pos < len(s.data) {
+		c := s.data[s.pos]
+		switch {
+		case isPDFSpace(c):
+			s.pos++
+		case c == '%':
+			for s.pos < len(s.data) && s.data[s.pos] != '\n' && s.data[s.pos] != '\r' {
+				s.pos++
+			}
+		case c == '(':
+			return s.literalString(), pdfString
+		case c == '<' && s.pos+1 < len(s.data) && s.data[s.pos+1] == '<':
+			s.pos += 2
+			return nil, pdfOther
+		case c == '>' && s.pos+1 < len(s.data) && s.data[s.pos+1] == '>':
+			s.pos += 2
+			return nil, pdfOther
+		case c == '<':
+			return s.hexString(), pdfString
+		case c == '[':
+			s.pos++
+			return nil, pdfArrayStart
+		case c == ']':
+			s.pos++
+			return nil, pdfArrayEnd
+		case c == '/':
+			s.pos++
+			s.word()
+			return nil, pdfOther
+		case c == '+' || c == '-' || c == '.' || (c >= '0' && c <= '9'):
+			return s.word(), pdfNumber
+		default:
+			word := s.word()
+			if len(word) == 0 {
+				// A lone delimiter such as ')' or '{'
+				s.pos++
+				continue
+			}
+			return word, pdfOperator
+		}
+	}
+	return nil, pdfEOF
+}
+
+func (s *pdfScanner) word() []byte {
+	start := s.pos
+	for s.pos < len(s.data) && !isPDFSpace(s.data[s.pos]) && !isPDFDelimiter(s.data[s.pos]) {
+		s.pos++
+	}
+	return s.data[start:s.pos]
+}
+
+// literalString reads a (string) with escapes and balanced parentheses
+func (s *pdfScanner) literalString() []byte {
+	s.pos++
+	var out []byte
+	depth := 1
+	for s.pos < len(s.data) {
+		c := s.data[s.pos]
+		s.pos++
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return out
+			}
+		case '\\':
+			if s.pos >= len(s.data) {
+				return out
+			}
+			e := s.data[s.pos]
+			s.pos++
+			switch e {
+			case 'n':
+				c = '\n'
+			case 'r':
+				c = '\r'
+			case 't':
+				c = '\t'
+			case 'b':
+				c = '\b'
+			case 'f':
+				c = '\f'
+			case '\r', '\n':
+				// Line continuation
+				if e == '\r' && s.pos < len(s.data) && s.data[s.pos] == '\n' {
+					s.pos++
+				}
+				continue
+			default:
+				if e >= '0' && e <= '7' {
+					n := int(e - '0')
+					for i := 0; i < 2 && s.pos < len(s.data) && s.data[s.pos] >= '0' && s.data[s.pos] <= '7'; i++ {
+						n = n*8 + int(s.data[s.pos]-'0')
+						s.pos++
+					}
+					c = byte(n)
+				} else {
+					c = e
+				}
+			}
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+// hexString reads a <hex string>; an odd final digit is padded with zero
+func (s *pdfScanner) hexString() []byte {
+	s.pos++
+	var out []byte
+	var digits []byte
+	for s.pos < len(s.data) && s.data[s.pos] != '>' {
+		if v, ok := hexValue(s.data[s.pos]); ok {
+			digits = append(digits, v)
+		}
+		s.pos++
+	}
+	s.pos++
+	if len(digits)%2 == 1 {
+		digits = append(digits, 0)
+	}
+	for i := 0; i < len(digits); i += 2 {
+		out = append(out, digits[i]<<4|digits[i+1])
+	}
+	return out
+}
+
+func hexValue(c byte) (byte, bool) {
+	switch {
+	case c >= '0' && c <= '9':
+		return c - '0', true
+	case c >= 'a' && c <= 'f':
+		return c - 'a' + 10, true
+	case c >= 'A' && c <= 'F':
+		return c - 'A' + 10, true
+	}
+	return 0, false
+}
+
+func isPDFSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n' || c == '\f' || c == 0
+}
+
+func isPDFDelimiter(c byte) bool {
+	return strings.IndexByte("()<>[]{}/%", c) >= 0
+}
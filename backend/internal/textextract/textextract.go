@@ -0,0 +1,108 @@
+// Package textextract pulls plain text out of library documents so they can
+// be indexed for search.
+//
+// Only the standard library is used. Plain text and DOCX files are handled
+// completely; PDF support is best effort: text drawn with simple fonts in
+// uncompressed or Flate-compressed content streams is recovered, while text
+// in CID-keyed fonts (which needs the font's ToUnicode map) is skipped.
+package textextract
+
+import (
+	"errors"
+	"path"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	ErrUnsupportedFormat = errors.New("unsupported document format")
+	ErrCorruptDocument   = errors.New("document could not be read")
+)
+
+// MaxTextLength caps the extracted text, in bytes, so one large document
+// cannot exceed MongoDB's document size limit
+const MaxTextLength = 1 << 20
+
+// maxDecompressedSize bounds every decompressed part of a document
+const maxDecompressedSize = 64 << 20
+
+type extractor func(data []byte) (string, error)
+
+var extractors = map[string]extractor{
+	".txt":  extractPlain,
+	".md":   extractPlain,
+	".csv":  extractPlain,
+	".docx": extractDOCX,
+	".pdf":  extractPDF,
+}
+
+// Supported reports whether text can be extracted from a file with this name
+func Supported(name string) bool {
+	_, ok := extractors[strings.ToLower(path.Ext(name))]
+	return ok
+}
+
+// Extract returns the text of a document, chosen by its file extension.
+// Whitespace is collapsed and the result is truncated to MaxTextLength.
+func Extract(name string, data []byte) (string, error) {
+	extract, ok := extractors[strings.ToLower(path.Ext(name))]
+	if !ok {
+		return "", ErrUnsupportedFormat
+	}
+
+	text, err := extract(data)
+	if err != nil {
+		return "", err
+	}
+	return normalize(text), nil
+}
+
+// extractPlain decodes a text file as UTF-8, falling back to Latin-1
+func extractPlain(data []byte) (string, error) {
+	data = []byte(strings.TrimPrefix(string(data), "\ufeff"))
+	if utf8.Valid(data) {
+		return string(data), nil
+	}
+	return latin1(data), nil
+}
+
+// latin1 decodes bytes as ISO-8859-1
+func latin1(data []byte) string {
+	runes := make([]rune, len(data))
+	for i, b := range data {
+		runes[i] = rune(b)
+	}
+	return string(runes)
+}
+
+// normalize collapses runs of spaces, drops control characters and blank
+// lines, and truncates the text to MaxTextLength on a rune boundary
+func normalize(text string) string {
+	var b strings.Builder
+	space, newline := false, false
+	for _, r := range text {
+		switch {
+		case r == '\n' || r == '\r':
+			newline = true
+		case unicode.IsSpace(r):
+			space = true
+		case unicode.IsControl(r) || r == utf8.RuneError:
+			continue
+		default:
+			if b.Len() > 0 {
+				if newline {
+					b.WriteByte('\n')
+				} else if space {
+					b.WriteByte(' ')
+				}
+			}
+			space, newline = false, false
+			if b.Len()+utf8.RuneLen(r) > MaxTextLength {
+				return b.String()
+			}
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
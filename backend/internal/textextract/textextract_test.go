@@ -0,0 +1,137 @@
+package textextract
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func buildDOCX(t *testing.T, body string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	f, err := w.Create("word/document.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Fprintf(f, `<?xml version="1.0" encoding="UTF-8"?><w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>%s</w:body></w:document>`, body)
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func buildPDF(content string, compress bool) []byte {
+	stream := []byte(content)
+	dict := fmt.Sprintf("<< /Length %d >>", len(stream))
+	if compress {
+		var buf bytes.Buffer
+		zw := zlib.NewWriter(&buf)
+		zw.Write(stream)
+		zw.Close()
+		stream = buf.Bytes()
+		dict = fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>", len(stream))
+	}
+
+	var pdf bytes.Buffer
+	pdf.WriteString("%PDF-1.4\n1 0 obj\n<< /Type /Catalog >>\nendobj\n")
+	fmt.Fprintf(&pdf, "4 0 obj\n%s\nstream\n", dict)
+	pdf.Write(stream)
+	pdf.WriteString("\nendstream\nendobj\n")
+	pdf.WriteString("5 0 obj\n<< /Subtype /Image /Length 4 >>\nstream\n(no)\nendstream\nendobj\n%%EOF\n")
+	return pdf.Bytes()
+}
+
+func TestExtract(t *testing.T) {
+	pageContent := "BT /F1 12 Tf 72 700 Td (DIC protocol) Tj 0 -14 Td [(Fibrin)-250(ogen \\(low\\))] TJ ET\n" +
+		"BT <48656C6C6F> Tj T* (Line\\040two) ' ET"
+
+	tests := []struct {
+		name     string
+		fileName string
+		data     []byte
+		want     string
+		wantErr  error
+	}{
+		{
+			name:     "plain text with BOM and spacing",
+			fileName: "notes.TXT",
+			data:     []byte("\ufeffTransfusion   thresholds\r\n\r\n\tHb < 70"),
+			want:     "Transfusion thresholds\nHb < 70",
+		},
+		{
+			name:     "latin-1 text",
+			fileName: "legacy.txt",
+			data:     []byte{'c', 'a', 'f', 0xe9},
+			want:     "café",
+		},
+		{
+			name:     "docx paragraphs and tabs",
+			fileName: "sop.docx",
+			data: buildDOCX(t, `<w:p><w:r><w:t>Massive</w:t></w:r><w:r><w:t xml:space="preserve"> haemorrhage</w:t></w:r></w:p>`+
+				`<w:p><w:r><w:t>Step</w:t><w:tab/><w:t>one</w:t></w:r></w:p>`),
+			want: "Massive haemorrhage\nStep one",
+		},
+		{
+			name:     "uncompressed pdf",
+			fileName: "protocol.pdf",
+			data:     buildPDF(pageContent, false),
+			want:     "DIC protocol\nFibrin ogen (low)\nHello\nLine two",
+		},
+		{
+			name:     "flate compressed pdf",
+			fileName: "protocol.pdf",
+			data:     buildPDF(pageContent, true),
+			want:     "DIC protocol\nFibrin ogen (low)\nHello\nLine two",
+		},
+		{
+			name:     "pdf with cid glyph ids is skipped",
+			fileName: "cid.pdf",
+			data:     buildPDF("BT <0003001100240057> Tj ET", false),
+			want:     "",
+		},
+		{
+			name:     "not a pdf",
+			fileName: "fake.pdf",
+			data:     []byte("hello"),
+			wantErr:  ErrCorruptDocument,
+		},
+		{
+			name:     "not a docx",
+			fileName: "fake.docx",
+			data:     []byte("hello"),
+			wantErr:  ErrCorruptDocument,
+		},
+		{
+			name:     "unsupported extension",
+			fileName: "scan.png",
+			data:     []byte("hello"),
+			wantErr:  ErrUnsupportedFormat,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Extract(tt.fileName, tt.data)
+			if err != tt.wantErr {
+				t.Fatalf("Extract() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Extract() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractTruncates(t *testing.T) {
+	got, err := Extract("big.txt", []byte(strings.Repeat("é", MaxTextLength)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) > MaxTextLength {
+		t.Errorf("len = %d, want at most %d", len(got), MaxTextLength)
+	}
+}
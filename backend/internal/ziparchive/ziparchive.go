@@ -0,0 +1,209 @@
+// Package ziparchive streams a set of remote files into a ZIP archive.
+//
+// Files are fetched concurrently, with at most Workers in flight or waiting
+// to be written, while the archive itself is written sequentially in entry
+// order. Each fetched file is spooled to a temporary file so a slow client
+// does not hold a download open and memory use does not grow with file size.
+package ziparchive
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+var (
+	ErrTooLarge = errors.New("archive is larger than allowed")
+)
+
+// DefaultWorkers is the number of files fetched at once when Workers is unset
+const DefaultWorkers = 4
+
+// Entry is one file to add to an archive
+type Entry struct {
+	Path     string // Path within the archive, using forward slashes
+	Source   string // What the opener fetches, such as a storage path
+	Size     int64
+	Modified time.Time
+	Store    bool // Add the file uncompressed, for formats that are already compressed
+}
+
+// Opener opens the content of an entry. The caller closes the returned
+// reader.
+type Opener func(ctx context.Context, entry Entry) (io.ReadCloser, error)
+
+// Archive writes entries fetched with Open into a ZIP archive
+type Archive struct {
+	Open     Opener
+	Workers  int    // Files fetched at once; DefaultWorkers if unset
+	MaxBytes int64  // Cap on the total uncompressed size; none if unset
+	TempDir  string // Where fetched files are spooled; the system default if unset
+}
+
+// fetched is the outcome of fetching one entry
+type fetched struct {
+	spool *os.File
+	err   error
+}
+
+// Write fetches every entry and writes the archive to w. On error the
+// archive written so far is incomplete and must be discarded.
+func (a *Archive) Write(ctx context.Context, w io.Writer, entries []Entry) error {
+	workers := a.Workers
+	if workers <= 0 {
+		workers = DefaultWorkers
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Each entry gets its own result so the writer can take them in order.
+	// A slot is taken before a fetch starts and given back once its file is
+	// written, bounding both downloads and spooled files.
+	results := make([]chan fetched, len(entries))
+	for i := range results {
+		results[i] = make(chan fetched, 1)
+	}
+	slots := make(chan struct{}, workers)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := range entries {
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				spool, err := a.fetch(ctx, entries[i])
+				results[i] <- fetched{spool: spool, err: err}
+			}(i)
+		}
+	}()
+
+	err := a.write(ctx, w, entries, results, slots)
+
+	// Stop outstanding fetches and remove whatever they spooled
+	cancel()
+	wg.Wait()
+	for _, result := range results {
+		select {
+		case r := <-result:
+			removeSpool(r.spool)
+		default:
+		}
+	}
+	return err
+}
+
+// write adds the fetched entries to the archive in order
+func (a *Archive) write(ctx context.Context, w io.Writer, entries []Entry, results []chan fetched, slots chan struct{}) error {
+	zw := zip.NewWriter(w)
+	var total int64
+
+	for i, entry := range entries {
+		var r fetched
+		select {
+		case r = <-results[i]:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if r.err != nil {
+			return fmt.Errorf("failed to fetch %s: %w", entry.Path, r.err)
+		}
+
+		written, err := a.add(zw, entry, r.spool, total)
+		removeSpool(r.spool)
+		<-slots
+		if err != nil {
+			return err
+		}
+		total += written
+	}
+
+	return zw.Close()
+}
+
+// add copies a spooled file into the archive, returning its size
+func (a *Archive) add(zw *zip.Writer, entry Entry, spool *os.File, total int64) (int64, error) {
+	info, err := spool.Stat()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read %s: %w", entry.Path, err)
+	}
+	// A file may have grown since it was listed
+	if a.MaxBytes > 0 && total+info.Size() > a.MaxBytes {
+		return 0, ErrTooLarge
+	}
+	if _, err := spool.Seek(0, io.SeekStart); err != nil {
+		return 0, fmt.Errorf("failed to read %s: %w", entry.Path, err)
+	}
+
+	header := &zip.FileHeader{
+		Name:     entry.Path,
+		Method:   zip.Deflate,
+		Modified: entry.Modified,
+	}
+	if entry.Store {
+		header.Method = zip.Store
+	}
+	fw, err := zw.CreateHeader(header)
+	if err != nil {
+		return 0, fmt.Errorf("failed to add %s: %w", entry.Path, err)
+	}
+	written, err := io.Copy(fw, spool)
+	if err != nil {
+		return 0, fmt.Errorf("failed to add %s: %w", entry.Path, err)
+	}
+	return written, nil
+}
+
+// fetch downloads an entry to a spool file
+func (a *Archive) fetch(ctx context.Context, entry Entry) (*os.File, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	content, err := a.Open(ctx, entry)
+	if err != nil {
+		return nil, err
+	}
+	defer content.Close()
+	// Openers that ignore the context are stopped by closing their content
+	stop := context.AfterFunc(ctx, func() { content.Close() })
+	defer stop()
+
+	spool, err := os.CreateTemp(a.TempDir, "ziparchive-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create spool file: %w", err)
+	}
+
+	source := io.Reader(content)
+	if a.MaxBytes > 0 {
+		source = io.LimitReader(content, a.MaxBytes+1)
+	}
+	if _, err := io.Copy(spool, source); err != nil {
+		removeSpool(spool)
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		return nil, err
+	}
+	return spool, nil
+}
+
+// removeSpool closes and deletes a spool file
+func removeSpool(spool *os.File) {
+	if spool == nil {
+		return
+	}
+	spool.Close()
+	os.Remove(spool.Name())
+}
@@ -0,0 +1,160 @@
+package ziparchive
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeSource serves entry contents by source, tracking concurrent opens
+type fakeSource struct {
+	contents map[string]string
+	fail     string
+	delay    time.Duration
+
+	mu      sync.Mutex
+	open    int
+	maxOpen int
+}
+
+func (f *fakeSource) Open(ctx context.Context, entry Entry) (io.ReadCloser, error) {
+	if entry.Source == f.fail {
+		return nil, errors.New("download failed")
+	}
+	f.mu.Lock()
+	f.open++
+	if f.open > f.maxOpen {
+		f.maxOpen = f.open
+	}
+	f.mu.Unlock()
+
+	time.Sleep(f.delay)
+
+	f.mu.Lock()
+	f.open--
+	f.mu.Unlock()
+	return io.NopCloser(strings.NewReader(f.contents[entry.Source])), nil
+}
+
+func TestArchiveWrite(t *testing.T) {
+	contents := map[string]string{
+		"rev1": "iron protocol",
+		"rev2": strings.Repeat("dosing table ", 100),
+		"rev3": "old version",
+		"rev4": "image bytes",
+	}
+	entries := []Entry{
+		{Path: "Haemophilia/Iron.pdf", Source: "rev1", Size: 13, Store: true},
+		{Path: "Haemophilia/Dosing.txt", Source: "rev2", Size: 1300},
+		{Path: "Haemophilia/Old/2019.docx", Source: "rev3", Size: 11, Store: true},
+		{Path: "Haemophilia/Old/Scan.png", Source: "rev4", Size: 11, Store: true},
+	}
+
+	tests := []struct {
+		name     string
+		workers  int
+		maxBytes int64
+		fail     string
+		wantErr  error
+		wantAny  bool // Expect an error other than a sentinel
+	}{
+		{name: "all files in order", workers: 2},
+		{name: "default workers"},
+		{name: "single worker", workers: 1},
+		{name: "more workers than files", workers: 10},
+		{name: "within size cap", workers: 2, maxBytes: 1335},
+		{name: "over size cap", workers: 2, maxBytes: 1334, wantErr: ErrTooLarge},
+		{name: "failed download", workers: 2, fail: "rev3", wantAny: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := &fakeSource{contents: contents, fail: tt.fail, delay: 5 * time.Millisecond}
+			tempDir := t.TempDir()
+			archive := &Archive{Open: source.Open, Workers: tt.workers, MaxBytes: tt.maxBytes, TempDir: tempDir}
+
+			var buf bytes.Buffer
+			err := archive.Write(context.Background(), &buf, entries)
+
+			leftover, _ := os.ReadDir(tempDir)
+			if len(leftover) != 0 {
+				t.Errorf("spool files left behind: %d", len(leftover))
+			}
+			workers := tt.workers
+			if workers == 0 {
+				workers = DefaultWorkers
+			}
+			if source.maxOpen > workers {
+				t.Errorf("fetched %d files at once, want at most %d", source.maxOpen, workers)
+			}
+
+			switch {
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Write() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			case tt.wantAny:
+				if err == nil {
+					t.Fatal("Write() error = nil, want an error")
+				}
+				return
+			case err != nil:
+				t.Fatalf("Write() error = %v", err)
+			}
+
+			reader, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+			if err != nil {
+				t.Fatalf("invalid archive: %v", err)
+			}
+			var names []string
+			for i, file := range reader.File {
+				names = append(names, file.Name)
+				wantMethod := zip.Deflate
+				if entries[i].Store {
+					wantMethod = zip.Store
+				}
+				if file.Method != wantMethod {
+					t.Errorf("%s method = %d, want %d", file.Name, file.Method, wantMethod)
+				}
+				rc, err := file.Open()
+				if err != nil {
+					t.Fatalf("open %s: %v", file.Name, err)
+				}
+				data, _ := io.ReadAll(rc)
+				rc.Close()
+				if string(data) != contents[entries[i].Source] {
+					t.Errorf("%s content = %q", file.Name, data)
+				}
+			}
+			want := []string{"Haemophilia/Iron.pdf", "Haemophilia/Dosing.txt", "Haemophilia/Old/2019.docx", "Haemophilia/Old/Scan.png"}
+			if !reflect.DeepEqual(names, want) {
+				t.Errorf("entries = %v, want %v", names, want)
+			}
+		})
+	}
+}
+
+func TestArchiveWriteCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tempDir := t.TempDir()
+	source := &fakeSource{contents: map[string]string{"rev1": "a"}}
+	archive := &Archive{Open: source.Open, TempDir: tempDir}
+	err := archive.Write(ctx, io.Discard, []Entry{{Path: "a.txt", Source: "rev1"}})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Write() error = %v, want %v", err, context.Canceled)
+	}
+	if leftover, _ := os.ReadDir(tempDir); len(leftover) != 0 {
+		t.Errorf("spool files left behind: %d", len(leftover))
+	}
+}